@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -30,65 +34,197 @@ const (
 
 	// Timeouts
 	DEFAULT_TIMEOUT = 5 * time.Second
+
+	// How long a challenge number is reused, a sensible ttl for WithChallengeCache
+	DEFAULT_CHALLENGE_TTL = 30 * time.Second
+
+	// Most players read from one A2S_PLAYER response; the count is a single byte, so a real
+	// server never sends more and anything past this is garbage
+	MAX_PARSED_PLAYERS = 255
 )
 
 // Client represents an A2S query client
 type Client struct {
 	timeout time.Duration
+	retries int // Extra attempts for queries that fail on the network, see WithRetries
+
+	batchConcurrency int // Addresses a batch query queries at once, see WithBatchConcurrency
+
+	// Socket and challenge reuse, see WithChallengeCache
+	reuse        bool
+	challengeTTL time.Duration
+	sockets      map[string]*socket
+	mu           sync.Mutex
+
+	// Local address outgoing queries are sent from, see SetLocalAddress. nil uses an ephemeral port.
+	localAddr *net.UDPAddr
+	// Held while a query uses a fixed local port, which only one socket can bind at a time
+	bindMu sync.Mutex
+}
+
+// socket is the UDP connection used for queries to one address. With reuse enabled
+// it stays open between queries and remembers the last challenge the server issued.
+type socket struct {
+	conn        net.Conn
+	challenge   int32
+	challengeAt time.Time
+	mu          sync.Mutex
 }
 
 // ServerInfo contains information about a Source engine server
 type ServerInfo struct {
-	Protocol    byte
-	Name        string
-	Map         string
-	Folder      string
-	Game        string
-	ID          uint16
-	Players     byte
-	MaxPlayers  byte
-	Bots        byte
-	ServerType  byte
-	Environment byte
-	Visibility  byte
-	VAC         byte
-	Version     string
+	Protocol    byte   `json:"protocol"`
+	Name        string `json:"name"`
+	Map         string `json:"map"`
+	Folder      string `json:"folder"`
+	Game        string `json:"game"`
+	ID          uint16 `json:"id"`
+	Players     byte   `json:"players"`
+	MaxPlayers  byte   `json:"max_players"`
+	Bots        byte   `json:"bots"`
+	ServerType  byte   `json:"server_type"`
+	Environment byte   `json:"environment"`
+	Visibility  byte   `json:"visibility"`
+	VAC         byte   `json:"vac"`
+	Version     string `json:"version"`
 
 	// Extended Data Flag (EDF)
-	Port         *uint16
-	SteamID      *uint64
-	SourceTVPort *uint16
-	SourceTVName *string
-	Keywords     *string
-	GameID       *uint64
+	Port         *uint16 `json:"port,omitempty"`
+	SteamID      *uint64 `json:"steam_id,omitempty"`
+	SourceTVPort *uint16 `json:"sourcetv_port,omitempty"`
+	SourceTVName *string `json:"sourcetv_name,omitempty"`
+	Keywords     *string `json:"keywords,omitempty"`
+	GameID       *uint64 `json:"game_id,omitempty"`
 }
 
 // Player represents a player on the server
 type Player struct {
-	Index    byte
-	Name     string
-	Score    int32
-	Duration float32
+	Index    byte    `json:"index"`
+	Name     string  `json:"name"`
+	Score    int32   `json:"score"`
+	Duration float32 `json:"duration"` // Seconds connected
 }
 
-// Rule represents a server configuration rule
-type Rule struct {
-	Name  string
-	Value string
-}
+// NewClient creates a new A2S client. Without options it queries with DEFAULT_TIMEOUT, no
+// retries, from an ephemeral port, and without caching sockets or challenges; see Options.
+//
+//	client := a2s.NewClient(a2s.WithTimeout(2*time.Second), a2s.WithRetries(2))
+func NewClient(opts ...Option) *Client {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
 
-// NewClient creates a new A2S client with default timeout
-func NewClient() *Client {
-	return &Client{
-		timeout: DEFAULT_TIMEOUT,
+	client := &Client{
+		timeout:          options.Timeout,
+		retries:          options.Retries,
+		batchConcurrency: options.BatchConcurrency,
+		localAddr:        options.LocalAddr,
 	}
+	if options.ChallengeCache {
+		client.reuse = true
+		client.challengeTTL = options.ChallengeTTL
+		client.sockets = make(map[string]*socket)
+	}
+	return client
 }
 
 // NewClientWithTimeout creates a new A2S client with custom timeout
+//
+// Deprecated: use NewClient(WithTimeout(timeout)).
 func NewClientWithTimeout(timeout time.Duration) *Client {
-	return &Client{
-		timeout: timeout,
+	return NewClient(WithTimeout(timeout))
+}
+
+// NewClientWithReuse creates a client that keeps one UDP socket open per address
+// and reuses the last challenge number for challengeTTL, so repeated player and rules
+// queries skip the challenge round trip. Queries to the same address are serialized.
+// Call Close to release the sockets.
+//
+// Deprecated: use NewClient(WithTimeout(timeout), WithChallengeCache(challengeTTL)).
+func NewClientWithReuse(timeout, challengeTTL time.Duration) *Client {
+	return NewClient(WithTimeout(timeout), WithChallengeCache(challengeTTL))
+}
+
+// SetLocalAddress sets the local address queries are sent from, for multi-homed hosts or
+// firewalls that expect a fixed source port. Accepts "ip", "ip:port" or ":port"; an empty
+// address restores the default of any interface and an ephemeral port.
+// The address is bound once to check it belongs to this host and is free.
+// With a fixed port only one socket can exist at a time, so queries are serialized and sockets
+// are not kept open between queries (challenges are still reused).
+// Set it before the client is used.
+func (c *Client) SetLocalAddress(address string) error {
+	if address == "" {
+		c.localAddr = nil
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// A bare IP without a port
+		host, port = address, "0"
+	}
+
+	localAddr := &net.UDPAddr{}
+	if host != "" {
+		if localAddr.IP = net.ParseIP(host); localAddr.IP == nil {
+			return fmt.Errorf("invalid local address %q: host must be an IP address", address)
+		}
+	}
+	if localAddr.Port, err = strconv.Atoi(port); err != nil || localAddr.Port < 0 || localAddr.Port > 65535 {
+		return fmt.Errorf("invalid local address %q: port must be between 0 and 65535", address)
+	}
+
+	conn, err := net.ListenUDP("udp", localAddr)
+	if err != nil {
+		return bindError(localAddr, err)
+	}
+	conn.Close()
+
+	c.localAddr = localAddr
+	return nil
+}
+
+// LocalAddress returns the configured local address, or "" for the default
+func (c *Client) LocalAddress() string {
+	if c.localAddr == nil {
+		return ""
+	}
+	return c.localAddr.String()
+}
+
+// fixedPort reports whether queries bind a fixed local port
+func (c *Client) fixedPort() bool {
+	return c.localAddr != nil && c.localAddr.Port != 0
+}
+
+// bindError explains a failure to bind the local address
+func bindError(localAddr *net.UDPAddr, err error) error {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return fmt.Errorf("local address %s is already in use: %w", localAddr, err)
+	}
+	if errors.Is(err, syscall.EADDRNOTAVAIL) {
+		return fmt.Errorf("local address %s is not an address of this host: %w", localAddr, err)
+	}
+	return fmt.Errorf("failed to bind local address %s: %w", localAddr, err)
+}
+
+// Close closes the sockets kept open by a client created with WithChallengeCache
+func (c *Client) Close() error {
+	c.mu.Lock()
+	sockets := c.sockets
+	c.sockets = make(map[string]*socket)
+	c.mu.Unlock()
+
+	for _, s := range sockets {
+		s.mu.Lock()
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		s.mu.Unlock()
 	}
+	return nil
 }
 
 // QueryInfo retrieves server information
@@ -98,11 +234,19 @@ func (c *Client) QueryInfo(address string) (*ServerInfo, error) {
 
 // QueryInfoContext retrieves server information with context support
 func (c *Client) QueryInfoContext(ctx context.Context, address string) (*ServerInfo, error) {
-	conn, err := net.DialTimeout("udp", address, c.timeout)
+	return withRetries(ctx, c, func() (*ServerInfo, error) {
+		return c.queryInfo(ctx, address)
+	})
+}
+
+// queryInfo sends one A2S_INFO query
+func (c *Client) queryInfo(ctx context.Context, address string) (info *ServerInfo, err error) {
+	s, err := c.acquire(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
+	defer func() { c.release(s, err != nil) }()
+	conn := s.conn
 
 	deadline := time.Now().Add(c.timeout)
 	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
@@ -146,192 +290,244 @@ func (c *Client) QueryPlayers(address string) ([]Player, error) {
 
 // QueryPlayersContext retrieves the list of players on the server with context support
 func (c *Client) QueryPlayersContext(ctx context.Context, address string) ([]Player, error) {
-	conn, err := net.DialTimeout("udp", address, c.timeout)
+	response, err := c.challengeQuery(ctx, address, A2S_PLAYER, S2A_PLAYER, 1400)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
+	return parsePlayers(response)
+}
 
-	deadline := time.Now().Add(c.timeout)
-	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
-		deadline = ctxDeadline
-	}
+// QueryRules retrieves server rules/cvars as a map of rule name to value
+func (c *Client) QueryRules(address string) (map[string]string, error) {
+	return c.QueryRulesContext(context.Background(), address)
+}
 
-	if err := conn.SetDeadline(deadline); err != nil {
-		return nil, fmt.Errorf("failed to set deadline: %w", err)
+// QueryRulesContext retrieves server rules/cvars as a map of rule name to value with context support
+func (c *Client) QueryRulesContext(ctx context.Context, address string) (map[string]string, error) {
+	// Rules responses are larger than info/player responses
+	response, err := c.challengeQuery(ctx, address, A2S_RULES, S2A_RULES, 4096)
+	if err != nil {
+		return nil, err
 	}
+	return parseRules(response)
+}
 
-	// Try direct query with challenge -1 first
-	// Some games (like Insurgency: Sandstorm) skip the challenge-response and return player data directly
-	request := &bytes.Buffer{}
-	binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
-	request.WriteByte(A2S_PLAYER)
-	binary.Write(request, binary.LittleEndian, int32(-1))
-
-	if _, err := conn.Write(request.Bytes()); err != nil {
-		return nil, fmt.Errorf("failed to send initial request: %w", err)
-	}
+// challengeQuery sends a player or rules query and returns the raw response.
+// The query is first sent with the cached challenge, or -1 without one. Some games
+// (like Insurgency: Sandstorm) skip the challenge-response and answer -1 directly;
+// otherwise the server answers with a challenge (also when a cached one was rejected)
+// and the query is repeated once with it.
+func (c *Client) challengeQuery(ctx context.Context, address string, queryType, responseType byte, bufferSize int) ([]byte, error) {
+	return withRetries(ctx, c, func() ([]byte, error) {
+		return c.sendChallengeQuery(ctx, address, queryType, responseType, bufferSize)
+	})
+}
 
-	// Read response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
+// sendChallengeQuery sends one player or rules query, with the challenge exchange if needed
+func (c *Client) sendChallengeQuery(ctx context.Context, address string, queryType, responseType byte, bufferSize int) (data []byte, err error) {
+	s, err := c.acquire(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read initial response: %w", err)
+		return nil, err
 	}
+	defer func() { c.release(s, err != nil) }()
 
-	// Check response type
-	if n < 6 {
-		return nil, fmt.Errorf("response too short: %d bytes", n)
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
 	}
 
-	// Skip header (4 bytes) and read response type
-	responseType := response[4]
-
-	// If we got player data directly (Insurgency: Sandstorm behavior), parse it
-	if responseType == S2A_PLAYER {
-		return parsePlayers(response[:n])
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %w", err)
 	}
 
-	// If we got a challenge, use it for a second request (standard behavior)
-	if responseType == S2A_CHALLENGE {
-		// Parse the challenge number
-		reader := bytes.NewReader(response[:n])
-		var header uint32
-		binary.Read(reader, binary.LittleEndian, &header)
-		reader.ReadByte() // skip response type
-		var challenge int32
-		if err := binary.Read(reader, binary.LittleEndian, &challenge); err != nil {
-			return nil, fmt.Errorf("failed to read challenge: %w", err)
-		}
+	// Check context before sending
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		// Build second request with the challenge
-		request2 := &bytes.Buffer{}
-		binary.Write(request2, binary.LittleEndian, uint32(PACKET_HEADER))
-		request2.WriteByte(A2S_PLAYER)
-		binary.Write(request2, binary.LittleEndian, challenge)
+	challenge := c.cachedChallenge(s)
+	for attempt := 0; attempt < 2; attempt++ {
+		request := &bytes.Buffer{}
+		binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
+		request.WriteByte(queryType)
+		binary.Write(request, binary.LittleEndian, challenge)
 
-		if _, err := conn.Write(request2.Bytes()); err != nil {
-			return nil, fmt.Errorf("failed to send challenge request: %w", err)
+		if _, err := s.conn.Write(request.Bytes()); err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
 		}
 
-		// Read player data response
-		response2 := make([]byte, 1400)
-		n2, err := conn.Read(response2)
+		response := make([]byte, bufferSize)
+		n, err := s.conn.Read(response)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read player response: %w", err)
+			return nil, fmt.Errorf("failed to read response: %w", err)
 		}
 
-		return parsePlayers(response2[:n2])
-	}
+		if n < 5 {
+			return nil, fmt.Errorf("response too short: %d bytes", n)
+		}
 
-	return nil, fmt.Errorf("unexpected response type: 0x%02x", responseType)
-}
+		// Skip header (4 bytes) and read response type
+		switch response[4] {
+		case responseType:
+			return response[:n], nil
+		case S2A_CHALLENGE:
+			if n < 9 {
+				return nil, fmt.Errorf("failed to read challenge: response too short: %d bytes", n)
+			}
+			challenge = int32(binary.LittleEndian.Uint32(response[5:9]))
+			c.storeChallenge(s, challenge)
+		default:
+			return nil, fmt.Errorf("unexpected response type: 0x%02x", response[4])
+		}
+	}
 
-// QueryRules retrieves server rules/cvars
-func (c *Client) QueryRules(address string) ([]Rule, error) {
-	return c.QueryRulesContext(context.Background(), address)
+	return nil, fmt.Errorf("server rejected challenge %d", challenge)
 }
 
-// QueryRulesContext retrieves server rules/cvars with context support
-func (c *Client) QueryRulesContext(ctx context.Context, address string) ([]Rule, error) {
-	conn, err := net.DialTimeout("udp", address, c.timeout)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+// acquire returns the socket to query address with. Without reuse it is a fresh connection;
+// with reuse it is the address's shared socket, locked until release.
+func (c *Client) acquire(ctx context.Context, address string) (*socket, error) {
+	if c.fixedPort() {
+		c.bindMu.Lock()
 	}
-	defer conn.Close()
 
-	deadline := time.Now().Add(c.timeout)
-	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
-		deadline = ctxDeadline
+	if !c.reuse {
+		conn, err := c.dialContext(ctx, address)
+		if err != nil {
+			c.unlockBind()
+			return nil, err
+		}
+		return &socket{conn: conn}, nil
 	}
 
-	if err := conn.SetDeadline(deadline); err != nil {
-		return nil, fmt.Errorf("failed to set deadline: %w", err)
+	c.mu.Lock()
+	s, exists := c.sockets[address]
+	if !exists {
+		s = &socket{}
+		c.sockets[address] = s
 	}
+	c.mu.Unlock()
 
-	// First, get challenge number
-	challenge, err := c.getChallengeContext(ctx, conn, A2S_RULES)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	s.mu.Lock()
+	if s.conn == nil {
+		conn, err := c.dialContext(ctx, address)
+		if err != nil {
+			s.mu.Unlock()
+			c.unlockBind()
+			return nil, err
+		}
+		s.conn = conn
 	}
+	return s, nil
+}
 
-	// Build A2S_RULES request with challenge
-	request := &bytes.Buffer{}
-	binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
-	request.WriteByte(A2S_RULES)
-	binary.Write(request, binary.LittleEndian, challenge)
+// release closes a socket that is not reused. A reused socket is closed after a failed
+// query, so a late response to it cannot be read as the answer to the next query.
+func (c *Client) release(s *socket, failed bool) {
+	defer c.unlockBind()
 
-	// Send request
-	if _, err := conn.Write(request.Bytes()); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	if !c.reuse {
+		s.conn.Close()
+		return
 	}
 
-	// Read response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	if failed {
+		s.conn.Close()
+		s.conn = nil
+		s.challengeAt = time.Time{}
+	} else if c.fixedPort() {
+		// Free the port for the next query; the challenge is kept
+		s.conn.Close()
+		s.conn = nil
 	}
+	s.mu.Unlock()
+}
 
-	return parseRules(response[:n])
+// unlockBind lets the next query bind the fixed local port
+func (c *Client) unlockBind() {
+	if c.fixedPort() {
+		c.bindMu.Unlock()
+	}
 }
 
-// getChallenge requests a challenge number from the server
-func (c *Client) getChallenge(conn net.Conn, queryType byte) (int32, error) {
-	return c.getChallengeContext(context.Background(), conn, queryType)
+// cachedChallenge returns the socket's challenge while it is within the TTL, otherwise -1
+func (c *Client) cachedChallenge(s *socket) int32 {
+	if !c.reuse || s.challengeAt.IsZero() || time.Since(s.challengeAt) > c.challengeTTL {
+		return -1
+	}
+	return s.challenge
 }
 
-// getChallengeContext requests a challenge number from the server with context support
-func (c *Client) getChallengeContext(ctx context.Context, conn net.Conn, queryType byte) (int32, error) {
-	// Check context
-	if err := ctx.Err(); err != nil {
-		return 0, err
+func (c *Client) storeChallenge(s *socket, challenge int32) {
+	if c.reuse {
+		s.challenge = challenge
+		s.challengeAt = time.Now()
 	}
+}
 
-	// Build challenge request
-	request := &bytes.Buffer{}
-	binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
-	request.WriteByte(queryType)
-	binary.Write(request, binary.LittleEndian, int32(-1)) // -1 to request challenge
+// splitAddress validates a query address and splits it into host and port.
+// Accepts IPv4 ("1.2.3.4:27102"), bracketed IPv6 ("[2001:db8::1]:27102") and hostnames ("example.com:27102").
+func splitAddress(address string) (host string, port string, err error) {
+	host, port, err = net.SplitHostPort(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid address %q (expected host:port or [ipv6]:port): %w", address, err)
+	}
 
-	// Send request
-	if _, err := conn.Write(request.Bytes()); err != nil {
-		return 0, fmt.Errorf("failed to send challenge request: %w", err)
+	if host == "" {
+		return "", "", fmt.Errorf("invalid address %q: missing host", address)
 	}
 
-	// Read response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read challenge response: %w", err)
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "", "", fmt.Errorf("invalid address %q: port must be between 1 and 65535", address)
 	}
 
-	// Parse challenge
-	reader := bytes.NewReader(response[:n])
+	return host, port, nil
+}
 
-	// Skip header
-	var header uint32
-	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
-		return 0, fmt.Errorf("failed to read header: %w", err)
+// dialContext validates and resolves the address within the context deadline and opens a UDP connection.
+// When a hostname resolves to multiple addresses, the first one that can be dialed is used.
+func (c *Client) dialContext(ctx context.Context, address string) (net.Conn, error) {
+	host, port, err := splitAddress(address)
+	if err != nil {
+		return nil, err
 	}
 
-	// Read response type
-	responseType, err := reader.ReadByte()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// IP literals are returned as-is without a DNS lookup
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
 	if err != nil {
-		return 0, fmt.Errorf("failed to read response type: %w", err)
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("failed to resolve %s: no addresses found", host)
 	}
 
-	if responseType != S2A_CHALLENGE {
-		return 0, fmt.Errorf("unexpected response type: %02x", responseType)
+	var dialer net.Dialer
+	if c.localAddr != nil {
+		dialer.LocalAddr = c.localAddr
 	}
 
-	// Read challenge number
-	var challenge int32
-	if err := binary.Read(reader, binary.LittleEndian, &challenge); err != nil {
-		return 0, fmt.Errorf("failed to read challenge: %w", err)
+	var lastErr error
+	for _, ip := range ips {
+		// A socket bound to an IPv4 address can't reach IPv6 servers and vice versa
+		if c.localAddr != nil && c.localAddr.IP != nil && (c.localAddr.IP.To4() == nil) != (ip.IP.To4() == nil) {
+			lastErr = fmt.Errorf("%s is not reachable from local address %s", ip, c.localAddr)
+			continue
+		}
+
+		conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if c.localAddr != nil && (errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, syscall.EADDRNOTAVAIL)) {
+			return nil, bindError(c.localAddr, err)
+		}
+		lastErr = err
 	}
 
-	return challenge, nil
+	return nil, fmt.Errorf("failed to connect: %w", lastErr)
 }
 
 // parseServerInfo parses the server info response
@@ -484,8 +680,9 @@ func parsePlayers(data []byte) ([]Player, error) {
 	players := make([]Player, 0, max(int(playerCount), 32)) // Allocate for at least 32 if count is 0
 
 	// Iterate until buffer is exhausted (like SAW does)
-	// This handles the case where Insurgency reports 0 players but sends data anyway
-	for reader.Len() > 0 {
+	// This handles the case where Insurgency reports 0 players but sends data anyway.
+	// Players that don't parse cleanly (truncated data) are dropped and the rest returned.
+	for reader.Len() > 0 && len(players) < MAX_PARSED_PLAYERS {
 		player := Player{}
 
 		// Read index
@@ -526,7 +723,7 @@ func max(a, b int) int {
 }
 
 // parseRules parses the server rules response
-func parseRules(data []byte) ([]Rule, error) {
+func parseRules(data []byte) (map[string]string, error) {
 	reader := bytes.NewReader(data)
 
 	// Skip header
@@ -546,27 +743,31 @@ func parseRules(data []byte) ([]Rule, error) {
 	}
 
 	// Read rule count
+	// NOTE: Like the player count, the rule count reported by Insurgency: Sandstorm is unreliable,
+	// so we read it but iterate until the buffer is empty instead.
 	var ruleCount uint16
 	if err := binary.Read(reader, binary.LittleEndian, &ruleCount); err != nil {
 		return nil, fmt.Errorf("failed to read rule count: %w", err)
 	}
 
-	rules := make([]Rule, 0, ruleCount)
-
-	for i := uint16(0); i < ruleCount; i++ {
-		rule := Rule{}
+	rules := make(map[string]string, ruleCount)
 
+	for reader.Len() > 0 {
 		// Read name
-		if rule.Name, err = readString(reader); err != nil {
-			return rules, fmt.Errorf("failed to read rule name: %w", err)
+		name, err := readString(reader)
+		if err != nil {
+			// End of buffer or malformed data
+			break
 		}
 
 		// Read value
-		if rule.Value, err = readString(reader); err != nil {
-			return rules, fmt.Errorf("failed to read rule value: %w", err)
+		value, err := readString(reader)
+		if err != nil {
+			// Truncated pair - keep what we have
+			break
 		}
 
-		rules = append(rules, rule)
+		rules[name] = value
 	}
 
 	return rules, nil
@@ -2,6 +2,12 @@ package a2s
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -29,6 +35,118 @@ func TestNewClientWithTimeout(t *testing.T) {
 	}
 }
 
+// TestNewClientOptions tests that options passed to NewClient take effect together: a query whose
+// first packet is lost is retried, is sent from the local address, and reuses the cached challenge
+func TestNewClientOptions(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	const challenge int32 = 0x0BADF00D
+	var mu sync.Mutex
+	received, challenges := 0, 0
+	sources := map[string]bool{}
+
+	go func() {
+		buffer := make([]byte, 1400)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n < 9 || buffer[4] != A2S_RULES {
+				continue
+			}
+
+			mu.Lock()
+			received++
+			sources[addr.String()] = true
+			drop := received == 1 // The first request is lost
+			respondChallenge := int32(binary.LittleEndian.Uint32(buffer[5:9])) != challenge
+			if respondChallenge && !drop {
+				challenges++
+			}
+			mu.Unlock()
+
+			if drop {
+				continue
+			}
+			if respondChallenge {
+				response := &bytes.Buffer{}
+				binary.Write(response, binary.LittleEndian, uint32(PACKET_HEADER))
+				response.WriteByte(S2A_CHALLENGE)
+				binary.Write(response, binary.LittleEndian, challenge)
+				conn.WriteTo(response.Bytes(), addr)
+				continue
+			}
+			conn.WriteTo(rulesFixture(1, "GameMode_s", "Checkpoint"), addr)
+		}
+	}()
+
+	// Find a free port to send from
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	localAddr := probe.LocalAddr().(*net.UDPAddr)
+	probe.Close()
+
+	client := NewClient(
+		WithTimeout(200*time.Millisecond),
+		WithRetries(2),
+		WithLocalAddr(localAddr),
+		WithChallengeCache(time.Minute),
+	)
+	defer client.Close()
+
+	if client.timeout != 200*time.Millisecond {
+		t.Errorf("expected timeout 200ms, got %v", client.timeout)
+	}
+	if client.LocalAddress() != localAddr.String() {
+		t.Errorf("expected local address %s, got %q", localAddr, client.LocalAddress())
+	}
+
+	for i := 0; i < 3; i++ {
+		rules, err := client.QueryRules(conn.LocalAddr().String())
+		if err != nil {
+			t.Fatalf("query %d failed: %v", i, err)
+		}
+		if rules["GameMode_s"] != "Checkpoint" {
+			t.Errorf("query %d: unexpected rules %v", i, rules)
+		}
+	}
+
+	mu.Lock()
+	if challenges != 1 {
+		t.Errorf("expected one challenge exchange, got %d", challenges)
+	}
+	if len(sources) != 1 || !sources[localAddr.String()] {
+		t.Errorf("expected every query to come from %s, got %v", localAddr, sources)
+	}
+	mu.Unlock()
+
+	t.Run("defaults", func(t *testing.T) {
+		client := NewClient()
+		if client.timeout != DEFAULT_TIMEOUT || client.retries != 0 || client.reuse || client.localAddr != nil {
+			t.Errorf("unexpected defaults: timeout %v, retries %d, reuse %v, local address %v",
+				client.timeout, client.retries, client.reuse, client.localAddr)
+		}
+	})
+
+	t.Run("bad responses are not retried", func(t *testing.T) {
+		var attempts int
+		_, err := withRetries(context.Background(), NewClient(WithRetries(3)), func() (int, error) {
+			attempts++
+			return 0, fmt.Errorf("unexpected response type: 0x%02x", 0x7A)
+		})
+		if err == nil || attempts != 1 {
+			t.Errorf("expected a single failed attempt, got %d (err %v)", attempts, err)
+		}
+	})
+}
+
 // Example test - requires a running Insurgency: Sandstorm server
 // To run: go test -v -run TestQueryInfo_Live
 // Skip by default as it requires a live server
@@ -106,8 +224,8 @@ func TestQueryRules_Live(t *testing.T) {
 	}
 
 	t.Logf("Found %d rules", len(rules))
-	for _, rule := range rules {
-		t.Logf("  %s = %s", rule.Name, rule.Value)
+	for name, value := range rules {
+		t.Logf("  %s = %s", name, value)
 	}
 }
 
@@ -152,3 +270,449 @@ func TestReadString(t *testing.T) {
 		})
 	}
 }
+
+// rulesFixture builds an S2A_RULES response declaring count rules but containing the given pairs
+func rulesFixture(count uint16, pairs ...string) []byte {
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, uint32(PACKET_HEADER))
+	buffer.WriteByte(S2A_RULES)
+	binary.Write(buffer, binary.LittleEndian, count)
+	for _, s := range pairs {
+		buffer.WriteString(s)
+		buffer.WriteByte(0)
+	}
+	return buffer.Bytes()
+}
+
+// TestParseRules tests that rules are read until the buffer drains regardless of the declared count
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:  "Accurate count",
+			input: rulesFixture(2, "GameMode_s", "Checkpoint", "Pwd_b", "false"),
+			expected: map[string]string{
+				"GameMode_s": "Checkpoint",
+				"Pwd_b":      "false",
+			},
+		},
+		{
+			name:  "Count lower than rules sent",
+			input: rulesFixture(1, "GameMode_s", "Push", "MaxPlayers_i", "28", "Coop_b", "false"),
+			expected: map[string]string{
+				"GameMode_s":   "Push",
+				"MaxPlayers_i": "28",
+				"Coop_b":       "false",
+			},
+		},
+		{
+			name:     "Count higher than rules sent",
+			input:    rulesFixture(40, "GameMode_s", "Checkpoint"),
+			expected: map[string]string{"GameMode_s": "Checkpoint"},
+		},
+		{
+			name:     "Truncated pair is dropped",
+			input:    append(rulesFixture(2, "GameMode_s", "Checkpoint"), []byte("Mutators_s")...),
+			expected: map[string]string{"GameMode_s": "Checkpoint"},
+		},
+		{
+			name:    "Wrong response type",
+			input:   []byte{0xFF, 0xFF, 0xFF, 0xFF, S2A_INFO_SRC, 0x00, 0x00},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseRules(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(result) != len(tt.expected) {
+				t.Errorf("parseRules() returned %d rules, want %d: %v", len(result), len(tt.expected), result)
+			}
+			for name, value := range tt.expected {
+				if result[name] != value {
+					t.Errorf("parseRules()[%q] = %q, want %q", name, result[name], value)
+				}
+			}
+		})
+	}
+}
+
+// playersFixture builds an S2A_PLAYER response declaring count players but containing the given players
+func playersFixture(count byte, players ...Player) []byte {
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, uint32(PACKET_HEADER))
+	buffer.WriteByte(S2A_PLAYER)
+	buffer.WriteByte(count)
+	for _, p := range players {
+		buffer.WriteByte(p.Index)
+		buffer.WriteString(p.Name)
+		buffer.WriteByte(0)
+		binary.Write(buffer, binary.LittleEndian, p.Score)
+		binary.Write(buffer, binary.LittleEndian, p.Duration)
+	}
+	return buffer.Bytes()
+}
+
+// TestParsePlayers tests that players are read until the buffer drains regardless of the declared count
+func TestParsePlayers(t *testing.T) {
+	armoredBear := Player{Index: 0, Name: "ArmoredBear", Score: 1250, Duration: 1834.5}
+	rabbit := Player{Index: 1, Name: "Rabbit", Score: 300, Duration: 95}
+	marksman := Player{Index: 2, Name: "Marksman", Score: 0, Duration: 12.25}
+
+	many := make([]Player, MAX_PARSED_PLAYERS+10)
+	for i := range many {
+		many[i] = Player{Name: fmt.Sprintf("Player%d", i)}
+	}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []Player
+		wantLen  int // Checked instead of expected when set
+		wantErr  bool
+	}{
+		{
+			name:     "Accurate count",
+			input:    playersFixture(3, armoredBear, rabbit, marksman),
+			expected: []Player{armoredBear, rabbit, marksman},
+		},
+		{
+			name:     "Count of 0 with players following",
+			input:    playersFixture(0, armoredBear, rabbit, marksman),
+			expected: []Player{armoredBear, rabbit, marksman},
+		},
+		{
+			name:     "Truncated player is dropped",
+			input:    append(playersFixture(0, armoredBear, rabbit), append([]byte{2}, []byte("Marks")...)...),
+			expected: []Player{armoredBear, rabbit},
+		},
+		{
+			name:     "Empty server",
+			input:    playersFixture(0),
+			expected: []Player{},
+		},
+		{
+			name:    "Stops at the upper bound",
+			input:   playersFixture(0, many...),
+			wantLen: MAX_PARSED_PLAYERS,
+		},
+		{
+			name:    "Wrong response type",
+			input:   []byte{0xFF, 0xFF, 0xFF, 0xFF, S2A_RULES, 0x00},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parsePlayers(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePlayers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantLen > 0 {
+				if len(result) != tt.wantLen {
+					t.Errorf("parsePlayers() returned %d players, want %d", len(result), tt.wantLen)
+				}
+				return
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parsePlayers() returned %d players, want %d: %+v", len(result), len(tt.expected), result)
+			}
+			for i, want := range tt.expected {
+				if result[i] != want {
+					t.Errorf("parsePlayers()[%d] = %+v, want %+v", i, result[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestSplitAddress tests address validation for IPv4, IPv6 and hostnames
+func TestSplitAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		wantHost string
+		wantPort string
+		wantErr  bool
+	}{
+		{name: "IPv4", address: "192.168.1.10:27102", wantHost: "192.168.1.10", wantPort: "27102"},
+		{name: "Bracketed IPv6", address: "[2001:db8::1]:27102", wantHost: "2001:db8::1", wantPort: "27102"},
+		{name: "IPv6 loopback", address: "[::1]:27131", wantHost: "::1", wantPort: "27131"},
+		{name: "Hostname", address: "sandstorm.example.com:27102", wantHost: "sandstorm.example.com", wantPort: "27102"},
+		{name: "Unbracketed IPv6", address: "2001:db8::1:27102", wantErr: true},
+		{name: "Missing port", address: "192.168.1.10", wantErr: true},
+		{name: "Missing host", address: ":27102", wantErr: true},
+		{name: "Non-numeric port", address: "localhost:query", wantErr: true},
+		{name: "Port out of range", address: "localhost:70000", wantErr: true},
+		{name: "Empty", address: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := splitAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("splitAddress(%q) = %q, %q, want %q, %q", tt.address, host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+// TestQueryInfo_InvalidAddress tests that malformed addresses fail before any network traffic
+func TestQueryInfo_InvalidAddress(t *testing.T) {
+	client := NewClientWithTimeout(time.Second)
+
+	_, err := client.QueryInfo("2001:db8::1:27102")
+	if err == nil {
+		t.Fatal("Expected an error for an unbracketed IPv6 address")
+	}
+	if !strings.Contains(err.Error(), "invalid address") {
+		t.Errorf("Expected an invalid address error, got: %v", err)
+	}
+}
+
+// TestQueryInfo_IPv6 tests querying a server over a bracketed IPv6 loopback address
+func TestQueryInfo_IPv6(t *testing.T) {
+	conn, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buffer := make([]byte, 1400)
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil || n < 5 || buffer[4] != A2S_INFO {
+			return
+		}
+
+		response := &bytes.Buffer{}
+		binary.Write(response, binary.LittleEndian, uint32(PACKET_HEADER))
+		response.WriteByte(S2A_INFO_SRC)
+		response.WriteByte(17)
+		response.WriteString("IPv6 Server\x00Town\x00Insurgency\x00Insurgency: Sandstorm\x00")
+		binary.Write(response, binary.LittleEndian, uint16(0))
+		response.Write([]byte{0, 28, 0, 'd', 'l', 0, 0})
+		response.WriteString("1.0\x00")
+		conn.WriteTo(response.Bytes(), addr)
+	}()
+
+	client := NewClientWithTimeout(time.Second)
+	info, err := client.QueryInfo(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("QueryInfo over IPv6 failed: %v", err)
+	}
+	if info.Name != "IPv6 Server" {
+		t.Errorf("Expected server name 'IPv6 Server', got %q", info.Name)
+	}
+}
+
+// TestClientWithReuse tests that a reusing client keeps its socket and skips the
+// challenge exchange while the cached challenge is valid
+func TestClientWithReuse(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	const challenge int32 = 0x1234ABCD
+	var mu sync.Mutex
+	challenges := 0
+	sources := map[string]bool{}
+
+	go func() {
+		buffer := make([]byte, 1400)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n < 9 || buffer[4] != A2S_RULES {
+				continue
+			}
+
+			response := &bytes.Buffer{}
+			binary.Write(response, binary.LittleEndian, uint32(PACKET_HEADER))
+
+			mu.Lock()
+			sources[addr.String()] = true
+			if int32(binary.LittleEndian.Uint32(buffer[5:9])) != challenge {
+				challenges++
+				response.WriteByte(S2A_CHALLENGE)
+				binary.Write(response, binary.LittleEndian, challenge)
+			} else {
+				response.WriteByte(S2A_RULES)
+				binary.Write(response, binary.LittleEndian, uint16(1))
+				response.WriteString("GameMode_s\x00Checkpoint\x00")
+			}
+			mu.Unlock()
+
+			conn.WriteTo(response.Bytes(), addr)
+		}
+	}()
+
+	client := NewClientWithReuse(time.Second, time.Minute)
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		rules, err := client.QueryRules(conn.LocalAddr().String())
+		if err != nil {
+			t.Fatalf("query %d failed: %v", i, err)
+		}
+		if rules["GameMode_s"] != "Checkpoint" {
+			t.Errorf("query %d: unexpected rules %v", i, rules)
+		}
+	}
+
+	exchanged := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return challenges
+	}
+
+	if got := exchanged(); got != 1 {
+		t.Errorf("expected one challenge exchange, got %d", got)
+	}
+	mu.Lock()
+	if len(sources) != 1 {
+		t.Errorf("expected every query to use the same socket, got %v", sources)
+	}
+	mu.Unlock()
+
+	t.Run("expired challenge is requested again", func(t *testing.T) {
+		expiring := NewClientWithReuse(time.Second, 0)
+		defer expiring.Close()
+
+		for i := 0; i < 2; i++ {
+			if _, err := expiring.QueryRules(conn.LocalAddr().String()); err != nil {
+				t.Fatalf("query %d failed: %v", i, err)
+			}
+		}
+
+		if got := exchanged(); got != 3 {
+			t.Errorf("expected a challenge exchange per query, got %d total", got)
+		}
+	})
+}
+
+// TestClientLocalAddress tests that queries are sent from the configured local address
+func TestClientLocalAddress(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	var mu sync.Mutex
+	sources := map[string]bool{}
+
+	go func() {
+		buffer := make([]byte, 1400)
+		for {
+			_, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			sources[addr.String()] = true
+			mu.Unlock()
+
+			conn.WriteTo(rulesFixture(1, "GameMode_s", "Checkpoint"), addr)
+		}
+	}()
+
+	// Find a free port to send from
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	localAddress := probe.LocalAddr().String()
+	probe.Close()
+
+	for _, tc := range []struct {
+		name   string
+		client *Client
+	}{
+		{"fresh socket per query", NewClientWithTimeout(time.Second)},
+		{"reused socket", NewClientWithReuse(time.Second, time.Minute)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer tc.client.Close()
+			if err := tc.client.SetLocalAddress(localAddress); err != nil {
+				t.Fatalf("SetLocalAddress(%q) failed: %v", localAddress, err)
+			}
+
+			// Concurrent queries share the fixed port one at a time
+			var wg sync.WaitGroup
+			errs := make(chan error, 4)
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := tc.client.QueryRules(conn.LocalAddr().String()); err != nil {
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Errorf("query failed: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(sources) != 1 || !sources[localAddress] {
+				t.Errorf("expected every query to come from %s, got %v", localAddress, sources)
+			}
+		})
+	}
+
+	t.Run("address in use is reported", func(t *testing.T) {
+		busy, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer busy.Close()
+
+		err = NewClient().SetLocalAddress(busy.LocalAddr().String())
+		if err == nil || !strings.Contains(err.Error(), "already in use") {
+			t.Errorf("expected an address in use error, got %v", err)
+		}
+	})
+
+	t.Run("invalid addresses are rejected", func(t *testing.T) {
+		for _, address := range []string{"example.com:27200", "127.0.0.1:99999", "127.0.0.1:port"} {
+			if err := NewClient().SetLocalAddress(address); err == nil {
+				t.Errorf("SetLocalAddress(%q) should fail", address)
+			}
+		}
+	})
+
+	t.Run("empty address keeps the ephemeral default", func(t *testing.T) {
+		client := NewClient()
+		if err := client.SetLocalAddress(""); err != nil {
+			t.Fatalf("SetLocalAddress(\"\") failed: %v", err)
+		}
+		if client.LocalAddress() != "" {
+			t.Errorf("expected no local address, got %q", client.LocalAddress())
+		}
+	})
+}
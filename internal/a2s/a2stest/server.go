@@ -0,0 +1,334 @@
+// Package a2stest provides a fake A2S server for testing code that queries game servers.
+package a2stest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+)
+
+const (
+	// SPLIT_HEADER starts every packet of a response split over several packets
+	SPLIT_HEADER = 0xFFFFFFFE
+
+	// DEFAULT_MAX_PACKET_SIZE is the largest packet a Source server sends; longer responses are split
+	DEFAULT_MAX_PACKET_SIZE = 1400
+
+	// splitHeaderSize is the split header, packet ID, total, number and size of a split packet
+	splitHeaderSize = 12
+)
+
+// Config is how a fake Server answers. The zero value answers every query straight away with an
+// empty info, no players and no rules, without a challenge, in single packets.
+type Config struct {
+	Info    a2s.ServerInfo
+	Players []a2s.Player
+	Rules   map[string]string
+
+	// Challenge, when not zero, is the challenge player and rules queries must carry; other
+	// queries are answered with it. Zero answers them directly, like Insurgency: Sandstorm.
+	Challenge int32
+	// ChallengeInfo also requires the challenge for info queries, like Source servers since 2020
+	ChallengeInfo bool
+
+	// LyingPlayerCount reports 0 players in A2S_PLAYER responses while still sending them all,
+	// like Insurgency: Sandstorm
+	LyingPlayerCount bool
+	// LyingRuleCount reports a single rule in A2S_RULES responses while still sending them all,
+	// like Insurgency: Sandstorm
+	LyingRuleCount bool
+
+	// MaxPacketSize splits responses longer than it over several packets. Default DEFAULT_MAX_PACKET_SIZE.
+	MaxPacketSize int
+	// Compress, when set, compresses split responses with it (bzip2 on a real server) and marks
+	// them compressed. Every response is then sent split, as real servers do.
+	Compress func([]byte) ([]byte, error)
+
+	// Delay holds each response back, to test timeouts
+	Delay time.Duration
+	// Silent drops every query without answering
+	Silent bool
+}
+
+// Server is a fake A2S server on a local UDP port
+type Server struct {
+	conn net.PacketConn
+
+	mu       sync.Mutex
+	config   Config
+	requests map[byte]int
+	packetID int32
+}
+
+// NewServer starts a fake A2S server answering as config says. It is closed when the test ends.
+func NewServer(t testing.TB, config Config) *Server {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &Server{
+		conn:     conn,
+		config:   config,
+		requests: make(map[byte]int),
+	}
+	t.Cleanup(s.Close)
+
+	go s.serve()
+	return s
+}
+
+// Addr returns the address to query the server at
+func (s *Server) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.conn.Close()
+}
+
+// Update changes how the server answers, for instance to make it go silent mid test
+func (s *Server) Update(update func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.config)
+}
+
+// Requests returns how many queries of a type (a2s.A2S_INFO, a2s.A2S_PLAYER, ...) the server
+// has received, including ones answered with a challenge or dropped
+func (s *Server) Requests(queryType byte) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[queryType]
+}
+
+func (s *Server) serve() {
+	buffer := make([]byte, 1400)
+	for {
+		n, addr, err := s.conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		if n < 5 || binary.LittleEndian.Uint32(buffer[:4]) != a2s.PACKET_HEADER {
+			continue
+		}
+
+		s.mu.Lock()
+		s.requests[buffer[4]]++
+		config := s.config
+		s.mu.Unlock()
+
+		if config.Silent {
+			continue
+		}
+		response := respond(config, buffer[:n])
+		if response == nil {
+			continue
+		}
+		packets, err := s.packets(config, response)
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			time.Sleep(config.Delay)
+			for _, packet := range packets {
+				s.conn.WriteTo(packet, addr)
+			}
+		}()
+	}
+}
+
+// respond builds the response to one query, or nil for queries the server ignores
+func respond(config Config, request []byte) []byte {
+	queryType := request[4]
+	challenge := int32(-1)
+
+	switch queryType {
+	case a2s.A2S_INFO:
+		const payload = "Source Engine Query\x00"
+		if len(request) >= 5+len(payload)+4 {
+			challenge = int32(binary.LittleEndian.Uint32(request[5+len(payload):]))
+		}
+		if config.ChallengeInfo && config.Challenge != 0 && challenge != config.Challenge {
+			return challengeResponse(config.Challenge)
+		}
+		return infoResponse(config.Info)
+	case a2s.A2S_PLAYER, a2s.A2S_RULES:
+		if len(request) >= 9 {
+			challenge = int32(binary.LittleEndian.Uint32(request[5:9]))
+		}
+		if config.Challenge != 0 && challenge != config.Challenge {
+			return challengeResponse(config.Challenge)
+		}
+		if queryType == a2s.A2S_PLAYER {
+			return playersResponse(config.Players, config.LyingPlayerCount)
+		}
+		return rulesResponse(config.Rules, config.LyingRuleCount)
+	case a2s.A2S_SERVERQUERY_GETCHALLENGE:
+		return challengeResponse(config.Challenge)
+	}
+	return nil
+}
+
+func newResponse(responseType byte) *bytes.Buffer {
+	response := &bytes.Buffer{}
+	binary.Write(response, binary.LittleEndian, uint32(a2s.PACKET_HEADER))
+	response.WriteByte(responseType)
+	return response
+}
+
+func challengeResponse(challenge int32) []byte {
+	response := newResponse(a2s.S2A_CHALLENGE)
+	binary.Write(response, binary.LittleEndian, challenge)
+	return response.Bytes()
+}
+
+func infoResponse(info a2s.ServerInfo) []byte {
+	response := newResponse(a2s.S2A_INFO_SRC)
+	response.WriteByte(info.Protocol)
+	for _, s := range []string{info.Name, info.Map, info.Folder, info.Game} {
+		response.WriteString(s + "\x00")
+	}
+	binary.Write(response, binary.LittleEndian, info.ID)
+	response.Write([]byte{info.Players, info.MaxPlayers, info.Bots, info.ServerType, info.Environment, info.Visibility, info.VAC})
+	response.WriteString(info.Version + "\x00")
+
+	var edf byte
+	if info.Port != nil {
+		edf |= 0x80
+	}
+	if info.SteamID != nil {
+		edf |= 0x10
+	}
+	if info.SourceTVPort != nil {
+		edf |= 0x40
+	}
+	if info.Keywords != nil {
+		edf |= 0x20
+	}
+	if info.GameID != nil {
+		edf |= 0x01
+	}
+	if edf == 0 {
+		return response.Bytes()
+	}
+
+	response.WriteByte(edf)
+	if info.Port != nil {
+		binary.Write(response, binary.LittleEndian, *info.Port)
+	}
+	if info.SteamID != nil {
+		binary.Write(response, binary.LittleEndian, *info.SteamID)
+	}
+	if info.SourceTVPort != nil {
+		binary.Write(response, binary.LittleEndian, *info.SourceTVPort)
+		name := ""
+		if info.SourceTVName != nil {
+			name = *info.SourceTVName
+		}
+		response.WriteString(name + "\x00")
+	}
+	if info.Keywords != nil {
+		response.WriteString(*info.Keywords + "\x00")
+	}
+	if info.GameID != nil {
+		binary.Write(response, binary.LittleEndian, *info.GameID)
+	}
+	return response.Bytes()
+}
+
+func playersResponse(players []a2s.Player, lying bool) []byte {
+	response := newResponse(a2s.S2A_PLAYER)
+	if lying {
+		response.WriteByte(0)
+	} else {
+		response.WriteByte(byte(len(players)))
+	}
+	for _, player := range players {
+		response.WriteByte(player.Index)
+		response.WriteString(player.Name + "\x00")
+		binary.Write(response, binary.LittleEndian, player.Score)
+		binary.Write(response, binary.LittleEndian, player.Duration)
+	}
+	return response.Bytes()
+}
+
+// rulesResponse sends the rules sorted by name, so responses are the same every time
+func rulesResponse(rules map[string]string, lying bool) []byte {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	count := uint16(len(rules))
+	if lying {
+		count = min(count, 1)
+	}
+	response := newResponse(a2s.S2A_RULES)
+	binary.Write(response, binary.LittleEndian, count)
+	for _, name := range names {
+		response.WriteString(name + "\x00" + rules[name] + "\x00")
+	}
+	return response.Bytes()
+}
+
+// packets splits a response into the packets sent for it. A response that fits is sent as is,
+// unless it is compressed. Split packets follow the Source engine format: the split header, the
+// packet ID (high bit set when compressed), the packet count and number, and the max packet size;
+// the first packet of a compressed response also carries the uncompressed size and CRC32.
+func (s *Server) packets(config Config, response []byte) ([][]byte, error) {
+	maxSize := config.MaxPacketSize
+	if maxSize <= 0 {
+		maxSize = DEFAULT_MAX_PACKET_SIZE
+	}
+	if config.Compress == nil && len(response) <= maxSize {
+		return [][]byte{response}, nil
+	}
+
+	s.mu.Lock()
+	s.packetID++
+	id := s.packetID
+	s.mu.Unlock()
+
+	payload := response
+	if config.Compress != nil {
+		compressed, err := config.Compress(response)
+		if err != nil {
+			return nil, err
+		}
+		prefix := &bytes.Buffer{}
+		binary.Write(prefix, binary.LittleEndian, int32(len(response)))
+		binary.Write(prefix, binary.LittleEndian, crc32.ChecksumIEEE(response))
+		payload = append(prefix.Bytes(), compressed...)
+		id |= -0x80000000
+	}
+
+	chunkSize := max(maxSize-splitHeaderSize, 1)
+	total := (len(payload) + chunkSize - 1) / chunkSize
+	packets := make([][]byte, 0, total)
+	for number := 0; number < total; number++ {
+		chunk := payload[number*chunkSize : min((number+1)*chunkSize, len(payload))]
+
+		packet := &bytes.Buffer{}
+		binary.Write(packet, binary.LittleEndian, uint32(SPLIT_HEADER))
+		binary.Write(packet, binary.LittleEndian, id)
+		packet.WriteByte(byte(total))
+		packet.WriteByte(byte(number))
+		binary.Write(packet, binary.LittleEndian, uint16(maxSize))
+		packet.Write(chunk)
+		packets = append(packets, packet.Bytes())
+	}
+	return packets, nil
+}
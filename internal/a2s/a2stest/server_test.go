@@ -0,0 +1,236 @@
+package a2stest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+)
+
+var testPlayers = []a2s.Player{
+	{Index: 0, Name: "ArmoredBear", Score: 1200, Duration: 300},
+	{Index: 1, Name: "Rabbit", Score: 400, Duration: 60},
+}
+
+// TestServerClient checks the a2s client reads what the fake sends, with and without a challenge
+func TestServerClient(t *testing.T) {
+	port := uint16(27102)
+	keywords := "checkpoint,hardcore"
+	config := Config{
+		Info: a2s.ServerInfo{
+			Protocol: 17, Name: "Test Server", Map: "Town", Folder: "Insurgency", Game: "Insurgency: Sandstorm",
+			Players: 2, MaxPlayers: 28, ServerType: 'd', Environment: 'w', Version: "1.0",
+			Port: &port, Keywords: &keywords,
+		},
+		Players:          testPlayers,
+		Rules:            map[string]string{"GameMode_s": "Checkpoint", "Mutators_s": "Hardcore"},
+		LyingPlayerCount: true,
+		LyingRuleCount:   true,
+	}
+
+	for _, challenge := range []int32{0, 0x0BADF00D} {
+		config.Challenge = challenge
+		server := NewServer(t, config)
+		client := a2s.NewClient(a2s.WithTimeout(time.Second))
+
+		info, err := client.QueryInfo(server.Addr())
+		if err != nil {
+			t.Fatalf("challenge %d: QueryInfo failed: %v", challenge, err)
+		}
+		if info.Name != "Test Server" || info.Map != "Town" || info.MaxPlayers != 28 || info.Port == nil || *info.Port != port ||
+			info.Keywords == nil || *info.Keywords != keywords {
+			t.Errorf("challenge %d: unexpected info %+v", challenge, info)
+		}
+
+		players, err := client.QueryPlayers(server.Addr())
+		if err != nil {
+			t.Fatalf("challenge %d: QueryPlayers failed: %v", challenge, err)
+		}
+		if !reflect.DeepEqual(players, testPlayers) {
+			t.Errorf("challenge %d: unexpected players %+v", challenge, players)
+		}
+
+		rules, err := client.QueryRules(server.Addr())
+		if err != nil {
+			t.Fatalf("challenge %d: QueryRules failed: %v", challenge, err)
+		}
+		if !reflect.DeepEqual(rules, config.Rules) {
+			t.Errorf("challenge %d: unexpected rules %v", challenge, rules)
+		}
+
+		// A challenge costs each query a second round trip
+		want := 1
+		if challenge != 0 {
+			want = 2
+		}
+		if got := server.Requests(a2s.A2S_PLAYER); got != want {
+			t.Errorf("challenge %d: expected %d player requests, got %d", challenge, want, got)
+		}
+	}
+}
+
+// TestServerTimeouts checks a silent or slow fake makes queries time out, and can be brought back
+func TestServerTimeouts(t *testing.T) {
+	server := NewServer(t, Config{Info: a2s.ServerInfo{Name: "Test Server"}, Silent: true})
+	client := a2s.NewClient(a2s.WithTimeout(100 * time.Millisecond))
+
+	if _, err := client.QueryInfo(server.Addr()); err == nil {
+		t.Error("expected a silent server to time out")
+	}
+	if got := server.Requests(a2s.A2S_INFO); got != 1 {
+		t.Errorf("expected the dropped query to be counted, got %d", got)
+	}
+
+	server.Update(func(c *Config) {
+		c.Silent = false
+		c.Delay = 300 * time.Millisecond
+	})
+	if _, err := client.QueryInfo(server.Addr()); err == nil {
+		t.Error("expected a slow server to time out")
+	}
+
+	server.Update(func(c *Config) { c.Delay = 0 })
+	if info, err := client.QueryInfo(server.Addr()); err != nil || info.Name != "Test Server" {
+		t.Errorf("expected the server to answer again, got %+v, %v", info, err)
+	}
+}
+
+// TestServerChallengeInfo checks info queries need the challenge when ChallengeInfo is set
+func TestServerChallengeInfo(t *testing.T) {
+	server := NewServer(t, Config{Challenge: 42, ChallengeInfo: true})
+	conn := dial(t, server)
+
+	request := append([]byte{0xFF, 0xFF, 0xFF, 0xFF, a2s.A2S_INFO}, "Source Engine Query\x00"...)
+	response := query(t, conn, request)
+	if response[4] != a2s.S2A_CHALLENGE || int32(binary.LittleEndian.Uint32(response[5:9])) != 42 {
+		t.Fatalf("expected challenge 42, got % x", response)
+	}
+
+	response = query(t, conn, binary.LittleEndian.AppendUint32(request, 42))
+	if response[4] != a2s.S2A_INFO_SRC {
+		t.Errorf("expected info with the challenge, got % x", response)
+	}
+}
+
+// TestServerSplitResponses checks long responses are split into Source engine multi-packet
+// responses that put back together give the whole response, compressed or not
+func TestServerSplitResponses(t *testing.T) {
+	players := make([]a2s.Player, 40)
+	for i := range players {
+		players[i] = a2s.Player{Index: byte(i), Name: "A player with a rather long name", Score: int32(i)}
+	}
+	want := playersResponse(players, false)
+	request := []byte{0xFF, 0xFF, 0xFF, 0xFF, a2s.A2S_PLAYER, 0xFF, 0xFF, 0xFF, 0xFF}
+
+	t.Run("multi-packet", func(t *testing.T) {
+		server := NewServer(t, Config{Players: players, MaxPacketSize: 512})
+		conn := dial(t, server)
+
+		got, compressed := querySplit(t, conn, request, 512)
+		if compressed {
+			t.Error("expected an uncompressed response")
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("reassembled response differs from the players response")
+		}
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		// Reversing stands in for bzip2, which the standard library can only decompress
+		reverse := func(data []byte) ([]byte, error) {
+			reversed := slices.Clone(data)
+			slices.Reverse(reversed)
+			return reversed, nil
+		}
+		server := NewServer(t, Config{Players: players, MaxPacketSize: 512, Compress: reverse})
+		conn := dial(t, server)
+
+		got, compressed := querySplit(t, conn, request, 512)
+		if !compressed {
+			t.Fatal("expected a compressed response")
+		}
+		size := binary.LittleEndian.Uint32(got[0:4])
+		crc := binary.LittleEndian.Uint32(got[4:8])
+		decompressed, _ := reverse(got[8:])
+		if int(size) != len(want) || crc != crc32.ChecksumIEEE(want) || !bytes.Equal(decompressed, want) {
+			t.Errorf("unexpected compressed response: size %d, crc %08x", size, crc)
+		}
+	})
+
+	t.Run("short responses are sent whole", func(t *testing.T) {
+		server := NewServer(t, Config{Players: testPlayers})
+		conn := dial(t, server)
+
+		if got := query(t, conn, request); !bytes.Equal(got, playersResponse(testPlayers, false)) {
+			t.Errorf("unexpected response % x", got)
+		}
+	})
+}
+
+func dial(t *testing.T, server *Server) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("udp", server.Addr())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(time.Second))
+	return conn
+}
+
+func query(t *testing.T, conn net.Conn, request []byte) []byte {
+	t.Helper()
+	if _, err := conn.Write(request); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	response := make([]byte, 1400)
+	n, err := conn.Read(response)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	return response[:n]
+}
+
+// querySplit sends a query and puts its split response back together, checking every packet's header
+func querySplit(t *testing.T, conn net.Conn, request []byte, maxSize int) ([]byte, bool) {
+	t.Helper()
+	if _, err := conn.Write(request); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	var chunks [][]byte
+	var id uint32
+	for received := 0; received == 0 || received < len(chunks); received++ {
+		packet := make([]byte, 1400)
+		n, err := conn.Read(packet)
+		if err != nil {
+			t.Fatalf("failed to read packet %d: %v", received, err)
+		}
+		if n > maxSize {
+			t.Errorf("packet of %d bytes is over the %d byte limit", n, maxSize)
+		}
+		if binary.LittleEndian.Uint32(packet[0:4]) != SPLIT_HEADER {
+			t.Fatalf("expected a split packet, got % x", packet[:n])
+		}
+		if received == 0 {
+			id = binary.LittleEndian.Uint32(packet[4:8])
+			chunks = make([][]byte, int(packet[8]))
+		} else if binary.LittleEndian.Uint32(packet[4:8]) != id {
+			t.Fatalf("packet ID changed mid response")
+		}
+		if size := binary.LittleEndian.Uint16(packet[10:12]); int(size) != maxSize {
+			t.Errorf("expected max packet size %d in the header, got %d", maxSize, size)
+		}
+		chunks[packet[9]] = packet[splitHeaderSize:n]
+	}
+	if len(chunks) < 2 {
+		t.Errorf("expected the response to be split, got %d packet", len(chunks))
+	}
+	return bytes.Join(chunks, nil), id&0x80000000 != 0
+}
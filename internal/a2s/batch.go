@@ -0,0 +1,112 @@
+package a2s
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DEFAULT_BATCH_CONCURRENCY is how many addresses a batch query queries at once by default
+const DEFAULT_BATCH_CONCURRENCY = 16
+
+// InfoResult is the outcome of one address's A2S_INFO query in QueryInfoBatch
+type InfoResult struct {
+	Info    *ServerInfo
+	Latency time.Duration
+	Err     error
+}
+
+// PlayersResult is the outcome of one address's A2S_PLAYER query in QueryPlayersBatch
+type PlayersResult struct {
+	Players []Player
+	Latency time.Duration
+	Err     error
+}
+
+// QueryInfoBatch queries the server info of every address concurrently, at most the client's
+// batch concurrency at a time (see WithBatchConcurrency), and returns a result for each address.
+// A failing address only fails its own result. Each address gets the client's timeout per
+// attempt, counted from when its query starts; cancelling ctx stops the whole batch, and
+// addresses not queried yet report ctx's error. Duplicate addresses are queried once.
+//
+// Unlike a ServerPool, nothing is kept between calls and queries are not rate limited.
+func (c *Client) QueryInfoBatch(ctx context.Context, addrs []string) map[string]InfoResult {
+	results := make(map[string]InfoResult, len(addrs))
+	var mu sync.Mutex
+
+	c.runBatch(ctx, addrs, func(ctx context.Context, address string, err error) {
+		result := InfoResult{Err: err}
+		if err == nil {
+			start := time.Now()
+			result.Info, result.Err = c.QueryInfoContext(ctx, address)
+			result.Latency = time.Since(start)
+		}
+
+		mu.Lock()
+		results[address] = result
+		mu.Unlock()
+	})
+
+	return results
+}
+
+// QueryPlayersBatch queries the player list of every address concurrently, like QueryInfoBatch
+func (c *Client) QueryPlayersBatch(ctx context.Context, addrs []string) map[string]PlayersResult {
+	results := make(map[string]PlayersResult, len(addrs))
+	var mu sync.Mutex
+
+	c.runBatch(ctx, addrs, func(ctx context.Context, address string, err error) {
+		result := PlayersResult{Err: err}
+		if err == nil {
+			start := time.Now()
+			result.Players, result.Err = c.QueryPlayersContext(ctx, address)
+			result.Latency = time.Since(start)
+		}
+
+		mu.Lock()
+		results[address] = result
+		mu.Unlock()
+	})
+
+	return results
+}
+
+// runBatch calls query once for every distinct address, at most the batch concurrency at a time,
+// and waits for them all. query gets ctx's error instead of a turn when ctx ends first.
+func (c *Client) runBatch(ctx context.Context, addrs []string, query func(ctx context.Context, address string, err error)) {
+	concurrency := c.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = DEFAULT_BATCH_CONCURRENCY
+	}
+	slots := make(chan struct{}, concurrency)
+
+	seen := make(map[string]bool, len(addrs))
+	var wg sync.WaitGroup
+	for _, address := range addrs {
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		wg.Add(1)
+		go func(address string) {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				query(ctx, address, ctx.Err())
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				query(ctx, address, err)
+				return
+			}
+
+			query(ctx, address, nil)
+		}(address)
+	}
+
+	wg.Wait()
+}
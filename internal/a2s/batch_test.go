@@ -0,0 +1,70 @@
+package a2s_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/a2s/a2stest"
+)
+
+// TestQueryInfoBatch tests that a batch returns every address's result, with an address that
+// never answers timing out on its own without holding up or failing the others
+func TestQueryInfoBatch(t *testing.T) {
+	online := a2stest.NewServer(t, a2stest.Config{Info: a2s.ServerInfo{Name: "Test Server"}}).Addr()
+
+	// Accepts queries but never answers
+	offline := a2stest.NewServer(t, a2stest.Config{Silent: true}).Addr()
+
+	client := a2s.NewClient(a2s.WithTimeout(300*time.Millisecond), a2s.WithBatchConcurrency(1))
+
+	start := time.Now()
+	results := client.QueryInfoBatch(context.Background(), []string{online, offline, online})
+	elapsed := time.Since(start)
+
+	if len(results) != 2 {
+		t.Fatalf("expected a result per distinct address, got %d", len(results))
+	}
+	if result := results[online]; result.Err != nil || result.Info == nil || result.Info.Name != "Test Server" {
+		t.Errorf("expected the online server's info, got %+v", result)
+	}
+	result := results[offline]
+	if result.Err == nil || result.Info != nil {
+		t.Errorf("expected the silent server to time out, got %+v", result)
+	}
+	var netErr net.Error
+	if !errors.As(result.Err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected a timeout error, got %v", result.Err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("batch took %v, expected about one timeout", elapsed)
+	}
+
+	t.Run("players", func(t *testing.T) {
+		results := client.QueryPlayersBatch(context.Background(), []string{online, offline})
+		if result := results[online]; result.Err != nil || len(result.Players) != 0 {
+			t.Errorf("expected an empty player list, got %+v", result)
+		}
+		if results[offline].Err == nil {
+			t.Error("expected the silent server to fail")
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results := client.QueryInfoBatch(ctx, []string{online, offline})
+		for address, result := range results {
+			if !errors.Is(result.Err, context.Canceled) {
+				t.Errorf("%s: expected context.Canceled, got %v", address, result.Err)
+			}
+		}
+		if len(results) != 2 {
+			t.Errorf("expected a result per address, got %d", len(results))
+		}
+	})
+}
@@ -120,7 +120,7 @@ func ExampleIntegrationWithTracker() {
 
 // ExampleWithRetries shows how to implement retry logic
 func ExampleWithRetries() {
-	client := NewClientWithTimeout(3 * time.Second)
+	client := NewClient(WithTimeout(3 * time.Second))
 	serverAddress := "yourserver.com:27102"
 
 	maxRetries := 3
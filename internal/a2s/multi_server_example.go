@@ -198,7 +198,7 @@ func ExampleServerAlerts() {
 // Example with custom timeout and context
 func ExampleWithTimeout() {
 	// Create client with custom timeout
-	client := NewClientWithTimeout(3 * time.Second)
+	client := NewClient(WithTimeout(3 * time.Second))
 	pool := NewServerPoolWithClient(client)
 
 	pool.AddServer("slow-server:27102", "Slow Server")
@@ -0,0 +1,101 @@
+package a2s
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Options configures a Client created with NewClient. The zero value of every field but
+// Timeout is the default; use the With* functions rather than filling it in directly.
+type Options struct {
+	// Timeout for each query attempt, including resolving and dialing. Default DEFAULT_TIMEOUT.
+	Timeout time.Duration
+
+	// Retries is how many more times a query that failed on the network (a lost packet or a
+	// timeout) is sent before giving up. Default 0: one attempt.
+	Retries int
+
+	// LocalAddr is the local address queries are sent from. Default nil: any interface and an
+	// ephemeral port.
+	LocalAddr *net.UDPAddr
+
+	// ChallengeCache keeps one UDP socket open per address and reuses the last challenge number
+	// for ChallengeTTL. Default off: a fresh socket and challenge for every query.
+	ChallengeCache bool
+	ChallengeTTL   time.Duration
+
+	// BatchConcurrency is how many addresses QueryInfoBatch and QueryPlayersBatch query at once.
+	// Default DEFAULT_BATCH_CONCURRENCY.
+	BatchConcurrency int
+}
+
+// Option sets one of a client's Options
+type Option func(*Options)
+
+// defaultOptions returns the options of a client created with no Option
+func defaultOptions() Options {
+	return Options{Timeout: DEFAULT_TIMEOUT, BatchConcurrency: DEFAULT_BATCH_CONCURRENCY}
+}
+
+// WithTimeout sets the timeout of each query attempt. A timeout of zero or less keeps the default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		if timeout > 0 {
+			o.Timeout = timeout
+		}
+	}
+}
+
+// WithRetries sets how many times a query that failed on the network is sent again.
+// Errors in the server's response are not retried.
+func WithRetries(retries int) Option {
+	return func(o *Options) {
+		o.Retries = max(retries, 0)
+	}
+}
+
+// WithLocalAddr sets the local address queries are sent from. Unlike SetLocalAddress, the
+// address is not bound up front to check it is free; use SetLocalAddress for addresses from config.
+func WithLocalAddr(addr *net.UDPAddr) Option {
+	return func(o *Options) {
+		o.LocalAddr = addr
+	}
+}
+
+// WithChallengeCache keeps one socket open per address and reuses its challenge number for ttl,
+// so repeated player and rules queries skip the challenge round trip (DEFAULT_CHALLENGE_TTL is a
+// sensible ttl). Queries to the same address are serialized; call Close to release the sockets.
+func WithChallengeCache(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.ChallengeCache = true
+		o.ChallengeTTL = ttl
+	}
+}
+
+// WithBatchConcurrency sets how many addresses a batch query queries at once. A limit of zero or
+// less keeps the default.
+func WithBatchConcurrency(limit int) Option {
+	return func(o *Options) {
+		if limit > 0 {
+			o.BatchConcurrency = limit
+		}
+	}
+}
+
+// withRetries runs query, running it again up to the client's retries while it fails on the network
+func withRetries[T any](ctx context.Context, c *Client, query func() (T, error)) (T, error) {
+	result, err := query()
+	for attempt := 0; attempt < c.retries && err != nil && retryable(err) && ctx.Err() == nil; attempt++ {
+		result, err = query()
+	}
+	return result, err
+}
+
+// retryable reports whether a failed query may succeed when sent again: the request or response
+// was lost, not rejected
+func retryable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -3,6 +3,7 @@ package a2s
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -13,16 +14,23 @@ type ServerPool struct {
 	servers     map[string]*Server
 	mu          sync.RWMutex
 	rateLimiter *RateLimiter
+	logger      *slog.Logger // Optional; nil leaves query failures unlogged
 }
 
 // Server represents a monitored server
 type Server struct {
-	Address   string
-	Name      string
-	lastInfo  *ServerInfo
-	lastError error
-	lastQuery time.Time
-	mu        sync.RWMutex
+	Address     string
+	Name        string
+	lastInfo    *ServerInfo
+	lastPlayers []Player
+	playersErr  error // Error of the last player query, nil when it succeeded
+	lastRules   map[string]string
+	lastError   error
+	lastQuery   time.Time
+	lastSuccess time.Time
+	failures    int       // Failed queries in a row since the last success
+	failedSince time.Time // First failed query of the current run of failures
+	mu          sync.RWMutex
 }
 
 // ServerStatus contains the current status of a server
@@ -31,11 +39,25 @@ type ServerStatus struct {
 	Online    bool
 	Info      *ServerInfo
 	Players   []Player
+	Rules     map[string]string // Snapshot of SnapshotRules reported by the server
 	Error     error
 	QueryTime time.Duration
 	LastQuery time.Time
 }
 
+// SnapshotRules are the server rules/cvars kept alongside the cached server info
+var SnapshotRules = []string{
+	"GameMode_s",
+	"MaxPlayers_i",
+	"Coop_b",
+	"Versus_b",
+	"Pwd_b",
+	"Mutators_s",
+	"RankedServer_b",
+	"OfficialRuleset_b",
+	"PlayerCount_i",
+}
+
 // RateLimiter limits queries per server
 type RateLimiter struct {
 	minInterval time.Duration
@@ -94,6 +116,16 @@ func NewServerPoolWithClient(client *Client) *ServerPool {
 	}
 }
 
+// SetLogger sets the logger query failures are written to at debug level
+func (p *ServerPool) SetLogger(logger *slog.Logger) {
+	p.logger = logger
+}
+
+// Close releases the sockets the pool's client keeps open (see WithChallengeCache)
+func (p *ServerPool) Close() error {
+	return p.client.Close()
+}
+
 // AddServer adds a server to the pool
 func (p *ServerPool) AddServer(address string, name string) {
 	p.mu.Lock()
@@ -222,23 +254,90 @@ func (p *ServerPool) queryServer(ctx context.Context, server *Server) (*ServerSt
 	players, err := p.client.QueryPlayersContext(ctx, server.Address)
 	if err == nil {
 		status.Players = players
-	} else {
-		// Log player query failures for debugging
-		fmt.Printf("[A2S] Failed to query players for %s: %v\n", server.Address, err)
+	} else if p.logger != nil {
+		p.logger.Debug("Failed to query players", "address", server.Address, "error", err)
+	}
+	server.updatePlayers(players, err)
+
+	// Rules are optional - keep the previous snapshot if the query fails
+	rules, err := p.client.QueryRulesContext(ctx, server.Address)
+	if err == nil {
+		status.Rules = snapshotRules(rules)
+		server.updateRules(status.Rules)
+	} else if p.logger != nil {
+		p.logger.Debug("Failed to query rules", "address", server.Address, "error", err)
 	}
 
 	server.updateStatus(info, nil)
 	return status, nil
 }
 
+// snapshotRules keeps only the SnapshotRules present in rules
+func snapshotRules(rules map[string]string) map[string]string {
+	snapshot := make(map[string]string)
+	for _, name := range SnapshotRules {
+		if value, ok := rules[name]; ok {
+			snapshot[name] = value
+		}
+	}
+	return snapshot
+}
+
 // updateStatus updates the server's cached status
+// A failed query keeps the last successful info so it can still be served as stale
 func (s *Server) updateStatus(info *ServerInfo, err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.lastInfo = info
 	s.lastError = err
 	s.lastQuery = time.Now()
+	if err == nil {
+		s.lastInfo = info
+		s.lastSuccess = s.lastQuery
+		s.failures = 0
+		s.failedSince = time.Time{}
+		return
+	}
+	if s.failures == 0 {
+		s.failedSince = s.lastQuery
+	}
+	s.failures++
+}
+
+// updatePlayers updates the server's cached player list
+// A failed query keeps the last player list and records the error
+func (s *Server) updatePlayers(players []Player, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.playersErr = err
+	if err == nil {
+		s.lastPlayers = players
+	}
+}
+
+// updateRules updates the server's cached rules snapshot
+func (s *Server) updateRules(rules map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastRules = rules
+}
+
+// GetLastRules returns a copy of the last rules snapshot, or nil if rules were never queried
+func (s *Server) GetLastRules() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.lastRules == nil {
+		return nil
+	}
+
+	rules := make(map[string]string, len(s.lastRules))
+	for name, value := range s.lastRules {
+		rules[name] = value
+	}
+	return rules
 }
 
 // GetLastInfo returns the last successful server info query
@@ -253,6 +352,93 @@ func (s *Server) GetLastInfo() (*ServerInfo, error) {
 	return s.lastInfo, nil
 }
 
+// Snapshot states, from the last query of a server
+const (
+	SnapshotOK          = "ok"          // The last query succeeded
+	SnapshotStale       = "stale"       // The last query failed; Info and Players are from an earlier query
+	SnapshotUnreachable = "unreachable" // No query has succeeded yet
+	SnapshotPending     = "pending"     // The server has not been queried yet
+)
+
+// Snapshot is a copy of a server's cached query results
+type Snapshot struct {
+	Address     string
+	Name        string
+	Info        *ServerInfo
+	Players     []Player
+	PlayersErr  error     // Error of the last player query; Players are from an earlier query when set
+	LastQuery   time.Time // Last query attempt
+	LastSuccess time.Time // Last query that reached the server, zero if none has
+	Error       error     // Error of the last query, nil when it succeeded
+	Failures    int       // Failed queries in a row, 0 when the last query succeeded
+	FailedSince time.Time // First of those failed queries, zero when the last query succeeded
+}
+
+// State returns the snapshot's state (SnapshotOK, SnapshotStale, SnapshotUnreachable or SnapshotPending)
+func (s Snapshot) State() string {
+	switch {
+	case s.LastQuery.IsZero():
+		return SnapshotPending
+	case s.Error == nil:
+		return SnapshotOK
+	case s.LastSuccess.IsZero():
+		return SnapshotUnreachable
+	default:
+		return SnapshotStale
+	}
+}
+
+// OnlineServers returns the keys of the snapshots whose last query succeeded
+func OnlineServers(snapshots map[string]Snapshot) map[string]bool {
+	online := make(map[string]bool, len(snapshots))
+	for key, snapshot := range snapshots {
+		if snapshot.State() == SnapshotOK {
+			online[key] = true
+		}
+	}
+	return online
+}
+
+// Age returns how old the cached info is at now, or 0 if there is none
+func (s Snapshot) Age(now time.Time) time.Duration {
+	if s.LastSuccess.IsZero() {
+		return 0
+	}
+	return now.Sub(s.LastSuccess)
+}
+
+// Snapshot returns a copy of the server's cached info and players without querying it
+func (s *Server) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := Snapshot{
+		Address:     s.Address,
+		Name:        s.Name,
+		Players:     append([]Player(nil), s.lastPlayers...),
+		PlayersErr:  s.playersErr,
+		LastQuery:   s.lastQuery,
+		LastSuccess: s.lastSuccess,
+		Error:       s.lastError,
+		Failures:    s.failures,
+		FailedSince: s.failedSince,
+	}
+	if s.lastInfo != nil {
+		info := *s.lastInfo
+		snapshot.Info = &info
+	}
+	return snapshot
+}
+
+// Snapshot returns the cached snapshot of a server by address
+func (p *ServerPool) Snapshot(address string) (Snapshot, error) {
+	server, err := p.GetServer(address)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return server.Snapshot(), nil
+}
+
 // IsOnline returns whether the server was online during last query
 func (s *Server) IsOnline() bool {
 	s.mu.RLock()
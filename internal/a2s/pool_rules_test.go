@@ -0,0 +1,62 @@
+package a2s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/a2s/a2stest"
+)
+
+func TestQueryServer_RulesSnapshot(t *testing.T) {
+	// Rules require a challenge and declare fewer rules than are sent, like Insurgency: Sandstorm
+	address := a2stest.NewServer(t, a2stest.Config{
+		Info: a2s.ServerInfo{Name: "Test Server", Map: "Town", MaxPlayers: 28},
+		Rules: map[string]string{
+			"GameMode_s":  "Checkpoint",
+			"Pwd_b":       "true",
+			"SessionId_s": "abc123",
+			"Mutators_s":  "Hardcore",
+		},
+		Challenge:      0x0BADF00D,
+		LyingRuleCount: true,
+	}).Addr()
+
+	pool := a2s.NewServerPoolWithClient(a2s.NewClientWithTimeout(time.Second))
+	pool.AddServer(address, "Test Server")
+
+	status, err := pool.QueryServer(context.Background(), address)
+	if err != nil {
+		t.Fatalf("QueryServer failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"GameMode_s": "Checkpoint",
+		"Pwd_b":      "true",
+		"Mutators_s": "Hardcore",
+	}
+	if len(status.Rules) != len(expected) {
+		t.Errorf("Expected %d snapshot rules, got %v", len(expected), status.Rules)
+	}
+	for name, value := range expected {
+		if status.Rules[name] != value {
+			t.Errorf("Rules[%q] = %q, want %q", name, status.Rules[name], value)
+		}
+	}
+	if _, ok := status.Rules["SessionId_s"]; ok {
+		t.Error("Rules not in SnapshotRules should not be kept")
+	}
+
+	server, _ := pool.GetServer(address)
+	cached := server.GetLastRules()
+	if cached["GameMode_s"] != "Checkpoint" {
+		t.Errorf("Expected cached game mode Checkpoint, got %v", cached)
+	}
+
+	// The cached snapshot is a copy
+	cached["GameMode_s"] = "Push"
+	if server.GetLastRules()["GameMode_s"] != "Checkpoint" {
+		t.Error("GetLastRules should return a copy")
+	}
+}
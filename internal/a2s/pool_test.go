@@ -191,6 +191,61 @@ func TestServerIsOnline(t *testing.T) {
 	}
 }
 
+func TestServerSnapshotState(t *testing.T) {
+	server := &Server{
+		Address: "test:27102",
+		Name:    "Test",
+	}
+
+	if state := server.Snapshot().State(); state != SnapshotPending {
+		t.Errorf("New server state = %q, want %q", state, SnapshotPending)
+	}
+
+	server.updateStatus(nil, fmt.Errorf("connection refused"))
+	if state := server.Snapshot().State(); state != SnapshotUnreachable {
+		t.Errorf("State after only failures = %q, want %q", state, SnapshotUnreachable)
+	}
+
+	server.updateStatus(&ServerInfo{Name: "Test Server", Map: "Farmhouse"}, nil)
+	server.updatePlayers([]Player{{Name: "ArmoredBear", Score: 100}}, nil)
+	snapshot := server.Snapshot()
+	if snapshot.State() != SnapshotOK || snapshot.Info == nil || len(snapshot.Players) != 1 {
+		t.Errorf("Snapshot after success = %+v", snapshot)
+	}
+
+	// A failed player query keeps the last players and reports the error
+	server.updatePlayers(nil, fmt.Errorf("i/o timeout"))
+	if snapshot := server.Snapshot(); snapshot.PlayersErr == nil || len(snapshot.Players) != 1 {
+		t.Errorf("Expected the last players kept with the error, got %+v", snapshot)
+	}
+	server.updatePlayers([]Player{{Name: "ArmoredBear", Score: 100}}, nil)
+
+	// A failed query keeps the last info and players, marked stale
+	server.updateStatus(nil, fmt.Errorf("i/o timeout"))
+	snapshot = server.Snapshot()
+	if snapshot.State() != SnapshotStale {
+		t.Errorf("State after a failure = %q, want %q", snapshot.State(), SnapshotStale)
+	}
+	if snapshot.Info == nil || snapshot.Info.Map != "Farmhouse" || len(snapshot.Players) != 1 {
+		t.Errorf("Expected the last info and players to be kept, got %+v", snapshot)
+	}
+	if server.IsOnline() {
+		t.Error("Server should be offline after error")
+	}
+
+	// Failures in a row are counted from the first one, and reset by a success
+	server.updateStatus(nil, fmt.Errorf("i/o timeout"))
+	snapshot = server.Snapshot()
+	if snapshot.Failures != 2 || snapshot.FailedSince.IsZero() || snapshot.FailedSince.After(snapshot.LastQuery) {
+		t.Errorf("Expected 2 failures since the first one, got %d since %v", snapshot.Failures, snapshot.FailedSince)
+	}
+	server.updateStatus(&ServerInfo{Name: "Test Server", Map: "Farmhouse"}, nil)
+	snapshot = server.Snapshot()
+	if snapshot.Failures != 0 || !snapshot.FailedSince.IsZero() {
+		t.Errorf("Expected failures to reset after a success, got %d since %v", snapshot.Failures, snapshot.FailedSince)
+	}
+}
+
 func TestMonitor_Cancellation(t *testing.T) {
 	pool := NewServerPool()
 	pool.AddServer("localhost:27102", "Test")
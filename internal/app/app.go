@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"sandstorm-tracker/internal/a2s"
 	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/geoip"
 	"sandstorm-tracker/internal/ghupdate"
 	"sandstorm-tracker/internal/handlers"
 	"sandstorm-tracker/internal/jobs"
@@ -42,8 +46,13 @@ type App struct {
 	Watcher  *watcher.Watcher
 	// ServerManager *servermgr.Plugin  // Server manager plugin
 	// logFileWriter *logger.FileWriter // File writer for PocketBase logs
-	customLogger *slog.Logger // Logger with TeeHandler (writes to both console and file)
+	customLogger *slog.Logger // Logger with TeeHandler (writes to both console and PocketBase's logs)
 	updater      *updater.Updater
+	metrics      *Metrics // Prometheus metrics served at /metrics
+	parserErrors *Counter // Incremented for every error the parser logs
+
+	scoreDebouncer *jobs.ScoreDebouncer // Pending score updates, flushed on shutdown
+	geoIP          *geoip.Resolver      // Locates players' known IPs for superusers; nil without geoip.databasePath
 
 	// Version information (injected at build time via ldflags)
 	Version string
@@ -78,23 +87,52 @@ func (app *App) setupServices() error {
 		return fmt.Errorf("failed to setup logger: %w", err)
 	}
 
+	app.setupMetrics()
+
 	app.RconPool = app.Store().GetOrSet("rconpool", func() any {
 		return rcon.NewClientPool(app.Logger().WithGroup("RCON"))
 	}).(*rcon.ClientPool)
 
 	app.Parser = app.Store().GetOrSet("parser", func() any {
-		return parser.NewLogParser(app, app.Logger().With("component", "PARSER"))
+		return parser.NewLogParser(app, countErrors(app.Logger().With("component", "PARSER"), app.parserErrors))
 	}).(*parser.LogParser)
 
-	app.A2SPool = app.Store().GetOrSet("a2spool", func() any {
-		return a2s.NewServerPool()
-	}).(*a2s.ServerPool)
+	// The pool is only stored once it is built, so a bad local address isn't cached and a
+	// corrected config is picked up on the next setup. The pool polls the same servers over and
+	// over, so its client keeps their sockets open and reuses challenge numbers.
+	pool, ok := app.Store().Get("a2spool").(*a2s.ServerPool)
+	if !ok {
+		client := a2s.NewClient(a2s.WithChallengeCache(a2s.DEFAULT_CHALLENGE_TTL))
+		if app.Config.A2S.LocalAddress != "" {
+			if err := client.SetLocalAddress(app.Config.A2S.LocalAddress); err != nil {
+				return fmt.Errorf("invalid a2s.localAddress: %w", err)
+			}
+		}
+		pool = a2s.NewServerPoolWithClient(client)
+		pool.SetLogger(app.Logger().WithGroup("A2S"))
+		app.Store().Set("a2spool", pool)
+	}
+	app.A2SPool = pool
+
+	// Geolocation is optional: without a usable database IPs are simply shown without a location
+	if path := app.Config.GeoIP.DatabasePath; path != "" && app.geoIP == nil {
+		resolver, err := geoip.Open(path)
+		if err != nil {
+			app.Logger().Warn("GeoIP database unavailable, player IPs will not be located", "component", "APP", "error", err)
+		} else {
+			app.geoIP = resolver
+		}
+	}
 
 	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
-		// remove our services
+		// remove our services once the other terminate hooks are done with them
+		err := e.Next()
+		if app.A2SPool != nil {
+			app.A2SPool.Close()
+		}
 		app.A2SPool = nil
 		app.Parser = nil
-		return e.Next()
+		return err
 	})
 
 	return nil
@@ -154,6 +192,12 @@ func (app *App) setupPlugins() {
 		},
 	})
 
+	// Register replay command
+	app.RootCmd.AddCommand(app.newReplayCommand())
+
+	// Register status command
+	app.RootCmd.AddCommand(app.newStatusCommand())
+
 	// Add other plugins here (jsvm, etc.)
 }
 
@@ -163,14 +207,14 @@ func (app *App) Bootstrap() error {
 	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
 		// Write PID file for graceful shutdown/update coordination
 		pidData := []byte(fmt.Sprintf("%d", os.Getpid()))
-		if err := os.WriteFile("sandstorm-tracker.pid", pidData, 0644); err != nil {
+		if err := os.WriteFile(ghupdate.PidFile, pidData, 0644); err != nil {
 			app.Logger().Warn("Failed to write PID file", "error", err)
 		}
 		return app.onServe(e)
 	})
 
 	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
-		os.Remove("sandstorm-tracker.pid")
+		os.Remove(ghupdate.PidFile)
 		return app.onTerminate(e)
 	})
 
@@ -187,6 +231,26 @@ func (app *App) onServe(e *core.ServeEvent) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// Chat message storage is opt-in
+	app.Parser.SetStoreChatMessages(app.Config.Chat.StoreMessages)
+
+	// Players reconnecting to the new map after a map travel are not counted as leaving
+	app.Parser.SetMapTravelReconnectWindow(app.Config.Presence.MapTravelReconnectWindow())
+
+	// Players switching teams leave and rejoin; a quick rejoin continues their session
+	app.Parser.SetTeamSwapWindow(app.Config.Presence.TeamSwapWindow())
+
+	// Shot tracking for accuracy is opt-in; the lines only exist with verbose gameplay logging
+	app.Parser.SetTrackWeaponFire(app.Config.Accuracy.TrackShots)
+
+	// AI and system actors never become tracked players
+	ignored := app.Config.IgnoredActors
+	ignoreList, err := parser.NewIgnoreList(ignored.IDs, ignored.Names, ignored.NamePrefixes, ignored.NamePatterns)
+	if err != nil {
+		return fmt.Errorf("invalid ignoredActors: %w", err)
+	}
+	app.Parser.SetIgnoreList(ignoreList)
+
 	// Setup servers in RCON and A2S pools
 	for _, sc := range app.Config.Servers {
 		if !sc.Enabled {
@@ -198,17 +262,17 @@ func (app *App) onServe(e *core.ServeEvent) error {
 			return fmt.Errorf("failed to get server ID from path %s: %w", sc.LogPath, err)
 		}
 
+		// Parse this server's log timestamps in its own timezone (already validated above)
+		if loc, err := sc.LogLocation(); err == nil {
+			app.Parser.SetServerLocation(serverID, loc)
+		}
+
 		// Add to RCON pool
 		if sc.RconAddress != "" && sc.RconPassword != "" {
-			timeout := 5 * time.Second
-			if sc.RconTimeout > 0 {
-				timeout = time.Duration(sc.RconTimeout) * time.Second
-			}
-
 			app.RconPool.AddServer(serverID, &rcon.ServerConfig{
 				Address:  sc.RconAddress,
 				Password: sc.RconPassword,
-				Timeout:  timeout,
+				Timeout:  rconTimeout(sc.RconTimeout),
 			})
 		}
 
@@ -235,13 +299,28 @@ func (app *App) onServe(e *core.ServeEvent) error {
 		return fmt.Errorf("failed to ensure servers in database: %w", err)
 	}
 
+	// Re-flag matches below the ranked minimum, which may have changed since the last start
+	unranked, err := database.ApplyRankedMinPlayers(context.Background(), app, app.Config.Ranked.MinPlayers)
+	if err != nil {
+		return err
+	}
+	logger.Info("Applied ranked match minimum", "minPlayers", app.Config.Ranked.MinPlayers, "unrankedMatches", unranked)
+
 	// Register web routes
 	handlers.Register(app, e)
 
 	// Create score debouncer for event-driven score updates
-	// Scores update 10 seconds after any kill/objective/round event
-	scoreDebouncer := jobs.NewScoreDebouncer(app, app.Config, 10*time.Second, 30*time.Second)
-	app.Logger().Info("Initialized event-driven score updater", "component", "APP", "debounce", "10s", "maxWait", "30s")
+	// Scores update a debounce window (default 10 seconds) after any kill/objective event
+	scores := app.Config.Scores
+	scoreDebouncer := jobs.NewScoreDebouncer(app, app.Config, scores.Debounce(), scores.MaxWait(), scores.MinInterval())
+	app.scoreDebouncer = scoreDebouncer
+	app.Logger().Info("Initialized event-driven score updater", "component", "APP",
+		"debounce", scores.Debounce(), "maxWait", scores.MaxWait(), "minInterval", scores.MinInterval())
+
+	// Replayed events don't refresh scores, so a caught up match gets one refresh at the end
+	if scores.AfterCatchupOrDefault() == "final" {
+		app.Watcher.OnCatchupComplete(scoreDebouncer.ExecuteImmediately)
+	}
 
 	// Register event handlers for hook-based processing
 	// Handlers process events created by the parser and trigger score updates
@@ -249,6 +328,26 @@ func (app *App) onServe(e *core.ServeEvent) error {
 	gameEventHandlers.RegisterHooks()
 	app.Logger().Info("Registered game event handlers", "component", "APP")
 
+	// Post match results and moderation warnings to the webhooks in notification_webhooks
+	handlers.NewNotifier(app).RegisterHooks()
+
+	// Raise advisory cheat flags from kill data for moderators to review
+	handlers.NewCheatDetector(app, app.Config.AntiCheat).RegisterHooks()
+
+	// Register servers added through the API or dashboard with the RCON and A2S pools as they change
+	serverPoolSync := handlers.NewServerPoolSync(app, app.RconPool, app.A2SPool)
+	serverPoolSync.RegisterHooks()
+	if err := serverPoolSync.LoadServers(); err != nil {
+		return err
+	}
+
+	// Keep each server's Bans.txt and live ban list in step with the bans collection
+	banSync := handlers.NewBanSync(app, app.SendRconCommand, app.Config.SAWPath)
+	banSync.RegisterHooks()
+	if err := banSync.WriteAll(); err != nil {
+		logger.Warn("Failed to write Bans.txt files", "error", err)
+	}
+
 	BindRecordMiddlewares(app.PocketBase)
 
 	// Start file watcher
@@ -260,8 +359,27 @@ func (app *App) onServe(e *core.ServeEvent) error {
 		}
 	}
 
-	// Register archive cron job for data older than 30 days
-	jobs.RegisterArchiveOldData(app.PocketBase, app.Logger().With("component", "ARCHIVE_JOB"))
+	// Prune old events and, if configured, old matches (retention)
+	jobs.RegisterArchiveOldData(app.PocketBase, app.Config.Retention, app.Config.Presence.MatchIdleTimeout(), app.Logger().With("component", "ARCHIVE_JOB"))
+
+	// Rotate and gzip large game server logs (logArchive.enabled)
+	jobs.RegisterLogArchiver(app.PocketBase, app.Config, app.Logger().With("component", "LOG_ARCHIVE"))
+
+	// Query every server over A2S each minute; the jobs below work from the cached snapshots
+	jobs.RegisterA2SPoller(app)
+
+	// Correct players left connected by a missed disconnect, using the A2S player list
+	presence := jobs.NewPresenceReconciler(app, app.Config.Presence.DisconnectGrace())
+	jobs.RegisterPresenceReconciler(app, app.Config, presence)
+
+	// Report servers that stop answering A2S queries, and their recovery, to the webhooks
+	jobs.RegisterOfflineMonitor(app, app.Config, jobs.NewOfflineMonitor(app, app.Config.OfflineAlerts.FailureThreshold(), app.Config.OfflineAlerts.RecoveryThreshold()))
+
+	// Switch scenarios by player count on servers with mapCycle enabled
+	jobs.RegisterMapCycler(app, app.Config, jobs.NewMapCycler(app, app))
+
+	// Close matches left active by a missed game over or log file change
+	jobs.RegisterStaleMatchCloser(app, app.Config.Presence.MatchIdleTimeout(), app.GetA2SSnapshots, app.Logger().With("component", "JOBS"))
 
 	// Register update checker cron job (every 30 minutes)
 	jobs.RegisterUpdateChecker(app, app.Config, app.Logger())
@@ -285,22 +403,68 @@ func (app *App) onServe(e *core.ServeEvent) error {
 	return e.Next()
 }
 
+// shutdownDrainTimeout bounds how long shutdown waits for in-flight events and score updates
+const shutdownDrainTimeout = 15 * time.Second
+
 // onTerminate is called when the application shuts down
 func (app *App) onTerminate(e *core.TerminateEvent) error {
-	// Cleanup resources
-	if app.Watcher != nil {
-		app.Watcher.Stop()
-	}
+	// Finish in-flight work while the database and RCON connections are still open
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownDrainTimeout)
+	defer cancel()
+	app.drain(ctx)
 
 	if app.RconPool != nil {
 		app.RconPool.CloseAll()
 	}
 
+	if err := app.geoIP.Close(); err != nil {
+		app.Logger().Warn("Failed to close GeoIP database", "component", "APP", "error", err)
+	}
+
 	// Note: ServerManager plugin handles its own cleanup via OnTerminate hook
 
 	return e.Next()
 }
 
+// drain stops reading new log lines, then saves the events waiting for a retry and runs the
+// pending score updates. Lines not read yet are picked up from the saved offset on the next
+// start. Gives up when ctx is done, so a stuck database or RCON cannot hold up shutdown.
+func (app *App) drain(ctx context.Context) {
+	logger := app.Logger().With("component", "APP")
+
+	// The debouncer's timers are stopped however the drain ends, so none fires during shutdown
+	if app.scoreDebouncer != nil {
+		defer app.scoreDebouncer.Stop()
+	}
+
+	if app.Watcher != nil {
+		stopped := make(chan struct{})
+		go func() {
+			app.Watcher.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			logger.Warn("Timed out waiting for log processing to stop")
+			return
+		}
+	}
+
+	if app.Parser != nil {
+		if err := app.Parser.FlushEvents(ctx); err != nil {
+			logger.Warn("Shutting down with events still waiting for a retry", "error", err)
+			return
+		}
+	}
+
+	if app.scoreDebouncer != nil {
+		if err := app.scoreDebouncer.Flush(ctx); err != nil {
+			logger.Warn("Shutting down with score updates still running", "error", err)
+		}
+	}
+}
+
 // Custom application methods
 
 // SendRconCommand sends an RCON command to a specific server
@@ -312,6 +476,61 @@ func (app *App) SendRconCommand(serverID string, command string) (string, error)
 	return app.RconPool.SendCommand(serverID, command)
 }
 
+// BroadcastRconCommand sends an RCON command to every configured server concurrently
+// Returns the result per server ID (nil error on success)
+func (app *App) BroadcastRconCommand(command string) map[string]error {
+	if app.RconPool == nil {
+		return map[string]error{}
+	}
+
+	return app.RconPool.BroadcastCommand(command)
+}
+
+// IsRconCommandAllowed checks a web console command against the rconConsole allow/deny lists
+func (app *App) IsRconCommandAllowed(command string) bool {
+	return app.Config.RconConsole.IsCommandAllowed(command)
+}
+
+// GetAdminCommands returns the in-game admin command configuration
+func (app *App) GetAdminCommands() config.AdminCommandsConfig {
+	return app.Config.AdminCommands
+}
+
+// GetGeoIP returns the GeoIP database, or nil when none is configured
+func (app *App) GetGeoIP() *geoip.Resolver {
+	return app.geoIP
+}
+
+// GetModerationConfig returns the moderation report configuration
+func (app *App) GetModerationConfig() config.ModerationConfig {
+	return app.Config.Moderation
+}
+
+// GetMVPConfig returns how match MVPs are picked
+func (app *App) GetMVPConfig() config.MVPConfig {
+	return app.Config.MVP
+}
+
+// GetRankedConfig returns which matches count towards lifetime stats
+func (app *App) GetRankedConfig() config.RankedConfig {
+	return app.Config.Ranked
+}
+
+// GetSAWPath returns the Sandstorm Admin Wrapper install, empty when servers are set up manually
+func (app *App) GetSAWPath() string {
+	return app.Config.SAWPath
+}
+
+// GetRateLimitConfig returns the per-IP HTTP rate limit
+func (app *App) GetRateLimitConfig() config.RateLimitConfig {
+	return app.Config.RateLimit
+}
+
+// GetPresenceConfig returns the connection and match idle timeouts
+func (app *App) GetPresenceConfig() config.PresenceConfig {
+	return app.Config.Presence
+}
+
 // GetEnabledServers returns all enabled servers from config
 func (app *App) GetEnabledServers() []config.ServerConfig {
 	var enabled []config.ServerConfig
@@ -333,7 +552,25 @@ func (app *App) GetServerByName(name string) (*config.ServerConfig, error) {
 	return nil, fmt.Errorf("server '%s' not found in config", name)
 }
 
-// GetRconPoolStatus returns the current status of the RCON pool
+// GetRconPoolHealth returns how many servers in the RCON pool are healthy, for the public health check
+func (app *App) GetRconPoolHealth() map[string]any {
+	if app.RconPool == nil {
+		return map[string]any{
+			"available": false,
+		}
+	}
+
+	healthy, unhealthy := app.RconPool.HealthCounts()
+	return map[string]any{
+		"available":     true,
+		"total_servers": healthy + unhealthy,
+		"healthy":       healthy,
+		"unhealthy":     unhealthy,
+	}
+}
+
+// GetRconPoolStatus returns the current status of the RCON pool, with each server's address and
+// last error (superusers only)
 func (app *App) GetRconPoolStatus() map[string]any {
 	if app.RconPool == nil {
 		return map[string]any{
@@ -354,6 +591,7 @@ func (app *App) GetRconPoolStatus() map[string]any {
 		"total_servers":     len(servers),
 		"connected_servers": len(connectedServers),
 		"connected_list":    connectedServers,
+		"servers":           app.RconPool.Status(),
 	}
 }
 
@@ -362,6 +600,80 @@ func (app *App) GetA2SPool() *a2s.ServerPool {
 	return app.A2SPool
 }
 
+// GetIngestStats returns the log parser's line counters and latest unmatched lines
+func (app *App) GetIngestStats() parser.IngestStats {
+	if app.Parser == nil {
+		return parser.IngestStats{Matched: map[string]int64{}, RecentUnmatched: []parser.UnmatchedLine{}}
+	}
+	return app.Parser.IngestStats()
+}
+
+// GetServerSettings returns the cached A2S rules snapshot for a server, or nil if none is available
+func (app *App) GetServerSettings(serverID string) map[string]string {
+	server := app.a2sServers()[serverID]
+	if server == nil {
+		return nil
+	}
+	return server.GetLastRules()
+}
+
+// GetA2SSnapshots returns the cached A2S snapshot of every pooled server, keyed by server ID.
+// Nothing is queried; the snapshots are as fresh as the last scheduled query.
+func (app *App) GetA2SSnapshots() map[string]a2s.Snapshot {
+	snapshots := make(map[string]a2s.Snapshot)
+	for serverID, server := range app.a2sServers() {
+		snapshots[serverID] = server.Snapshot()
+	}
+	return snapshots
+}
+
+// a2sServers returns the A2S pool entry of each enabled server, from the config or the servers
+// collection, keyed by server ID
+func (app *App) a2sServers() map[string]*a2s.Server {
+	servers := make(map[string]*a2s.Server)
+	if app.A2SPool == nil || app.Config == nil {
+		return servers
+	}
+
+	for _, sc := range app.Config.Servers {
+		if !sc.Enabled {
+			continue
+		}
+		serverID, err := util.GetServerIdFromPath(sc.LogPath)
+		if err != nil {
+			continue
+		}
+
+		queryAddr := sc.RconAddress
+		if sc.QueryAddress != "" {
+			queryAddr = sc.QueryAddress
+		}
+		if server, err := app.A2SPool.GetServer(queryAddr); err == nil {
+			servers[serverID] = server
+		}
+	}
+
+	// Servers added through the API carry their addresses on the record
+	records, err := app.FindRecordsByFilter("servers", "enabled = true && (query_address != '' || rcon_address != '')", "", 0, 0)
+	if err != nil {
+		return servers
+	}
+	for _, record := range records {
+		serverID := record.GetString("external_id")
+		if _, ok := servers[serverID]; ok {
+			continue
+		}
+		queryAddr := record.GetString("query_address")
+		if queryAddr == "" {
+			queryAddr = record.GetString("rcon_address")
+		}
+		if server, err := app.A2SPool.GetServer(queryAddr); err == nil {
+			servers[serverID] = server
+		}
+	}
+	return servers
+}
+
 func (app *App) Logger() *slog.Logger {
 	if app.customLogger != nil {
 		return app.customLogger
@@ -384,6 +696,20 @@ func (app *App) setupLogger() error {
 	// Use config values or defaults
 	logCfg := app.Config.Logging
 
+	// Console logs in the configured format. In --dev PocketBase already prints its logs as text.
+	level, err := logger.ParseLevel(logCfg.Level)
+	if err != nil {
+		return err
+	}
+	handlers := []slog.Handler{&pocketBaseHandler{app: app.PocketBase}}
+	if !app.IsDev() || strings.EqualFold(logCfg.Format, "json") {
+		console := logger.NewHandler(os.Stderr, logCfg.Format, level)
+		handlers = append(handlers, console)
+		// Anything logged through slog.Default or the log package uses the same format
+		slog.SetDefault(slog.New(console))
+	}
+	app.customLogger = slog.New(logger.NewTeeHandler(handlers...))
+
 	// Generate date-based log filename: sandstorm-tracker.2025-11-21.log
 	logFilePath := fmt.Sprintf("logs/sandstorm-tracker.%s.log", time.Now().Format("2006-01-02"))
 
@@ -394,8 +720,9 @@ func (app *App) setupLogger() error {
 		MaxBackups: logCfg.MaxBackups,
 	}
 
-	// Cleanup hook: close file writer on app termination
+	// Cleanup hook: close file writer on app termination, after the other hooks have logged their shutdown
 	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		err := e.Next()
 		writer := app.Store().Get("logger:filewriter")
 		if writer != nil {
 			fw := writer.(*logger.FileWriter)
@@ -403,7 +730,7 @@ func (app *App) setupLogger() error {
 				app.Logger().Error("Failed to close log file writer", "component", "APP", "error", err)
 			}
 		}
-		return e.Next()
+		return err
 	})
 
 	// Create and store file writer (singleton)
@@ -446,6 +773,38 @@ func (app *App) setupLogger() error {
 	return nil
 }
 
+// pocketBaseHandler passes records on to PocketBase's logger, which stores them in the logs
+// collection shown in the dashboard. PocketBase only sets up its logger when it bootstraps, so
+// records logged before then are left to the console.
+type pocketBaseHandler struct {
+	app   *pocketbase.PocketBase
+	attrs []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, applied in order
+}
+
+func (h *pocketBaseHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.app.IsBootstrapped() && h.app.Logger().Handler().Enabled(ctx, level)
+}
+
+func (h *pocketBaseHandler) Handle(ctx context.Context, record slog.Record) error {
+	handler := h.app.Logger().Handler()
+	for _, with := range h.attrs {
+		handler = with(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *pocketBaseHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *pocketBaseHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *pocketBaseHandler) with(apply func(slog.Handler) slog.Handler) slog.Handler {
+	return &pocketBaseHandler{app: h.app, attrs: append(slices.Clip(h.attrs), apply)}
+}
+
 // logLevelToString converts PocketBase log level to human-readable string
 func logLevelToString(level int) string {
 	switch level {
@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/parser"
+	"sandstorm-tracker/internal/rcon"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Metrics is a minimal registry of counters and scrape-time gauges,
+// rendered in the Prometheus text exposition format
+type Metrics struct {
+	mu      sync.Mutex
+	metrics []*metric // Kept in registration order
+}
+
+// MetricSample is one labeled value of a metric
+type MetricSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+type metric struct {
+	name    string
+	help    string
+	kind    string                // "counter" or "gauge"
+	values  map[string]float64    // Rendered label set -> value, for counters
+	collect func() []MetricSample // Computed on every scrape, for gauges
+}
+
+// Counter is a monotonically increasing metric, optionally split by labels
+type Counter struct {
+	registry *Metrics
+	metric   *metric
+}
+
+// NewMetrics creates an empty metrics registry
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// Counter registers a counter
+func (m *Metrics) Counter(name, help string) *Counter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &metric{name: name, help: help, kind: "counter", values: make(map[string]float64)}
+	m.metrics = append(m.metrics, entry)
+	return &Counter{registry: m, metric: entry}
+}
+
+// GaugeFunc registers a gauge whose samples are collected on every scrape
+func (m *Metrics) GaugeFunc(name, help string, collect func() []MetricSample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.metrics = append(m.metrics, &metric{name: name, help: help, kind: "gauge", collect: collect})
+}
+
+// CounterFunc registers a counter whose samples are collected on every scrape, for counts kept elsewhere
+func (m *Metrics) CounterFunc(name, help string, collect func() []MetricSample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.metrics = append(m.metrics, &metric{name: name, help: help, kind: "counter", collect: collect})
+}
+
+// Inc increments the counter for the given label pairs ("key", "value", ...)
+func (c *Counter) Inc(labelPairs ...string) {
+	labels := make(map[string]string, len(labelPairs)/2)
+	for i := 0; i+1 < len(labelPairs); i += 2 {
+		labels[labelPairs[i]] = labelPairs[i+1]
+	}
+
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	c.metric.values[formatLabels(labels)]++
+}
+
+// WriteMetrics writes every registered metric in the Prometheus text format
+func (m *Metrics) WriteMetrics(w io.Writer) error {
+	m.mu.Lock()
+	metrics := make([]*metric, len(m.metrics))
+	copy(metrics, m.metrics)
+	counterValues := make(map[*metric]map[string]float64)
+	for _, entry := range metrics {
+		if entry.kind == "counter" {
+			values := make(map[string]float64, len(entry.values))
+			for labels, value := range entry.values {
+				values[labels] = value
+			}
+			counterValues[entry] = values
+		}
+	}
+	m.mu.Unlock()
+
+	var b strings.Builder
+	for _, entry := range metrics {
+		// Collected metrics are read outside the lock, they may query the database or pools
+		values := counterValues[entry]
+		if entry.collect != nil {
+			values = make(map[string]float64)
+			for _, sample := range entry.collect() {
+				values[formatLabels(sample.Labels)] = sample.Value
+			}
+		}
+
+		fmt.Fprintf(&b, "# HELP %s %s\n", entry.name, entry.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", entry.name, entry.kind)
+
+		// A counter is reported as 0 before its first increment
+		if entry.kind == "counter" && len(values) == 0 {
+			fmt.Fprintf(&b, "%s 0\n", entry.name)
+		}
+
+		labelSets := make([]string, 0, len(values))
+		for labels := range values {
+			labelSets = append(labelSets, labels)
+		}
+		sort.Strings(labelSets)
+		for _, labels := range labelSets {
+			fmt.Fprintf(&b, "%s%s %g\n", entry.name, labels, values[labels])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels renders a label set as {key="value",...} with keys sorted, or "" when empty
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, key, escaper.Replace(labels[key]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// errorCountingHandler is a slog handler that counts error records before passing them on
+type errorCountingHandler struct {
+	slog.Handler
+	counter *Counter
+}
+
+func (h *errorCountingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError {
+		h.counter.Inc()
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *errorCountingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &errorCountingHandler{Handler: h.Handler.WithAttrs(attrs), counter: h.counter}
+}
+
+func (h *errorCountingHandler) WithGroup(name string) slog.Handler {
+	return &errorCountingHandler{Handler: h.Handler.WithGroup(name), counter: h.counter}
+}
+
+// countErrors returns a logger that increments counter for every error it logs
+func countErrors(logger *slog.Logger, counter *Counter) *slog.Logger {
+	return slog.New(&errorCountingHandler{Handler: logger.Handler(), counter: counter})
+}
+
+// collectOnlinePlayers reports the player count of every server from its last A2S query
+func collectOnlinePlayers(pool *a2s.ServerPool) []MetricSample {
+	if pool == nil {
+		return nil
+	}
+
+	var samples []MetricSample
+	for _, address := range pool.ListServers() {
+		server, err := pool.GetServer(address)
+		if err != nil {
+			continue
+		}
+		info, err := server.GetLastInfo()
+		if err != nil || info == nil {
+			continue
+		}
+		samples = append(samples, MetricSample{
+			Labels: map[string]string{"server": server.Name, "address": address},
+			Value:  float64(info.Players),
+		})
+	}
+	return samples
+}
+
+// collectActiveMatches reports the number of matches that have not ended
+func collectActiveMatches(pbApp core.App) []MetricSample {
+	var count int
+	err := pbApp.DB().
+		NewQuery("SELECT COUNT(*) FROM matches WHERE end_time = '' OR end_time IS NULL").
+		Row(&count)
+	if err != nil {
+		return nil
+	}
+	return []MetricSample{{Value: float64(count)}}
+}
+
+// collectRconHealth reports 1 for every RCON server with a live connection and 0 otherwise
+func collectRconHealth(pool *rcon.ClientPool) []MetricSample {
+	if pool == nil {
+		return nil
+	}
+
+	var samples []MetricSample
+	for _, serverID := range pool.ListServers() {
+		connected := 0.0
+		if pool.IsConnected(serverID) {
+			connected = 1
+		}
+		samples = append(samples, MetricSample{
+			Labels: map[string]string{"server": serverID},
+			Value:  connected,
+		})
+	}
+	return samples
+}
+
+// collectDroppedEvents reports the events the parser gave up on, by event type
+func collectDroppedEvents(p *parser.LogParser) []MetricSample {
+	if p == nil {
+		return nil
+	}
+
+	var samples []MetricSample
+	for eventType, count := range p.DroppedEvents() {
+		samples = append(samples, MetricSample{
+			Labels: map[string]string{"type": eventType},
+			Value:  float64(count),
+		})
+	}
+	return samples
+}
+
+// collectIngestLines reports the log lines the parser has processed, by outcome and line type
+func collectIngestLines(p *parser.LogParser) []MetricSample {
+	if p == nil {
+		return nil
+	}
+
+	stats := p.IngestStats()
+	// Lines without a timestamp (continuations, banners) are neither matched nor unmatched
+	skipped := stats.LinesProcessed - stats.Unmatched - stats.ParseErrors
+	samples := []MetricSample{
+		{Labels: map[string]string{"outcome": "unmatched"}, Value: float64(stats.Unmatched)},
+		{Labels: map[string]string{"outcome": "parse_error"}, Value: float64(stats.ParseErrors)},
+	}
+	for lineType, count := range stats.Matched {
+		skipped -= count
+		samples = append(samples, MetricSample{
+			Labels: map[string]string{"outcome": "matched", "type": lineType},
+			Value:  float64(count),
+		})
+	}
+	samples = append(samples, MetricSample{Labels: map[string]string{"outcome": "skipped"}, Value: float64(max(skipped, 0))})
+	return samples
+}
+
+// setupMetrics registers the application metrics.
+// Event and parser error counters are fed by record hooks and the parser's logger;
+// everything else is read from the database and pools on every scrape.
+func (app *App) setupMetrics() {
+	app.metrics = NewMetrics()
+
+	app.metrics.GaugeFunc("sandstorm_server_players_online",
+		"Players online per server from the last A2S query",
+		func() []MetricSample { return collectOnlinePlayers(app.A2SPool) })
+
+	app.metrics.GaugeFunc("sandstorm_active_matches",
+		"Matches that have started and not yet ended",
+		func() []MetricSample { return collectActiveMatches(app) })
+
+	app.metrics.GaugeFunc("sandstorm_rcon_connected",
+		"Whether the RCON pool holds a live connection to the server (1) or not (0)",
+		func() []MetricSample { return collectRconHealth(app.RconPool) })
+
+	eventsProcessed := app.metrics.Counter("sandstorm_events_processed_total",
+		"Game events recorded, by event type")
+	app.OnRecordAfterCreateSuccess("events").BindFunc(func(e *core.RecordEvent) error {
+		eventsProcessed.Inc("type", e.Record.GetString("type"))
+		return e.Next()
+	})
+
+	app.parserErrors = app.metrics.Counter("sandstorm_parser_errors_total",
+		"Errors logged while parsing and recording log lines")
+
+	app.metrics.CounterFunc("sandstorm_log_lines_total",
+		"Log lines parsed, by outcome (matched by line type, unmatched, parse_error, or skipped without a timestamp)",
+		func() []MetricSample { return collectIngestLines(app.Parser) })
+
+	app.metrics.CounterFunc("sandstorm_events_dropped_total",
+		"Game events dropped after saving them kept failing, by event type",
+		func() []MetricSample { return collectDroppedEvents(app.Parser) })
+}
+
+// WriteMetrics writes the application metrics in the Prometheus text format
+func (app *App) WriteMetrics(w io.Writer) error {
+	if app.metrics == nil {
+		return fmt.Errorf("metrics not initialized")
+	}
+	return app.metrics.WriteMetrics(w)
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// metricsTestApp is a test app that serves a metrics registry
+type metricsTestApp struct {
+	*tests.TestApp
+	metrics *Metrics
+}
+
+func (m *metricsTestApp) SendRconCommand(serverID string, command string) (string, error) {
+	return "", nil
+}
+
+func (m *metricsTestApp) WriteMetrics(w io.Writer) error {
+	return m.metrics.WriteMetrics(w)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	if _, err := database.GetOrCreateServer(ctx, baseApp, "metrics-server", "Metrics Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if _, err := database.CreateMatch(ctx, baseApp, "metrics-server", nil, nil, nil); err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		metrics := NewMetrics()
+		metrics.GaugeFunc("sandstorm_active_matches", "Matches that have started and not yet ended",
+			func() []MetricSample { return collectActiveMatches(app) })
+
+		events := metrics.Counter("sandstorm_events_processed_total", "Game events recorded, by event type")
+		events.Inc("type", "player_kill")
+		events.Inc("type", "player_kill")
+		events.Inc("type", `say "hi"`)
+
+		metrics.Counter("sandstorm_parser_errors_total", "Errors logged while parsing and recording log lines")
+
+		handlers.Register(&metricsTestApp{TestApp: app, metrics: metrics}, e)
+	}
+
+	scenario := tests.ApiScenario{
+		Name:           "metrics are exposed in the prometheus text format",
+		Method:         http.MethodGet,
+		URL:            "/metrics",
+		ExpectedStatus: http.StatusOK,
+		ExpectedContent: []string{
+			"# TYPE sandstorm_active_matches gauge\nsandstorm_active_matches 1\n",
+			"# HELP sandstorm_events_processed_total Game events recorded, by event type\n",
+			`sandstorm_events_processed_total{type="player_kill"} 2`,
+			`sandstorm_events_processed_total{type="say \"hi\""} 1`,
+			"sandstorm_parser_errors_total 0\n",
+		},
+		TestAppFactory: setup,
+		BeforeTestFunc: registerRoutes,
+	}
+	scenario.Test(t)
+}
+
+func TestCountErrors(t *testing.T) {
+	metrics := NewMetrics()
+	counter := metrics.Counter("sandstorm_parser_errors_total", "Errors logged while parsing and recording log lines")
+	logger := countErrors(slog.New(slog.NewTextHandler(io.Discard, nil)), counter).With("component", "PARSER")
+
+	logger.Info("Parsed line")
+	logger.Error("Failed to create kill event")
+	logger.WithGroup("kill").Error("Failed to create kill event")
+
+	var b bytes.Buffer
+	if err := metrics.WriteMetrics(&b); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	if want := "sandstorm_parser_errors_total 2\n"; !bytes.Contains(b.Bytes(), []byte(want)) {
+		t.Errorf("expected %q in output:\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/loader"
+	"sandstorm-tracker/internal/util"
+
+	"github.com/spf13/cobra"
+)
+
+// newReplayCommand creates the replay command, which backfills the database from a historical log file
+func (app *App) newReplayCommand() *cobra.Command {
+	var filePath, serverID, since string
+	var offset int64
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay a server log file into the database",
+		Long: `Replay a historical server log file into the database.
+
+Lines are processed in catchup mode: stats are recorded, but no RCON commands
+are sent and no score updates are scheduled. With --since, only the slice from
+the last map event before that time is replayed. With --offset, lines from that
+byte offset are replayed into the match of the last map event before it.`,
+		Example: `  sandstorm-tracker replay --file logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde.log
+  sandstorm-tracker replay --file old.log --server 1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde --since 2025-11-10T21:00:00Z
+  sandstorm-tracker replay --file logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde.log --offset 524288`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Server ID defaults to the log file name, like configured servers
+			if serverID == "" {
+				id, err := util.GetServerIdFromPath(filePath)
+				if err != nil {
+					return fmt.Errorf("failed to get server ID from path: %w", err)
+				}
+				serverID = id
+			}
+
+			var sinceTime time.Time
+			if since != "" {
+				t, err := time.Parse(time.RFC3339, since)
+				if err != nil {
+					return fmt.Errorf("invalid --since time (expected RFC3339): %w", err)
+				}
+				sinceTime = t
+			}
+
+			// Migrations normally run on serve
+			if err := app.RunAllMigrations(); err != nil {
+				return fmt.Errorf("failed to run migrations: %w", err)
+			}
+
+			// No score debouncer - scores are not updated during replay
+			handlers.NewGameEventHandlers(app, nil).RegisterHooks()
+
+			var result *loader.ReplayResult
+			var err error
+			if cmd.Flags().Changed("offset") {
+				result, err = loader.ReplayLogFileFrom(context.Background(), app, app.Parser, filePath, serverID, offset)
+			} else {
+				result, err = loader.ReplayLogFile(context.Background(), app, app.Parser, filePath, serverID, sinceTime)
+			}
+			if err != nil {
+				return err
+			}
+
+			if result.StartLine > 0 {
+				fmt.Printf("Replayed %d lines from %s (starting on %s at line %d) into server %s\n",
+					result.LinesProcessed, filePath, result.Map, result.StartLine+1, serverID)
+			} else {
+				fmt.Printf("Replayed %d lines from %s into server %s\n", result.LinesProcessed, filePath, serverID)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&filePath, "file", "", "path to the log file to replay")
+	cmd.Flags().StringVar(&serverID, "server", "", "server ID to record events under (defaults to the log file name)")
+	cmd.Flags().StringVar(&since, "since", "", "only replay from the last map event before this RFC3339 time")
+	cmd.Flags().Int64Var(&offset, "offset", 0, "only replay from this byte offset, which must start a line (plaintext logs only)")
+	cmd.MarkFlagRequired("file")
+	cmd.MarkFlagsMutuallyExclusive("since", "offset")
+
+	return cmd
+}
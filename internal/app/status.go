@@ -0,0 +1,263 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/rcon"
+	"sandstorm-tracker/internal/util"
+
+	"github.com/spf13/cobra"
+)
+
+// statusTarget is a server the status command queries
+type statusTarget struct {
+	ID           string
+	Name         string
+	QueryAddress string
+	RconAddress  string
+	RconPassword string
+	RconTimeout  time.Duration
+}
+
+// serverStatusRow is one server's line in the status command's output
+type serverStatusRow struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	QueryAddress string `json:"query_address"`
+	Online       bool   `json:"online"`
+	Map          string `json:"map,omitempty"`
+	Players      int    `json:"players"`
+	MaxPlayers   int    `json:"max_players"`
+	PingMs       int64  `json:"ping_ms"`
+	Rcon         string `json:"rcon,omitempty"` // "ok" or "failed"; empty when RCON is not configured
+	RconError    string `json:"rcon_error,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// newStatusCommand creates the status command, which queries every configured server once and prints what it finds
+func (app *App) newStatusCommand() *cobra.Command {
+	var asJSON bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Query all configured servers and print their status",
+		Long: `Query every enabled server over A2S (and RCON, when configured) and print
+its name, map, players, ping and whether it is online.
+
+Servers come from the config file (including SAW configs) and from the servers
+collection. All servers are queried at the same time.`,
+		Example: `  sandstorm-tracker status
+  sandstorm-tracker status --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Migrations normally run on serve
+			if err := app.RunAllMigrations(); err != nil {
+				return fmt.Errorf("failed to run migrations: %w", err)
+			}
+
+			targets := app.statusTargets()
+			if len(targets) == 0 {
+				return fmt.Errorf("no enabled servers with a query or RCON address are configured")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			rows := queryServerStatuses(ctx, targets, timeout)
+
+			if asJSON {
+				return writeStatusJSON(os.Stdout, rows)
+			}
+			return writeStatusTable(os.Stdout, rows)
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the status as JSON")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "how long to wait for each server")
+
+	return cmd
+}
+
+// statusTargets collects the enabled servers from the config and from the servers collection.
+// A server in both is queried once, with the config's addresses.
+func (app *App) statusTargets() []statusTarget {
+	var targets []statusTarget
+	seen := make(map[string]bool)
+
+	for _, sc := range app.Config.Servers {
+		if !sc.Enabled {
+			continue
+		}
+		serverID, err := util.GetServerIdFromPath(sc.LogPath)
+		if err != nil {
+			continue
+		}
+
+		target := statusTarget{
+			ID:           serverID,
+			Name:         sc.Name,
+			QueryAddress: sc.QueryAddress,
+			RconAddress:  sc.RconAddress,
+			RconPassword: sc.RconPassword,
+			RconTimeout:  rconTimeout(sc.RconTimeout),
+		}
+		if target.QueryAddress == "" {
+			target.QueryAddress = sc.RconAddress
+		}
+		if target.QueryAddress == "" {
+			continue
+		}
+		seen[serverID] = true
+		targets = append(targets, target)
+	}
+
+	// Servers added through the API carry their addresses on the record
+	records, err := app.FindRecordsByFilter("servers", "enabled = true && (query_address != '' || rcon_address != '')", "", 0, 0)
+	if err != nil {
+		return targets
+	}
+	for _, record := range records {
+		serverID := record.GetString("external_id")
+		if seen[serverID] {
+			continue
+		}
+		target := statusTarget{
+			ID:           serverID,
+			Name:         record.GetString("name"),
+			QueryAddress: record.GetString("query_address"),
+			RconAddress:  record.GetString("rcon_address"),
+			RconPassword: record.GetString("rcon_password"),
+			RconTimeout:  rconTimeout(0), // Records have no timeout of their own
+		}
+		if target.QueryAddress == "" {
+			target.QueryAddress = target.RconAddress
+		}
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// rconTimeout returns the RCON timeout of a server configured with the given seconds,
+// defaulting to 5 seconds when it has none
+func rconTimeout(seconds int) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 5 * time.Second
+}
+
+// queryServerStatuses queries every target over A2S, and over RCON when it has a password,
+// concurrently. Rows are sorted by server name.
+func queryServerStatuses(ctx context.Context, targets []statusTarget, timeout time.Duration) []serverStatusRow {
+	pool := a2s.NewServerPoolWithClient(a2s.NewClient(a2s.WithTimeout(timeout)))
+	rconPool := rcon.NewClientPool(nil)
+	defer rconPool.CloseAll()
+
+	for _, target := range targets {
+		pool.AddServer(target.QueryAddress, target.Name)
+		if target.RconAddress != "" && target.RconPassword != "" {
+			rconPool.AddServer(target.ID, &rcon.ServerConfig{
+				Address:  target.RconAddress,
+				Password: target.RconPassword,
+				Timeout:  target.RconTimeout,
+			})
+		}
+	}
+
+	// RCON runs alongside the A2S queries rather than after them
+	rconErrors := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, serverID := range rconPool.ListServers() {
+		wg.Add(1)
+		go func(serverID string) {
+			defer wg.Done()
+			_, err := rconPool.SendCommand(serverID, "listplayers")
+			mu.Lock()
+			rconErrors[serverID] = err
+			mu.Unlock()
+		}(serverID)
+	}
+
+	statuses := pool.QueryAll(ctx)
+	wg.Wait()
+
+	rows := make([]serverStatusRow, 0, len(targets))
+	for _, target := range targets {
+		row := serverStatusRow{
+			ID:           target.ID,
+			Name:         target.Name,
+			QueryAddress: target.QueryAddress,
+		}
+
+		if status := statuses[target.QueryAddress]; status != nil {
+			if status.Online && status.Info != nil {
+				row.Online = true
+				row.Map = status.Info.Map
+				row.MaxPlayers = int(status.Info.MaxPlayers)
+				row.PingMs = status.QueryTime.Milliseconds()
+				// The player list is more reliable than the count in the server info
+				row.Players = int(status.Info.Players)
+				if status.Players != nil {
+					row.Players = len(status.Players)
+				}
+			} else if status.Error != nil {
+				row.Error = status.Error.Error()
+			}
+		}
+
+		if err, ok := rconErrors[target.ID]; ok {
+			row.Rcon = "ok"
+			if err != nil {
+				row.Rcon = "failed"
+				row.RconError = err.Error()
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Name != rows[j].Name {
+			return rows[i].Name < rows[j].Name
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows
+}
+
+// writeStatusTable prints the rows as an aligned table
+func writeStatusTable(w io.Writer, rows []serverStatusRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tMAP\tPLAYERS\tPING\tONLINE\tRCON")
+	for _, row := range rows {
+		mapName, players, ping, online, rconState := "-", "-", "-", "no", "-"
+		if row.Online {
+			mapName = row.Map
+			players = fmt.Sprintf("%d/%d", row.Players, row.MaxPlayers)
+			ping = fmt.Sprintf("%dms", row.PingMs)
+			online = "yes"
+		}
+		if row.Rcon != "" {
+			rconState = row.Rcon
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", row.Name, mapName, players, ping, online, rconState)
+	}
+	return tw.Flush()
+}
+
+// writeStatusJSON prints the rows as a JSON array for scripts
+func writeStatusJSON(w io.Writer, rows []serverStatusRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/a2s/a2stest"
+)
+
+// closedTCPAddress returns an address nothing is listening on
+func closedTCPAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func TestQueryServerStatuses(t *testing.T) {
+	bravoServer := a2stest.NewServer(t, a2stest.Config{
+		Info: a2s.ServerInfo{Name: "Bravo", Map: "Town", MaxPlayers: 28},
+	})
+	alphaServer := a2stest.NewServer(t, a2stest.Config{
+		Info:    a2s.ServerInfo{Name: "Alpha", Map: "Farmhouse", Players: 2, MaxPlayers: 28},
+		Players: []a2s.Player{{Index: 0, Name: "ArmoredBear"}, {Index: 1, Name: "Rabbit"}},
+	})
+
+	targets := []statusTarget{
+		{
+			ID:           "bravo-id",
+			Name:         "Bravo",
+			QueryAddress: bravoServer.Addr(),
+			RconAddress:  closedTCPAddress(t),
+			RconPassword: "secret",
+			RconTimeout:  time.Second,
+		},
+		{
+			ID:           "alpha-id",
+			Name:         "Alpha",
+			QueryAddress: alphaServer.Addr(),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rows := queryServerStatuses(ctx, targets, time.Second)
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	alpha, bravo := rows[0], rows[1]
+	if alpha.Name != "Alpha" || bravo.Name != "Bravo" {
+		t.Fatalf("Expected rows sorted by name, got %q then %q", alpha.Name, bravo.Name)
+	}
+	if !alpha.Online || alpha.Map != "Farmhouse" || alpha.Players != 2 || alpha.MaxPlayers != 28 {
+		t.Errorf("Unexpected status for Alpha: %+v", alpha)
+	}
+	if alpha.Rcon != "" {
+		t.Errorf("Expected no RCON status for Alpha without RCON configured, got %q", alpha.Rcon)
+	}
+	if !bravo.Online || bravo.Map != "Town" || bravo.Players != 0 {
+		t.Errorf("Unexpected status for Bravo: %+v", bravo)
+	}
+	if bravo.Rcon != "failed" || bravo.RconError == "" {
+		t.Errorf("Expected Bravo's RCON to fail, got %q (%s)", bravo.Rcon, bravo.RconError)
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := writeStatusTable(&out, rows); err != nil {
+			t.Fatalf("writeStatusTable() error = %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected a header and 2 rows, got:\n%s", out.String())
+		}
+		want := [][]string{
+			{"NAME", "MAP", "PLAYERS", "PING", "ONLINE", "RCON"},
+			{"Alpha", "Farmhouse", "2/28", "", "yes", "-"},
+			{"Bravo", "Town", "0/28", "", "yes", "failed"},
+		}
+		for i, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) != len(want[i]) {
+				t.Fatalf("line %d: expected %d columns, got %q", i, len(want[i]), line)
+			}
+			for j, field := range want[i] {
+				// Ping varies between runs
+				if field == "" {
+					if !strings.HasSuffix(fields[j], "ms") {
+						t.Errorf("line %d: expected a ping in ms, got %q", i, fields[j])
+					}
+					continue
+				}
+				if fields[j] != field {
+					t.Errorf("line %d column %d: expected %q, got %q", i, j, field, fields[j])
+				}
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := writeStatusJSON(&out, rows); err != nil {
+			t.Fatalf("writeStatusJSON() error = %v", err)
+		}
+
+		var decoded []map[string]any
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+		}
+		if len(decoded) != 2 {
+			t.Fatalf("Expected 2 servers, got %d", len(decoded))
+		}
+		if decoded[0]["id"] != "alpha-id" || decoded[0]["map"] != "Farmhouse" || decoded[0]["players"] != float64(2) || decoded[0]["online"] != true {
+			t.Errorf("Unexpected JSON for Alpha: %v", decoded[0])
+		}
+		if _, ok := decoded[0]["rcon"]; ok {
+			t.Errorf("Expected no rcon key for Alpha, got %v", decoded[0]["rcon"])
+		}
+		if decoded[1]["id"] != "bravo-id" || decoded[1]["rcon"] != "failed" || decoded[1]["max_players"] != float64(28) {
+			t.Errorf("Unexpected JSON for Bravo: %v", decoded[1])
+		}
+	})
+}
+
+func TestRconTimeout(t *testing.T) {
+	// Servers without a timeout of their own, like those added through the API, get the default
+	if got := rconTimeout(0); got != 5*time.Second {
+		t.Errorf("rconTimeout(0) = %v, want 5s", got)
+	}
+	if got := rconTimeout(12); got != 12*time.Second {
+		t.Errorf("rconTimeout(12) = %v, want 12s", got)
+	}
+}
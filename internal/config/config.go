@@ -4,7 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sandstorm-tracker/assets"
+	"sandstorm-tracker/internal/logger"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/spf13/viper"
@@ -17,20 +23,552 @@ type ServerConfig struct {
 	RconPassword string `mapstructure:"rconPassword"`
 	RconTimeout  int    `mapstructure:"rconTimeout"` // timeout in seconds, default 5
 	QueryAddress string `mapstructure:"queryAddress"`
+	LogTimezone  string `mapstructure:"logTimezone"` // IANA zone the game server writes log timestamps in (default: LOG_TIMEZONE env, then local)
+	Group        string `mapstructure:"group"`       // Optional group (region, community, mode) the UI can filter by
 	Enabled      bool   `mapstructure:"enabled"`
+
+	MapCycle MapCycleConfig `mapstructure:"mapCycle"` // Switch scenarios over RCON as the player count changes (opt-in)
+}
+
+// MapCycleConfig switches a server's scenario over RCON by player count and time of day, so a
+// near-empty server can move to a small map and a full one to a big map without editing
+// MapCycle.txt. Rules are checked in order every minute against the A2S player count; the first
+// that holds picks the scenarios, and the server travels to the next of them unless it is
+// already playing one.
+type MapCycleConfig struct {
+	Enabled         bool           `mapstructure:"enabled"`         // Manage this server's map (default: false)
+	CooldownMinutes int            `mapstructure:"cooldownMinutes"` // Least time between two switches (default: 15)
+	TravelCommand   string         `mapstructure:"travelCommand"`   // RCON command, {scenario} is replaced (default: "travelscenario {scenario}")
+	Rules           []MapCycleRule `mapstructure:"rules"`
+}
+
+// MapCycleRule is one player count and time window and the scenarios to play in it
+type MapCycleRule struct {
+	Name       string   `mapstructure:"name"`       // Shown in the logs, e.g. "quiet"
+	MinPlayers int      `mapstructure:"minPlayers"` // Fewest players the rule applies to (default: 0)
+	MaxPlayers *int     `mapstructure:"maxPlayers"` // Most players the rule applies to (default: no limit)
+	Hours      string   `mapstructure:"hours"`      // Hours of the day as "from-to" in the tracker's local time, e.g. "22-6" (default: all day)
+	Scenarios  []string `mapstructure:"scenarios"`  // Scenarios to rotate through, e.g. Scenario_Farmhouse_Checkpoint_Security
+}
+
+// validate checks an enabled map cycle has rules that can match and somewhere to travel to
+func (m MapCycleConfig) validate() error {
+	if !m.Enabled {
+		return nil
+	}
+	if len(m.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	for i, rule := range m.Rules {
+		if len(rule.Scenarios) == 0 {
+			return fmt.Errorf("rule %d has no scenarios", i)
+		}
+		if rule.MinPlayers < 0 || (rule.MaxPlayers != nil && *rule.MaxPlayers < rule.MinPlayers) {
+			return fmt.Errorf("rule %d has an invalid player range", i)
+		}
+		if rule.Hours != "" {
+			if _, _, err := rule.HourRange(); err != nil {
+				return fmt.Errorf("rule %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Cooldown returns the least time between two switches, defaulting to 15 minutes
+func (m MapCycleConfig) Cooldown() time.Duration {
+	minutes := m.CooldownMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// TravelCommandFor returns the RCON command that travels to scenario
+func (m MapCycleConfig) TravelCommandFor(scenario string) string {
+	command := m.TravelCommand
+	if command == "" {
+		command = "travelscenario {scenario}"
+	}
+	return strings.ReplaceAll(command, "{scenario}", scenario)
+}
+
+// Matches reports whether the rule applies to a server with players connected at now
+func (r MapCycleRule) Matches(players int, now time.Time) bool {
+	if players < r.MinPlayers || (r.MaxPlayers != nil && players > *r.MaxPlayers) {
+		return false
+	}
+	if r.Hours == "" {
+		return true
+	}
+	from, to, err := r.HourRange()
+	if err != nil {
+		return false
+	}
+	hour := now.Hour()
+	if from <= to {
+		return hour >= from && hour < to
+	}
+	// The window wraps past midnight
+	return hour >= from || hour < to
+}
+
+// HourRange parses the rule's hours as the first hour of the window and the hour it ends at
+func (r MapCycleRule) HourRange() (from, to int, err error) {
+	fromText, toText, ok := strings.Cut(r.Hours, "-")
+	if ok {
+		from, err = strconv.Atoi(strings.TrimSpace(fromText))
+	}
+	if ok && err == nil {
+		to, err = strconv.Atoi(strings.TrimSpace(toText))
+	}
+	if !ok || err != nil || from < 0 || from > 23 || to < 0 || to > 24 || from == to {
+		return 0, 0, fmt.Errorf("invalid hours %q (expected \"from-to\" hours, e.g. \"22-6\")", r.Hours)
+	}
+	return from, to, nil
+}
+
+// LogLocation resolves the timezone used to parse this server's log timestamps
+// Precedence: logTimezone field, then LOG_TIMEZONE environment variable, then time.Local
+func (s ServerConfig) LogLocation() (*time.Location, error) {
+	name := s.LogTimezone
+	if name == "" {
+		name = os.Getenv("LOG_TIMEZONE")
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return loc, nil
 }
 
 type LoggingConfig struct {
-	Level      string `mapstructure:"level"`      // "debug", "info", "warn", "error" (default: "info")
+	Level      string `mapstructure:"level"`      // "debug", "info", "warn", "error" (default: "info", LOG_LEVEL overrides)
+	Format     string `mapstructure:"format"`     // Console log format, "text" or "json" (default: "text", LOG_FORMAT overrides)
 	MaxBackups int    `mapstructure:"maxBackups"` // Number of rotated log files to keep (default: 10)
 	MaxSizeMB  int    `mapstructure:"maxSizeMB"`  // Max file size in MB before rotation (default: 100)
 	MaxAgeDays int    `mapstructure:"maxAgeDays"` // Max age in days before rotation (default: 7)
 }
 
+// LogArchiveConfig controls the job that gzips the game servers' rotated log files
+type LogArchiveConfig struct {
+	Enabled     bool `mapstructure:"enabled"`     // Archive server logs every hour (default: false)
+	MaxArchives int  `mapstructure:"maxArchives"` // Compressed logs kept per server (default: 10)
+}
+
+// MaxArchivesOrDefault returns the number of compressed logs kept per server (default: 10)
+func (l LogArchiveConfig) MaxArchivesOrDefault() int {
+	if l.MaxArchives <= 0 {
+		return 10
+	}
+	return l.MaxArchives
+}
+
+// ChatConfig controls what the tracker records from in-game chat
+type ChatConfig struct {
+	StoreMessages bool `mapstructure:"storeMessages"` // Store every global/team chat message for moderation (default: false)
+}
+
+// AccuracyConfig controls shot tracking for weapon accuracy stats
+type AccuracyConfig struct {
+	// Record shots fired and hits per weapon (default: false). Needs the game server to log
+	// LogGameplayEvents at Verbose, which is high-volume; without those lines nothing is recorded.
+	TrackShots bool `mapstructure:"trackShots"`
+}
+
+// IgnoredActorsConfig lists the AI and system actors that are never tracked as players, on top
+// of the INVALID ID the game logs for bots
+type IgnoredActorsConfig struct {
+	IDs          []string `mapstructure:"ids"`          // IDs treated like INVALID
+	Names        []string `mapstructure:"names"`        // Exact names (case-insensitive)
+	NamePrefixes []string `mapstructure:"namePrefixes"` // Name prefixes (case-insensitive)
+	NamePatterns []string `mapstructure:"namePatterns"` // Regular expressions matched against names
+}
+
+// ScoresConfig controls how often player scores are refreshed over RCON after game events
+// Round and match end always refresh immediately
+type ScoresConfig struct {
+	DebounceSeconds    int `mapstructure:"debounceSeconds"`    // Quiet period after the last event before scores refresh (default: 10)
+	MaxWaitSeconds     int `mapstructure:"maxWaitSeconds"`     // Longest a refresh is held back while events keep coming (default: 30)
+	MinIntervalSeconds int `mapstructure:"minIntervalSeconds"` // Minimum gap between two refreshes of the same server (default: 5)
+	// Refreshes once a startup catchup has replayed the log: "final" refreshes each caught up
+	// server's active match once, "none" waits for the next live event (default: final).
+	// Replayed events never refresh scores themselves.
+	AfterCatchup string `mapstructure:"afterCatchup"`
+}
+
+// CatchupScoreUpdates are the accepted values of scores.afterCatchup
+var CatchupScoreUpdates = []string{"final", "none"}
+
+// AfterCatchupOrDefault returns the configured refresh after a catchup, defaulting to final
+func (s ScoresConfig) AfterCatchupOrDefault() string {
+	if s.AfterCatchup == "" {
+		return "final"
+	}
+	return s.AfterCatchup
+}
+
+// Debounce returns the debounce window, defaulting to 10 seconds
+func (s ScoresConfig) Debounce() time.Duration {
+	return secondsOrDefault(s.DebounceSeconds, 10)
+}
+
+// MaxWait returns the longest a refresh may be delayed, defaulting to 30 seconds
+func (s ScoresConfig) MaxWait() time.Duration {
+	return secondsOrDefault(s.MaxWaitSeconds, 30)
+}
+
+// MinInterval returns the minimum time between refreshes of one server, defaulting to 5 seconds
+func (s ScoresConfig) MinInterval() time.Duration {
+	return secondsOrDefault(s.MinIntervalSeconds, 5)
+}
+
+// PresenceConfig controls how the A2S player list is used to correct players left connected
+// by a missed disconnect
+type PresenceConfig struct {
+	DisconnectGraceSeconds    int `mapstructure:"disconnectGraceSeconds"`    // How long a connected player may be missing from A2S before being disconnected (default: 180)
+	MapTravelReconnectSeconds int `mapstructure:"mapTravelReconnectSeconds"` // Disconnects this soon after a map travel are reconnects, not leaves (default: 30)
+	TeamSwapSeconds           int `mapstructure:"teamSwapSeconds"`           // A rejoin this soon after leaving is a team swap, not a new session (default: 15)
+	MatchIdleMinutes          int `mapstructure:"matchIdleMinutes"`          // Active matches with no activity for this long are closed as stale (default: 240)
+}
+
+// DisconnectGrace returns the grace period, defaulting to 3 minutes
+func (p PresenceConfig) DisconnectGrace() time.Duration {
+	return secondsOrDefault(p.DisconnectGraceSeconds, 180)
+}
+
+// MapTravelReconnectWindow returns how long after a map travel disconnects are ignored, defaulting to 30 seconds
+func (p PresenceConfig) MapTravelReconnectWindow() time.Duration {
+	return secondsOrDefault(p.MapTravelReconnectSeconds, 30)
+}
+
+// TeamSwapWindow returns how soon after leaving a rejoin counts as a team swap, defaulting to 15 seconds
+func (p PresenceConfig) TeamSwapWindow() time.Duration {
+	return secondsOrDefault(p.TeamSwapSeconds, 15)
+}
+
+// MatchIdleTimeout returns how long an active match may go without activity before it is closed,
+// defaulting to 4 hours
+func (p PresenceConfig) MatchIdleTimeout() time.Duration {
+	minutes := p.MatchIdleMinutes
+	if minutes <= 0 {
+		minutes = 240
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GeoIPConfig points at an offline MaxMind database (GeoLite2/GeoIP2 City or Country .mmdb) used
+// to show the country and region of players' known IPs to superusers. Leave it unset to skip
+// geolocation; locations are never stored or shown on public pages.
+type GeoIPConfig struct {
+	DatabasePath string `mapstructure:"databasePath"`
+}
+
+// OfflineAlertsConfig controls the alerts raised when a server stops answering A2S queries
+// Alerts are posted to the notification_webhooks subscribed to server_status
+type OfflineAlertsConfig struct {
+	Failures   int `mapstructure:"failures"`   // Failed A2S queries in a row before a server is reported offline (default: 3)
+	Recoveries int `mapstructure:"recoveries"` // Successful A2S queries in a row before an offline server is reported back (default: 2)
+}
+
+// FailureThreshold returns the failed queries in a row that mark a server offline, defaulting to 3
+func (o OfflineAlertsConfig) FailureThreshold() int {
+	if o.Failures <= 0 {
+		return 3
+	}
+	return o.Failures
+}
+
+// RecoveryThreshold returns the successful queries in a row that mark a server back online, defaulting to 2
+func (o OfflineAlertsConfig) RecoveryThreshold() int {
+	if o.Recoveries <= 0 {
+		return 2
+	}
+	return o.Recoveries
+}
+
+// RateLimitConfig throttles HTTP requests per client IP, so scrapers cannot tie up the server with
+// the stats pages' aggregations. Superusers, /health and static files are never throttled.
+// Behind a reverse proxy, set PocketBase's trusted proxy headers first, or every client shares
+// the proxy's IP.
+type RateLimitConfig struct {
+	Enabled           bool `mapstructure:"enabled"`           // Throttle requests (default: false)
+	RequestsPerMinute int  `mapstructure:"requestsPerMinute"` // Sustained requests per minute per IP (default: 120)
+	Burst             int  `mapstructure:"burst"`             // Requests an IP can make at once before being throttled (default: 30)
+}
+
+// RequestsPerMinuteOrDefault returns the sustained request rate, defaulting to 120 per minute
+func (r RateLimitConfig) RequestsPerMinuteOrDefault() int {
+	if r.RequestsPerMinute <= 0 {
+		return 120
+	}
+	return r.RequestsPerMinute
+}
+
+// BurstOrDefault returns the burst size, defaulting to 30 requests
+func (r RateLimitConfig) BurstOrDefault() int {
+	if r.Burst <= 0 {
+		return 30
+	}
+	return r.Burst
+}
+
+// AntiCheatConfig sets the thresholds for advisory cheat flags raised from kill data
+// Flags are only recorded for review; nobody is kicked or banned because of them
+type AntiCheatConfig struct {
+	HeadshotRatio         float64 `mapstructure:"headshotRatio"`         // Share of kills that are headshots (default: 0.6)
+	HeadshotMinKills      int     `mapstructure:"headshotMinKills"`      // Kills needed before the headshot ratio is judged (default: 50)
+	KillsPerMinute        float64 `mapstructure:"killsPerMinute"`        // Kill rate within the window (default: 10)
+	KillRateWindowSeconds int     `mapstructure:"killRateWindowSeconds"` // Sliding window the kill rate is measured over (default: 60)
+	ZeroDeathMatches      int     `mapstructure:"zeroDeathMatches"`      // Finished matches with kills and no deaths (default: 3)
+	ZeroDeathKills        int     `mapstructure:"zeroDeathKills"`        // Kills across those matches (default: 60)
+}
+
+// HeadshotRatioThreshold returns the headshot ratio threshold, defaulting to 0.6
+func (a AntiCheatConfig) HeadshotRatioThreshold() float64 {
+	if a.HeadshotRatio <= 0 {
+		return 0.6
+	}
+	return a.HeadshotRatio
+}
+
+// HeadshotMinKillsThreshold returns the kills needed to judge the headshot ratio, defaulting to 50
+func (a AntiCheatConfig) HeadshotMinKillsThreshold() int {
+	if a.HeadshotMinKills <= 0 {
+		return 50
+	}
+	return a.HeadshotMinKills
+}
+
+// KillsPerMinuteThreshold returns the kill rate threshold, defaulting to 10 kills per minute
+func (a AntiCheatConfig) KillsPerMinuteThreshold() float64 {
+	if a.KillsPerMinute <= 0 {
+		return 10
+	}
+	return a.KillsPerMinute
+}
+
+// KillRateWindow returns the kill rate window, defaulting to 60 seconds
+func (a AntiCheatConfig) KillRateWindow() time.Duration {
+	return secondsOrDefault(a.KillRateWindowSeconds, 60)
+}
+
+// ZeroDeathThresholds returns the deathless matches and kills that raise a flag, defaulting to 3 and 60
+func (a AntiCheatConfig) ZeroDeathThresholds() (matches, kills int) {
+	matches, kills = a.ZeroDeathMatches, a.ZeroDeathKills
+	if matches <= 0 {
+		matches = 3
+	}
+	if kills <= 0 {
+		kills = 60
+	}
+	return matches, kills
+}
+
+// A2SConfig controls outgoing A2S queries
+type A2SConfig struct {
+	LocalAddress string `mapstructure:"localAddress"` // Local "ip", "ip:port" or ":port" queries are sent from (default: any interface, ephemeral port)
+}
+
+// ModerationConfig controls the admin moderation reports
+type ModerationConfig struct {
+	SharedIPMaxPlayers int `mapstructure:"sharedIPMaxPlayers"` // IPs shared by more players than this are ignored as carrier/NAT noise (default: 4)
+}
+
+// SharedIPMaxPlayersThreshold returns the most players an IP may have to count as shared, defaulting to 4
+func (m ModerationConfig) SharedIPMaxPlayersThreshold() int {
+	if m.SharedIPMaxPlayers <= 0 {
+		return 4
+	}
+	return m.SharedIPMaxPlayers
+}
+
+// MVPConfig controls how a match's MVP is picked when the match ends
+type MVPConfig struct {
+	Metric string `mapstructure:"metric"` // score, computed_score or kills (default: score)
+}
+
+// MVPMetrics are the accepted values of mvp.metric
+var MVPMetrics = []string{"score", "computed_score", "kills"}
+
+// MetricOrDefault returns the configured MVP metric, defaulting to the scoreboard score
+func (m MVPConfig) MetricOrDefault() string {
+	if m.Metric == "" {
+		return "score"
+	}
+	return m.Metric
+}
+
+// RankedConfig controls which matches count towards lifetime stats and leaderboards
+type RankedConfig struct {
+	// Matches that never reach this many connected players are left out of lifetime stats and
+	// leaderboards but can still be viewed on their own (default: 0, every match counts)
+	MinPlayers int `mapstructure:"minPlayers"`
+}
+
+// RetentionConfig controls the daily job that prunes old data
+type RetentionConfig struct {
+	// Delete events older than this many days, once the matches they belong to are over; the
+	// match stats built from them are kept, but those matches can no longer be recomputed (default: 90)
+	EventsDays int `mapstructure:"eventsDays"`
+	// Delete finished matches, with their stats, older than this many days (default: 0, keep forever)
+	MatchesDays int `mapstructure:"matchesDays"`
+}
+
+// EventsMaxAge returns how long events are kept, defaulting to 90 days
+func (r RetentionConfig) EventsMaxAge() time.Duration {
+	days := r.EventsDays
+	if days <= 0 {
+		days = 90
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// MatchesMaxAge returns how long finished matches are kept, or 0 to keep them forever
+func (r RetentionConfig) MatchesMaxAge() time.Duration {
+	return time.Duration(max(r.MatchesDays, 0)) * 24 * time.Hour
+}
+
+func secondsOrDefault(seconds, def int) time.Duration {
+	if seconds <= 0 {
+		seconds = def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// RconConsoleConfig restricts which commands can be run from the web RCON console
+// Commands are matched on their first word, case-insensitively
+type RconConsoleConfig struct {
+	AllowedCommands []string `mapstructure:"allowedCommands"` // If set, only these commands may be run
+	DeniedCommands  []string `mapstructure:"deniedCommands"`  // Always rejected (default: quit, exit)
+}
+
+// defaultDeniedRconCommands is used when deniedCommands is not set
+var defaultDeniedRconCommands = []string{"quit", "exit"}
+
+// IsCommandAllowed reports whether a console command passes the allow/deny lists
+func (r RconConsoleConfig) IsCommandAllowed(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	name := strings.ToLower(fields[0])
+
+	denied := r.DeniedCommands
+	if denied == nil {
+		denied = defaultDeniedRconCommands
+	}
+	for _, d := range denied {
+		if strings.EqualFold(d, name) {
+			return false
+		}
+	}
+
+	if len(r.AllowedCommands) == 0 {
+		return true
+	}
+	for _, a := range r.AllowedCommands {
+		if strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminCommandsConfig configures the in-game moderation commands (!kick, !ban, ...)
+// Only players whose SteamID is listed in Admins may run them
+type AdminCommandsConfig struct {
+	Admins   []string       `mapstructure:"admins"`   // SteamIDs allowed to run admin commands
+	Commands []AdminCommand `mapstructure:"commands"` // Added to the defaults; an entry with a default's name replaces it
+}
+
+// AdminCommand maps a chat command and its aliases to an RCON command template
+// The template may use {target} (first argument), {reason} (the remaining arguments) and {args} (all arguments)
+type AdminCommand struct {
+	Name    string   `mapstructure:"name"`    // Chat command without the leading "!", e.g. "kick"
+	Aliases []string `mapstructure:"aliases"` // Alternative names, e.g. "k"
+	Rcon    string   `mapstructure:"rcon"`    // RCON command template, e.g. "kick {target} {reason}"
+	MinArgs int      `mapstructure:"minArgs"` // Arguments required before the command is sent
+	Usage   string   `mapstructure:"usage"`   // Shown in chat when too few arguments are given
+}
+
+// DefaultAdminCommands is the built-in moderation command table
+var DefaultAdminCommands = []AdminCommand{
+	{Name: "kick", Aliases: []string{"k"}, Rcon: "kick {target} {reason}", MinArgs: 1, Usage: "!kick <player> [reason]"},
+	{Name: "ban", Rcon: "permban {target} {reason}", MinArgs: 1, Usage: "!ban <player> [reason]"},
+	{Name: "map", Aliases: []string{"travel"}, Rcon: "travel {args}", MinArgs: 1, Usage: "!map <map>"},
+}
+
+// IsAdmin reports whether steamID may run admin commands
+func (a AdminCommandsConfig) IsAdmin(steamID string) bool {
+	for _, admin := range a.Admins {
+		if admin == steamID {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup finds the admin command for a chat command name or alias, with or without the leading "!"
+func (a AdminCommandsConfig) Lookup(name string) (AdminCommand, bool) {
+	name = strings.TrimPrefix(name, "!")
+
+	for _, command := range a.CommandTable() {
+		if strings.EqualFold(command.Name, name) {
+			return command, true
+		}
+		for _, alias := range command.Aliases {
+			if strings.EqualFold(alias, name) {
+				return command, true
+			}
+		}
+	}
+	return AdminCommand{}, false
+}
+
+// CommandTable returns the configured commands followed by the defaults they do not replace
+func (a AdminCommandsConfig) CommandTable() []AdminCommand {
+	table := append([]AdminCommand{}, a.Commands...)
+	for _, def := range DefaultAdminCommands {
+		replaced := false
+		for _, command := range a.Commands {
+			if strings.EqualFold(command.Name, def.Name) {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			table = append(table, def)
+		}
+	}
+	return table
+}
+
 type Config struct {
-	SAWPath string         `mapstructure:"sawPath"` // Path to Sandstorm Admin Wrapper installation
-	Servers []ServerConfig `mapstructure:"servers"`
-	Logging LoggingConfig  `mapstructure:"logging"`
+	SAWPath       string              `mapstructure:"sawPath"` // Path to Sandstorm Admin Wrapper installation
+	Servers       []ServerConfig      `mapstructure:"servers"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
+	LogArchive    LogArchiveConfig    `mapstructure:"logArchive"`
+	Chat          ChatConfig          `mapstructure:"chat"`
+	Accuracy      AccuracyConfig      `mapstructure:"accuracy"`
+	Scores        ScoresConfig        `mapstructure:"scores"`
+	Presence      PresenceConfig      `mapstructure:"presence"`
+	OfflineAlerts OfflineAlertsConfig `mapstructure:"offlineAlerts"`
+	AntiCheat     AntiCheatConfig     `mapstructure:"antiCheat"`
+	Moderation    ModerationConfig    `mapstructure:"moderation"`
+	MVP           MVPConfig           `mapstructure:"mvp"`
+	Ranked        RankedConfig        `mapstructure:"ranked"`
+	Retention     RetentionConfig     `mapstructure:"retention"`
+	A2S           A2SConfig           `mapstructure:"a2s"`
+	RconConsole   RconConsoleConfig   `mapstructure:"rconConsole"`
+	AdminCommands AdminCommandsConfig `mapstructure:"adminCommands"`
+	IgnoredActors IgnoredActorsConfig `mapstructure:"ignoredActors"`
+	GeoIP         GeoIPConfig         `mapstructure:"geoip"`
+	RateLimit     RateLimitConfig     `mapstructure:"rateLimit"`
 }
 
 func Load() (*Config, error) {
@@ -49,14 +587,9 @@ func Load() (*Config, error) {
 		err = viper.ReadInConfig()
 		if err != nil {
 			// No config file found - return empty config (will be handled by serve command)
-			return &Config{
-				Logging: LoggingConfig{
-					Level:      "info",
-					MaxBackups: 10,
-					MaxSizeMB:  100,
-					MaxAgeDays: 7,
-				},
-			}, nil
+			config := &Config{}
+			applyLoggingDefaults(&config.Logging)
+			return config, nil
 		}
 	}
 
@@ -89,8 +622,25 @@ func Load() (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to load from SAW: %w", err)
 		}
-		// Preserve logging config from file
+		// Preserve logging, log archiving, chat, accuracy, scores, presence, offline alerts, rate limit, anti-cheat, moderation, MVP, ranked, retention, A2S, RCON console, admin command and ignored actor config from file
 		sawConfig.Logging = config.Logging
+		sawConfig.LogArchive = config.LogArchive
+		sawConfig.Chat = config.Chat
+		sawConfig.Accuracy = config.Accuracy
+		sawConfig.Scores = config.Scores
+		sawConfig.Presence = config.Presence
+		sawConfig.OfflineAlerts = config.OfflineAlerts
+		sawConfig.GeoIP = config.GeoIP
+		sawConfig.RateLimit = config.RateLimit
+		sawConfig.AntiCheat = config.AntiCheat
+		sawConfig.Moderation = config.Moderation
+		sawConfig.MVP = config.MVP
+		sawConfig.Ranked = config.Ranked
+		sawConfig.Retention = config.Retention
+		sawConfig.A2S = config.A2S
+		sawConfig.RconConsole = config.RconConsole
+		sawConfig.AdminCommands = config.AdminCommands
+		sawConfig.IgnoredActors = config.IgnoredActors
 		sawConfig.SAWPath = config.SAWPath
 
 		// Merge manual servers - they override SAW-discovered servers by name
@@ -112,6 +662,7 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	applyLoggingDefaults(&config.Logging)
 
 	// Validate that all enabled servers have required fields
 	if err := config.Validate(); err != nil {
@@ -147,6 +698,43 @@ func (c *Config) Validate() error {
 		if server.QueryAddress == "" {
 			return fmt.Errorf("server '%s' (index %d) is missing 'queryAddress' field (A2S query port, usually game port + 29)", server.Name, i)
 		}
+
+		if _, err := server.LogLocation(); err != nil {
+			return fmt.Errorf("server '%s' (index %d) has an invalid 'logTimezone' (or LOG_TIMEZONE): %w", server.Name, i, err)
+		}
+
+		if err := server.MapCycle.validate(); err != nil {
+			return fmt.Errorf("server '%s' (index %d) has an invalid 'mapCycle': %w", server.Name, i, err)
+		}
+	}
+
+	if _, err := logger.ParseLevel(c.Logging.Level); c.Logging.Level != "" && err != nil {
+		return fmt.Errorf("invalid logging.level (or LOG_LEVEL): %w", err)
+	}
+	if c.Logging.Format != "" && !slices.Contains(logger.Formats, strings.ToLower(c.Logging.Format)) {
+		return fmt.Errorf("invalid logging.format (or LOG_FORMAT) %q (expected one of %s)", c.Logging.Format, strings.Join(logger.Formats, ", "))
+	}
+
+	if !slices.Contains(MVPMetrics, c.MVP.MetricOrDefault()) {
+		return fmt.Errorf("invalid mvp.metric %q (expected one of %s)", c.MVP.Metric, strings.Join(MVPMetrics, ", "))
+	}
+
+	if !slices.Contains(CatchupScoreUpdates, c.Scores.AfterCatchupOrDefault()) {
+		return fmt.Errorf("invalid scores.afterCatchup %q (expected one of %s)", c.Scores.AfterCatchup, strings.Join(CatchupScoreUpdates, ", "))
+	}
+
+	if c.Ranked.MinPlayers < 0 {
+		return fmt.Errorf("invalid ranked.minPlayers %d (must be 0 or more)", c.Ranked.MinPlayers)
+	}
+
+	if c.Retention.EventsDays < 0 || c.Retention.MatchesDays < 0 {
+		return fmt.Errorf("invalid retention (eventsDays %d, matchesDays %d): days must be 0 or more", c.Retention.EventsDays, c.Retention.MatchesDays)
+	}
+
+	for _, pattern := range c.IgnoredActors.NamePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid ignoredActors.namePatterns entry %q: %w", pattern, err)
+		}
 	}
 
 	return nil
@@ -183,7 +771,14 @@ func (c *Config) EnsureServersInDatabase(pbApp core.App, getServerID func(string
 		)
 
 		if err == nil && len(exists) > 0 {
-			// Server already exists
+			// Server already exists; keep its group in step with the config when one is set,
+			// otherwise leave any group assigned in the dashboard alone
+			if serverCfg.Group != "" && exists[0].GetString("group") != serverCfg.Group {
+				exists[0].Set("group", serverCfg.Group)
+				if err := pbApp.Save(exists[0]); err != nil {
+					return fmt.Errorf("failed to update group of server %s: %w", serverCfg.Name, err)
+				}
+			}
 			continue
 		}
 
@@ -197,6 +792,8 @@ func (c *Config) EnsureServersInDatabase(pbApp core.App, getServerID func(string
 		record.Set("name", serverCfg.Name)  // Friendly name from config
 		record.Set("external_id", serverID) // UUID from filename
 		record.Set("path", absPath)
+		record.Set("group", serverCfg.Group)
+		record.Set("is_public", true) // Shown on the public pages until an admin says otherwise
 
 		if err := pbApp.Save(record); err != nil {
 			return fmt.Errorf("failed to create server record for %s: %w", serverCfg.Name, err)
@@ -240,6 +837,14 @@ func mergeServerConfigs(sawServers []ServerConfig, manualServers []ServerConfig)
 			if manualSrv.QueryAddress != "" {
 				merged.QueryAddress = manualSrv.QueryAddress
 			}
+			if manualSrv.LogTimezone != "" {
+				merged.LogTimezone = manualSrv.LogTimezone
+			}
+			if manualSrv.Group != "" {
+				merged.Group = manualSrv.Group
+			}
+			// The map cycle is only ever set in the config file
+			merged.MapCycle = manualSrv.MapCycle
 
 			// Enabled is always taken from manual config (allows disabling)
 			merged.Enabled = manualSrv.Enabled
@@ -286,10 +891,21 @@ func Exists() bool {
 }
 
 // applyLoggingDefaults sets default values for logging config if not specified
+// The LOG_LEVEL and LOG_FORMAT environment variables take precedence over the config file
 func applyLoggingDefaults(cfg *LoggingConfig) {
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		cfg.Level = level
+	}
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		cfg.Format = format
+	}
+
 	if cfg.Level == "" {
 		cfg.Level = "info"
 	}
+	if cfg.Format == "" {
+		cfg.Format = "text"
+	}
 	if cfg.MaxBackups == 0 {
 		cfg.MaxBackups = 10
 	}
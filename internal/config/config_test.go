@@ -263,6 +263,65 @@ func TestValidate(t *testing.T) {
 			wantErr:     true,
 			errContains: "missing 'queryAddress' field",
 		},
+		{
+			name: "invalid logTimezone",
+			config: Config{
+				Servers: []ServerConfig{
+					{
+						Name:         "Test",
+						LogPath:      "/logs",
+						RconAddress:  "127.0.0.1:27015",
+						RconPassword: "pass",
+						QueryAddress: "127.0.0.1:27016",
+						LogTimezone:  "Mars/Olympus_Mons",
+						Enabled:      true,
+					},
+				},
+			},
+			wantErr:     true,
+			errContains: "invalid 'logTimezone'",
+		},
+		{
+			name: "invalid map cycle hours",
+			config: Config{
+				Servers: []ServerConfig{
+					{
+						Name:         "Test",
+						LogPath:      "/logs",
+						RconAddress:  "127.0.0.1:27015",
+						RconPassword: "pass",
+						QueryAddress: "127.0.0.1:27016",
+						Enabled:      true,
+						MapCycle: MapCycleConfig{
+							Enabled: true,
+							Rules:   []MapCycleRule{{Hours: "evening", Scenarios: []string{"Scenario_Farmhouse_Checkpoint_Security"}}},
+						},
+					},
+				},
+			},
+			wantErr:     true,
+			errContains: "invalid 'mapCycle': rule 0: invalid hours",
+		},
+		{
+			name: "invalid ignored actor pattern",
+			config: Config{
+				IgnoredActors: IgnoredActorsConfig{NamePatterns: []string{"^AI[0-9+$"}},
+			},
+			wantErr:     true,
+			errContains: "invalid ignoredActors.namePatterns",
+		},
+		{
+			name:        "invalid log format",
+			config:      Config{Logging: LoggingConfig{Level: "info", Format: "xml"}},
+			wantErr:     true,
+			errContains: "invalid logging.format",
+		},
+		{
+			name:        "invalid log level",
+			config:      Config{Logging: LoggingConfig{Level: "verbose", Format: "json"}},
+			wantErr:     true,
+			errContains: "invalid logging.level",
+		},
 		{
 			name: "disabled server skips validation",
 			config: Config{
@@ -310,3 +369,63 @@ func contains(str, substr string) bool {
 	}
 	return false
 }
+
+func TestRconConsoleConfig_IsCommandAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RconConsoleConfig
+		command string
+		want    bool
+	}{
+		{"default allows regular command", RconConsoleConfig{}, "listplayers", true},
+		{"default denies quit", RconConsoleConfig{}, "quit", false},
+		{"deny is case-insensitive", RconConsoleConfig{}, "  EXIT now", false},
+		{"empty command", RconConsoleConfig{}, "   ", false},
+		{"custom deny list replaces default", RconConsoleConfig{DeniedCommands: []string{"permban"}}, "quit", true},
+		{"custom deny list", RconConsoleConfig{DeniedCommands: []string{"permban"}}, "permban 7656119", false},
+		{"allow list permits listed", RconConsoleConfig{AllowedCommands: []string{"say", "listplayers"}}, "say hello all", true},
+		{"allow list rejects others", RconConsoleConfig{AllowedCommands: []string{"say", "listplayers"}}, "kick 7656119", false},
+		{"deny wins over allow", RconConsoleConfig{AllowedCommands: []string{"quit"}}, "quit", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.IsCommandAllowed(tt.command); got != tt.want {
+				t.Errorf("IsCommandAllowed(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminCommandsConfig_Lookup(t *testing.T) {
+	admin := AdminCommandsConfig{
+		Commands: []AdminCommand{
+			{Name: "kick", Aliases: []string{"boot"}, Rcon: "kick {target}"},
+			{Name: "restart", Rcon: "restartround"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		lookup   string
+		wantRcon string
+		wantOK   bool
+	}{
+		{"configured command replaces default", "!kick", "kick {target}", true},
+		{"configured alias", "!BOOT", "kick {target}", true},
+		{"configured new command", "restart", "restartround", true},
+		{"default command", "!ban", "permban {target} {reason}", true},
+		{"default alias", "!travel", "travel {args}", true},
+		{"replaced default alias is gone", "!k", "", false},
+		{"unknown command", "!stats", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, ok := admin.Lookup(tt.lookup)
+			if ok != tt.wantOK || command.Rcon != tt.wantRcon {
+				t.Errorf("Lookup(%q) = %q, %v; want %q, %v", tt.lookup, command.Rcon, ok, tt.wantRcon, tt.wantOK)
+			}
+		})
+	}
+}
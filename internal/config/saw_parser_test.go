@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/spf13/viper"
 )
 
 func TestLoadFromSAW(t *testing.T) {
@@ -136,3 +139,61 @@ func TestLoadFromSAW_MissingFile(t *testing.T) {
 		t.Error("LoadFromSAW() should return error when server-configs.json doesn't exist")
 	}
 }
+
+func TestLoad_SAWKeepsFileConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, "admin-interface", "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	testConfig := map[string]SAWServerConfig{
+		"test-server-uuid": {
+			ID:                 "test-server-uuid",
+			ServerHostname:     "Test Server",
+			ServerRconEnabled:  "true",
+			ServerRconPort:     "27015",
+			ServerRconPassword: "password123",
+			ServerQueryPort:    "27131",
+		},
+	}
+	configData, _ := json.MarshalIndent(testConfig, "", "  ")
+	if err := os.WriteFile(filepath.Join(configDir, "server-configs.json"), configData, 0644); err != nil {
+		t.Fatalf("Failed to write SAW config: %v", err)
+	}
+
+	yamlContent := `
+sawPath: "` + filepath.ToSlash(tmpDir) + `"
+ignoredActors:
+  names: ["Observer"]
+  namePrefixes: ["BOT_"]
+rateLimit:
+  enabled: true
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "sandstorm-tracker.yml"), []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tmpDir)
+	t.Setenv("SAW_PATH", "")
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(cfg.Servers) != 1 || cfg.Servers[0].Name != "Test Server" {
+		t.Fatalf("Expected the SAW server, got %+v", cfg.Servers)
+	}
+	// Settings SAW knows nothing about still come from the file
+	if !reflect.DeepEqual(cfg.IgnoredActors.Names, []string{"Observer"}) ||
+		!reflect.DeepEqual(cfg.IgnoredActors.NamePrefixes, []string{"BOT_"}) {
+		t.Errorf("IgnoredActors = %+v, want the file's list", cfg.IgnoredActors)
+	}
+	if !cfg.RateLimit.Enabled {
+		t.Error("RateLimit.Enabled = false, want the file's setting")
+	}
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Ban is a ban from the bans collection. A ban without a server applies to every server, and
+// one without an expiry is permanent.
+type Ban struct {
+	ID       string     `json:"id"`
+	SteamID  string     `json:"steam_id"`
+	ServerID string     `json:"server"` // Server record ID, empty for every server
+	Reason   string     `json:"reason"`
+	Expires  *time.Time `json:"expires"`
+}
+
+// BanFromRecord converts a bans record to a Ban
+func BanFromRecord(record *core.Record) Ban {
+	ban := Ban{
+		ID:       record.Id,
+		SteamID:  record.GetString("steam_id"),
+		ServerID: record.GetString("server"),
+		Reason:   record.GetString("reason"),
+	}
+	if expires := record.GetDateTime("expires"); !expires.IsZero() {
+		t := expires.Time()
+		ban.Expires = &t
+	}
+	return ban
+}
+
+// Active reports whether the ban is still in force at now
+func (b Ban) Active(now time.Time) bool {
+	return b.Expires == nil || b.Expires.After(now)
+}
+
+// RconCommand returns the RCON command that bans the player for the rest of the ban:
+// "banid <id> <minutes> <reason>", with -1 minutes for a permanent ban
+func (b Ban) RconCommand(now time.Time) string {
+	minutes := -1
+	if b.Expires != nil {
+		minutes = max(int(math.Ceil(b.Expires.Sub(now).Minutes())), 1)
+	}
+	command := fmt.Sprintf("banid %s %d", b.SteamID, minutes)
+	// The reason is the rest of the command line, so it has to stay on one line
+	if reason := strings.Join(strings.Fields(b.Reason), " "); reason != "" {
+		command += " " + reason
+	}
+	return command
+}
+
+// GetActiveBans returns the bans in force on a server (by record ID) at now, including bans
+// for every server, oldest first
+func GetActiveBans(ctx context.Context, pbApp core.App, serverRecordID string, now time.Time) ([]Ban, error) {
+	records, err := pbApp.FindRecordsByFilter(
+		"bans",
+		"(server = '' || server = {:server}) && (expires = '' || expires > {:now})",
+		"created",
+		0,
+		0,
+		dbx.Params{"server": serverRecordID, "now": now.UTC().Format(types.DefaultDateLayout)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bans: %w", err)
+	}
+
+	bans := make([]Ban, 0, len(records))
+	for _, record := range records {
+		bans = append(bans, BanFromRecord(record))
+	}
+	return bans, nil
+}
+
+// FormatBansFile renders bans as Bans.txt: one player ID per line, each banned player once
+func FormatBansFile(bans []Ban) string {
+	var b strings.Builder
+	seen := make(map[string]bool, len(bans))
+	for _, ban := range bans {
+		if seen[ban.SteamID] {
+			continue
+		}
+		seen[ban.SteamID] = true
+		b.WriteString(ban.SteamID)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ParseBansFile returns the player IDs listed in a Bans.txt, each once, skipping blank lines and
+// comments. Only the first field of a line is read, so a hand-written note after the ID is ignored.
+func ParseBansFile(content string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], ";") || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		if id := fields[0]; !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
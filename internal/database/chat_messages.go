@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ChatMessage represents a stored in-game chat message
+type ChatMessage struct {
+	ID         string
+	ServerID   string // Server record ID
+	MatchID    string // Active match when the message was sent (may be empty)
+	PlayerID   string // Player record ID (may be empty if the player is unknown)
+	SteamID    string
+	PlayerName string
+	Channel    string // global or team
+	Message    string
+	Timestamp  time.Time
+}
+
+// RecordChatMessage stores a chat message
+func RecordChatMessage(ctx context.Context, pbApp core.App, msg *ChatMessage) error {
+	collection, err := pbApp.FindCollectionByNameOrId("chat_messages")
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("server", msg.ServerID)
+	if msg.MatchID != "" {
+		record.Set("match", msg.MatchID)
+	}
+	if msg.PlayerID != "" {
+		record.Set("player", msg.PlayerID)
+	}
+	record.Set("steam_id", msg.SteamID)
+	record.Set("player_name", msg.PlayerName)
+	record.Set("channel", msg.Channel)
+	record.Set("message", msg.Message)
+	record.Set("timestamp", msg.Timestamp)
+
+	if err := pbApp.Save(record); err != nil {
+		return err
+	}
+	msg.ID = record.Id
+	return nil
+}
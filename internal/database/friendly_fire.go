@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/pocketbase/pocketbase/core"
@@ -288,6 +289,88 @@ func GetRecentFriendlyFireIncidents(ctx context.Context, pbApp core.App, matchID
 	return incidents, nil
 }
 
+// FriendlyFireListItem is a friendly fire incident with the names needed to display it
+type FriendlyFireListItem struct {
+	ID            string    `json:"id"`
+	MatchID       string    `json:"match_id"`
+	Map           string    `json:"map"`
+	ServerID      string    `json:"server_id"`
+	ServerName    string    `json:"server_name"`
+	KillerID      string    `json:"killer_id"`
+	KillerName    string    `json:"killer_name"`
+	KillerSteamID string    `json:"killer_steam_id"`
+	VictimID      string    `json:"victim_id"`
+	VictimName    string    `json:"victim_name"`
+	VictimSteamID string    `json:"victim_steam_id"`
+	Weapon        string    `json:"weapon"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// ListFriendlyFireIncidents returns FF incidents newest first, optionally limited to a server
+// and to incidents where a player was the killer or the victim (record IDs; empty means any)
+func ListFriendlyFireIncidents(ctx context.Context, pbApp core.App, serverID, playerID string, limit, offset int) ([]FriendlyFireListItem, error) {
+	filters := []string{}
+	params := map[string]any{}
+
+	if serverID != "" {
+		filters = append(filters, "match.server = {:server}")
+		params["server"] = serverID
+	}
+	if playerID != "" {
+		filters = append(filters, "(killer = {:player} || victim = {:player})")
+		params["player"] = playerID
+	}
+
+	records, err := pbApp.FindRecordsByFilter(
+		"friendly_fire_incidents",
+		strings.Join(filters, " && "),
+		"-timestamp",
+		limit,
+		offset,
+		params,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := pbApp.ExpandRecords(records, []string{"killer", "victim", "match.server"}, nil); len(errs) > 0 {
+		getLogger(pbApp).Debug("Failed to expand friendly fire incidents", "errors", errs)
+	}
+
+	items := make([]FriendlyFireListItem, len(records))
+	for i, record := range records {
+		items[i] = FriendlyFireListItem{
+			ID:        record.Id,
+			MatchID:   record.GetString("match"),
+			Map:       record.GetString("map"),
+			KillerID:  record.GetString("killer"),
+			VictimID:  record.GetString("victim"),
+			Weapon:    record.GetString("weapon"),
+			Timestamp: record.GetDateTime("timestamp").Time(),
+		}
+
+		if killer := record.ExpandedOne("killer"); killer != nil {
+			items[i].KillerName = killer.GetString("name")
+			items[i].KillerSteamID = killer.GetString("external_id")
+		}
+		if victim := record.ExpandedOne("victim"); victim != nil {
+			items[i].VictimName = victim.GetString("name")
+			items[i].VictimSteamID = victim.GetString("external_id")
+		}
+		if match := record.ExpandedOne("match"); match != nil {
+			if items[i].Map == "" {
+				items[i].Map = match.GetString("map")
+			}
+			if server := match.ExpandedOne("server"); server != nil {
+				items[i].ServerID = server.Id
+				items[i].ServerName = server.GetString("name")
+			}
+		}
+	}
+
+	return items, nil
+}
+
 // Helper functions for pointer conversions
 func stringPtr(s string) *string {
 	if s == "" {
@@ -13,7 +13,9 @@ import (
 
 	"sandstorm-tracker/internal/util"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 // Match represents a match record from PocketBase
@@ -35,6 +37,7 @@ type Match struct {
 type Player struct {
 	ID         string
 	ExternalID string
+	Platform   string // One of the Platform constants, empty for name-only players
 	Name       string
 }
 
@@ -130,10 +133,10 @@ func CreateMatch(ctx context.Context, pbApp core.App, serverID string, mapName,
 		record.Set("map", *mapName)
 	}
 
-	// Extract game mode from scenario string
-	gameMode := "Unknown"
+	// Extract game mode, and the side when the caller did not pass one, from the scenario string
+	gameMode, side := "Unknown", ""
 	if mode != nil {
-		gameMode = util.ExtractGameMode(*mode)
+		gameMode, side = util.NormalizeScenario(*mode)
 	}
 	record.Set("mode", gameMode)
 
@@ -144,12 +147,28 @@ func CreateMatch(ctx context.Context, pbApp core.App, serverID string, mapName,
 	}
 	record.Set("title", title)
 
+	if mode != nil {
+		record.Set("scenario", *mode)
+	}
+
+	// The live progress bar needs the objective count, which the log never states: take it from
+	// the maps catalog, and leave it unset (bar hidden) for maps and modes the catalog lacks
+	if mapName != nil {
+		if n := GetMapInfo(pbApp, *mapName).ObjectiveCount(gameMode); n > 0 {
+			record.Set("num_objectives", n)
+		}
+	}
+
 	if startTime != nil {
 		record.Set("start_time", startTime.Format(time.RFC3339))
 	}
 	if len(playerTeam) > 0 && playerTeam[0] != nil {
 		record.Set("player_team", *playerTeam[0])
+	} else if side != "" {
+		record.Set("player_team", side)
 	}
+	record.Set("winning_team", NoWinningTeam)
+	record.Set("score_weights", GetScoreWeights(pbApp))
 
 	if err := pbApp.Save(record); err != nil {
 		return nil, err
@@ -169,14 +188,16 @@ func CreateMatch(ctx context.Context, pbApp core.App, serverID string, mapName,
 	if startTime != nil {
 		match.StartTime = startTime
 	}
-	if len(playerTeam) > 0 && playerTeam[0] != nil {
-		match.PlayerTeam = playerTeam[0]
+	if team := record.GetString("player_team"); team != "" {
+		match.PlayerTeam = &team
 	}
 
 	return match, nil
 }
 
-// GetOrCreatePlayerBySteamID finds or creates a player by Steam ID
+// GetOrCreatePlayerBySteamID finds a player by ID or creates one. Kill and objective
+// lines carry only the ID, so the lookup matches any platform; logins, which name the platform,
+// use GetOrCreatePlayerByPlatformID.
 func GetOrCreatePlayerBySteamID(ctx context.Context, pbApp core.App, steamID, name string) (*Player, error) {
 	// Try to find existing player
 	player, err := GetPlayerByExternalID(ctx, pbApp, steamID)
@@ -188,7 +209,7 @@ func GetOrCreatePlayerBySteamID(ctx context.Context, pbApp core.App, steamID, na
 	return CreatePlayer(ctx, pbApp, steamID, name)
 }
 
-// GetPlayerByExternalID fetches a player by external_id (e.g., Steam ID)
+// GetPlayerByExternalID fetches a player by external_id (e.g., Steam ID) on any platform
 func GetPlayerByExternalID(ctx context.Context, pbApp core.App, externalID string) (*Player, error) {
 	record, err := pbApp.FindFirstRecordByFilter(
 		"players",
@@ -199,11 +220,7 @@ func GetPlayerByExternalID(ctx context.Context, pbApp core.App, externalID strin
 		return nil, err
 	}
 
-	return &Player{
-		ID:         record.Id,
-		ExternalID: record.GetString("external_id"),
-		Name:       record.GetString("name"),
-	}, nil
+	return playerFromRecord(record), nil
 }
 
 // GetPlayerByName finds a player by their display name
@@ -217,15 +234,17 @@ func GetPlayerByName(ctx context.Context, pbApp core.App, name string) (*Player,
 		return nil, err
 	}
 
-	return &Player{
-		ID:         record.Id,
-		ExternalID: record.GetString("external_id"),
-		Name:       record.GetString("name"),
-	}, nil
+	return playerFromRecord(record), nil
 }
 
-// CreatePlayer creates a new player record
+// CreatePlayer creates a new player record. Kill and objective lines don't say which platform
+// an ID is from, and Epic IDs can be numeric like Steam IDs, so the platform is left unknown
+// until a login names it (see findLoginPlayer).
 func CreatePlayer(ctx context.Context, pbApp core.App, externalID, name string) (*Player, error) {
+	return createPlayer(ctx, pbApp, "", externalID, name)
+}
+
+func createPlayer(ctx context.Context, pbApp core.App, platform, externalID, name string) (*Player, error) {
 	collection, err := pbApp.FindCollectionByNameOrId("players")
 	if err != nil {
 		return nil, err
@@ -233,6 +252,7 @@ func CreatePlayer(ctx context.Context, pbApp core.App, externalID, name string)
 
 	record := core.NewRecord(collection)
 	record.Set("external_id", externalID)
+	record.Set("platform", platform)
 	record.Set("name", name)
 
 	if err := pbApp.Save(record); err != nil {
@@ -242,10 +262,21 @@ func CreatePlayer(ctx context.Context, pbApp core.App, externalID, name string)
 	return &Player{
 		ID:         record.Id,
 		ExternalID: externalID,
+		Platform:   platform,
 		Name:       name,
 	}, nil
 }
 
+// playerFromRecord converts a players record to a Player
+func playerFromRecord(record *core.Record) *Player {
+	return &Player{
+		ID:         record.Id,
+		ExternalID: record.GetString("external_id"),
+		Platform:   record.GetString("platform"),
+		Name:       record.GetString("name"),
+	}
+}
+
 // UpdatePlayerName updates a player's name if it has changed
 func UpdatePlayerName(ctx context.Context, pbApp core.App, player *Player, newName string) error {
 	if player.Name == newName {
@@ -268,6 +299,11 @@ func UpdatePlayerName(ctx context.Context, pbApp core.App, player *Player, newNa
 
 // UpdatePlayerExternalID updates a player's external_id (Steam ID) if it's currently empty
 func UpdatePlayerExternalID(ctx context.Context, pbApp core.App, player *Player, externalID string) error {
+	return UpdatePlayerPlatformID(ctx, pbApp, player, PlatformSteam, externalID)
+}
+
+// UpdatePlayerPlatformID gives a player its platform and external_id if it has no external_id yet
+func UpdatePlayerPlatformID(ctx context.Context, pbApp core.App, player *Player, platform, externalID string) error {
 	if player.ExternalID != "" {
 		return nil // Already has an external_id
 	}
@@ -277,12 +313,16 @@ func UpdatePlayerExternalID(ctx context.Context, pbApp core.App, player *Player,
 		return err
 	}
 
+	platform = NormalizePlatform(platform)
 	record.Set("external_id", externalID)
+	record.Set("platform", platform)
 	if err := pbApp.Save(record); err != nil {
 		return err
 	}
 
-	player.ExternalID = externalID // Update the in-memory struct too
+	// Update the in-memory struct too
+	player.ExternalID = externalID
+	player.Platform = platform
 	return nil
 }
 
@@ -306,7 +346,10 @@ func UpsertMatchPlayerStats(ctx context.Context, pbApp core.App, matchID, player
 		// Record already exists - player is already in the match
 		record = records[0]
 		// Don't increment session_count - it should only count when player first joins
-		// Just ensure the record exists (no updates needed here)
+		// Only track the player's latest known team (players can switch teams in versus modes)
+		if team != nil {
+			record.Set("team", *team)
+		}
 	} else {
 		// Create new record - player is joining the match for the first time
 		collection, err := pbApp.FindCollectionByNameOrId("match_player_stats")
@@ -317,6 +360,11 @@ func UpsertMatchPlayerStats(ctx context.Context, pbApp core.App, matchID, player
 		record = core.NewRecord(collection)
 		record.Set("match", matchID)
 		record.Set("player", playerID)
+		// Keep the name the player used in this match; players.name follows renames
+		if player, err := pbApp.FindRecordById("players", playerID); err == nil {
+			record.Set("player_name", player.GetString("name"))
+		}
+		record.Set("team", -1) // Unknown until a kill or objective reveals it
 		if team != nil {
 			record.Set("team", *team)
 		}
@@ -356,7 +404,8 @@ func getLatestMatchPlayerStats(pbApp core.App, matchID, playerID string) (*core.
 }
 
 // IncrementMatchPlayerStat increments a numeric field for a player in a match by 1.
-// Common field names: "kills", "assists", "deaths", "friendly_fire_kills", "objectives_destroyed", "objectives_captured"
+// Incrementing a scored stat also refreshes computed_score with the match's score weights.
+// Common field names: "kills", "assists", "deaths", "headshots", "friendly_fire_kills", "objectives_destroyed", "objectives_captured"
 func IncrementMatchPlayerStat(ctx context.Context, pbApp core.App, matchID, playerID, fieldName string) error {
 	record, err := getLatestMatchPlayerStats(pbApp, matchID, playerID)
 	if err != nil {
@@ -366,9 +415,73 @@ func IncrementMatchPlayerStat(ctx context.Context, pbApp core.App, matchID, play
 	currentValue := record.GetInt(fieldName)
 	record.Set(fieldName, currentValue+1)
 
+	if scoredStats[fieldName] {
+		weights, err := GetMatchScoreWeights(pbApp, matchID)
+		if err != nil {
+			return err
+		}
+		record.Set("computed_score", weights.Score(statLineFromRecord(record)))
+	}
+
 	return pbApp.Save(record)
 }
 
+// IncrementMatchWeaponStat increments a numeric field on a player's weapon stats in a match by 1.
+// The weapon stats record must already exist (see UpsertMatchWeaponStats).
+// Common field names: "headshots"
+func IncrementMatchWeaponStat(ctx context.Context, pbApp core.App, matchID, playerID, weaponName, fieldName string) error {
+	record, err := pbApp.FindFirstRecordByFilter(
+		"match_weapon_stats",
+		"match = {:match} && player = {:player} && weapon_name = {:weapon}",
+		map[string]any{
+			"match":  matchID,
+			"player": playerID,
+			"weapon": CleanWeaponName(weaponName),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	record.Set(fieldName, record.GetInt(fieldName)+1)
+
+	return pbApp.Save(record)
+}
+
+// AddMatchWeaponShots adds shots fired and hits to a player's weapon stats in a match,
+// creating the weapon stats record if the player has not scored with the weapon yet
+func AddMatchWeaponShots(ctx context.Context, pbApp core.App, matchID, playerID, weaponName string, shots, hits int) error {
+	if err := UpsertMatchWeaponStats(ctx, pbApp, matchID, playerID, weaponName, nil, nil); err != nil {
+		return err
+	}
+
+	record, err := pbApp.FindFirstRecordByFilter(
+		"match_weapon_stats",
+		"match = {:match} && player = {:player} && weapon_name = {:weapon}",
+		map[string]any{
+			"match":  matchID,
+			"player": playerID,
+			"weapon": CleanWeaponName(weaponName),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	record.Set("shots", record.GetInt("shots")+shots)
+	record.Set("hits", record.GetInt("hits")+hits)
+
+	return pbApp.Save(record)
+}
+
+// WeaponAccuracy returns hits as a percentage of shots fired, or 0 when no shots were recorded
+func WeaponAccuracy(shots, hits int) float64 {
+	if shots <= 0 {
+		return 0
+	}
+	return float64(hits) / float64(shots) * 100
+}
+
 // GetWeaponType returns the weapon category type based on weapon name
 // Extracts the type from the blueprint class name by taking everything between the first and second underscore
 // Examples: BP_Firearm_M4A1 -> Firearm, BP_Projectile_F1 -> Projectile, BP_Melee_Knife -> Melee
@@ -507,6 +620,7 @@ func GetOrCreateServer(ctx context.Context, pbApp core.App, externalID, name, pa
 	record.Set("name", name)
 	record.Set("path", path)
 	record.Set("enabled", true)
+	record.Set("is_public", true)
 
 	if err := pbApp.Save(record); err != nil {
 		return "", err
@@ -561,6 +675,39 @@ func GetAllPlayersInMatch(ctx context.Context, pbApp core.App, matchID string) (
 	return stats, nil
 }
 
+// GetConnectedPlayers returns the players currently connected to a match
+func GetConnectedPlayers(ctx context.Context, pbApp core.App, matchID string) ([]Player, error) {
+	records, err := pbApp.FindRecordsByFilter(
+		"match_player_stats",
+		"match = {:match} && is_currently_connected = true",
+		"",
+		-1,
+		0,
+		map[string]any{"match": matchID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := pbApp.ExpandRecords(records, []string{"player"}, nil); len(errs) > 0 {
+		return nil, fmt.Errorf("failed to expand players: %v", errs)
+	}
+
+	players := make([]Player, 0, len(records))
+	for _, record := range records {
+		player := record.ExpandedOne("player")
+		if player == nil {
+			continue
+		}
+		players = append(players, Player{
+			ID:         player.Id,
+			ExternalID: player.GetString("external_id"),
+			Name:       player.GetString("name"),
+		})
+	}
+	return players, nil
+}
+
 // EndMatch updates a match with end time and winner team
 func EndMatch(ctx context.Context, pbApp core.App, matchID string, endTime *time.Time, winnerTeam *int64, status *string) error {
 	log := getLogger(pbApp)
@@ -599,8 +746,13 @@ func EndMatch(ctx context.Context, pbApp core.App, matchID string, endTime *time
 	)
 
 	if err == nil {
+		// Settle every player's computed score with the weights the match is scored with
+		weights, weightsErr := GetMatchScoreWeights(pbApp, matchID)
 		for _, playerRecord := range playerRecords {
 			playerRecord.Set("status", matchStatus)
+			if weightsErr == nil {
+				playerRecord.Set("computed_score", weights.Score(statLineFromRecord(playerRecord)))
+			}
 			if err := pbApp.Save(playerRecord); err != nil {
 				log.Debug("Failed to update player status to %s: %v", matchStatus, err)
 			}
@@ -610,6 +762,29 @@ func EndMatch(ctx context.Context, pbApp core.App, matchID string, endTime *time
 	return nil
 }
 
+// SetMatchCrashReason records why a crashed match ended, e.g. the fatal error the server logged
+func SetMatchCrashReason(ctx context.Context, pbApp core.App, matchID string, reason string) error {
+	record, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return err
+	}
+	record.Set("crash_reason", reason)
+	return pbApp.Save(record)
+}
+
+// TouchMatchLastEvent records at, the log time of an event counted towards a match, as the match's
+// last event time unless it already has a later one. It runs for most events, so it skips the
+// record hooks a full save would run.
+func TouchMatchLastEvent(ctx context.Context, pbApp core.App, matchID string, at time.Time) error {
+	stamp := at.UTC().Format(types.DefaultDateLayout)
+	_, err := pbApp.DB().
+		NewQuery("UPDATE matches SET last_event_time = {:at} WHERE id = {:id} AND (last_event_time = '' OR last_event_time < {:at})").
+		Bind(dbx.Params{"at": stamp, "id": matchID}).
+		WithContext(ctx).
+		Execute()
+	return err
+}
+
 // DisconnectAllPlayersInMatch marks all players in a match as disconnected
 func DisconnectAllPlayersInMatch(ctx context.Context, pbApp core.App, matchID string, lastLeftAt *time.Time) error {
 	log := getLogger(pbApp)
@@ -660,6 +835,29 @@ func DisconnectPlayerFromMatch(ctx context.Context, pbApp core.App, matchID, pla
 	return pbApp.Save(record)
 }
 
+// ReconnectPlayerToMatch marks a player who left a match as connected again when they rejoin.
+// A team swap continues the player's session and clears left_at; any other rejoin starts a
+// new session and increments session_count. Players who are still connected are left alone.
+func ReconnectPlayerToMatch(ctx context.Context, pbApp core.App, matchID, playerID string, teamSwap bool) error {
+	record, err := getLatestMatchPlayerStats(pbApp, matchID, playerID)
+	if err != nil {
+		return err
+	}
+	if record.GetBool("is_currently_connected") {
+		return nil
+	}
+
+	if teamSwap {
+		record.Set("left_at", "")
+	} else {
+		record.Set("session_count", record.GetInt("session_count")+1)
+	}
+	record.Set("is_currently_connected", true)
+	record.Set("status", "ongoing")
+
+	return pbApp.Save(record)
+}
+
 // DeleteMatchIfEmpty deletes a match only if it has no player stats or weapon stats
 func DeleteMatchIfEmpty(ctx context.Context, pbApp core.App, matchID string) error {
 	log := getLogger(pbApp)
@@ -677,7 +875,7 @@ func DeleteMatchIfEmpty(ctx context.Context, pbApp core.App, matchID string) err
 	}
 
 	if len(playerStats) > 0 {
-		log.Debug("Match has player stats, keeping it", "matchID", matchID)
+		log.Debug("Match has player stats, keeping it", "match_id", matchID)
 		return nil
 	}
 
@@ -695,7 +893,7 @@ func DeleteMatchIfEmpty(ctx context.Context, pbApp core.App, matchID string) err
 	}
 
 	if len(weaponStats) > 0 {
-		log.Debug("Match has weapon stats, keeping it", "matchID", matchID)
+		log.Debug("Match has weapon stats, keeping it", "match_id", matchID)
 		return nil
 	}
 
@@ -709,7 +907,7 @@ func DeleteMatchIfEmpty(ctx context.Context, pbApp core.App, matchID string) err
 		return fmt.Errorf("failed to delete match: %w", err)
 	}
 
-	log.Debug("Deleted empty match", "matchID", matchID)
+	log.Debug("Deleted empty match", "match_id", matchID)
 	return nil
 }
 
@@ -739,7 +937,7 @@ func UpdateMatchField(ctx context.Context, pbApp core.App, matchID, fieldName, o
 		return fmt.Errorf("failed to update match %s: %w", fieldName, err)
 	}
 
-	log.Debug("Updated field for match", "field", fieldName, "matchID", matchID, "newValue", newValue, "operation", operation)
+	log.Debug("Updated field for match", "field", fieldName, "match_id", matchID, "newValue", newValue, "operation", operation)
 	return nil
 }
 
@@ -759,6 +957,23 @@ func ResetMatchRoundObjective(ctx context.Context, pbApp core.App, matchID strin
 	return UpdateMatchField(ctx, pbApp, matchID, "round_objective", "set", 0)
 }
 
+// EnsureActiveMatch makes the match started by a map event the server's active match, for a
+// replay that starts after that event instead of processing it. An active match started by the
+// same event (same map, start time within a second) is kept; any other is ended, as on a map
+// change, and a match for the event is created. Reports whether a match was created.
+func EnsureActiveMatch(ctx context.Context, pbApp core.App, serverID string, mapName, scenario string, timestamp time.Time) (bool, error) {
+	activeMatch, err := GetActiveMatch(ctx, pbApp, serverID)
+	if err == nil && activeMatch.Map != nil && strings.EqualFold(*activeMatch.Map, mapName) &&
+		activeMatch.StartTime != nil && activeMatch.StartTime.Sub(timestamp).Abs() < time.Second {
+		return false, nil
+	}
+
+	if err := EndActiveMatchAndCreateNew(ctx, pbApp, serverID, mapName, scenario, timestamp, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // EndActiveMatchAndCreateNew ends any active match on a server and creates a new one
 // This is called when a map changes (either initial load or travel during gameplay)
 // It handles:
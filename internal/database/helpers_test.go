@@ -287,6 +287,72 @@ func TestIncrementMatchPlayerStat(t *testing.T) {
 	}
 }
 
+func TestScoreWeights(t *testing.T) {
+	line := StatLine{Kills: 12, Assists: 4, ObjectivesCaptured: 2, ObjectivesDestroyed: 1, Deaths: 7}
+
+	// 12*10 + 4*5 + 2*50 + 1*50 - 7*5
+	if got := DefaultScoreWeights.Score(line); got != 255 {
+		t.Errorf("DefaultScoreWeights.Score() = %d, want 255", got)
+	}
+
+	weights := ScoreWeights{Kill: 3, Assist: 1, ObjectiveCaptured: 20, ObjectiveDestroyed: 30, DeathPenalty: 2}
+	// 12*3 + 4*1 + 2*20 + 1*30 - 7*2
+	if got := weights.Score(line); got != 96 {
+		t.Errorf("Score() = %d, want 96", got)
+	}
+}
+
+func TestComputedScoreUsesMatchWeights(t *testing.T) {
+	testApp, cleanup := setupTestApp(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	if got := GetScoreWeights(testApp); got != DefaultScoreWeights {
+		t.Fatalf("GetScoreWeights() = %+v, want the seeded defaults %+v", got, DefaultScoreWeights)
+	}
+
+	settings, err := testApp.FindFirstRecordByFilter("score_settings", "")
+	if err != nil {
+		t.Fatalf("score_settings record not found: %v", err)
+	}
+	settings.Set("kill", 3)
+	settings.Set("death_penalty", 2)
+	if err := testApp.Save(settings); err != nil {
+		t.Fatalf("failed to update score settings: %v", err)
+	}
+
+	_, _ = GetOrCreateServer(ctx, testApp, "test-server-1", "Test Server", "/test/path")
+	match, _ := CreateMatch(ctx, testApp, "test-server-1", stringPtr("Crossing"), stringPtr("Push"), nil)
+	player, _ := CreatePlayer(ctx, testApp, "76561198012345678", "TestPlayer")
+	UpsertMatchPlayerStats(ctx, testApp, match.ID, player.ID, int64Ptr(0), nil)
+
+	// Changing the settings mid-match must not affect a match that already started
+	settings.Set("kill", 100)
+	if err := testApp.Save(settings); err != nil {
+		t.Fatalf("failed to update score settings: %v", err)
+	}
+
+	for _, field := range []string{"kills", "kills", "assists", "objectives_captured", "deaths"} {
+		if err := IncrementMatchPlayerStat(ctx, testApp, match.ID, player.ID, field); err != nil {
+			t.Fatalf("IncrementMatchPlayerStat(%s) error = %v", field, err)
+		}
+	}
+
+	// 2*3 + 1*5 + 1*50 - 1*2
+	want := 59
+	summary, err := GetMatchSummary(ctx, testApp, match.ID)
+	if err != nil {
+		t.Fatalf("GetMatchSummary() error = %v", err)
+	}
+	if got := summary.Players[0].ComputedScore; got != want {
+		t.Errorf("computed_score = %d, want %d", got, want)
+	}
+	if summary.ScoreWeights == nil || summary.ScoreWeights.Kill != 3 {
+		t.Errorf("match score weights = %+v, want kill weight 3", summary.ScoreWeights)
+	}
+}
+
 func TestEndMatch(t *testing.T) {
 	testApp, cleanup := setupTestApp(t)
 	defer cleanup()
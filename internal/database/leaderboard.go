@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Leaderboard time windows, measured back from now against the match end time
+const (
+	LeaderboardWindowDay   = "day"
+	LeaderboardWindowWeek  = "week"
+	LeaderboardWindowMonth = "month"
+	LeaderboardWindowAll   = "all"
+)
+
+// LeaderboardWindows are the accepted leaderboard windows, in display order
+var LeaderboardWindows = []string{LeaderboardWindowDay, LeaderboardWindowWeek, LeaderboardWindowMonth, LeaderboardWindowAll}
+
+// leaderboardWindowDurations is how far back each window reaches; "all" has no limit
+var leaderboardWindowDurations = map[string]time.Duration{
+	LeaderboardWindowDay:   24 * time.Hour,
+	LeaderboardWindowWeek:  7 * 24 * time.Hour,
+	LeaderboardWindowMonth: 30 * 24 * time.Hour,
+}
+
+// LeaderboardMetrics are the accepted leaderboard rankings, in display order
+var LeaderboardMetrics = []string{"kills", "kd", "score", "objectives"}
+
+// leaderboardOrder is the column each metric ranks by. Only these are ever put into the query.
+var leaderboardOrder = map[string]string{
+	"kills":      "kills",
+	"kd":         "kd",
+	"score":      "score",
+	"objectives": "objectives",
+}
+
+// LeaderboardFilter selects the matches a leaderboard is built from
+type LeaderboardFilter struct {
+	Window     string // One of LeaderboardWindows (default: all)
+	Metric     string // One of LeaderboardMetrics (default: kills)
+	ServerID   string // Server record ID, empty for every server
+	Mode       string // Game mode, empty for every mode
+	PublicOnly bool   // Only count servers shown on the public pages (is_public)
+	NamedOnly  bool   // Only count servers that show player names (hide_player_names off)
+}
+
+// LeaderboardEntry is one player's totals over the leaderboard's matches
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank" db:"-"`
+	PlayerID   string  `json:"player_id" db:"player_id"`
+	Name       string  `json:"name" db:"name"`
+	Kills      int     `json:"kills" db:"kills"`
+	Deaths     int     `json:"deaths" db:"deaths"`
+	Score      int     `json:"score" db:"score"`
+	Objectives int     `json:"objectives" db:"objectives"`
+	Matches    int     `json:"matches" db:"matches"`
+	KD         float64 `json:"kd" db:"kd"`
+}
+
+// LeaderboardSince returns the earliest match end time the window covers, zero for "all"
+func LeaderboardSince(window string, now time.Time) time.Time {
+	duration, ok := leaderboardWindowDurations[window]
+	if !ok {
+		return time.Time{}
+	}
+	return now.Add(-duration)
+}
+
+// GetLeaderboard ranks players across servers by the filter's metric over finished, ranked
+// matches that ended inside the window. Players with nothing to rank by are left out; ties
+// go by name. Returns up to limit entries from offset.
+func GetLeaderboard(ctx context.Context, pbApp core.App, filter LeaderboardFilter, now time.Time, limit, offset int) ([]LeaderboardEntry, error) {
+	metric := filter.Metric
+	if metric == "" {
+		metric = "kills"
+	}
+	order, ok := leaderboardOrder[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard metric %q", filter.Metric)
+	}
+
+	// Finished matches only; the window is a range on the indexed end_time
+	where := "m.end_time != '' AND m.unranked = FALSE"
+	params := dbx.Params{"limit": limit, "offset": offset}
+	if since := LeaderboardSince(filter.Window, now); !since.IsZero() {
+		where += " AND m.end_time >= {:since}"
+		params["since"] = since.UTC().Format(types.DefaultDateLayout)
+	}
+	if filter.ServerID != "" {
+		where += " AND m.server = {:server}"
+		params["server"] = filter.ServerID
+	}
+	if filter.Mode != "" {
+		where += " AND m.mode = {:mode}"
+		params["mode"] = filter.Mode
+	}
+	if filter.PublicOnly {
+		where += " AND m.server IN (SELECT id FROM servers WHERE is_public = TRUE)"
+	}
+	if filter.NamedOnly {
+		where += " AND m.server IN (SELECT id FROM servers WHERE hide_player_names = FALSE)"
+	}
+
+	var entries []LeaderboardEntry
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT
+				p.id as player_id,
+				p.name as name,
+				COALESCE(SUM(s.kills), 0) as kills,
+				COALESCE(SUM(s.deaths), 0) as deaths,
+				COALESCE(SUM(s.score), 0) as score,
+				COALESCE(SUM(s.objectives_captured + s.objectives_destroyed), 0) as objectives,
+				COUNT(DISTINCT s.match) as matches,
+				CAST(COALESCE(SUM(s.kills), 0) AS REAL) / MAX(COALESCE(SUM(s.deaths), 0), 1) as kd
+			FROM matches m
+			JOIN match_player_stats s ON s.match = m.id
+			JOIN players p ON p.id = s.player
+			WHERE ` + where + `
+			GROUP BY p.id
+			HAVING ` + order + ` > 0
+			ORDER BY ` + order + ` DESC, p.name
+			LIMIT {:limit} OFFSET {:offset}
+		`).
+		Bind(params).
+		All(&entries)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Rank = offset + i + 1
+	}
+	return entries, nil
+}
+
+// GetMatchModes returns the game modes of stored matches, for filtering
+func GetMatchModes(ctx context.Context, pbApp core.App) ([]string, error) {
+	var modes []string
+	err := pbApp.DB().
+		NewQuery("SELECT DISTINCT mode FROM matches WHERE mode != '' ORDER BY mode").
+		Column(&modes)
+	return modes, err
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// MapVote represents a completed end-of-match map vote
+type MapVote struct {
+	ID        string
+	ServerID  string // Server record ID
+	MatchID   string // Match the vote ended (may be empty)
+	Options   []events.MapVoteOption
+	Winner    events.MapVoteOption
+	Outcome   string
+	VoteShare *float64
+	Threshold *float64
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// RecordMapVote stores a completed map vote
+func RecordMapVote(ctx context.Context, pbApp core.App, vote *MapVote) error {
+	collection, err := pbApp.FindCollectionByNameOrId("map_votes")
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("server", vote.ServerID)
+	if vote.MatchID != "" {
+		record.Set("match", vote.MatchID)
+	}
+	record.Set("options", vote.Options)
+	record.Set("winner_map", vote.Winner.Map)
+	record.Set("winner_scenario", vote.Winner.Scenario)
+	record.Set("winner_option_id", vote.Winner.ID)
+	record.Set("outcome", vote.Outcome)
+	if vote.VoteShare != nil {
+		record.Set("vote_share", *vote.VoteShare)
+	}
+	if vote.Threshold != nil {
+		record.Set("threshold", *vote.Threshold)
+	}
+	if !vote.StartedAt.IsZero() {
+		record.Set("started_at", vote.StartedAt)
+	}
+	if !vote.EndedAt.IsZero() {
+		record.Set("ended_at", vote.EndedAt)
+	}
+
+	if err := pbApp.Save(record); err != nil {
+		return err
+	}
+	vote.ID = record.Id
+	return nil
+}
+
+// GetRecentMapVotes returns the most recent map votes for a server (by external_id), newest first
+func GetRecentMapVotes(ctx context.Context, pbApp core.App, serverID string, limit int) ([]MapVote, error) {
+	serverRecord, err := pbApp.FindFirstRecordByFilter(
+		"servers",
+		"external_id = {:serverID}",
+		map[string]any{"serverID": serverID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("server not found: %w", err)
+	}
+
+	records, err := pbApp.FindRecordsByFilter(
+		"map_votes",
+		"server = {:server}",
+		"-ended_at",
+		limit,
+		0,
+		map[string]any{"server": serverRecord.Id},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make([]MapVote, len(records))
+	for i, record := range records {
+		votes[i] = MapVote{
+			ID:       record.Id,
+			ServerID: record.GetString("server"),
+			MatchID:  record.GetString("match"),
+			Winner: events.MapVoteOption{
+				ID:       record.GetInt("winner_option_id"),
+				Map:      record.GetString("winner_map"),
+				Scenario: record.GetString("winner_scenario"),
+			},
+			Outcome:   record.GetString("outcome"),
+			StartedAt: record.GetDateTime("started_at").Time(),
+			EndedAt:   record.GetDateTime("ended_at").Time(),
+		}
+
+		if raw := record.GetString("options"); raw != "" {
+			_ = json.Unmarshal([]byte(raw), &votes[i].Options)
+		}
+		if votes[i].Outcome == "majority" {
+			share := record.GetFloat("vote_share")
+			threshold := record.GetFloat("threshold")
+			votes[i].VoteShare = &share
+			votes[i].Threshold = &threshold
+		}
+	}
+
+	return votes, nil
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// mapTopPlayersLimit is the number of top players listed per map
+const mapTopPlayersLimit = 3
+
+// MapInfo is a map's metadata from the maps collection
+type MapInfo struct {
+	Name        string         `json:"name"`         // Map name as logged, e.g. "Town"
+	DisplayName string         `json:"display_name"` // In-game name, e.g. "Hideout"
+	Image       string         `json:"image"`        // Image path or URL, empty when unknown
+	Objectives  map[string]int `json:"objectives"`   // Objective count per game mode
+}
+
+// ObjectiveCount returns the map's objective count for a game mode, or 0 when unknown.
+// Modes are matched case-insensitively, as they are typed in by hand in the dashboard.
+func (m MapInfo) ObjectiveCount(mode string) int {
+	if n, ok := m.Objectives[mode]; ok {
+		return n
+	}
+	for catalogMode, n := range m.Objectives {
+		if strings.EqualFold(catalogMode, mode) {
+			return n
+		}
+	}
+	return 0
+}
+
+// mapInfoFromRecord reads a maps record, falling back to the logged name for the display name
+func mapInfoFromRecord(record *core.Record) MapInfo {
+	info := MapInfo{
+		Name:        record.GetString("name"),
+		DisplayName: record.GetString("display_name"),
+		Image:       record.GetString("image"),
+	}
+	_ = record.UnmarshalJSONField("objectives", &info.Objectives)
+	if info.DisplayName == "" {
+		info.DisplayName = info.Name
+	}
+	return info
+}
+
+// GetMapInfo returns the metadata of a map. Unknown maps get their logged name as display name
+// and no image or objective counts.
+func GetMapInfo(pbApp core.App, mapName string) MapInfo {
+	record, err := pbApp.FindFirstRecordByData("maps", "name", mapName)
+	if err != nil {
+		return MapInfo{Name: mapName, DisplayName: mapName}
+	}
+	return mapInfoFromRecord(record)
+}
+
+// ResolveNumObjectives returns a match's objective count: num_objectives as set from the maps
+// catalog when the match was created, otherwise the catalog's current count for its map and mode,
+// for matches created before the catalog had one (0 when neither is known)
+func ResolveNumObjectives(pbApp core.App, match *core.Record) int {
+	if n := match.GetInt("num_objectives"); n > 0 {
+		return n
+	}
+	mapName := match.GetString("map")
+	if mapName == "" {
+		return 0
+	}
+	return GetMapInfo(pbApp, mapName).ObjectiveCount(match.GetString("mode"))
+}
+
+// MapTopPlayer is a player ranked by kills on one map
+type MapTopPlayer struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Kills int    `json:"kills"`
+}
+
+// MapStats is the per-map leaderboard entry
+type MapStats struct {
+	MapInfo
+	MatchesPlayed      int            `json:"matches_played"`
+	AvgDurationSeconds int            `json:"avg_duration_seconds"` // Over matches with a known duration, see MatchDuration
+	MostPlayedMode     string         `json:"most_played_mode"`
+	TopPlayers         []MapTopPlayer `json:"top_players"`
+}
+
+// GetMapStats returns stats for every map with at least one match, most played first.
+// publicOnly leaves out servers hidden from the public pages, and keeps players of servers that
+// hide player names out of the top players.
+func GetMapStats(ctx context.Context, pbApp core.App, publicOnly bool) ([]MapStats, error) {
+	matchesWhere, playersWhere := "", ""
+	if publicOnly {
+		matchesWhere = " AND server IN (SELECT id FROM servers WHERE is_public = TRUE)"
+		playersWhere = " AND m.server IN (SELECT id FROM servers WHERE is_public = TRUE AND hide_player_names = FALSE)"
+	}
+
+	type mapRow struct {
+		Map                string  `db:"map"`
+		MatchesPlayed      int     `db:"matches_played"`
+		AvgDurationSeconds float64 `db:"avg_duration_seconds"`
+	}
+
+	var mapRows []mapRow
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT
+				map,
+				COUNT(*) as matches_played,
+				COALESCE(AVG(CASE
+					WHEN duration_seconds >= 0 THEN duration_seconds
+				END), 0) as avg_duration_seconds
+			FROM (
+				-- Timed as MatchDuration does: crashed matches up to their last event, or not at all
+				SELECT map, server, CASE
+					WHEN start_time = '' OR end_time = '' THEN NULL
+					WHEN status != 'crashed' THEN (julianday(end_time) - julianday(start_time)) * 86400
+					WHEN last_event_time != '' THEN (julianday(MIN(end_time, last_event_time)) - julianday(start_time)) * 86400
+				END as duration_seconds
+				FROM matches
+			)
+			WHERE map != ''` + matchesWhere + `
+			GROUP BY map
+			ORDER BY matches_played DESC, map
+		`).
+		All(&mapRows)
+	if err != nil {
+		return nil, err
+	}
+
+	type modeRow struct {
+		Map   string `db:"map"`
+		Mode  string `db:"mode"`
+		Count int    `db:"count"`
+	}
+
+	var modeRows []modeRow
+	err = pbApp.DB().
+		NewQuery(`
+			SELECT map, mode, COUNT(*) as count
+			FROM matches
+			WHERE map != '' AND mode != ''` + matchesWhere + `
+			GROUP BY map, mode
+			ORDER BY count DESC, mode
+		`).
+		All(&modeRows)
+	if err != nil {
+		return nil, err
+	}
+
+	type playerRow struct {
+		Map   string `db:"map"`
+		ID    string `db:"id"`
+		Name  string `db:"name"`
+		Kills int    `db:"kills"`
+	}
+
+	var playerRows []playerRow
+	err = pbApp.DB().
+		NewQuery(`
+			SELECT m.map as map, p.id as id, p.name as name, SUM(s.kills) as kills
+			FROM match_player_stats s
+			JOIN matches m ON m.id = s.match
+			JOIN players p ON p.id = s.player
+			WHERE m.map != ''` + playersWhere + `
+			GROUP BY m.map, p.id
+			HAVING SUM(s.kills) > 0
+			ORDER BY kills DESC, p.name
+		`).
+		All(&playerRows)
+	if err != nil {
+		return nil, err
+	}
+
+	mostPlayedMode := make(map[string]string)
+	for _, row := range modeRows {
+		if _, ok := mostPlayedMode[row.Map]; !ok {
+			mostPlayedMode[row.Map] = row.Mode
+		}
+	}
+
+	topPlayers := make(map[string][]MapTopPlayer)
+	for _, row := range playerRows {
+		if len(topPlayers[row.Map]) < mapTopPlayersLimit {
+			topPlayers[row.Map] = append(topPlayers[row.Map], MapTopPlayer{ID: row.ID, Name: row.Name, Kills: row.Kills})
+		}
+	}
+
+	metadata := make(map[string]MapInfo)
+	if records, err := pbApp.FindAllRecords("maps"); err == nil {
+		for _, record := range records {
+			info := mapInfoFromRecord(record)
+			metadata[info.Name] = info
+		}
+	}
+
+	stats := make([]MapStats, 0, len(mapRows))
+	for _, row := range mapRows {
+		info, ok := metadata[row.Map]
+		if !ok {
+			info = MapInfo{Name: row.Map, DisplayName: row.Map}
+		}
+		stats = append(stats, MapStats{
+			MapInfo:            info,
+			MatchesPlayed:      row.MatchesPlayed,
+			AvgDurationSeconds: int(row.AvgDurationSeconds),
+			MostPlayedMode:     mostPlayedMode[row.Map],
+			TopPlayers:         topPlayers[row.Map],
+		})
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// NoWinningTeam is stored in matches.winning_team when a match has no winner
+// (a tie, a crash, or no rounds played)
+const NoWinningTeam = -1
+
+// Match results attributed to players in match_player_stats.result
+const (
+	MatchResultWin  = "win"
+	MatchResultLoss = "loss"
+	MatchResultTie  = "tie"
+)
+
+// RoundResult is one entry of matches.round_results
+type RoundResult struct {
+	Round       int `json:"round"`
+	WinningTeam int `json:"winning_team"`
+}
+
+// RecordRoundWin credits a round win to a team (0 = Security, 1 = Insurgents)
+// and appends the result to the match's round-by-round history.
+// The round number is taken from the match's round counter, so call it after IncrementMatchRound.
+func RecordRoundWin(ctx context.Context, pbApp core.App, matchID string, team int) error {
+	if team != 0 && team != 1 {
+		return fmt.Errorf("invalid team: %d", team)
+	}
+
+	record, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return fmt.Errorf("failed to find match %s: %w", matchID, err)
+	}
+
+	// An empty field fails to unmarshal and leaves rounds nil, starting a fresh history
+	var rounds []RoundResult
+	_ = record.UnmarshalJSONField("round_results", &rounds)
+	rounds = append(rounds, RoundResult{Round: record.GetInt("round"), WinningTeam: team})
+
+	winsField := fmt.Sprintf("team_%d_round_wins", team)
+	record.Set(winsField, record.GetInt(winsField)+1)
+	record.Set("round_results", rounds)
+
+	if err := pbApp.Save(record); err != nil {
+		return fmt.Errorf("failed to record round win for match %s: %w", matchID, err)
+	}
+	return nil
+}
+
+// RecordMatchOutcome decides the match winner from the per-team round wins and attributes a
+// win, loss or tie to every connected player whose team is known.
+// A match without any round results gets no winner and no player results.
+// Returns the winning team, or NoWinningTeam for a tie or undecided match.
+func RecordMatchOutcome(ctx context.Context, pbApp core.App, matchID string) (int, error) {
+	log := getLogger(pbApp)
+	record, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return NoWinningTeam, err
+	}
+
+	team0Wins := record.GetInt("team_0_round_wins")
+	team1Wins := record.GetInt("team_1_round_wins")
+
+	winningTeam := NoWinningTeam
+	switch {
+	case team0Wins > team1Wins:
+		winningTeam = 0
+	case team1Wins > team0Wins:
+		winningTeam = 1
+	}
+
+	record.Set("winning_team", winningTeam)
+	if err := pbApp.Save(record); err != nil {
+		return NoWinningTeam, err
+	}
+
+	if team0Wins+team1Wins == 0 {
+		log.Debug("Match ended without round results, no winner recorded", "match_id", matchID)
+		return winningTeam, nil
+	}
+
+	players, err := pbApp.FindRecordsByFilter(
+		"match_player_stats",
+		"match = {:match} && is_currently_connected = true",
+		"",
+		-1,
+		0,
+		map[string]any{"match": matchID},
+	)
+	if err != nil {
+		return winningTeam, err
+	}
+
+	for _, player := range players {
+		team := player.GetInt("team")
+		if team != 0 && team != 1 {
+			continue
+		}
+
+		result := MatchResultTie
+		if winningTeam != NoWinningTeam {
+			result = MatchResultLoss
+			if team == winningTeam {
+				result = MatchResultWin
+			}
+		}
+
+		player.Set("result", result)
+		if err := pbApp.Save(player); err != nil {
+			return winningTeam, err
+		}
+	}
+
+	log.Debug("Recorded match outcome", "match_id", matchID, "winningTeam", winningTeam, "team0RoundWins", team0Wins, "team1RoundWins", team1Wins)
+	return winningTeam, nil
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// TeamNames maps match_player_stats.team numbers to display names
+var TeamNames = map[int]string{
+	0: "Security",
+	1: "Insurgents",
+}
+
+// MatchPlayerSummary is one player's line in a match summary
+type MatchPlayerSummary struct {
+	Rank                int    `json:"rank"`
+	PlayerID            string `json:"player_id"`
+	SteamID             string `json:"steam_id"`
+	Name                string `json:"name"`                   // Name used in the match
+	CurrentName         string `json:"current_name,omitempty"` // Set when the player has been renamed since
+	Team                int    `json:"team"`
+	Kills               int    `json:"kills"`
+	Deaths              int    `json:"deaths"`
+	Assists             int    `json:"assists"`
+	ObjectivesCaptured  int    `json:"objectives_captured"`
+	ObjectivesDestroyed int    `json:"objectives_destroyed"`
+	Score               int    `json:"score"`
+	ComputedScore       int    `json:"computed_score"`
+	Result              string `json:"result"`
+}
+
+// MatchTeamSummary holds the summed stats of every player on one team
+type MatchTeamSummary struct {
+	Team                int    `json:"team"`
+	Name                string `json:"name"`
+	Players             int    `json:"players"`
+	RoundWins           int    `json:"round_wins"`
+	Kills               int    `json:"kills"`
+	Deaths              int    `json:"deaths"`
+	Assists             int    `json:"assists"`
+	ObjectivesCaptured  int    `json:"objectives_captured"`
+	ObjectivesDestroyed int    `json:"objectives_destroyed"`
+	Score               int    `json:"score"`
+	ComputedScore       int    `json:"computed_score"`
+}
+
+// MatchSummary is the structured result of a single match
+type MatchSummary struct {
+	ID              string               `json:"id"`
+	ServerID        string               `json:"server_id"`
+	Map             string               `json:"map"`
+	Title           string               `json:"title"`
+	Mode            string               `json:"mode"`
+	Scenario        string               `json:"scenario"`
+	Status          string               `json:"status"`
+	CrashReason     string               `json:"crash_reason,omitempty"` // Fatal error logged when the server crashed mid-match
+	StartTime       *time.Time           `json:"start_time"`
+	EndTime         *time.Time           `json:"end_time"`
+	LastEventTime   *time.Time           `json:"last_event_time,omitempty"` // Log time of the match's last kill, objective or chat
+	DurationSeconds int                  `json:"duration_seconds"`
+	DurationUnknown bool                 `json:"duration_unknown,omitempty"` // Set for a crashed match with no events to time it by
+	WinningTeam     int                  `json:"winning_team"`
+	MVPPlayerID     string               `json:"mvp_player_id"` // Player record ID of the match MVP, empty until the match ends
+	ScoreWeights    *ScoreWeights        `json:"score_weights"`
+	Teams           []MatchTeamSummary   `json:"teams"`
+	Rounds          []RoundResult        `json:"rounds"`
+	Players         []MatchPlayerSummary `json:"players"`
+}
+
+// MatchDuration returns how long an ended match lasted, and whether that is known. A crashed
+// match's end time is when the tracker noticed, which can be hours after the server went down, so
+// it is timed up to its last event instead; with no events to go by its duration is unknown.
+func MatchDuration(record *core.Record) (time.Duration, bool) {
+	start := record.GetDateTime("start_time")
+	end := record.GetDateTime("end_time")
+	if start.IsZero() || end.IsZero() {
+		return 0, false
+	}
+	if record.GetString("status") == "crashed" {
+		lastEvent := record.GetDateTime("last_event_time")
+		if lastEvent.IsZero() {
+			return 0, false
+		}
+		if lastEvent.Time().Before(end.Time()) {
+			end = lastEvent
+		}
+	}
+	// Start and end come from different clocks when a match was closed as stale
+	duration := end.Time().Sub(start.Time())
+	if duration < 0 {
+		return 0, false
+	}
+	return duration, true
+}
+
+// GetMatchPlayerSummaries returns every player's stats for a match, ranked by score,
+// then kills, then fewest deaths
+func GetMatchPlayerSummaries(ctx context.Context, pbApp core.App, matchID string) ([]MatchPlayerSummary, error) {
+	stats, err := pbApp.FindRecordsByFilter(
+		"match_player_stats",
+		"match = {:match}",
+		"",
+		-1,
+		0,
+		map[string]any{"match": matchID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := pbApp.ExpandRecords(stats, []string{"player"}, nil); len(errs) > 0 {
+		getLogger(pbApp).Debug("Failed to expand players for match summary", "match_id", matchID, "errors", errs)
+	}
+
+	players := make([]MatchPlayerSummary, 0, len(stats))
+	for _, stat := range stats {
+		playerRecord := stat.ExpandedOne("player")
+		if playerRecord == nil {
+			continue
+		}
+
+		// Rows from before names were kept per match fall back to the current name
+		name, currentName := stat.GetString("player_name"), playerRecord.GetString("name")
+		if name == "" || name == currentName {
+			name, currentName = currentName, ""
+		}
+
+		players = append(players, MatchPlayerSummary{
+			PlayerID:            playerRecord.Id,
+			SteamID:             playerRecord.GetString("external_id"),
+			Name:                name,
+			CurrentName:         currentName,
+			Team:                stat.GetInt("team"),
+			Kills:               stat.GetInt("kills"),
+			Deaths:              stat.GetInt("deaths"),
+			Assists:             stat.GetInt("assists"),
+			ObjectivesCaptured:  stat.GetInt("objectives_captured"),
+			ObjectivesDestroyed: stat.GetInt("objectives_destroyed"),
+			Score:               stat.GetInt("score"),
+			ComputedScore:       stat.GetInt("computed_score"),
+			Result:              stat.GetString("result"),
+		})
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kills != b.Kills {
+			return a.Kills > b.Kills
+		}
+		return a.Deaths < b.Deaths
+	})
+	for i := range players {
+		players[i].Rank = i + 1
+	}
+
+	return players, nil
+}
+
+// SumTeamStats totals player stats per team; players whose team is unknown are left out
+func SumTeamStats(players []MatchPlayerSummary) []MatchTeamSummary {
+	teams := []MatchTeamSummary{
+		{Team: 0, Name: TeamNames[0]},
+		{Team: 1, Name: TeamNames[1]},
+	}
+
+	for _, player := range players {
+		if player.Team != 0 && player.Team != 1 {
+			continue
+		}
+		team := &teams[player.Team]
+		team.Players++
+		team.Kills += player.Kills
+		team.Deaths += player.Deaths
+		team.Assists += player.Assists
+		team.ObjectivesCaptured += player.ObjectivesCaptured
+		team.ObjectivesDestroyed += player.ObjectivesDestroyed
+		team.Score += player.Score
+		team.ComputedScore += player.ComputedScore
+	}
+
+	return teams
+}
+
+// GetMatchSummary builds the structured summary of a match.
+// Returns the record lookup error (sql.ErrNoRows) for an unknown match id.
+func GetMatchSummary(ctx context.Context, pbApp core.App, matchID string) (*MatchSummary, error) {
+	record, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	players, err := GetMatchPlayerSummaries(ctx, pbApp, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty field fails to unmarshal and leaves rounds nil
+	var rounds []RoundResult
+	_ = record.UnmarshalJSONField("round_results", &rounds)
+	if rounds == nil {
+		rounds = []RoundResult{}
+	}
+
+	var weights *ScoreWeights
+	_ = record.UnmarshalJSONField("score_weights", &weights)
+
+	summary := &MatchSummary{
+		ID:           record.Id,
+		ServerID:     record.GetString("server"),
+		Map:          record.GetString("map"),
+		Title:        record.GetString("title"),
+		Mode:         record.GetString("mode"),
+		Scenario:     record.GetString("scenario"),
+		Status:       record.GetString("status"),
+		CrashReason:  record.GetString("crash_reason"),
+		WinningTeam:  record.GetInt("winning_team"),
+		MVPPlayerID:  record.GetString("mvp_player"),
+		ScoreWeights: weights,
+		Teams:        SumTeamStats(players),
+		Rounds:       rounds,
+		Players:      players,
+	}
+	for i := range summary.Teams {
+		summary.Teams[i].RoundWins = record.GetInt(fmt.Sprintf("team_%d_round_wins", i))
+	}
+
+	if startTime := record.GetDateTime("start_time"); !startTime.IsZero() {
+		t := startTime.Time()
+		summary.StartTime = &t
+	}
+	if endTime := record.GetDateTime("end_time"); !endTime.IsZero() {
+		t := endTime.Time()
+		summary.EndTime = &t
+		duration, known := MatchDuration(record)
+		summary.DurationSeconds = int(duration.Seconds())
+		summary.DurationUnknown = !known
+	}
+	if lastEvent := record.GetDateTime("last_event_time"); !lastEvent.IsZero() {
+		t := lastEvent.Time()
+		summary.LastEventTime = &t
+	}
+
+	return summary, nil
+}
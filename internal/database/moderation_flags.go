@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Moderation flag kinds stored in moderation_flags.kind
+const (
+	FlagHeadshotRatio = "headshot_ratio" // Share of kills that were headshots
+	FlagKillRate      = "kill_rate"      // Kills per minute within a short window
+	FlagZeroDeaths    = "zero_deaths"    // Kills across finished matches without dying
+)
+
+// ModerationFlag is a suspicious signal for a player. Flags are advisory: they point a human at
+// a player worth reviewing and never kick or ban anyone.
+type ModerationFlag struct {
+	Kind      string  `json:"kind"`
+	Value     float64 `json:"value"`     // Measured value of the signal
+	Threshold float64 `json:"threshold"` // Threshold it crossed
+	MatchID   string  `json:"match,omitempty"`
+	Details   string  `json:"details,omitempty"`
+}
+
+// PlayerFlag is the summary of a flag kept in players.metadata.flags
+type PlayerFlag struct {
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Advisory  bool      `json:"advisory"`
+	RaisedAt  time.Time `json:"raised_at"`
+}
+
+// UpdatePlayerMetadata applies update to a player's metadata and saves it, keeping the keys update leaves alone
+func UpdatePlayerMetadata(pbApp core.App, player *core.Record, update func(metadata map[string]any)) error {
+	metadata := playerMetadata(player)
+	update(metadata)
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	player.Set("metadata", string(metadataJSON))
+	return pbApp.Save(player)
+}
+
+// playerMetadata decodes a player's metadata, or returns an empty map when it is unset or invalid
+func playerMetadata(player *core.Record) map[string]any {
+	metadata := map[string]any{}
+	if raw := player.GetString("metadata"); raw != "" && raw != "null" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil || metadata == nil {
+			metadata = map[string]any{}
+		}
+	}
+	return metadata
+}
+
+// GetPlayerFlags returns the flags recorded in a player's metadata, by kind
+func GetPlayerFlags(player *core.Record) map[string]PlayerFlag {
+	var metadata struct {
+		Flags map[string]PlayerFlag `json:"flags"`
+	}
+	if raw := player.GetString("metadata"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &metadata)
+	}
+	return metadata.Flags
+}
+
+// RaiseModerationFlag records a flag for a player (players record ID) in moderation_flags and
+// players.metadata.flags. A player has at most one flag per kind and match (per kind for
+// flags without a match); raising it again keeps the highest value.
+// Returns true when a new flag was created.
+func RaiseModerationFlag(ctx context.Context, pbApp core.App, playerID string, flag ModerationFlag) (bool, error) {
+	record, err := pbApp.FindFirstRecordByFilter(
+		"moderation_flags",
+		"player = {:player} && kind = {:kind} && match = {:match}",
+		dbx.Params{"player": playerID, "kind": flag.Kind, "match": flag.MatchID},
+	)
+	created := err != nil
+	if created {
+		collection, err := pbApp.FindCollectionByNameOrId("moderation_flags")
+		if err != nil {
+			return false, err
+		}
+		record = core.NewRecord(collection)
+		record.Set("player", playerID)
+		record.Set("kind", flag.Kind)
+		record.Set("match", flag.MatchID)
+		record.Set("advisory", true)
+	} else if record.GetFloat("value") >= flag.Value {
+		return false, nil
+	}
+
+	record.Set("value", flag.Value)
+	record.Set("threshold", flag.Threshold)
+	record.Set("details", flag.Details)
+	if err := pbApp.Save(record); err != nil {
+		return false, err
+	}
+
+	player, err := pbApp.FindRecordById("players", playerID)
+	if err != nil {
+		return created, err
+	}
+	err = UpdatePlayerMetadata(pbApp, player, func(metadata map[string]any) {
+		flags, _ := metadata["flags"].(map[string]any)
+		if flags == nil {
+			flags = map[string]any{}
+		}
+		flags[flag.Kind] = PlayerFlag{
+			Value:     flag.Value,
+			Threshold: flag.Threshold,
+			Advisory:  true,
+			RaisedAt:  time.Now().UTC(),
+		}
+		metadata["flags"] = flags
+	})
+	return created, err
+}
+
+// GetPlayerKillTotals returns a player's kills and headshots across all matches
+func GetPlayerKillTotals(ctx context.Context, pbApp core.App, playerID string) (kills, headshots int, err error) {
+	var row struct {
+		Kills     int `db:"kills"`
+		Headshots int `db:"headshots"`
+	}
+	err = pbApp.DB().
+		NewQuery(`
+			SELECT
+				COALESCE(SUM(kills), 0) as kills,
+				COALESCE(SUM(headshots), 0) as headshots
+			FROM match_player_stats
+			WHERE player = {:player}
+		`).
+		Bind(dbx.Params{"player": playerID}).
+		One(&row)
+	return row.Kills, row.Headshots, err
+}
+
+// GetPlayerDeathlessMatches returns the finished matches a player scored kills in without dying,
+// and the kills across them
+func GetPlayerDeathlessMatches(ctx context.Context, pbApp core.App, playerID string) (matches, kills int, err error) {
+	var row struct {
+		Matches int `db:"matches"`
+		Kills   int `db:"kills"`
+	}
+	err = pbApp.DB().
+		NewQuery(`
+			SELECT
+				COUNT(*) as matches,
+				COALESCE(SUM(s.kills), 0) as kills
+			FROM match_player_stats s
+			JOIN matches m ON m.id = s.match
+			WHERE s.player = {:player} AND s.deaths = 0 AND s.kills > 0 AND m.end_time != ''
+		`).
+		Bind(dbx.Params{"player": playerID}).
+		One(&row)
+	return row.Matches, row.Kills, err
+}
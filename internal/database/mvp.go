@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// MVP metrics: the match_player_stats field a match's MVP is picked by
+const (
+	MVPMetricScore         = "score"          // In-game scoreboard score, read over RCON
+	MVPMetricComputedScore = "computed_score" // The tracker's own score formula (see ScoreWeights)
+	MVPMetricKills         = "kills"
+)
+
+// mvpCandidate is one player's line when picking a match MVP
+type mvpCandidate struct {
+	PlayerID string
+	Value    int
+	Kills    int
+	Deaths   int
+}
+
+// betterMVP reports whether a ranks above b: higher metric value, then higher K/D,
+// then fewer deaths, then more kills, then player ID so ties always resolve the same way
+func betterMVP(a, b mvpCandidate) bool {
+	if a.Value != b.Value {
+		return a.Value > b.Value
+	}
+	// Compare K/D without floats; no deaths counts as one so a flawless match ranks by kills
+	if kdA, kdB := a.Kills*max(b.Deaths, 1), b.Kills*max(a.Deaths, 1); kdA != kdB {
+		return kdA > kdB
+	}
+	if a.Deaths != b.Deaths {
+		return a.Deaths < b.Deaths
+	}
+	if a.Kills != b.Kills {
+		return a.Kills > b.Kills
+	}
+	return a.PlayerID < b.PlayerID
+}
+
+// RecordMatchMVP picks the match's most valuable player by metric and stores it in matches.mvp_player.
+// When the metric is score and nobody has a scoreboard score (no RCON, or a replayed log), computed_score
+// is used instead. A match where nobody scored gets no MVP. Returns the MVP's player record ID, or "".
+func RecordMatchMVP(ctx context.Context, pbApp core.App, matchID, metric string) (string, error) {
+	record, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return "", fmt.Errorf("failed to find match %s: %w", matchID, err)
+	}
+
+	stats, err := pbApp.FindRecordsByFilter(
+		"match_player_stats",
+		"match = {:match}",
+		"",
+		-1,
+		0,
+		map[string]any{"match": matchID},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	field := metric
+	switch metric {
+	case MVPMetricScore, MVPMetricComputedScore, MVPMetricKills:
+	default:
+		field = MVPMetricScore
+	}
+	if field == MVPMetricScore && !anyPositive(stats, MVPMetricScore) {
+		field = MVPMetricComputedScore
+	}
+
+	candidates := make([]mvpCandidate, 0, len(stats))
+	for _, stat := range stats {
+		candidates = append(candidates, mvpCandidate{
+			PlayerID: stat.GetString("player"),
+			Value:    stat.GetInt(field),
+			Kills:    stat.GetInt("kills"),
+			Deaths:   stat.GetInt("deaths"),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return betterMVP(candidates[i], candidates[j]) })
+
+	mvp := ""
+	if len(candidates) > 0 && candidates[0].Value > 0 {
+		mvp = candidates[0].PlayerID
+	}
+
+	record.Set("mvp_player", mvp)
+	if err := pbApp.Save(record); err != nil {
+		return "", fmt.Errorf("failed to record MVP for match %s: %w", matchID, err)
+	}
+
+	getLogger(pbApp).Debug("Recorded match MVP", "match_id", matchID, "player", mvp, "metric", field)
+	return mvp, nil
+}
+
+// anyPositive reports whether any record has a positive value in field
+func anyPositive(records []*core.Record, field string) bool {
+	for _, record := range records {
+		if record.GetInt(field) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPlayerMVPCounts returns how many ranked matches each player was MVP of, keyed by player record ID
+func GetPlayerMVPCounts(ctx context.Context, pbApp core.App) (map[string]int, error) {
+	var rows []struct {
+		Player string `db:"player"`
+		Count  int    `db:"count"`
+	}
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT mvp_player as player, COUNT(*) as count
+			FROM matches
+			WHERE mvp_player != '' AND unranked = FALSE
+			GROUP BY mvp_player
+		`).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Player] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Objective actions stored in objective_events.action
+const (
+	ObjectiveCaptured  = "captured"
+	ObjectiveDestroyed = "destroyed"
+)
+
+// ObjectiveEvent is one objective taken during a match
+type ObjectiveEvent struct {
+	ID        string                   `json:"id"`
+	MatchID   string                   `json:"match"`
+	EventID   string                   `json:"event,omitempty"` // Source events record
+	Objective string                   `json:"objective"`       // Objective number from the log, 0 is A
+	Action    string                   `json:"action"`          // ObjectiveCaptured or ObjectiveDestroyed
+	Team      int                      `json:"team"`            // Team that captured or destroyed it
+	Players   []events.ObjectivePlayer `json:"players"`
+	Timestamp time.Time                `json:"timestamp"`
+}
+
+// Label returns the objective's letter (objective 0 is A), or the raw objective when it isn't a number
+func (o ObjectiveEvent) Label() string {
+	n, err := strconv.Atoi(o.Objective)
+	if err != nil || n < 0 || n >= 26 {
+		return o.Objective
+	}
+	return string(rune('A' + n))
+}
+
+// RecordObjectiveEvent adds an objective to its match's timeline
+func RecordObjectiveEvent(ctx context.Context, pbApp core.App, objective *ObjectiveEvent) error {
+	collection, err := pbApp.FindCollectionByNameOrId("objective_events")
+	if err != nil {
+		return err
+	}
+
+	players, err := json.Marshal(objective.Players)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("match", objective.MatchID)
+	record.Set("event", objective.EventID)
+	record.Set("objective", objective.Objective)
+	record.Set("action", objective.Action)
+	record.Set("team", objective.Team)
+	record.Set("players", string(players))
+	record.Set("timestamp", objective.Timestamp.UTC().Format(time.RFC3339Nano))
+	if err := pbApp.Save(record); err != nil {
+		return err
+	}
+
+	objective.ID = record.Id
+	return nil
+}
+
+// GetMatchObjectiveTimeline returns a match's objectives in the order they were taken
+func GetMatchObjectiveTimeline(ctx context.Context, pbApp core.App, matchID string) ([]ObjectiveEvent, error) {
+	records, err := pbApp.FindRecordsByFilter(
+		"objective_events",
+		"match = {:match}",
+		"timestamp,created,id",
+		-1,
+		0,
+		dbx.Params{"match": matchID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]ObjectiveEvent, 0, len(records))
+	for _, record := range records {
+		objective := ObjectiveEvent{
+			ID:        record.Id,
+			MatchID:   record.GetString("match"),
+			EventID:   record.GetString("event"),
+			Objective: record.GetString("objective"),
+			Action:    record.GetString("action"),
+			Team:      record.GetInt("team"),
+			Timestamp: record.GetDateTime("timestamp").Time(),
+		}
+		_ = record.UnmarshalJSONField("players", &objective.Players)
+		timeline = append(timeline, objective)
+	}
+	return timeline, nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Platforms a player's external_id comes from, stored in players.platform
+const (
+	PlatformSteam       = "steam"
+	PlatformEpic        = "epic"
+	PlatformXbox        = "xbox"
+	PlatformPlayStation = "playstation"
+)
+
+// platformLabels are the display names of the known platforms
+var platformLabels = map[string]string{
+	PlatformSteam:       "Steam",
+	PlatformEpic:        "Epic",
+	PlatformXbox:        "Xbox",
+	PlatformPlayStation: "PlayStation",
+}
+
+// NormalizePlatform maps the platform named in a login line (SteamNWI, EOS, XboxOne, PS5, ...)
+// to one of the Platform constants. Steam is the default; an unknown platform is kept lowercased.
+func NormalizePlatform(raw string) string {
+	platform := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case platform == "", strings.HasPrefix(platform, "steam"):
+		return PlatformSteam
+	case platform == "epic", platform == "eos":
+		return PlatformEpic
+	case strings.HasPrefix(platform, "xbox"), platform == "xsx", platform == "gdk":
+		return PlatformXbox
+	case strings.HasPrefix(platform, "ps"), platform == "playstation":
+		return PlatformPlayStation
+	}
+	return platform
+}
+
+// PlatformLabel returns the display name of a stored platform, empty for name-only players
+func PlatformLabel(platform string) string {
+	if label, ok := platformLabels[platform]; ok {
+		return label
+	}
+	return platform
+}
+
+// GetPlayerByPlatformID fetches a player by its platform and external_id
+func GetPlayerByPlatformID(ctx context.Context, pbApp core.App, platform, externalID string) (*Player, error) {
+	record, err := pbApp.FindFirstRecordByFilter(
+		"players",
+		"platform = {:platform} && external_id = {:externalID}",
+		dbx.Params{"platform": NormalizePlatform(platform), "externalID": externalID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return playerFromRecord(record), nil
+}
+
+// GetOrCreatePlayerByPlatformID finds or creates a player by platform and external_id, so the
+// same ID on two platforms gives two players
+func GetOrCreatePlayerByPlatformID(ctx context.Context, pbApp core.App, platform, externalID, name string) (*Player, error) {
+	if player, err := findLoginPlayer(ctx, pbApp, platform, externalID); err == nil {
+		return player, nil
+	}
+
+	return createPlayer(ctx, pbApp, NormalizePlatform(platform), externalID, name)
+}
+
+// findLoginPlayer finds the player a login's platform and ID belong to. A player created for
+// the ID before its platform was known (see CreatePlayer) is claimed and given the platform
+// rather than duplicated.
+func findLoginPlayer(ctx context.Context, pbApp core.App, platform, externalID string) (*Player, error) {
+	platform = NormalizePlatform(platform)
+	if player, err := GetPlayerByPlatformID(ctx, pbApp, platform, externalID); err == nil {
+		return player, nil
+	}
+
+	record, err := pbApp.FindFirstRecordByFilter(
+		"players",
+		"platform = '' && external_id = {:externalID}",
+		dbx.Params{"externalID": externalID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	record.Set("platform", platform)
+	if err := pbApp.Save(record); err != nil {
+		return nil, err
+	}
+	return playerFromRecord(record), nil
+}
@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PlayerMergeResult reports what merging a duplicate player moved onto the kept player
+type PlayerMergeResult struct {
+	Target          string `json:"target"`
+	Source          string `json:"source"`
+	MatchStats      int    `json:"matchStats"`      // match_player_stats rows moved or folded
+	WeaponStats     int    `json:"weaponStats"`     // match_weapon_stats rows moved or folded
+	FriendlyFire    int    `json:"friendlyFire"`    // friendly_fire_incidents reassigned (as killer or victim)
+	OtherReferences int    `json:"otherReferences"` // chat messages, moderation flags, names and match MVPs
+}
+
+// Counters added together when both players have a stats row for the same match (and weapon)
+var (
+	mergedPlayerStatCounters = []string{
+		"kills", "assists", "deaths", "headshots", "friendly_fire_kills", "score", "computed_score",
+		"objectives_captured", "objectives_destroyed", "total_play_time", "session_count", "multi_kills",
+	}
+	mergedWeaponStatCounters = []string{"kills", "headshots", "shots", "hits"}
+
+	// Counters of a visit that was recorded twice in the same match (see LinkNameOnlyPlayer),
+	// which take the larger of the two rows instead of adding up
+	sameVisitStatCounters = []string{"total_play_time", "session_count"}
+)
+
+// MergePlayers folds a duplicate player (sourceID) into the player being kept (targetID) and
+// deletes the duplicate, all in one transaction. Stats rows are moved to the target, or added
+// into the target's row when both played the same match; friendly fire incidents, chat
+// messages, moderation flags, name history and match MVPs are reassigned; known IPs are
+// combined. The target takes the source's Steam ID when it has none of its own.
+func MergePlayers(ctx context.Context, pbApp core.App, sourceID, targetID string) (PlayerMergeResult, error) {
+	return mergePlayers(ctx, pbApp, sourceID, targetID, "")
+}
+
+// mergePlayers is MergePlayers, treating both players' rows of sameVisitMatch (when set) as the
+// same visit recorded twice rather than two visits
+func mergePlayers(ctx context.Context, pbApp core.App, sourceID, targetID, sameVisitMatch string) (PlayerMergeResult, error) {
+	result := PlayerMergeResult{Source: sourceID, Target: targetID}
+	if sourceID == targetID {
+		return result, fmt.Errorf("cannot merge a player into itself")
+	}
+
+	err := pbApp.RunInTransaction(func(txApp core.App) error {
+		source, err := txApp.FindRecordById("players", sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to find source player %s: %w", sourceID, err)
+		}
+		target, err := txApp.FindRecordById("players", targetID)
+		if err != nil {
+			return fmt.Errorf("failed to find target player %s: %w", targetID, err)
+		}
+
+		if result.MatchStats, err = mergeStatRows(txApp, "match_player_stats", source.Id, target.Id, nil, mergedPlayerStatCounters, sameVisitMatch); err != nil {
+			return err
+		}
+		if result.WeaponStats, err = mergeStatRows(txApp, "match_weapon_stats", source.Id, target.Id, []string{"weapon_name"}, mergedWeaponStatCounters, sameVisitMatch); err != nil {
+			return err
+		}
+
+		for _, field := range []string{"killer", "victim"} {
+			moved, err := reassignPlayerRelation(txApp, "friendly_fire_incidents", field, source.Id, target.Id)
+			if err != nil {
+				return err
+			}
+			result.FriendlyFire += moved
+		}
+		for _, ref := range [][2]string{{"chat_messages", "player"}, {"moderation_flags", "player"}, {"matches", "mvp_player"}} {
+			moved, err := reassignPlayerRelation(txApp, ref[0], ref[1], source.Id, target.Id)
+			if err != nil {
+				return err
+			}
+			result.OtherReferences += moved
+		}
+		moved, err := mergePlayerNames(ctx, txApp, source, target)
+		if err != nil {
+			return err
+		}
+		result.OtherReferences += moved
+
+		sourceExternalID := source.GetString("external_id")
+		sourceMetadata := playerMetadata(source)
+
+		if err := txApp.Delete(source); err != nil {
+			return fmt.Errorf("failed to delete source player: %w", err)
+		}
+
+		if target.GetString("external_id") == "" && sourceExternalID != "" {
+			target.Set("external_id", sourceExternalID)
+		}
+		return UpdatePlayerMetadata(txApp, target, func(metadata map[string]any) {
+			mergePlayerMetadata(metadata, sourceMetadata)
+		})
+	})
+	if err != nil {
+		return result, err
+	}
+
+	pbApp.Logger().Info("Merged duplicate player", "component", "PLAYERS", "source", sourceID, "target", targetID,
+		"matchStats", result.MatchStats, "weaponStats", result.WeaponStats)
+	return result, nil
+}
+
+// mergeStatRows moves a collection's per-match rows from source to target. When the target
+// already has a row for the same match (and keyFields), the counters are added into it and the
+// source row is deleted, except for the sameVisitStatCounters of rows of sameVisitMatch, which
+// keep the larger value. Returns the number of source rows handled.
+func mergeStatRows(txApp core.App, collection, sourceID, targetID string, keyFields, counters []string, sameVisitMatch string) (int, error) {
+	rows, err := txApp.FindAllRecords(collection, dbx.HashExp{"player": sourceID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s: %w", collection, err)
+	}
+
+	for _, row := range rows {
+		key := dbx.HashExp{"player": targetID, "match": row.GetString("match")}
+		for _, field := range keyFields {
+			key[field] = row.Get(field)
+		}
+		existing, err := txApp.FindAllRecords(collection, key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load %s: %w", collection, err)
+		}
+
+		if len(existing) == 0 {
+			row.Set("player", targetID)
+			if err := txApp.Save(row); err != nil {
+				return 0, fmt.Errorf("failed to move %s row %s: %w", collection, row.Id, err)
+			}
+			continue
+		}
+
+		into := existing[0]
+		sameVisit := sameVisitMatch != "" && row.GetString("match") == sameVisitMatch
+		for _, field := range counters {
+			if sameVisit && slices.Contains(sameVisitStatCounters, field) {
+				into.Set(field, max(into.GetFloat(field), row.GetFloat(field)))
+				continue
+			}
+			into.Set(field, into.GetFloat(field)+row.GetFloat(field))
+		}
+		if collection == "match_player_stats" {
+			foldPlayerStatRow(into, row)
+		}
+		if err := txApp.Save(into); err != nil {
+			return 0, fmt.Errorf("failed to fold %s row %s: %w", collection, row.Id, err)
+		}
+		if err := txApp.Delete(row); err != nil {
+			return 0, fmt.Errorf("failed to delete %s row %s: %w", collection, row.Id, err)
+		}
+	}
+	return len(rows), nil
+}
+
+// foldPlayerStatRow combines the non-counter fields of two match_player_stats rows for the same match
+func foldPlayerStatRow(into, row *core.Record) {
+	into.Set("best_streak", max(into.GetInt("best_streak"), row.GetInt("best_streak")))
+	if row.GetDateTime("last_seen_at").After(into.GetDateTime("last_seen_at")) {
+		into.Set("last_seen_at", row.GetDateTime("last_seen_at"))
+	}
+	if row.GetBool("is_currently_connected") && !into.GetBool("is_currently_connected") {
+		into.Set("is_currently_connected", true)
+		into.Set("status", row.GetString("status"))
+		into.Set("left_at", nil)
+	}
+}
+
+// reassignPlayerRelation points every record in a collection whose field is sourceID at targetID
+func reassignPlayerRelation(txApp core.App, collection, field, sourceID, targetID string) (int, error) {
+	records, err := txApp.FindAllRecords(collection, dbx.HashExp{field: sourceID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s: %w", collection, err)
+	}
+	for _, record := range records {
+		record.Set(field, targetID)
+		if err := txApp.Save(record); err != nil {
+			return 0, fmt.Errorf("failed to reassign %s %s: %w", collection, record.Id, err)
+		}
+	}
+	return len(records), nil
+}
+
+// mergePlayerNames moves the source's name history onto the target, widening first/last seen
+// for names both have used. The source's current name is kept in the history as well.
+func mergePlayerNames(ctx context.Context, txApp core.App, source, target *core.Record) (int, error) {
+	if err := RecordPlayerName(ctx, txApp, source.Id, source.GetString("name"), source.GetDateTime("created").Time()); err != nil {
+		return 0, err
+	}
+
+	names, err := txApp.FindAllRecords("player_names", dbx.HashExp{"player": source.Id})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load player names: %w", err)
+	}
+	for _, name := range names {
+		existing, err := txApp.FindFirstRecordByFilter("player_names", "player = {:player} && name = {:name}",
+			dbx.Params{"player": target.Id, "name": name.GetString("name")})
+		if err != nil {
+			name.Set("player", target.Id)
+			if err := txApp.Save(name); err != nil {
+				return 0, fmt.Errorf("failed to move player name: %w", err)
+			}
+			continue
+		}
+
+		if name.GetDateTime("first_seen").Before(existing.GetDateTime("first_seen")) {
+			existing.Set("first_seen", name.GetDateTime("first_seen"))
+		}
+		if name.GetDateTime("last_seen").After(existing.GetDateTime("last_seen")) {
+			existing.Set("last_seen", name.GetDateTime("last_seen"))
+		}
+		if err := txApp.Save(existing); err != nil {
+			return 0, fmt.Errorf("failed to merge player name: %w", err)
+		}
+		if err := txApp.Delete(name); err != nil {
+			return 0, fmt.Errorf("failed to delete player name: %w", err)
+		}
+	}
+	return len(names), nil
+}
+
+// mergePlayerMetadata combines the source's known IPs into the target's metadata and copies
+// any other keys the target doesn't have
+func mergePlayerMetadata(metadata, source map[string]any) {
+	var knownIPs []string
+	for _, m := range []map[string]any{metadata, source} {
+		ips, _ := m["knownIPs"].([]any)
+		for _, ip := range ips {
+			if ip, ok := ip.(string); ok && !slices.Contains(knownIPs, ip) {
+				knownIPs = append(knownIPs, ip)
+			}
+		}
+	}
+	for key, value := range source {
+		if _, ok := metadata[key]; !ok {
+			metadata[key] = value
+		}
+	}
+	if len(knownIPs) > 0 {
+		metadata["knownIPs"] = knownIPs
+	}
+}
+
+// LinkNameOnlyPlayer resolves a login against a name-only player created by a join that was
+// logged before it. When a player with no Steam ID and the login's name has stats in the match,
+// it is given the login's platform and ID, or merged into the existing player for them (see
+// MergePlayers) when one was already created, e.g. by a kill, counting the visit both recorded
+// in the match once. Returns nil when there is nothing to reconcile.
+func LinkNameOnlyPlayer(ctx context.Context, pbApp core.App, matchID, platform, steamID, name string) (*Player, error) {
+	if steamID == "" || name == "" {
+		return nil, nil
+	}
+
+	// Only the name-only player who joined this match; another with the same name may be left
+	// over from an earlier match or a different person
+	stats, err := pbApp.FindFirstRecordByFilter("match_player_stats",
+		"match = {:match} && player.external_id = '' && player.name = {:name}",
+		dbx.Params{"match": matchID, "name": name})
+	if err != nil {
+		return nil, nil
+	}
+	record, err := pbApp.FindRecordById("players", stats.GetString("player"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find name-only player: %w", err)
+	}
+
+	existing, err := findLoginPlayer(ctx, pbApp, platform, steamID)
+	if err != nil {
+		player := &Player{ID: record.Id, Name: record.GetString("name")}
+		if err := UpdatePlayerPlatformID(ctx, pbApp, player, platform, steamID); err != nil {
+			return nil, fmt.Errorf("failed to link Steam ID: %w", err)
+		}
+		return player, nil
+	}
+
+	// The join and the kill or login that created the other player are the same visit
+	if _, err := mergePlayers(ctx, pbApp, record.Id, existing.ID, matchID); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PlayerName is a name a player has used, from the player_names collection
+type PlayerName struct {
+	Name      string    `json:"name"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// RecordPlayerName adds a name to a player's name history, or moves its last_seen forward
+// when the player has used it before
+func RecordPlayerName(ctx context.Context, pbApp core.App, playerID, name string, seenAt time.Time) error {
+	if name == "" {
+		return nil
+	}
+
+	record, err := pbApp.FindFirstRecordByFilter(
+		"player_names",
+		"player = {:player} && name = {:name}",
+		dbx.Params{"player": playerID, "name": name},
+	)
+	if err != nil {
+		collection, err := pbApp.FindCollectionByNameOrId("player_names")
+		if err != nil {
+			return err
+		}
+		record = core.NewRecord(collection)
+		record.Set("player", playerID)
+		record.Set("name", name)
+		record.Set("first_seen", seenAt)
+	} else if !seenAt.After(record.GetDateTime("last_seen").Time()) {
+		return nil
+	}
+
+	record.Set("last_seen", seenAt)
+	return pbApp.Save(record)
+}
+
+// RenamePlayer updates a player's current name and records it in the name history.
+// On a rename the old name stays in the history as last seen now; players from before the
+// history existed get it recorded from their creation, so it shows up in the "aka" list.
+func RenamePlayer(ctx context.Context, pbApp core.App, player *Player, newName string, seenAt time.Time) error {
+	if newName == "" {
+		return nil
+	}
+
+	if player.Name != "" && player.Name != newName {
+		record, err := pbApp.FindRecordById("players", player.ID)
+		if err != nil {
+			return err
+		}
+		if err := RecordPlayerName(ctx, pbApp, player.ID, player.Name, record.GetDateTime("created").Time()); err != nil {
+			return err
+		}
+		if err := RecordPlayerName(ctx, pbApp, player.ID, player.Name, seenAt); err != nil {
+			return err
+		}
+	}
+
+	if err := RecordPlayerName(ctx, pbApp, player.ID, newName, seenAt); err != nil {
+		return err
+	}
+	return UpdatePlayerName(ctx, pbApp, player, newName)
+}
+
+// GetPlayerNameHistory returns every name a player has used, most recently seen first
+func GetPlayerNameHistory(ctx context.Context, pbApp core.App, playerID string) ([]PlayerName, error) {
+	records, err := pbApp.FindRecordsByFilter(
+		"player_names",
+		"player = {:player}",
+		"-last_seen",
+		-1,
+		0,
+		dbx.Params{"player": playerID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]PlayerName, 0, len(records))
+	for _, record := range records {
+		names = append(names, PlayerName{
+			Name:      record.GetString("name"),
+			FirstSeen: record.GetDateTime("first_seen").Time(),
+			LastSeen:  record.GetDateTime("last_seen").Time(),
+		})
+	}
+	return names, nil
+}
+
+// GetPlayerAliases returns the previous names of each player (by players record ID), excluding
+// their current name, most recently used first
+func GetPlayerAliases(ctx context.Context, pbApp core.App) (map[string][]string, error) {
+	var rows []struct {
+		Player string `db:"player"`
+		Name   string `db:"name"`
+	}
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT n.player as player, n.name as name
+			FROM player_names n
+			JOIN players p ON p.id = n.player
+			WHERE n.name != p.name
+			ORDER BY n.last_seen DESC
+		`).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make(map[string][]string)
+	for _, row := range rows {
+		aliases[row.Player] = append(aliases[row.Player], row.Name)
+	}
+	return aliases, nil
+}
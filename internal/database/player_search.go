@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PlayerSearchResult is a compact player entry for search boxes
+type PlayerSearchResult struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	SteamID     string `json:"steam_id"`               // external_id, empty for name-only players
+	Platform    string `json:"platform,omitempty"`     // Display name of the platform
+	MatchedName string `json:"matched_name,omitempty"` // Earlier name the query matched, when the current one did not
+}
+
+// likeEscaper escapes the LIKE wildcards in a search query so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// namedPlayerWhere matches the players (aliased p) seen on a public server that shows player
+// names, the only players the public pages may list by name
+const namedPlayerWhere = `EXISTS (
+	SELECT 1 FROM match_player_stats s
+	INNER JOIN matches m ON m.id = s.match
+	INNER JOIN servers sv ON sv.id = m.server
+	WHERE s.player = p.id AND sv.is_public = TRUE AND sv.hide_player_names = FALSE
+)`
+
+// GetNamedPlayerIDs returns the IDs of the players seen on a public server that shows player names
+func GetNamedPlayerIDs(ctx context.Context, pbApp core.App) (map[string]bool, error) {
+	var ids []string
+	err := pbApp.DB().
+		NewQuery("SELECT p.id FROM players p WHERE " + namedPlayerWhere).
+		WithContext(ctx).
+		Column(&ids)
+	if err != nil {
+		return nil, err
+	}
+
+	named := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		named[id] = true
+	}
+	return named, nil
+}
+
+// SearchPlayers finds up to limit players whose current or earlier name contains query, or whose
+// Steam ID starts with it. Exact matches come first, then name prefixes, then the rest by name.
+// With publicOnly set, only players seen on a public server that shows player names are searched,
+// so a search cannot tie a Steam ID or name to a player on a server that hides them.
+func SearchPlayers(ctx context.Context, pbApp core.App, query string, publicOnly bool, limit int) ([]PlayerSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || limit <= 0 {
+		return []PlayerSearchResult{}, nil
+	}
+
+	escaped := likeEscaper.Replace(query)
+	params := dbx.Params{
+		"query":    query,
+		"contains": "%" + escaped + "%",
+		"prefix":   escaped + "%",
+		"limit":    limit,
+	}
+
+	visible := ""
+	if publicOnly {
+		visible = " AND " + namedPlayerWhere
+	}
+
+	var rows []struct {
+		ID          string `db:"id"`
+		Name        string `db:"name"`
+		ExternalID  string `db:"external_id"`
+		Platform    string `db:"platform"`
+		MatchedName string `db:"matched_name"`
+	}
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT
+				p.id,
+				p.name,
+				p.external_id,
+				p.platform,
+				CASE WHEN p.name LIKE {:contains} ESCAPE '\' THEN '' ELSE COALESCE((
+					SELECT n.name FROM player_names n
+					WHERE n.player = p.id AND n.name LIKE {:contains} ESCAPE '\'
+					ORDER BY n.last_seen DESC
+					LIMIT 1
+				), '') END as matched_name
+			FROM players p
+			WHERE (
+				p.name LIKE {:contains} ESCAPE '\'
+				OR (p.external_id != '' AND p.external_id LIKE {:prefix} ESCAPE '\')
+				OR EXISTS (SELECT 1 FROM player_names n WHERE n.player = p.id AND n.name LIKE {:contains} ESCAPE '\')
+			)` + visible + `
+			ORDER BY
+				CASE
+					WHEN p.external_id = {:query} OR p.name = {:query} COLLATE NOCASE THEN 0
+					WHEN p.name LIKE {:prefix} ESCAPE '\' THEN 1
+					ELSE 2
+				END,
+				p.name COLLATE NOCASE
+			LIMIT {:limit}
+		`).
+		Bind(params).
+		WithContext(ctx).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PlayerSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, PlayerSearchResult{
+			ID:          row.ID,
+			Name:        row.Name,
+			SteamID:     row.ExternalID,
+			Platform:    PlatformLabel(row.Platform),
+			MatchedName: row.MatchedName,
+		})
+	}
+	return results, nil
+}
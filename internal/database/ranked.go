@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Matches with fewer connected players than the ranked minimum at their peak are flagged
+// matches.unranked: they are left out of lifetime stats and leaderboards (the player_total_stats
+// and player_weapon_stats views, the players, weapons and server stats pages) but can still be
+// viewed on their own. matches.peak_players keeps the high-water mark so the flag is cheap to
+// re-apply when the minimum changes.
+
+// isUnranked reports whether a match peaking at peakPlayers falls below minPlayers (0 disables the minimum)
+func isUnranked(peakPlayers, minPlayers int) bool {
+	return minPlayers > 0 && peakPlayers < minPlayers
+}
+
+// UpdateMatchPeakPlayers raises a match's peak_players to its current connected-player count
+// and flags it unranked while the peak is below minPlayers
+func UpdateMatchPeakPlayers(ctx context.Context, pbApp core.App, matchID string, minPlayers int) error {
+	match, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return fmt.Errorf("failed to find match %s: %w", matchID, err)
+	}
+
+	connected, err := pbApp.CountRecords("match_player_stats", dbx.HashExp{
+		"match":                  matchID,
+		"is_currently_connected": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count connected players: %w", err)
+	}
+
+	peak := max(match.GetInt("peak_players"), int(connected))
+	unranked := isUnranked(peak, minPlayers)
+	if peak == match.GetInt("peak_players") && unranked == match.GetBool("unranked") {
+		return nil
+	}
+
+	match.Set("peak_players", peak)
+	match.Set("unranked", unranked)
+	return pbApp.Save(match)
+}
+
+// ApplyRankedMinPlayers re-flags every match against minPlayers from its stored peak.
+// Returns the number of unranked matches.
+func ApplyRankedMinPlayers(ctx context.Context, pbApp core.App, minPlayers int) (int, error) {
+	if minPlayers < 0 {
+		minPlayers = 0
+	}
+
+	_, err := pbApp.DB().
+		NewQuery(`UPDATE matches SET unranked = ({:min} > 0 AND peak_players < {:min})`).
+		Bind(dbx.Params{"min": minPlayers}).
+		Execute()
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply ranked minimum: %w", err)
+	}
+
+	unranked, err := pbApp.CountRecords("matches", dbx.HashExp{"unranked": true})
+	if err != nil {
+		return 0, err
+	}
+	return int(unranked), nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// derivedPlayerStats are the match_player_stats fields built up from events, reset before a rebuild.
+// score, ping, play time, connection state and result come from RCON and match lifecycle
+// events and are kept as they are.
+var derivedPlayerStats = []string{
+	"kills",
+	"assists",
+	"deaths",
+	"friendly_fire_kills",
+	"headshots",
+	"objectives_captured",
+	"objectives_destroyed",
+	"current_streak",
+	"best_streak",
+	"multi_kills",
+	"multi_kill_chain",
+	"computed_score",
+}
+
+// statEventTypes are the event types whose handlers write per-player match stats
+var statEventTypes = []string{
+	events.TypePlayerKill,
+	events.TypeKillAssist,
+	events.TypeObjectiveCaptured,
+	events.TypeObjectiveDestroyed,
+	events.TypeWeaponFire,
+}
+
+// FindMatchStatEvents returns the stat-bearing events of a match in processing order.
+// Events are linked to their match when they are handled; events stored before that link
+// existed are attributed by processing time, between the match's creation and the next
+// match created on the same server.
+func FindMatchStatEvents(ctx context.Context, pbApp core.App, matchID string) ([]*core.Record, error) {
+	match, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := dbx.Params{
+		"match":  match.Id,
+		"server": match.GetString("server"),
+		"from":   match.GetDateTime("created").String(),
+	}
+	legacy := "match = '' && server = {:server} && created >= {:from}"
+
+	next, err := pbApp.FindRecordsByFilter(
+		"matches",
+		"server = {:server} && created > {:from}",
+		"created",
+		1,
+		0,
+		params,
+	)
+	if err == nil && len(next) > 0 {
+		params["to"] = next[0].GetDateTime("created").String()
+		legacy += " && created < {:to}"
+	}
+
+	typeFilter := ""
+	for i, eventType := range statEventTypes {
+		if i > 0 {
+			typeFilter += " || "
+		}
+		typeFilter += "type = '" + eventType + "'"
+	}
+
+	return pbApp.FindRecordsByFilter(
+		"events",
+		"("+typeFilter+") && (match = {:match} || ("+legacy+"))",
+		"created,id",
+		-1,
+		0,
+		params,
+	)
+}
+
+// ResetMatchDerivedStats clears everything a match's events produced so they can be replayed:
+// the event-derived match_player_stats counters are zeroed, and the match's weapon stats,
+// friendly fire incidents and objective timeline are deleted.
+// Returns the number of match_player_stats rows reset.
+func ResetMatchDerivedStats(ctx context.Context, pbApp core.App, matchID string) (int, error) {
+	params := map[string]any{"match": matchID}
+
+	stats, err := pbApp.FindRecordsByFilter("match_player_stats", "match = {:match}", "", -1, 0, params)
+	if err != nil {
+		return 0, err
+	}
+	for _, stat := range stats {
+		for _, field := range derivedPlayerStats {
+			stat.Set(field, 0)
+		}
+		stat.Set("last_kill_at", "")
+		if err := pbApp.Save(stat); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, collection := range []string{"match_weapon_stats", "friendly_fire_incidents", "objective_events"} {
+		records, err := pbApp.FindRecordsByFilter(collection, "match = {:match}", "", -1, 0, params)
+		if err != nil {
+			return 0, err
+		}
+		for _, record := range records {
+			if err := pbApp.Delete(record); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(stats), nil
+}
+
+// CountMatchStatRows returns the number of match_player_stats and match_weapon_stats rows of a match
+func CountMatchStatRows(ctx context.Context, pbApp core.App, matchID string) (int, error) {
+	total := 0
+	for _, collection := range []string{"match_player_stats", "match_weapon_stats"} {
+		count, err := pbApp.CountRecords(collection, dbx.HashExp{"match": matchID})
+		if err != nil {
+			return 0, err
+		}
+		total += int(count)
+	}
+	return total, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ScoreWeights are the points awarded per stat by the tracker's own score formula:
+//
+//	computed_score = kills*Kill + assists*Assist
+//	               + objectives_captured*ObjectiveCaptured + objectives_destroyed*ObjectiveDestroyed
+//	               - deaths*DeathPenalty
+//
+// The active weights live in the score_settings collection. Each match keeps a copy of the
+// weights it was scored with in matches.score_weights, so editing the settings only affects
+// matches started afterwards.
+type ScoreWeights struct {
+	Kill               int `json:"kill"`
+	Assist             int `json:"assist"`
+	ObjectiveCaptured  int `json:"objective_captured"`
+	ObjectiveDestroyed int `json:"objective_destroyed"`
+	DeathPenalty       int `json:"death_penalty"`
+}
+
+// DefaultScoreWeights are used when no score_settings record exists
+var DefaultScoreWeights = ScoreWeights{
+	Kill:               10,
+	Assist:             5,
+	ObjectiveCaptured:  50,
+	ObjectiveDestroyed: 50,
+	DeathPenalty:       5,
+}
+
+// scoredStats are the match_player_stats fields the score formula reads
+var scoredStats = map[string]bool{
+	"kills":                true,
+	"assists":              true,
+	"objectives_captured":  true,
+	"objectives_destroyed": true,
+	"deaths":               true,
+}
+
+// StatLine is the set of per-match stats a score is computed from
+type StatLine struct {
+	Kills               int
+	Assists             int
+	ObjectivesCaptured  int
+	ObjectivesDestroyed int
+	Deaths              int
+}
+
+// Score applies the weights to a stat line
+func (w ScoreWeights) Score(line StatLine) int {
+	return line.Kills*w.Kill +
+		line.Assists*w.Assist +
+		line.ObjectivesCaptured*w.ObjectiveCaptured +
+		line.ObjectivesDestroyed*w.ObjectiveDestroyed -
+		line.Deaths*w.DeathPenalty
+}
+
+// statLineFromRecord reads the scored fields from a match_player_stats record
+func statLineFromRecord(record *core.Record) StatLine {
+	return StatLine{
+		Kills:               record.GetInt("kills"),
+		Assists:             record.GetInt("assists"),
+		ObjectivesCaptured:  record.GetInt("objectives_captured"),
+		ObjectivesDestroyed: record.GetInt("objectives_destroyed"),
+		Deaths:              record.GetInt("deaths"),
+	}
+}
+
+// GetScoreWeights returns the active weights from the score_settings collection,
+// falling back to DefaultScoreWeights when it has no record
+func GetScoreWeights(pbApp core.App) ScoreWeights {
+	records, err := pbApp.FindRecordsByFilter("score_settings", "", "-updated", 1, 0)
+	if err != nil || len(records) == 0 {
+		return DefaultScoreWeights
+	}
+
+	record := records[0]
+	return ScoreWeights{
+		Kill:               record.GetInt("kill"),
+		Assist:             record.GetInt("assist"),
+		ObjectiveCaptured:  record.GetInt("objective_captured"),
+		ObjectiveDestroyed: record.GetInt("objective_destroyed"),
+		DeathPenalty:       record.GetInt("death_penalty"),
+	}
+}
+
+// GetMatchScoreWeights returns the weights a match is scored with.
+// Matches created before weights were stored are pinned to the active weights on first use.
+func GetMatchScoreWeights(pbApp core.App, matchID string) (ScoreWeights, error) {
+	record, err := pbApp.FindRecordById("matches", matchID)
+	if err != nil {
+		return ScoreWeights{}, err
+	}
+
+	var weights *ScoreWeights
+	// An empty field fails to unmarshal and leaves weights nil
+	_ = record.UnmarshalJSONField("score_weights", &weights)
+	if weights != nil {
+		return *weights, nil
+	}
+
+	active := GetScoreWeights(pbApp)
+	record.Set("score_weights", active)
+	if err := pbApp.Save(record); err != nil {
+		return ScoreWeights{}, err
+	}
+	return active, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// UngroupedServers is the heading for servers without a group when other servers have one
+const UngroupedServers = "Ungrouped"
+
+// ServerGroup is a set of servers sharing servers.group
+type ServerGroup struct {
+	Name    string // Empty when no server has a group
+	Servers []*core.Record
+}
+
+// GetServersInGroup returns the servers in group, or every server when group is empty
+func GetServersInGroup(ctx context.Context, pbApp core.App, group string) ([]*core.Record, error) {
+	if group == "" {
+		return pbApp.FindAllRecords("servers")
+	}
+	return pbApp.FindRecordsByFilter("servers", "group = {:group}", "", -1, 0, dbx.Params{"group": group})
+}
+
+// GetServerGroups returns the distinct server groups, sorted by name
+func GetServerGroups(ctx context.Context, pbApp core.App) ([]string, error) {
+	servers, err := pbApp.FindAllRecords("servers")
+	if err != nil {
+		return nil, err
+	}
+	return serverGroupNames(servers), nil
+}
+
+// GroupServers splits servers by group, keeping their order within each group.
+// Named groups are sorted and servers without a group come last under UngroupedServers;
+// when no server has a group they are returned as one unnamed group.
+func GroupServers(servers []*core.Record) []ServerGroup {
+	names := serverGroupNames(servers)
+	if len(names) == 0 {
+		if len(servers) == 0 {
+			return nil
+		}
+		return []ServerGroup{{Servers: servers}}
+	}
+
+	byName := make(map[string][]*core.Record, len(names))
+	var ungrouped []*core.Record
+	for _, server := range servers {
+		if group := server.GetString("group"); group != "" {
+			byName[group] = append(byName[group], server)
+		} else {
+			ungrouped = append(ungrouped, server)
+		}
+	}
+
+	groups := make([]ServerGroup, 0, len(names)+1)
+	for _, name := range names {
+		groups = append(groups, ServerGroup{Name: name, Servers: byName[name]})
+	}
+	if len(ungrouped) > 0 {
+		groups = append(groups, ServerGroup{Name: UngroupedServers, Servers: ungrouped})
+	}
+	return groups
+}
+
+// serverGroupNames returns the distinct non-empty groups of servers, sorted
+func serverGroupNames(servers []*core.Record) []string {
+	var names []string
+	for _, server := range servers {
+		if group := server.GetString("group"); group != "" && !slices.Contains(names, group) {
+			names = append(names, group)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
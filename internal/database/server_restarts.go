@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Where a server restart was detected, stored in server_restarts.source
+const (
+	RestartSourceLogFile  = "log_file" // The first line of a new server log was parsed
+	RestartSourceWatchdog = "watchdog" // The log watcher saw the log file replaced
+)
+
+// RestartMatchWindow is how far apart two detections of a restart can be and still be the same
+// restart. The log file's timestamp comes from the game server's clock and the watcher's from
+// the tracker's, which can disagree.
+const RestartMatchWindow = 2 * time.Minute
+
+// ServerRestart is a server restart from the server_restarts collection
+type ServerRestart struct {
+	RestartedAt time.Time `json:"restarted_at"`
+	Source      string    `json:"source"`
+}
+
+// RecordServerRestart records that a server (by record ID) restarted at restartedAt, unless the
+// restart is already recorded within RestartMatchWindow. A restart seen by the watchdog and then
+// in the log keeps the log's timestamp, since it is the one the game server wrote.
+//
+// A restartedAt later than now means the game server's clock is ahead of the tracker's; the
+// restart is recorded at now so uptime never starts in the future. Reports whether a new
+// restart was recorded.
+func RecordServerRestart(ctx context.Context, pbApp core.App, serverRecordID string, restartedAt, now time.Time, source string) (bool, error) {
+	if restartedAt.After(now) {
+		restartedAt = now
+	}
+
+	existing, err := pbApp.FindRecordsByFilter(
+		"server_restarts",
+		"server = {:server} && restarted_at >= {:from} && restarted_at <= {:to}",
+		"-restarted_at",
+		1,
+		0,
+		dbx.Params{
+			"server": serverRecordID,
+			"from":   restartedAt.Add(-RestartMatchWindow).UTC().Format(types.DefaultDateLayout),
+			"to":     restartedAt.Add(RestartMatchWindow).UTC().Format(types.DefaultDateLayout),
+		},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up server restarts: %w", err)
+	}
+	if len(existing) > 0 {
+		record := existing[0]
+		if source == RestartSourceLogFile && record.GetString("source") == RestartSourceWatchdog {
+			record.Set("restarted_at", restartedAt.UTC())
+			record.Set("source", source)
+			if err := pbApp.Save(record); err != nil {
+				return false, fmt.Errorf("failed to update server restart: %w", err)
+			}
+		}
+		return false, nil
+	}
+
+	collection, err := pbApp.FindCollectionByNameOrId("server_restarts")
+	if err != nil {
+		return false, err
+	}
+	record := core.NewRecord(collection)
+	record.Set("server", serverRecordID)
+	record.Set("restarted_at", restartedAt.UTC())
+	record.Set("source", source)
+	if err := pbApp.Save(record); err != nil {
+		return false, fmt.Errorf("failed to record server restart: %w", err)
+	}
+	return true, nil
+}
+
+// GetServerRestarts returns a server's restarts since the given time, newest first
+func GetServerRestarts(ctx context.Context, pbApp core.App, serverRecordID string, since time.Time) ([]ServerRestart, error) {
+	records, err := pbApp.FindRecordsByFilter(
+		"server_restarts",
+		"server = {:server} && restarted_at >= {:since}",
+		"-restarted_at",
+		0,
+		0,
+		dbx.Params{"server": serverRecordID, "since": since.UTC().Format(types.DefaultDateLayout)},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	restarts := make([]ServerRestart, 0, len(records))
+	for _, record := range records {
+		restarts = append(restarts, ServerRestart{
+			RestartedAt: record.GetDateTime("restarted_at").Time(),
+			Source:      record.GetString("source"),
+		})
+	}
+	return restarts, nil
+}
+
+// LastServerStart returns when a server last started: the creation time of its current log
+// file, or its latest recorded restart when that is unknown. Zero when neither is known.
+func LastServerStart(ctx context.Context, pbApp core.App, server *core.Record) time.Time {
+	// Written as RFC 3339 by the watcher and in PocketBase's date format by the event handler
+	if raw := server.GetString("log_file_creation_time"); raw != "" {
+		if started, err := time.Parse(time.RFC3339, raw); err == nil {
+			return started
+		}
+		if started, err := types.ParseDateTime(raw); err == nil && !started.IsZero() {
+			return started.Time()
+		}
+	}
+
+	restarts, err := pbApp.FindRecordsByFilter("server_restarts", "server = {:server}", "-restarted_at", 1, 0,
+		dbx.Params{"server": server.Id})
+	if err != nil || len(restarts) == 0 {
+		return time.Time{}
+	}
+	return restarts[0].GetDateTime("restarted_at").Time()
+}
+
+// ServerUptime returns how long a server that started at startedAt has been up. A start in the
+// future (the game server's clock ahead of the tracker's) counts as just started.
+func ServerUptime(startedAt, now time.Time) time.Duration {
+	if startedAt.IsZero() || startedAt.After(now) {
+		return 0
+	}
+	return now.Sub(startedAt)
+}
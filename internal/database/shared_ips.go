@@ -0,0 +1,131 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// SharedIPPlayer is a player in a shared IP group
+type SharedIPPlayer struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ExternalID string `json:"external_id"`
+}
+
+// SharedIPGroup is a set of players linked by known IPs they share, directly or through
+// another player in the group (possible alternate accounts)
+type SharedIPGroup struct {
+	Players []SharedIPPlayer `json:"players"`
+	IPs     []string         `json:"ips"` // The shared IPs linking the group
+}
+
+// GetSharedIPGroups groups players who share known IPs (players.metadata.knownIPs).
+// IPs seen on more than maxPlayersPerIP players are skipped as carrier/NAT noise.
+// The IPs are expanded and counted in a single query with json_each, so player metadata is only read once.
+func GetSharedIPGroups(ctx context.Context, pbApp core.App, maxPlayersPerIP int) ([]SharedIPGroup, error) {
+	type ipRow struct {
+		IP         string `db:"ip"`
+		ID         string `db:"id"`
+		Name       string `db:"name"`
+		ExternalID string `db:"external_id"`
+	}
+
+	var rows []ipRow
+	err := pbApp.DB().
+		NewQuery(`
+			WITH player_ips AS (
+				SELECT DISTINCT p.id as id, p.name as name, p.external_id as external_id, ip.value as ip
+				FROM players p, json_each(p.metadata, '$.knownIPs') ip
+				WHERE ip.type = 'text' AND ip.value != ''
+			),
+			shared AS (
+				SELECT ip
+				FROM player_ips
+				GROUP BY ip
+				HAVING COUNT(*) BETWEEN 2 AND {:maxPlayers}
+			)
+			SELECT player_ips.ip, player_ips.id, player_ips.name, player_ips.external_id
+			FROM player_ips
+			JOIN shared ON shared.ip = player_ips.ip
+			ORDER BY player_ips.ip, player_ips.name
+		`).
+		Bind(dbx.Params{"maxPlayers": maxPlayersPerIP}).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Union the players of each shared IP so players linked through a chain of IPs end up together
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+
+	players := make(map[string]SharedIPPlayer)
+	firstOnIP := make(map[string]string)
+	for _, row := range rows {
+		if _, ok := players[row.ID]; !ok {
+			players[row.ID] = SharedIPPlayer{ID: row.ID, Name: row.Name, ExternalID: row.ExternalID}
+			parent[row.ID] = row.ID
+		}
+		if first, ok := firstOnIP[row.IP]; ok {
+			parent[find(row.ID)] = find(first)
+		} else {
+			firstOnIP[row.IP] = row.ID
+		}
+	}
+
+	byRoot := make(map[string]*SharedIPGroup)
+	var roots []string
+	for _, row := range rows {
+		root := find(row.ID)
+		group, ok := byRoot[root]
+		if !ok {
+			group = &SharedIPGroup{}
+			byRoot[root] = group
+			roots = append(roots, root)
+		}
+		if !slices.ContainsFunc(group.Players, func(p SharedIPPlayer) bool { return p.ID == row.ID }) {
+			group.Players = append(group.Players, players[row.ID])
+		}
+		if !slices.Contains(group.IPs, row.IP) {
+			group.IPs = append(group.IPs, row.IP)
+		}
+	}
+
+	groups := make([]SharedIPGroup, 0, len(roots))
+	for _, root := range roots {
+		group := byRoot[root]
+		slices.SortFunc(group.Players, func(a, b SharedIPPlayer) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+		})
+		groups = append(groups, *group)
+	}
+
+	// Largest groups first
+	slices.SortStableFunc(groups, func(a, b SharedIPGroup) int {
+		return len(b.Players) - len(a.Players)
+	})
+
+	return groups, nil
+}
+
+// GetPlayerKnownIPs returns the IPs recorded in a player's metadata, in the order they were first seen
+func GetPlayerKnownIPs(player *core.Record) []string {
+	var metadata struct {
+		KnownIPs []string `json:"knownIPs"`
+	}
+	if raw := player.GetString("metadata"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &metadata)
+	}
+	return metadata.KnownIPs
+}
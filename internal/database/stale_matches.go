@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// StaleMatch is an active match closed because it went too long without activity
+type StaleMatch struct {
+	MatchID      string    `json:"match_id"`
+	ServerID     string    `json:"server_id"` // Server external ID
+	Status       string    `json:"status"`    // finished, or crashed when the server restarted since
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// CloseStaleMatches closes active matches the tracker lost track of, for instance because it was
+// down while a server restarted and never saw the game over or the new log file. A match's last
+// activity is the latest of its creation, the events from its server since, and A2S sightings of
+// its players. Matches idle for longer than idle are ended at their last activity; a match
+// another match has since started after on the same server is closed straight away. The latest
+// match of a server in online (external IDs of servers answering A2S queries) is left running
+// however long it has been idle, as an empty server keeps playing its match.
+//
+// A closed match is marked crashed when the server has started a new log file since the match
+// began, and finished otherwise. Returns the matches closed.
+func CloseStaleMatches(ctx context.Context, pbApp core.App, idle time.Duration, online map[string]bool, now time.Time) ([]StaleMatch, error) {
+	active, err := pbApp.FindRecordsByFilter("matches", "end_time = ''", "created", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active matches: %w", err)
+	}
+
+	closed := []StaleMatch{}
+	for _, match := range active {
+		// A later match on the same server means this one is over, whatever its activity
+		newer, err := pbApp.FindRecordsByFilter("matches", "server = {:server} && created > {:created}", "created", 1, 0,
+			dbx.Params{"server": match.GetString("server"), "created": match.GetString("created")})
+		if err != nil {
+			return closed, fmt.Errorf("failed to find newer matches: %w", err)
+		}
+		var until time.Time
+		if len(newer) > 0 {
+			until = newer[0].GetDateTime("created").Time()
+		}
+
+		server, err := pbApp.FindRecordById("servers", match.GetString("server"))
+		if err != nil {
+			return closed, fmt.Errorf("failed to find server of match %s: %w", match.Id, err)
+		}
+		if until.IsZero() && online[server.GetString("external_id")] {
+			continue
+		}
+
+		lastActivity, err := matchLastActivity(pbApp, match, until)
+		if err != nil {
+			return closed, err
+		}
+		if until.IsZero() && now.Sub(lastActivity) <= idle {
+			continue
+		}
+		status := "finished"
+		if restarted, err := serverRestartedSince(ctx, pbApp, server, match); err != nil {
+			return closed, err
+		} else if restarted {
+			status = "crashed"
+		}
+
+		// Activity is timed by the tracker's clock and the match by the game server's; never end before it started
+		endTime := lastActivity
+		if start := match.GetDateTime("start_time").Time(); endTime.Before(start) {
+			endTime = start
+		}
+		if err := EndMatch(ctx, pbApp, match.Id, &endTime, nil, &status); err != nil {
+			return closed, fmt.Errorf("failed to end stale match %s: %w", match.Id, err)
+		}
+		if err := DisconnectAllPlayersInMatch(ctx, pbApp, match.Id, &endTime); err != nil {
+			return closed, fmt.Errorf("failed to disconnect players from stale match %s: %w", match.Id, err)
+		}
+
+		closed = append(closed, StaleMatch{
+			MatchID:      match.Id,
+			ServerID:     server.GetString("external_id"),
+			Status:       status,
+			LastActivity: lastActivity,
+		})
+	}
+	return closed, nil
+}
+
+// matchLastActivity returns when a match last saw activity: its creation, the latest event from
+// its server since then (and before until, when set), or the latest A2S sighting of its players
+func matchLastActivity(pbApp core.App, match *core.Record, until time.Time) (time.Time, error) {
+	lastActivity := match.GetDateTime("created").Time()
+
+	where := "server = {:server} AND created >= {:since}"
+	params := dbx.Params{"server": match.GetString("server"), "since": match.GetString("created"), "match": match.Id}
+	if !until.IsZero() {
+		where += " AND created < {:until}"
+		params["until"] = until.UTC().Format(types.DefaultDateLayout)
+	}
+
+	var latest struct {
+		Event    string `db:"event"`
+		LastSeen string `db:"last_seen"`
+	}
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT
+				COALESCE((SELECT MAX(created) FROM events WHERE ` + where + `), '') as event,
+				COALESCE((SELECT MAX(last_seen_at) FROM match_player_stats WHERE match = {:match}), '') as last_seen
+		`).
+		Bind(params).
+		One(&latest)
+	if err != nil {
+		return lastActivity, fmt.Errorf("failed to find last activity of match %s: %w", match.Id, err)
+	}
+
+	for _, raw := range []string{latest.Event, latest.LastSeen} {
+		if at, err := types.ParseDateTime(raw); err == nil && at.Time().After(lastActivity) {
+			lastActivity = at.Time()
+		}
+	}
+	return lastActivity, nil
+}
+
+// serverRestartedSince reports whether a match's server has started a new log file since the
+// match began: a log_file_created event after the match was created, or a later server start
+func serverRestartedSince(ctx context.Context, pbApp core.App, server *core.Record, match *core.Record) (bool, error) {
+	logFiles, err := pbApp.FindRecordsByFilter("events", "server = {:server} && type = {:type} && created > {:created}", "", 1, 0,
+		dbx.Params{"server": server.Id, "type": events.TypeLogFileCreated, "created": match.GetString("created")})
+	if err != nil {
+		return false, fmt.Errorf("failed to find log file events: %w", err)
+	}
+	if len(logFiles) > 0 {
+		return true, nil
+	}
+	return LastServerStart(ctx, pbApp, server).After(match.GetDateTime("start_time").Time()), nil
+}
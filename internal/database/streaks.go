@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// MultiKillWindow is the longest gap between consecutive kills that still chains them
+	MultiKillWindow = 5 * time.Second
+	// MultiKillThreshold is the number of chained kills that counts as one multi-kill
+	MultiKillThreshold = 3
+)
+
+// RecordKillStreak credits a kill to a player's streak in a match.
+// It extends current_streak, raises best_streak when it is beaten, and counts a multi-kill
+// once each time a chain of kills within MultiKillWindow reaches MultiKillThreshold.
+func RecordKillStreak(ctx context.Context, pbApp core.App, matchID, playerID string, killTime time.Time) error {
+	record, err := getLatestMatchPlayerStats(pbApp, matchID, playerID)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return nil
+	}
+
+	streak := record.GetInt("current_streak") + 1
+	record.Set("current_streak", streak)
+	if streak > record.GetInt("best_streak") {
+		record.Set("best_streak", streak)
+	}
+
+	chain := 1
+	lastKill := record.GetDateTime("last_kill_at").Time()
+	if !lastKill.IsZero() && !killTime.Before(lastKill) && killTime.Sub(lastKill) <= MultiKillWindow {
+		chain = record.GetInt("multi_kill_chain") + 1
+	}
+	record.Set("multi_kill_chain", chain)
+	record.Set("last_kill_at", killTime)
+
+	if chain == MultiKillThreshold {
+		record.Set("multi_kills", record.GetInt("multi_kills")+1)
+	}
+
+	return pbApp.Save(record)
+}
+
+// ResetKillStreak ends a player's current streak and multi-kill chain in a match (on death or suicide)
+func ResetKillStreak(ctx context.Context, pbApp core.App, matchID, playerID string) error {
+	record, err := getLatestMatchPlayerStats(pbApp, matchID, playerID)
+	if err != nil {
+		return err
+	}
+	if record == nil || (record.GetInt("current_streak") == 0 && record.GetInt("multi_kill_chain") == 0) {
+		return nil
+	}
+
+	record.Set("current_streak", 0)
+	record.Set("multi_kill_chain", 0)
+
+	return pbApp.Save(record)
+}
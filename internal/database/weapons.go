@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// WeaponUsageDays is how many days back a weapon's usage over time reaches
+const WeaponUsageDays = 30
+
+// WeaponSorts are the accepted orders of the weapons index, in display order
+var WeaponSorts = []string{"kills", "users", "name"}
+
+// weaponOrder is the ORDER BY of each weapon sort. Only these are ever put into the query.
+var weaponOrder = map[string]string{
+	"kills": "kills DESC, users DESC, weapon_name",
+	"users": "users DESC, kills DESC, weapon_name",
+	"name":  "weapon_name",
+}
+
+// WeaponSummary is a weapon's totals over ranked matches
+type WeaponSummary struct {
+	Name  string `json:"name" db:"weapon_name"`
+	Kills int    `json:"kills" db:"kills"`
+	Users int    `json:"users" db:"users"` // Players with at least one kill with it
+}
+
+// WeaponLeader is a player ranked by kills with one weapon
+type WeaponLeader struct {
+	Rank     int    `json:"rank" db:"-"`
+	PlayerID string `json:"player_id" db:"player_id"`
+	Name     string `json:"name" db:"name"`
+	Kills    int    `json:"kills" db:"kills"`
+	Matches  int    `json:"matches" db:"matches"`
+}
+
+// WeaponUsage is a weapon's kills on one day (UTC, as YYYY-MM-DD)
+type WeaponUsage struct {
+	Day   string `json:"day" db:"day"`
+	Kills int    `json:"kills" db:"kills"`
+}
+
+// WeaponDetail is a weapon's totals, kill leaders and daily kills over the last WeaponUsageDays
+type WeaponDetail struct {
+	WeaponSummary
+	Leaders []WeaponLeader `json:"leaders"`
+	Usage   []WeaponUsage  `json:"usage"`
+}
+
+// weaponMatchesWhere limits weapon stats to ranked matches, and to public servers when publicOnly
+func weaponMatchesWhere(publicOnly bool) string {
+	where := "s.kills > 0 AND m.unranked = FALSE"
+	if publicOnly {
+		where += " AND m.server IN (SELECT id FROM servers WHERE is_public = TRUE)"
+	}
+	return where
+}
+
+// GetWeaponSummaries returns every weapon with kills in ranked matches, ordered by one of
+// WeaponSorts (default kills). publicOnly leaves out servers hidden from the public pages.
+func GetWeaponSummaries(ctx context.Context, pbApp core.App, sort string, publicOnly bool) ([]WeaponSummary, error) {
+	order, ok := weaponOrder[sort]
+	if !ok {
+		order = weaponOrder["kills"]
+	}
+
+	var summaries []WeaponSummary
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT s.weapon_name as weapon_name, SUM(s.kills) as kills, COUNT(DISTINCT s.player) as users
+			FROM match_weapon_stats s
+			JOIN matches m ON m.id = s.match
+			WHERE s.weapon_name != '' AND ` + weaponMatchesWhere(publicOnly) + `
+			GROUP BY s.weapon_name
+			ORDER BY ` + order).
+		All(&summaries)
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// GetWeaponDetail returns a weapon's totals, its top limit players by kills (ties by name) and
+// its kills per day since WeaponUsageDays before now. Returns nil when the weapon has no kills.
+// With publicOnly set, kills on servers that hide player names count towards the totals but not
+// towards the leaders, who are listed by name.
+func GetWeaponDetail(ctx context.Context, pbApp core.App, weapon string, limit int, publicOnly bool, now time.Time) (*WeaponDetail, error) {
+	where := "s.weapon_name = {:weapon} AND " + weaponMatchesWhere(publicOnly)
+	leadersWhere := where
+	if publicOnly {
+		leadersWhere += " AND m.server IN (SELECT id FROM servers WHERE hide_player_names = FALSE)"
+	}
+
+	detail := &WeaponDetail{}
+	err := pbApp.DB().
+		NewQuery(`
+			SELECT s.weapon_name as weapon_name, SUM(s.kills) as kills, COUNT(DISTINCT s.player) as users
+			FROM match_weapon_stats s
+			JOIN matches m ON m.id = s.match
+			WHERE ` + where + `
+			GROUP BY s.weapon_name
+		`).
+		Bind(dbx.Params{"weapon": weapon}).
+		One(&detail.WeaponSummary)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = pbApp.DB().
+		NewQuery(`
+			SELECT p.id as player_id, p.name as name, SUM(s.kills) as kills, COUNT(DISTINCT s.match) as matches
+			FROM match_weapon_stats s
+			JOIN matches m ON m.id = s.match
+			JOIN players p ON p.id = s.player
+			WHERE ` + leadersWhere + `
+			GROUP BY p.id
+			ORDER BY kills DESC, p.name
+			LIMIT {:limit}
+		`).
+		Bind(dbx.Params{"weapon": weapon, "limit": limit}).
+		All(&detail.Leaders)
+	if err != nil {
+		return nil, err
+	}
+	for i := range detail.Leaders {
+		detail.Leaders[i].Rank = i + 1
+	}
+
+	// Matches are dated by their start, or their creation when the start time is unknown
+	err = pbApp.DB().
+		NewQuery(`
+			SELECT date(COALESCE(NULLIF(m.start_time, ''), m.created)) as day, SUM(s.kills) as kills
+			FROM match_weapon_stats s
+			JOIN matches m ON m.id = s.match
+			WHERE ` + where + ` AND COALESCE(NULLIF(m.start_time, ''), m.created) >= {:since}
+			GROUP BY day
+			ORDER BY day
+		`).
+		Bind(dbx.Params{
+			"weapon": weapon,
+			"since":  now.AddDate(0, 0, -WeaponUsageDays).UTC().Format(types.DefaultDateLayout),
+		}).
+		All(&detail.Usage)
+	if err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
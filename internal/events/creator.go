@@ -1,62 +1,181 @@
 package events
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
 )
 
 // Creator provides methods for creating event records
 type Creator struct {
-	app core.App
+	app      core.App
+	dedupKey string      // Identifies the source log line; events already created from it are skipped
+	retries  *retryQueue // Shared by every copy of the creator
 }
 
 // NewCreator creates a new event creator
 func NewCreator(app core.App) *Creator {
-	return &Creator{app: app}
+	return &Creator{app: app, retries: newRetryQueue()}
+}
+
+// WithDedupKey returns a copy of the creator whose events are deduplicated by key
+// (see DedupKey). Creating the same event type twice with the same key is a no-op,
+// so reprocessing a log line (restart, overlapping reads, replay) does not double count.
+func (c *Creator) WithDedupKey(key string) *Creator {
+	return &Creator{app: c.app, dedupKey: key, retries: c.retries}
+}
+
+// SetRetryPolicy sets how often, and how patiently, a failed event insert is retried, and how
+// many events a server may have waiting. Values of zero or less keep the current setting.
+// Must be called before events are created.
+func (c *Creator) SetRetryPolicy(attempts int, backoff time.Duration, maxQueue int) {
+	if attempts > 0 {
+		c.retries.attempts = attempts
+	}
+	if backoff > 0 {
+		c.retries.backoff = backoff
+	}
+	if maxQueue > 0 {
+		c.retries.maxQueue = maxQueue
+	}
+}
+
+// PendingEvents returns the number of events waiting for their insert to be retried
+func (c *Creator) PendingEvents() int {
+	return c.retries.pendingCount()
+}
+
+// Flush retries the events waiting in the retry queue straight away and waits until they are
+// saved or given up on. Returns ctx's error when events are still waiting once it is done.
+func (c *Creator) Flush(ctx context.Context) error {
+	return c.retries.flush(ctx)
+}
+
+// DroppedEvents returns the number of events given up on after their insert kept failing, by event type
+func (c *Creator) DroppedEvents() map[string]int {
+	return c.retries.droppedCounts()
+}
+
+// DedupKey builds a deterministic key for a parsed log line from the server, the line's timestamp
+// and line-specific fields (typically the raw line)
+func DedupKey(serverExternalID string, timestamp time.Time, fields ...string) string {
+	hash := sha256.New()
+	hash.Write([]byte(serverExternalID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(timestamp.UTC().Format(time.RFC3339Nano)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 // CreateEvent creates a new event record in the events collection
 // This is a low-level method - prefer using specific typed methods below
 // serverExternalID is the server's external_id (UUID), not the PocketBase record ID
 // data can include "is_catchup" boolean to mark events created during catchup mode
+// Creators with a dedup key (see WithDedupKey) skip events that already exist
 // All player data (Steam IDs, names) should be stored in the data JSON
+//
+// An event whose save fails is queued and retried in the background (see retryQueue), and
+// later events for the same server queue behind it to keep them in order; both return nil.
+// An error is only returned when the event cannot be built or the retry queue is full.
 func (c *Creator) CreateEvent(eventType string, serverExternalID string, data interface{}) error {
+	event := &pendingEvent{
+		eventType:        eventType,
+		serverExternalID: serverExternalID,
+		dedupKey:         c.dedupKey,
+		data:             data,
+	}
+
+	if !c.retries.busy(serverExternalID) {
+		err := c.insert(event)
+		if err == nil {
+			return nil
+		}
+		var saveErr *saveError
+		if !errors.As(err, &saveErr) {
+			return err
+		}
+		c.app.Logger().Warn("Failed to save event, queued for retry", "event_type", eventType, "server_id", serverExternalID, "error", err)
+	}
+
+	accepted, start := c.retries.enqueue(event)
+	if !accepted {
+		return fmt.Errorf("event retry queue for server %s is full, dropped %s event", serverExternalID, eventType)
+	}
+	if start {
+		go c.retries.drain(serverExternalID, c.insert, func(event *pendingEvent, attempts int, err error) {
+			c.app.Logger().Error("Dropped event after retries", "event_type", event.eventType,
+				"server_id", event.serverExternalID, "attempts", attempts, "error", err)
+		})
+	}
+	return nil
+}
+
+// saveError marks a failure to save an event record, the only failure worth retrying
+type saveError struct{ err error }
+
+func (e *saveError) Error() string { return "failed to save event: " + e.err.Error() }
+func (e *saveError) Unwrap() error { return e.err }
+
+// insert saves one event record, skipping it when its dedup key was already recorded
+func (c *Creator) insert(event *pendingEvent) error {
 	collection, err := c.app.FindCollectionByNameOrId("events")
 	if err != nil {
 		return fmt.Errorf("events collection not found: %w", err)
 	}
 
+	// Skip events already created from the same log line (one line can only produce one event of each type)
+	var eventKey string
+	if event.dedupKey != "" {
+		eventKey = event.eventType + ":" + event.dedupKey
+		if _, err := c.app.FindFirstRecordByFilter("events", "dedup_key = {:key}", map[string]any{"key": eventKey}); err == nil {
+			c.app.Logger().Debug("Skipping duplicate event", "event_type", event.eventType, "server_id", event.serverExternalID)
+			return nil
+		}
+	}
+
 	record := core.NewRecord(collection)
-	record.Set("type", eventType)
+	record.Set("type", event.eventType)
+	record.Set("dedup_key", eventKey)
 
 	// Set server relation (optional - can be empty for system events)
 	// Need to look up server record ID from external_id
-	if serverExternalID != "" {
+	if event.serverExternalID != "" {
 		serverRecord, err := c.app.FindFirstRecordByFilter(
 			"servers",
 			"external_id = {:external_id}",
-			map[string]any{"external_id": serverExternalID},
+			map[string]any{"external_id": event.serverExternalID},
 		)
 		if err != nil {
-			return fmt.Errorf("server not found with external_id %s: %w", serverExternalID, err)
+			return fmt.Errorf("server not found with external_id %s: %w", event.serverExternalID, err)
 		}
 		record.Set("server", serverRecord.Id)
 	}
 
 	// Set event-specific data as JSON
-	if data != nil {
-		dataJSON, err := json.Marshal(data)
+	if event.data != nil {
+		dataJSON, err := json.Marshal(event.data)
 		if err != nil {
 			return fmt.Errorf("failed to marshal event data: %w", err)
 		}
 		record.Set("data", string(dataJSON))
 	}
 
-	// Save triggers OnRecordCreate hooks automatically
-	if err := c.app.Save(record); err != nil {
-		return fmt.Errorf("failed to save event: %w", err)
+	// Save triggers the OnRecordCreate hooks, which apply the event's stats before the insert.
+	// Saving in a transaction rolls those back with a failed insert, so a retried event is not
+	// counted twice.
+	err = c.app.RunInTransaction(func(txApp core.App) error {
+		return txApp.Save(record)
+	})
+	if err != nil {
+		return &saveError{err: err}
 	}
 
 	return nil
@@ -86,11 +205,7 @@ func (c *Creator) CreatePlayerKillEvent(serverID string, killers []Killer, victi
 }
 
 // CreatePlayerJoinEvent creates a player join event
-func (c *Creator) CreatePlayerJoinEvent(serverID, playerName string, isCatchup bool) error {
-	data := PlayerJoinData{
-		PlayerName: playerName,
-		IsCatchup:  isCatchup,
-	}
+func (c *Creator) CreatePlayerJoinEvent(serverID string, data PlayerJoinData) error {
 	return c.CreateEvent(TypePlayerJoin, serverID, data)
 }
 
@@ -143,24 +258,26 @@ func (c *Creator) CreateRoundEndEvent(serverID, matchID string, roundNumber int,
 }
 
 // CreateObjectiveCapturedEvent creates an objective captured event with multiple players
-func (c *Creator) CreateObjectiveCapturedEvent(serverID, matchID, objectiveNum string, players []ObjectivePlayer, capturingTeam int, isCatchup bool) error {
+func (c *Creator) CreateObjectiveCapturedEvent(serverID, matchID, objectiveNum string, players []ObjectivePlayer, capturingTeam int, timestamp time.Time, isCatchup bool) error {
 	data := ObjectiveCapturedData{
 		MatchID:       matchID,
 		Players:       players,
 		Objective:     objectiveNum,
 		CapturingTeam: capturingTeam,
+		Timestamp:     timestamp,
 		IsCatchup:     isCatchup,
 	}
 	return c.CreateEvent(TypeObjectiveCaptured, serverID, data)
 }
 
 // CreateObjectiveDestroyedEvent creates an objective destroyed event with multiple players
-func (c *Creator) CreateObjectiveDestroyedEvent(serverID, matchID, objectiveNum string, players []ObjectivePlayer, destroyingTeam int, isCatchup bool) error {
+func (c *Creator) CreateObjectiveDestroyedEvent(serverID, matchID, objectiveNum string, players []ObjectivePlayer, destroyingTeam int, timestamp time.Time, isCatchup bool) error {
 	data := ObjectiveDestroyedData{
 		MatchID:        matchID,
 		Players:        players,
 		Objective:      objectiveNum,
 		DestroyingTeam: destroyingTeam,
+		Timestamp:      timestamp,
 		IsCatchup:      isCatchup,
 	}
 	return c.CreateEvent(TypeObjectiveDestroyed, serverID, data)
@@ -178,6 +295,44 @@ func (c *Creator) CreateChatCommandEvent(serverID, steamID, playerName, command
 	return c.CreateEvent(TypeChatCommand, serverID, data)
 }
 
+// CreateChatMessageEvent creates a chat message event
+func (c *Creator) CreateChatMessageEvent(serverID, steamID, playerName, channel, message string, timestamp time.Time, isCatchup bool) error {
+	data := ChatMessageData{
+		SteamID:    steamID,
+		PlayerName: playerName,
+		Channel:    channel,
+		Message:    message,
+		Timestamp:  timestamp,
+		IsCatchup:  isCatchup,
+	}
+	return c.CreateEvent(TypeChatMessage, serverID, data)
+}
+
+// CreateWeaponFireEvent creates a weapon fire event
+func (c *Creator) CreateWeaponFireEvent(serverID string, data WeaponFireData) error {
+	return c.CreateEvent(TypeWeaponFire, serverID, data)
+}
+
+// CreateKillAssistEvent creates a kill assist event
+func (c *Creator) CreateKillAssistEvent(serverID string, data KillAssistData) error {
+	return c.CreateEvent(TypeKillAssist, serverID, data)
+}
+
+// CreateMapVoteEvent creates a map vote event
+func (c *Creator) CreateMapVoteEvent(serverID string, data MapVoteData) error {
+	return c.CreateEvent(TypeMapVote, serverID, data)
+}
+
+// CreateServerOfflineEvent creates a server offline event
+func (c *Creator) CreateServerOfflineEvent(serverID string, data ServerOfflineData) error {
+	return c.CreateEvent(TypeServerOffline, serverID, data)
+}
+
+// CreateServerOnlineEvent creates a server back online event
+func (c *Creator) CreateServerOnlineEvent(serverID string, data ServerOnlineData) error {
+	return c.CreateEvent(TypeServerOnline, serverID, data)
+}
+
 // CreateAppStartedEvent creates an app started event (no server)
 func (c *Creator) CreateAppStartedEvent(version string) error {
 	data := AppStartedData{
@@ -0,0 +1,203 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestCreateEvent_RetriesTransientFailures fails the first saves of an event and checks it is
+// saved once the failures stop, that an event created meanwhile is saved after it rather than
+// before, and that an event that never saves is dropped and counted
+func TestCreateEvent_RetriesTransientFailures(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	servers, err := testApp.FindCollectionByNameOrId("servers")
+	if err != nil {
+		t.Fatalf("failed to find servers collection: %v", err)
+	}
+	server := core.NewRecord(servers)
+	server.Set("external_id", "test-server-retry")
+	server.Set("name", "Retry Test Server")
+	server.Set("path", "/path")
+	if err := testApp.Save(server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// Fail the next `failures` saves, as a locked database would, and record the saved order
+	var (
+		mu       sync.Mutex
+		failures int
+		saved    []string
+	)
+	testApp.OnRecordCreate("events").BindFunc(func(e *core.RecordEvent) error {
+		mu.Lock()
+		if failures > 0 {
+			failures--
+			mu.Unlock()
+			return errors.New("database is locked")
+		}
+		mu.Unlock()
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+		mu.Lock()
+		saved = append(saved, e.Record.GetString("type"))
+		mu.Unlock()
+		return nil
+	})
+	setFailures := func(n int) {
+		mu.Lock()
+		failures = n
+		mu.Unlock()
+	}
+
+	creator := NewCreator(testApp)
+	creator.SetRetryPolicy(5, 10*time.Millisecond, 0)
+
+	waitForQueue := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for creator.PendingEvents() > 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("retry queue did not drain, %d events pending", creator.PendingEvents())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	setFailures(3)
+	if err := creator.CreateEvent(TypeMapTravel, "test-server-retry", map[string]any{"map": "Farmhouse"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v, want the failed save to be queued", err)
+	}
+	// Created while the map travel is still failing: must not be saved ahead of it
+	if err := creator.CreateEvent(TypePlayerKill, "test-server-retry", map[string]any{"weapon": "M4A1"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	waitForQueue()
+
+	mu.Lock()
+	order := append([]string(nil), saved...)
+	mu.Unlock()
+	if len(order) != 2 || order[0] != TypeMapTravel || order[1] != TypePlayerKill {
+		t.Fatalf("Expected the map travel then the kill to be saved, got %v", order)
+	}
+	if count, _ := testApp.CountRecords("events"); count != 2 {
+		t.Errorf("Expected 2 events, got %d", count)
+	}
+	if dropped := creator.DroppedEvents(); len(dropped) != 0 {
+		t.Errorf("Expected no dropped events, got %v", dropped)
+	}
+
+	// An event that keeps failing is given up on after the retries and counted
+	setFailures(100)
+	if err := creator.CreateEvent(TypeRoundEnd, "test-server-retry", nil); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	waitForQueue()
+	if dropped := creator.DroppedEvents(); dropped[TypeRoundEnd] != 1 {
+		t.Errorf("Expected 1 dropped round end event, got %v", dropped)
+	}
+
+	// The queue is free again once the failures stop
+	setFailures(0)
+	if err := creator.CreateEvent(TypeRoundStart, "test-server-retry", nil); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if count, _ := testApp.CountRecords("events"); count != 3 {
+		t.Errorf("Expected the next event to be saved straight away, got %d events", count)
+	}
+
+	// On shutdown, Flush retries without waiting out the backoff
+	creator.SetRetryPolicy(0, time.Hour, 0)
+	setFailures(1)
+	if err := creator.CreateEvent(TypeGameOver, "test-server-retry", nil); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := creator.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v, %d events pending", err, creator.PendingEvents())
+	}
+	if count, _ := testApp.CountRecords("events"); count != 4 {
+		t.Errorf("Expected the flushed event to be saved, got %d events", count)
+	}
+}
+
+// TestCreateEvent_RetryDoesNotRepeatHookEffects fails an event's insert after its hook already
+// wrote to the database, and checks the retried event leaves that write applied once
+func TestCreateEvent_RetryDoesNotRepeatHookEffects(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	servers, err := testApp.FindCollectionByNameOrId("servers")
+	if err != nil {
+		t.Fatalf("failed to find servers collection: %v", err)
+	}
+	server := core.NewRecord(servers)
+	server.Set("external_id", "test-server-retry")
+	server.Set("name", "Kills:")
+	server.Set("path", "/path")
+	if err := testApp.Save(server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// Count the kill on the server record like the stat hooks do, then fail the first insert
+	var mu sync.Mutex
+	failures := 1
+	testApp.OnRecordCreate("events").BindFunc(func(e *core.RecordEvent) error {
+		record, err := e.App.FindRecordById("servers", server.Id)
+		if err != nil {
+			return err
+		}
+		record.Set("name", record.GetString("name")+"+")
+		if err := e.App.Save(record); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			return errors.New("database is locked")
+		}
+		return e.Next()
+	})
+
+	creator := NewCreator(testApp)
+	creator.SetRetryPolicy(5, 10*time.Millisecond, 0)
+	if err := creator.CreateEvent(TypePlayerKill, "test-server-retry", map[string]any{"weapon": "M4A1"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := creator.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	record, err := testApp.FindRecordById("servers", server.Id)
+	if err != nil {
+		t.Fatalf("failed to find server: %v", err)
+	}
+	if got := record.GetString("name"); got != "Kills:+" {
+		t.Errorf("Expected the kill to be counted once, got server name %q", got)
+	}
+	if count, _ := testApp.CountRecords("events"); count != 1 {
+		t.Errorf("Expected 1 event, got %d", count)
+	}
+}
@@ -0,0 +1,165 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Retry defaults for events whose insert fails
+const (
+	DefaultRetryAttempts = 5                      // Attempts after the first failure before an event is dropped
+	DefaultRetryBackoff  = 500 * time.Millisecond // Wait before the first retry; doubles on every attempt
+	DefaultRetryMaxQueue = 1000                   // Events held per server before new ones are dropped
+)
+
+// pendingEvent is an event waiting for its insert to be retried
+type pendingEvent struct {
+	eventType        string
+	serverExternalID string
+	dedupKey         string
+	data             any
+}
+
+// retryQueue holds events whose insert failed (e.g. the database was briefly locked) and
+// re-attempts them in the background with exponential backoff.
+//
+// Events are queued per server, and while a server has events queued every new event for it
+// is queued behind them rather than inserted, so a retried map travel is still recorded before
+// the kills that followed it. An event that keeps failing is dropped and counted so the queue
+// cannot stall forever.
+type retryQueue struct {
+	mu       sync.Mutex
+	pending  map[string][]*pendingEvent // Server external ID -> events in log order
+	dropped  map[string]int             // Event type -> events given up on
+	attempts int
+	backoff  time.Duration
+	maxQueue int
+
+	hurry     chan struct{} // Closed by flush to skip the remaining backoff waits
+	hurryOnce sync.Once
+}
+
+func newRetryQueue() *retryQueue {
+	return &retryQueue{
+		pending:  make(map[string][]*pendingEvent),
+		dropped:  make(map[string]int),
+		attempts: DefaultRetryAttempts,
+		backoff:  DefaultRetryBackoff,
+		maxQueue: DefaultRetryMaxQueue,
+		hurry:    make(chan struct{}),
+	}
+}
+
+// enqueue appends an event to its server's queue and reports whether a drain needs to be
+// started. Returns false for accepted when the queue is full and the event was dropped.
+func (q *retryQueue) enqueue(event *pendingEvent) (accepted, start bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.pending[event.serverExternalID]
+	if len(queue) >= q.maxQueue {
+		q.dropped[event.eventType]++
+		return false, false
+	}
+	q.pending[event.serverExternalID] = append(queue, event)
+	return true, len(queue) == 0
+}
+
+// busy reports whether a server has events waiting, so new ones must queue behind them
+func (q *retryQueue) busy(serverExternalID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending[serverExternalID]) > 0
+}
+
+// head returns the oldest event waiting for a server, or nil when its queue is empty
+func (q *retryQueue) head(serverExternalID string) *pendingEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if queue := q.pending[serverExternalID]; len(queue) > 0 {
+		return queue[0]
+	}
+	return nil
+}
+
+// pop removes a server's oldest event, counting it as dropped when it was given up on
+func (q *retryQueue) pop(serverExternalID string, dropped bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.pending[serverExternalID]
+	if len(queue) == 0 {
+		return
+	}
+	if dropped {
+		q.dropped[queue[0].eventType]++
+	}
+	if len(queue) == 1 {
+		delete(q.pending, serverExternalID)
+		return
+	}
+	q.pending[serverExternalID] = queue[1:]
+}
+
+// drain retries a server's queued events in order until the queue is empty. Only one drain
+// runs per server: it is started by the enqueue that found the queue empty.
+func (q *retryQueue) drain(serverExternalID string, insert func(*pendingEvent) error, logFailure func(*pendingEvent, int, error)) {
+	for event := q.head(serverExternalID); event != nil; event = q.head(serverExternalID) {
+		delay := q.backoff
+		var err error
+		for attempt := 1; attempt <= q.attempts; attempt++ {
+			select {
+			case <-time.After(delay):
+			case <-q.hurry:
+			}
+			delay *= 2
+			if err = insert(event); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			logFailure(event, q.attempts, err)
+		}
+		q.pop(serverExternalID, err != nil)
+	}
+}
+
+// flush makes the drains retry without waiting out their backoff, and waits until every queue
+// is empty or ctx is done. Events still failing are given up on after their attempts as usual.
+func (q *retryQueue) flush(ctx context.Context) error {
+	q.hurryOnce.Do(func() { close(q.hurry) })
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for q.pendingCount() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// pendingCount returns the number of events waiting to be retried across all servers
+func (q *retryQueue) pendingCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	count := 0
+	for _, queue := range q.pending {
+		count += len(queue)
+	}
+	return count
+}
+
+// droppedCounts returns the number of events given up on, by event type
+func (q *retryQueue) droppedCounts() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	counts := make(map[string]int, len(q.dropped))
+	for eventType, count := range q.dropped {
+		counts[eventType] = count
+	}
+	return counts
+}
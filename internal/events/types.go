@@ -9,6 +9,8 @@ const (
 	TypePlayerKill  = "player_kill"
 	TypePlayerJoin  = "player_join"
 	TypePlayerLeave = "player_leave"
+	TypeWeaponFire  = "weapon_fire"
+	TypeKillAssist  = "kill_assist"
 
 	// Match events
 	TypeMatchStart     = "match_start"
@@ -17,6 +19,12 @@ const (
 	TypeMapTravel      = "map_travel"
 	TypeGameOver       = "game_over"
 	TypeLogFileCreated = "log_file_created"
+	TypeServerCrash    = "server_crash"
+	TypeMapVote        = "map_vote"
+
+	// Availability events (from A2S, not the log)
+	TypeServerOffline = "server_offline"
+	TypeServerOnline  = "server_online"
 
 	// Round events
 	TypeRoundStart = "round_start"
@@ -28,6 +36,7 @@ const (
 
 	// Chat events
 	TypeChatCommand = "chat_command"
+	TypeChatMessage = "chat_message"
 
 	// Connection events (no game event)
 	TypePlayerConnection = "player_connection"
@@ -47,10 +56,23 @@ type PlayerLoginData struct {
 
 // PlayerKillData represents data for a player_kill event
 type PlayerKillData struct {
-	Killers   []Killer `json:"killers"`
-	Victim    Victim   `json:"victim"`
-	Weapon    string   `json:"weapon"` // Raw weapon name from log (e.g., BP_Firearm_M4A1_C_2147480587)
-	IsCatchup bool     `json:"is_catchup"`
+	Killers    []Killer  `json:"killers"`
+	Victim     Victim    `json:"victim"`
+	Weapon     string    `json:"weapon"`               // Raw weapon name from log (e.g., BP_Firearm_M4A1_C_2147480587)
+	HitRegion  string    `json:"hit_region,omitempty"` // Hit region when the log reports one (e.g., Head)
+	IsHeadshot bool      `json:"is_headshot"`
+	Timestamp  time.Time `json:"timestamp"` // Log timestamp of the kill (used for multi-kill windows)
+	IsCatchup  bool      `json:"is_catchup"`
+}
+
+// KillAssistData represents data for a kill_assist event: assisters logged on their own line
+// after the kill, for configs that split a multi-killer kill across lines
+type KillAssistData struct {
+	Assisters []Killer  `json:"assisters"`
+	Victim    Victim    `json:"victim"`
+	Weapon    string    `json:"weapon"`    // Raw weapon name from the kill the assist belongs to
+	Timestamp time.Time `json:"timestamp"` // Log timestamp of the assist line
+	IsCatchup bool      `json:"is_catchup"`
 }
 
 // Killer represents a killer in a player_kill event
@@ -70,6 +92,7 @@ type Victim struct {
 // PlayerJoinData represents data for a player_join event
 type PlayerJoinData struct {
 	PlayerName string `json:"player_name"`
+	TeamSwap   bool   `json:"team_swap,omitempty"` // Rejoined straight after leaving, e.g. switching teams
 	IsCatchup  bool   `json:"is_catchup"`
 }
 
@@ -90,8 +113,9 @@ type MatchStartData struct {
 
 // MatchEndData represents data for a match_end event
 type MatchEndData struct {
-	MatchID string    `json:"match_id"`
-	EndTime time.Time `json:"end_time"`
+	MatchID   string    `json:"match_id"`
+	EndTime   time.Time `json:"end_time"`
+	IsCatchup bool      `json:"is_catchup"`
 }
 
 // MapLoadData represents data for a map_load event
@@ -112,9 +136,30 @@ type MapTravelData struct {
 	IsCatchup  bool      `json:"is_catchup"`
 }
 
+// MapVoteOption represents a single option offered in an end-of-match map vote
+type MapVoteOption struct {
+	ID       int    `json:"id"`
+	Map      string `json:"map"`
+	Scenario string `json:"scenario"`
+}
+
+// MapVoteData represents data for a map_vote event
+// Emitted once the server travels to the map that won the vote
+type MapVoteData struct {
+	Options   []MapVoteOption `json:"options"`
+	Winner    MapVoteOption   `json:"winner"`     // ID is -1 when the winner was not one of the listed options
+	Outcome   string          `json:"outcome"`    // majority, deadline, or random (no votes cast)
+	VoteShare *float64        `json:"vote_share"` // Fraction of players that voted for the winner (majority only)
+	Threshold *float64        `json:"threshold"`  // Fraction required for a majority (majority only)
+	StartedAt time.Time       `json:"started_at"`
+	EndedAt   time.Time       `json:"ended_at"`
+	IsCatchup bool            `json:"is_catchup"`
+}
+
 // GameOverData represents data for a game_over event
 type GameOverData struct {
 	Timestamp time.Time `json:"timestamp"`
+	IsCatchup bool      `json:"is_catchup"`
 }
 
 // LogFileCreatedData represents data for a log_file_created event
@@ -122,6 +167,30 @@ type LogFileCreatedData struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// ServerCrashData represents data for a server_crash event
+// Emitted once per crash report, from its first fatal error or assertion line
+type ServerCrashData struct {
+	Reason    string    `json:"reason"` // The fatal error or assertion message, without the log prefix
+	Timestamp time.Time `json:"timestamp"`
+	IsCatchup bool      `json:"is_catchup"`
+}
+
+// ServerOfflineData represents data for a server_offline event
+// Emitted once A2S queries of a server have failed a configured number of times in a row
+type ServerOfflineData struct {
+	Failures  int       `json:"failures"`   // Failed queries in a row when the alert was raised
+	Since     time.Time `json:"since"`      // First failed query
+	LastError string    `json:"last_error"` // Error of the latest failed query
+}
+
+// ServerOnlineData represents data for a server_online event
+// Emitted when a server reported offline answers an A2S query again
+type ServerOnlineData struct {
+	OfflineSince    time.Time `json:"offline_since"` // First failed query of the outage
+	RecoveredAt     time.Time `json:"recovered_at"`
+	DowntimeSeconds int       `json:"downtime_seconds"`
+}
+
 // RoundStartData represents data for a round_start event
 type RoundStartData struct {
 	MatchID     string `json:"match_id"`
@@ -148,6 +217,7 @@ type ObjectiveCapturedData struct {
 	Players       []ObjectivePlayer `json:"players"`
 	Objective     string            `json:"objective"`
 	CapturingTeam int               `json:"capturing_team"`
+	Timestamp     time.Time         `json:"timestamp"` // Log timestamp of the capture
 	IsCatchup     bool              `json:"is_catchup"`
 }
 
@@ -157,6 +227,7 @@ type ObjectiveDestroyedData struct {
 	Players        []ObjectivePlayer `json:"players"`
 	Objective      string            `json:"objective"`
 	DestroyingTeam int               `json:"destroying_team"`
+	Timestamp      time.Time         `json:"timestamp"` // Log timestamp of the destruction
 	IsCatchup      bool              `json:"is_catchup"`
 }
 
@@ -169,6 +240,28 @@ type ChatCommandData struct {
 	IsCatchup  bool     `json:"is_catchup"`
 }
 
+// ChatMessageData represents data for a chat_message event
+type ChatMessageData struct {
+	SteamID    string    `json:"steam_id"`
+	PlayerName string    `json:"player_name"`
+	Channel    string    `json:"channel"` // global or team
+	Message    string    `json:"message"`
+	Timestamp  time.Time `json:"timestamp"`
+	IsCatchup  bool      `json:"is_catchup"`
+}
+
+// WeaponFireData represents data for a weapon_fire event (verbose LogGameplayEvents only)
+type WeaponFireData struct {
+	SteamID    string    `json:"steam_id"`
+	PlayerName string    `json:"player_name"`
+	Team       int       `json:"team"`
+	Weapon     string    `json:"weapon"` // Raw weapon name from log, cleaned when stats are stored
+	Shots      int       `json:"shots"`
+	Hits       int       `json:"hits"`
+	Timestamp  time.Time `json:"timestamp"`
+	IsCatchup  bool      `json:"is_catchup"`
+}
+
 // PlayerConnectionData represents data for a player_connection event
 type PlayerConnectionData struct {
 	IP        string    `json:"ip"`
@@ -0,0 +1,83 @@
+// Package geoip resolves player IP addresses to a country and region using an offline
+// MaxMind database (GeoLite2/GeoIP2 City or Country, .mmdb).
+//
+// Lookups are best effort: a nil Resolver, an unparsable address or an address missing from
+// the database all return no location, so callers never depend on geolocation being set up.
+package geoip
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// Location is where an IP address is registered
+type Location struct {
+	Country     string `json:"country"`      // English country name, e.g. "Germany"
+	CountryCode string `json:"country_code"` // ISO 3166-1 alpha-2 code, e.g. "DE"
+	Region      string `json:"region"`       // English name of the largest subdivision; empty in Country databases
+}
+
+// record is the part of a GeoIP2 City/Country record that is read
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+// Resolver looks up IP addresses in an open GeoIP database. It is safe for concurrent use.
+type Resolver struct {
+	reader *maxminddb.Reader
+}
+
+// Open opens the GeoIP database at path
+func Open(path string) (*Resolver, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+	}
+	return &Resolver{reader: reader}, nil
+}
+
+// Lookup returns the location of ip, and false when it is unknown or r is nil
+func (r *Resolver) Lookup(ip string) (Location, bool) {
+	if r == nil {
+		return Location{}, false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Location{}, false
+	}
+
+	result := r.reader.Lookup(addr.Unmap())
+	if !result.Found() {
+		return Location{}, false
+	}
+	var rec record
+	if err := result.Decode(&rec); err != nil || rec.Country.ISOCode == "" {
+		return Location{}, false
+	}
+
+	location := Location{
+		Country:     rec.Country.Names["en"],
+		CountryCode: rec.Country.ISOCode,
+	}
+	if len(rec.Subdivisions) > 0 {
+		location.Region = rec.Subdivisions[0].Names["en"]
+	}
+	return location, true
+}
+
+// Close closes the database; a nil Resolver is a no-op
+func (r *Resolver) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
@@ -0,0 +1,48 @@
+package geoip
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// testdata/test-city.mmdb holds 81.2.69.0/24 (United Kingdom, England) and
+// 89.160.20.112/28 (Sweden, no region)
+func TestLookup(t *testing.T) {
+	resolver, err := Open(filepath.Join("testdata", "test-city.mmdb"))
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	defer resolver.Close()
+
+	tests := []struct {
+		ip    string
+		want  Location
+		found bool
+	}{
+		{"81.2.69.142", Location{Country: "United Kingdom", CountryCode: "GB", Region: "England"}, true},
+		{"::ffff:81.2.69.142", Location{Country: "United Kingdom", CountryCode: "GB", Region: "England"}, true},
+		{"89.160.20.120", Location{Country: "Sweden", CountryCode: "SE"}, true},
+		{"10.0.0.1", Location{}, false},
+		{"not an ip", Location{}, false},
+	}
+	for _, tt := range tests {
+		got, found := resolver.Lookup(tt.ip)
+		if found != tt.found || got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, %v; want %+v, %v", tt.ip, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestLookupWithoutDatabase(t *testing.T) {
+	var resolver *Resolver
+	if _, found := resolver.Lookup("81.2.69.142"); found {
+		t.Error("Expected no location without a database")
+	}
+	if err := resolver.Close(); err != nil {
+		t.Errorf("Close on a nil resolver returned %v", err)
+	}
+
+	if _, err := Open(filepath.Join("testdata", "missing.mmdb")); err == nil {
+		t.Error("Expected an error opening a missing database")
+	}
+}
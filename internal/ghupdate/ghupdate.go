@@ -100,7 +100,14 @@ func Register(app core.App, rootCmd *cobra.Command, config Config) (Plugin, erro
 		p.config.Context = context.Background()
 	}
 
-	rootCmd.AddCommand(p.updateCmd())
+	rootCmd.AddCommand(p.updateCmd(), p.waitHealthyCmd(), p.stopCmd())
+
+	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		if err := listenForShutdown(e.App); err != nil {
+			e.App.Logger().Warn("Graceful stop will not be available", "error", err)
+		}
+		return e.Next()
+	})
 
 	app.Store().Set("plugin:ghupdate", p)
 
@@ -130,7 +137,7 @@ type plugin struct {
 
 // Update performs the update with optional backup (implements Plugin interface).
 func (p *plugin) Update(withBackup bool) error {
-	return p.update(withBackup)
+	return p.update(withBackup, false)
 }
 
 // CheckForUpdate checks if a newer version is available without updating (implements Plugin interface).
@@ -157,6 +164,7 @@ func (p *plugin) CheckForUpdate(ctx context.Context) (string, error) {
 
 func (p *plugin) updateCmd() *cobra.Command {
 	var withBackup bool
+	var keepPrevious bool
 
 	command := &cobra.Command{
 		Use:          "update",
@@ -182,7 +190,7 @@ func (p *plugin) updateCmd() *cobra.Command {
 				}
 			}
 
-			return p.update(withBackup)
+			return p.update(withBackup, keepPrevious)
 		},
 	}
 
@@ -193,10 +201,24 @@ func (p *plugin) updateCmd() *cobra.Command {
 		"Creates a pb_data backup at the end of the update process",
 	)
 
+	command.PersistentFlags().BoolVar(
+		&keepPrevious,
+		"keep-previous",
+		false,
+		"Keeps the replaced executable as <executable>.old so it can be restored if the new version fails to start",
+	)
+
 	return command
 }
 
-func (p *plugin) update(withBackup bool) error {
+func (p *plugin) update(withBackup bool, keepPrevious bool) error {
+	release, err := acquireUpdateLock(filepath.Join(p.app.DataDir(), updateLockFile))
+	if err != nil {
+		color.Red("%v", err)
+		return err
+	}
+	defer release()
+
 	color.Yellow("Fetching release information...")
 
 	latest, err := fetchLatestRelease(
@@ -251,7 +273,9 @@ func (p *plugin) update(withBackup bool) error {
 		return err
 	}
 	renamedOldExec := oldExec + ".old"
-	defer os.Remove(renamedOldExec)
+	if !keepPrevious {
+		defer os.Remove(renamedOldExec)
+	}
 
 	newExec := filepath.Join(extractDir, p.config.ArchiveExecutable)
 	if _, err := os.Stat(newExec); err != nil {
@@ -262,6 +286,9 @@ func (p *plugin) update(withBackup bool) error {
 		}
 	}
 
+	// drop a previous version kept by an earlier --keep-previous update
+	_ = os.Remove(renamedOldExec)
+
 	// rename the current executable
 	if err := os.Rename(oldExec, renamedOldExec); err != nil {
 		return fmt.Errorf("failed to rename the current executable: %w", err)
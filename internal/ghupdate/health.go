@@ -0,0 +1,78 @@
+package ghupdate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// WaitHealthy polls url until it responds with 200 OK or timeout elapses.
+// It is used after an update to confirm the relaunched app actually came up.
+func WaitHealthy(ctx context.Context, client HttpClient, url string, timeout, interval time.Duration) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastErr := fmt.Errorf("no response")
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+		} else if ctx.Err() == nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not become healthy within %s: %w", url, timeout, lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *plugin) waitHealthyCmd() *cobra.Command {
+	var url string
+	var timeout, interval time.Duration
+
+	command := &cobra.Command{
+		Use:          "wait-healthy",
+		Short:        "Waits until a running instance of the app reports healthy (used after an update)",
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			color.Yellow("Waiting for %s...", url)
+
+			if err := WaitHealthy(p.config.Context, p.config.HttpClient, url, timeout, interval); err != nil {
+				color.Red("Health check failed: %v", err)
+				return err
+			}
+
+			color.Green("%s is healthy.", url)
+			return nil
+		},
+	}
+
+	command.Flags().StringVar(&url, "url", "http://127.0.0.1:8090/health", "Health endpoint to poll")
+	command.Flags().DurationVar(&timeout, "timeout", time.Minute, "How long to wait for a 200 response")
+	command.Flags().DurationVar(&interval, "interval", time.Second, "Delay between attempts")
+
+	return command
+}
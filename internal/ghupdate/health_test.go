@@ -0,0 +1,54 @@
+package ghupdate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitHealthy(t *testing.T) {
+	// Stands in for a freshly started app that serves /health only after it finished booting
+	started := time.Now()
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		if time.Since(started) < 150*time.Millisecond {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer app.Close()
+
+	t.Run("healthy after a delay", func(t *testing.T) {
+		err := WaitHealthy(context.Background(), nil, app.URL+"/health", 2*time.Second, 20*time.Millisecond)
+		if err != nil {
+			t.Fatalf("expected the app to become healthy, got: %v", err)
+		}
+		if time.Since(started) < 150*time.Millisecond {
+			t.Fatal("returned before the app was healthy")
+		}
+	})
+
+	t.Run("times out with the last status", func(t *testing.T) {
+		err := WaitHealthy(context.Background(), nil, app.URL+"/missing", 100*time.Millisecond, 20*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "status 404") {
+			t.Fatalf("expected a timeout reporting status 404, got: %v", err)
+		}
+	})
+
+	t.Run("times out when nothing is listening", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		url := closed.URL + "/health"
+		closed.Close()
+
+		if err := WaitHealthy(context.Background(), nil, url, 100*time.Millisecond, 20*time.Millisecond); err == nil {
+			t.Fatal("expected an error for an app that never started")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package ghupdate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrUpdateInProgress is returned when another process holds the update lock
+var ErrUpdateInProgress = errors.New("another update is already in progress")
+
+// updateLockFile is created in the data dir and locked for the duration of an update
+const updateLockFile = "update.lock"
+
+// acquireUpdateLock takes an exclusive lock on path without blocking, so two
+// updates (e.g. a stuck scheduled task and a manual run) cannot swap the executable
+// at the same time. The lock is held by the open file and released by the returned
+// function or when the process exits.
+func acquireUpdateLock(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open update lock: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, errLocked) {
+			if pid := lockHolder(path); pid != 0 {
+				return nil, fmt.Errorf("%w (pid %d, lock %s)", ErrUpdateInProgress, pid, path)
+			}
+			return nil, fmt.Errorf("%w (lock %s)", ErrUpdateInProgress, path)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// Record the holder for the message above; the lock itself does not depend on it
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+// lockHolder returns the PID recorded in the lock file, or 0 if it cannot be read
+func lockHolder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
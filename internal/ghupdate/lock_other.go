@@ -0,0 +1,23 @@
+//go:build !windows
+
+package ghupdate
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errLocked = syscall.EWOULDBLOCK
+
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errLocked
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,35 @@
+package ghupdate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAcquireUpdateLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), updateLockFile)
+
+	release, err := acquireUpdateLock(path)
+	if err != nil {
+		t.Fatalf("first acquisition failed: %v", err)
+	}
+
+	_, err = acquireUpdateLock(path)
+	if !errors.Is(err, ErrUpdateInProgress) {
+		t.Fatalf("expected ErrUpdateInProgress while the lock is held, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "pid "+strconv.Itoa(os.Getpid())) {
+		t.Errorf("expected the holder's pid in the error: %v", err)
+	}
+
+	release()
+
+	release, err = acquireUpdateLock(path)
+	if err != nil {
+		t.Fatalf("acquisition after release failed: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package ghupdate
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var errLocked = windows.ERROR_LOCK_VIOLATION
+
+// lockRangeOffset puts the locked byte range past the PID written to the file,
+// so other processes can still read who holds the lock
+const lockRangeOffset = 1 << 30
+
+func lockFile(file *os.File) error {
+	overlapped := &windows.Overlapped{Offset: lockRangeOffset}
+	err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, overlapped)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLocked
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	overlapped := &windows.Overlapped{Offset: lockRangeOffset}
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, overlapped)
+}
@@ -0,0 +1,108 @@
+package ghupdate
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cobra"
+)
+
+// PidFile is written by the serve command and names the running app process
+const PidFile = "sandstorm-tracker.pid"
+
+// gracefulStop asks proc to shut down without waiting for it.
+// SIGTERM is not delivered to Windows processes, so there the app is
+// signalled through the named shutdown event it creates on serve.
+func gracefulStop(proc *os.Process) error {
+	if runtime.GOOS == "windows" {
+		return signalShutdownEvent(proc.Pid)
+	}
+	return proc.Signal(syscall.SIGTERM)
+}
+
+// StopProcess stops proc gracefully and force kills it if it is still running after timeout.
+// It reports whether the process exited on its own.
+func StopProcess(proc *os.Process, timeout time.Duration) (bool, error) {
+	if err := gracefulStop(proc); err != nil {
+		color.Yellow("Graceful stop of process %d failed: %v", proc.Pid, err)
+	} else if waitExit(proc, timeout) {
+		return true, nil
+	}
+
+	if err := proc.Kill(); err != nil {
+		return false, fmt.Errorf("failed to kill process %d: %w", proc.Pid, err)
+	}
+	return false, nil
+}
+
+// shutdownEventName is the name of the event a Windows app instance waits on to shut down
+func shutdownEventName(pid int) string {
+	return fmt.Sprintf(`Local\sandstorm-tracker-shutdown-%d`, pid)
+}
+
+// terminate runs the app's terminate hooks and exits, the same way a signal would
+func terminate(app core.App) {
+	event := new(core.TerminateEvent)
+	event.App = app
+	app.OnTerminate().Trigger(event, func(e *core.TerminateEvent) error {
+		return e.App.ResetBootstrapState()
+	})
+	os.Exit(0)
+}
+
+func (p *plugin) stopCmd() *cobra.Command {
+	var pid int
+	var pidFile string
+	var timeout time.Duration
+
+	command := &cobra.Command{
+		Use:          "stop",
+		Short:        "Gracefully stops a running instance of the app, killing it after a timeout",
+		SilenceUsage: true,
+		RunE: func(command *cobra.Command, args []string) error {
+			if pid == 0 {
+				data, err := os.ReadFile(pidFile)
+				if err != nil {
+					return fmt.Errorf("failed to read PID file: %w", err)
+				}
+				pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+				if err != nil {
+					return fmt.Errorf("invalid PID file %s: %w", pidFile, err)
+				}
+			}
+
+			proc, err := os.FindProcess(pid)
+			if err != nil {
+				return fmt.Errorf("failed to find process %d: %w", pid, err)
+			}
+
+			color.Yellow("Stopping process %d...", pid)
+
+			graceful, err := StopProcess(proc, timeout)
+			if err != nil {
+				color.Red("%v", err)
+				return err
+			}
+
+			if graceful {
+				color.Green("Process %d stopped.", pid)
+			} else {
+				color.Yellow("Process %d did not stop within %s and was killed.", pid, timeout)
+			}
+			return nil
+		},
+	}
+
+	command.Flags().IntVar(&pid, "pid", 0, "Process to stop (defaults to the PID in --pid-file)")
+	command.Flags().StringVar(&pidFile, "pid-file", PidFile, "PID file written by serve")
+	command.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for a graceful shutdown before killing")
+
+	return command
+}
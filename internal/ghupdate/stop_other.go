@@ -0,0 +1,35 @@
+//go:build !windows
+
+package ghupdate
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// listenForShutdown is a no-op outside Windows, where serve already shuts down on SIGTERM
+func listenForShutdown(app core.App) error {
+	return nil
+}
+
+func signalShutdownEvent(pid int) error {
+	return errors.New("shutdown events are only supported on Windows")
+}
+
+// waitExit polls until proc has exited or timeout elapses
+func waitExit(proc *os.Process, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := proc.Signal(syscall.Signal(0)); err != nil {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !windows
+
+package ghupdate
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startTrapping starts a shell that records SIGTERM in a file and then runs onTerm
+func startTrapping(t *testing.T, onTerm string) (*exec.Cmd, string) {
+	t.Helper()
+
+	marker := filepath.Join(t.TempDir(), "term")
+	cmd := exec.Command("sh", "-c", `trap 'echo term > "$MARKER"; `+onTerm+`' TERM; echo ready; while :; do sleep 0.05; done`)
+	cmd.Env = append(os.Environ(), "MARKER="+marker)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to capture stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start shell: %v", err)
+	}
+	// Wait until the trap is installed
+	if _, err := stdout.Read(make([]byte, 5)); err != nil {
+		t.Fatalf("shell did not start: %v", err)
+	}
+
+	// Reap the child so it does not linger as a zombie that still accepts signals
+	go cmd.Wait()
+
+	return cmd, marker
+}
+
+func TestStopProcess(t *testing.T) {
+	t.Run("exits on SIGTERM", func(t *testing.T) {
+		cmd, marker := startTrapping(t, "exit 0")
+
+		graceful, err := StopProcess(cmd.Process, 5*time.Second)
+		if err != nil {
+			t.Fatalf("StopProcess failed: %v", err)
+		}
+		if !graceful {
+			t.Error("expected the process to stop gracefully")
+		}
+		if _, err := os.Stat(marker); err != nil {
+			t.Errorf("SIGTERM was not delivered: %v", err)
+		}
+	})
+
+	t.Run("killed after SIGTERM is ignored", func(t *testing.T) {
+		cmd, marker := startTrapping(t, ":")
+
+		graceful, err := StopProcess(cmd.Process, 300*time.Millisecond)
+		if err != nil {
+			t.Fatalf("StopProcess failed: %v", err)
+		}
+		if graceful {
+			t.Error("expected the process to be killed")
+		}
+		if _, err := os.Stat(marker); err != nil {
+			t.Errorf("SIGTERM was not attempted before the kill: %v", err)
+		}
+		if !waitExit(cmd.Process, 2*time.Second) {
+			t.Error("process is still running after being killed")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+//go:build windows
+
+package ghupdate
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"golang.org/x/sys/windows"
+)
+
+// listenForShutdown creates the named shutdown event for this process and
+// terminates the app once another process (the stop command) sets it
+func listenForShutdown(app core.App) error {
+	name, err := windows.UTF16PtrFromString(shutdownEventName(os.Getpid()))
+	if err != nil {
+		return err
+	}
+
+	event, err := windows.CreateEvent(nil, 1, 0, name)
+	if err != nil {
+		return fmt.Errorf("failed to create shutdown event: %w", err)
+	}
+
+	go func() {
+		defer windows.CloseHandle(event)
+		if _, err := windows.WaitForSingleObject(event, windows.INFINITE); err != nil {
+			app.Logger().Warn("Waiting for the shutdown event failed", "error", err)
+			return
+		}
+		app.Logger().Info("Shutdown requested, stopping")
+		terminate(app)
+	}()
+
+	return nil
+}
+
+func signalShutdownEvent(pid int) error {
+	name, err := windows.UTF16PtrFromString(shutdownEventName(pid))
+	if err != nil {
+		return err
+	}
+
+	event, err := windows.OpenEvent(windows.EVENT_MODIFY_STATE, false, name)
+	if err != nil {
+		return fmt.Errorf("process %d is not listening for shutdown: %w", pid, err)
+	}
+	defer windows.CloseHandle(event)
+
+	return windows.SetEvent(event)
+}
+
+// waitExit waits until proc has exited or timeout elapses
+func waitExit(proc *os.Process, timeout time.Duration) bool {
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(proc.Pid))
+	if err != nil {
+		// The process is already gone
+		return true
+	}
+	defer windows.CloseHandle(handle)
+
+	result, err := windows.WaitForSingleObject(handle, uint32(timeout.Milliseconds()))
+	return err == nil && result == windows.WAIT_OBJECT_0
+}
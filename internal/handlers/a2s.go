@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// a2sSnapshotProvider is implemented by apps that keep an A2S pool
+type a2sSnapshotProvider interface {
+	GetA2SSnapshots() map[string]a2s.Snapshot // Keyed by server external_id
+}
+
+// a2sServerSnapshot is one server's cached A2S snapshot as returned by the API
+type a2sServerSnapshot struct {
+	ServerID    string          `json:"server_id"` // Server record ID, empty if the server has no record yet
+	ExternalID  string          `json:"external_id"`
+	Name        string          `json:"name"`
+	Address     string          `json:"address"`
+	Status      string          `json:"status"` // ok, stale, unreachable or pending
+	Info        *a2s.ServerInfo `json:"info"`
+	Players     []a2s.Player    `json:"players"`
+	AgeSeconds  float64         `json:"age_seconds"` // Age of info and players, 0 when there are none
+	LastQuery   *time.Time      `json:"last_query"`
+	LastSuccess *time.Time      `json:"last_success"`
+	Error       string          `json:"error,omitempty"` // Why the last query failed
+}
+
+// newA2SServerSnapshot builds the API view of a snapshot; server may be nil.
+// Player names are replaced by names when the server hides them.
+func newA2SServerSnapshot(externalID string, server *core.Record, snapshot a2s.Snapshot, names *playerNames, now time.Time) a2sServerSnapshot {
+	result := a2sServerSnapshot{
+		ExternalID: externalID,
+		Name:       snapshot.Name,
+		Address:    snapshot.Address,
+		Status:     snapshot.State(),
+		Info:       snapshot.Info,
+		Players:    make([]a2s.Player, 0, len(snapshot.Players)),
+		AgeSeconds: snapshot.Age(now).Seconds(),
+	}
+	if server != nil {
+		result.ServerID = server.Id
+		result.Name = server.GetString("name")
+	}
+	// A2S reports no Steam IDs, so players are told apart by name
+	for _, player := range snapshot.Players {
+		player.Name = names.name("", player.Name)
+		result.Players = append(result.Players, player)
+	}
+	if !snapshot.LastQuery.IsZero() {
+		result.LastQuery = &snapshot.LastQuery
+	}
+	if !snapshot.LastSuccess.IsZero() {
+		result.LastSuccess = &snapshot.LastSuccess
+	}
+	if snapshot.Error != nil {
+		result.Error = snapshot.Error.Error()
+	}
+	return result
+}
+
+// registerA2S registers the endpoints that expose the A2S pool's cached snapshots
+// The pool is never queried on request; snapshots are as fresh as the last scheduled query
+func registerA2S(app AppInterface, e *core.ServeEvent) {
+	snapshots := func(re *core.RequestEvent) (map[string]a2s.Snapshot, error) {
+		provider, ok := app.(a2sSnapshotProvider)
+		if !ok {
+			return nil, re.Error(http.StatusServiceUnavailable, "A2S is not available", nil)
+		}
+		return provider.GetA2SSnapshots(), nil
+	}
+
+	// GET /api/a2s - Cached server info and players of every server in the A2S pool the viewer
+	// may see, by name
+	e.Router.GET("/api/a2s", func(re *core.RequestEvent) error {
+		cached, err := snapshots(re)
+		if err != nil {
+			return err
+		}
+
+		v := viewerOf(re)
+		now := time.Now()
+		results := make([]a2sServerSnapshot, 0, len(cached))
+		for externalID, snapshot := range cached {
+			server, _ := re.App.FindFirstRecordByData("servers", "external_id", externalID)
+			names := &playerNames{}
+			switch {
+			case server == nil && !v.admin:
+				continue // Not set up as a server yet, so not public either
+			case server != nil:
+				if !v.canSee(server) {
+					continue
+				}
+				names = v.newPlayerNames(server)
+			}
+			results = append(results, newA2SServerSnapshot(externalID, server, snapshot, names, now))
+		}
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].Name != results[j].Name {
+				return results[i].Name < results[j].Name
+			}
+			return results[i].ExternalID < results[j].ExternalID
+		})
+
+		return re.JSON(http.StatusOK, results)
+	})
+
+	// GET /api/a2s/{serverId} - Cached server info and players of one server
+	// serverId accepts the server record ID or its external_id
+	e.Router.GET("/api/a2s/{serverId}", func(re *core.RequestEvent) error {
+		v := viewerOf(re)
+		server, err := findRecordByIdOrExternalID(re.App, "servers", re.Request.PathValue("serverId"))
+		if err != nil || !v.canSee(server) {
+			return re.NotFoundError("Server not found", err)
+		}
+
+		cached, err := snapshots(re)
+		if err != nil {
+			return err
+		}
+
+		externalID := server.GetString("external_id")
+		snapshot, ok := cached[externalID]
+		if !ok {
+			return re.NotFoundError("Server is not monitored over A2S", nil)
+		}
+
+		return re.JSON(http.StatusOK, newA2SServerSnapshot(externalID, server, snapshot, v.newPlayerNames(server), time.Now()))
+	})
+}
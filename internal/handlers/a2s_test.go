@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// mockA2SApp serves fixed A2S snapshots in place of the app's pool
+type mockA2SApp struct {
+	mockRconApp
+	snapshots map[string]a2s.Snapshot
+}
+
+func (m *mockA2SApp) GetA2SSnapshots() map[string]a2s.Snapshot {
+	return m.snapshots
+}
+
+// TestA2SSnapshotEndpoints checks the cached snapshot JSON for one and all servers,
+// including a server whose last query failed
+func TestA2SSnapshotEndpoints(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	liveID, err := database.GetOrCreateServer(ctx, baseApp, "server-live", "Live Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if _, err := database.GetOrCreateServer(ctx, baseApp, "server-down", "Down Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if _, err := database.GetOrCreateServer(ctx, baseApp, "server-no-a2s", "No A2S", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	now := time.Now()
+	snapshots := map[string]a2s.Snapshot{
+		"server-live": {
+			Address:     "127.0.0.1:27131",
+			Name:        "Live Server",
+			Info:        &a2s.ServerInfo{Name: "Live Server", Map: "Farmhouse", Players: 2, MaxPlayers: 16},
+			Players:     []a2s.Player{{Name: "ArmoredBear", Score: 420, Duration: 900}, {Name: "Kestrel", Score: 150, Duration: 300}},
+			LastQuery:   now.Add(-30 * time.Second),
+			LastSuccess: now.Add(-30 * time.Second),
+		},
+		"server-down": {
+			Address:     "127.0.0.1:27132",
+			Name:        "Down Server",
+			Info:        &a2s.ServerInfo{Name: "Down Server", Map: "Town"},
+			LastQuery:   now.Add(-10 * time.Second),
+			LastSuccess: now.Add(-10 * time.Minute),
+			Error:       errors.New("i/o timeout"),
+		},
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockA2SApp{mockRconApp: mockRconApp{TestApp: app}, snapshots: snapshots}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "single server snapshot",
+			Method:         http.MethodGet,
+			URL:            "/api/a2s/" + liveID,
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"status":"ok"`,
+				`"external_id":"server-live"`,
+				`"map":"Farmhouse"`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				var snapshot a2sServerSnapshot
+				if err := json.NewDecoder(res.Body).Decode(&snapshot); err != nil {
+					t.Fatalf("failed to decode snapshot: %v", err)
+				}
+				if snapshot.ServerID != liveID || snapshot.Name != "Live Server" || snapshot.Address != "127.0.0.1:27131" {
+					t.Errorf("unexpected server fields: %+v", snapshot)
+				}
+				if len(snapshot.Players) != 2 || snapshot.Players[0].Name != "ArmoredBear" || snapshot.Players[0].Score != 420 {
+					t.Errorf("unexpected players: %+v", snapshot.Players)
+				}
+				if snapshot.AgeSeconds < 30 || snapshot.AgeSeconds > 60 {
+					t.Errorf("expected a snapshot about 30s old, got %.1fs", snapshot.AgeSeconds)
+				}
+				if snapshot.Error != "" {
+					t.Errorf("expected no error, got %q", snapshot.Error)
+				}
+			},
+		},
+		{
+			Name:           "failed query is reported as stale",
+			Method:         http.MethodGet,
+			URL:            "/api/a2s/server-down",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"status":"stale"`,
+				`"error":"i/o timeout"`,
+				`"players":[]`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:            "server outside the pool",
+			Method:          http.MethodGet,
+			URL:             "/api/a2s/server-no-a2s",
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"message":"Server is not monitored over A2S."`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:           "all servers",
+			Method:         http.MethodGet,
+			URL:            "/api/a2s",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"external_id":"server-live"`,
+				`"external_id":"server-down"`,
+			},
+			NotExpectedContent: []string{"server-no-a2s"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				var all []a2sServerSnapshot
+				if err := json.NewDecoder(res.Body).Decode(&all); err != nil {
+					t.Fatalf("failed to decode snapshots: %v", err)
+				}
+				if len(all) != 2 {
+					t.Fatalf("expected 2 snapshots, got %d", len(all))
+				}
+				// Sorted by name
+				if all[0].Name != "Down Server" || all[0].Status != a2s.SnapshotStale {
+					t.Errorf("first snapshot = %+v", all[0])
+				}
+				if all[1].Name != "Live Server" || all[1].Status != a2s.SnapshotOK {
+					t.Errorf("second snapshot = %+v", all[1])
+				}
+			},
+		},
+		{
+			Name:            "unavailable without an A2S pool",
+			Method:          http.MethodGet,
+			URL:             "/api/a2s",
+			ExpectedStatus:  http.StatusServiceUnavailable,
+			ExpectedContent: []string{`"message":"A2S is not available."`},
+			TestAppFactory:  setup,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				Register(&mockRconApp{TestApp: app}, e)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
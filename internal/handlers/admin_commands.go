@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// adminCommandsGetter is implemented by apps that configure in-game admin commands
+type adminCommandsGetter interface {
+	GetAdminCommands() config.AdminCommandsConfig
+}
+
+// steamIDPattern matches a 64-bit SteamID, which is passed to RCON as-is
+var steamIDPattern = regexp.MustCompile(`^\d{17}$`)
+
+// HandleAdminCommand runs a moderation chat command (!kick, !ban, ...) from the admin command table.
+// Returns true when the chat command is an admin command - whether it ran or was rejected - so the
+// caller only falls back to the regular chat commands otherwise.
+func HandleAdminCommand(e *core.RecordEvent, rconSender func(string, string) (string, error), admin config.AdminCommandsConfig) bool {
+	logger := e.App.Logger().With("COMPONENT", "CHAT_EVENT")
+	ctx := context.Background()
+
+	var data events.ChatCommandData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		return false
+	}
+
+	fields := append(strings.Fields(data.Command), data.Args...)
+	if len(fields) == 0 {
+		return false
+	}
+	command, ok := admin.Lookup(fields[0])
+	if !ok {
+		return false
+	}
+	args := fields[1:]
+
+	// Never act on replayed log lines
+	if data.IsCatchup || rconSender == nil {
+		return true
+	}
+
+	serverRecord, err := e.App.FindRecordById("servers", e.Record.GetString("server"))
+	if err != nil {
+		logger.Debug("Failed to get server record", "error", err)
+		return true
+	}
+	serverID := serverRecord.GetString("external_id")
+
+	if !admin.IsAdmin(data.SteamID) {
+		logger.Warn("Rejected admin command from non-admin", "player", data.PlayerName, "steamID", data.SteamID, "command", data.Command)
+		sendRconSay(rconSender, logger, serverID, fmt.Sprintf("%s: you are not allowed to use !%s", data.PlayerName, command.Name))
+		return true
+	}
+
+	if len(args) < command.MinArgs {
+		usage := command.Usage
+		if usage == "" {
+			usage = "!" + command.Name
+		}
+		sendRconSay(rconSender, logger, serverID, "Usage: "+usage)
+		return true
+	}
+
+	rconCommand, err := renderAdminCommand(ctx, e.App, serverID, command, args)
+	if err != nil {
+		sendRconSay(rconSender, logger, serverID, fmt.Sprintf("!%s: %v", command.Name, err))
+		return true
+	}
+
+	response, err := rconSender(serverID, rconCommand)
+	if err != nil {
+		logger.Warn("Admin command failed", "admin", data.PlayerName, "steamID", data.SteamID, "rcon", rconCommand, "error", err)
+		sendRconSay(rconSender, logger, serverID, fmt.Sprintf("!%s failed: %v", command.Name, err))
+		return true
+	}
+
+	logger.Info("Admin command executed", "admin", data.PlayerName, "steamID", data.SteamID, "rcon", rconCommand)
+
+	// RCON replies can span several lines; the first one is enough for chat
+	reply, _, _ := strings.Cut(strings.TrimSpace(response), "\n")
+	if reply == "" {
+		reply = "done"
+	}
+	sendRconSay(rconSender, logger, serverID, fmt.Sprintf("!%s: %s", command.Name, reply))
+	return true
+}
+
+// renderAdminCommand fills in an admin command's RCON template.
+// {target} is resolved to the SteamID of a connected player when it names one.
+func renderAdminCommand(ctx context.Context, pbApp core.App, serverID string, command config.AdminCommand, args []string) (string, error) {
+	var target, reason string
+	if len(args) > 0 {
+		target = args[0]
+		reason = strings.Join(args[1:], " ")
+	}
+
+	if strings.Contains(command.Rcon, "{target}") && target != "" {
+		resolved, err := resolveTargetPlayer(ctx, pbApp, serverID, target)
+		if err != nil {
+			return "", err
+		}
+		target = resolved
+	}
+
+	rendered := strings.NewReplacer(
+		"{target}", target,
+		"{reason}", reason,
+		"{args}", strings.Join(args, " "),
+	).Replace(command.Rcon)
+
+	return strings.TrimSpace(rendered), nil
+}
+
+// resolveTargetPlayer turns a (partial) player name into the SteamID of a connected player.
+// A SteamID is returned unchanged; an exact name match wins over partial matches.
+func resolveTargetPlayer(ctx context.Context, pbApp core.App, serverID, target string) (string, error) {
+	if steamIDPattern.MatchString(target) {
+		return target, nil
+	}
+
+	match, err := database.GetActiveMatch(ctx, pbApp, serverID)
+	if err != nil || match == nil {
+		return "", fmt.Errorf("no active match to find %q in", target)
+	}
+	players, err := database.GetConnectedPlayers(ctx, pbApp, match.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list players: %w", err)
+	}
+
+	var matches []database.Player
+	for _, player := range players {
+		if strings.EqualFold(player.Name, target) {
+			return player.ExternalID, nil
+		}
+		if strings.Contains(strings.ToLower(player.Name), strings.ToLower(target)) {
+			matches = append(matches, player)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no connected player matches %q", target)
+	case 1:
+		return matches[0].ExternalID, nil
+	default:
+		return "", fmt.Errorf("%q matches %d players, be more specific", target, len(matches))
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// mockAdminApp is a test app with an admin command configuration
+type mockAdminApp struct {
+	*mockRconApp
+	admin config.AdminCommandsConfig
+}
+
+func (m *mockAdminApp) GetAdminCommands() config.AdminCommandsConfig {
+	return m.admin
+}
+
+func TestAdminChatCommands(t *testing.T) {
+	const (
+		adminSteamID  = "76561198995742987" // ArmoredBear
+		rabbitSteamID = "76561198995742956" // Rabbit
+		serverID      = "admin-command-server"
+	)
+
+	cases := []struct {
+		name     string
+		steamID  string
+		player   string
+		command  string
+		wantSent []string
+	}{
+		{
+			name:    "admin kick resolves the player name to a steam id",
+			steamID: adminSteamID,
+			player:  "ArmoredBear",
+			command: "!kick rabb team killing",
+			wantSent: []string{
+				serverID + ":kick " + rabbitSteamID + " team killing",
+				serverID + ":say !kick: Kicked Rabbit",
+			},
+		},
+		{
+			name:    "aliases from the config are honoured",
+			steamID: adminSteamID,
+			player:  "ArmoredBear",
+			command: "!boot Rabbit",
+			wantSent: []string{
+				serverID + ":kick " + rabbitSteamID,
+				serverID + ":say !kick: done",
+			},
+		},
+		{
+			name:    "non-admin is rejected",
+			steamID: rabbitSteamID,
+			player:  "Rabbit",
+			command: "!kick ArmoredBear",
+			wantSent: []string{
+				serverID + ":say Rabbit: you are not allowed to use !kick",
+			},
+		},
+		{
+			name:    "unknown player is reported",
+			steamID: adminSteamID,
+			player:  "ArmoredBear",
+			command: "!ban Nobody",
+			wantSent: []string{
+				serverID + `:say !ban: no connected player matches "Nobody"`,
+			},
+		},
+		{
+			name:    "missing arguments show usage",
+			steamID: adminSteamID,
+			player:  "ArmoredBear",
+			command: "!map",
+			wantSent: []string{
+				serverID + ":say Usage: !map <map>",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			testApp, err := tests.NewTestApp(t.TempDir())
+			if err != nil {
+				t.Fatalf("failed to create test app: %v", err)
+			}
+			defer testApp.Cleanup()
+
+			ctx := context.Background()
+			if _, err := database.GetOrCreateServer(ctx, testApp, serverID, "Admin Server", "test/path"); err != nil {
+				t.Fatalf("failed to create server: %v", err)
+			}
+			match, err := database.CreateMatch(ctx, testApp, serverID, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create match: %v", err)
+			}
+			for steamID, name := range map[string]string{adminSteamID: "ArmoredBear", rabbitSteamID: "Rabbit"} {
+				player, err := database.GetOrCreatePlayerBySteamID(ctx, testApp, steamID, name)
+				if err != nil {
+					t.Fatalf("failed to create player: %v", err)
+				}
+				if err := database.UpsertMatchPlayerStats(ctx, testApp, match.ID, player.ID, nil, nil); err != nil {
+					t.Fatalf("failed to add player to match: %v", err)
+				}
+			}
+
+			mock := &mockAdminApp{
+				mockRconApp: &mockRconApp{
+					TestApp: testApp,
+					responses: map[string]string{
+						"kick " + rabbitSteamID + " team killing": "Kicked Rabbit\nReason: team killing",
+						"kick " + rabbitSteamID:                   "",
+					},
+				},
+				admin: config.AdminCommandsConfig{
+					Admins: []string{adminSteamID},
+					Commands: []config.AdminCommand{
+						{Name: "kick", Aliases: []string{"boot"}, Rcon: "kick {target} {reason}", MinArgs: 1},
+					},
+				},
+			}
+			NewGameEventHandlers(mock, nil).RegisterHooks()
+
+			if err := events.NewCreator(testApp).CreateChatCommandEvent(serverID, tt.steamID, tt.player, tt.command, nil, false); err != nil {
+				t.Fatalf("failed to create chat command event: %v", err)
+			}
+
+			if !reflect.DeepEqual(mock.sent, tt.wantSent) {
+				t.Errorf("unexpected RCON commands\n got: %q\nwant: %q", mock.sent, tt.wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// CheatDetector raises advisory moderation flags from kill events: an abnormally high headshot
+// ratio, kill rate spikes, and many kills across matches without dying.
+// Flags go to the moderation_flags collection and players.metadata.flags for a human to review;
+// nobody is kicked or banned because of them.
+type CheatDetector struct {
+	app core.App
+	cfg config.AntiCheatConfig
+
+	mu          sync.Mutex
+	recentKills map[string][]time.Time // Killer Steam ID -> log timestamps of kills within the kill rate window
+}
+
+// NewCheatDetector creates a detector with the given thresholds
+func NewCheatDetector(app core.App, cfg config.AntiCheatConfig) *CheatDetector {
+	return &CheatDetector{
+		app:         app,
+		cfg:         cfg,
+		recentKills: make(map[string][]time.Time),
+	}
+}
+
+// RegisterHooks subscribes the detector to newly recorded events
+func (d *CheatDetector) RegisterHooks() {
+	d.app.OnRecordAfterCreateSuccess("events").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetString("type") == events.TypePlayerKill {
+			d.checkKill(e)
+		}
+		return e.Next()
+	})
+}
+
+func (d *CheatDetector) logger() *slog.Logger {
+	return d.app.Logger().With("component", "ANTICHEAT")
+}
+
+// checkKill evaluates the credited killer of an enemy kill against every signal
+func (d *CheatDetector) checkKill(e *core.RecordEvent) {
+	ctx := context.Background()
+
+	killevent := &Killevent{}
+	killevent.SetProxyRecord(e.Record)
+
+	killers := killevent.Killers()
+	if len(killers) == 0 {
+		return
+	}
+	killer := killers[0]
+	if killer.SteamID == "" || killer.SteamID == "INVALID" || killer.SteamID == killevent.VictimSteamID() || killer.Team == killevent.VictimTeam() {
+		return
+	}
+
+	player, err := database.GetPlayerByExternalID(ctx, e.App, killer.SteamID)
+	if err != nil {
+		return
+	}
+
+	flags := []database.ModerationFlag{}
+	if flag, ok := d.killRate(killer.SteamID, killevent.Timestamp()); ok {
+		flag.MatchID = e.Record.GetString("match")
+		flags = append(flags, flag)
+	}
+	if flag, ok := d.headshotRatio(ctx, e.App, player.ID); ok {
+		flags = append(flags, flag)
+	}
+	if flag, ok := d.zeroDeaths(ctx, e.App, player.ID); ok {
+		flags = append(flags, flag)
+	}
+
+	for _, flag := range flags {
+		created, err := database.RaiseModerationFlag(ctx, e.App, player.ID, flag)
+		if err != nil {
+			d.logger().Warn("Failed to record moderation flag", "player", killer.Name, "kind", flag.Kind, "error", err)
+			continue
+		}
+		if created {
+			d.logger().Info("Raised advisory moderation flag", "player", killer.Name, "steamID", killer.SteamID, "kind", flag.Kind, "value", flag.Value, "threshold", flag.Threshold)
+		}
+	}
+}
+
+// killRate records a kill and reports a flag when the killer's kills within the window reach the rate threshold
+func (d *CheatDetector) killRate(steamID string, at time.Time) (database.ModerationFlag, bool) {
+	window := d.cfg.KillRateWindow()
+	threshold := d.cfg.KillsPerMinuteThreshold()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kills := d.recentKills[steamID]
+	// Logs are replayed in order, so older kills sit at the front
+	start := 0
+	for start < len(kills) && at.Sub(kills[start]) >= window {
+		start++
+	}
+	kills = append(kills[start:], at)
+	d.recentKills[steamID] = kills
+
+	rate := float64(len(kills)) / window.Minutes()
+	if rate < threshold {
+		return database.ModerationFlag{}, false
+	}
+	return database.ModerationFlag{
+		Kind:      database.FlagKillRate,
+		Value:     rate,
+		Threshold: threshold,
+		Details:   fmt.Sprintf("%d kills within %s", len(kills), window),
+	}, true
+}
+
+// headshotRatio reports a flag when enough of a player's kills are headshots
+func (d *CheatDetector) headshotRatio(ctx context.Context, app core.App, playerID string) (database.ModerationFlag, bool) {
+	kills, headshots, err := database.GetPlayerKillTotals(ctx, app, playerID)
+	if err != nil || kills < d.cfg.HeadshotMinKillsThreshold() {
+		return database.ModerationFlag{}, false
+	}
+
+	threshold := d.cfg.HeadshotRatioThreshold()
+	ratio := float64(headshots) / float64(kills)
+	if ratio < threshold {
+		return database.ModerationFlag{}, false
+	}
+	return database.ModerationFlag{
+		Kind:      database.FlagHeadshotRatio,
+		Value:     ratio,
+		Threshold: threshold,
+		Details:   fmt.Sprintf("%d headshots in %d kills", headshots, kills),
+	}, true
+}
+
+// zeroDeaths reports a flag when a player has many kills across finished matches without dying
+func (d *CheatDetector) zeroDeaths(ctx context.Context, app core.App, playerID string) (database.ModerationFlag, bool) {
+	minMatches, minKills := d.cfg.ZeroDeathThresholds()
+	matches, kills, err := database.GetPlayerDeathlessMatches(ctx, app, playerID)
+	if err != nil || matches < minMatches || kills < minKills {
+		return database.ModerationFlag{}, false
+	}
+	return database.ModerationFlag{
+		Kind:      database.FlagZeroDeaths,
+		Value:     float64(kills),
+		Threshold: float64(minKills),
+		Details:   fmt.Sprintf("%d kills across %d matches without dying", kills, matches),
+	}, true
+}
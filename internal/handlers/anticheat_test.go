@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/parser"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestCheatDetector_KillRateFlag feeds a burst of kills through the parser and checks only the
+// player killing at a suspicious rate gets an advisory flag
+func TestCheatDetector_KillRateFlag(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-anticheat"
+
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Anticheat Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	match, err := database.CreateMatch(ctx, testApp, serverExternalID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	NewGameEventHandlers(&mockRconApp{TestApp: testApp}, nil).RegisterHooks()
+	// Defaults: 10 kills per minute over a 60 second window
+	NewCheatDetector(testApp, config.AntiCheatConfig{}).RegisterHooks()
+	logParser := parser.NewLogParser(testApp, testApp.Logger())
+
+	start := time.Date(2025, 10, 4, 14, 30, 0, 0, time.UTC)
+	kill := func(at time.Time, killer string) string {
+		return fmt.Sprintf("[%s:%03d][100]LogGameplayEvents: Display: %s killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419",
+			at.Format("2006.01.02-15.04.05"), at.Nanosecond()/int(time.Millisecond), killer)
+	}
+
+	var lines []string
+	// ArmoredBear: 12 kills, one every 4 seconds
+	for i := range 12 {
+		lines = append(lines, kill(start.Add(time.Duration(i)*4*time.Second), "ArmoredBear[76561198995742987, team 0]"))
+	}
+	// Rabbit: 6 kills spread over five minutes
+	for i := range 6 {
+		lines = append(lines, kill(start.Add(time.Duration(i)*time.Minute), "Rabbit[76561198995742956, team 0]"))
+	}
+	for _, line := range lines {
+		if err := logParser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+			t.Fatalf("failed to process log line: %v", err)
+		}
+	}
+
+	flagsFor := func(steamID string) ([]database.ModerationFlag, map[string]database.PlayerFlag) {
+		t.Helper()
+		player, err := testApp.FindFirstRecordByData("players", "external_id", steamID)
+		if err != nil {
+			t.Fatalf("failed to find player %s: %v", steamID, err)
+		}
+		records, err := testApp.FindRecordsByFilter("moderation_flags", "player = {:player}", "", -1, 0, dbx.Params{"player": player.Id})
+		if err != nil {
+			t.Fatalf("failed to query flags: %v", err)
+		}
+		var flags []database.ModerationFlag
+		for _, record := range records {
+			if !record.GetBool("advisory") {
+				t.Errorf("Expected flag %s to be advisory", record.Id)
+			}
+			flags = append(flags, database.ModerationFlag{
+				Kind:      record.GetString("kind"),
+				Value:     record.GetFloat("value"),
+				Threshold: record.GetFloat("threshold"),
+				MatchID:   record.GetString("match"),
+			})
+		}
+		return flags, database.GetPlayerFlags(player)
+	}
+
+	flags, metadataFlags := flagsFor("76561198995742987")
+	if len(flags) != 1 {
+		t.Fatalf("Expected 1 flag for ArmoredBear, got %d: %+v", len(flags), flags)
+	}
+	flag := flags[0]
+	if flag.Kind != database.FlagKillRate {
+		t.Errorf("Expected a %s flag, got %s", database.FlagKillRate, flag.Kind)
+	}
+	if flag.MatchID != match.ID {
+		t.Errorf("Expected the flag on match %s, got %q", match.ID, flag.MatchID)
+	}
+	// 12 kills in 44 seconds all fall in one 60 second window
+	if flag.Value != 12 || flag.Threshold != 10 {
+		t.Errorf("Expected value 12 over threshold 10, got %v over %v", flag.Value, flag.Threshold)
+	}
+	if summary, ok := metadataFlags[database.FlagKillRate]; !ok || !summary.Advisory {
+		t.Errorf("Expected an advisory kill_rate flag in player metadata, got %+v", metadataFlags)
+	}
+
+	if flags, _ := flagsFor("76561198995742956"); len(flags) != 0 {
+		t.Errorf("Expected no flags for Rabbit, got %+v", flags)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// safeRedirectPath returns next when it is a path on this site, and "/" otherwise, so the login
+// page cannot be used to send people elsewhere
+func safeRedirectPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// registerLogin registers the superuser login page that guarded pages redirect to
+func registerLogin(e *core.ServeEvent, registry *template.Registry) {
+	e.Router.GET("/login", func(re *core.RequestEvent) error {
+		html, err := renderPage(re, registry, "templates/login.html", map[string]any{
+			"ActivePage": "login",
+			"Next":       safeRedirectPath(re.Request.URL.Query().Get("next")),
+		})
+
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	})
+}
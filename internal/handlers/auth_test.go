@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"sandstorm-tracker/internal/adminauth"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// createSuperuserToken saves a superuser in app and returns an auth token for it
+func createSuperuserToken(t testing.TB, app core.App) string {
+	t.Helper()
+	superusers, err := app.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := app.Save(superuser); err != nil {
+		t.Fatalf("failed to create superuser: %v", err)
+	}
+	token, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create superuser token: %v", err)
+	}
+	return token
+}
+
+// mockHealthApp reports a fixed RCON pool with one server failing
+type mockHealthApp struct {
+	*mockRconApp
+}
+
+func (m *mockHealthApp) GetRconPoolHealth() map[string]any {
+	return map[string]any{"available": true, "total_servers": 2, "healthy": 1, "unhealthy": 1}
+}
+
+func (m *mockHealthApp) GetRconPoolStatus() map[string]any {
+	return map[string]any{"available": true, "servers": map[string]any{
+		"server-a": map[string]any{"address": "10.0.0.5:27015", "connected": false, "last_error": "authentication failed"},
+	}}
+}
+
+// TestRequireAdmin checks admin pages and APIs turn anonymous requests away, let superusers in
+// by header or (for page loads) by the web UI's auth cookie, and leave the public pages open
+func TestRequireAdmin(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	token := createSuperuserToken(t, baseApp)
+	// The JS SDK's exportToCookie value: the URL encoded auth store
+	cookie := adminauth.CookieName + "=" + url.QueryEscape(`{"token":"`+token+`","record":null}`)
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "anonymous api request is rejected",
+			Method:          http.MethodGet,
+			URL:             "/api/friendly-fire",
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "anonymous page load is sent to the login page",
+			Method:          http.MethodGet,
+			URL:             "/moderation/friendly-fire?player=76561198995742987",
+			Headers:         map[string]string{"Accept": "text/html,application/xhtml+xml"},
+			ExpectedStatus:  http.StatusFound,
+			ExpectedContent: []string{},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				want := "/login?next=" + url.QueryEscape("/moderation/friendly-fire?player=76561198995742987")
+				if location := res.Header.Get("Location"); location != want {
+					t.Errorf("expected redirect to %q, got %q", want, location)
+				}
+			},
+		},
+		{
+			Name:            "superuser token is accepted",
+			Method:          http.MethodGet,
+			URL:             "/api/friendly-fire",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"items":[]`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "closing stale matches needs a superuser",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/close-stale-matches",
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "superuser closes stale matches",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/close-stale-matches?idle_minutes=60",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"closed":[]`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "idle timeout below the minimum is rejected",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/close-stale-matches?idle_minutes=0",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"at least 15"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "auth cookie is accepted on page loads",
+			Method:          http.MethodGet,
+			URL:             "/admin/servers",
+			Headers:         map[string]string{"Accept": "text/html", "Cookie": cookie},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"<html"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "auth cookie is not accepted for changes",
+			Method:          http.MethodDelete,
+			URL:             "/api/servers/missing",
+			Headers:         map[string]string{"Cookie": cookie},
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "invalid auth cookie is rejected",
+			Method:          http.MethodGet,
+			URL:             "/admin/rcon",
+			Headers:         map[string]string{"Cookie": adminauth.CookieName + "=not-json"},
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "public pages stay open",
+			Method:          http.MethodGet,
+			URL:             "/players",
+			Headers:         map[string]string{"Accept": "text/html"},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"<html"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "public health check only counts RCON servers",
+			Method:             http.MethodGet,
+			URL:                "/health",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`"healthy":1`, `"unhealthy":1`},
+			NotExpectedContent: []string{"10.0.0.5", "authentication failed"},
+			TestAppFactory:     setup,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				Register(&mockHealthApp{mockRconApp: &mockRconApp{TestApp: app}}, e)
+			},
+		},
+		{
+			Name:            "detailed health check needs a superuser",
+			Method:          http.MethodGet,
+			URL:             "/api/admin/health",
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				Register(&mockHealthApp{mockRconApp: &mockRconApp{TestApp: app}}, e)
+			},
+		},
+		{
+			Name:            "superuser gets each RCON server's status",
+			Method:          http.MethodGet,
+			URL:             "/api/admin/health",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"address":"10.0.0.5:27015"`, `"last_error":"authentication failed"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				Register(&mockHealthApp{mockRconApp: &mockRconApp{TestApp: app}}, e)
+			},
+		},
+		{
+			Name:               "login page only redirects within the site",
+			Method:             http.MethodGet,
+			URL:                "/login?next=" + url.QueryEscape("//evil.example.com"),
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`data-next="/"`},
+			NotExpectedContent: []string{"evil.example.com"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
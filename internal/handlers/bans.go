@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BanSync pushes the bans collection to the game servers. Each server's Bans.txt in SAW's
+// server-config directory is rewritten from the database, so the server manager's config copy
+// applies the bans on the next start, and banid/unban is sent over RCON so a change applies to a
+// running server straight away.
+type BanSync struct {
+	app        core.App
+	rconSender func(serverID string, command string) (string, error)
+	configDir  string // <sawPath>/server-config, empty when there is no SAW install to write to
+	now        func() time.Time
+	importing  atomic.Bool // Set while WriteAll imports Bans.txt, whose bans are in force already
+}
+
+// NewBanSync creates a ban sync. sawPath may be empty, in which case bans are only sent over RCON.
+func NewBanSync(app core.App, rconSender func(string, string) (string, error), sawPath string) *BanSync {
+	s := &BanSync{app: app, rconSender: rconSender, now: time.Now}
+	if sawPath != "" {
+		s.configDir = filepath.Join(sawPath, "server-config")
+	}
+	return s
+}
+
+// RegisterHooks syncs the servers a ban applies to after it is created, updated or deleted
+func (s *BanSync) RegisterHooks() {
+	s.app.OnRecordAfterCreateSuccess("bans").BindFunc(func(e *core.RecordEvent) error {
+		if !s.importing.Load() {
+			s.apply(database.BanFromRecord(e.Record))
+		}
+		return e.Next()
+	})
+	s.app.OnRecordAfterUpdateSuccess("bans").BindFunc(func(e *core.RecordEvent) error {
+		ban, previous := database.BanFromRecord(e.Record), database.BanFromRecord(e.Record.Original())
+		if previous.SteamID != ban.SteamID || previous.ServerID != ban.ServerID {
+			s.lift(previous)
+		}
+		s.apply(ban)
+		return e.Next()
+	})
+	s.app.OnRecordAfterDeleteSuccess("bans").BindFunc(func(e *core.RecordEvent) error {
+		s.lift(database.BanFromRecord(e.Record))
+		return e.Next()
+	})
+}
+
+// WriteAll rewrites every server's Bans.txt from the database, for use at startup. Players the
+// file lists without a ban in the database are imported first (see importBansFile).
+func (s *BanSync) WriteAll() error {
+	if s.configDir == "" {
+		return nil
+	}
+	servers, err := s.app.FindAllRecords("servers")
+	if err != nil {
+		return fmt.Errorf("failed to load servers: %w", err)
+	}
+	for _, server := range servers {
+		if err := s.importBansFile(server); err != nil {
+			return err
+		}
+		if err := s.writeBansFile(server); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importBansFile adds the players listed in a server's Bans.txt that the bans collection has no
+// ban for, in force or expired, as permanent bans on that server. This keeps bans maintained by
+// hand in the file before the tracker managed it when the file is rewritten from the database.
+// The players are banned on the server already, so nothing is sent over RCON.
+func (s *BanSync) importBansFile(server *core.Record) error {
+	if s.configDir == "" {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(s.configDir, server.GetString("external_id"), "Bans.txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Bans.txt: %w", err)
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("bans")
+	if err != nil {
+		return fmt.Errorf("bans collection not found: %w", err)
+	}
+
+	s.importing.Store(true)
+	defer s.importing.Store(false)
+
+	imported := 0
+	for _, steamID := range database.ParseBansFile(string(content)) {
+		existing, err := s.app.FindFirstRecordByFilter("bans", "steam_id = {:steam_id} && (server = '' || server = {:server})",
+			dbx.Params{"steam_id": steamID, "server": server.Id})
+		if err == nil && existing != nil {
+			continue
+		}
+
+		ban := core.NewRecord(collection)
+		ban.Set("steam_id", steamID)
+		ban.Set("server", server.Id)
+		ban.Set("reason", "Imported from Bans.txt")
+		if err := s.app.Save(ban); err != nil {
+			return fmt.Errorf("failed to import ban of %s: %w", steamID, err)
+		}
+		imported++
+	}
+
+	if imported > 0 {
+		s.logger().Info("Imported bans from Bans.txt", "server_id", server.GetString("external_id"), "bans", imported)
+	}
+	return nil
+}
+
+func (s *BanSync) logger() *slog.Logger {
+	return s.app.Logger().With("component", "BANS")
+}
+
+// apply bans the player on every server the ban covers. An expired ban is lifted instead.
+func (s *BanSync) apply(ban database.Ban) {
+	now := s.now()
+	if !ban.Active(now) {
+		s.lift(ban)
+		return
+	}
+	for _, server := range s.servers(ban) {
+		s.sync(server, ban.RconCommand(now))
+	}
+}
+
+// lift unbans the player on every server the ban covered, unless another ban still holds
+// there (a ban for every server alongside one for the server, say)
+func (s *BanSync) lift(ban database.Ban) {
+	for _, server := range s.servers(ban) {
+		bans, err := database.GetActiveBans(context.Background(), s.app, server.Id, s.now())
+		if err != nil {
+			s.logger().Warn("Failed to check remaining bans", "server", server.GetString("external_id"), "error", err)
+			continue
+		}
+		command := "unban " + ban.SteamID
+		for _, other := range bans {
+			if other.SteamID == ban.SteamID {
+				command = ""
+				break
+			}
+		}
+		s.sync(server, command)
+	}
+}
+
+// sync rewrites the server's Bans.txt and sends it the RCON command, when there is one
+func (s *BanSync) sync(server *core.Record, command string) {
+	serverID := server.GetString("external_id")
+	if err := s.writeBansFile(server); err != nil {
+		s.logger().Warn("Failed to write Bans.txt", "server_id", serverID, "error", err)
+	}
+	if command == "" || s.rconSender == nil {
+		return
+	}
+	if _, err := s.rconSender(serverID, command); err != nil {
+		// Bans.txt still has it, so an offline server picks the change up when it next starts
+		s.logger().Warn("Failed to send ban over RCON", "server_id", serverID, "rcon", command, "error", err)
+		return
+	}
+	s.logger().Info("Sent ban over RCON", "server_id", serverID, "rcon", command)
+}
+
+// servers returns the server records a ban applies to
+func (s *BanSync) servers(ban database.Ban) []*core.Record {
+	if ban.ServerID != "" {
+		server, err := s.app.FindRecordById("servers", ban.ServerID)
+		if err != nil {
+			return nil
+		}
+		return []*core.Record{server}
+	}
+	servers, err := s.app.FindAllRecords("servers")
+	if err != nil {
+		s.logger().Warn("Failed to load servers", "error", err)
+		return nil
+	}
+	return servers
+}
+
+// writeBansFile writes the bans in force on a server to <configDir>/<external_id>/Bans.txt
+func (s *BanSync) writeBansFile(server *core.Record) error {
+	if s.configDir == "" {
+		return nil
+	}
+	bans, err := database.GetActiveBans(context.Background(), s.app, server.Id, s.now())
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(s.configDir, server.GetString("external_id"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create server config directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "Bans.txt"), []byte(database.FormatBansFile(bans)), 0644)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestBanSync checks adding a ban writes the server's Bans.txt and bans the player over RCON,
+// and deleting it empties the file and unbans them
+func TestBanSync(t *testing.T) {
+	app, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer app.Cleanup()
+
+	serverRecordID, err := database.GetOrCreateServer(context.Background(), app, "test-server-bans", "Bans Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	var sent []string
+	sender := func(serverID string, command string) (string, error) {
+		sent = append(sent, serverID+":"+command)
+		return "", nil
+	}
+	sawPath := t.TempDir()
+	NewBanSync(app, sender, sawPath).RegisterHooks()
+	bansFile := filepath.Join(sawPath, "server-config", "test-server-bans", "Bans.txt")
+
+	collection, err := app.FindCollectionByNameOrId("bans")
+	if err != nil {
+		t.Fatalf("failed to find bans collection: %v", err)
+	}
+	ban := core.NewRecord(collection)
+	ban.Set("steam_id", "76561198995742987")
+	ban.Set("server", serverRecordID)
+	ban.Set("reason", "Cheating\non the server")
+	if err := app.Save(ban); err != nil {
+		t.Fatalf("failed to save ban: %v", err)
+	}
+
+	content, err := os.ReadFile(bansFile)
+	if err != nil {
+		t.Fatalf("failed to read Bans.txt: %v", err)
+	}
+	if string(content) != "76561198995742987\n" {
+		t.Errorf("Bans.txt = %q, want the banned player's ID", content)
+	}
+	want := []string{"test-server-bans:banid 76561198995742987 -1 Cheating on the server"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent %v, want %v", sent, want)
+	}
+
+	sent = nil
+	if err := app.Delete(ban); err != nil {
+		t.Fatalf("failed to delete ban: %v", err)
+	}
+	content, err = os.ReadFile(bansFile)
+	if err != nil {
+		t.Fatalf("failed to read Bans.txt: %v", err)
+	}
+	if len(content) != 0 {
+		t.Errorf("Bans.txt = %q after the ban was deleted, want it empty", content)
+	}
+	want = []string{"test-server-bans:unban 76561198995742987"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent %v, want %v", sent, want)
+	}
+}
+
+// TestBanRconCommand checks a temporary ban is sent for the minutes it has left
+func TestBanRconCommand(t *testing.T) {
+	now := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	expires := now.Add(90*time.Minute + 30*time.Second)
+	ban := database.Ban{SteamID: "76561198995742987", Expires: &expires}
+
+	if got, want := ban.RconCommand(now), "banid 76561198995742987 91"; got != want {
+		t.Errorf("RconCommand() = %q, want %q", got, want)
+	}
+	if ban.Active(expires) {
+		t.Error("ban should not be active once it expires")
+	}
+}
+
+// TestBanSync_WriteAllKeepsBansFile checks the bans listed by hand in an existing Bans.txt are
+// imported into the bans collection rather than wiped when the file is rewritten at startup
+func TestBanSync_WriteAllKeepsBansFile(t *testing.T) {
+	app, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer app.Cleanup()
+
+	if _, err := database.GetOrCreateServer(context.Background(), app, "test-server-bans", "Bans Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	sawPath := t.TempDir()
+	bansFile := filepath.Join(sawPath, "server-config", "test-server-bans", "Bans.txt")
+	if err := os.MkdirAll(filepath.Dir(bansFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bansFile, []byte("; banned by hand\n76561198995742987 aimbot\n76561198995742956\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	sender := func(serverID string, command string) (string, error) {
+		sent = append(sent, serverID+":"+command)
+		return "", nil
+	}
+	sync := NewBanSync(app, sender, sawPath)
+	sync.RegisterHooks()
+
+	// Twice, as on two starts: the second finds the bans in the database
+	for range 2 {
+		if err := sync.WriteAll(); err != nil {
+			t.Fatalf("WriteAll() error = %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(bansFile)
+	if err != nil {
+		t.Fatalf("failed to read Bans.txt: %v", err)
+	}
+	if string(content) != "76561198995742987\n76561198995742956\n" {
+		t.Errorf("Bans.txt = %q, want the players banned by hand", content)
+	}
+	if count, _ := app.CountRecords("bans"); count != 2 {
+		t.Errorf("Expected 2 imported bans, got %d", count)
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent %v, want nothing for bans already in force", sent)
+	}
+}
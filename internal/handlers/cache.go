@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// statsCollections are the collections the stats pages are rendered from. Any change to them
+// makes the cached copies of the pages stale.
+var statsCollections = []string{
+	"players",
+	"player_names",
+	"servers",
+	"matches",
+	"match_player_stats",
+	"match_weapon_stats",
+	"objective_events",
+	"maps",
+}
+
+// unshownFields are the fields of statsCollections that no stats page shows. Updates that only
+// touch them, like the watcher saving its log offset on the server record every batch, leave the
+// cached pages current.
+var unshownFields = map[string][]string{
+	"servers": {"offset", "log_file_creation_time"},
+}
+
+// changesPages reports whether an update touched a field the stats pages are rendered from
+func changesPages(record *core.Record) bool {
+	original := record.Original()
+	unshown := unshownFields[record.Collection().Name]
+	for _, field := range record.Collection().Fields.FieldNames() {
+		if field == core.FieldNameId || field == "updated" || slices.Contains(unshown, field) {
+			continue
+		}
+		if fmt.Sprint(record.Get(field)) != fmt.Sprint(original.Get(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dataVersion counts the changes made to a set of collections since startup, so a page rendered
+// from them can be given an ETag without querying the database. Changes made with raw SQL (the
+// retention job, recomputing ranked matches on startup) are not counted.
+type dataVersion struct {
+	started string // startup time, so ETags from before a restart never match
+
+	mu       sync.Mutex
+	version  uint64
+	modified time.Time
+}
+
+// trackDataVersion starts counting record changes in the collections
+func trackDataVersion(app core.App, collections ...string) *dataVersion {
+	now := time.Now()
+	v := &dataVersion{started: fmt.Sprint(now.UnixNano()), modified: now}
+
+	changed := func(e *core.ModelEvent) error {
+		v.mu.Lock()
+		v.version++
+		v.modified = time.Now()
+		v.mu.Unlock()
+		return e.Next()
+	}
+	app.OnModelAfterCreateSuccess(collections...).BindFunc(changed)
+	app.OnModelAfterUpdateSuccess(collections...).BindFunc(func(e *core.ModelEvent) error {
+		if record, ok := e.Model.(*core.Record); ok && !changesPages(record) {
+			return e.Next()
+		}
+		return changed(e)
+	})
+	app.OnModelAfterDeleteSuccess(collections...).BindFunc(changed)
+	return v
+}
+
+// notModified sets the ETag and Last-Modified of a page rendered from data, and reports whether
+// the client's copy, named by If-None-Match, is still current, in which case a 304 has been sent
+// and the page need not be rendered. The ETag covers the URL, whether the page is an HTMX partial
+// and whether the viewer is a superuser, since each of those changes what is rendered.
+func notModified(re *core.RequestEvent, data *dataVersion) (bool, error) {
+	data.mu.Lock()
+	version, modified := data.version, data.modified
+	data.mu.Unlock()
+
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%s\n%d\n%s\n%s\n%t", data.started, version, re.Request.URL.RequestURI(),
+		re.Request.Header.Get("HX-Request"), viewerOf(re).admin)
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+
+	header := re.Response.Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	// Caches must check back every time; the ETag makes that cheap
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Vary", "HX-Request, Cookie, Authorization")
+
+	for _, candidate := range strings.Split(re.Request.Header.Get("If-None-Match"), ",") {
+		// Weak comparison: proxies may strip the W/ prefix
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(etag, "W/") || candidate == "*" {
+			return true, re.NoContent(http.StatusNotModified)
+		}
+	}
+	return false, nil
+}
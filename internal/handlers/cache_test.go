@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestStatsPagesConditionalGet checks an unchanged stats page answers a conditional request with
+// 304, and that new data or a different representation gets a full response
+func TestStatsPagesConditionalGet(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	if _, err := database.CreatePlayer(ctx, testApp, "76561198995742987", "ArmoredBear"); err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+
+	router, err := apis.NewRouter(testApp)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	Register(&mockRconApp{TestApp: testApp}, &core.ServeEvent{App: testApp, Router: router})
+	mux, err := router.BuildMux()
+	if err != nil {
+		t.Fatalf("failed to build mux: %v", err)
+	}
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{"/players", "/weapons", "/match-history"} {
+		t.Run(path, func(t *testing.T) {
+			first := get(path, nil)
+			if first.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", first.Code)
+			}
+			etag := first.Header().Get("ETag")
+			if etag == "" || first.Header().Get("Last-Modified") == "" {
+				t.Fatalf("Expected ETag and Last-Modified, got %q and %q", etag, first.Header().Get("Last-Modified"))
+			}
+
+			unchanged := get(path, map[string]string{"If-None-Match": etag})
+			if unchanged.Code != http.StatusNotModified {
+				t.Fatalf("Expected 304 for an unchanged page, got %d", unchanged.Code)
+			}
+			if unchanged.Body.Len() != 0 {
+				t.Errorf("Expected no body with 304, got %d bytes", unchanged.Body.Len())
+			}
+
+			// An HTMX partial of the same URL is a different representation
+			if partial := get(path, map[string]string{"If-None-Match": etag, "HX-Request": "true"}); partial.Code != http.StatusOK {
+				t.Errorf("Expected 200 for the HTMX partial, got %d", partial.Code)
+			}
+		})
+	}
+
+	// A new player invalidates the cached pages
+	etag := get("/players", nil).Header().Get("ETag")
+	if _, err := database.CreatePlayer(ctx, testApp, "76561198995742956", "Rabbit"); err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	changed := get("/players", map[string]string{"If-None-Match": etag})
+	if changed.Code != http.StatusOK {
+		t.Fatalf("Expected 200 after a new player, got %d", changed.Code)
+	}
+	if changed.Header().Get("ETag") == etag {
+		t.Error("Expected a new ETag after a new player")
+	}
+
+	// The watcher saving its log offset on the server record every batch does not
+	serverID, err := database.GetOrCreateServer(ctx, testApp, "test-server-offset", "Offset Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server, err := testApp.FindRecordById("servers", serverID)
+	if err != nil {
+		t.Fatalf("failed to find server: %v", err)
+	}
+	etag = get("/players", nil).Header().Get("ETag")
+	server.Set("offset", 4096)
+	server.Set("log_file_creation_time", "2025-11-15T12:00:00Z")
+	if err := testApp.Save(server); err != nil {
+		t.Fatalf("failed to save offset: %v", err)
+	}
+	if unchanged := get("/players", map[string]string{"If-None-Match": etag}); unchanged.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 after the log offset was saved, got %d", unchanged.Code)
+	}
+
+	// Renaming the server does
+	server.Set("name", "Renamed Server")
+	if err := testApp.Save(server); err != nil {
+		t.Fatalf("failed to rename server: %v", err)
+	}
+	if changed := get("/players", map[string]string{"If-None-Match": etag}); changed.Code != http.StatusOK {
+		t.Errorf("Expected 200 after the server was renamed, got %d", changed.Code)
+	}
+
+	// So does editing a map, which the map pages are rendered from
+	etag = get("/players", nil).Header().Get("ETag")
+	town, err := testApp.FindFirstRecordByData("maps", "name", "Town")
+	if err != nil {
+		t.Fatalf("failed to find map: %v", err)
+	}
+	town.Set("display_name", "Old Town")
+	if err := testApp.Save(town); err != nil {
+		t.Fatalf("failed to save map: %v", err)
+	}
+	if changed := get("/players", map[string]string{"If-None-Match": etag}); changed.Code != http.StatusOK {
+		t.Errorf("Expected 200 after a map was edited, got %d", changed.Code)
+	}
+}
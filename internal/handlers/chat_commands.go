@@ -155,8 +155,8 @@ func sendRconSay(rconSender func(string, string) (string, error), logger *slog.L
 	command := fmt.Sprintf("say %s", message)
 	_, err := rconSender(serverID, command)
 	if err != nil {
-		logger.Debug("Failed to send RCON message", "serverID", serverID, "error", err)
+		logger.Debug("Failed to send RCON message", "server_id", serverID, "error", err)
 	} else {
-		logger.Debug("Sent RCON message", "serverID", serverID, "message", message)
+		logger.Debug("Sent RCON message", "server_id", serverID, "message", message)
 	}
 }
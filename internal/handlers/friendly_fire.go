@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// friendlyFirePageSize is the number of incidents per page on the page and the API
+const friendlyFirePageSize = 25
+
+// friendlyFireQuery is the server/player filter and page shared by the FF page and API
+type friendlyFireQuery struct {
+	ServerID string // Server record ID
+	PlayerID string // Player record ID
+	Page     int
+}
+
+// parseFriendlyFireQuery reads ?server=&player=&page=. server and player accept a record
+// ID or an external ID (log server ID / Steam ID); unknown ones are reported as not found.
+func parseFriendlyFireQuery(re *core.RequestEvent) (*friendlyFireQuery, error) {
+	query := &friendlyFireQuery{Page: 1}
+	values := re.Request.URL.Query()
+
+	if p := values.Get("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			query.Page = parsed
+		}
+	}
+
+	if id := values.Get("server"); id != "" {
+		server, err := findRecordByIdOrExternalID(re.App, "servers", id)
+		if err != nil {
+			return nil, re.NotFoundError("Server not found", err)
+		}
+		query.ServerID = server.Id
+	}
+
+	if id := values.Get("player"); id != "" {
+		player, err := findRecordByIdOrExternalID(re.App, "players", id)
+		if err != nil {
+			return nil, re.NotFoundError("Player not found", err)
+		}
+		query.PlayerID = player.Id
+	}
+
+	return query, nil
+}
+
+// list returns the incidents on the query's page and whether there is a next one
+func (q *friendlyFireQuery) list(re *core.RequestEvent) ([]database.FriendlyFireListItem, bool, error) {
+	incidents, err := database.ListFriendlyFireIncidents(re.Request.Context(), re.App, q.ServerID, q.PlayerID,
+		friendlyFirePageSize+1, // Get one extra to determine if there's a next page
+		(q.Page-1)*friendlyFirePageSize)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasNextPage := len(incidents) > friendlyFirePageSize
+	if hasNextPage {
+		incidents = incidents[:friendlyFirePageSize]
+	}
+	return incidents, hasNextPage, nil
+}
+
+func findRecordByIdOrExternalID(app core.App, collection, id string) (*core.Record, error) {
+	record, err := app.FindRecordById(collection, id)
+	if err != nil {
+		return app.FindFirstRecordByData(collection, "external_id", id)
+	}
+	return record, nil
+}
+
+// registerFriendlyFire registers the superuser-only friendly fire moderation page and API
+func registerFriendlyFire(e *core.ServeEvent, registry *template.Registry) {
+	// Friendly fire moderation page
+	e.Router.GET("/moderation/friendly-fire", func(re *core.RequestEvent) error {
+		query, err := parseFriendlyFireQuery(re)
+		if err != nil {
+			return err
+		}
+
+		incidents, hasNextPage, err := query.list(re)
+		if err != nil {
+			return re.InternalServerError("Failed to load friendly fire incidents", err)
+		}
+
+		servers, err := re.App.FindAllRecords("servers")
+		if err != nil {
+			servers = []*core.Record{}
+		}
+
+		playerName := ""
+		if query.PlayerID != "" {
+			if player, err := re.App.FindRecordById("players", query.PlayerID); err == nil {
+				playerName = player.GetString("name")
+			}
+		}
+
+		html, err := renderPage(re, registry, "templates/friendly_fire.html", map[string]any{
+			"ActivePage":     "friendly-fire",
+			"Incidents":      incidents,
+			"Servers":        servers,
+			"SelectedServer": query.ServerID,
+			"SelectedPlayer": query.PlayerID,
+			"PlayerName":     playerName,
+			"Page":           query.Page,
+			"PrevPage":       query.Page - 1,
+			"NextPage":       query.Page + 1,
+			"HasNextPage":    hasNextPage,
+		})
+
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	}).Bind(adminauth.RequireAdmin())
+
+	// GET /api/friendly-fire?server=&player=&page= - Friendly fire incidents, newest first
+	e.Router.GET("/api/friendly-fire", func(re *core.RequestEvent) error {
+		query, err := parseFriendlyFireQuery(re)
+		if err != nil {
+			return err
+		}
+
+		incidents, hasNextPage, err := query.list(re)
+		if err != nil {
+			return re.InternalServerError("Failed to load friendly fire incidents", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"page":        query.Page,
+			"perPage":     friendlyFirePageSize,
+			"hasNextPage": hasNextPage,
+			"items":       incidents,
+		})
+	}).Bind(adminauth.RequireAdmin())
+}
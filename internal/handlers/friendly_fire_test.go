@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/parser"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestFriendlyFireEndpoint feeds a teamkill through the parser and handlers and checks
+// the recorded incident is listed by the friendly fire API and page
+func TestFriendlyFireEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-ff"
+
+	if _, err := database.GetOrCreateServer(ctx, baseApp, serverExternalID, "FF Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if _, err := database.GetOrCreateServer(ctx, baseApp, "other-server", "Other Server", "other/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if _, err := database.CreateMatch(ctx, baseApp, serverExternalID, nil, nil, nil); err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	NewGameEventHandlers(&mockRconApp{TestApp: baseApp}, nil).RegisterHooks()
+	logParser := parser.NewLogParser(baseApp, baseApp.Logger())
+
+	lines := []string{
+		// An enemy kill is not an incident
+		`[2025.10.04-14.31.00:000][100]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		// ArmoredBear team kills Rabbit
+		`[2025.10.04-14.31.32:000][150]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 0] with BP_Firearm_M16A4_C_2147481419`,
+	}
+	for _, line := range lines {
+		if err := logParser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+			t.Fatalf("failed to process log line: %v", err)
+		}
+	}
+
+	auth := map[string]string{"Authorization": createSuperuserToken(t, baseApp)}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "teamkill is listed",
+			Method:         http.MethodGet,
+			URL:            "/api/friendly-fire",
+			Headers:        auth,
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"killer_name":"ArmoredBear"`,
+				`"killer_steam_id":"76561198995742987"`,
+				`"victim_name":"Rabbit"`,
+				`"server_name":"FF Server"`,
+				`"hasNextPage":false`,
+			},
+			NotExpectedContent: []string{`"victim_name":"Marksman"`},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "filtered by victim steam id and server",
+			Method:             http.MethodGet,
+			URL:                "/api/friendly-fire?player=76561198995742956&server=" + serverExternalID,
+			Headers:            auth,
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`"victim_name":"Rabbit"`},
+			NotExpectedContent: []string{`"items":[]`},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:            "other server has no incidents",
+			Method:          http.MethodGet,
+			URL:             "/api/friendly-fire?server=other-server",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"items":[]`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "unknown player",
+			Method:          http.MethodGet,
+			URL:             "/api/friendly-fire?player=missing",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "moderation page lists the incident",
+			Method:          http.MethodGet,
+			URL:             "/moderation/friendly-fire?player=76561198995742987",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"Friendly Fire - ArmoredBear", "<strong>ArmoredBear</strong>", "Rabbit"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
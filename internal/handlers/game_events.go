@@ -10,10 +10,12 @@ import (
 	"slices"
 	"time"
 
+	"sandstorm-tracker/internal/config"
 	"sandstorm-tracker/internal/database"
 	"sandstorm-tracker/internal/events"
 
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 // GameEventHandlers handles game event processing via PocketBase hooks
@@ -37,21 +39,79 @@ func NewGameEventHandlers(app AppInterface, scoreDebouncer ScoreDebouncer) *Game
 	}
 }
 
+// mvpConfigGetter is implemented by apps that configure how match MVPs are picked
+type mvpConfigGetter interface {
+	GetMVPConfig() config.MVPConfig
+}
+
+// mvpMetric returns the configured MVP metric, or the default when the app does not configure one
+func (h *GameEventHandlers) mvpMetric() string {
+	cfg := config.MVPConfig{}
+	if getter, ok := h.app.(mvpConfigGetter); ok {
+		cfg = getter.GetMVPConfig()
+	}
+	return cfg.MetricOrDefault()
+}
+
+// rankedConfigGetter is implemented by apps that configure which matches count towards lifetime stats
+type rankedConfigGetter interface {
+	GetRankedConfig() config.RankedConfig
+}
+
+// minRankedPlayers returns the players a match needs to be ranked, or 0 when every match counts
+func minRankedPlayers(app any) int {
+	if getter, ok := app.(rankedConfigGetter); ok {
+		return getter.GetRankedConfig().MinPlayers
+	}
+	return 0
+}
+
 // RegisterHooks registers all event handlers with PocketBase hooks
 func (h *GameEventHandlers) RegisterHooks() {
 	// Register handler for all event types
 	h.app.OnRecordCreate("events").BindFunc(h.handleEvent)
+	h.app.OnRecordAfterCreateSuccess("events").BindFunc(h.recordMatchLastEvent)
+}
+
+// recordMatchLastEvent keeps a match's last event time at the log time of the latest event
+// linked to it, so a match that crashes can be timed up to when it last saw play
+func (h *GameEventHandlers) recordMatchLastEvent(e *core.RecordEvent) error {
+	matchID := e.Record.GetString("match")
+	if matchID == "" {
+		return e.Next()
+	}
+
+	// Only events carrying the log time count; their created date is the tracker's clock
+	var data struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil || data.Timestamp.IsZero() {
+		return e.Next()
+	}
+
+	if err := database.TouchMatchLastEvent(context.Background(), e.App, matchID, data.Timestamp); err != nil {
+		h.eventLogger(e).Debug("Failed to record match last event time", "match_id", matchID, "error", err)
+	}
+	return e.Next()
 }
 
 // handleEvent routes events to specific handlers based on type
 func (h *GameEventHandlers) handleEvent(e *core.RecordEvent) error {
 	eventType := e.Record.GetString("type")
 
+	// The handlers run before the save, when PocketBase hasn't stamped created yet. Stamping it
+	// here gives them the time that is stored (a created date set before the save is kept).
+	if e.Record.GetDateTime("created").IsZero() {
+		e.Record.SetRaw("created", types.NowDateTime())
+	}
+
 	switch eventType {
 	case events.TypePlayerLogin:
 		return h.handlePlayerLogin(e)
 	case events.TypePlayerKill:
 		return h.handlePlayerKill(e)
+	case events.TypeKillAssist:
+		return h.handleKillAssist(e)
 	case events.TypePlayerJoin:
 		return h.handlePlayerJoin(e)
 	case events.TypePlayerLeave:
@@ -70,20 +130,30 @@ func (h *GameEventHandlers) handleEvent(e *core.RecordEvent) error {
 		return h.handleGameOver(e)
 	case events.TypeLogFileCreated:
 		return h.handleLogFileCreated(e)
+	case events.TypeServerCrash:
+		return h.handleServerCrash(e)
 	case events.TypeObjectiveCaptured:
 		return h.handleObjectiveCaptured(e)
 	case events.TypeObjectiveDestroyed:
 		return h.handleObjectiveDestroyed(e)
 	case events.TypeChatCommand:
 		return h.handleChatCommand(e)
+	case events.TypeChatMessage:
+		return h.handleChatMessage(e)
+	case events.TypeMapVote:
+		return h.handleMapVote(e)
+	case events.TypeWeaponFire:
+		return h.handleWeaponFire(e)
 	}
 
 	// Not a game event we handle, continue
 	return e.Next()
 }
 
-func getLogger(e *core.RecordEvent) *slog.Logger {
-	return e.App.Logger().With("component", "GAME_EVENTS")
+// eventLogger returns the logger for handling an event, tagged with its type. It logs through the
+// app rather than e.App so event logs reach the console as well as PocketBase's logs.
+func (h *GameEventHandlers) eventLogger(e *core.RecordEvent) *slog.Logger {
+	return h.app.Logger().With("component", "GAME_EVENTS", "event_type", e.Record.GetString("type"))
 }
 
 // getServerExternalID converts a server record ID to external_id
@@ -98,10 +168,37 @@ func (h *GameEventHandlers) getServerExternalID(ctx context.Context, serverRecor
 	return serverRecord.GetString("external_id"), nil
 }
 
+// eventTime returns when an event was recorded, from its created date (stamped by handleEvent
+// before the save). Reports false for an event without one, which callers skip.
+func eventTime(record *core.Record) (time.Time, bool) {
+	created := record.GetDateTime("created")
+	return created.Time(), !created.IsZero()
+}
+
+// linkActiveMatch links the event to the server's active match, the match it counted towards, so
+// the match's stats can be rebuilt from its events. Returns nil when no match is in progress.
+func linkActiveMatch(ctx context.Context, e *core.RecordEvent, serverID string) (*database.Match, error) {
+	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
+	if err != nil || activeMatch == nil {
+		return activeMatch, err
+	}
+	e.Record.Set("match", activeMatch.ID)
+	return activeMatch, nil
+}
+
+// knownTeam returns the team for stat upserts, or nil when the log reported no team
+func knownTeam(team int) *int64 {
+	if team < 0 {
+		return nil
+	}
+	t := int64(team)
+	return &t
+}
+
 // handlePlayerLogin processes player login events
 // Creates or updates player record when they connect to server
 func (h *GameEventHandlers) handlePlayerLogin(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 
 	// Extract typed data from event
@@ -122,11 +219,35 @@ func (h *GameEventHandlers) handlePlayerLogin(e *core.RecordEvent) error {
 
 	log.Debug("Processing player login", "player", data.PlayerName, "steamID", data.SteamID, "platform", data.Platform)
 
+	// A join logged before its login creates a name-only player; give it this platform ID (or
+	// fold it into the player for it) so the player's match stats stay on one record
+	var player *database.Player
+	if activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID); err == nil && activeMatch != nil {
+		player, err = database.LinkNameOnlyPlayer(ctx, e.App, activeMatch.ID, data.Platform, data.SteamID, data.PlayerName)
+		if err != nil {
+			log.Debug("Failed to link name-only player", "player", data.PlayerName, "steamID", data.SteamID, "error", err)
+		} else if player != nil {
+			log.Debug("Linked name-only player to Steam ID", "player", data.PlayerName, "steamID", data.SteamID)
+		}
+	}
+
 	// Create or update player record
-	_, err = database.GetOrCreatePlayerBySteamID(ctx, e.App, data.SteamID, data.PlayerName)
-	if err != nil {
-		log.Debug("Failed to create/update player", "steamID", data.SteamID, "error", err)
-		return e.Next()
+	if player == nil {
+		player, err = database.GetOrCreatePlayerByPlatformID(ctx, e.App, data.Platform, data.SteamID, data.PlayerName)
+		if err != nil {
+			log.Debug("Failed to create/update player", "steamID", data.SteamID, "platform", data.Platform, "error", err)
+			return e.Next()
+		}
+	}
+
+	// Follow renames, keeping the old names in the history. Replayed logins are skipped so an
+	// old log can't roll the name back.
+	if !data.IsCatchup {
+		if at, ok := eventTime(e.Record); !ok {
+			log.Warn("Skipping player rename for an event without a created date", "steamID", data.SteamID)
+		} else if err := database.RenamePlayer(ctx, e.App, player, data.PlayerName, at); err != nil {
+			log.Debug("Failed to update player name", "steamID", data.SteamID, "error", err)
+		}
 	}
 
 	// Try to get stored IP from connection event and update player metadata
@@ -142,44 +263,26 @@ func (h *GameEventHandlers) handlePlayerLogin(e *core.RecordEvent) error {
 				map[string]any{"externalID": data.SteamID},
 			)
 			if err == nil && playerRecord != nil {
-				// Get existing known IPs list or create new one
-				existingMetadataStr := playerRecord.GetString("metadata")
-				var knownIPs []string
-
-				if existingMetadataStr != "" {
-					var metadata map[string]interface{}
-					if err := json.Unmarshal([]byte(existingMetadataStr), &metadata); err == nil {
-						if ipsInterface, exists := metadata["knownIPs"]; exists {
-							if ips, ok := ipsInterface.([]interface{}); ok {
-								for _, ip := range ips {
-									if ipStr, ok := ip.(string); ok {
-										knownIPs = append(knownIPs, ipStr)
-									}
-								}
+				// Add the IP to the known IPs list, keeping the rest of the metadata
+				added := false
+				err := database.UpdatePlayerMetadata(e.App, playerRecord, func(metadata map[string]any) {
+					var knownIPs []string
+					if ips, ok := metadata["knownIPs"].([]any); ok {
+						for _, ip := range ips {
+							if ip, ok := ip.(string); ok {
+								knownIPs = append(knownIPs, ip)
 							}
 						}
 					}
-				}
-
-				// Add IP if not already in list
-				if !slices.Contains(knownIPs, ipStr) {
-					knownIPs = append(knownIPs, ipStr)
-
-					// Build new metadata with updated knownIPs
-					newMetadata := map[string]interface{}{
-						"knownIPs": knownIPs,
-					}
-					metadataJSON, err := json.Marshal(newMetadata)
-					if err != nil {
-						log.Debug("Failed to marshal metadata", "error", err)
-					} else {
-						playerRecord.Set("metadata", string(metadataJSON))
-						if err := e.App.Save(playerRecord); err != nil {
-							log.Debug("Failed to update player metadata", "error", err)
-						} else {
-							log.Debug("Added IP to player", "ip", ipStr, "player", data.PlayerName, "knownIPs", len(knownIPs))
-						}
+					if !slices.Contains(knownIPs, ipStr) {
+						metadata["knownIPs"] = append(knownIPs, ipStr)
+						added = true
 					}
+				})
+				if err != nil {
+					log.Debug("Failed to update player metadata", "error", err)
+				} else if added {
+					log.Debug("Added IP to player", "ip", ipStr, "player", data.PlayerName)
 				}
 			}
 
@@ -195,7 +298,7 @@ func (h *GameEventHandlers) handlePlayerLogin(e *core.RecordEvent) error {
 // handlePlayerKill processes player kill events
 // Handles regular kills, assists, friendly fire, and suicides
 func (h *GameEventHandlers) handlePlayerKill(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 
 	// Use Killevent proxy for all data access
@@ -209,49 +312,70 @@ func (h *GameEventHandlers) handlePlayerKill(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	killers := killevent.Killers()
-	victimSteamID := killevent.VictimSteamID()
-	victimName := killevent.VictimName()
-	victimTeam := killevent.VictimTeam()
-	weapon := killevent.Weapon()
-
-	log.Debug("Processing kill event", "killerCount", len(killers), "victim", victimName, "weapon", weapon, "serverID", serverID)
+	log.Debug("Processing kill event", "killerCount", len(killevent.Killers()), "victim", killevent.VictimName(), "weapon", killevent.Weapon(), "server_id", serverID)
 
 	// Get active match for this server
-	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
+	activeMatch, err := linkActiveMatch(ctx, e, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found", "serverID", serverID)
+		log.Debug("No active match found", "server_id", serverID)
 		return e.Next()
 	}
 
+	// PocketBase hooks run within transactions automatically, so we use e.App directly
+	if err := applyKillStats(ctx, e.App, log, activeMatch.ID, killevent); err != nil {
+		log.Debug("Failed to apply kill stats", "error", err)
+		return e.Next()
+	}
+
+	// Trigger score update (debounced) - skip during catchup (outside transaction)
+	if h.scoreDebouncer != nil {
+		if !killevent.IsCatchup() {
+			h.scoreDebouncer.TriggerScoreUpdate(serverID)
+		}
+	}
+
+	return e.Next()
+}
+
+// applyKillStats credits a kill event to the match: kills, assists, deaths, headshots,
+// streaks, weapon stats and friendly fire incidents.
+// Shared by the event hook and the stats rebuild (see RecomputeMatchStats).
+func applyKillStats(ctx context.Context, app core.App, log *slog.Logger, matchID string, killevent *Killevent) error {
+	killers := killevent.Killers()
+	victimSteamID := killevent.VictimSteamID()
+	victimName := killevent.VictimName()
+	victimTeam := killevent.VictimTeam()
+	weapon := killevent.Weapon()
+
 	// Detect suicide (killer == victim)
 	isSuicide := false
 	if len(killers) > 0 && killers[0].SteamID == victimSteamID && victimSteamID != "" && victimSteamID != "INVALID" {
 		isSuicide = true
 	}
 
-	// PocketBase hooks run within transactions automatically, so we use e.App directly
 	// For suicides: only increment victim deaths
 	if isSuicide && killevent.VictimIsPlayer() {
-		victimPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, e.App, victimSteamID, victimName)
+		victimPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, app, victimSteamID, victimName)
 		if err != nil {
-			log.Debug("Failed to get/create suicide victim player", "victim", victimName, "error", err)
-			return e.Next()
+			return fmt.Errorf("failed to get/create suicide victim player: %w", err)
 		}
 
 		// Upsert player into match
-		if err := database.UpsertMatchPlayerStats(ctx, e.App, activeMatch.ID, victimPlayer.ID, nil, nil); err != nil {
-			log.Debug("Failed to upsert suicide victim into match", "error", err)
-			return e.Next()
+		if err := database.UpsertMatchPlayerStats(ctx, app, matchID, victimPlayer.ID, nil, nil); err != nil {
+			return fmt.Errorf("failed to upsert suicide victim into match: %w", err)
 		}
 
 		// Increment deaths (only stat for suicide)
-		if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, victimPlayer.ID, "deaths"); err != nil {
-			log.Debug("Failed to increment deaths for suicide", "error", err)
-			return e.Next()
+		if err := database.IncrementMatchPlayerStat(ctx, app, matchID, victimPlayer.ID, "deaths"); err != nil {
+			return fmt.Errorf("failed to increment deaths for suicide: %w", err)
 		}
 
-		return e.Next()
+		// A suicide ends the player's kill streak
+		if err := database.ResetKillStreak(ctx, app, matchID, victimPlayer.ID); err != nil {
+			log.Debug("Failed to reset kill streak for suicide", "error", err)
+		}
+
+		return nil
 	}
 
 	// For non-suicides: process killer(s) and victim
@@ -260,16 +384,14 @@ func (h *GameEventHandlers) handlePlayerKill(e *core.RecordEvent) error {
 			continue
 		}
 
-		killerPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, e.App, killer.SteamID, killer.Name)
+		killerPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, app, killer.SteamID, killer.Name)
 		if err != nil {
-			log.Debug("Failed to get/create killer player", "player", killer.Name, "error", err)
-			return e.Next()
+			return fmt.Errorf("failed to get/create killer player: %w", err)
 		}
 
 		// Upsert player into match
-		if err := database.UpsertMatchPlayerStats(ctx, e.App, activeMatch.ID, killerPlayer.ID, nil, nil); err != nil {
-			log.Debug("Failed to upsert killer into match", "error", err)
-			return e.Next()
+		if err := database.UpsertMatchPlayerStats(ctx, app, matchID, killerPlayer.ID, knownTeam(killer.Team), nil); err != nil {
+			return fmt.Errorf("failed to upsert killer into match: %w", err)
 		}
 
 		// Check if this is a friendly fire kill
@@ -278,97 +400,169 @@ func (h *GameEventHandlers) handlePlayerKill(e *core.RecordEvent) error {
 		if isTeamKill {
 			// Friendly fire: record incident and increment friendly_fire_kills
 			if killevent.VictimIsPlayer() {
-				if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, killerPlayer.ID, "friendly_fire_kills"); err != nil {
-					log.Debug("Failed to increment friendly_fire_kills", "error", err)
-					return e.Next()
+				if err := database.IncrementMatchPlayerStat(ctx, app, matchID, killerPlayer.ID, "friendly_fire_kills"); err != nil {
+					return fmt.Errorf("failed to increment friendly_fire_kills: %w", err)
 				}
 
 				// Record friendly fire incident
 				killerTeam := killer.Team
 				ff := &database.FriendlyFireIncident{
-					MatchID:    activeMatch.ID,
+					MatchID:    matchID,
 					KillerID:   killerPlayer.ID,
 					VictimID:   "", // Will be fetched if victim is player
 					Weapon:     weapon,
-					Timestamp:  e.Record.GetDateTime("created").Time(),
+					Timestamp:  killevent.Timestamp(),
 					KillerTeam: &killerTeam,
 					VictimTeam: &victimTeam,
 				}
 
 				// Get victim player for FF record
-				if victimPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, e.App, victimSteamID, victimName); err == nil {
+				if victimPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, app, victimSteamID, victimName); err == nil {
 					ff.VictimID = victimPlayer.ID
-					if err := database.RecordFriendlyFireIncident(ctx, e.App, ff); err != nil {
+					if err := database.RecordFriendlyFireIncident(ctx, app, ff); err != nil {
 						log.Debug("Failed to record friendly fire incident", "error", err)
 					}
 				}
 			}
 		} else if i == 0 {
 			// Regular kill: first killer gets the kill credit
-			if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, killerPlayer.ID, "kills"); err != nil {
-				log.Debug("Failed to increment kills for killer", "error", err)
-				return e.Next()
+			if err := database.IncrementMatchPlayerStat(ctx, app, matchID, killerPlayer.ID, "kills"); err != nil {
+				return fmt.Errorf("failed to increment kills for killer: %w", err)
+			}
+
+			// Only credited kills extend streaks; team kills and assists never do
+			if err := database.RecordKillStreak(ctx, app, matchID, killerPlayer.ID, killevent.Timestamp()); err != nil {
+				log.Debug("Failed to record kill streak", "error", err)
 			}
 
 			// Update weapon stats (only for primary killer)
 			killCount := int64(1)
 			assistCount := int64(0)
-			if err := database.UpsertMatchWeaponStats(ctx, e.App, activeMatch.ID, killerPlayer.ID, weapon, &killCount, &assistCount); err != nil {
-				log.Debug("Failed to update weapon stats", "error", err)
-				return e.Next()
+			if err := database.UpsertMatchWeaponStats(ctx, app, matchID, killerPlayer.ID, weapon, &killCount, &assistCount); err != nil {
+				return fmt.Errorf("failed to update weapon stats: %w", err)
+			}
+
+			// Headshots are credited to the primary killer only, like the kill itself
+			if killevent.IsHeadshot() {
+				if err := database.IncrementMatchPlayerStat(ctx, app, matchID, killerPlayer.ID, "headshots"); err != nil {
+					log.Debug("Failed to increment headshots", "error", err)
+				}
+				if err := database.IncrementMatchWeaponStat(ctx, app, matchID, killerPlayer.ID, weapon, "headshots"); err != nil {
+					log.Debug("Failed to increment weapon headshots", "error", err)
+				}
 			}
 		} else {
 			// Regular assist: non-first killers get assist credit
-			if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, killerPlayer.ID, "assists"); err != nil {
-				log.Debug("Failed to increment assists", "error", err)
-				return e.Next()
-			}
-
-			// Update weapon stats with assist
-			killCount := int64(0)
-			assistCount := int64(1)
-			if err := database.UpsertMatchWeaponStats(ctx, e.App, activeMatch.ID, killerPlayer.ID, weapon, &killCount, &assistCount); err != nil {
-				log.Debug("Failed to update weapon stats for assist", "error", err)
-				return e.Next()
+			if err := creditAssist(ctx, app, matchID, killerPlayer.ID, weapon); err != nil {
+				return err
 			}
 		}
 	}
 
 	// Update victim stats (if not empty - could be bot death)
 	if killevent.VictimIsPlayer() && !isSuicide {
-		victimPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, e.App, victimSteamID, victimName)
+		victimPlayer, err := database.GetOrCreatePlayerBySteamID(ctx, app, victimSteamID, victimName)
 		if err != nil {
-			log.Debug("Failed to get/create victim player", "player", victimName, "error", err)
-			return e.Next()
+			return fmt.Errorf("failed to get/create victim player: %w", err)
 		}
 
 		// Upsert player into match
-		if err := database.UpsertMatchPlayerStats(ctx, e.App, activeMatch.ID, victimPlayer.ID, nil, nil); err != nil {
-			log.Debug("Failed to upsert victim into match", "error", err)
-			return e.Next()
+		if err := database.UpsertMatchPlayerStats(ctx, app, matchID, victimPlayer.ID, knownTeam(victimTeam), nil); err != nil {
+			return fmt.Errorf("failed to upsert victim into match: %w", err)
 		}
 
 		// Increment deaths (always incremented except for suicides)
-		if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, victimPlayer.ID, "deaths"); err != nil {
-			log.Debug("Failed to increment deaths for victim", "error", err)
-			return e.Next()
+		if err := database.IncrementMatchPlayerStat(ctx, app, matchID, victimPlayer.ID, "deaths"); err != nil {
+			return fmt.Errorf("failed to increment deaths for victim: %w", err)
 		}
-	}
 
-	// Trigger score update (debounced) - skip during catchup (outside transaction)
-	if h.scoreDebouncer != nil {
-		if !killevent.IsCatchup() {
-			h.scoreDebouncer.TriggerScoreUpdate(serverID)
+		// Any death, including to a teammate, ends the victim's kill streak
+		if err := database.ResetKillStreak(ctx, app, matchID, victimPlayer.ID); err != nil {
+			log.Debug("Failed to reset kill streak for victim", "error", err)
 		}
 	}
 
+	return nil
+}
+
+// creditAssist gives a player an assist in the match and on the weapon of the kill
+func creditAssist(ctx context.Context, app core.App, matchID, playerID, weapon string) error {
+	if err := database.IncrementMatchPlayerStat(ctx, app, matchID, playerID, "assists"); err != nil {
+		return fmt.Errorf("failed to increment assists: %w", err)
+	}
+
+	// Update weapon stats with assist
+	killCount := int64(0)
+	assistCount := int64(1)
+	if err := database.UpsertMatchWeaponStats(ctx, app, matchID, playerID, weapon, &killCount, &assistCount); err != nil {
+		return fmt.Errorf("failed to update weapon stats for assist: %w", err)
+	}
+	return nil
+}
+
+// handleKillAssist processes assists logged on their own line after the kill they belong to
+func (h *GameEventHandlers) handleKillAssist(e *core.RecordEvent) error {
+	log := h.eventLogger(e)
+	ctx := context.Background()
+	serverID, err := h.getServerExternalID(ctx, e.Record.GetString("server"))
+	if err != nil {
+		log.Debug("Failed to get server external_id", "error", err)
+		return e.Next()
+	}
+
+	var data events.KillAssistData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		log.Debug("Failed to parse kill assist event data", "error", err)
+		return e.Next()
+	}
+
+	activeMatch, err := linkActiveMatch(ctx, e, serverID)
+	if err != nil || activeMatch == nil {
+		log.Debug("No active match found for kill assist", "server_id", serverID)
+		return e.Next()
+	}
+
+	if err := applyAssistStats(ctx, e.App, activeMatch.ID, data); err != nil {
+		log.Debug("Failed to apply kill assist stats", "error", err)
+		return e.Next()
+	}
+
+	if h.scoreDebouncer != nil && !data.IsCatchup {
+		h.scoreDebouncer.TriggerScoreUpdate(serverID)
+	}
+
 	return e.Next()
 }
 
+// applyAssistStats credits a kill assist event's assisters like the non-first killers of a kill.
+// Assisting on a teammate's death earns nothing.
+func applyAssistStats(ctx context.Context, app core.App, matchID string, data events.KillAssistData) error {
+	for _, assister := range data.Assisters {
+		if assister.SteamID == "" || assister.SteamID == "INVALID" {
+			continue
+		}
+		if assister.Team == data.Victim.Team && assister.Team >= 0 {
+			continue
+		}
+
+		player, err := database.GetOrCreatePlayerBySteamID(ctx, app, assister.SteamID, assister.PlayerName)
+		if err != nil {
+			return fmt.Errorf("failed to get/create assisting player: %w", err)
+		}
+		if err := database.UpsertMatchPlayerStats(ctx, app, matchID, player.ID, knownTeam(assister.Team), nil); err != nil {
+			return fmt.Errorf("failed to upsert assisting player into match: %w", err)
+		}
+		if err := creditAssist(ctx, app, matchID, player.ID, data.Weapon); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // handlePlayerJoin processes player join events
 // Creates match_player_stats record so player appears in match
 func (h *GameEventHandlers) handlePlayerJoin(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -397,28 +591,48 @@ func (h *GameEventHandlers) handlePlayerJoin(e *core.RecordEvent) error {
 
 	playerID := player.ID
 
-	log.Debug("Processing player join", "player", playerID, "server", serverID) // Get active match
+	if !data.IsCatchup {
+		if at, ok := eventTime(e.Record); !ok {
+			log.Warn("Skipping player name history for an event without a created date", "player", playerID)
+		} else if err := database.RecordPlayerName(ctx, e.App, playerID, data.PlayerName, at); err != nil {
+			log.Debug("Failed to record player name", "error", err)
+		}
+	}
+
+	log.Debug("Processing player join", "player", playerID, "server_id", serverID) // Get active match
 	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for player join", "serverID", serverID)
+		log.Debug("No active match found for player join", "server_id", serverID)
 		return e.Next()
 	}
 
 	// Add player to match (upsert creates row if needed)
-	timestamp := e.Record.GetDateTime("created").Time()
+	timestamp, ok := eventTime(e.Record)
+	if !ok {
+		log.Warn("Skipping player join without a created date", "player", playerID)
+		return e.Next()
+	}
 	err = database.UpsertMatchPlayerStats(ctx, e.App, activeMatch.ID, playerID, nil, &timestamp)
 	if err != nil {
 		log.Debug("Failed to add player to match", "error", err)
 		return e.Next()
 	}
 
+	// A player rejoining after leaving keeps their stats row; a team swap continues the same session
+	if err := database.ReconnectPlayerToMatch(ctx, e.App, activeMatch.ID, playerID, data.TeamSwap); err != nil {
+		log.Debug("Failed to reconnect player to match", "error", err)
+	}
+	if err := database.UpdateMatchPeakPlayers(ctx, e.App, activeMatch.ID, minRankedPlayers(h.app)); err != nil {
+		log.Debug("Failed to update match peak players", "error", err)
+	}
+
 	log.Debug("Player added to match", "player", playerID, "match", activeMatch.ID)
 	return e.Next()
 }
 
 // handlePlayerLeave processes player leave events
 func (h *GameEventHandlers) handlePlayerLeave(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -447,17 +661,20 @@ func (h *GameEventHandlers) handlePlayerLeave(e *core.RecordEvent) error {
 	}
 
 	playerID := player.ID
-	log.Debug("Processing player leave", "player", playerID, "server", serverID)
+	log.Debug("Processing player leave", "player", playerID, "server_id", serverID)
 
 	// Get active match
 	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for player leave", "serverID", serverID)
+		log.Debug("No active match found for player leave", "server_id", serverID)
 		return e.Next()
 	}
 
-	// Get timestamp from event
-	timestamp := e.Record.GetDateTime("created").Time()
+	timestamp, ok := eventTime(e.Record)
+	if !ok {
+		log.Warn("Skipping player leave without a created date", "player", playerID)
+		return e.Next()
+	}
 
 	// Mark player as disconnected from the match
 	err = database.DisconnectPlayerFromMatch(ctx, e.App, activeMatch.ID, playerID, &timestamp)
@@ -472,7 +689,7 @@ func (h *GameEventHandlers) handlePlayerLeave(e *core.RecordEvent) error {
 
 // handleRoundEnd processes round end events
 func (h *GameEventHandlers) handleRoundEnd(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -488,12 +705,12 @@ func (h *GameEventHandlers) handleRoundEnd(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Processing round end", "winningTeam", data.WinningTeam, "server", serverID)
+	log.Debug("Processing round end", "winningTeam", data.WinningTeam, "server_id", serverID)
 
 	// Get active match to increment round counter
 	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for round end event", "serverID", serverID)
+		log.Debug("No active match found for round end event", "server_id", serverID)
 		return e.Next()
 	}
 
@@ -502,6 +719,10 @@ func (h *GameEventHandlers) handleRoundEnd(e *core.RecordEvent) error {
 		log.Debug("Failed to increment round for match", "match", activeMatch.ID, "error", err)
 	}
 
+	// Tally the round win; the match winner is decided from these at game over
+	if err := database.RecordRoundWin(ctx, e.App, activeMatch.ID, data.WinningTeam); err != nil {
+		log.Debug("Failed to record round win for match", "match", activeMatch.ID, "winningTeam", data.WinningTeam, "error", err)
+	}
 
 	// Trigger immediate score update after round end - skip during catchup
 	if h.scoreDebouncer != nil {
@@ -517,7 +738,7 @@ func (h *GameEventHandlers) handleRoundEnd(e *core.RecordEvent) error {
 // Match creation is handled in parser (tryProcessMapLoad)
 // This handler exists for potential future logic (e.g., notifications)
 func (h *GameEventHandlers) handleMatchStart(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -533,14 +754,14 @@ func (h *GameEventHandlers) handleMatchStart(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Match started on server", "serverID", serverID, "map", data.Map, "scenario", data.Scenario)
+	log.Debug("Match started on server", "server_id", serverID, "map", data.Map, "scenario", data.Scenario)
 	return e.Next()
 }
 
 // handleMatchEnd processes match end events
 // Sets the final winner_team based on the last round end event for this match
 func (h *GameEventHandlers) handleMatchEnd(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -556,15 +777,26 @@ func (h *GameEventHandlers) handleMatchEnd(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Match end event processed", "serverID", serverID)
+	log.Debug("Match end event processed", "server_id", serverID)
 
 	// Get the active match
 	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for match end event", "serverID", serverID)
+		log.Debug("No active match found for match end event", "server_id", serverID)
 		return e.Next()
 	}
 
+	// Match ended without a game over (which records the outcome and MVP itself)
+	if _, err := database.RecordMatchOutcome(ctx, e.App, activeMatch.ID); err != nil {
+		log.Debug("Failed to record match outcome", "match_id", activeMatch.ID, "error", err)
+	}
+	if _, err := database.RecordMatchMVP(ctx, e.App, activeMatch.ID, h.mvpMetric()); err != nil {
+		log.Debug("Failed to record match MVP", "match_id", activeMatch.ID, "error", err)
+	}
+	if err := database.UpdateMatchPeakPlayers(ctx, e.App, activeMatch.ID, minRankedPlayers(h.app)); err != nil {
+		log.Debug("Failed to update match peak players", "match_id", activeMatch.ID, "error", err)
+	}
+
 	// Find the last round end event for this match to determine the final winner
 	roundEndEvents, err := e.App.FindRecordsByFilter(
 		"events",
@@ -601,19 +833,20 @@ func (h *GameEventHandlers) handleMatchEnd(e *core.RecordEvent) error {
 				if playerTeam >= 0 && playerTeam == roundEndData.WinningTeam {
 					matchRecord.Set("winner_team", roundEndData.WinningTeam)
 					if err := e.App.Save(matchRecord); err != nil {
-						log.Debug("Failed to set final winner_team", "matchID", activeMatch.ID, "error", err)
+						log.Debug("Failed to set final winner_team", "match_id", activeMatch.ID, "error", err)
 					} else {
-						log.Debug("Set final winner_team from last round end event", "matchID", activeMatch.ID, "winningTeam", roundEndData.WinningTeam, "playerTeam", playerTeamStr)
+						log.Debug("Set final winner_team from last round end event", "match_id", activeMatch.ID, "winningTeam", roundEndData.WinningTeam, "playerTeam", playerTeamStr)
 					}
 				} else if playerTeam >= 0 {
-					log.Debug("Last round winner does not match player_team, not updating winner_team", "matchID", activeMatch.ID, "winningTeam", roundEndData.WinningTeam, "playerTeam", playerTeamStr)
+					log.Debug("Last round winner does not match player_team, not updating winner_team", "match_id", activeMatch.ID, "winningTeam", roundEndData.WinningTeam, "playerTeam", playerTeamStr)
 				}
 			}
 		}
 	}
 
-	// Trigger immediate score update when match ends
-	if h.scoreDebouncer != nil {
+	// Trigger immediate score update when match ends - skip during catchup, the scores on the
+	// server are not this match's any more
+	if h.scoreDebouncer != nil && !data.IsCatchup {
 		h.scoreDebouncer.ExecuteImmediately(serverID)
 	}
 
@@ -622,7 +855,7 @@ func (h *GameEventHandlers) handleMatchEnd(e *core.RecordEvent) error {
 
 // handleObjectiveCaptured processes objective captured events
 func (h *GameEventHandlers) handleObjectiveCaptured(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -643,41 +876,19 @@ func (h *GameEventHandlers) handleObjectiveCaptured(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Processing objective captured", "players", len(data.Players), "objective", data.Objective, "team", data.CapturingTeam, "server", serverID)
+	log.Debug("Processing objective captured", "players", len(data.Players), "objective", data.Objective, "team", data.CapturingTeam, "server_id", serverID)
 
 	// Get active match
-	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
+	activeMatch, err := linkActiveMatch(ctx, e, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for objective captured", "serverID", serverID)
+		log.Debug("No active match found for objective captured", "server_id", serverID)
 		return e.Next()
 	}
 
-	timestamp := e.Record.GetDateTime("created").Time()
-	team := int64(data.CapturingTeam)
-
 	// PocketBase hooks run within transactions automatically
-	// Process all players involved in objective
-	for _, p := range data.Players {
-		if p.SteamID == "" || p.SteamID == "INVALID" {
-			continue
-		}
-
-		player, err := database.GetOrCreatePlayerBySteamID(ctx, e.App, p.SteamID, p.PlayerName)
-		if err != nil {
-			log.Debug("Failed to get/create player", "player", p.PlayerName, "error", err)
-			return e.Next()
-		}
-
-		// Ensure player is in match and increment objectives_captured
-		if err := database.UpsertMatchPlayerStats(ctx, e.App, activeMatch.ID, player.ID, &team, &timestamp); err != nil {
-			log.Debug("Failed to upsert player into match", "error", err)
-			return e.Next()
-		}
-
-		if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, player.ID, "objectives_captured"); err != nil {
-			log.Debug("Failed to increment objectives_captured for player", "player", p.PlayerName, "error", err)
-			return e.Next()
-		}
+	if err := applyObjectiveStats(ctx, e.App, activeMatch.ID, data.Players, data.CapturingTeam, "objectives_captured"); err != nil {
+		log.Debug("Failed to apply objective captured stats", "error", err)
+		return e.Next()
 	}
 
 	// Increment round_objective counter (once per objective event, not per player)
@@ -686,17 +897,24 @@ func (h *GameEventHandlers) handleObjectiveCaptured(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	// Trigger fixed 10s delay score update for objectives (outside transaction)
-	if h.scoreDebouncer != nil {
+	// Trigger fixed 10s delay score update for objectives (outside transaction) - skip during catchup
+	if h.scoreDebouncer != nil && !data.IsCatchup {
 		h.scoreDebouncer.TriggerScoreUpdateFixed(serverID, 10*time.Second)
 	}
 
-	return e.Next()
+	// The timeline links back to the event, so it is written once the event is saved
+	if err := e.Next(); err != nil {
+		return err
+	}
+	if err := recordObjectiveTimeline(ctx, e.App, activeMatch.ID, e.Record, database.ObjectiveCaptured, data.Objective, data.CapturingTeam, data.Players, data.Timestamp); err != nil {
+		log.Debug("Failed to record objective captured in timeline", "error", err)
+	}
+	return nil
 }
 
 // handleObjectiveDestroyed processes objective destroyed events
 func (h *GameEventHandlers) handleObjectiveDestroyed(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -717,54 +935,88 @@ func (h *GameEventHandlers) handleObjectiveDestroyed(e *core.RecordEvent) error
 		return e.Next()
 	}
 
-	log.Debug("Processing objective destroyed", "players", len(data.Players), "objective", data.Objective, "team", data.DestroyingTeam, "server", serverID)
+	log.Debug("Processing objective destroyed", "players", len(data.Players), "objective", data.Objective, "team", data.DestroyingTeam, "server_id", serverID)
 
 	// Get active match
-	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
+	activeMatch, err := linkActiveMatch(ctx, e, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for objective destroyed", "serverID", serverID)
+		log.Debug("No active match found for objective destroyed", "server_id", serverID)
 		return e.Next()
 	}
 
-	timestamp := e.Record.GetDateTime("created").Time()
-	team := int64(data.DestroyingTeam)
-
 	// PocketBase hooks run within transactions automatically
-	// Process all players involved in objective
-	for _, p := range data.Players {
+	if err := applyObjectiveStats(ctx, e.App, activeMatch.ID, data.Players, data.DestroyingTeam, "objectives_destroyed"); err != nil {
+		log.Debug("Failed to apply objective destroyed stats", "error", err)
+		return e.Next()
+	}
+
+	// Trigger fixed 10s delay score update for objectives (outside transaction) - skip during catchup
+	if h.scoreDebouncer != nil && !data.IsCatchup {
+		h.scoreDebouncer.TriggerScoreUpdateFixed(serverID, 10*time.Second)
+	}
+
+	// The timeline links back to the event, so it is written once the event is saved
+	if err := e.Next(); err != nil {
+		return err
+	}
+	if err := recordObjectiveTimeline(ctx, e.App, activeMatch.ID, e.Record, database.ObjectiveDestroyed, data.Objective, data.DestroyingTeam, data.Players, data.Timestamp); err != nil {
+		log.Debug("Failed to record objective destroyed in timeline", "error", err)
+	}
+	return nil
+}
+
+// recordObjectiveTimeline adds an objective event to its match's objective timeline.
+// Events stored before the log timestamp was recorded fall back to when they were processed.
+// Shared by the event hooks and the stats rebuild (see RecomputeMatchStats).
+func recordObjectiveTimeline(ctx context.Context, app core.App, matchID string, event *core.Record, action, objective string, team int, players []events.ObjectivePlayer, timestamp time.Time) error {
+	if timestamp.IsZero() {
+		created, ok := eventTime(event)
+		if !ok {
+			return fmt.Errorf("objective event %s has no timestamp or created date", event.Id)
+		}
+		timestamp = created
+	}
+	return database.RecordObjectiveEvent(ctx, app, &database.ObjectiveEvent{
+		MatchID:   matchID,
+		EventID:   event.Id,
+		Objective: objective,
+		Action:    action,
+		Team:      team,
+		Players:   players,
+		Timestamp: timestamp,
+	})
+}
+
+// applyObjectiveStats credits an objective to every player involved in it by incrementing field
+// (objectives_captured or objectives_destroyed) and recording their team.
+// Shared by the event hooks and the stats rebuild (see RecomputeMatchStats).
+func applyObjectiveStats(ctx context.Context, app core.App, matchID string, players []events.ObjectivePlayer, team int, field string) error {
+	playerTeam := int64(team)
+	for _, p := range players {
 		if p.SteamID == "" || p.SteamID == "INVALID" {
 			continue
 		}
 
-		player, err := database.GetOrCreatePlayerBySteamID(ctx, e.App, p.SteamID, p.PlayerName)
+		player, err := database.GetOrCreatePlayerBySteamID(ctx, app, p.SteamID, p.PlayerName)
 		if err != nil {
-			log.Debug("Failed to get/create player", "player", p.PlayerName, "error", err)
-			return e.Next()
+			return fmt.Errorf("failed to get/create player %s: %w", p.PlayerName, err)
 		}
 
-		// Ensure player is in match and increment objectives_destroyed
-		if err := database.UpsertMatchPlayerStats(ctx, e.App, activeMatch.ID, player.ID, &team, &timestamp); err != nil {
-			log.Debug("Failed to upsert player into match", "error", err)
-			return e.Next()
+		// Ensure player is in match and increment the objective stat
+		if err := database.UpsertMatchPlayerStats(ctx, app, matchID, player.ID, &playerTeam, nil); err != nil {
+			return fmt.Errorf("failed to upsert player into match: %w", err)
 		}
 
-		if err := database.IncrementMatchPlayerStat(ctx, e.App, activeMatch.ID, player.ID, "objectives_destroyed"); err != nil {
-			log.Debug("Failed to increment objectives_destroyed for player", "player", p.PlayerName, "error", err)
-			return e.Next()
+		if err := database.IncrementMatchPlayerStat(ctx, app, matchID, player.ID, field); err != nil {
+			return fmt.Errorf("failed to increment %s for player %s: %w", field, p.PlayerName, err)
 		}
 	}
-
-	// Trigger fixed 10s delay score update for objectives (outside transaction)
-	if h.scoreDebouncer != nil {
-		h.scoreDebouncer.TriggerScoreUpdateFixed(serverID, 10*time.Second)
-	}
-
-	return e.Next()
+	return nil
 }
 
 // handleMapLoad processes map load events and creates a new match
 func (h *GameEventHandlers) handleMapLoad(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -822,13 +1074,13 @@ func (h *GameEventHandlers) handleMapLoad(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Map load processed", "map", data.Map, "scenario", data.Scenario, "server", serverID, "matchID", activeMatch.ID)
+	log.Debug("Map load processed", "map", data.Map, "scenario", data.Scenario, "server_id", serverID, "match_id", activeMatch.ID)
 	return e.Next()
 }
 
 // handleMapTravel processes map travel events and creates a new match
 func (h *GameEventHandlers) handleMapTravel(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -886,7 +1138,7 @@ func (h *GameEventHandlers) handleMapTravel(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Map travel processed", "map", data.Map, "scenario", data.Scenario, "server", serverID)
+	log.Debug("Map travel processed", "map", data.Map, "scenario", data.Scenario, "server_id", serverID)
 	return e.Next()
 }
 
@@ -895,7 +1147,7 @@ func (h *GameEventHandlers) handleMapTravel(e *core.RecordEvent) error {
 // - Sets all player match_player_stats to not connected
 // - Triggers score update
 func (h *GameEventHandlers) handleGameOver(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -907,10 +1159,21 @@ func (h *GameEventHandlers) handleGameOver(e *core.RecordEvent) error {
 	// Get the active match for this server
 	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
 	if err != nil || activeMatch == nil {
-		log.Debug("No active match found for server", "serverID", serverID)
+		log.Debug("No active match found for server", "server_id", serverID)
 		return e.Next()
 	}
 
+	// Decide the winner and attribute W/L while players are still marked connected
+	if _, err := database.RecordMatchOutcome(ctx, e.App, activeMatch.ID); err != nil {
+		log.Debug("Failed to record match outcome", "match_id", activeMatch.ID, "error", err)
+	}
+	if _, err := database.RecordMatchMVP(ctx, e.App, activeMatch.ID, h.mvpMetric()); err != nil {
+		log.Debug("Failed to record match MVP", "match_id", activeMatch.ID, "error", err)
+	}
+	if err := database.UpdateMatchPeakPlayers(ctx, e.App, activeMatch.ID, minRankedPlayers(h.app)); err != nil {
+		log.Debug("Failed to update match peak players", "match_id", activeMatch.ID, "error", err)
+	}
+
 	// End the match using database helper
 	endTime := time.Now()
 	if err := database.EndMatch(ctx, e.App, activeMatch.ID, &endTime, nil, nil); err != nil {
@@ -936,9 +1199,14 @@ func (h *GameEventHandlers) handleGameOver(e *core.RecordEvent) error {
 	matchEndEvent.Set("server", serverRecordID)
 	matchEndEvent.Set("timestamp", time.Now())
 
+	// Catchup is carried over so listeners (e.g. notifications) can skip replayed matches
+	var gameOver events.GameOverData
+	_ = json.Unmarshal([]byte(e.Record.GetString("data")), &gameOver)
+
 	endData := events.MatchEndData{
-		MatchID: activeMatch.ID,
-		EndTime: endTime,
+		MatchID:   activeMatch.ID,
+		EndTime:   endTime,
+		IsCatchup: gameOver.IsCatchup,
 	}
 	dataJSON, _ := json.Marshal(endData)
 	matchEndEvent.Set("data", string(dataJSON))
@@ -948,10 +1216,10 @@ func (h *GameEventHandlers) handleGameOver(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	log.Debug("Game over processed for server, match ended gracefully", "serverID", serverID)
+	log.Debug("Game over processed for server, match ended gracefully", "server_id", serverID)
 
-	// Trigger immediate score update when match ends
-	if h.scoreDebouncer != nil {
+	// Trigger immediate score update when match ends - skip during catchup
+	if h.scoreDebouncer != nil && !gameOver.IsCatchup {
 		h.scoreDebouncer.ExecuteImmediately(serverID)
 	}
 
@@ -960,9 +1228,10 @@ func (h *GameEventHandlers) handleGameOver(e *core.RecordEvent) error {
 
 // handleLogFileCreated processes log file created events
 // - Updates the server's file_creation_time field
+// - Records the server restart in server_restarts
 // - Ensures no active match exists (cleans up stale matches from server crash)
 func (h *GameEventHandlers) handleLogFileCreated(e *core.RecordEvent) error {
-	log := getLogger(e)
+	log := h.eventLogger(e)
 	ctx := context.Background()
 	serverRecordID := e.Record.GetString("server")
 	serverID, err := h.getServerExternalID(ctx, serverRecordID)
@@ -985,37 +1254,219 @@ func (h *GameEventHandlers) handleLogFileCreated(e *core.RecordEvent) error {
 		return e.Next()
 	}
 
-	serverRecord.Set("log_file_creation_time", data.Timestamp.Format("2006-01-02 15:04:05.000Z"))
+	serverRecord.Set("log_file_creation_time", data.Timestamp.UTC().Format("2006-01-02 15:04:05.000Z"))
 	if err := e.App.Save(serverRecord); err != nil {
 		log.Debug("Failed to update server file_creation_time", "error", err)
 		return e.Next()
 	}
 
-	log.Debug("Updated log file creation time for server", "serverID", serverID, "timestamp", data.Timestamp)
+	log.Debug("Updated log file creation time for server", "server_id", serverID, "timestamp", data.Timestamp)
+
+	// A new log file means the server (re)started
+	if _, err := database.RecordServerRestart(ctx, e.App, serverRecordID, data.Timestamp, time.Now(), database.RestartSourceLogFile); err != nil {
+		log.Debug("Failed to record server restart", "server_id", serverID, "error", err)
+	}
 
 	// Check if there's an active match and end it gracefully
-	// (This handles the case where the server crashed mid-match)
+	// (This handles the case where the server crashed mid-match without logging why)
+	h.endCrashedMatch(e, serverID, data.Timestamp, "")
+
+	return e.Next()
+}
+
+// handleServerCrash processes server crash events
+// - Ends the active match as crashed with the fatal error as its crash reason
+// - The restart that follows then finds no active match to clean up
+func (h *GameEventHandlers) handleServerCrash(e *core.RecordEvent) error {
+	log := h.eventLogger(e)
+	ctx := context.Background()
+	serverRecordID := e.Record.GetString("server")
+	serverID, err := h.getServerExternalID(ctx, serverRecordID)
+	if err != nil {
+		log.Debug("Failed to get server external_id", "error", err)
+		return e.Next()
+	}
+
+	var data events.ServerCrashData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		log.Debug("Failed to parse server crash event data", "error", err)
+		return e.Next()
+	}
+
+	log.Warn("Server crashed", "server_id", serverID, "reason", data.Reason)
+	h.endCrashedMatch(e, serverID, data.Timestamp, data.Reason)
+
+	return e.Next()
+}
+
+// endCrashedMatch ends a server's active match, if any, as crashed at endTime and disconnects its
+// players. The reason is stored as the match's crash reason when known.
+func (h *GameEventHandlers) endCrashedMatch(e *core.RecordEvent, serverID string, endTime time.Time, reason string) {
+	log := h.eventLogger(e)
+	ctx := context.Background()
+
 	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID)
-	if err == nil && activeMatch != nil {
-		log.Debug("Found stale active match after log file creation, marking as crashed", "matchID", activeMatch.ID, "serverID", serverID)
+	if err != nil || activeMatch == nil {
+		return
+	}
+	log.Debug("Marking active match as crashed", "match_id", activeMatch.ID, "server_id", serverID)
 
-		endTime := data.Timestamp
-		crashed := "crashed"
+	crashed := "crashed"
+	if err := database.EndMatch(ctx, e.App, activeMatch.ID, &endTime, nil, &crashed); err != nil {
+		log.Debug("Failed to end crashed match", "error", err)
+	}
 
-		if err := database.EndMatch(ctx, e.App, activeMatch.ID, &endTime, nil, &crashed); err != nil {
-			log.Debug("Failed to end stale match", "error", err)
+	if reason != "" {
+		if err := database.SetMatchCrashReason(ctx, e.App, activeMatch.ID, reason); err != nil {
+			log.Debug("Failed to save match crash reason", "error", err)
 		}
+	}
 
-		if err := database.DisconnectAllPlayersInMatch(ctx, e.App, activeMatch.ID, &endTime); err != nil {
-			log.Debug("Failed to disconnect players from stale match", "error", err)
-		}
+	if err := database.DisconnectAllPlayersInMatch(ctx, e.App, activeMatch.ID, &endTime); err != nil {
+		log.Debug("Failed to disconnect players from crashed match", "error", err)
+	}
+}
+
+// handleChatMessage stores a chat message for moderation
+// Only emitted when chat storage is enabled in config; never sends RCON commands
+func (h *GameEventHandlers) handleChatMessage(e *core.RecordEvent) error {
+	log := h.eventLogger(e)
+	ctx := context.Background()
+	serverRecordID := e.Record.GetString("server")
+	serverID, err := h.getServerExternalID(ctx, serverRecordID)
+	if err != nil {
+		log.Debug("Failed to get server external_id", "error", err)
+		return e.Next()
+	}
+
+	var data events.ChatMessageData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		log.Debug("Failed to parse chat message event data", "error", err)
+		return e.Next()
+	}
+
+	msg := &database.ChatMessage{
+		ServerID:   serverRecordID,
+		SteamID:    data.SteamID,
+		PlayerName: data.PlayerName,
+		Channel:    data.Channel,
+		Message:    data.Message,
+		Timestamp:  data.Timestamp,
+	}
+
+	if activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID); err == nil && activeMatch != nil {
+		msg.MatchID = activeMatch.ID
+	}
+	if player, err := database.GetPlayerByExternalID(ctx, e.App, data.SteamID); err == nil && player != nil {
+		msg.PlayerID = player.ID
+	}
+
+	if err := database.RecordChatMessage(ctx, e.App, msg); err != nil {
+		log.Debug("Failed to record chat message", "error", err)
 	}
 
 	return e.Next()
 }
 
+// handleWeaponFire adds shots fired and hits to the shooter's weapon stats for the active match
+// Only emitted when weapon fire tracking is enabled and the server logs gameplay events at Verbose
+func (h *GameEventHandlers) handleWeaponFire(e *core.RecordEvent) error {
+	log := h.eventLogger(e)
+	ctx := context.Background()
+	serverID, err := h.getServerExternalID(ctx, e.Record.GetString("server"))
+	if err != nil {
+		log.Debug("Failed to get server external_id", "error", err)
+		return e.Next()
+	}
+
+	var data events.WeaponFireData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		log.Debug("Failed to parse weapon fire event data", "error", err)
+		return e.Next()
+	}
+
+	activeMatch, err := linkActiveMatch(ctx, e, serverID)
+	if err != nil || activeMatch == nil {
+		log.Debug("No active match found for weapon fire", "server_id", serverID)
+		return e.Next()
+	}
+
+	if err := applyWeaponFireStats(ctx, e.App, activeMatch.ID, data); err != nil {
+		log.Debug("Failed to apply weapon fire stats", "error", err)
+	}
+
+	return e.Next()
+}
+
+// applyWeaponFireStats adds a weapon fire event's shots and hits to the shooter's match weapon stats
+func applyWeaponFireStats(ctx context.Context, app core.App, matchID string, data events.WeaponFireData) error {
+	if data.SteamID == "" || data.SteamID == "INVALID" || data.Shots <= 0 {
+		return nil
+	}
+
+	player, err := database.GetOrCreatePlayerBySteamID(ctx, app, data.SteamID, data.PlayerName)
+	if err != nil {
+		return fmt.Errorf("failed to get/create player %s: %w", data.PlayerName, err)
+	}
+
+	playerTeam := int64(data.Team)
+	if err := database.UpsertMatchPlayerStats(ctx, app, matchID, player.ID, &playerTeam, nil); err != nil {
+		return fmt.Errorf("failed to upsert player into match: %w", err)
+	}
+
+	return database.AddMatchWeaponShots(ctx, app, matchID, player.ID, data.Weapon, data.Shots, data.Hits)
+}
+
+// handleMapVote stores a completed map vote against the match it ended
+// Votes are recorded during catchup too; this handler never sends RCON commands
+func (h *GameEventHandlers) handleMapVote(e *core.RecordEvent) error {
+	log := h.eventLogger(e)
+	ctx := context.Background()
+	serverRecordID := e.Record.GetString("server")
+	serverID, err := h.getServerExternalID(ctx, serverRecordID)
+	if err != nil {
+		log.Debug("Failed to get server external_id", "error", err)
+		return e.Next()
+	}
+
+	var data events.MapVoteData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		log.Debug("Failed to parse map vote event data", "error", err)
+		return e.Next()
+	}
+
+	vote := &database.MapVote{
+		ServerID:  serverRecordID,
+		Options:   data.Options,
+		Winner:    data.Winner,
+		Outcome:   data.Outcome,
+		VoteShare: data.VoteShare,
+		Threshold: data.Threshold,
+		StartedAt: data.StartedAt,
+		EndedAt:   data.EndedAt,
+	}
+
+	// The vote is emitted before the map travel, so the active match is the one that just ended
+	if activeMatch, err := database.GetActiveMatch(ctx, e.App, serverID); err == nil && activeMatch != nil {
+		vote.MatchID = activeMatch.ID
+	}
+
+	if err := database.RecordMapVote(ctx, e.App, vote); err != nil {
+		log.Debug("Failed to record map vote", "error", err)
+		return e.Next()
+	}
+
+	log.Debug("Map vote recorded", "winner", data.Winner.Map, "scenario", data.Winner.Scenario, "outcome", data.Outcome, "server_id", serverID, "isCatchup", data.IsCatchup)
+	return e.Next()
+}
+
 // handleChatCommand processes chat command events
-// Uses the functional HandleChatCommand to process the event
+// Admin commands are tried first when the app configures them, then the functional HandleChatCommand
 func (h *GameEventHandlers) handleChatCommand(e *core.RecordEvent) error {
+	if adminApp, ok := h.app.(adminCommandsGetter); ok {
+		if HandleAdminCommand(e, h.app.SendRconCommand, adminApp.GetAdminCommands()) {
+			return e.Next()
+		}
+	}
 	return HandleChatCommand(h.app.SendRconCommand)(e)
 }
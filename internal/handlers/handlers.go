@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"bytes"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"sandstorm-tracker/assets"
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/database"
 
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
@@ -25,15 +33,28 @@ type AppInterface interface {
 func Register(app AppInterface, e *core.ServeEvent) {
 	registry := template.NewRegistry()
 
+	// Per-IP throttling of every route below, when enabled
+	registerRateLimit(app, e)
+
+	// Changes to the data behind the stats pages, for their ETags
+	statsData := trackDataVersion(app, statsCollections...)
+
 	// Serve static files (PocketBase JS SDK, etc.) using PocketBase's apis.Static helper
 	e.Router.GET("/static/{path...}", apis.Static(assets.StaticFS(), false))
 
 	// Live Server Status page (homepage)
 	e.Router.GET("/", func(re *core.RequestEvent) error {
-		servers, err := re.App.FindAllRecords("servers")
+		selectedGroup := re.Request.URL.Query().Get("group")
+		v := viewerOf(re)
+		servers, err := database.GetServersInGroup(re.Request.Context(), re.App, selectedGroup)
 		if err != nil {
 			servers = []*core.Record{}
 		}
+		servers = v.visibleServers(servers)
+		groups, err := database.GetServerGroups(re.Request.Context(), re.App)
+		if err != nil {
+			groups = []string{}
+		}
 
 		// Build server status info
 		type PlayerInfo struct {
@@ -54,9 +75,23 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			CurrentPlayers     []PlayerInfo
 			PlayerCount        int
 			IsActive           bool
+			Settings           []ServerSetting // Server settings reported over A2S_RULES
+			Uptime             string          // Time since the current log file was created, empty when unknown
+			RecentRestarts     int             // Restarts in the last 7 days
+		}
+
+		type StatusGroup struct {
+			Name    string // Group header, empty when no server has a group
+			Servers []ServerStatus
+		}
+
+		// Cached A2S rules snapshot, if the app keeps one
+		type serverSettingsGetter interface {
+			GetServerSettings(serverID string) map[string]string
 		}
+		settingsApp, hasSettings := app.(serverSettingsGetter)
 
-		serverStatuses := make([]ServerStatus, 0, len(servers))
+		serverStatuses := make(map[string]ServerStatus, len(servers))
 		for _, server := range servers {
 			// Get active match for this server
 			matches, err := re.App.FindRecordsByFilter(
@@ -75,6 +110,18 @@ func Register(app AppInterface, e *core.ServeEvent) {
 				CurrentPlayers: []PlayerInfo{},
 			}
 
+			if hasSettings {
+				status.Settings = serverSettings(settingsApp.GetServerSettings(server.GetString("external_id")))
+			}
+
+			now := time.Now()
+			if startedAt := database.LastServerStart(re.Request.Context(), re.App, server); !startedAt.IsZero() {
+				status.Uptime = formatUptime(database.ServerUptime(startedAt, now))
+			}
+			if restarts, err := database.GetServerRestarts(re.Request.Context(), re.App, server.Id, now.AddDate(0, 0, -7)); err == nil {
+				status.RecentRestarts = len(restarts)
+			}
+
 			if err == nil && len(matches) > 0 {
 				match := matches[0]
 				status.IsActive = true
@@ -82,16 +129,16 @@ func Register(app AppInterface, e *core.ServeEvent) {
 				status.Mode = match.GetString("mode")
 				status.Round = match.GetInt("round")
 				status.RoundObjective = match.GetInt("round_objective")
-				status.NumObjectives = match.GetInt("num_objectives")
+				status.NumObjectives = database.ResolveNumObjectives(re.App, match)
 
 				// Calculate objective percentage
 				if status.NumObjectives > 0 {
-					status.ObjectivePercent = (status.RoundObjective * 100) / status.NumObjectives
+					status.ObjectivePercent = min((status.RoundObjective*100)/status.NumObjectives, 100)
 				}
 
 				// Convert objective numbers to letters
 				// 0 = A, 1 = B, 2 = C, etc.
-				status.CurrentObjective = string(rune('A' + status.RoundObjective))
+				status.CurrentObjective = currentObjectiveLabel(status.RoundObjective, status.NumObjectives)
 
 				// Total objectives as letter range (e.g., "A-F" for 6 objectives)
 				if status.NumObjectives > 0 {
@@ -117,10 +164,11 @@ func Register(app AppInterface, e *core.ServeEvent) {
 					// Expand player records to get names
 					re.App.ExpandRecords(playerStats, []string{"player"}, nil)
 
+					names := v.newPlayerNames(server)
 					for _, stat := range playerStats {
 						if playerRec := stat.ExpandedOne("player"); playerRec != nil {
 							status.CurrentPlayers = append(status.CurrentPlayers, PlayerInfo{
-								Name: playerRec.GetString("name"),
+								Name: names.name(playerRec.GetString("external_id"), playerRec.GetString("name")),
 							})
 						}
 					}
@@ -128,15 +176,24 @@ func Register(app AppInterface, e *core.ServeEvent) {
 				}
 			}
 
-			serverStatuses = append(serverStatuses, status)
+			serverStatuses[server.Id] = status
 		}
 
-		html, err := registry.LoadFS(assets.GetWebAssets().FS(),
-			"templates/layout.html",
-			"templates/server_status.html",
-		).Render(map[string]any{
-			"ActivePage": "status",
-			"Servers":    serverStatuses,
+		// Group headers, in the order GroupServers gives
+		statusGroups := []StatusGroup{}
+		for _, group := range database.GroupServers(servers) {
+			statusGroup := StatusGroup{Name: group.Name}
+			for _, server := range group.Servers {
+				statusGroup.Servers = append(statusGroup.Servers, serverStatuses[server.Id])
+			}
+			statusGroups = append(statusGroups, statusGroup)
+		}
+
+		html, err := renderPage(re, registry, "templates/server_status.html", map[string]any{
+			"ActivePage":    "status",
+			"Groups":        statusGroups,
+			"ServerGroups":  groups,
+			"SelectedGroup": selectedGroup,
 		})
 
 		if err != nil {
@@ -146,17 +203,14 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		return re.HTML(http.StatusOK, html)
 	})
 
-	// Admin servers page (original functionality)
+	// Admin servers page (superusers only)
 	e.Router.GET("/admin/servers", func(re *core.RequestEvent) error {
 		servers, err := re.App.FindAllRecords("servers")
 		if err != nil {
 			servers = []*core.Record{} // Empty if error
 		}
 
-		html, err := registry.LoadFS(assets.GetWebAssets().FS(),
-			"templates/layout.html",
-			"templates/servers.html",
-		).Render(map[string]any{
+		html, err := renderPage(re, registry, "templates/servers.html", map[string]any{
 			"ActivePage": "servers",
 			"Servers":    servers,
 		})
@@ -166,7 +220,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		}
 
 		return re.HTML(http.StatusOK, html)
-	})
+	}).Bind(adminauth.RequireAdmin())
 
 	// Server matches endpoint
 	e.Router.GET("/servers/{id}/matches", func(re *core.RequestEvent) error {
@@ -174,7 +228,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 		// Get server info
 		server, err := re.App.FindRecordById("servers", serverID)
-		if err != nil {
+		if err != nil || !viewerOf(re).canSee(server) {
 			return re.NotFoundError("Server not found", err)
 		}
 
@@ -232,12 +286,19 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 	// Matches page
 	e.Router.GET("/matches", func(re *core.RequestEvent) error {
+		selectedGroup := re.Request.URL.Query().Get("group")
+		filter := ""
+		if selectedGroup != "" {
+			filter = "server.group = {:group}"
+		}
+
 		matches, err := re.App.FindRecordsByFilter(
 			"matches",
-			"",
+			viewerOf(re).matchFilter(filter),
 			"-start_time",
 			50,
 			0,
+			map[string]any{"group": selectedGroup},
 		)
 		if err != nil {
 			matches = []*core.Record{}
@@ -279,12 +340,16 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			}
 		}
 
-		html, err := registry.LoadFS(assets.GetWebAssets().FS(),
-			"templates/layout.html",
-			"templates/matches.html",
-		).Render(map[string]any{
-			"ActivePage": "matches",
-			"Matches":    matchInfos,
+		groups, err := database.GetServerGroups(re.Request.Context(), re.App)
+		if err != nil {
+			groups = []string{}
+		}
+
+		html, err := renderPage(re, registry, "templates/matches.html", map[string]any{
+			"ActivePage":    "matches",
+			"Matches":       matchInfos,
+			"ServerGroups":  groups,
+			"SelectedGroup": selectedGroup,
 		})
 
 		if err != nil {
@@ -296,12 +361,16 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 	// Players page
 	e.Router.GET("/players", func(re *core.RequestEvent) error {
+		if ok, err := notModified(re, statsData); ok {
+			return err
+		}
+
 		searchQuery := re.Request.URL.Query().Get("search")
 
-		// Build filter for search (only by name)
+		// Build filter for search by current or earlier name, or Steam ID
 		filter := ""
 		if searchQuery != "" {
-			filter = "name ~ {:search}"
+			filter = "name ~ {:search} || external_id ~ {:search} || player_names_via_player.name ?~ {:search}"
 		}
 
 		var players []*core.Record
@@ -323,24 +392,54 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			players = []*core.Record{}
 		}
 
+		// Leave out players only seen on servers the viewer can't see, or that hide their names
+		v := viewerOf(re)
+		if !v.admin {
+			named, err := database.GetNamedPlayerIDs(re.Request.Context(), re.App)
+			if err != nil {
+				named = map[string]bool{}
+			}
+			players = slices.DeleteFunc(players, func(player *core.Record) bool {
+				return !named[player.Id]
+			})
+		}
+
 		// Calculate stats for each player
 		type PlayerStats struct {
 			Name        string
+			Aka         []string // Previous names, most recent first
 			ExternalID  string
+			Platform    string // Display name, empty for name-only players
 			TotalKills  int
 			TotalDeaths int
 			TotalScore  int
 			KDRatio     string
+			FFKills     int
+			Flags       []string // Advisory moderation flag kinds
+			Wins        int
+			Losses      int
+			Ties        int
+			MVPs        int
 			Created     string
 		}
 
+		aliases, err := database.GetPlayerAliases(re.Request.Context(), re.App)
+		if err != nil {
+			aliases = map[string][]string{}
+		}
+
+		mvpCounts, err := database.GetPlayerMVPCounts(re.Request.Context(), re.App)
+		if err != nil {
+			mvpCounts = map[string]int{}
+		}
+
 		playerStats := make([]PlayerStats, len(players))
 		for i, player := range players {
-			// Get total kills from match_weapon_stats
+			// Get total kills from match_weapon_stats, ranked matches only
 			kills := 0
 			weaponStats, err := re.App.FindRecordsByFilter(
 				"match_weapon_stats",
-				"player = {:playerId}",
+				v.statsFilter("player = {:playerId} && match.unranked = false"),
 				"",
 				-1,
 				0,
@@ -352,12 +451,14 @@ func Register(app AppInterface, e *core.ServeEvent) {
 				}
 			}
 
-			// Get total deaths and score from match_player_stats
+			// Get total deaths and score from match_player_stats, ranked matches only
 			deaths := 0
 			totalScore := 0
+			ffKills := 0
+			wins, losses, ties := 0, 0, 0
 			playerMatchStats, err := re.App.FindRecordsByFilter(
 				"match_player_stats",
-				"player = {:playerId}",
+				v.statsFilter("player = {:playerId} && match.unranked = false"),
 				"",
 				-1,
 				0,
@@ -367,6 +468,15 @@ func Register(app AppInterface, e *core.ServeEvent) {
 				for _, stat := range playerMatchStats {
 					deaths += stat.GetInt("deaths")
 					totalScore += stat.GetInt("score")
+					ffKills += stat.GetInt("friendly_fire_kills")
+					switch stat.GetString("result") {
+					case database.MatchResultWin:
+						wins++
+					case database.MatchResultLoss:
+						losses++
+					case database.MatchResultTie:
+						ties++
+					}
 				}
 			}
 
@@ -380,11 +490,19 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 			playerStats[i] = PlayerStats{
 				Name:        player.GetString("name"),
+				Aka:         aliases[player.Id],
 				ExternalID:  player.GetString("external_id"),
+				Platform:    database.PlatformLabel(player.GetString("platform")),
 				TotalKills:  kills,
 				TotalDeaths: deaths,
 				TotalScore:  totalScore,
 				KDRatio:     kdRatio,
+				FFKills:     ffKills,
+				Flags:       slices.Sorted(maps.Keys(database.GetPlayerFlags(player))),
+				Wins:        wins,
+				Losses:      losses,
+				Ties:        ties,
+				MVPs:        mvpCounts[player.Id],
 				Created:     player.GetDateTime("created").Time().Format("2006-01-02 15:04"),
 			}
 		}
@@ -402,10 +520,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			})
 		} else {
 			// Return full page
-			html, err = registry.LoadFS(assets.GetWebAssets().FS(),
-				"templates/layout.html",
-				"templates/players.html",
-			).Render(map[string]any{
+			html, err = renderPage(re, registry, "templates/players.html", map[string]any{
 				"ActivePage": "players",
 				"Players":    playerStats,
 			})
@@ -420,6 +535,10 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 	// Weapons page - shows each player's top 3 weapons
 	e.Router.GET("/weapons", func(re *core.RequestEvent) error {
+		if ok, err := notModified(re, statsData); ok {
+			return err
+		}
+
 		searchQuery := re.Request.URL.Query().Get("search")
 
 		// Get all players
@@ -428,8 +547,8 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			players = []*core.Record{}
 		}
 
-		// Get all weapon stats
-		weaponStats, err := re.App.FindAllRecords("match_weapon_stats")
+		// Get all weapon stats from ranked matches the viewer may see players of
+		weaponStats, err := re.App.FindRecordsByFilter("match_weapon_stats", viewerOf(re).statsFilter("match.unranked = false"), "", -1, 0)
 		if err != nil {
 			weaponStats = []*core.Record{}
 		}
@@ -529,13 +648,18 @@ func Register(app AppInterface, e *core.ServeEvent) {
 				"Players": playerWeapons,
 			})
 		} else {
+			weapons, weaponsErr := weaponRows(re, viewerOf(re))
+			if weaponsErr != nil {
+				return re.InternalServerError("Failed to load weapon stats", weaponsErr)
+			}
+
 			// Return full page
-			html, err = registry.LoadFS(assets.GetWebAssets().FS(),
-				"templates/layout.html",
-				"templates/weapons.html",
-			).Render(map[string]any{
-				"ActivePage": "weapons",
-				"Players":    playerWeapons,
+			html, err = renderPage(re, registry, "templates/weapons.html", map[string]any{
+				"ActivePage":   "weapons",
+				"Players":      playerWeapons,
+				"Weapons":      weapons,
+				"Sorts":        database.WeaponSorts,
+				"SelectedSort": weaponSort(re),
 			})
 		}
 
@@ -547,11 +671,12 @@ func Register(app AppInterface, e *core.ServeEvent) {
 	})
 
 	// Live Match - Current match details with player scores
-	e.Router.GET("/live-match/:serverId", func(re *core.RequestEvent) error {
+	e.Router.GET("/live-match/{serverId}", func(re *core.RequestEvent) error {
 		serverID := re.Request.PathValue("serverId")
 
+		v := viewerOf(re)
 		server, err := re.App.FindRecordById("servers", serverID)
-		if err != nil {
+		if err != nil || !v.canSee(server) {
 			return re.NotFoundError("Server not found", err)
 		}
 
@@ -576,6 +701,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		data := map[string]any{
 			"ActivePage": "live-match",
 			"IsActive":   false,
+			"ServerID":   server.Id,
 			"ServerName": server.GetString("name"),
 		}
 
@@ -587,11 +713,12 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			data["Round"] = match.GetInt("round")
 			data["StartTime"] = match.GetDateTime("start_time").Time().Format("15:04")
 			data["RoundObjective"] = match.GetInt("round_objective")
-			data["NumObjectives"] = match.GetInt("num_objectives")
+			numObj := database.ResolveNumObjectives(re.App, match)
+			data["NumObjectives"] = numObj
 
-			if numObj := match.GetInt("num_objectives"); numObj > 0 {
-				data["ObjectivePercent"] = (match.GetInt("round_objective") * 100) / numObj
-				data["CurrentObjective"] = string(rune('A' + match.GetInt("round_objective")))
+			if numObj > 0 {
+				data["ObjectivePercent"] = min((match.GetInt("round_objective")*100)/numObj, 100)
+				data["CurrentObjective"] = currentObjectiveLabel(match.GetInt("round_objective"), numObj)
 				lastLetter := string(rune('A' + numObj - 1))
 				if numObj == 1 {
 					data["TotalObjectivesStr"] = "A"
@@ -612,6 +739,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			if err == nil {
 				re.App.ExpandRecords(playerStats, []string{"player"}, nil)
 
+				names := v.newPlayerNames(server)
 				players := make([]PlayerScore, 0, len(playerStats))
 				securityKills, securityDeaths, insurgentKills, insurgentDeaths := 0, 0, 0, 0
 				securityCount, insurgentCount := 0, 0
@@ -634,7 +762,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 					}
 
 					player := PlayerScore{
-						PlayerName: playerRec.GetString("name"),
+						PlayerName: names.name(playerRec.GetString("external_id"), playerRec.GetString("name")),
 						Kills:      kills,
 						Deaths:     deaths,
 						Assists:    assists,
@@ -672,10 +800,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			}
 		}
 
-		html, err := registry.LoadFS(assets.GetWebAssets().FS(),
-			"templates/layout.html",
-			"templates/live-match.html",
-		).Render(data)
+		html, err := renderPage(re, registry, "templates/live-match.html", data)
 
 		if err != nil {
 			return re.InternalServerError("Failed to render template", err)
@@ -686,6 +811,10 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 	// Match History - Historical matches with player stats
 	e.Router.GET("/match-history", func(re *core.RequestEvent) error {
+		if ok, err := notModified(re, statsData); ok {
+			return err
+		}
+
 		page := 1
 		pageSize := 10
 
@@ -699,15 +828,24 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		selectedServer := re.Request.URL.Query().Get("server")
 		selectedMap := re.Request.URL.Query().Get("map")
 		selectedMode := re.Request.URL.Query().Get("mode")
+		selectedGroup := re.Request.URL.Query().Get("group")
 
 		// Build filter
+		v := viewerOf(re)
 		filters := []string{"end_time != ''"}
 		filterParams := make(map[string]any)
+		if !v.admin {
+			filters = append(filters, "server.is_public = true")
+		}
 
 		if selectedServer != "" {
 			filters = append(filters, "server = {:serverId}")
 			filterParams["serverId"] = selectedServer
 		}
+		if selectedGroup != "" {
+			filters = append(filters, "server.group = {:group}")
+			filterParams["group"] = selectedGroup
+		}
 		if selectedMap != "" {
 			filters = append(filters, "title = {:mapName}")
 			filterParams["mapName"] = selectedMap
@@ -719,8 +857,10 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 		filterStr := strings.Join(filters, " && ")
 
-		// Get all servers for filter dropdown
-		servers, _ := re.App.FindAllRecords("servers")
+		// Get the group's servers (all servers when no group is selected) for filter dropdown
+		servers, _ := database.GetServersInGroup(re.Request.Context(), re.App, selectedGroup)
+		servers = v.visibleServers(servers)
+		groups, _ := database.GetServerGroups(re.Request.Context(), re.App)
 
 		// Get unique titles and modes
 		allMatches, _ := re.App.FindAllRecords("matches")
@@ -761,12 +901,23 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		}
 
 		type MatchPlayer struct {
-			PlayerName string
-			Team       string
-			Kills      int
-			Deaths     int
-			Assists    int
-			KDRatio    string
+			PlayerName  string // Name used in the match
+			CurrentName string // Set when the player has been renamed since
+			Team        string
+			Kills       int
+			Deaths      int
+			Assists     int
+			KDRatio     string
+			IsMVP       bool
+		}
+
+		type MatchObjective struct {
+			Label   string // Objective letter
+			Action  string // captured or destroyed
+			Team    string
+			Players string
+			Elapsed string // Time into the match, m:ss
+			Time    string
 		}
 
 		type MatchData struct {
@@ -780,85 +931,100 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			SecurityDeaths  int
 			InsurgentKills  int
 			InsurgentDeaths int
+			MVP             string // Name the match MVP played under
 			Players         []MatchPlayer
+			Objectives      []MatchObjective
 		}
 
+		re.App.ExpandRecords(matches, []string{"server"}, nil)
+
 		matchData := make([]MatchData, 0, len(matches))
 		for _, match := range matches {
+			names := &playerNames{}
+			if server := match.ExpandedOne("server"); server != nil {
+				names = v.newPlayerNames(server)
+			}
 			startTime := match.GetDateTime("start_time").Time()
 			endTime := match.GetDateTime("end_time").Time()
-			duration := endTime.Sub(startTime)
 
 			md := MatchData{
 				MatchId:  match.Id,
 				Map:      match.GetString("map"),
 				Title:    match.GetString("title"),
 				Mode:     match.GetString("mode"),
-				Duration: fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60),
+				Duration: matchDurationLabel(match),
 				EndTime:  endTime.Format("2006-01-02 15:04"),
 			}
 
 			// Get player stats for this match
-			playerStats, err := re.App.FindRecordsByFilter(
-				"match_player_stats",
-				"match = {:matchId}",
-				"-kills",
-				-1,
-				0,
-				map[string]any{"matchId": match.Id},
-			)
-
+			players, err := database.GetMatchPlayerSummaries(re.Request.Context(), re.App, match.Id)
 			if err == nil {
-				re.App.ExpandRecords(playerStats, []string{"player"}, nil)
-
-				for _, stat := range playerStats {
-					playerRec := stat.ExpandedOne("player")
-					if playerRec == nil {
-						continue
+				for _, p := range players {
+					kdRatio := 0.0
+					if p.Deaths > 0 {
+						kdRatio = float64(p.Kills) / float64(p.Deaths)
+					} else if p.Kills > 0 {
+						kdRatio = float64(p.Kills)
 					}
 
-					kills := stat.GetInt("kills")
-					deaths := stat.GetInt("deaths")
-					assists := stat.GetInt("assists")
-
-					kdRatio := 0.0
-					if deaths > 0 {
-						kdRatio = float64(kills) / float64(deaths)
-					} else if kills > 0 {
-						kdRatio = float64(kills)
+					name, currentName := names.name(p.SteamID, p.Name), p.CurrentName
+					if names.hide {
+						currentName = ""
 					}
 
-					player := MatchPlayer{
-						PlayerName: playerRec.GetString("name"),
-						Kills:      kills,
-						Deaths:     deaths,
-						Assists:    assists,
-						KDRatio:    fmt.Sprintf("%.2f", kdRatio),
-						Team:       stat.GetString("team"),
+					isMVP := p.PlayerID != "" && p.PlayerID == match.GetString("mvp_player")
+					if isMVP {
+						md.MVP = name
 					}
 
-					if player.Team == "Security" {
-						md.SecurityKills += kills
-						md.SecurityDeaths += deaths
-					} else {
-						md.InsurgentKills += kills
-						md.InsurgentDeaths += deaths
+					md.Players = append(md.Players, MatchPlayer{
+						PlayerName:  name,
+						CurrentName: currentName,
+						Kills:       p.Kills,
+						Deaths:      p.Deaths,
+						Assists:     p.Assists,
+						KDRatio:     fmt.Sprintf("%.2f", kdRatio),
+						Team:        database.TeamNames[p.Team],
+						IsMVP:       isMVP,
+					})
+				}
+
+				teams := database.SumTeamStats(players)
+				md.SecurityKills, md.SecurityDeaths = teams[0].Kills, teams[0].Deaths
+				md.InsurgentKills, md.InsurgentDeaths = teams[1].Kills, teams[1].Deaths
+			}
+
+			// Objective timeline: who took each objective and when
+			if timeline, err := database.GetMatchObjectiveTimeline(re.Request.Context(), re.App, match.Id); err == nil {
+				for _, objective := range timeline {
+					takenBy := make([]string, 0, len(objective.Players))
+					for _, p := range objective.Players {
+						takenBy = append(takenBy, names.name(p.SteamID, p.PlayerName))
 					}
 
-					md.Players = append(md.Players, player)
+					mo := MatchObjective{
+						Label:   objective.Label(),
+						Action:  objective.Action,
+						Team:    database.TeamNames[objective.Team],
+						Players: strings.Join(takenBy, ", "),
+						Time:    objective.Timestamp.Local().Format("15:04:05"),
+					}
+					if elapsed := objective.Timestamp.Sub(startTime); !startTime.IsZero() && elapsed >= 0 {
+						mo.Elapsed = fmt.Sprintf("%d:%02d", int(elapsed.Minutes()), int(elapsed.Seconds())%60)
+					}
+					md.Objectives = append(md.Objectives, mo)
 				}
 			}
 
 			matchData = append(matchData, md)
 		}
 
-		html, err := registry.LoadFS(assets.GetWebAssets().FS(),
-			"templates/layout.html",
-			"templates/match-history.html",
-		).Render(map[string]any{
+		html, err := renderPage(re, registry, "templates/match-history.html", map[string]any{
 			"ActivePage":     "match-history",
 			"Matches":        matchData,
 			"Servers":        servers,
+			"ServerGroups":   groups,
+			"SelectedGroup":  selectedGroup,
 			"Maps":           titles,
 			"Modes":          modes,
 			"SelectedServer": selectedServer,
@@ -876,6 +1042,47 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		return re.HTML(http.StatusOK, html)
 	})
 
+	// Match summary - structured result of a single match
+	e.Router.GET("/api/matches/{id}", func(re *core.RequestEvent) error {
+		summary, err := database.GetMatchSummary(re.Request.Context(), re.App, re.Request.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return re.NotFoundError("Match not found", err)
+			}
+			return re.InternalServerError("Failed to load match", err)
+		}
+
+		v := viewerOf(re)
+		server, err := re.App.FindRecordById("servers", summary.ServerID)
+		if err != nil || !v.canSee(server) {
+			return re.NotFoundError("Match not found", err)
+		}
+		if names := v.newPlayerNames(server); names.hide {
+			for i, p := range summary.Players {
+				summary.Players[i].Name = names.name(p.SteamID, p.Name)
+				summary.Players[i].CurrentName = ""
+				summary.Players[i].SteamID = ""
+			}
+		}
+
+		return re.JSON(http.StatusOK, summary)
+	})
+
+	// Prometheus metrics, if the app keeps them
+	type metricsWriter interface {
+		WriteMetrics(w io.Writer) error
+	}
+	if metricsApp, ok := app.(metricsWriter); ok {
+		e.Router.GET("/metrics", func(re *core.RequestEvent) error {
+			var b bytes.Buffer
+			if err := metricsApp.WriteMetrics(&b); err != nil {
+				return re.InternalServerError("Failed to collect metrics", err)
+			}
+
+			return re.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", b.Bytes())
+		})
+	}
+
 	// Server Stats page - player statistics per server
 	e.Router.GET("/servers/{id}/stats", func(re *core.RequestEvent) error {
 		serverID := re.Request.PathValue("id")
@@ -885,11 +1092,23 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			sortBy = "kills"
 		}
 
+		v := viewerOf(re)
+		names := &playerNames{}
+		if serverID != "" {
+			if server, err := re.App.FindRecordById("servers", serverID); err == nil {
+				if !v.canSee(server) {
+					return re.NotFoundError("Server not found", nil)
+				}
+				names = v.newPlayerNames(server)
+			}
+		}
+
 		// Get all servers for dropdown
 		servers, err := re.App.FindAllRecords("servers")
 		if err != nil {
 			servers = []*core.Record{}
 		}
+		servers = v.visibleServers(servers)
 
 		type ServerInfo struct {
 			ID   string
@@ -916,6 +1135,9 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			KDRatioStr string
 			Score      int
 			MatchCount int
+			Wins       int
+			Losses     int
+			Ties       int
 			LastSeen   string
 		}
 
@@ -925,10 +1147,10 @@ func Register(app AppInterface, e *core.ServeEvent) {
 
 		// Get all players that have played on this server
 		if serverID != "" {
-			// Get all matches for this server
+			// Get all ranked matches for this server
 			matches, err := re.App.FindRecordsByFilter(
 				"matches",
-				"server = {:serverId}",
+				"server = {:serverId} && unranked = false",
 				"",
 				-1,
 				0,
@@ -957,7 +1179,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 								continue
 							}
 
-							playerName := playerRecord.GetString("name")
+							playerName := names.name(playerRecord.GetString("external_id"), playerRecord.GetString("name"))
 
 							if _, exists := playerMap[playerID]; !exists {
 								playerMap[playerID] = map[string]any{
@@ -966,6 +1188,9 @@ func Register(app AppInterface, e *core.ServeEvent) {
 									"deaths":    0,
 									"score":     0,
 									"matches":   0,
+									"wins":      0,
+									"losses":    0,
+									"ties":      0,
 									"last_seen": pstat.GetDateTime("updated").Time(),
 								}
 							}
@@ -996,6 +1221,15 @@ func Register(app AppInterface, e *core.ServeEvent) {
 							current["score"] = current["score"].(int) + pstat.GetInt("score")
 							current["matches"] = current["matches"].(int) + 1
 
+							switch pstat.GetString("result") {
+							case database.MatchResultWin:
+								current["wins"] = current["wins"].(int) + 1
+							case database.MatchResultLoss:
+								current["losses"] = current["losses"].(int) + 1
+							case database.MatchResultTie:
+								current["ties"] = current["ties"].(int) + 1
+							}
+
 							// Update last seen
 							updated := pstat.GetDateTime("updated").Time()
 							if updated.After(current["last_seen"].(time.Time)) {
@@ -1037,6 +1271,9 @@ func Register(app AppInterface, e *core.ServeEvent) {
 					KDRatioStr: kdRatioStr,
 					Score:      score,
 					MatchCount: matchCount,
+					Wins:       data["wins"].(int),
+					Losses:     data["losses"].(int),
+					Ties:       data["ties"].(int),
 					LastSeen:   lastSeen.Format("2006-01-02 15:04"),
 				})
 
@@ -1116,10 +1353,7 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			})
 		} else {
 			// Return full page
-			html, err = registry.LoadFS(assets.GetWebAssets().FS(),
-				"templates/layout.html",
-				"templates/server_stats.html",
-			).Render(map[string]any{
+			html, err = renderPage(re, registry, "templates/server_stats.html", map[string]any{
 				"ActivePage":      "server-stats",
 				"CurrentServerID": serverID,
 				"ServerName":      currentServerName,
@@ -1141,8 +1375,61 @@ func Register(app AppInterface, e *core.ServeEvent) {
 		return re.HTML(http.StatusOK, html)
 	})
 
-	// Health check endpoint
-	e.Router.GET("/health", func(re *core.RequestEvent) error {
+	// Superuser login page for the admin and moderation pages
+	registerLogin(e, registry)
+
+	// RCON console page and API (superusers only)
+	registerRconConsole(app, e, registry)
+
+	// Friendly fire moderation page and API (superusers only)
+	registerFriendlyFire(e, registry)
+
+	// Maps leaderboard page and API
+	registerMaps(e, registry)
+
+	// Weapon pages and API
+	registerWeapons(e, registry)
+
+	// Cross-server player leaderboard
+	registerLeaderboard(e, registry)
+
+	// Player search for autocomplete
+	registerPlayerSearch(e)
+
+	// Rebuild match stats from stored events (superusers only)
+	registerRecompute(app, e)
+
+	// Close matches left active by a missed game over (superusers only)
+	registerStaleMatches(app, e)
+
+	// Shared IP report and player merge (superusers only)
+	registerModeration(app, e, registry)
+
+	// Live updates for the status and live match pages
+	registerLiveStream(e)
+
+	// Live server log (superusers only)
+	registerLogTail(e)
+
+	// Log ingestion counters and unmatched lines (superusers only)
+	registerIngest(app, e)
+
+	// Config files deployed to each server (superusers only)
+	registerServerConfig(app, e, registry)
+
+	// Cached A2S server info and players
+	registerA2S(app, e)
+	registerServers(e)
+
+	// rconPoolGetter is implemented by apps with an RCON pool
+	type rconPoolGetter interface {
+		GetRconPoolHealth() map[string]any
+		GetRconPoolStatus() map[string]any
+	}
+
+	// healthReport builds the health check. The public check only counts healthy and unhealthy
+	// RCON servers; with detailed set it lists each server's address and last error.
+	healthReport := func(detailed bool) map[string]any {
 		health := map[string]any{
 			"status": "ok",
 			"database": map[string]any{
@@ -1150,13 +1437,12 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			},
 		}
 
-		// Try to get RCON pool info if app has the method
-		type rconPoolGetter interface {
-			GetRconPoolStatus() map[string]any
-		}
-
 		if customApp, ok := app.(rconPoolGetter); ok {
-			health["rcon"] = customApp.GetRconPoolStatus()
+			if detailed {
+				health["rcon"] = customApp.GetRconPoolStatus()
+			} else {
+				health["rcon"] = customApp.GetRconPoolHealth()
+			}
 		}
 
 		// Try to get A2S pool info if app has the method
@@ -1168,17 +1454,30 @@ func Register(app AppInterface, e *core.ServeEvent) {
 			pool := customApp.GetA2SPool()
 			if pool != nil {
 				servers := pool.ListServers()
-				health["a2s"] = map[string]any{
+				a2sHealth := map[string]any{
 					"available":     true,
 					"total_servers": len(servers),
-					"servers":       servers,
 				}
+				if detailed {
+					a2sHealth["servers"] = servers
+				}
+				health["a2s"] = a2sHealth
 			}
 		}
 
-		return re.JSON(http.StatusOK, health)
+		return health
+	}
+
+	// Health check endpoint
+	e.Router.GET("/health", func(re *core.RequestEvent) error {
+		return re.JSON(http.StatusOK, healthReport(false))
 	})
 
+	// GET /api/admin/health - The health check with each RCON server's status (superusers only)
+	e.Router.GET("/api/admin/health", func(re *core.RequestEvent) error {
+		return re.JSON(http.StatusOK, healthReport(true))
+	}).Bind(adminauth.RequireAdmin())
+
 	app.Logger().Info("Registered custom HTTP handlers")
 
 	// Note: Server management API endpoints are registered by the servermgr plugin
@@ -1189,9 +1488,111 @@ func Register(app AppInterface, e *core.ServeEvent) {
 	// - GET /api/server/list
 }
 
+// renderPage renders a page template, and any partials it uses, inside the layout. The layout
+// is told whether the viewer is an admin, so it only links admins to the admin-only pages.
+func renderPage(re *core.RequestEvent, registry *template.Registry, page string, data map[string]any, partials ...string) (string, error) {
+	data["Admin"] = viewerOf(re).admin
+	files := append([]string{"templates/layout.html", page}, partials...)
+	return registry.LoadFS(assets.GetWebAssets().FS(), files...).Render(data)
+}
+
 // contains performs a case-insensitive substring search
 func contains(s, substr string) bool {
 	s = strings.ToLower(s)
 	substr = strings.ToLower(substr)
 	return strings.Contains(s, substr)
 }
+
+// allObjectivesCapturedLabel is shown instead of a letter once the last objective has been taken
+const allObjectivesCapturedLabel = "All captured"
+
+// matchDurationLabel formats how long an ended match lasted, flagging crashed matches that
+// could only be timed up to their last event or not at all
+func matchDurationLabel(match *core.Record) string {
+	duration, known := database.MatchDuration(match)
+	crashed := match.GetString("status") == "crashed"
+	switch {
+	case !known && crashed:
+		return "crashed (duration unknown)"
+	case !known:
+		return "unknown"
+	}
+	formatted := fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60)
+	if crashed {
+		formatted += " (crashed)"
+	}
+	return formatted
+}
+
+// formatUptime renders an uptime as "3d 4h", "5h 12m" or "12m"
+func formatUptime(uptime time.Duration) string {
+	minutes := int(uptime.Minutes())
+	switch {
+	case minutes >= 24*60:
+		return fmt.Sprintf("%dd %dh", minutes/(24*60), minutes%(24*60)/60)
+	case minutes >= 60:
+		return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// currentObjectiveLabel converts the round objective index to its letter (0 = A, 1 = B, etc.)
+// The index is clamped to the map's objectives so it never renders past the last letter
+func currentObjectiveLabel(roundObjective, numObjectives int) string {
+	if roundObjective < 0 {
+		roundObjective = 0
+	}
+
+	if numObjectives <= 0 {
+		// Objective count unknown - only guard against running past Z
+		return string(rune('A' + min(roundObjective, 25)))
+	}
+
+	if roundObjective >= numObjectives {
+		return allObjectivesCapturedLabel
+	}
+
+	return string(rune('A' + roundObjective))
+}
+
+// ServerSetting is a labelled server rule shown on the status page
+type ServerSetting struct {
+	Label string
+	Value string
+}
+
+// serverSettingLabels maps the A2S rules shown on the status page to their labels, in display order
+var serverSettingLabels = []struct {
+	Rule  string
+	Label string
+}{
+	{"GameMode_s", "Game Mode"},
+	{"MaxPlayers_i", "Max Players"},
+	{"Coop_b", "Co-op"},
+	{"Versus_b", "Versus"},
+	{"Pwd_b", "Password"},
+	{"RankedServer_b", "Ranked"},
+	{"OfficialRuleset_b", "Official Rules"},
+	{"Mutators_s", "Mutators"},
+}
+
+// serverSettings converts an A2S rules snapshot into labelled rows, skipping rules the server did not report
+func serverSettings(rules map[string]string) []ServerSetting {
+	settings := make([]ServerSetting, 0, len(serverSettingLabels))
+	for _, s := range serverSettingLabels {
+		value, ok := rules[s.Rule]
+		if !ok || value == "" {
+			continue
+		}
+		if strings.HasSuffix(s.Rule, "_b") {
+			if value == "true" {
+				value = "Yes"
+			} else {
+				value = "No"
+			}
+		}
+		settings = append(settings, ServerSetting{Label: s.Label, Value: value})
+	}
+	return settings
+}
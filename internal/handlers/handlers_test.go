@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestCurrentObjectiveLabel(t *testing.T) {
+	cases := []struct {
+		name           string
+		roundObjective int
+		numObjectives  int
+		want           string
+	}{
+		{"first objective", 0, 6, "A"},
+		{"middle objective", 3, 6, "D"},
+		{"last objective", 5, 6, "F"},
+		{"last objective taken", 6, 6, allObjectivesCapturedLabel},
+		{"beyond objective count", 40, 6, allObjectivesCapturedLabel},
+		{"negative index", -1, 6, "A"},
+		{"single objective map", 0, 1, "A"},
+		{"single objective taken", 1, 1, allObjectivesCapturedLabel},
+		{"unknown objective count", 2, 0, "C"},
+		{"unknown objective count beyond alphabet", 100, 0, "Z"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := currentObjectiveLabel(tc.roundObjective, tc.numObjectives); got != tc.want {
+				t.Errorf("currentObjectiveLabel(%d, %d) = %q, want %q", tc.roundObjective, tc.numObjectives, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestServerSettings(t *testing.T) {
+	got := serverSettings(map[string]string{
+		"Mutators_s":   "Hardcore,AntiMaterielRiflesOnly",
+		"GameMode_s":   "Checkpoint",
+		"Pwd_b":        "true",
+		"Coop_b":       "false",
+		"MaxPlayers_i": "",
+		"SessionId_s":  "abc123",
+	})
+
+	want := []ServerSetting{
+		{"Game Mode", "Checkpoint"},
+		{"Co-op", "No"},
+		{"Password", "Yes"},
+		{"Mutators", "Hardcore,AntiMaterielRiflesOnly"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("serverSettings() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("serverSettings()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := serverSettings(nil); len(got) != 0 {
+		t.Errorf("serverSettings(nil) = %v, want no settings", got)
+	}
+}
+
+// TestServerGroupFilter checks the status and matches pages only show the selected group's servers
+func TestServerGroupFilter(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	start := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	for _, server := range []struct{ externalID, name, group string }{
+		{"server-eu-1", "Frankfurt Checkpoint", "EU"},
+		{"server-eu-2", "Amsterdam Push", "EU"},
+		{"server-na-1", "Dallas Hardcore", "NA"},
+		{"server-none", "Test Bench", ""},
+	} {
+		id, err := database.GetOrCreateServer(ctx, baseApp, server.externalID, server.name, "test/path")
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		record, err := baseApp.FindRecordById("servers", id)
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		record.Set("group", server.group)
+		if err := baseApp.Save(record); err != nil {
+			t.Fatalf("failed to set server group: %v", err)
+		}
+
+		mapName, scenario := "Town", "Scenario_Hideout_Checkpoint_Security"
+		if _, err := database.CreateMatch(ctx, baseApp, server.externalID, &mapName, &scenario, &start); err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "status page groups every server",
+			Method:         http.MethodGet,
+			URL:            "/",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`<h2 class="group-header">EU</h2>`,
+				`<h2 class="group-header">NA</h2>`,
+				`<h2 class="group-header">Ungrouped</h2>`,
+				"Frankfurt Checkpoint",
+				"Dallas Hardcore",
+				"Test Bench",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "status page filtered by group",
+			Method:         http.MethodGet,
+			URL:            "/?group=EU",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`<h2 class="group-header">EU</h2>`,
+				"Frankfurt Checkpoint",
+				"Amsterdam Push",
+			},
+			NotExpectedContent: []string{
+				`<h2 class="group-header">NA</h2>`,
+				"Dallas Hardcore",
+				"Test Bench",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "matches page filtered by group",
+			Method:         http.MethodGet,
+			URL:            "/matches?group=NA",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				"server-na-1",
+			},
+			NotExpectedContent: []string{
+				"server-eu-1",
+				"server-none",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
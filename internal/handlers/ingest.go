@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/parser"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// ingestStatsGetter is implemented by apps that run the log parser
+type ingestStatsGetter interface {
+	GetIngestStats() parser.IngestStats
+}
+
+// registerIngest registers the endpoint reporting how log ingestion is keeping up
+func registerIngest(app AppInterface, e *core.ServeEvent) {
+	// GET /api/ingest/stats - Lines processed, recognised per line type, unmatched and failing to
+	// parse since startup, with the latest unmatched lines (superusers only: lines carry player
+	// names and IPs)
+	e.Router.GET("/api/ingest/stats", func(re *core.RequestEvent) error {
+		getter, ok := app.(ingestStatsGetter)
+		if !ok {
+			return re.Error(http.StatusServiceUnavailable, "Log ingestion is not available", nil)
+		}
+		return re.JSON(http.StatusOK, getter.GetIngestStats())
+	}).Bind(adminauth.RequireAdmin())
+}
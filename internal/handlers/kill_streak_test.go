@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/parser"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestKillStreakTracking feeds a kill sequence through the parser and handlers and checks the recorded streaks
+func TestKillStreakTracking(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-streaks"
+
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Streak Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	match, err := database.CreateMatch(ctx, testApp, serverExternalID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	NewGameEventHandlers(&mockRconApp{TestApp: testApp}, nil).RegisterHooks()
+	logParser := parser.NewLogParser(testApp, testApp.Logger())
+
+	lines := []string{
+		// ArmoredBear: three kills inside the multi-kill window
+		`[2025.10.04-14.31.00:000][100]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		`[2025.10.04-14.31.02:000][110]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		`[2025.10.04-14.31.04:000][120]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Gunner[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		// A fourth kill outside the window extends the streak but starts a new chain
+		`[2025.10.04-14.31.30:000][130]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Observer[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		// Rabbit kills once, then is team killed by ArmoredBear (does not count for ArmoredBear)
+		`[2025.10.04-14.31.31:000][140]LogGameplayEvents: Display: Rabbit[76561198995742956, team 0] killed Sniper[INVALID, team 1] with BP_Firearm_AKM_C_2147481420`,
+		`[2025.10.04-14.31.32:000][150]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 0] with BP_Firearm_M16A4_C_2147481419`,
+		// ArmoredBear is killed by a bot, then kills once more
+		`[2025.10.04-14.31.40:000][160]LogGameplayEvents: Display: Marksman[INVALID, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147481420`,
+		`[2025.10.04-14.31.50:000][170]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		// Rabbit kills once and then commits suicide
+		`[2025.10.04-14.32.00:000][180]LogGameplayEvents: Display: Rabbit[76561198995742956, team 0] killed Gunner[INVALID, team 1] with BP_Firearm_AKM_C_2147481420`,
+		`[2025.10.04-14.32.05:000][190]LogGameplayEvents: Display: Rabbit[76561198995742956, team 0] killed Rabbit[76561198995742956, team 0] with BP_Character_Player_C_2147481498`,
+	}
+	for _, line := range lines {
+		if err := logParser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+			t.Fatalf("failed to process log line: %v", err)
+		}
+	}
+
+	statsFor := func(t *testing.T, steamID string) map[string]int {
+		t.Helper()
+		player, err := database.GetPlayerByExternalID(ctx, testApp, steamID)
+		if err != nil {
+			t.Fatalf("failed to find player %s: %v", steamID, err)
+		}
+		record, err := testApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": player.ID})
+		if err != nil {
+			t.Fatalf("failed to find match stats for %s: %v", steamID, err)
+		}
+		stats := make(map[string]int)
+		for _, field := range []string{"kills", "deaths", "current_streak", "best_streak", "multi_kills"} {
+			stats[field] = record.GetInt(field)
+		}
+		return stats
+	}
+
+	cases := []struct {
+		steamID string
+		want    map[string]int
+	}{
+		{
+			steamID: "76561198995742987", // ArmoredBear
+			want:    map[string]int{"kills": 5, "deaths": 1, "current_streak": 1, "best_streak": 4, "multi_kills": 1},
+		},
+		{
+			steamID: "76561198995742956", // Rabbit
+			want:    map[string]int{"kills": 2, "deaths": 2, "current_streak": 0, "best_streak": 1, "multi_kills": 0},
+		},
+	}
+
+	for _, tt := range cases {
+		got := statsFor(t, tt.steamID)
+		for field, want := range tt.want {
+			if got[field] != want {
+				t.Errorf("%s: expected %s = %d, got %d", tt.steamID, field, want, got[field])
+			}
+		}
+	}
+}
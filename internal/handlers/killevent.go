@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/types"
@@ -133,6 +134,20 @@ func (k *Killevent) IsCatchup() bool {
 	return v
 }
 
+// Timestamp returns the log time of the kill, falling back to the record's created time
+// for events written before the parser included it
+func (k *Killevent) Timestamp() time.Time {
+	data := k.getDataMap()
+	if data != nil {
+		if v, ok := data["timestamp"].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil && !t.IsZero() {
+				return t
+			}
+		}
+	}
+	return k.Created().Time()
+}
+
 func (k *Killevent) Created() types.DateTime {
 	return k.GetDateTime("created")
 }
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"sandstorm-tracker/assets"
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// leaderboardPageSize is the number of players per leaderboard page
+const leaderboardPageSize = 25
+
+// leaderboardQuery is the window, metric, filters and page of a leaderboard request
+type leaderboardQuery struct {
+	database.LeaderboardFilter
+	Page      int
+	hideNames bool // The selected server hides player names from the viewer
+}
+
+// parseLeaderboardQuery reads ?window=&metric=&server=&mode=&page=. server accepts a record ID
+// or the server's external ID. Private servers only count for admins, and servers that hide
+// player names are left out of the leaderboard of every server.
+func parseLeaderboardQuery(re *core.RequestEvent, v viewer) (*leaderboardQuery, error) {
+	values := re.Request.URL.Query()
+	query := &leaderboardQuery{
+		LeaderboardFilter: database.LeaderboardFilter{
+			Window:     database.LeaderboardWindowAll,
+			Metric:     "kills",
+			Mode:       values.Get("mode"),
+			PublicOnly: !v.admin,
+			NamedOnly:  !v.admin,
+		},
+		Page: 1,
+	}
+
+	if window := values.Get("window"); window != "" {
+		if !slices.Contains(database.LeaderboardWindows, window) {
+			return nil, re.BadRequestError("Invalid window (expected day, week, month or all)", nil)
+		}
+		query.Window = window
+	}
+
+	if metric := values.Get("metric"); metric != "" {
+		if !slices.Contains(database.LeaderboardMetrics, metric) {
+			return nil, re.BadRequestError("Invalid metric (expected kills, kd, score or objectives)", nil)
+		}
+		query.Metric = metric
+	}
+
+	if p := values.Get("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			query.Page = parsed
+		}
+	}
+
+	if id := values.Get("server"); id != "" {
+		server, err := findRecordByIdOrExternalID(re.App, "servers", id)
+		if err != nil || !v.canSee(server) {
+			return nil, re.NotFoundError("Server not found", err)
+		}
+		query.ServerID = server.Id
+		// The server's own leaderboard numbers its players instead
+		query.hideNames = v.hidesNames(server)
+		query.NamedOnly = false
+	}
+
+	return query, nil
+}
+
+// registerLeaderboard registers the cross-server leaderboard page
+func registerLeaderboard(e *core.ServeEvent, registry *template.Registry) {
+	// Leaderboard page - players ranked over a time window, refreshed in place by HTMX
+	e.Router.GET("/leaderboard", func(re *core.RequestEvent) error {
+		v := viewerOf(re)
+		query, err := parseLeaderboardQuery(re, v)
+		if err != nil {
+			return err
+		}
+
+		entries, err := database.GetLeaderboard(re.Request.Context(), re.App, query.LeaderboardFilter, time.Now(),
+			leaderboardPageSize+1, // Get one extra to determine if there's a next page
+			(query.Page-1)*leaderboardPageSize)
+		if err != nil {
+			return re.InternalServerError("Failed to load leaderboard", err)
+		}
+
+		hasNextPage := len(entries) > leaderboardPageSize
+		if hasNextPage {
+			entries = entries[:leaderboardPageSize]
+		}
+		if query.hideNames {
+			for i := range entries {
+				entries[i].Name = fmt.Sprintf("Player #%d", entries[i].Rank)
+			}
+		}
+
+		data := map[string]any{
+			"ActivePage":     "leaderboard",
+			"Entries":        entries,
+			"Windows":        database.LeaderboardWindows,
+			"Metrics":        database.LeaderboardMetrics,
+			"SelectedWindow": query.Window,
+			"SelectedMetric": query.Metric,
+			"SelectedServer": query.ServerID,
+			"SelectedMode":   query.Mode,
+			"Page":           query.Page,
+			"PrevPage":       query.Page - 1,
+			"NextPage":       query.Page + 1,
+			"HasNextPage":    hasNextPage,
+		}
+
+		var html string
+		if re.Request.Header.Get("HX-Request") == "true" {
+			// Return just the table for HTMX updates
+			html, err = registry.LoadFS(assets.GetWebAssets().FS(),
+				"templates/leaderboard_table.html",
+			).Render(data)
+		} else {
+			servers, serversErr := re.App.FindAllRecords("servers")
+			if serversErr != nil {
+				servers = []*core.Record{}
+			}
+			modes, modesErr := database.GetMatchModes(re.Request.Context(), re.App)
+			if modesErr != nil {
+				modes = []string{}
+			}
+			data["Servers"] = v.visibleServers(servers)
+			data["Modes"] = modes
+
+			html, err = renderPage(re, registry, "templates/leaderboard.html", data, "templates/leaderboard_table.html")
+		}
+
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	})
+}
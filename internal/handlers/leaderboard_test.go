@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestLeaderboardEndpoint checks the leaderboard page renders ranked players, HTMX requests get
+// just the table, and unknown windows and metrics are rejected
+func TestLeaderboardEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-leaderboard"
+
+	if _, err := database.GetOrCreateServer(ctx, baseApp, serverExternalID, "Leaderboard Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	kills := map[string]int{"ArmoredBear": 3, "Kestrel": 5}
+	mapName, scenario := "Town", "Scenario_Hideout_Checkpoint_Security"
+	startTime := time.Now().Add(-time.Hour)
+	match, err := database.CreateMatch(ctx, baseApp, serverExternalID, &mapName, &scenario, &startTime)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	for i, name := range []string{"ArmoredBear", "Kestrel"} {
+		player, err := database.CreatePlayer(ctx, baseApp, "7656119899574298"+string(rune('0'+i)), name)
+		if err != nil {
+			t.Fatalf("failed to create player: %v", err)
+		}
+		if err := database.UpsertMatchPlayerStats(ctx, baseApp, match.ID, player.ID, nil, &startTime); err != nil {
+			t.Fatalf("failed to create match stats: %v", err)
+		}
+		for k := 0; k < kills[name]; k++ {
+			if err := database.IncrementMatchPlayerStat(ctx, baseApp, match.ID, player.ID, "kills"); err != nil {
+				t.Fatalf("failed to add kill: %v", err)
+			}
+		}
+	}
+	endTime := time.Now().Add(-30 * time.Minute)
+	if err := database.EndMatch(ctx, baseApp, match.ID, &endTime, nil, nil); err != nil {
+		t.Fatalf("failed to end match: %v", err)
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "page ranks players by kills",
+			Method:         http.MethodGet,
+			URL:            "/leaderboard?window=day",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				"<html",
+				`id="leaderboardFilters"`,
+				"<td>1</td>\n            <td><strong>Kestrel</strong></td>",
+				"<td>2</td>\n            <td><strong>ArmoredBear</strong></td>",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:               "htmx request gets just the table",
+			Method:             http.MethodGet,
+			URL:                "/leaderboard?window=week&metric=kd&server=" + serverExternalID,
+			Headers:            map[string]string{"HX-Request": "true"},
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"<strong>Kestrel</strong>", "5.00"},
+			NotExpectedContent: []string{"<html", `id="leaderboardFilters"`},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:            "unknown window is rejected",
+			Method:          http.MethodGet,
+			URL:             "/leaderboard?window=year",
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"Invalid window"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "unknown metric is rejected",
+			Method:          http.MethodGet,
+			URL:             "/leaderboard?metric=headshots",
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"Invalid metric"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/watcher"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	defaultLogTailLines = 100
+	maxLogTailLines     = 1000
+)
+
+// logTailPollInterval is how often a tailed log is checked for new lines
+var logTailPollInterval = 500 * time.Millisecond
+
+// serverLogPath returns the log file of a server. The path may be the log file itself
+// (sandstorm-admin-wrapper) or the Logs directory holding <external_id>.log.
+func serverLogPath(server *core.Record) string {
+	path := server.GetString("path")
+	if strings.HasSuffix(path, ".log") {
+		return path
+	}
+	return filepath.Join(path, server.GetString("external_id")+".log")
+}
+
+// registerLogTail registers the endpoint that streams a server's log to the browser
+func registerLogTail(e *core.ServeEvent) {
+	// GET /api/server/{id}/logtail?lines=N - Server-Sent Events with the last N lines of the
+	// server's log, then each new line as it is written. id accepts the record ID or external_id.
+	e.Router.GET("/api/server/{id}/logtail", func(re *core.RequestEvent) error {
+		server, err := findRecordByIdOrExternalID(re.App, "servers", re.Request.PathValue("id"))
+		if err != nil {
+			return re.NotFoundError("Server not found", err)
+		}
+
+		lines := defaultLogTailLines
+		if value := re.Request.URL.Query().Get("lines"); value != "" {
+			lines, err = strconv.Atoi(value)
+			if err != nil || lines < 0 {
+				return re.BadRequestError("lines must be a non-negative number", err)
+			}
+			lines = min(lines, maxLogTailLines)
+		}
+
+		path := serverLogPath(server)
+		if _, err := os.Stat(path); err != nil {
+			return re.NotFoundError("Server log not found", err)
+		}
+
+		re.Response.Header().Set("Content-Type", "text/event-stream")
+		re.Response.Header().Set("Cache-Control", "no-cache")
+		re.Response.Header().Set("Connection", "keep-alive")
+		re.Response.Header().Set("X-Accel-Buffering", "no")
+		re.Response.WriteHeader(http.StatusOK)
+
+		if _, err := fmt.Fprintf(re.Response, "event: connected\ndata: {\"server\":%q}\n\n", server.Id); err != nil {
+			return nil
+		}
+		if err := re.Flush(); err != nil {
+			return nil
+		}
+
+		// Follow the log in its own goroutine; cancelling ctx when the client goes away stops it,
+		// and the handler waits for it so no reader outlives the request
+		ctx, cancel := context.WithCancel(re.Request.Context())
+		logLines := make(chan string, streamBufferSize)
+		followErr := make(chan error, 1)
+		go func() {
+			followErr <- watcher.FollowLog(ctx, path, lines, logTailPollInterval, func(line string) error {
+				select {
+				case logLines <- line:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+		defer func() {
+			cancel()
+			<-followErr
+		}()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-re.Request.Context().Done():
+				return nil
+			case err := <-followErr:
+				// Put it back for the deferred wait
+				followErr <- err
+				if err != nil {
+					re.App.Logger().Debug("Log tail stopped", "server", server.Id, "error", err)
+				}
+				return nil
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(re.Response, ": keep-alive\n\n"); err != nil {
+					return nil
+				}
+			case line := <-logLines:
+				if _, err := fmt.Fprintf(re.Response, "event: line\ndata: %s\n\n", line); err != nil {
+					return nil
+				}
+			}
+			if err := re.Flush(); err != nil {
+				return nil
+			}
+		}
+	}).Bind(adminauth.RequireAdmin())
+}
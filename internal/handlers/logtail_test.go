@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestLogTail_DeliversAppendedLines connects to a server's log tail and checks the backlog
+// and a line written afterwards both reach the reader
+func TestLogTail_DeliversAppendedLines(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	logDir := t.TempDir()
+	serverExternalID := "test-server-logtail"
+	logPath := filepath.Join(logDir, serverExternalID+".log")
+	if err := os.WriteFile(logPath, []byte("line 1\nline 2\nline 3\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Log Server", logDir); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	superusers, err := testApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := testApp.Save(superuser); err != nil {
+		t.Fatalf("failed to create superuser: %v", err)
+	}
+	token, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create superuser token: %v", err)
+	}
+
+	router, err := apis.NewRouter(testApp)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	Register(&mockRconApp{TestApp: testApp}, &core.ServeEvent{App: testApp, Router: router})
+	mux, err := router.BuildMux()
+	if err != nil {
+		t.Fatalf("failed to build mux: %v", err)
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Without a superuser token the log stays private
+	res, err := http.Get(server.URL + "/api/server/" + serverExternalID + "/logtail")
+	if err != nil {
+		t.Fatalf("failed to request log tail: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 without auth, got %d", res.StatusCode)
+	}
+
+	streamCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, server.URL+"/api/server/"+serverExternalID+"/logtail?lines=2", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", token)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", res.StatusCode)
+	}
+
+	// readEvent returns the next event name and data from the stream, skipping comments
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() (string, string) {
+		t.Helper()
+		var name, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("stream ended before an event arrived: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "" && name != "":
+				return name, data
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	if name, _ := readEvent(); name != "connected" {
+		t.Fatalf("Expected the connected event first, got %q", name)
+	}
+	for _, want := range []string{"line 2", "line 3"} {
+		if name, data := readEvent(); name != "line" || data != want {
+			t.Fatalf("Expected backlog line %q, got %s %q", want, name, data)
+		}
+	}
+
+	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString("line 4\n"); err != nil {
+		t.Fatalf("failed to append to log: %v", err)
+	}
+
+	if name, data := readEvent(); name != "line" || data != "line 4" {
+		t.Fatalf("Expected the appended line, got %s %q", name, data)
+	}
+}
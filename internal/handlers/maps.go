@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// formatMatchDuration renders a duration in seconds as "1h 05m" or "12m 30s"
+func formatMatchDuration(seconds int) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	if seconds >= 3600 {
+		return fmt.Sprintf("%dh %02dm", seconds/3600, seconds%3600/60)
+	}
+	return fmt.Sprintf("%dm %02ds", seconds/60, seconds%60)
+}
+
+// registerMaps registers the maps leaderboard page and API
+func registerMaps(e *core.ServeEvent, registry *template.Registry) {
+	// Maps page - per-map match counts, durations, modes and top players
+	e.Router.GET("/maps", func(re *core.RequestEvent) error {
+		stats, err := database.GetMapStats(re.Request.Context(), re.App, !viewerOf(re).admin)
+		if err != nil {
+			return re.InternalServerError("Failed to load map stats", err)
+		}
+
+		type MapRow struct {
+			database.MapStats
+			AvgDuration string
+		}
+
+		rows := make([]MapRow, 0, len(stats))
+		for _, s := range stats {
+			rows = append(rows, MapRow{MapStats: s, AvgDuration: formatMatchDuration(s.AvgDurationSeconds)})
+		}
+
+		html, err := renderPage(re, registry, "templates/maps.html", map[string]any{
+			"ActivePage": "maps",
+			"Maps":       rows,
+		})
+
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	})
+
+	// GET /api/maps - Per-map stats with map metadata, most played first
+	e.Router.GET("/api/maps", func(re *core.RequestEvent) error {
+		stats, err := database.GetMapStats(re.Request.Context(), re.App, !viewerOf(re).admin)
+		if err != nil {
+			return re.InternalServerError("Failed to load map stats", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"items": stats,
+		})
+	})
+}
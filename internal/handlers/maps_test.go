@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestMapsEndpoint plays matches on two maps and checks the maps page and API aggregate them per map
+func TestMapsEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-maps"
+
+	if _, err := database.GetOrCreateServer(ctx, baseApp, serverExternalID, "Maps Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	armoredBear, err := database.CreatePlayer(ctx, baseApp, "76561198995742987", "ArmoredBear")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+
+	start := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	played := []struct {
+		mapName  string
+		scenario string
+		duration time.Duration
+		kills    int
+	}{
+		{"Town", "Scenario_Hideout_Checkpoint_Security", 20 * time.Minute, 3},
+		{"Town", "Scenario_Hideout_Checkpoint_Insurgents", 30 * time.Minute, 2},
+		{"Town", "Scenario_Hideout_Push_Security", 40 * time.Minute, 0},
+		{"Farmhouse", "Scenario_Farmhouse_Checkpoint_Security", 10 * time.Minute, 1},
+	}
+	for i, p := range played {
+		mapName, scenario := p.mapName, p.scenario
+		startTime := start.Add(time.Duration(i) * time.Hour)
+		match, err := database.CreateMatch(ctx, baseApp, serverExternalID, &mapName, &scenario, &startTime)
+		if err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+		if err := database.UpsertMatchPlayerStats(ctx, baseApp, match.ID, armoredBear.ID, nil, &startTime); err != nil {
+			t.Fatalf("failed to create match stats: %v", err)
+		}
+		for k := 0; k < p.kills; k++ {
+			if err := database.IncrementMatchPlayerStat(ctx, baseApp, match.ID, armoredBear.ID, "kills"); err != nil {
+				t.Fatalf("failed to add kill: %v", err)
+			}
+		}
+		endTime := startTime.Add(p.duration)
+		if err := database.EndMatch(ctx, baseApp, match.ID, &endTime, nil, nil); err != nil {
+			t.Fatalf("failed to end match: %v", err)
+		}
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "stats are aggregated per map",
+			Method:         http.MethodGet,
+			URL:            "/api/maps",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				// Most played first
+				`{"items":[{"name":"Town","display_name":"Hideout","image":"","objectives":null,"matches_played":3,"avg_duration_seconds":1800,"most_played_mode":"Checkpoint","top_players":[{"id":"` + armoredBear.ID + `","name":"ArmoredBear","kills":5}]}`,
+				`{"name":"Farmhouse","display_name":"Farmhouse","image":"","objectives":null,"matches_played":1,"avg_duration_seconds":600,"most_played_mode":"Checkpoint","top_players":[{"id":"` + armoredBear.ID + `","name":"ArmoredBear","kills":1}]}`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "maps page lists both maps",
+			Method:         http.MethodGet,
+			URL:            "/maps",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				"Hideout",
+				"Farmhouse",
+				"30m 00s",
+				"10m 00s",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+// TestResolveNumObjectivesFromMaps checks the maps table fills in the objective count of a match
+func TestResolveNumObjectivesFromMaps(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-objectives"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Objectives Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	town, err := testApp.FindFirstRecordByData("maps", "name", "Town")
+	if err != nil {
+		t.Fatalf("expected Town to be seeded: %v", err)
+	}
+	town.Set("objectives", map[string]int{"Checkpoint": 6})
+	if err := testApp.Save(town); err != nil {
+		t.Fatalf("failed to save map: %v", err)
+	}
+
+	resolve := func(mapName, scenario string) int {
+		t.Helper()
+		match, err := database.CreateMatch(ctx, testApp, serverExternalID, &mapName, &scenario, nil)
+		if err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+		record, err := testApp.FindRecordById("matches", match.ID)
+		if err != nil {
+			t.Fatalf("failed to load match: %v", err)
+		}
+		return database.ResolveNumObjectives(testApp, record)
+	}
+
+	if got := resolve("Town", "Scenario_Hideout_Checkpoint_Security"); got != 6 {
+		t.Errorf("Town Checkpoint objectives = %d, want 6", got)
+	}
+	if got := resolve("Town", "Scenario_Hideout_Push_Security"); got != 0 {
+		t.Errorf("Town Push objectives = %d, want 0 (unknown)", got)
+	}
+	if got := resolve("CustomMap", "Scenario_CustomMap_Checkpoint_Security"); got != 0 {
+		t.Errorf("unknown map objectives = %d, want 0", got)
+	}
+}
+
+// TestObjectiveProgress checks a match on a map the maps catalog knows gets its objective count
+// when created, and that the progress bar is hidden for a map the catalog has no count for
+func TestObjectiveProgress(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+
+	town, err := baseApp.FindFirstRecordByData("maps", "name", "Town")
+	if err != nil {
+		t.Fatalf("failed to find map: %v", err)
+	}
+	town.Set("objectives", map[string]int{"checkpoint": 6})
+	if err := baseApp.Save(town); err != nil {
+		t.Fatalf("failed to save map: %v", err)
+	}
+
+	startMatch := func(serverExternalID, mapName, scenario string) (string, *core.Record) {
+		t.Helper()
+		serverID, err := database.GetOrCreateServer(ctx, baseApp, serverExternalID, serverExternalID, "test/path")
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		if err := database.EndActiveMatchAndCreateNew(ctx, baseApp, serverExternalID, mapName, scenario, time.Now(), nil); err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+		match, err := baseApp.FindFirstRecordByFilter("matches", "server = {:server}", map[string]any{"server": serverID})
+		if err != nil {
+			t.Fatalf("failed to find match: %v", err)
+		}
+		return serverID, match
+	}
+
+	knownServer, known := startMatch("test-server-objectives-known", "Town", "Scenario_Hideout_Checkpoint_Security")
+	if got := known.GetInt("num_objectives"); got != 6 {
+		t.Errorf("expected 6 objectives for Hideout Checkpoint, got %d", got)
+	}
+	unknownServer, unknown := startMatch("test-server-objectives-unknown", "CustomMap", "Scenario_CustomMap_Checkpoint_Security")
+	if got := unknown.GetInt("num_objectives"); got != 0 {
+		t.Errorf("expected no objective count for an unknown map, got %d", got)
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "known map shows the progress bar",
+			Method:          http.MethodGet,
+			URL:             "/live-match/" + knownServer,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"Progress: A / A-F", `class="progress-bar"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "unknown map hides the progress bar",
+			Method:             http.MethodGet,
+			URL:                "/live-match/" + unknownServer,
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"CustomMap"},
+			NotExpectedContent: []string{"Progress:", `class="progress-bar"`},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "status page hides the progress bar for the unknown map",
+			Method:             http.MethodGet,
+			URL:                "/",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"A-F", `class="progress-fill"`},
+			NotExpectedContent: []string{`<span class="value"></span>`},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
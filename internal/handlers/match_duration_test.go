@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/parser"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestCrashedMatchDuration ends matches as crashed long after their last kill, as happens when
+// the tracker only notices the crash once the server is back, and checks the match history times
+// them up to their last event or flags the duration as unknown
+func TestCrashedMatchDuration(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-crash"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Crash Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	NewGameEventHandlers(&mockRconApp{TestApp: testApp}, nil).RegisterHooks()
+	logParser := parser.NewLogParser(testApp, testApp.Logger())
+	crashed := "crashed"
+
+	// A match with two kills, the last at 14:32:05, noticed as crashed at 20:00
+	mapName, scenario := "Town", "Scenario_Hideout_Checkpoint_Security"
+	startTime := time.Date(2025, 10, 4, 14, 0, 0, 0, time.UTC)
+	timed, err := database.CreateMatch(ctx, testApp, serverExternalID, &mapName, &scenario, &startTime)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	for _, line := range []string{
+		`[2025.10.04-14.31.00:000][100]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		`[2025.10.04-14.32.05:000][110]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+	} {
+		if err := logParser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+			t.Fatalf("failed to process log line: %v", err)
+		}
+	}
+	noticedAt := time.Date(2025, 10, 4, 20, 0, 0, 0, time.UTC)
+	if err := database.EndMatch(ctx, testApp, timed.ID, &noticedAt, nil, &crashed); err != nil {
+		t.Fatalf("failed to end match: %v", err)
+	}
+
+	record, err := testApp.FindRecordById("matches", timed.ID)
+	if err != nil {
+		t.Fatalf("failed to find match: %v", err)
+	}
+	wantLastEvent := time.Date(2025, 10, 4, 14, 32, 5, 0, time.UTC)
+	if got := record.GetDateTime("last_event_time").Time(); !got.Equal(wantLastEvent) {
+		t.Errorf("last_event_time = %v, want %v", got, wantLastEvent)
+	}
+
+	// A match that crashed before anything happened in it
+	secondStart := noticedAt.Add(time.Minute)
+	empty, err := database.CreateMatch(ctx, testApp, serverExternalID, &mapName, &scenario, &secondStart)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	secondNoticed := secondStart.Add(3 * time.Hour)
+	if err := database.EndMatch(ctx, testApp, empty.ID, &secondNoticed, nil, &crashed); err != nil {
+		t.Fatalf("failed to end match: %v", err)
+	}
+
+	summary, err := database.GetMatchSummary(ctx, testApp, timed.ID)
+	if err != nil {
+		t.Fatalf("failed to get match summary: %v", err)
+	}
+	if summary.DurationSeconds != 32*60+5 || summary.DurationUnknown {
+		t.Errorf("Expected the crashed match to last until its last kill (1925s), got %ds (unknown: %t)", summary.DurationSeconds, summary.DurationUnknown)
+	}
+	summary, err = database.GetMatchSummary(ctx, testApp, empty.ID)
+	if err != nil {
+		t.Fatalf("failed to get match summary: %v", err)
+	}
+	if summary.DurationSeconds != 0 || !summary.DurationUnknown {
+		t.Errorf("Expected an unknown duration for the crashed match without events, got %ds (unknown: %t)", summary.DurationSeconds, summary.DurationUnknown)
+	}
+
+	router, err := apis.NewRouter(testApp)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	Register(&mockRconApp{TestApp: testApp}, &core.ServeEvent{App: testApp, Router: router})
+	mux, err := router.BuildMux()
+	if err != nil {
+		t.Fatalf("failed to build mux: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/match-history", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{"0h 32m (crashed)", "crashed (duration unknown)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the match history to show %q", want)
+		}
+	}
+	for _, unwanted := range []string{"6h 0m", "3h 0m"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("Expected no duration timed to when the crash was noticed, found %q", unwanted)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/geoip"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// moderationConfigGetter is implemented by apps that configure the moderation reports
+type moderationConfigGetter interface {
+	GetModerationConfig() config.ModerationConfig
+}
+
+// geoIPGetter is implemented by apps that can locate IP addresses
+type geoIPGetter interface {
+	GetGeoIP() *geoip.Resolver
+}
+
+// knownIP is one of a player's known IPs with where it is registered, if known
+type knownIP struct {
+	IP string `json:"ip"`
+	geoip.Location
+}
+
+// registerModeration registers the superuser-only moderation reports and player merge
+func registerModeration(app AppInterface, e *core.ServeEvent, registry *template.Registry) {
+	// GET /moderation/shared-ips - Players sharing known IPs, to spot alternate accounts and ban evaders
+	// IP addresses are only ever shown to superusers
+	e.Router.GET("/moderation/shared-ips", func(re *core.RequestEvent) error {
+		cfg := config.ModerationConfig{}
+		if getter, ok := app.(moderationConfigGetter); ok {
+			cfg = getter.GetModerationConfig()
+		}
+		maxPlayers := cfg.SharedIPMaxPlayersThreshold()
+
+		groups, err := database.GetSharedIPGroups(re.Request.Context(), re.App, maxPlayers)
+		if err != nil {
+			return re.InternalServerError("Failed to load shared IPs", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"maxPlayersPerIP": maxPlayers,
+			"groups":          groups,
+		})
+	}).Bind(adminauth.RequireAdmin())
+
+	// GET /moderation/players/{id} - A player's known IPs, located with the GeoIP database when one is configured
+	// id accepts the players record ID or Steam ID. Locations are only ever shown to superusers.
+	e.Router.GET("/moderation/players/{id}", func(re *core.RequestEvent) error {
+		player, err := findRecordByIdOrExternalID(re.App, "players", re.Request.PathValue("id"))
+		if err != nil {
+			return re.NotFoundError("Player not found", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"id":          player.Id,
+			"name":        player.GetString("name"),
+			"external_id": player.GetString("external_id"),
+			"knownIPs":    locateKnownIPs(app, player),
+		})
+	}).Bind(adminauth.RequireAdmin())
+
+	// Player moderation page (superusers only): the same known IPs and locations as above
+	e.Router.GET("/admin/players/{id}", func(re *core.RequestEvent) error {
+		player, err := findRecordByIdOrExternalID(re.App, "players", re.Request.PathValue("id"))
+		if err != nil {
+			return re.NotFoundError("Player not found", err)
+		}
+
+		html, err := renderPage(re, registry, "templates/player_moderation.html", map[string]any{
+			"ActivePage": "players",
+			"Player":     player,
+			"KnownIPs":   locateKnownIPs(app, player),
+		})
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	}).Bind(adminauth.RequireAdmin())
+
+	// POST /api/admin/players/merge - Merge a duplicate player into another (superusers only)
+	// source and target accept the players record ID or Steam ID; source is deleted
+	e.Router.POST("/api/admin/players/merge", func(re *core.RequestEvent) error {
+		data := struct {
+			Source string `json:"source"`
+			Target string `json:"target"`
+		}{}
+		if err := re.BindBody(&data); err != nil {
+			return re.BadRequestError("Invalid request body", err)
+		}
+		if data.Source == "" || data.Target == "" {
+			return re.BadRequestError("source and target are required", nil)
+		}
+
+		source, err := findRecordByIdOrExternalID(re.App, "players", data.Source)
+		if err != nil {
+			return re.NotFoundError("Source player not found", err)
+		}
+		target, err := findRecordByIdOrExternalID(re.App, "players", data.Target)
+		if err != nil {
+			return re.NotFoundError("Target player not found", err)
+		}
+		if source.Id == target.Id {
+			return re.BadRequestError("source and target are the same player", nil)
+		}
+
+		result, err := database.MergePlayers(re.Request.Context(), re.App, source.Id, target.Id)
+		if err != nil {
+			return re.InternalServerError("Failed to merge players", err)
+		}
+
+		return re.JSON(http.StatusOK, result)
+	}).Bind(adminauth.RequireAdmin())
+}
+
+// locateKnownIPs returns a player's known IPs, located with the app's GeoIP database when it has one
+func locateKnownIPs(app AppInterface, player *core.Record) []knownIP {
+	var resolver *geoip.Resolver
+	if getter, ok := app.(geoIPGetter); ok {
+		resolver = getter.GetGeoIP()
+	}
+
+	ips := []knownIP{}
+	for _, ip := range database.GetPlayerKnownIPs(player) {
+		location, _ := resolver.Lookup(ip)
+		ips = append(ips, knownIP{IP: ip, Location: location})
+	}
+	return ips
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/geoip"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// mockModerationApp is a test app with a moderation config
+type mockModerationApp struct {
+	mockRconApp
+	moderation config.ModerationConfig
+}
+
+func (m *mockModerationApp) GetModerationConfig() config.ModerationConfig {
+	return m.moderation
+}
+
+// mockGeoIPApp is a test app with a GeoIP database
+type mockGeoIPApp struct {
+	mockRconApp
+	geoIP *geoip.Resolver
+}
+
+func (m *mockGeoIPApp) GetGeoIP() *geoip.Resolver {
+	return m.geoIP
+}
+
+func TestSharedIPsEndpoint(t *testing.T) {
+	// Seed three players across two IPs: ArmoredBear and Rabbit share one, Marksman is alone on the other
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	seeded := []struct {
+		steamID string
+		name    string
+		ips     []string
+	}{
+		{"76561198995742987", "ArmoredBear", []string{"203.0.113.10"}},
+		{"76561198995742956", "Rabbit", []string{"203.0.113.10"}},
+		{"76561198995742911", "Marksman", []string{"198.51.100.7"}},
+	}
+	ids := make(map[string]string)
+	for _, s := range seeded {
+		player, err := database.CreatePlayer(ctx, baseApp, s.steamID, s.name)
+		if err != nil {
+			t.Fatalf("failed to create player: %v", err)
+		}
+		record, err := baseApp.FindRecordById("players", player.ID)
+		if err != nil {
+			t.Fatalf("failed to load player: %v", err)
+		}
+		if err := database.UpdatePlayerMetadata(baseApp, record, func(metadata map[string]any) {
+			metadata["knownIPs"] = s.ips
+		}); err != nil {
+			t.Fatalf("failed to set known IPs: %v", err)
+		}
+		ids[s.name] = player.ID
+	}
+
+	superusers, err := baseApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := baseApp.Save(superuser); err != nil {
+		t.Fatalf("failed to create superuser: %v", err)
+	}
+	superuserToken, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create superuser token: %v", err)
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(moderation config.ModerationConfig) func(testing.TB, *tests.TestApp, *core.ServeEvent) {
+		return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			Register(&mockModerationApp{mockRconApp: mockRconApp{TestApp: app}, moderation: moderation}, e)
+		}
+	}
+
+	auth := map[string]string{"Authorization": superuserToken}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthenticated request is rejected",
+			Method:          http.MethodGet,
+			URL:             "/moderation/shared-ips",
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			NotExpectedContent: []string{
+				"203.0.113.10",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes(config.ModerationConfig{}),
+		},
+		{
+			Name:           "players sharing an IP are grouped",
+			Method:         http.MethodGet,
+			URL:            "/moderation/shared-ips",
+			Headers:        auth,
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"groups":[{"players":[` +
+					`{"id":"` + ids["ArmoredBear"] + `","name":"ArmoredBear","external_id":"76561198995742987"},` +
+					`{"id":"` + ids["Rabbit"] + `","name":"Rabbit","external_id":"76561198995742956"}` +
+					`],"ips":["203.0.113.10"]}]`,
+				`"maxPlayersPerIP":4`,
+			},
+			NotExpectedContent: []string{
+				"Marksman",
+				"198.51.100.7",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes(config.ModerationConfig{}),
+		},
+		{
+			Name:            "IPs over the max players threshold are ignored",
+			Method:          http.MethodGet,
+			URL:             "/moderation/shared-ips",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"groups":[]`, `"maxPlayersPerIP":1`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes(config.ModerationConfig{SharedIPMaxPlayers: 1}),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
+
+// TestGetSharedIPGroups_Chained checks players linked through a chain of shared IPs form one group
+func TestGetSharedIPGroups_Chained(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	for i, ips := range [][]string{{"10.0.0.1"}, {"10.0.0.1", "10.0.0.2"}, {"10.0.0.2"}} {
+		player, err := database.CreatePlayer(ctx, testApp, "7656119899574290"+string(rune('0'+i)), "Player"+string(rune('A'+i)))
+		if err != nil {
+			t.Fatalf("failed to create player: %v", err)
+		}
+		record, err := testApp.FindRecordById("players", player.ID)
+		if err != nil {
+			t.Fatalf("failed to load player: %v", err)
+		}
+		if err := database.UpdatePlayerMetadata(testApp, record, func(metadata map[string]any) {
+			metadata["knownIPs"] = ips
+		}); err != nil {
+			t.Fatalf("failed to set known IPs: %v", err)
+		}
+	}
+
+	groups, err := database.GetSharedIPGroups(ctx, testApp, 4)
+	if err != nil {
+		t.Fatalf("GetSharedIPGroups failed: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group, got %d: %+v", len(groups), groups)
+	}
+	if len(groups[0].Players) != 3 || len(groups[0].IPs) != 2 {
+		t.Errorf("Expected 3 players over 2 IPs, got %+v", groups[0])
+	}
+}
+
+func TestPlayerKnownIPsEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	player, err := database.CreatePlayer(context.Background(), baseApp, "76561198995742987", "ArmoredBear")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	record, err := baseApp.FindRecordById("players", player.ID)
+	if err != nil {
+		t.Fatalf("failed to load player: %v", err)
+	}
+	if err := database.UpdatePlayerMetadata(baseApp, record, func(metadata map[string]any) {
+		metadata["knownIPs"] = []string{"81.2.69.142", "10.0.0.1"}
+	}); err != nil {
+		t.Fatalf("failed to set known IPs: %v", err)
+	}
+
+	superusers, err := baseApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := baseApp.Save(superuser); err != nil {
+		t.Fatalf("failed to create superuser: %v", err)
+	}
+	superuserToken, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create superuser token: %v", err)
+	}
+
+	resolver, err := geoip.Open(filepath.Join("..", "geoip", "testdata", "test-city.mmdb"))
+	if err != nil {
+		t.Fatalf("failed to open test GeoIP database: %v", err)
+	}
+	defer resolver.Close()
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(resolver *geoip.Resolver) func(testing.TB, *tests.TestApp, *core.ServeEvent) {
+		return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			Register(&mockGeoIPApp{mockRconApp: mockRconApp{TestApp: app}, geoIP: resolver}, e)
+		}
+	}
+
+	auth := map[string]string{"Authorization": superuserToken}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:               "unauthenticated request is rejected",
+			Method:             http.MethodGet,
+			URL:                "/moderation/players/76561198995742987",
+			ExpectedStatus:     http.StatusUnauthorized,
+			ExpectedContent:    []string{`"data":{}`},
+			NotExpectedContent: []string{"81.2.69.142", "United Kingdom"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes(resolver),
+		},
+		{
+			Name:           "known IPs are located",
+			Method:         http.MethodGet,
+			URL:            "/moderation/players/76561198995742987",
+			Headers:        auth,
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"id":"` + player.ID + `"`,
+				`"knownIPs":[` +
+					`{"ip":"81.2.69.142","country":"United Kingdom","country_code":"GB","region":"England"},` +
+					`{"ip":"10.0.0.1","country":"","country_code":"","region":""}]`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes(resolver),
+		},
+		{
+			Name:            "known IPs are listed without a GeoIP database",
+			Method:          http.MethodGet,
+			URL:             "/moderation/players/" + player.ID,
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`{"ip":"81.2.69.142","country":"","country_code":"","region":""}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes(nil),
+		},
+		{
+			Name:            "page lists the located known IPs",
+			Method:          http.MethodGet,
+			URL:             "/admin/players/76561198995742987",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"ArmoredBear", "81.2.69.142", "United Kingdom (GB)", "England", "10.0.0.1"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes(resolver),
+		},
+		{
+			Name:               "page requires a superuser",
+			Method:             http.MethodGet,
+			URL:                "/admin/players/76561198995742987",
+			ExpectedStatus:     http.StatusUnauthorized,
+			NotExpectedContent: []string{"81.2.69.142", "United Kingdom"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes(resolver),
+		},
+		{
+			Name:               "records API hides known IPs",
+			Method:             http.MethodGet,
+			URL:                "/api/collections/players/records",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`"name":"ArmoredBear"`},
+			NotExpectedContent: []string{"81.2.69.142", "metadata"},
+			TestAppFactory:     setup,
+		},
+		{
+			Name:            "records API shows known IPs to superusers",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/players/records",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"81.2.69.142"},
+			TestAppFactory:  setup,
+		},
+		{
+			Name:            "unknown player",
+			Method:          http.MethodGet,
+			URL:             "/moderation/players/missing",
+			Headers:         auth,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes(resolver),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
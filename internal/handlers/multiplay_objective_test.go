@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"sandstorm-tracker/internal/database"
 	"sandstorm-tracker/internal/events"
@@ -56,6 +57,7 @@ func TestMultiPlayerObjectiveHandling(t *testing.T) {
 		"0",
 		players,
 		0,
+		time.Now(),
 		false,
 	)
 	if err != nil {
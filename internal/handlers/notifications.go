@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Notification filters selectable in notification_webhooks.events
+const (
+	NotifyMatchEnd     = "match_end"
+	NotifyTeamKills    = "teamkills"
+	NotifyServerStatus = "server_status"
+)
+
+// defaultTeamKillThreshold is used when a webhook does not set teamkill_threshold
+const defaultTeamKillThreshold = 3
+
+// maxRetryAfter caps how long a rate limited delivery waits before retrying
+const maxRetryAfter = time.Minute
+
+// Embed colors
+const (
+	colorMatchEnd = 0xff6b35
+	colorWarning  = 0xf44336
+	colorOnline   = 0x4caf50
+)
+
+// Notifier posts Discord-compatible webhook messages when a match ends, a player keeps team killing
+// or a server goes offline and comes back.
+// Webhooks are configured in the notification_webhooks collection. Deliveries run in the background,
+// one at a time per URL, and are retried with backoff on 429 and 5xx responses.
+type Notifier struct {
+	app        core.App
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration // First retry delay, doubled on every attempt
+	urlLocks   sync.Map      // URL -> *sync.Mutex
+	wg         sync.WaitGroup
+}
+
+// WebhookMessage is a Discord webhook payload; other receivers get the same JSON
+type WebhookMessage struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []WebhookEmbed `json:"embeds,omitempty"`
+}
+
+// WebhookEmbed is a Discord embed
+type WebhookEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Fields      []WebhookField `json:"fields,omitempty"`
+}
+
+// WebhookField is a name/value row of a Discord embed
+type WebhookField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// NewNotifier creates a webhook notifier
+func NewNotifier(app core.App) *Notifier {
+	return &Notifier{
+		app:        app,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		maxRetries: 4,
+		backoff:    time.Second,
+	}
+}
+
+// RegisterHooks subscribes the notifier to newly recorded events
+func (n *Notifier) RegisterHooks() {
+	n.app.OnRecordAfterCreateSuccess("events").BindFunc(n.handleEvent)
+}
+
+// Wait blocks until every queued delivery has finished
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+func (n *Notifier) logger() *slog.Logger {
+	return n.app.Logger().With("component", "NOTIFY")
+}
+
+func (n *Notifier) handleEvent(e *core.RecordEvent) error {
+	switch e.Record.GetString("type") {
+	case events.TypeMatchEnd:
+		n.notifyMatchEnd(e)
+	case events.TypePlayerKill:
+		n.notifyTeamKills(e)
+	case events.TypeServerOffline, events.TypeServerOnline:
+		n.notifyServerStatus(e)
+	}
+	return e.Next()
+}
+
+// webhooks returns the enabled webhooks subscribed to filter; a webhook without filters gets everything
+func (n *Notifier) webhooks(app core.App, filter string) []*core.Record {
+	records, err := app.FindRecordsByFilter("notification_webhooks", "enabled = true", "", -1, 0)
+	if err != nil {
+		return nil
+	}
+
+	subscribed := make([]*core.Record, 0, len(records))
+	for _, record := range records {
+		filters := record.GetStringSlice("events")
+		if len(filters) == 0 || slices.Contains(filters, filter) {
+			subscribed = append(subscribed, record)
+		}
+	}
+	return subscribed
+}
+
+// notifyMatchEnd posts the result summary of a finished match
+func (n *Notifier) notifyMatchEnd(e *core.RecordEvent) {
+	log := n.logger()
+
+	var data events.MatchEndData
+	if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+		log.Debug("Failed to parse match end event data", "error", err)
+		return
+	}
+	// Replayed logs would otherwise repost every old match
+	if data.IsCatchup {
+		return
+	}
+
+	webhooks := n.webhooks(e.App, NotifyMatchEnd)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	summary, err := database.GetMatchSummary(context.Background(), e.App, data.MatchID)
+	if err != nil {
+		log.Debug("Failed to build match summary for notification", "match_id", data.MatchID, "error", err)
+		return
+	}
+
+	message := matchEndMessage(serverName(e.App, e.Record.GetString("server")), summary)
+	for _, webhook := range webhooks {
+		n.deliver(webhook.GetString("url"), message)
+	}
+}
+
+// notifyTeamKills posts a warning when a player's team kills in the current match reach a webhook's threshold
+func (n *Notifier) notifyTeamKills(e *core.RecordEvent) {
+	log := n.logger()
+	ctx := context.Background()
+
+	killevent := &Killevent{}
+	killevent.SetProxyRecord(e.Record)
+	if killevent.IsCatchup() || !killevent.VictimIsPlayer() {
+		return
+	}
+
+	victimTeam := killevent.VictimTeam()
+	killers := killevent.Killers()
+	if len(killers) == 0 || victimTeam < 0 {
+		return
+	}
+	killer := killers[0]
+	if killer.Team != victimTeam || killer.SteamID == "" || killer.SteamID == "INVALID" || killer.SteamID == killevent.VictimSteamID() {
+		return
+	}
+
+	webhooks := n.webhooks(e.App, NotifyTeamKills)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	serverRecord, err := e.App.FindRecordById("servers", e.Record.GetString("server"))
+	if err != nil {
+		return
+	}
+	activeMatch, err := database.GetActiveMatch(ctx, e.App, serverRecord.GetString("external_id"))
+	if err != nil || activeMatch == nil {
+		return
+	}
+	player, err := database.GetPlayerByExternalID(ctx, e.App, killer.SteamID)
+	if err != nil {
+		return
+	}
+	stats, err := e.App.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+		map[string]any{"match": activeMatch.ID, "player": player.ID})
+	if err != nil {
+		log.Debug("Failed to find match stats for team kill notification", "player", killer.Name, "error", err)
+		return
+	}
+	teamKills := stats.GetInt("friendly_fire_kills")
+
+	message := WebhookMessage{Embeds: []WebhookEmbed{{
+		Title:       fmt.Sprintf("Repeated team kills on %s", serverRecord.GetString("name")),
+		Description: fmt.Sprintf("**%s** has %d team kills this match", killer.Name, teamKills),
+		Color:       colorWarning,
+		Fields: []WebhookField{
+			{Name: "Steam ID", Value: killer.SteamID, Inline: true},
+			{Name: "Latest victim", Value: killevent.VictimName(), Inline: true},
+			{Name: "Weapon", Value: killevent.Weapon(), Inline: true},
+		},
+	}}}
+
+	// Post once, when the count reaches the threshold
+	for _, webhook := range webhooks {
+		threshold := webhook.GetInt("teamkill_threshold")
+		if threshold <= 0 {
+			threshold = defaultTeamKillThreshold
+		}
+		if teamKills == threshold {
+			n.deliver(webhook.GetString("url"), message)
+		}
+	}
+}
+
+// notifyServerStatus posts that a server appears offline, or is back online after an outage
+func (n *Notifier) notifyServerStatus(e *core.RecordEvent) {
+	webhooks := n.webhooks(e.App, NotifyServerStatus)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	message, err := serverStatusMessage(serverName(e.App, e.Record.GetString("server")), e.Record)
+	if err != nil {
+		n.logger().Debug("Failed to parse server status event data", "error", err)
+		return
+	}
+	for _, webhook := range webhooks {
+		n.deliver(webhook.GetString("url"), message)
+	}
+}
+
+// serverStatusMessage formats a server_offline or server_online event
+func serverStatusMessage(server string, event *core.Record) (WebhookMessage, error) {
+	if event.GetString("type") == events.TypeServerOnline {
+		var data events.ServerOnlineData
+		if err := json.Unmarshal([]byte(event.GetString("data")), &data); err != nil {
+			return WebhookMessage{}, err
+		}
+		return WebhookMessage{Embeds: []WebhookEmbed{{
+			Title:       fmt.Sprintf("Server %s is back online", server),
+			Description: fmt.Sprintf("Offline for %s", time.Duration(data.DowntimeSeconds)*time.Second),
+			Color:       colorOnline,
+		}}}, nil
+	}
+
+	var data events.ServerOfflineData
+	if err := json.Unmarshal([]byte(event.GetString("data")), &data); err != nil {
+		return WebhookMessage{}, err
+	}
+	return WebhookMessage{Embeds: []WebhookEmbed{{
+		Title:       fmt.Sprintf("Server %s appears offline", server),
+		Description: fmt.Sprintf("No answer to %d queries in a row", data.Failures),
+		Color:       colorWarning,
+		Fields: []WebhookField{
+			{Name: "Since", Value: data.Since.UTC().Format(time.RFC3339), Inline: true},
+			{Name: "Last error", Value: data.LastError},
+		},
+	}}}, nil
+}
+
+// serverName returns a server's display name, falling back to its external id
+func serverName(app core.App, serverRecordID string) string {
+	server, err := app.FindRecordById("servers", serverRecordID)
+	if err != nil {
+		return "unknown server"
+	}
+	if name := server.GetString("name"); name != "" {
+		return name
+	}
+	return server.GetString("external_id")
+}
+
+// matchEndMessage formats a match summary: result, map, duration and top fragger
+func matchEndMessage(server string, summary *database.MatchSummary) WebhookMessage {
+	result := "No winner"
+	if len(summary.Teams) == 2 {
+		security, insurgents := summary.Teams[0].RoundWins, summary.Teams[1].RoundWins
+		switch {
+		case summary.WinningTeam == 0 || summary.WinningTeam == 1:
+			winner := summary.Teams[summary.WinningTeam]
+			loser := summary.Teams[1-summary.WinningTeam]
+			result = fmt.Sprintf("**%s** won %d-%d", winner.Name, winner.RoundWins, loser.RoundWins)
+		case security+insurgents > 0:
+			result = fmt.Sprintf("Tie %d-%d", security, insurgents)
+		}
+	}
+
+	mapName := summary.Title
+	if mapName == "" || mapName == "Unknown" {
+		mapName = summary.Map
+	}
+
+	fields := []WebhookField{
+		{Name: "Map", Value: fmt.Sprintf("%s (%s)", mapName, summary.Mode), Inline: true},
+		{Name: "Duration", Value: (time.Duration(summary.DurationSeconds) * time.Second).String(), Inline: true},
+		{Name: "Players", Value: strconv.Itoa(len(summary.Players)), Inline: true},
+	}
+
+	var top *database.MatchPlayerSummary
+	for i := range summary.Players {
+		if top == nil || summary.Players[i].Kills > top.Kills {
+			top = &summary.Players[i]
+		}
+	}
+	if top != nil && top.Kills > 0 {
+		fields = append(fields, WebhookField{
+			Name:  "Top fragger",
+			Value: fmt.Sprintf("%s (%d kills, %d deaths)", top.Name, top.Kills, top.Deaths),
+		})
+	}
+
+	return WebhookMessage{Embeds: []WebhookEmbed{{
+		Title:       fmt.Sprintf("Match ended on %s", server),
+		Description: result,
+		Color:       colorMatchEnd,
+		Fields:      fields,
+	}}}
+}
+
+// deliver posts message to url in the background, retrying on 429 and 5xx responses.
+// Deliveries to the same URL are serialized so a burst of events does not trip the rate limit.
+func (n *Notifier) deliver(url string, message WebhookMessage) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		n.logger().Error("Failed to encode webhook message", "error", err)
+		return
+	}
+
+	lock, _ := n.urlLocks.LoadOrStore(url, &sync.Mutex{})
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		lock.(*sync.Mutex).Lock()
+		defer lock.(*sync.Mutex).Unlock()
+
+		log := n.logger()
+		delay := n.backoff
+		for attempt := 0; ; attempt++ {
+			resp, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+			status := 0
+			retryAfter := time.Duration(0)
+			if err == nil {
+				status = resp.StatusCode
+				retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+				resp.Body.Close()
+				if status >= 200 && status < 300 {
+					return
+				}
+			}
+
+			retryable := err != nil || status == http.StatusTooManyRequests || status >= 500
+			if !retryable || attempt >= n.maxRetries {
+				log.Warn("Webhook delivery failed", "status", status, "error", err, "attempts", attempt+1)
+				return
+			}
+
+			wait := delay
+			if status == http.StatusTooManyRequests && retryAfter > 0 {
+				wait = retryAfter
+			}
+			log.Debug("Retrying webhook delivery", "status", status, "error", err, "wait", wait)
+			time.Sleep(wait)
+			delay *= 2
+		}
+	}()
+}
+
+// parseRetryAfter reads a Retry-After header in (possibly fractional) seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return min(time.Duration(seconds*float64(time.Second)), maxRetryAfter)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestNotifierMatchEnd(t *testing.T) {
+	// The first delivery is rate limited; the retry succeeds
+	var mu sync.Mutex
+	var bodies []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		attempt := len(bodies)
+		mu.Unlock()
+
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "notify-server"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverID, "Notify Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	mapName, scenario := "Farmhouse", "Scenario_Farmhouse_Checkpoint_Security"
+	startTime := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	match, err := database.CreateMatch(ctx, testApp, serverID, &mapName, &scenario, &startTime)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	addPlayer := func(steamID, name string, team int64, kills, deaths int) {
+		t.Helper()
+		player, err := database.GetOrCreatePlayerBySteamID(ctx, testApp, steamID, name)
+		if err != nil {
+			t.Fatalf("failed to create player: %v", err)
+		}
+		if err := database.UpsertMatchPlayerStats(ctx, testApp, match.ID, player.ID, &team, nil); err != nil {
+			t.Fatalf("failed to create match stats: %v", err)
+		}
+		stats, err := testApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": player.ID})
+		if err != nil {
+			t.Fatalf("failed to find match stats: %v", err)
+		}
+		stats.Set("kills", kills)
+		stats.Set("deaths", deaths)
+		if err := testApp.Save(stats); err != nil {
+			t.Fatalf("failed to save match stats: %v", err)
+		}
+	}
+	addPlayer("76561198995742987", "ArmoredBear", 0, 12, 3)
+	addPlayer("76561198995742956", "Rabbit", 1, 5, 9)
+
+	matchRecord, err := testApp.FindRecordById("matches", match.ID)
+	if err != nil {
+		t.Fatalf("failed to find match: %v", err)
+	}
+	matchRecord.Set("team_0_round_wins", 2)
+	matchRecord.Set("team_1_round_wins", 1)
+	matchRecord.Set("winning_team", 0)
+	matchRecord.Set("end_time", startTime.Add(32*time.Minute).Format(time.RFC3339))
+	if err := testApp.Save(matchRecord); err != nil {
+		t.Fatalf("failed to save match: %v", err)
+	}
+
+	webhooks, err := testApp.FindCollectionByNameOrId("notification_webhooks")
+	if err != nil {
+		t.Fatalf("failed to find notification_webhooks collection: %v", err)
+	}
+	for _, w := range []struct {
+		url     string
+		enabled bool
+		events  []string
+	}{
+		{webhook.URL, true, []string{NotifyMatchEnd}},
+		{webhook.URL + "/teamkills-only", true, []string{NotifyTeamKills}},
+		{webhook.URL + "/disabled", false, nil},
+	} {
+		record := core.NewRecord(webhooks)
+		record.Set("url", w.url)
+		record.Set("enabled", w.enabled)
+		record.Set("events", w.events)
+		if err := testApp.Save(record); err != nil {
+			t.Fatalf("failed to create webhook: %v", err)
+		}
+	}
+
+	notifier := NewNotifier(testApp)
+	notifier.backoff = 10 * time.Millisecond
+	notifier.RegisterHooks()
+
+	if err := events.NewCreator(testApp).CreateMatchEndEvent(serverID, match.ID); err != nil {
+		t.Fatalf("failed to create match end event: %v", err)
+	}
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("expected a rate limited attempt and one retry, got %d requests: %v", len(bodies), bodies)
+	}
+	if bodies[0] != bodies[1] {
+		t.Errorf("retry posted a different body:\n%s\n%s", bodies[0], bodies[1])
+	}
+
+	var got WebhookMessage
+	if err := json.Unmarshal([]byte(bodies[1]), &got); err != nil {
+		t.Fatalf("failed to decode webhook body: %v", err)
+	}
+	want := WebhookMessage{Embeds: []WebhookEmbed{{
+		Title:       "Match ended on Notify Server",
+		Description: "**Security** won 2-1",
+		Color:       colorMatchEnd,
+		Fields: []WebhookField{
+			{Name: "Map", Value: "Farmhouse (Checkpoint)", Inline: true},
+			{Name: "Duration", Value: "32m0s", Inline: true},
+			{Name: "Players", Value: "2", Inline: true},
+			{Name: "Top fragger", Value: "ArmoredBear (12 kills, 3 deaths)"},
+		},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected webhook body\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      0,
+		"abc":   0,
+		"-1":    0,
+		"2":     2 * time.Second,
+		"0.25":  250 * time.Millisecond,
+		"86400": maxRetryAfter,
+	}
+	for value, want := range cases {
+		if got := parseRetryAfter(value); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/parser"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestPlayerRename_KeepsHistory plays a match under one name, renames the player on their next
+// login, and checks the history gains a row while the old match keeps the old name
+func TestPlayerRename_KeepsHistory(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-rename"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Rename Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	NewGameEventHandlers(&mockRconApp{TestApp: testApp}, nil).RegisterHooks()
+	logParser := parser.NewLogParser(testApp, testApp.Logger())
+	process := func(lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			if err := logParser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+				t.Fatalf("failed to process log line: %v", err)
+			}
+		}
+	}
+
+	firstMatch, err := database.CreateMatch(ctx, testApp, serverExternalID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	process(
+		"[2025.11.15-12.00.05:000][200]LogNet: Login request:	?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI",
+		"[2025.11.15-12.00.07:000][202]LogNet: Join succeeded: ArmoredBear",
+	)
+	endTime := time.Date(2025, 11, 15, 12, 30, 0, 0, time.UTC)
+	if err := database.EndMatch(ctx, testApp, firstMatch.ID, &endTime, nil, nil); err != nil {
+		t.Fatalf("failed to end match: %v", err)
+	}
+
+	secondMatch, err := database.CreateMatch(ctx, testApp, serverExternalID, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	process(
+		"[2025.11.15-13.00.05:000][300]LogNet: Login request:	?Name=PolarBear userId: SteamNWI:76561198995742987 platform: SteamNWI",
+		"[2025.11.15-13.00.07:000][302]LogNet: Join succeeded: PolarBear",
+	)
+
+	player, err := database.GetPlayerByExternalID(ctx, testApp, "76561198995742987")
+	if err != nil {
+		t.Fatalf("failed to find player: %v", err)
+	}
+	if player.Name != "PolarBear" {
+		t.Errorf("Expected the current name PolarBear, got %q", player.Name)
+	}
+
+	history, err := database.GetPlayerNameHistory(ctx, testApp, player.ID)
+	if err != nil {
+		t.Fatalf("failed to load name history: %v", err)
+	}
+	if len(history) != 2 || history[0].Name != "PolarBear" || history[1].Name != "ArmoredBear" {
+		t.Fatalf("Expected history [PolarBear ArmoredBear], got %+v", history)
+	}
+
+	// The name is dated with the event's stored created date, not a time of its own
+	login, err := testApp.FindFirstRecordByFilter("events", "type = 'player_login' && data ~ 'PolarBear'")
+	if err != nil {
+		t.Fatalf("failed to find login event: %v", err)
+	}
+	if created := login.GetDateTime("created").Time(); !history[0].FirstSeen.Equal(created) {
+		t.Errorf("Expected PolarBear first seen at the login's created date %v, got %v", created, history[0].FirstSeen)
+	}
+
+	// The rename must not leave a second player behind for the join
+	players, err := testApp.FindRecordsByFilter("players", "name = {:name}", "", -1, 0, dbx.Params{"name": "PolarBear"})
+	if err != nil || len(players) != 1 {
+		t.Errorf("Expected one PolarBear player, got %d (err: %v)", len(players), err)
+	}
+
+	summaries, err := database.GetMatchPlayerSummaries(ctx, testApp, firstMatch.ID)
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("Expected one player in the first match, got %d (err: %v)", len(summaries), err)
+	}
+	if summaries[0].Name != "ArmoredBear" || summaries[0].CurrentName != "PolarBear" {
+		t.Errorf("Expected the first match to show ArmoredBear (now PolarBear), got %q (now %q)", summaries[0].Name, summaries[0].CurrentName)
+	}
+
+	summaries, err = database.GetMatchPlayerSummaries(ctx, testApp, secondMatch.ID)
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("Expected one player in the second match, got %d (err: %v)", len(summaries), err)
+	}
+	if summaries[0].Name != "PolarBear" || summaries[0].CurrentName != "" {
+		t.Errorf("Expected the second match to show PolarBear, got %q (now %q)", summaries[0].Name, summaries[0].CurrentName)
+	}
+
+	aliases, err := database.GetPlayerAliases(ctx, testApp)
+	if err != nil {
+		t.Fatalf("failed to load aliases: %v", err)
+	}
+	if got := aliases[player.ID]; len(got) != 1 || got[0] != "ArmoredBear" {
+		t.Errorf("Expected aka [ArmoredBear], got %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// playerSearchMinLength is the shortest query searched; shorter ones match too much to be useful
+	playerSearchMinLength = 2
+	// playerSearchDefaultLimit and playerSearchMaxLimit bound the results, keeping each keystroke cheap
+	playerSearchDefaultLimit = 10
+	playerSearchMaxLimit     = 25
+)
+
+// registerPlayerSearch registers the player search API used for autocomplete
+func registerPlayerSearch(e *core.ServeEvent) {
+	// GET /api/players/search?q=&limit= - Players by current or earlier name, or Steam ID prefix
+	e.Router.GET("/api/players/search", func(re *core.RequestEvent) error {
+		query := strings.TrimSpace(re.Request.URL.Query().Get("q"))
+		if len([]rune(query)) < playerSearchMinLength {
+			return re.JSON(http.StatusOK, map[string]any{
+				"items": []database.PlayerSearchResult{},
+			})
+		}
+
+		limit := playerSearchDefaultLimit
+		if l := re.Request.URL.Query().Get("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+				limit = min(parsed, playerSearchMaxLimit)
+			}
+		}
+
+		results, err := database.SearchPlayers(re.Request.Context(), re.App, query, !viewerOf(re).admin, limit)
+		if err != nil {
+			return re.InternalServerError("Failed to search players", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"items": results,
+		})
+	})
+}
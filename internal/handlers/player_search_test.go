@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestPlayerSearchEndpoint checks players are found by Steam ID and by part of their current or
+// an earlier name, and that players only seen on a server hiding names are found by admins only
+func TestPlayerSearchEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	startTime := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+
+	// playOn creates a server and a match on it with the players in it
+	playOn := func(externalID string, hideNames bool, players ...*database.Player) {
+		t.Helper()
+		serverID, err := database.GetOrCreateServer(ctx, baseApp, externalID, externalID, "test/path")
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		server, err := baseApp.FindRecordById("servers", serverID)
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		server.Set("is_public", true)
+		server.Set("hide_player_names", hideNames)
+		if err := baseApp.Save(server); err != nil {
+			t.Fatalf("failed to save server: %v", err)
+		}
+
+		mapName, scenario := "Town", "Scenario_Hideout_Checkpoint_Security"
+		match, err := database.CreateMatch(ctx, baseApp, externalID, &mapName, &scenario, &startTime)
+		if err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+		for _, player := range players {
+			if err := database.UpsertMatchPlayerStats(ctx, baseApp, match.ID, player.ID, nil, &startTime); err != nil {
+				t.Fatalf("failed to create match stats: %v", err)
+			}
+		}
+	}
+
+	// Logged in from Steam, so their platform is known
+	armoredBear, err := database.GetOrCreatePlayerByPlatformID(ctx, baseApp, database.PlatformSteam, "76561198995742987", "SleepyBear")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	if err := database.RenamePlayer(ctx, baseApp, armoredBear, "ArmoredBear", startTime); err != nil {
+		t.Fatalf("failed to rename player: %v", err)
+	}
+	rabbit, err := database.GetOrCreatePlayerByPlatformID(ctx, baseApp, database.PlatformSteam, "76561198995742956", "Rabbit")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	hidden, err := database.CreatePlayer(ctx, baseApp, "76561198000000001", "BearGrylls")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	playOn("test-server-search", false, armoredBear, rabbit)
+	playOn("test-server-anonymous", true, hidden)
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "Steam ID finds the player",
+			Method:         http.MethodGet,
+			URL:            "/api/players/search?q=76561198995742987",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`{"items":[{"id":"` + armoredBear.ID + `","name":"ArmoredBear","steam_id":"76561198995742987","platform":"Steam"}]}`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "partial name finds the player",
+			Method:         http.MethodGet,
+			URL:            "/api/players/search?q=abb",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`{"items":[{"id":"` + rabbit.ID + `","name":"Rabbit","steam_id":"76561198995742956","platform":"Steam"}]}`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "earlier name finds the player and is reported",
+			Method:         http.MethodGet,
+			URL:            "/api/players/search?q=sleepy",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"name":"ArmoredBear"`,
+				`"matched_name":"SleepyBear"`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "players only seen with hidden names are left out for the public",
+			Method:         http.MethodGet,
+			URL:            "/api/players/search?q=bear",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`{"items":[{"id":"` + armoredBear.ID + `"`,
+			},
+			NotExpectedContent: []string{"BearGrylls", "76561198000000001"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:   "admins find players with hidden names",
+			Method: http.MethodGet,
+			URL:    "/api/players/search?q=76561198000000001",
+			Headers: map[string]string{
+				"Authorization": createSuperuserToken(t, baseApp),
+			},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"name":"BearGrylls"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "too short a query returns nothing",
+			Method:          http.MethodGet,
+			URL:             "/api/players/search?q=b",
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`{"items":[]}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "players page searches by Steam ID",
+			Method:             http.MethodGet,
+			URL:                "/players?search=76561198995742956",
+			Headers:            map[string]string{"HX-Request": "true"},
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"Rabbit"},
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/config"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// rateLimitMiddlewareId identifies the per-IP rate limiter among the router's middlewares
+const rateLimitMiddlewareId = "sandstormRateLimit"
+
+// rateLimitSweepInterval is how often buckets of clients that have gone quiet are dropped
+const rateLimitSweepInterval = time.Minute
+
+// rateLimitExemptPrefixes are paths never throttled: the health check polled by monitoring and
+// updates, and static files, which are cheap to serve
+var rateLimitExemptPrefixes = []string{"/health", "/static/", "/_/"}
+
+// rateLimitConfigGetter is implemented by apps that configure the HTTP rate limit
+type rateLimitConfigGetter interface {
+	GetRateLimitConfig() config.RateLimitConfig
+}
+
+// registerRateLimit throttles each client IP with a token bucket when rateLimit.enabled is set.
+// Requests over the limit get a 429 with a Retry-After header. Superusers are never throttled.
+func registerRateLimit(app AppInterface, e *core.ServeEvent) {
+	getter, ok := app.(rateLimitConfigGetter)
+	if !ok || !getter.GetRateLimitConfig().Enabled {
+		return
+	}
+	cfg := getter.GetRateLimitConfig()
+	limiter := newRateLimiter(float64(cfg.RequestsPerMinuteOrDefault())/60, cfg.BurstOrDefault())
+
+	e.Router.Bind(&hook.Handler[*core.RequestEvent]{
+		Id:       rateLimitMiddlewareId,
+		Priority: apis.DefaultRateLimitMiddlewarePriority,
+		Func: func(re *core.RequestEvent) error {
+			for _, prefix := range rateLimitExemptPrefixes {
+				if strings.HasPrefix(re.Request.URL.Path, prefix) {
+					return re.Next()
+				}
+			}
+
+			// The cookie only exempts the request here; re.Auth is left alone so later handlers
+			// don't treat it as a superuser unless adminauth.RequireAdmin lets it through
+			auth := re.Auth
+			if auth == nil && re.Request.Method == http.MethodGet {
+				auth = adminauth.CookieAuth(re)
+			}
+			if auth != nil && auth.IsSuperuser() {
+				return re.Next()
+			}
+
+			if ok, retryAfter := limiter.allow(re.RealIP(), time.Now()); !ok {
+				re.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return re.TooManyRequestsError("Too many requests, slow down.", nil)
+			}
+			return re.Next()
+		},
+	})
+}
+
+// rateLimiter keeps a token bucket per client: each holds up to burst tokens, refilled at rate
+// tokens per second, and every request takes one
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the client's bucket. When it is empty, it reports false and how long
+// until the next token.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		l.sweep(now)
+	}
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled completely, which are the same as a new one
+func (l *rateLimiter) sweep(now time.Time) {
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.updated) >= full {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/config"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// mockRateLimitApp is a test app with a rate limit config
+type mockRateLimitApp struct {
+	mockRconApp
+	rateLimit config.RateLimitConfig
+}
+
+func (m *mockRateLimitApp) GetRateLimitConfig() config.RateLimitConfig {
+	return m.rateLimit
+}
+
+// TestRateLimit sends requests straight through the router from a few client IPs and checks one
+// IP is throttled once its burst is spent without affecting the others
+func TestRateLimit(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	superusers, err := testApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := testApp.Save(superuser); err != nil {
+		t.Fatalf("failed to create superuser: %v", err)
+	}
+	token, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create superuser token: %v", err)
+	}
+
+	router, err := apis.NewRouter(testApp)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	app := &mockRateLimitApp{
+		mockRconApp: mockRconApp{TestApp: testApp},
+		rateLimit:   config.RateLimitConfig{Enabled: true, RequestsPerMinute: 1, Burst: 3},
+	}
+	Register(app, &core.ServeEvent{App: testApp, Router: router})
+	mux, err := router.BuildMux()
+	if err != nil {
+		t.Fatalf("failed to build mux: %v", err)
+	}
+
+	get := func(path, ip, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":40000"
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The burst goes through, then the scraper is throttled
+	for i := range 3 {
+		if rec := get("/api/maps", "203.0.113.10", ""); rec.Code == http.StatusTooManyRequests {
+			t.Fatalf("request %d within the burst was throttled", i+1)
+		}
+	}
+	rec := get("/api/maps", "203.0.113.10", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once the burst is spent, got %d", rec.Code)
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Retry-After = %q, want 60 (one request per minute)", retryAfter)
+	}
+
+	// Other clients have their own bucket
+	if rec := get("/api/maps", "198.51.100.7", ""); rec.Code == http.StatusTooManyRequests {
+		t.Error("A different IP was throttled")
+	}
+
+	// The health check and superusers are exempt
+	if rec := get("/health", "203.0.113.10", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected /health to stay available, got %d", rec.Code)
+	}
+	if rec := get("/api/maps", "203.0.113.10", token); rec.Code == http.StatusTooManyRequests {
+		t.Error("A superuser was throttled")
+	}
+
+	// The web UI's auth cookie exempts page loads, but doesn't authenticate the rest of the
+	// request: the records API still wants a superuser token
+	cookie := adminauth.CookieName + "=" + url.QueryEscape(`{"token":"`+token+`","record":null}`)
+	req := httptest.NewRequest(http.MethodGet, "/api/collections/"+core.CollectionNameSuperusers+"/records", nil)
+	req.RemoteAddr = "203.0.113.10:40000"
+	req.Header.Set("Cookie", cookie)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code == http.StatusTooManyRequests {
+		t.Error("A superuser with the auth cookie was throttled")
+	}
+	if rec.Code == http.StatusOK {
+		t.Error("The auth cookie authenticated a records API request")
+	}
+}
+
+func TestRateLimiter_Refills(t *testing.T) {
+	limiter := newRateLimiter(1, 2) // one token per second, two at once
+	now := time.Now()
+
+	for i := range 2 {
+		if ok, _ := limiter.allow("client", now); !ok {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+	ok, wait := limiter.allow("client", now)
+	if ok {
+		t.Fatal("Expected the third request at once to be refused")
+	}
+	if wait != time.Second {
+		t.Errorf("Expected to wait 1s for a token, got %v", wait)
+	}
+
+	if ok, _ := limiter.allow("client", now.Add(time.Second)); !ok {
+		t.Error("Expected a token to be back after a second")
+	}
+
+	// Idle clients are forgotten once their bucket would be full again
+	limiter.allow("other", now)
+	limiter.allow("client", now.Add(time.Hour))
+	if _, ok := limiter.buckets["other"]; ok {
+		t.Error("Expected the idle client's bucket to be swept")
+	}
+}
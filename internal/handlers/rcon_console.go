@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+
+	"sandstorm-tracker/internal/adminauth"
+)
+
+// rconCommandPolicy is implemented by apps that restrict which console commands may run
+type rconCommandPolicy interface {
+	IsRconCommandAllowed(command string) bool
+}
+
+// rconCommandAllowed reports whether the console may run a command. Apps without a policy run
+// none, so a missing config cannot open the console up to every command.
+func rconCommandAllowed(app AppInterface, command string) bool {
+	policy, ok := app.(rconCommandPolicy)
+	return ok && policy.IsRconCommandAllowed(command)
+}
+
+// rconBroadcaster is implemented by apps that can fan an RCON command out to every server
+type rconBroadcaster interface {
+	BroadcastRconCommand(command string) map[string]error
+}
+
+// registerRconConsole registers the superuser-only RCON console page and API
+func registerRconConsole(app AppInterface, e *core.ServeEvent, registry *template.Registry) {
+	// RCON console page
+	e.Router.GET("/admin/rcon", func(re *core.RequestEvent) error {
+		servers, err := re.App.FindAllRecords("servers")
+		if err != nil {
+			servers = []*core.Record{}
+		}
+
+		html, err := renderPage(re, registry, "templates/rcon_console.html", map[string]any{
+			"ActivePage": "rcon",
+			"Servers":    servers,
+		})
+
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	}).Bind(adminauth.RequireAdmin())
+
+	// POST /api/server/{id}/rcon - Run an RCON command on a server (superusers only)
+	// {id} may be the server record ID or its external_id
+	e.Router.POST("/api/server/{id}/rcon", func(re *core.RequestEvent) error {
+		data := struct {
+			Command string `json:"command"`
+		}{}
+
+		if err := re.BindBody(&data); err != nil {
+			return re.BadRequestError("Invalid request body", err)
+		}
+
+		command := strings.TrimSpace(data.Command)
+		if command == "" {
+			return re.BadRequestError("command is required", nil)
+		}
+
+		id := re.Request.PathValue("id")
+		server, err := re.App.FindRecordById("servers", id)
+		if err != nil {
+			server, err = re.App.FindFirstRecordByData("servers", "external_id", id)
+			if err != nil {
+				return re.NotFoundError("Server not found", err)
+			}
+		}
+		serverID := server.GetString("external_id")
+
+		user := ""
+		if re.Auth != nil {
+			user = re.Auth.Email()
+		}
+		log := app.Logger().With("component", "RCON_CONSOLE")
+
+		if !rconCommandAllowed(app, command) {
+			log.Warn("Rejected RCON console command", "server_id", serverID, "command", command, "user", user)
+			return re.ForbiddenError("Command is not allowed by the rconConsole configuration", nil)
+		}
+
+		log.Info("Executing RCON console command", "server_id", serverID, "command", command, "user", user)
+
+		response, err := app.SendRconCommand(serverID, command)
+		if err != nil {
+			log.Warn("RCON console command failed", "server_id", serverID, "command", command, "user", user, "error", err)
+			return re.JSON(http.StatusBadGateway, map[string]any{
+				"server":  serverID,
+				"command": command,
+				"error":   err.Error(),
+			})
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"server":   serverID,
+			"command":  command,
+			"response": response,
+		})
+	}).Bind(adminauth.RequireAdmin())
+
+	// POST /api/rcon/broadcast - Run an RCON command on every configured server (superusers only)
+	e.Router.POST("/api/rcon/broadcast", func(re *core.RequestEvent) error {
+		data := struct {
+			Command string `json:"command"`
+		}{}
+
+		if err := re.BindBody(&data); err != nil {
+			return re.BadRequestError("Invalid request body", err)
+		}
+
+		command := strings.TrimSpace(data.Command)
+		if command == "" {
+			return re.BadRequestError("command is required", nil)
+		}
+
+		broadcaster, ok := app.(rconBroadcaster)
+		if !ok {
+			return re.InternalServerError("RCON broadcast is not available", nil)
+		}
+
+		user := ""
+		if re.Auth != nil {
+			user = re.Auth.Email()
+		}
+		log := app.Logger().With("component", "RCON_CONSOLE")
+
+		if !rconCommandAllowed(app, command) {
+			log.Warn("Rejected RCON broadcast command", "command", command, "user", user)
+			return re.ForbiddenError("Command is not allowed by the rconConsole configuration", nil)
+		}
+
+		log.Info("Broadcasting RCON command", "command", command, "user", user)
+
+		results := make(map[string]any)
+		for serverID, err := range broadcaster.BroadcastRconCommand(command) {
+			if err != nil {
+				results[serverID] = map[string]any{"ok": false, "error": err.Error()}
+				continue
+			}
+			results[serverID] = map[string]any{"ok": true}
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"command": command,
+			"results": results,
+		})
+	}).Bind(adminauth.RequireAdmin())
+}
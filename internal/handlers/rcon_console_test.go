@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// mockRconApp is a test app whose RCON connection returns canned output
+type mockRconApp struct {
+	*tests.TestApp
+	responses map[string]string // command -> response
+	sent      []string          // "serverID:command" for every command that reached RCON
+	denied    []string          // commands rejected by the console policy
+}
+
+func (m *mockRconApp) SendRconCommand(serverID string, command string) (string, error) {
+	m.sent = append(m.sent, serverID+":"+command)
+	if response, ok := m.responses[command]; ok {
+		return response, nil
+	}
+	return "", fmt.Errorf("rcon connection refused")
+}
+
+func (m *mockRconApp) BroadcastRconCommand(command string) map[string]error {
+	m.sent = append(m.sent, "*:"+command)
+	return map[string]error{
+		"server-a": nil,
+		"server-b": fmt.Errorf("dial tcp: connection refused"),
+	}
+}
+
+func (m *mockRconApp) IsRconCommandAllowed(command string) bool {
+	for _, d := range m.denied {
+		if strings.HasPrefix(command, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// mockUnrestrictedApp sends RCON commands without a console policy
+type mockUnrestrictedApp struct {
+	*tests.TestApp
+	sent []string
+}
+
+func (m *mockUnrestrictedApp) SendRconCommand(serverID string, command string) (string, error) {
+	m.sent = append(m.sent, serverID+":"+command)
+	return "", nil
+}
+
+func TestRconConsoleEndpoint(t *testing.T) {
+	// Seed a data dir with a server and a superuser; every scenario gets its own copy
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	serverRecordID, err := database.GetOrCreateServer(context.Background(), baseApp, "rcon-console-server", "Console Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	superusers, err := baseApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatalf("failed to find superusers collection: %v", err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := baseApp.Save(superuser); err != nil {
+		t.Fatalf("failed to create superuser: %v", err)
+	}
+	superuserToken, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatalf("failed to create superuser token: %v", err)
+	}
+
+	var mock *mockRconApp
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		mock = &mockRconApp{
+			TestApp: testApp,
+			responses: map[string]string{
+				"listplayers": "ID | Name | NetID\n0 | ArmoredBear | 76561198995742987",
+			},
+			denied: []string{"quit"},
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(mock, e)
+	}
+
+	auth := map[string]string{"Authorization": superuserToken}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "unauthenticated request is rejected",
+			Method:          http.MethodPost,
+			URL:             "/api/server/rcon-console-server/rcon",
+			Body:            strings.NewReader(`{"command":"listplayers"}`),
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if len(mock.sent) != 0 {
+					t.Errorf("expected no RCON commands, got %v", mock.sent)
+				}
+			},
+		},
+		{
+			Name:            "superuser command response is proxied",
+			Method:          http.MethodPost,
+			URL:             "/api/server/rcon-console-server/rcon",
+			Body:            strings.NewReader(`{"command":"listplayers"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"response":"ID | Name | NetID\n0 | ArmoredBear | 76561198995742987"`, `"server":"rcon-console-server"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if len(mock.sent) != 1 || mock.sent[0] != "rcon-console-server:listplayers" {
+					t.Errorf("expected listplayers to be sent once, got %v", mock.sent)
+				}
+			},
+		},
+		{
+			Name:            "server can be addressed by record id",
+			Method:          http.MethodPost,
+			URL:             "/api/server/" + serverRecordID + "/rcon",
+			Body:            strings.NewReader(`{"command":"listplayers"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"server":"rcon-console-server"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "denied command is not sent",
+			Method:          http.MethodPost,
+			URL:             "/api/server/rcon-console-server/rcon",
+			Body:            strings.NewReader(`{"command":"quit"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusForbidden,
+			ExpectedContent: []string{"not allowed"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if len(mock.sent) != 0 {
+					t.Errorf("expected no RCON commands, got %v", mock.sent)
+				}
+			},
+		},
+		{
+			Name:            "commands are denied without a console policy",
+			Method:          http.MethodPost,
+			URL:             "/api/server/rcon-console-server/rcon",
+			Body:            strings.NewReader(`{"command":"listplayers"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusForbidden,
+			ExpectedContent: []string{"not allowed"},
+			TestAppFactory:  setup,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				unrestricted := &mockUnrestrictedApp{TestApp: app}
+				Register(unrestricted, e)
+				t.Cleanup(func() {
+					if len(unrestricted.sent) != 0 {
+						t.Errorf("expected no RCON commands, got %v", unrestricted.sent)
+					}
+				})
+			},
+		},
+		{
+			Name:            "rcon failure is reported",
+			Method:          http.MethodPost,
+			URL:             "/api/server/rcon-console-server/rcon",
+			Body:            strings.NewReader(`{"command":"travel Town"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusBadGateway,
+			ExpectedContent: []string{`"error":"rcon connection refused"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "unknown server",
+			Method:          http.MethodPost,
+			URL:             "/api/server/missing/rcon",
+			Body:            strings.NewReader(`{"command":"listplayers"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "broadcast reports per-server results",
+			Method:          http.MethodPost,
+			URL:             "/api/rcon/broadcast",
+			Body:            strings.NewReader(`{"command":"say hello"}`),
+			Headers:         auth,
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"server-a":{"ok":true}`, `"server-b":{"error":"dial tcp: connection refused","ok":false}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "broadcast requires superuser",
+			Method:          http.MethodPost,
+			URL:             "/api/rcon/broadcast",
+			Body:            strings.NewReader(`{"command":"say hello"}`),
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if len(mock.sent) != 0 {
+					t.Errorf("expected no RCON commands, got %v", mock.sent)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
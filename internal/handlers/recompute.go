@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RecomputeResult reports what a stats rebuild replayed and rewrote
+type RecomputeResult struct {
+	Matches        int `json:"matches"`
+	EventsReplayed int `json:"eventsReplayed"`
+	RowsRewritten  int `json:"rowsRewritten"` // match_player_stats and match_weapon_stats rows after the rebuild
+}
+
+// ErrEventsPruned is returned when rebuilding a match whose events were deleted by the retention job
+var ErrEventsPruned = errors.New("match events have been pruned")
+
+// RecomputeMatchStats rebuilds a match's match_player_stats, match_weapon_stats and objective
+// timeline from its stored events, replacing the existing aggregates. Kills, assists, objectives and weapon fire are replayed through
+// the same code the event hooks use, so a fixed handler also fixes historical matches.
+// Run it inside a transaction so a failed rebuild leaves the old aggregates in place.
+// Matches whose events were pruned are refused with ErrEventsPruned.
+func RecomputeMatchStats(ctx context.Context, app core.App, matchID string) (RecomputeResult, error) {
+	log := app.Logger().With("component", "RECOMPUTE")
+	result := RecomputeResult{Matches: 1}
+
+	match, err := app.FindRecordById("matches", matchID)
+	if err != nil {
+		return result, fmt.Errorf("failed to find match %s: %w", matchID, err)
+	}
+	if match.GetBool("events_pruned") {
+		return result, fmt.Errorf("match %s: %w", matchID, ErrEventsPruned)
+	}
+
+	records, err := database.FindMatchStatEvents(ctx, app, matchID)
+	if err != nil {
+		return result, fmt.Errorf("failed to load events for match %s: %w", matchID, err)
+	}
+
+	if _, err := database.ResetMatchDerivedStats(ctx, app, matchID); err != nil {
+		return result, fmt.Errorf("failed to reset stats for match %s: %w", matchID, err)
+	}
+
+	for _, record := range records {
+		switch record.GetString("type") {
+		case events.TypePlayerKill:
+			killevent := &Killevent{}
+			killevent.SetProxyRecord(record)
+			err = applyKillStats(ctx, app, log, matchID, killevent)
+		case events.TypeKillAssist:
+			var data events.KillAssistData
+			if err := json.Unmarshal([]byte(record.GetString("data")), &data); err != nil {
+				log.Debug("Skipping unreadable kill assist event", "event", record.Id, "error", err)
+				continue
+			}
+			err = applyAssistStats(ctx, app, matchID, data)
+		case events.TypeObjectiveCaptured:
+			var data events.ObjectiveCapturedData
+			if err := json.Unmarshal([]byte(record.GetString("data")), &data); err != nil {
+				log.Debug("Skipping unreadable objective captured event", "event", record.Id, "error", err)
+				continue
+			}
+			err = applyObjectiveStats(ctx, app, matchID, data.Players, data.CapturingTeam, "objectives_captured")
+			if err == nil {
+				err = recordObjectiveTimeline(ctx, app, matchID, record, database.ObjectiveCaptured, data.Objective, data.CapturingTeam, data.Players, data.Timestamp)
+			}
+		case events.TypeObjectiveDestroyed:
+			var data events.ObjectiveDestroyedData
+			if err := json.Unmarshal([]byte(record.GetString("data")), &data); err != nil {
+				log.Debug("Skipping unreadable objective destroyed event", "event", record.Id, "error", err)
+				continue
+			}
+			err = applyObjectiveStats(ctx, app, matchID, data.Players, data.DestroyingTeam, "objectives_destroyed")
+			if err == nil {
+				err = recordObjectiveTimeline(ctx, app, matchID, record, database.ObjectiveDestroyed, data.Objective, data.DestroyingTeam, data.Players, data.Timestamp)
+			}
+		case events.TypeWeaponFire:
+			var data events.WeaponFireData
+			if err := json.Unmarshal([]byte(record.GetString("data")), &data); err != nil {
+				log.Debug("Skipping unreadable weapon fire event", "event", record.Id, "error", err)
+				continue
+			}
+			err = applyWeaponFireStats(ctx, app, matchID, data)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to replay event %s: %w", record.Id, err)
+		}
+		result.EventsReplayed++
+	}
+
+	rows, err := database.CountMatchStatRows(ctx, app, matchID)
+	if err != nil {
+		return result, err
+	}
+	result.RowsRewritten = rows
+
+	log.Info("Recomputed match stats", "match", matchID, "events", result.EventsReplayed, "rows", result.RowsRewritten)
+	return result, nil
+}
+
+// registerRecompute registers the stats rebuild endpoint
+func registerRecompute(app AppInterface, e *core.ServeEvent) {
+	// POST /api/admin/recompute?match= or ?server= - Rebuild match stats from stored events (superusers only)
+	// server accepts the server record ID or its external_id and rebuilds every match on it that still has its events
+	e.Router.POST("/api/admin/recompute", func(re *core.RequestEvent) error {
+		matchID := re.Request.URL.Query().Get("match")
+		serverID := re.Request.URL.Query().Get("server")
+
+		var matchIDs []string
+		switch {
+		case matchID != "":
+			match, err := re.App.FindRecordById("matches", matchID)
+			if err != nil {
+				return re.NotFoundError("Match not found", err)
+			}
+			if match.GetBool("events_pruned") {
+				return re.BadRequestError("This match's events have been pruned, so its stats can't be rebuilt", nil)
+			}
+			matchIDs = []string{match.Id}
+		case serverID != "":
+			server, err := findRecordByIdOrExternalID(re.App, "servers", serverID)
+			if err != nil {
+				return re.NotFoundError("Server not found", err)
+			}
+			// Matches whose events have been pruned are skipped
+			matches, err := re.App.FindRecordsByFilter("matches", "server = {:server} && events_pruned = false", "created", -1, 0, map[string]any{"server": server.Id})
+			if err != nil {
+				return re.InternalServerError("Failed to load matches", err)
+			}
+			for _, match := range matches {
+				matchIDs = append(matchIDs, match.Id)
+			}
+		default:
+			return re.BadRequestError("match or server is required", nil)
+		}
+
+		total := RecomputeResult{}
+		err := re.App.RunInTransaction(func(txApp core.App) error {
+			for _, id := range matchIDs {
+				result, err := RecomputeMatchStats(re.Request.Context(), txApp, id)
+				if err != nil {
+					return err
+				}
+				// Re-apply the ranked minimum so the rebuilt stats count (or not) under the current config
+				if err := database.UpdateMatchPeakPlayers(re.Request.Context(), txApp, id, minRankedPlayers(app)); err != nil {
+					return err
+				}
+				total.Matches += result.Matches
+				total.EventsReplayed += result.EventsReplayed
+				total.RowsRewritten += result.RowsRewritten
+			}
+			return nil
+		})
+		if err != nil {
+			return re.InternalServerError("Failed to recompute stats", err)
+		}
+
+		return re.JSON(http.StatusOK, total)
+	}).Bind(adminauth.RequireAdmin())
+}
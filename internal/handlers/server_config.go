@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/servermgr"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// sawPathGetter is implemented by apps set up from a Sandstorm Admin Wrapper install
+type sawPathGetter interface {
+	GetSAWPath() string
+}
+
+// registerServerConfig registers the page and API showing the config files deployed to a server
+func registerServerConfig(app AppInterface, e *core.ServeEvent, registry *template.Registry) {
+	// sawServer resolves the server named by the request's id (record ID or external_id) and the
+	// SAW install its config files live in
+	sawServer := func(re *core.RequestEvent) (*core.Record, string, error) {
+		server, err := findRecordByIdOrExternalID(re.App, "servers", re.Request.PathValue("id"))
+		if err != nil {
+			return nil, "", re.NotFoundError("Server not found", err)
+		}
+
+		sawPath := ""
+		if getter, ok := app.(sawPathGetter); ok {
+			sawPath = getter.GetSAWPath()
+		}
+		if sawPath == "" {
+			return nil, "", re.NotFoundError("Server config files are only available with sawPath set", nil)
+		}
+		return server, sawPath, nil
+	}
+
+	// serverFiles reads the config files of the request's server
+	serverFiles := func(re *core.RequestEvent) (*core.Record, *servermgr.ServerFiles, error) {
+		server, sawPath, err := sawServer(re)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files, err := servermgr.ReadServerFiles(sawPath, server.GetString("external_id"))
+		if err != nil {
+			return nil, nil, re.InternalServerError("Failed to read server config files", err)
+		}
+		return server, files, nil
+	}
+
+	// managedFile returns the managed file named by the request's name, or a 404 for any other
+	managedFile := func(re *core.RequestEvent) (string, error) {
+		name := re.Request.PathValue("name")
+		if !slices.Contains(servermgr.ManagedServerFiles, name) {
+			return "", re.NotFoundError("Not a managed server config file", nil)
+		}
+		return name, nil
+	}
+
+	// Server config page (superusers only)
+	e.Router.GET("/admin/servers/{id}/config", func(re *core.RequestEvent) error {
+		server, files, err := serverFiles(re)
+		if err != nil {
+			return err
+		}
+
+		html, err := renderPage(re, registry, "templates/server_config.html", map[string]any{
+			"ActivePage":    "servers",
+			"Server":        server,
+			"Files":         files,
+			"ManagedFiles":  servermgr.ManagedServerFiles,
+			"ReadOnlyFiles": servermgr.ReadOnlyServerFiles,
+		})
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	}).Bind(adminauth.RequireAdmin())
+
+	// GET /api/server/{id}/config - The server's parsed MapCycle.txt, Motd.txt and Admins.txt,
+	// and the mutators it is launched with (superusers only)
+	e.Router.GET("/api/server/{id}/config", func(re *core.RequestEvent) error {
+		_, files, err := serverFiles(re)
+		if err != nil {
+			return err
+		}
+		return re.JSON(http.StatusOK, files)
+	}).Bind(adminauth.RequireAdmin())
+
+	// GET /api/server/{id}/config/files/{name} - The raw content of one of the files deployed to
+	// the server on start (superusers only)
+	e.Router.GET("/api/server/{id}/config/files/{name}", func(re *core.RequestEvent) error {
+		name, err := managedFile(re)
+		if err != nil {
+			return err
+		}
+		server, sawPath, err := sawServer(re)
+		if err != nil {
+			return err
+		}
+
+		content, exists, err := servermgr.ReadServerFile(sawPath, server.GetString("external_id"), name)
+		if err != nil {
+			return re.InternalServerError("Failed to read server config file", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"name":    name,
+			"content": content,
+			"exists":  exists,
+		})
+	}).Bind(adminauth.RequireAdmin())
+
+	// PUT /api/server/{id}/config/files/{name} - Validate and save one of the files deployed to the
+	// server, backing up the previous version. The game has no RCON command to reload them, so the
+	// change applies on the server's next start. Bans.txt is read-only; bans are edited through the
+	// bans collection (superusers only).
+	e.Router.PUT("/api/server/{id}/config/files/{name}", func(re *core.RequestEvent) error {
+		name, err := managedFile(re)
+		if err != nil {
+			return err
+		}
+		server, sawPath, err := sawServer(re)
+		if err != nil {
+			return err
+		}
+
+		data := struct {
+			Content string `json:"content"`
+		}{}
+		if err := re.BindBody(&data); err != nil {
+			return re.BadRequestError("Invalid request body", err)
+		}
+
+		backup, err := servermgr.WriteServerFile(sawPath, server.GetString("external_id"), name, data.Content)
+		if err != nil {
+			var validationErr *servermgr.FileValidationError
+			if errors.As(err, &validationErr) {
+				return re.BadRequestError(validationErr.Error(), nil)
+			}
+			if errors.Is(err, servermgr.ErrReadOnlyServerFile) {
+				return re.BadRequestError(name+" is written from the bans collection; add or remove bans there instead", nil)
+			}
+			return re.InternalServerError("Failed to save server config file", err)
+		}
+
+		re.App.Logger().Info("Saved server config file", "server_id", server.GetString("external_id"),
+			"file", name, "backup", backup)
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"name":       name,
+			"backup":     backup,
+			"applies_on": "next_start",
+		})
+	}).Bind(adminauth.RequireAdmin())
+}
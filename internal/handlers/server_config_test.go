@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// mockSAWApp is a mock app set up from a SAW install at sawPath
+type mockSAWApp struct {
+	*mockRconApp
+	sawPath string
+}
+
+func (m *mockSAWApp) GetSAWPath() string {
+	return m.sawPath
+}
+
+// TestServerConfigEndpoint checks a server's config files are read from a sample SAW install,
+// and that saving one validates it before writing it
+func TestServerConfigEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	if _, err := database.GetOrCreateServer(context.Background(), baseApp, "server-1", "Test Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	sawPath := t.TempDir()
+	serverDir := filepath.Join(sawPath, "server-config", "server-1")
+	configDir := filepath.Join(sawPath, "admin-interface", "config")
+	for _, dir := range []string{serverDir, configDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, content := range map[string]string{
+		filepath.Join(serverDir, "MapCycle.txt"):        "(Scenario=\"Scenario_Crossing_Checkpoint_Security\",Lighting=\"Night\")\n",
+		filepath.Join(serverDir, "Motd.txt"):            "Welcome\n",
+		filepath.Join(configDir, "server-configs.json"): `{"server-1": {"server_mutators": ["Hardcore"]}}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token := createSuperuserToken(t, baseApp)
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerWithSAW := func(sawPath string) func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		return func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+			Register(&mockSAWApp{mockRconApp: &mockRconApp{TestApp: app}, sawPath: sawPath}, e)
+		}
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "returns the parsed config files",
+			Method:         http.MethodGet,
+			URL:            "/api/server/server-1/config",
+			Headers:        map[string]string{"Authorization": token},
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"server_id":"server-1"`,
+				`"map_cycle":[{"scenario":"Scenario_Crossing_Checkpoint_Security","lighting":"Night"}]`,
+				`"motd":"Welcome"`,
+				`"admins":[]`,
+				`"mutators":["Hardcore"]`,
+				`"missing":["Admins.txt"]`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerWithSAW(sawPath),
+		},
+		{
+			Name:            "page lists the map cycle",
+			Method:          http.MethodGet,
+			URL:             "/admin/servers/server-1/config",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"Scenario_Crossing_Checkpoint_Security", "Hardcore", "Admins.txt"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+		},
+		{
+			Name:            "requires a superuser",
+			Method:          http.MethodGet,
+			URL:             "/api/server/server-1/config",
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+		},
+		{
+			Name:            "not found without a SAW install",
+			Method:          http.MethodGet,
+			URL:             "/api/server/server-1/config",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(""),
+		},
+		{
+			Name:           "saves a valid config file",
+			Method:         http.MethodPut,
+			URL:            "/api/server/server-1/config/files/Game.ini",
+			Body:           strings.NewReader(`{"content": "[/Script/Insurgency.INSGameMode]\nbKillFeed=True\n"}`),
+			Headers:        map[string]string{"Authorization": token, "Content-Type": "application/json"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"name":"Game.ini"`,
+				`"applies_on":"next_start"`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerWithSAW(sawPath),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				data, err := os.ReadFile(filepath.Join(serverDir, "Game.ini"))
+				if err != nil || string(data) != "[/Script/Insurgency.INSGameMode]\nbKillFeed=True\n" {
+					t.Errorf("Game.ini = %q (%v), want the saved content", data, err)
+				}
+			},
+		},
+		{
+			Name:            "reads a config file",
+			Method:          http.MethodGet,
+			URL:             "/api/server/server-1/config/files/Motd.txt",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`{"content":"Welcome\n","exists":true,"name":"Motd.txt"}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+		},
+		{
+			Name:            "rejects an invalid config file",
+			Method:          http.MethodPut,
+			URL:             "/api/server/server-1/config/files/Engine.ini",
+			Body:            strings.NewReader(`{"content": "bKillFeed=True\n"}`),
+			Headers:         map[string]string{"Authorization": token, "Content-Type": "application/json"},
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"outside of a [Section]"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if _, err := os.Stat(filepath.Join(serverDir, "Engine.ini")); !os.IsNotExist(err) {
+					t.Errorf("Engine.ini was written: %v", err)
+				}
+			},
+		},
+		{
+			Name:            "only managed files can be written",
+			Method:          http.MethodPut,
+			URL:             "/api/server/server-1/config/files/server-configs.json",
+			Body:            strings.NewReader(`{"content": "{}"}`),
+			Headers:         map[string]string{"Authorization": token, "Content-Type": "application/json"},
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+		},
+		{
+			Name:            "Bans.txt is read-only",
+			Method:          http.MethodPut,
+			URL:             "/api/server/server-1/config/files/Bans.txt",
+			Body:            strings.NewReader(`{"content": "76561198995742987\n"}`),
+			Headers:         map[string]string{"Authorization": token, "Content-Type": "application/json"},
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"written from the bans collection"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				if _, err := os.Stat(filepath.Join(serverDir, "Bans.txt")); !os.IsNotExist(err) {
+					t.Errorf("Bans.txt was written: %v", err)
+				}
+			},
+		},
+		{
+			Name:            "writing requires a superuser",
+			Method:          http.MethodPut,
+			URL:             "/api/server/server-1/config/files/Game.ini",
+			Body:            strings.NewReader(`{"content": ""}`),
+			Headers:         map[string]string{"Content-Type": "application/json"},
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+		},
+		{
+			Name:            "unknown server",
+			Method:          http.MethodGet,
+			URL:             "/api/server/nope/config",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerWithSAW(sawPath),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/rcon"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// rconPoolRegistrar is the part of rcon.ClientPool used to register servers live
+type rconPoolRegistrar interface {
+	AddServer(serverID string, config *rcon.ServerConfig)
+	RemoveServer(serverID string)
+}
+
+// a2sPoolRegistrar is the part of a2s.ServerPool used to register servers live
+type a2sPoolRegistrar interface {
+	AddServer(address string, name string)
+	RemoveServer(address string)
+}
+
+// serverRegistration is what a server record put in the pools, so it can be taken out again
+// after the record's addresses change or it is deleted
+type serverRegistration struct {
+	externalID   string
+	name         string
+	rconAddress  string
+	rconPassword string
+	queryAddress string
+}
+
+// ServerPoolSync keeps the RCON and A2S pools in step with servers records that carry their
+// own addresses, so servers added through the API or dashboard are monitored without a restart.
+// Records without addresses (servers from the config file) are left to the config.
+type ServerPoolSync struct {
+	app  core.App
+	rcon rconPoolRegistrar
+	a2s  a2sPoolRegistrar
+
+	mu         sync.Mutex
+	registered map[string]serverRegistration // Server record ID -> what it put in the pools
+}
+
+// NewServerPoolSync creates a sync for the given pools
+func NewServerPoolSync(app core.App, rconPool rconPoolRegistrar, a2sPool a2sPoolRegistrar) *ServerPoolSync {
+	return &ServerPoolSync{
+		app:        app,
+		rcon:       rconPool,
+		a2s:        a2sPool,
+		registered: make(map[string]serverRegistration),
+	}
+}
+
+// RegisterHooks validates server addresses on save and registers or deregisters servers
+// with the pools after records are created, updated or deleted
+func (s *ServerPoolSync) RegisterHooks() {
+	s.app.OnRecordValidate("servers").BindFunc(func(e *core.RecordEvent) error {
+		if err := ValidateServerAddresses(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	s.app.OnRecordAfterCreateSuccess("servers").BindFunc(func(e *core.RecordEvent) error {
+		s.sync(e.Record)
+		return e.Next()
+	})
+	s.app.OnRecordAfterUpdateSuccess("servers").BindFunc(func(e *core.RecordEvent) error {
+		s.sync(e.Record)
+		return e.Next()
+	})
+	s.app.OnRecordAfterDeleteSuccess("servers").BindFunc(func(e *core.RecordEvent) error {
+		s.deregister(e.Record.Id)
+		return e.Next()
+	})
+}
+
+// LoadServers registers every existing server record that has addresses, for use at startup
+func (s *ServerPoolSync) LoadServers() error {
+	records, err := s.app.FindRecordsByFilter("servers", "query_address != '' || rcon_address != ''", "", 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load servers: %w", err)
+	}
+	for _, record := range records {
+		s.sync(record)
+	}
+	return nil
+}
+
+func (s *ServerPoolSync) logger() *slog.Logger {
+	return s.app.Logger().With("component", "SERVERS")
+}
+
+// sync replaces whatever a server record put in the pools with its current addresses. Saves that
+// change nothing the pools use, like the watcher storing its log offset, leave the registration
+// alone so the RCON connection and A2S state survive them.
+func (s *ServerPoolSync) sync(record *core.Record) {
+	next := serverRegistration{
+		externalID:   record.GetString("external_id"),
+		name:         record.GetString("name"),
+		rconAddress:  strings.TrimSpace(record.GetString("rcon_address")),
+		rconPassword: record.GetString("rcon_password"),
+		queryAddress: strings.TrimSpace(record.GetString("query_address")),
+	}
+	// Same fallback as the config: query the RCON host when no query address is set
+	if next.queryAddress == "" {
+		next.queryAddress = next.rconAddress
+	}
+	if next.rconPassword == "" {
+		next.rconAddress = ""
+	}
+	enabled := record.GetBool("enabled") && next.queryAddress != ""
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.registered[record.Id]
+	if ok && enabled && prev == next {
+		return
+	}
+	if ok {
+		s.removeLocked(prev)
+		delete(s.registered, record.Id)
+	}
+
+	if !enabled {
+		return
+	}
+
+	if next.rconAddress != "" {
+		s.rcon.AddServer(next.externalID, &rcon.ServerConfig{
+			Address:  next.rconAddress,
+			Password: next.rconPassword,
+			Timeout:  5 * time.Second,
+		})
+	}
+	s.a2s.AddServer(next.queryAddress, next.name)
+
+	s.registered[record.Id] = next
+	s.logger().Info("Registered server with pools", "server", next.externalID,
+		"rcon", next.rconAddress, "query", next.queryAddress)
+}
+
+// deregister takes a deleted server record out of the pools
+func (s *ServerPoolSync) deregister(recordID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.registered[recordID]
+	if !ok {
+		return
+	}
+	s.removeLocked(prev)
+	delete(s.registered, recordID)
+	s.logger().Info("Deregistered server from pools", "server", prev.externalID)
+}
+
+func (s *ServerPoolSync) removeLocked(reg serverRegistration) {
+	if reg.rconAddress != "" {
+		s.rcon.RemoveServer(reg.externalID)
+	}
+	if reg.queryAddress != "" {
+		s.a2s.RemoveServer(reg.queryAddress)
+	}
+}
+
+// ValidateServerAddresses checks a server record's query and RCON addresses are host:port
+// and that an RCON address comes with a password
+func ValidateServerAddresses(record *core.Record) error {
+	for _, field := range []string{"query_address", "rcon_address"} {
+		address := strings.TrimSpace(record.GetString(field))
+		if address == "" {
+			continue
+		}
+		if err := validateHostPort(address); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	if strings.TrimSpace(record.GetString("rcon_address")) != "" && record.GetString("rcon_password") == "" {
+		return fmt.Errorf("rcon_password: required when rcon_address is set")
+	}
+	return nil
+}
+
+// validateHostPort checks an address is host:port with a port between 1 and 65535
+func validateHostPort(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q, expected host:port", address)
+	}
+	if host == "" {
+		return fmt.Errorf("invalid address %q, host is missing", address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid address %q, port must be between 1 and 65535", address)
+	}
+	return nil
+}
+
+// serverRequest is the body of the server create and update endpoints.
+// Fields left out of an update keep their current value.
+type serverRequest struct {
+	ExternalID   *string `json:"external_id"`
+	Name         *string `json:"name"`
+	Path         *string `json:"path"`
+	Group        *string `json:"group"`
+	Enabled      *bool   `json:"enabled"`
+	IsPublic     *bool   `json:"is_public"`
+	HideNames    *bool   `json:"hide_player_names"`
+	QueryAddress *string `json:"query_address"`
+	RconAddress  *string `json:"rcon_address"`
+	RconPassword *string `json:"rcon_password"`
+}
+
+// apply copies the fields set in the request onto a server record
+func (data serverRequest) apply(record *core.Record) {
+	setString := func(field string, value *string) {
+		if value != nil {
+			record.Set(field, strings.TrimSpace(*value))
+		}
+	}
+	setString("external_id", data.ExternalID)
+	setString("name", data.Name)
+	setString("path", data.Path)
+	setString("group", data.Group)
+	setString("query_address", data.QueryAddress)
+	setString("rcon_address", data.RconAddress)
+	if data.RconPassword != nil {
+		record.Set("rcon_password", *data.RconPassword)
+	}
+	if data.Enabled != nil {
+		record.Set("enabled", *data.Enabled)
+	}
+	if data.IsPublic != nil {
+		record.Set("is_public", *data.IsPublic)
+	}
+	if data.HideNames != nil {
+		record.Set("hide_player_names", *data.HideNames)
+	}
+}
+
+// registerServers registers the endpoints that create, update and delete servers (superusers only).
+// Saving goes through the usual record hooks, so the pools pick up changes straight away.
+func registerServers(e *core.ServeEvent) {
+	// POST /api/servers - Create a server; external_id, name and path are required
+	e.Router.POST("/api/servers", func(re *core.RequestEvent) error {
+		data := serverRequest{}
+		if err := re.BindBody(&data); err != nil {
+			return re.BadRequestError("Invalid request body", err)
+		}
+		if data.ExternalID == nil || strings.TrimSpace(*data.ExternalID) == "" {
+			return re.BadRequestError("external_id is required", nil)
+		}
+		if data.Name == nil || strings.TrimSpace(*data.Name) == "" {
+			return re.BadRequestError("name is required", nil)
+		}
+		if data.Path == nil || strings.TrimSpace(*data.Path) == "" {
+			return re.BadRequestError("path is required", nil)
+		}
+		if _, err := re.App.FindFirstRecordByData("servers", "external_id", strings.TrimSpace(*data.ExternalID)); err == nil {
+			return re.BadRequestError("A server with this external_id already exists", nil)
+		}
+
+		collection, err := re.App.FindCollectionByNameOrId("servers")
+		if err != nil {
+			return re.InternalServerError("Failed to load servers collection", err)
+		}
+		record := core.NewRecord(collection)
+		record.Set("enabled", true)
+		record.Set("is_public", true)
+		data.apply(record)
+
+		if err := re.App.Save(record); err != nil {
+			return re.BadRequestError("Failed to create server", err)
+		}
+
+		return re.JSON(http.StatusOK, record)
+	}).Bind(adminauth.RequireAdmin())
+
+	// PATCH /api/servers/{id} - Update a server; {id} may be the record ID or its external_id
+	e.Router.PATCH("/api/servers/{id}", func(re *core.RequestEvent) error {
+		record, err := findRecordByIdOrExternalID(re.App, "servers", re.Request.PathValue("id"))
+		if err != nil {
+			return re.NotFoundError("Server not found", err)
+		}
+
+		data := serverRequest{}
+		if err := re.BindBody(&data); err != nil {
+			return re.BadRequestError("Invalid request body", err)
+		}
+		if data.ExternalID != nil {
+			externalID := strings.TrimSpace(*data.ExternalID)
+			if externalID == "" {
+				return re.BadRequestError("external_id cannot be empty", nil)
+			}
+			if other, err := re.App.FindFirstRecordByData("servers", "external_id", externalID); err == nil && other.Id != record.Id {
+				return re.BadRequestError("A server with this external_id already exists", nil)
+			}
+		}
+		data.apply(record)
+
+		if err := re.App.Save(record); err != nil {
+			return re.BadRequestError("Failed to update server", err)
+		}
+
+		return re.JSON(http.StatusOK, record)
+	}).Bind(adminauth.RequireAdmin())
+
+	// DELETE /api/servers/{id} - Delete a server; {id} may be the record ID or its external_id
+	e.Router.DELETE("/api/servers/{id}", func(re *core.RequestEvent) error {
+		record, err := findRecordByIdOrExternalID(re.App, "servers", re.Request.PathValue("id"))
+		if err != nil {
+			return re.NotFoundError("Server not found", err)
+		}
+
+		if err := re.App.Delete(record); err != nil {
+			return re.BadRequestError("Failed to delete server", err)
+		}
+
+		return re.NoContent(http.StatusNoContent)
+	}).Bind(adminauth.RequireAdmin())
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// minStaleMatchIdle is the shortest idle timeout the endpoint accepts, so a typo cannot close the
+// matches of servers between rounds
+const minStaleMatchIdle = 15 * time.Minute
+
+// presenceConfigGetter is implemented by apps that configure connection and match idle timeouts
+type presenceConfigGetter interface {
+	GetPresenceConfig() config.PresenceConfig
+}
+
+// matchIdleTimeout returns how long an active match may go without activity, from the app's config
+func matchIdleTimeout(app any) time.Duration {
+	if getter, ok := app.(presenceConfigGetter); ok {
+		return getter.GetPresenceConfig().MatchIdleTimeout()
+	}
+	return config.PresenceConfig{}.MatchIdleTimeout()
+}
+
+// registerStaleMatches registers the endpoint that closes orphaned active matches
+func registerStaleMatches(app AppInterface, e *core.ServeEvent) {
+	// POST /api/admin/close-stale-matches?idle_minutes= - Close active matches with no activity for
+	// longer than the idle timeout (default: presence.matchIdleMinutes, at least 15), leaving the
+	// match of servers that answer A2S queries running (superusers only)
+	e.Router.POST("/api/admin/close-stale-matches", func(re *core.RequestEvent) error {
+		idle := matchIdleTimeout(app)
+		if raw := re.Request.URL.Query().Get("idle_minutes"); raw != "" {
+			minutes, err := strconv.Atoi(raw)
+			if err != nil || minutes < int(minStaleMatchIdle/time.Minute) {
+				return re.BadRequestError(fmt.Sprintf("idle_minutes must be a whole number of minutes, at least %d",
+					int(minStaleMatchIdle/time.Minute)), err)
+			}
+			idle = time.Duration(minutes) * time.Minute
+		}
+
+		online := map[string]bool{}
+		if provider, ok := app.(a2sSnapshotProvider); ok {
+			online = a2s.OnlineServers(provider.GetA2SSnapshots())
+		}
+
+		var closed []database.StaleMatch
+		err := re.App.RunInTransaction(func(txApp core.App) error {
+			var err error
+			closed, err = database.CloseStaleMatches(re.Request.Context(), txApp, idle, online, time.Now())
+			return err
+		})
+		if err != nil {
+			return re.InternalServerError("Failed to close stale matches", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{"closed": closed})
+	}).Bind(adminauth.RequireAdmin())
+}
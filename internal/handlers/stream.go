@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// streamBufferSize is how many updates a slow subscriber may fall behind before updates are dropped
+const streamBufferSize = 32
+
+// streamKeepAlive is how often an idle stream gets a comment line so proxies keep it open
+const streamKeepAlive = 15 * time.Second
+
+// LiveUpdate is one Server-Sent Event pushed to the live pages
+type LiveUpdate struct {
+	Type   string // SSE event name: "match", "score" or "event"
+	Data   any    // Marshalled to JSON as the event data
+	Server string // Record ID of the server the update is about, set by Publish
+}
+
+// LiveStream fans match, score and killfeed updates out to the browsers subscribed to a server
+type LiveStream struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan LiveUpdate]struct{} // Server record ID -> subscriber channels
+}
+
+// NewLiveStream creates a stream with no subscribers
+func NewLiveStream() *LiveStream {
+	return &LiveStream{
+		subscribers: make(map[string]map[chan LiveUpdate]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber for the updates of one or more servers, delivered on one channel.
+// The returned function unsubscribes and must be called when the subscriber goes away.
+func (s *LiveStream) Subscribe(serverIDs ...string) (<-chan LiveUpdate, func()) {
+	ch := make(chan LiveUpdate, streamBufferSize)
+
+	s.mu.Lock()
+	for _, serverID := range serverIDs {
+		if s.subscribers[serverID] == nil {
+			s.subscribers[serverID] = make(map[chan LiveUpdate]struct{})
+		}
+		s.subscribers[serverID][ch] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, serverID := range serverIDs {
+				delete(s.subscribers[serverID], ch)
+				if len(s.subscribers[serverID]) == 0 {
+					delete(s.subscribers, serverID)
+				}
+			}
+		})
+	}
+}
+
+// Publish sends an update to every subscriber of a server without blocking.
+// Subscribers whose buffer is full miss the update; the pages catch up on the next one or by polling.
+func (s *LiveStream) Publish(serverID string, update LiveUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update.Server = serverID
+	for ch := range s.subscribers[serverID] {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// hasSubscribers reports whether anyone is listening to a server
+func (s *LiveStream) hasSubscribers(serverID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers[serverID]) > 0
+}
+
+// hasAnySubscribers reports whether anyone is listening at all, for updates that need a query
+// to find their server
+func (s *LiveStream) hasAnySubscribers() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers) > 0
+}
+
+// playerNameKeys are the name and Steam ID keys of a player in event data, as written by the
+// event types and by the parser's kill events
+var playerNameKeys = [][2]string{{"player_name", "steam_id"}, {"Name", "SteamID"}}
+
+// liveUpdate returns an update the way a viewer of a server that hides player names may see it:
+// names in event data are numbered and Steam IDs and player record IDs are left out
+func (n *playerNames) liveUpdate(update LiveUpdate) LiveUpdate {
+	data, ok := update.Data.(map[string]any)
+	if !n.hide || !ok {
+		return update
+	}
+
+	// The update is shared by every subscriber, so change a copy
+	redacted := make(map[string]any, len(data))
+	for key, value := range data {
+		redacted[key] = value
+	}
+	switch update.Type {
+	case "event":
+		redacted["data"] = n.redact(data["data"])
+	case "score":
+		delete(redacted, "player")
+	}
+	return LiveUpdate{Type: update.Type, Data: redacted}
+}
+
+// redact copies event data with every player's name numbered and Steam ID removed. Bots, which
+// have no Steam ID, keep their names.
+func (n *playerNames) redact(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for key, v := range value {
+			copied[key] = n.redact(v)
+		}
+		for _, keys := range playerNameKeys {
+			name, ok := value[keys[0]].(string)
+			steamID, _ := value[keys[1]].(string)
+			if !ok || steamID == "INVALID" {
+				continue
+			}
+			copied[keys[0]] = n.name(steamID, name)
+			delete(copied, keys[1])
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, v := range value {
+			copied[i] = n.redact(v)
+		}
+		return copied
+	}
+	return value
+}
+
+// RegisterHooks publishes updates as events, matches and player stats are written
+func (s *LiveStream) RegisterHooks(app core.App) {
+	// Same OnRecordCreate path as the game event handlers; publish once the event is saved
+	app.OnRecordCreate("events").BindFunc(func(e *core.RecordEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		serverID := e.Record.GetString("server")
+		if !s.hasSubscribers(serverID) {
+			return nil
+		}
+
+		var data map[string]any
+		if err := json.Unmarshal([]byte(e.Record.GetString("data")), &data); err != nil {
+			return nil
+		}
+		// Replayed log lines are history, not live updates
+		if catchup, _ := data["is_catchup"].(bool); catchup {
+			return nil
+		}
+
+		s.Publish(serverID, LiveUpdate{Type: "event", Data: map[string]any{
+			"id":    e.Record.Id,
+			"type":  e.Record.GetString("type"),
+			"match": e.Record.GetString("match"),
+			"data":  data,
+		}})
+		return nil
+	})
+
+	publishMatch := func(e *core.RecordEvent) error {
+		serverID := e.Record.GetString("server")
+		if s.hasSubscribers(serverID) {
+			numObjectives := database.ResolveNumObjectives(e.App, e.Record)
+			s.Publish(serverID, LiveUpdate{Type: "match", Data: map[string]any{
+				"action":            e.Type,
+				"id":                e.Record.Id,
+				"map":               e.Record.GetString("map"),
+				"mode":              e.Record.GetString("mode"),
+				"round":             e.Record.GetInt("round"),
+				"round_objective":   e.Record.GetInt("round_objective"),
+				"num_objectives":    numObjectives,
+				"current_objective": currentObjectiveLabel(e.Record.GetInt("round_objective"), numObjectives),
+				"end_time":          e.Record.GetString("end_time"),
+			}})
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("matches").BindFunc(publishMatch)
+	app.OnRecordAfterUpdateSuccess("matches").BindFunc(publishMatch)
+
+	publishScore := func(e *core.RecordEvent) error {
+		// Stats are saved on every kill, so skip the match lookup while nobody is listening
+		if !s.hasAnySubscribers() {
+			return e.Next()
+		}
+		match, err := e.App.FindRecordById("matches", e.Record.GetString("match"))
+		if err != nil {
+			return e.Next()
+		}
+		serverID := match.GetString("server")
+		if s.hasSubscribers(serverID) {
+			s.Publish(serverID, LiveUpdate{Type: "score", Data: map[string]any{
+				"match":   match.Id,
+				"player":  e.Record.GetString("player"),
+				"team":    e.Record.GetString("team"),
+				"kills":   e.Record.GetInt("kills"),
+				"deaths":  e.Record.GetInt("deaths"),
+				"assists": e.Record.GetInt("assists"),
+				"score":   e.Record.GetInt("score"),
+			}})
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("match_player_stats").BindFunc(publishScore)
+	app.OnRecordAfterUpdateSuccess("match_player_stats").BindFunc(publishScore)
+}
+
+// registerLiveStream registers the Server-Sent Events endpoints the live pages subscribe to
+func registerLiveStream(e *core.ServeEvent) {
+	stream := NewLiveStream()
+	stream.RegisterHooks(e.App)
+
+	// GET /api/stream/{serverId} - Server-Sent Events for a server's match, score and killfeed updates
+	// serverId accepts the server record ID or its external_id
+	e.Router.GET("/api/stream/{serverId}", func(re *core.RequestEvent) error {
+		v := viewerOf(re)
+		server, err := findRecordByIdOrExternalID(re.App, "servers", re.Request.PathValue("serverId"))
+		if err != nil || !v.canSee(server) {
+			return re.NotFoundError("Server not found", err)
+		}
+		names := v.newPlayerNames(server)
+
+		updates, unsubscribe := stream.Subscribe(server.Id)
+		defer unsubscribe()
+
+		return serveLiveStream(re, updates, map[string]any{"server": server.Id}, func(update LiveUpdate) any {
+			return names.liveUpdate(update).Data
+		})
+	})
+
+	// GET /api/stream?servers={id},{id} - one Server-Sent Events stream for several servers, like
+	// the status page's cards. Each update's data carries the "server" it is about, as requested.
+	// Servers that don't exist or the viewer can't see are left out.
+	e.Router.GET("/api/stream", func(re *core.RequestEvent) error {
+		v := viewerOf(re)
+		requested := make(map[string]string)   // Server record ID -> ID as requested
+		names := make(map[string]*playerNames) // Server record ID -> names for the viewer
+		var serverIDs, requestedIDs []string
+		for _, id := range strings.Split(re.Request.URL.Query().Get("servers"), ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			server, err := findRecordByIdOrExternalID(re.App, "servers", id)
+			if err != nil || !v.canSee(server) {
+				continue
+			}
+			if _, ok := requested[server.Id]; ok {
+				continue
+			}
+			requested[server.Id] = id
+			names[server.Id] = v.newPlayerNames(server)
+			serverIDs = append(serverIDs, server.Id)
+			requestedIDs = append(requestedIDs, id)
+		}
+		if len(serverIDs) == 0 {
+			return re.NotFoundError("No servers found", nil)
+		}
+
+		updates, unsubscribe := stream.Subscribe(serverIDs...)
+		defer unsubscribe()
+
+		return serveLiveStream(re, updates, map[string]any{"servers": requestedIDs}, func(update LiveUpdate) any {
+			data, _ := names[update.Server].liveUpdate(update).Data.(map[string]any)
+			tagged := make(map[string]any, len(data)+1)
+			for key, value := range data {
+				tagged[key] = value
+			}
+			tagged["server"] = requested[update.Server]
+			return tagged
+		})
+	})
+}
+
+// serveLiveStream writes updates as Server-Sent Events until the client goes away, starting with
+// a connected event carrying connected. encode returns the data the viewer gets for an update.
+func serveLiveStream(re *core.RequestEvent, updates <-chan LiveUpdate, connected any, encode func(LiveUpdate) any) error {
+	re.Response.Header().Set("Content-Type", "text/event-stream")
+	re.Response.Header().Set("Cache-Control", "no-cache")
+	re.Response.Header().Set("Connection", "keep-alive")
+	re.Response.Header().Set("X-Accel-Buffering", "no")
+	re.Response.WriteHeader(http.StatusOK)
+
+	// Tell the browser it is connected so it can stop polling
+	payload, err := json.Marshal(connected)
+	if err != nil {
+		return nil
+	}
+	if _, err := fmt.Fprintf(re.Response, "event: connected\ndata: %s\n\n", payload); err != nil {
+		return nil
+	}
+	if err := re.Flush(); err != nil {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-re.Request.Context().Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(re.Response, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case update := <-updates:
+			payload, err := json.Marshal(encode(update))
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(re.Response, "event: %s\ndata: %s\n\n", update.Type, payload); err != nil {
+				return nil
+			}
+		}
+		if err := re.Flush(); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/parser"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestLiveStream_DeliversKill subscribes to a server's stream and checks a kill parsed afterwards is pushed to it
+func TestLiveStream_DeliversKill(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-stream"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Stream Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// Serve the real routes so the stream is read the way a browser reads it
+	router, err := apis.NewRouter(testApp)
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	Register(&mockRconApp{TestApp: testApp}, &core.ServeEvent{App: testApp, Router: router})
+	mux, err := router.BuildMux()
+	if err != nil {
+		t.Fatalf("failed to build mux: %v", err)
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	streamCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, server.URL+"/api/stream/"+serverExternalID, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", res.StatusCode)
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", contentType)
+	}
+
+	// readEvent returns the next event name and data from the stream, skipping comments
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() (string, string) {
+		t.Helper()
+		var name, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("stream ended before an event arrived: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "" && name != "":
+				return name, data
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	if name, _ := readEvent(); name != "connected" {
+		t.Fatalf("Expected the connected event first, got %q", name)
+	}
+
+	logParser := parser.NewLogParser(testApp, testApp.Logger())
+	line := "[2025.10.04-14.31.00:000][100]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419"
+	if err := logParser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+		t.Fatalf("failed to process log line: %v", err)
+	}
+
+	name, data := readEvent()
+	if name != "event" {
+		t.Fatalf("Expected an event message, got %q: %s", name, data)
+	}
+	var message struct {
+		Type string `json:"type"`
+		Data struct {
+			Killers []struct {
+				Name string
+			} `json:"killers"`
+			Weapon string `json:"weapon"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		t.Fatalf("failed to decode message %s: %v", data, err)
+	}
+	if message.Type != "player_kill" {
+		t.Errorf("Expected a player_kill event, got %q", message.Type)
+	}
+	if len(message.Data.Killers) != 1 || message.Data.Killers[0].Name != "ArmoredBear" {
+		t.Errorf("Expected ArmoredBear as the killer, got %+v", message.Data.Killers)
+	}
+	if message.Data.Weapon != "BP_Firearm_M16A4_C_2147481419" {
+		t.Errorf("Expected the M16A4, got %q", message.Data.Weapon)
+	}
+}
+
+func TestLiveStream_UnsubscribeRemovesSubscriber(t *testing.T) {
+	stream := NewLiveStream()
+
+	updates, unsubscribe := stream.Subscribe("server-1")
+	stream.Publish("server-1", LiveUpdate{Type: "match"})
+	if update := <-updates; update.Type != "match" {
+		t.Errorf("Expected a match update, got %q", update.Type)
+	}
+
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	if stream.hasSubscribers("server-1") {
+		t.Error("Expected no subscribers after unsubscribing")
+	}
+	// Publishing without subscribers must not block
+	stream.Publish("server-1", LiveUpdate{Type: "match"})
+}
+
+func TestLiveStream_SubscribeSeveralServers(t *testing.T) {
+	stream := NewLiveStream()
+	if stream.hasAnySubscribers() {
+		t.Error("Expected no subscribers on a new stream")
+	}
+
+	updates, unsubscribe := stream.Subscribe("server-1", "server-2")
+	stream.Publish("server-2", LiveUpdate{Type: "match"})
+	stream.Publish("server-3", LiveUpdate{Type: "match"})
+	stream.Publish("server-1", LiveUpdate{Type: "score"})
+
+	// Both servers' updates arrive on the one channel, tagged with their server
+	for _, want := range []LiveUpdate{{Type: "match", Server: "server-2"}, {Type: "score", Server: "server-1"}} {
+		if update := <-updates; update.Type != want.Type || update.Server != want.Server {
+			t.Errorf("Expected %+v, got %+v", want, update)
+		}
+	}
+	select {
+	case update := <-updates:
+		t.Errorf("Expected no update from an unsubscribed server, got %+v", update)
+	default:
+	}
+
+	unsubscribe()
+	if stream.hasAnySubscribers() {
+		t.Error("Expected no subscribers after unsubscribing")
+	}
+}
+
+// TestPlayerNames_LiveUpdate checks a kill streamed from a server that hides player names carries
+// numbered names and no Steam IDs, and leaves the update other subscribers get untouched
+func TestPlayerNames_LiveUpdate(t *testing.T) {
+	var data map[string]any
+	raw := `{"killers":[{"Name":"ArmoredBear","SteamID":"76561198995742987","Team":0}],` +
+		`"victim":{"Name":"Marksman","SteamID":"INVALID","Team":1},"weapon":"BP_Firearm_M16A4_C_2147481419"}`
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		t.Fatal(err)
+	}
+	update := LiveUpdate{Type: "event", Data: map[string]any{"type": "player_kill", "data": data}}
+
+	names := &playerNames{hide: true, numbers: make(map[string]int)}
+	payload, err := json.Marshal(names.liveUpdate(update).Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"killers":[{"Name":"Player #1","Team":0}],` +
+		`"victim":{"Name":"Marksman","SteamID":"INVALID","Team":1},"weapon":"BP_Firearm_M16A4_C_2147481419"},"type":"player_kill"}`
+	if string(payload) != want {
+		t.Errorf("liveUpdate() = %s, want %s", payload, want)
+	}
+
+	original, _ := json.Marshal(update.Data)
+	if !strings.Contains(string(original), "ArmoredBear") {
+		t.Errorf("liveUpdate() changed the shared update: %s", original)
+	}
+
+	score := names.liveUpdate(LiveUpdate{Type: "score", Data: map[string]any{"player": "abc", "kills": 3}})
+	if _, ok := score.Data.(map[string]any)["player"]; ok {
+		t.Errorf("liveUpdate() kept the player record ID of a score: %v", score.Data)
+	}
+}
+
+// TestLiveStream_MatchUpdateLabelsObjective checks a match update carries the objective label the
+// page shows, so a round past its last objective reads "All captured" rather than a stray letter
+func TestLiveStream_MatchUpdateLabelsObjective(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID, err := database.GetOrCreateServer(ctx, testApp, "test-server-objective", "Objective Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	match, err := database.CreateMatch(ctx, testApp, "test-server-objective", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	record, err := testApp.FindRecordById("matches", match.ID)
+	if err != nil {
+		t.Fatalf("failed to find match: %v", err)
+	}
+
+	stream := NewLiveStream()
+	stream.RegisterHooks(testApp)
+	updates, unsubscribe := stream.Subscribe(serverID)
+	defer unsubscribe()
+
+	for _, tc := range []struct {
+		roundObjective int
+		want           string
+	}{{1, "B"}, {3, allObjectivesCapturedLabel}} {
+		record.Set("num_objectives", 3)
+		record.Set("round_objective", tc.roundObjective)
+		if err := testApp.Save(record); err != nil {
+			t.Fatalf("failed to save match: %v", err)
+		}
+		update := <-updates
+		if got := update.Data.(map[string]any)["current_objective"]; got != tc.want {
+			t.Errorf("current_objective at objective %d = %v, want %q", tc.roundObjective, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+
+	"sandstorm-tracker/internal/adminauth"
+)
+
+// viewer is what the person loading a public page may see of each server. Servers with
+// is_public off are left out of the public pages, and servers with hide_player_names on show
+// "Player #n" instead of names. Superusers see everything.
+type viewer struct {
+	admin bool
+}
+
+// viewerOf returns the viewer of a request: an admin when a superuser is authenticated by
+// header or, on page loads, by the web UI's auth cookie
+func viewerOf(re *core.RequestEvent) viewer {
+	auth := re.Auth
+	if auth == nil && re.Request.Method == http.MethodGet {
+		auth = adminauth.CookieAuth(re)
+	}
+	return viewer{admin: auth != nil && auth.IsSuperuser()}
+}
+
+// canSee reports whether the viewer may see the server at all
+func (v viewer) canSee(server *core.Record) bool {
+	return v.admin || server.GetBool("is_public")
+}
+
+// hidesNames reports whether player names on the server are hidden from the viewer
+func (v viewer) hidesNames(server *core.Record) bool {
+	return !v.admin && server.GetBool("hide_player_names")
+}
+
+// visibleServers returns the servers the viewer may see, in order
+func (v viewer) visibleServers(servers []*core.Record) []*core.Record {
+	if v.admin {
+		return servers
+	}
+	visible := make([]*core.Record, 0, len(servers))
+	for _, server := range servers {
+		if v.canSee(server) {
+			visible = append(visible, server)
+		}
+	}
+	return visible
+}
+
+// matchFilter narrows a matches filter to the viewer's servers
+func (v viewer) matchFilter(filter string) string {
+	if v.admin {
+		return filter
+	}
+	if filter == "" {
+		return "server.is_public = true"
+	}
+	return "(" + filter + ") && server.is_public = true"
+}
+
+// statsFilter narrows a match_player_stats or match_weapon_stats filter to the matches of servers
+// whose players the viewer may see by name
+func (v viewer) statsFilter(filter string) string {
+	if v.admin {
+		return filter
+	}
+	return "(" + filter + ") && match.server.is_public = true && match.server.hide_player_names = false"
+}
+
+// playerNames stands in for player names on a server that hides them, numbering players
+// "Player #1", "Player #2", ... in the order they are first shown
+type playerNames struct {
+	hide    bool
+	numbers map[string]int // Steam ID (or name when there is none) -> number
+}
+
+// newPlayerNames returns the names to show for the server's players
+func (v viewer) newPlayerNames(server *core.Record) *playerNames {
+	return &playerNames{hide: v.hidesNames(server), numbers: make(map[string]int)}
+}
+
+// name returns the name to show for a player, keyed by its Steam ID when it has one so the
+// same player keeps one number across a page
+func (n *playerNames) name(steamID, name string) string {
+	if !n.hide {
+		return name
+	}
+	key := steamID
+	if key == "" {
+		key = "name:" + name
+	}
+	number, ok := n.numbers[key]
+	if !ok {
+		number = len(n.numbers) + 1
+		n.numbers[key] = number
+	}
+	return fmt.Sprintf("Player #%d", number)
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestServerVisibility checks a private server is left out of the public pages but shown to an
+// authenticated admin, and that a server hiding player names shows "Player #n" instead
+func TestServerVisibility(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+
+	createServer := func(externalID, name string, isPublic, hideNames bool) string {
+		t.Helper()
+		serverID, err := database.GetOrCreateServer(ctx, baseApp, externalID, name, "test/path")
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		server, err := baseApp.FindRecordById("servers", serverID)
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		server.Set("is_public", isPublic)
+		server.Set("hide_player_names", hideNames)
+		if err := baseApp.Save(server); err != nil {
+			t.Fatalf("failed to save server: %v", err)
+		}
+		return serverID
+	}
+
+	createServer("test-server-public", "Public Server", true, false)
+	privateServer := createServer("test-server-private", "Private Server", false, false)
+	anonymousServer := createServer("test-server-anonymous", "Anonymous Server", true, true)
+
+	mapName, scenario := "Town", "Scenario_Hideout_Checkpoint_Security"
+	startTime := time.Now()
+	match, err := database.CreateMatch(ctx, baseApp, "test-server-anonymous", &mapName, &scenario, &startTime)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	player, err := database.CreatePlayer(ctx, baseApp, "76561198995742987", "ArmoredBear")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	if err := database.UpsertMatchPlayerStats(ctx, baseApp, match.ID, player.ID, nil, &startTime); err != nil {
+		t.Fatalf("failed to create match stats: %v", err)
+	}
+
+	// A finished match with kills, for the pages that list players across servers
+	earlier := startTime.Add(-2 * time.Hour)
+	finished, err := database.CreateMatch(ctx, baseApp, "test-server-anonymous", &mapName, &scenario, &earlier)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	if err := database.UpsertMatchPlayerStats(ctx, baseApp, finished.ID, player.ID, nil, &earlier); err != nil {
+		t.Fatalf("failed to create match stats: %v", err)
+	}
+	if err := database.IncrementMatchPlayerStat(ctx, baseApp, finished.ID, player.ID, "kills"); err != nil {
+		t.Fatalf("failed to add kill: %v", err)
+	}
+	weaponKills := int64(1)
+	if err := database.UpsertMatchWeaponStats(ctx, baseApp, finished.ID, player.ID, "BP_Firearm_M16A4_C_2147480587", &weaponKills, nil); err != nil {
+		t.Fatalf("failed to add weapon kills: %v", err)
+	}
+	endTime := earlier.Add(30 * time.Minute)
+	if err := database.EndMatch(ctx, baseApp, finished.ID, &endTime, nil, nil); err != nil {
+		t.Fatalf("failed to end match: %v", err)
+	}
+
+	now := time.Now()
+	snapshots := map[string]a2s.Snapshot{
+		"test-server-private":   {Name: "Private Server", Players: []a2s.Player{{Name: "Kestrel"}}, LastQuery: now, LastSuccess: now},
+		"test-server-anonymous": {Name: "Anonymous Server", Players: []a2s.Player{{Name: "ArmoredBear"}}, LastQuery: now, LastSuccess: now},
+	}
+
+	adminToken := createSuperuserToken(t, baseApp)
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	registerA2SRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockA2SApp{mockRconApp: mockRconApp{TestApp: app}, snapshots: snapshots}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:               "homepage leaves out the private server",
+			Method:             http.MethodGet,
+			URL:                "/",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"Public Server"},
+			NotExpectedContent: []string{"Private Server"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:   "homepage shows the private server to an admin",
+			Method: http.MethodGet,
+			URL:    "/",
+			Headers: map[string]string{
+				"Authorization": adminToken,
+			},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"Public Server", "Private Server"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "private server's live match is not found",
+			Method:          http.MethodGet,
+			URL:             "/live-match/" + privateServer,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "hidden player names are numbered",
+			Method:             http.MethodGet,
+			URL:                "/live-match/" + anonymousServer,
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"Player #1"},
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "A2S leaves out the private server and numbers hidden names",
+			Method:             http.MethodGet,
+			URL:                "/api/a2s",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`"name":"Anonymous Server"`, `"name":"Player #1"`},
+			NotExpectedContent: []string{"Private Server", "Kestrel", "ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerA2SRoutes,
+		},
+		{
+			Name:            "private server's A2S snapshot is not found",
+			Method:          http.MethodGet,
+			URL:             "/api/a2s/" + privateServer,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerA2SRoutes,
+		},
+		{
+			Name:            "private server's live stream is not found",
+			Method:          http.MethodGet,
+			URL:             "/api/stream/" + privateServer,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "multiplexed live stream leaves out private servers",
+			Method:          http.MethodGet,
+			URL:             "/api/stream?servers=" + privateServer,
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "leaderboard of every server leaves out hidden names",
+			Method:             http.MethodGet,
+			URL:                "/leaderboard",
+			ExpectedStatus:     http.StatusOK,
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "weapon leaders leave out hidden names",
+			Method:             http.MethodGet,
+			URL:                "/api/weapons/M16A4",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`"kills":1`, `"leaders":[]`},
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "players page leaves out players seen only under hidden names",
+			Method:             http.MethodGet,
+			URL:                "/players",
+			ExpectedStatus:     http.StatusOK,
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:   "players page shows every player to an admin",
+			Method: http.MethodGet,
+			URL:    "/players",
+			Headers: map[string]string{
+				"Authorization": adminToken,
+			},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "layout does not link anonymous visitors to the admin pages",
+			Method:             http.MethodGet,
+			URL:                "/players",
+			ExpectedStatus:     http.StatusOK,
+			NotExpectedContent: []string{`href="/moderation/friendly-fire"`},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:   "layout links an admin to the admin pages",
+			Method: http.MethodGet,
+			URL:    "/players",
+			Headers: map[string]string{
+				"Authorization": adminToken,
+			},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`href="/moderation/friendly-fire"`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "weapons page leaves out hidden names",
+			Method:             http.MethodGet,
+			URL:                "/weapons",
+			ExpectedStatus:     http.StatusOK,
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "maps page leaves hidden names out of the top players",
+			Method:             http.MethodGet,
+			URL:                "/maps",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"Hideout"},
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:               "maps API leaves hidden names out of the top players",
+			Method:             http.MethodGet,
+			URL:                "/api/maps",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{`"matches_played":2`, `"top_players":null`},
+			NotExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:     setup,
+			BeforeTestFunc:     registerRoutes,
+		},
+		{
+			Name:   "maps API shows hidden names to an admin",
+			Method: http.MethodGet,
+			URL:    "/api/maps",
+			Headers: map[string]string{
+				"Authorization": adminToken,
+			},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{"ArmoredBear"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:               "records API leaves out the private server",
+			Method:             http.MethodGet,
+			URL:                "/api/collections/servers/records",
+			ExpectedStatus:     http.StatusOK,
+			ExpectedContent:    []string{"Public Server", "Anonymous Server"},
+			NotExpectedContent: []string{"Private Server"},
+			TestAppFactory:     setup,
+		},
+		{
+			Name:            "records API leaves out stats of servers hiding names",
+			Method:          http.MethodGet,
+			URL:             "/api/collections/match_player_stats/records",
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"totalItems":0`},
+			TestAppFactory:  setup,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
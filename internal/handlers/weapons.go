@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/template"
+)
+
+// weaponLeadersLimit is the number of kill leaders on a weapon's page
+const weaponLeadersLimit = 25
+
+// weaponRow is a weapons index entry with the escaped path of its detail page, as weapon names
+// can contain spaces
+type weaponRow struct {
+	database.WeaponSummary
+	Path string
+}
+
+// weaponSort returns the ?sort= of a weapons request, defaulting to kills
+func weaponSort(re *core.RequestEvent) string {
+	sort := re.Request.URL.Query().Get("sort")
+	if !slices.Contains(database.WeaponSorts, sort) {
+		return "kills"
+	}
+	return sort
+}
+
+// weaponRows returns the weapons index in the request's sort order
+func weaponRows(re *core.RequestEvent, v viewer) ([]weaponRow, error) {
+	summaries, err := database.GetWeaponSummaries(re.Request.Context(), re.App, weaponSort(re), !v.admin)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]weaponRow, 0, len(summaries))
+	for _, summary := range summaries {
+		rows = append(rows, weaponRow{WeaponSummary: summary, Path: "/weapons/" + url.PathEscape(summary.Name)})
+	}
+	return rows, nil
+}
+
+// registerWeapons registers the weapon detail page and the weapons API
+func registerWeapons(e *core.ServeEvent, registry *template.Registry) {
+	// Weapon page - a weapon's kill leaders and kills per day
+	e.Router.GET("/weapons/{name}", func(re *core.RequestEvent) error {
+		v := viewerOf(re)
+		detail, err := database.GetWeaponDetail(re.Request.Context(), re.App, re.Request.PathValue("name"),
+			weaponLeadersLimit, !v.admin, time.Now())
+		if err != nil {
+			return re.InternalServerError("Failed to load weapon stats", err)
+		}
+		if detail == nil {
+			return re.NotFoundError("Weapon not found", nil)
+		}
+
+		type UsageRow struct {
+			database.WeaponUsage
+			Percent int // Bar width relative to the busiest day
+		}
+
+		busiest := 0
+		for _, usage := range detail.Usage {
+			busiest = max(busiest, usage.Kills)
+		}
+		usage := make([]UsageRow, 0, len(detail.Usage))
+		for _, u := range detail.Usage {
+			usage = append(usage, UsageRow{WeaponUsage: u, Percent: u.Kills * 100 / max(busiest, 1)})
+		}
+
+		html, err := renderPage(re, registry, "templates/weapon.html", map[string]any{
+			"ActivePage": "weapons",
+			"Weapon":     detail,
+			"Usage":      usage,
+			"UsageDays":  database.WeaponUsageDays,
+		})
+		if err != nil {
+			return re.InternalServerError("Failed to render template", err)
+		}
+
+		return re.HTML(http.StatusOK, html)
+	})
+
+	// GET /api/weapons?sort=kills|users|name - Every weapon's kills and number of players
+	e.Router.GET("/api/weapons", func(re *core.RequestEvent) error {
+		summaries, err := database.GetWeaponSummaries(re.Request.Context(), re.App, weaponSort(re), !viewerOf(re).admin)
+		if err != nil {
+			return re.InternalServerError("Failed to load weapon stats", err)
+		}
+
+		return re.JSON(http.StatusOK, map[string]any{
+			"items": summaries,
+		})
+	})
+
+	// GET /api/weapons/{name} - A weapon's totals, kill leaders and kills per day
+	e.Router.GET("/api/weapons/{name}", func(re *core.RequestEvent) error {
+		detail, err := database.GetWeaponDetail(re.Request.Context(), re.App, re.Request.PathValue("name"),
+			weaponLeadersLimit, !viewerOf(re).admin, time.Now())
+		if err != nil {
+			return re.InternalServerError("Failed to load weapon stats", err)
+		}
+		if detail == nil {
+			return re.NotFoundError("Weapon not found", nil)
+		}
+
+		return re.JSON(http.StatusOK, detail)
+	})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestWeaponsEndpoint has two players get kills with the same weapon and checks they are ranked
+// by kills on its page, reached through its URL-encoded name
+func TestWeaponsEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-weapons"
+
+	if _, err := database.GetOrCreateServer(ctx, baseApp, serverExternalID, "Weapons Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	armoredBear, err := database.CreatePlayer(ctx, baseApp, "76561198995742987", "ArmoredBear")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	rabbit, err := database.CreatePlayer(ctx, baseApp, "76561198000000001", "Rabbit")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+
+	// Two matches so kills are summed across them
+	start := time.Now().UTC().Add(-48 * time.Hour)
+	matchIDs := make([]string, 2)
+	for i := range matchIDs {
+		mapName, scenario := "Town", "Scenario_Hideout_Checkpoint_Security"
+		startTime := start.Add(time.Duration(i) * time.Hour)
+		match, err := database.CreateMatch(ctx, baseApp, serverExternalID, &mapName, &scenario, &startTime)
+		if err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+		endTime := startTime.Add(30 * time.Minute)
+		if err := database.EndMatch(ctx, baseApp, match.ID, &endTime, nil, nil); err != nil {
+			t.Fatalf("failed to end match: %v", err)
+		}
+		matchIDs[i] = match.ID
+	}
+
+	kills := []struct {
+		match  int
+		player *database.Player
+		weapon string
+		kills  int64
+	}{
+		{0, armoredBear, "BP_Firearm_M16A4_Carryhandle_C_2147480587", 2},
+		{0, rabbit, "BP_Firearm_M16A4_Carryhandle_C_2147480587", 3},
+		{1, armoredBear, "BP_Firearm_AKM_C_2147480111", 1},
+		{1, armoredBear, "BP_Firearm_M16A4_Carryhandle_C_2147480999", 2},
+	}
+	for _, k := range kills {
+		if err := database.UpsertMatchWeaponStats(ctx, baseApp, matchIDs[k.match], k.player.ID, k.weapon, &k.kills, nil); err != nil {
+			t.Fatalf("failed to add weapon kills: %v", err)
+		}
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		Register(&mockRconApp{TestApp: app}, e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "weapon leaders are ranked by kills",
+			Method:         http.MethodGet,
+			URL:            "/api/weapons/M16A4%20Carryhandle",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"name":"M16A4 Carryhandle","kills":7,"users":2`,
+				`"leaders":[{"rank":1,"player_id":"` + armoredBear.ID + `","name":"ArmoredBear","kills":4,"matches":2},{"rank":2,"player_id":"` + rabbit.ID + `","name":"Rabbit","kills":3,"matches":1}]`,
+				`"usage":[{"day":"`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "weapon page lists its leaders",
+			Method:         http.MethodGet,
+			URL:            "/weapons/M16A4%20Carryhandle",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				"<h2>M16A4 Carryhandle</h2>",
+				"<td>1</td>\n                <td><strong>ArmoredBear</strong></td>\n                <td>4</td>",
+				"<td>2</td>\n                <td><strong>Rabbit</strong></td>\n                <td>3</td>",
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:            "unknown weapons are not found",
+			Method:          http.MethodGet,
+			URL:             "/weapons/M16A4",
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:           "weapons are sorted by players",
+			Method:         http.MethodGet,
+			URL:            "/api/weapons?sort=users",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`{"items":[{"name":"M16A4 Carryhandle","kills":7,"users":2},{"name":"AKM","kills":1,"users":1}]}`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+		{
+			Name:           "weapons index links to escaped weapon pages",
+			Method:         http.MethodGet,
+			URL:            "/weapons",
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`href="/weapons/M16A4%20Carryhandle"`,
+				`href="/weapons/AKM"`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
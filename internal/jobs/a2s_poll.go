@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// RegisterA2SPoller sets up a cron job that queries every server in the A2S pool each minute.
+// The presence, offline alert and map cycle jobs work from the snapshots it leaves in the pool
+// rather than querying the servers again.
+func RegisterA2SPoller(app AppInterface) {
+	logger := app.Logger().With("component", "JOBS")
+
+	app.Cron().MustAdd("a2s_poll", "* * * * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		pool := app.GetA2SPool()
+		if pool == nil {
+			return
+		}
+		pool.QueryAll(ctx)
+	})
+
+	logger.Info("Registered cron job to query servers over A2S every minute")
+}
@@ -2,16 +2,22 @@ package jobs
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"sandstorm-tracker/internal/config"
+
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
-// RegisterArchiveOldData sets up a cron job that archives data older than 30 days
-// Archives matches, match player stats, weapon stats, and events
-func RegisterArchiveOldData(app core.App, logger *slog.Logger) {
+// RegisterArchiveOldData sets up a daily cron job that prunes old events and, when
+// retention.matchesDays is set, old finished matches. matchIdle is the stale-match timeout:
+// active matches without activity for that long no longer hold back the events cutoff.
+func RegisterArchiveOldData(app core.App, cfg config.RetentionConfig, matchIdle time.Duration, logger *slog.Logger) {
 	scheduler := app.Cron()
 
 	// Run archive job daily at 2 AM UTC
@@ -19,177 +25,195 @@ func RegisterArchiveOldData(app core.App, logger *slog.Logger) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 		defer cancel()
 
-		archiveOldData(ctx, app, logger)
+		if _, err := PruneOldData(ctx, app, cfg, matchIdle, time.Now()); err != nil {
+			logger.Error("Archive job failed", "component", "ARCHIVE_JOB", "error", err)
+		}
 	})
 
-	logger.Info("Registered cron job to archive data older than 30 days daily at 2 AM UTC", "component", "JOBS")
+	logger.Info("Registered cron job to prune old data daily at 2 AM UTC", "component", "JOBS",
+		"eventsMaxAge", cfg.EventsMaxAge(), "matchesMaxAge", cfg.MatchesMaxAge())
 }
 
-// archiveOldData moves matches and related data older than 30 days into an archived state
-func archiveOldData(ctx context.Context, app core.App, logger *slog.Logger) {
-	logger.Info("Starting archive job", "component", "ARCHIVE_JOB")
+// RetentionResult reports what a retention run deleted
+type RetentionResult struct {
+	Matches       int       // Finished matches deleted with their stats
+	Events        int       // Events deleted
+	PrunedMatches int       // Matches newly marked events_pruned
+	EventsCutoff  time.Time // Events created before this were deleted
+}
 
-	// Calculate cutoff date (30 days ago)
-	cutoffDate := time.Now().AddDate(0, 0, -30)
+// matchOutliving are the collections whose rows outlive their match: they are unlinked from it
+// instead of being deleted with it
+var matchOutliving = map[string]bool{
+	"moderation_flags": true,
+}
 
-	// Run in a transaction for consistency
-	err := app.RunInTransaction(func(txApp core.App) error {
-		// Archive old events first (least dependent)
-		archivedEvents, err := archiveEvents(txApp, logger, cutoffDate)
-		if err != nil {
-			logger.Error("Failed to archive events", "error", err)
-			return err
+// matchRelation is a relation field of a collection that points at matches
+type matchRelation struct {
+	collection string
+	field      string
+}
+
+// matchRelations finds every relation field pointing at matches, so collections added later are
+// pruned with their match without having to be listed here
+func matchRelations(txApp core.App) ([]matchRelation, error) {
+	matches, err := txApp.FindCollectionByNameOrId("matches")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the matches collection: %w", err)
+	}
+	collections, err := txApp.FindAllCollections(core.CollectionTypeBase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	var relations []matchRelation
+	for _, collection := range collections {
+		if collection.Id == matches.Id {
+			continue
 		}
+		for _, field := range collection.Fields {
+			if relation, ok := field.(*core.RelationField); ok && relation.CollectionId == matches.Id {
+				relations = append(relations, matchRelation{collection: collection.Name, field: relation.Name})
+			}
+		}
+	}
+	return relations, nil
+}
 
-		// Archive related match player stats
-		archivedPlayerStats, err := archiveMatchPlayerStats(txApp, logger, cutoffDate)
-		if err != nil {
-			logger.Error("Failed to archive match player stats", "error", err)
-			return err
+// PruneOldData deletes old finished matches (when retention.matchesDays is set) and events
+// older than retention.eventsDays, in one transaction.
+//
+// Events are never pruned from the start of the oldest match still in progress onwards, so a
+// live match (and a recompute of it) always has all of its events. Active matches without
+// activity for matchIdle are left over from a missed match end and don't count. Matches that lose events
+// are marked events_pruned, which the recompute endpoint refuses to rebuild: replaying an
+// incomplete event history would wipe the stats that were built from it.
+func PruneOldData(ctx context.Context, app core.App, cfg config.RetentionConfig, matchIdle time.Duration, now time.Time) (RetentionResult, error) {
+	logger := app.Logger().With("component", "ARCHIVE_JOB")
+	result := RetentionResult{}
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		if maxAge := cfg.MatchesMaxAge(); maxAge > 0 {
+			deleted, err := pruneMatches(txApp, now.Add(-maxAge))
+			if err != nil {
+				return err
+			}
+			result.Matches = deleted
 		}
 
-		// Archive related weapon stats
-		archivedWeaponStats, err := archiveMatchWeaponStats(txApp, logger, cutoffDate)
+		cutoff, err := safeEventsCutoff(txApp, now.Add(-cfg.EventsMaxAge()), now.Add(-matchIdle))
 		if err != nil {
-			logger.Error("Failed to archive weapon stats", "error", err)
 			return err
 		}
+		result.EventsCutoff = cutoff
 
-		// Archive old matches last
-		archivedMatches, err := archiveMatches(txApp, logger, cutoffDate)
+		pruned, events, err := pruneEvents(txApp, cutoff)
 		if err != nil {
-			logger.Error("Failed to archive matches", "error", err)
 			return err
 		}
-
-		logger.Info("Archive job completed successfully",
-			"component", "ARCHIVE_JOB",
-			"archived_matches", archivedMatches,
-			"archived_player_stats", archivedPlayerStats,
-			"archived_weapon_stats", archivedWeaponStats,
-			"archived_events", archivedEvents,
-			"cutoff_date", cutoffDate.Format("2006-01-02"))
-
+		result.PrunedMatches = pruned
+		result.Events = events
 		return nil
 	})
-
 	if err != nil {
-		logger.Error("Archive job failed", "component", "ARCHIVE_JOB", "error", err)
-		return
+		return result, err
 	}
+
+	logger.Info("Archive job completed successfully",
+		"deleted_matches", result.Matches,
+		"deleted_events", result.Events,
+		"pruned_matches", result.PrunedMatches,
+		"events_cutoff", result.EventsCutoff.Format(time.RFC3339))
+	return result, nil
 }
 
-// archiveMatches marks old matches with an archived flag or soft-deletes them
-// Returns the count of archived records
-func archiveMatches(txApp core.App, logger *slog.Logger, cutoffDate time.Time) (int, error) {
-	// Find all matches that ended before the cutoff date
-	// Assumes matches have an end_time field
-	records, err := txApp.FindRecordsByFilter(
-		"matches",
-		"end_time != '' && end_time < {:cutoff}",
-		"-end_time", // Sort by end_time descending
-		10000,       // Limit to a large number to avoid memory issues
-		0,           // No offset
-		map[string]any{"cutoff": cutoffDate.Format(time.RFC3339)},
-	)
+// pruneMatches deletes matches that ended before the cutoff, with the rows that belong to them.
+// Moderation flags outlive their match. Returns the number of matches deleted.
+func pruneMatches(txApp core.App, cutoff time.Time) (int, error) {
+	params := dbx.Params{"cutoff": dateString(cutoff)}
+	oldMatches := "SELECT id FROM matches WHERE end_time != '' AND end_time < {:cutoff}"
 
+	relations, err := matchRelations(txApp)
 	if err != nil {
-		return 0, fmt.Errorf("failed to query old matches: %w", err)
+		return 0, err
 	}
-
-	archived := 0
-	for _, record := range records {
-		// Delete the record
-		if err := txApp.Delete(record); err != nil {
-			logger.Error("Failed to delete old match", "matchID", record.Id, "error", err)
+	for _, relation := range relations {
+		collection := txApp.DB().QuoteSimpleTableName(relation.collection)
+		field := txApp.DB().QuoteSimpleColumnName(relation.field)
+		if matchOutliving[relation.collection] {
+			if _, err := txApp.DB().NewQuery("UPDATE " + collection + " SET " + field + " = '' WHERE " + field + " IN (" + oldMatches + ")").Bind(params).Execute(); err != nil {
+				return 0, fmt.Errorf("failed to unlink %s from old matches: %w", relation.collection, err)
+			}
 			continue
 		}
-		archived++
+		if _, err := txApp.DB().NewQuery("DELETE FROM " + collection + " WHERE " + field + " IN (" + oldMatches + ")").Bind(params).Execute(); err != nil {
+			return 0, fmt.Errorf("failed to delete old %s: %w", relation.collection, err)
+		}
 	}
 
-	return archived, nil
+	res, err := txApp.DB().NewQuery("DELETE FROM matches WHERE end_time != '' AND end_time < {:cutoff}").Bind(params).Execute()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old matches: %w", err)
+	}
+	return rowsAffected(res), nil
 }
 
-// archiveMatchPlayerStats deletes old player stats
-func archiveMatchPlayerStats(txApp core.App, logger *slog.Logger, cutoffDate time.Time) (int, error) {
-	// Find stats for matches that ended before the cutoff date using created timestamp
-	records, err := txApp.FindRecordsByFilter(
-		"match_player_stats",
-		"created < {:cutoff}",
-		"-created",
-		10000,
-		0,
-		map[string]any{"cutoff": cutoffDate.Format(time.RFC3339)},
-	)
-
+// safeEventsCutoff moves the events cutoff back to the start of the oldest match still in
+// progress. Active matches with no activity since idleSince (no event on their server and no
+// player seen in them) are orphans the stale-match closer hasn't ended yet, and are ignored so
+// they can't hold the cutoff back for good.
+func safeEventsCutoff(txApp core.App, cutoff time.Time, idleSince time.Time) (time.Time, error) {
+	var row struct {
+		Oldest types.DateTime `db:"oldest"`
+	}
+	err := txApp.DB().NewQuery(`
+		SELECT COALESCE(MIN(m.created), '') AS oldest FROM matches m
+		WHERE m.end_time = '' AND (
+			m.created >= {:idle}
+			OR EXISTS (SELECT 1 FROM events e WHERE e.server = m.server AND e.created >= m.created AND e.created >= {:idle})
+			OR EXISTS (SELECT 1 FROM match_player_stats s WHERE s.match = m.id AND s.last_seen_at >= {:idle})
+		)`).Bind(dbx.Params{"idle": dateString(idleSince)}).One(&row)
 	if err != nil {
-		return 0, fmt.Errorf("failed to query old match_player_stats: %w", err)
+		return cutoff, fmt.Errorf("failed to find the oldest active match: %w", err)
 	}
-
-	archived := 0
-	for _, record := range records {
-		if err := txApp.Delete(record); err != nil {
-			logger.Error("Failed to delete old player stats", "statsID", record.Id, "error", err)
-			continue
-		}
-		archived++
+	if !row.Oldest.IsZero() && row.Oldest.Time().Before(cutoff) {
+		return row.Oldest.Time(), nil
 	}
-
-	return archived, nil
+	return cutoff, nil
 }
 
-// archiveMatchWeaponStats deletes old weapon stats
-func archiveMatchWeaponStats(txApp core.App, logger *slog.Logger, cutoffDate time.Time) (int, error) {
-	// Find stats for matches that ended before the cutoff date
-	records, err := txApp.FindRecordsByFilter(
-		"match_weapon_stats",
-		"created < {:cutoff}",
-		"-created",
-		10000,
-		0,
-		map[string]any{"cutoff": cutoffDate.Format(time.RFC3339)},
-	)
+// pruneEvents deletes events created before the cutoff and marks the matches that started
+// before it as events_pruned. Objective timeline entries keep their data but lose the link to
+// their event. Returns the matches marked and the events deleted.
+func pruneEvents(txApp core.App, cutoff time.Time) (int, int, error) {
+	params := dbx.Params{"cutoff": dateString(cutoff)}
 
+	marked, err := txApp.DB().NewQuery("UPDATE matches SET events_pruned = TRUE WHERE events_pruned = FALSE AND created < {:cutoff}").Bind(params).Execute()
 	if err != nil {
-		return 0, fmt.Errorf("failed to query old match_weapon_stats: %w", err)
+		return 0, 0, fmt.Errorf("failed to mark pruned matches: %w", err)
 	}
 
-	archived := 0
-	for _, record := range records {
-		if err := txApp.Delete(record); err != nil {
-			logger.Error("Failed to delete old weapon stats", "statsID", record.Id, "error", err)
-			continue
-		}
-		archived++
+	if _, err := txApp.DB().NewQuery("UPDATE objective_events SET event = '' WHERE event IN (SELECT id FROM events WHERE created < {:cutoff})").Bind(params).Execute(); err != nil {
+		return 0, 0, fmt.Errorf("failed to unlink objective events: %w", err)
 	}
 
-	return archived, nil
+	deleted, err := txApp.DB().NewQuery("DELETE FROM events WHERE created < {:cutoff}").Bind(params).Execute()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete old events: %w", err)
+	}
+	return rowsAffected(marked), rowsAffected(deleted), nil
 }
 
-// archiveEvents deletes old events
-func archiveEvents(txApp core.App, logger *slog.Logger, cutoffDate time.Time) (int, error) {
-	// Find events older than cutoff date
-	records, err := txApp.FindRecordsByFilter(
-		"events",
-		"created < {:cutoff}",
-		"-created",
-		10000,
-		0,
-		map[string]any{"cutoff": cutoffDate.Format(time.RFC3339)},
-	)
+// dateString formats a time the way PocketBase stores dates, so it compares correctly in SQL
+func dateString(t time.Time) string {
+	date, _ := types.ParseDateTime(t)
+	return date.String()
+}
 
+func rowsAffected(res sql.Result) int {
+	n, err := res.RowsAffected()
 	if err != nil {
-		return 0, fmt.Errorf("failed to query old events: %w", err)
+		return 0
 	}
-
-	archived := 0
-	for _, record := range records {
-		if err := txApp.Delete(record); err != nil {
-			logger.Error("Failed to delete old event", "eventID", record.Id, "error", err)
-			continue
-		}
-		archived++
-	}
-
-	return archived, nil
+	return int(n)
 }
@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestPruneOldData checks events older than the retention cutoff are deleted while recent ones
+// and those of a match still in progress survive, and that old matches are only deleted when
+// retention.matchesDays is set
+func TestPruneOldData(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	serverRecordID, err := database.GetOrCreateServer(ctx, testApp, "test-server-retention", "Retention Test Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// setCreated backdates a record, since PocketBase always stamps created with the current time
+	setCreated := func(collection, id string, created time.Time) {
+		t.Helper()
+		_, err := testApp.DB().Update(collection, dbx.Params{"created": dateString(created)}, dbx.HashExp{"id": id}).Execute()
+		if err != nil {
+			t.Fatalf("failed to backdate %s %s: %v", collection, id, err)
+		}
+	}
+
+	newMatch := func(serverID string, created time.Time, ended *time.Time) string {
+		t.Helper()
+		collection, err := testApp.FindCollectionByNameOrId("matches")
+		if err != nil {
+			t.Fatalf("failed to find matches: %v", err)
+		}
+		match := core.NewRecord(collection)
+		match.Set("server", serverID)
+		match.Set("map", "Farmhouse")
+		match.Set("start_time", created)
+		if ended != nil {
+			match.Set("end_time", *ended)
+			match.Set("status", "finished")
+		}
+		if err := testApp.Save(match); err != nil {
+			t.Fatalf("failed to create match: %v", err)
+		}
+		setCreated("matches", match.Id, created)
+		return match.Id
+	}
+
+	newEvent := func(serverID, matchID string, created time.Time) string {
+		t.Helper()
+		collection, err := testApp.FindCollectionByNameOrId("events")
+		if err != nil {
+			t.Fatalf("failed to find events: %v", err)
+		}
+		event := core.NewRecord(collection)
+		event.Set("type", "player_kill")
+		event.Set("data", "{}")
+		event.Set("server", serverID)
+		event.Set("match", matchID)
+		if err := testApp.Save(event); err != nil {
+			t.Fatalf("failed to create event: %v", err)
+		}
+		setCreated("events", event.Id, created)
+		return event.Id
+	}
+
+	exists := func(collection, id string) bool {
+		_, err := testApp.FindRecordById(collection, id)
+		return err == nil
+	}
+
+	oldEnd, recentEnd := daysAgo(199), daysAgo(4)
+	oldMatch := newMatch(serverRecordID, daysAgo(200), &oldEnd)
+	recentMatch := newMatch(serverRecordID, daysAgo(5), &recentEnd)
+	// A match in progress since before the events cutoff holds the cutoff back
+	activeMatch := newMatch(serverRecordID, daysAgo(100), nil)
+
+	oldEvent := newEvent(serverRecordID, oldMatch, daysAgo(200))
+	recentEvent := newEvent(serverRecordID, recentMatch, daysAgo(5))
+	activeEvent := newEvent(serverRecordID, activeMatch, daysAgo(95))
+	newEvent(serverRecordID, activeMatch, now.Add(-time.Hour))
+
+	// A match whose end was missed, on a server gone quiet since, doesn't
+	orphanServerID, err := database.GetOrCreateServer(ctx, testApp, "test-server-orphan", "Orphan Test Server", "test/orphan")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	orphanMatch := newMatch(orphanServerID, daysAgo(150), nil)
+	orphanEvent := newEvent(orphanServerID, orphanMatch, daysAgo(150))
+
+	player, err := database.GetOrCreatePlayerBySteamID(ctx, testApp, "76561198995742987", "ArmoredBear")
+	if err != nil {
+		t.Fatalf("failed to create player: %v", err)
+	}
+	if err := database.UpsertMatchPlayerStats(ctx, testApp, oldMatch, player.ID, nil, nil); err != nil {
+		t.Fatalf("failed to create match stats: %v", err)
+	}
+
+	// Default retention: 90 days of events, matches kept forever
+	result, err := PruneOldData(ctx, testApp, config.RetentionConfig{}, 4*time.Hour, now)
+	if err != nil {
+		t.Fatalf("PruneOldData() error = %v", err)
+	}
+	if result.Events != 2 || result.Matches != 0 {
+		t.Errorf("Expected 2 events and no matches deleted, got %+v", result)
+	}
+	if exists("events", oldEvent) {
+		t.Error("Expected the event older than the cutoff to be deleted")
+	}
+	if exists("events", orphanEvent) {
+		t.Error("Expected the orphaned match not to hold the cutoff back")
+	}
+	if !exists("events", recentEvent) {
+		t.Error("Expected the recent event to survive")
+	}
+	if !exists("events", activeEvent) {
+		t.Error("Expected the in-progress match's event to survive the cutoff")
+	}
+
+	old, err := testApp.FindRecordById("matches", oldMatch)
+	if err != nil {
+		t.Fatalf("Expected the old match to be kept: %v", err)
+	}
+	if !old.GetBool("events_pruned") {
+		t.Error("Expected the old match to be marked events_pruned")
+	}
+	recent, err := testApp.FindRecordById("matches", recentMatch)
+	if err != nil {
+		t.Fatalf("failed to find recent match: %v", err)
+	}
+	if recent.GetBool("events_pruned") {
+		t.Error("Expected the recent match to keep its events")
+	}
+	if count, _ := testApp.CountRecords("match_player_stats", dbx.HashExp{"match": oldMatch}); count != 1 {
+		t.Errorf("Expected the old match's stats to be kept, got %d rows", count)
+	}
+
+	// With a match retention, finished matches past it are deleted with their stats
+	result, err = PruneOldData(ctx, testApp, config.RetentionConfig{MatchesDays: 180}, 4*time.Hour, now)
+	if err != nil {
+		t.Fatalf("PruneOldData() error = %v", err)
+	}
+	if result.Matches != 1 {
+		t.Errorf("Expected 1 match deleted, got %+v", result)
+	}
+	if exists("matches", oldMatch) {
+		t.Error("Expected the old match to be deleted")
+	}
+	if count, _ := testApp.CountRecords("match_player_stats", dbx.HashExp{"match": oldMatch}); count != 0 {
+		t.Errorf("Expected the old match's stats to be deleted, got %d rows", count)
+	}
+	if !exists("matches", recentMatch) || !exists("matches", activeMatch) {
+		t.Error("Expected the recent and in-progress matches to be kept")
+	}
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/logger"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterLogArchiver sets up an hourly cron job that gzips the logs the game servers rotated
+func RegisterLogArchiver(app core.App, cfg *config.Config, log *slog.Logger) {
+	if !cfg.LogArchive.Enabled {
+		return
+	}
+
+	app.Cron().MustAdd("archive_server_logs", "15 * * * *", func() {
+		ArchiveServerLogs(cfg.Servers, cfg.LogArchive.MaxArchivesOrDefault(), log)
+	})
+
+	log.Info("Registered cron job to archive server logs every hour", "component", "JOBS")
+}
+
+// ArchiveServerLogs gzips the backups the enabled servers' game servers rotated themselves, and keeps
+// maxArchives compressed logs per server. Returns the number of files compressed.
+//
+// The active <id>.log is never touched: the game server holds it open, so moving or truncating it
+// would lose the lines written meanwhile (and fails on Windows). The game starts a new log and
+// leaves the old one as a -backup- file every time it restarts.
+func ArchiveServerLogs(servers []config.ServerConfig, maxArchives int, log *slog.Logger) int {
+	compressed := 0
+	for _, server := range servers {
+		if !server.Enabled {
+			continue
+		}
+
+		logFiles, err := serverLogFiles(server.LogPath)
+		if err != nil {
+			log.Warn("Failed to list server logs", "server", server.Name, "error", err)
+			continue
+		}
+
+		for _, logFile := range logFiles {
+			compressed += compressGameBackups(logFile, log)
+
+			// <id>.log.<timestamp>.gz are archives of the active log from earlier versions
+			base := filepath.Base(logFile)
+			logger.PruneBackups(filepath.Dir(logFile), []string{base + ".", strings.TrimSuffix(base, ".log") + "-backup-"}, maxArchives)
+		}
+	}
+	return compressed
+}
+
+// compressGameBackups gzips the <name>-backup-<timestamp>.log files the game server leaves when it starts a new log
+func compressGameBackups(logFile string, log *slog.Logger) int {
+	dir := filepath.Dir(logFile)
+	prefix := strings.TrimSuffix(filepath.Base(logFile), ".log") + "-backup-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	compressed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		if _, err := logger.CompressFile(filepath.Join(dir, entry.Name())); err != nil {
+			log.Warn("Failed to compress server log backup", "file", entry.Name(), "error", err)
+			continue
+		}
+		compressed++
+	}
+	return compressed
+}
+
+// serverLogFiles returns the active log files of a server's logPath, which is either the log file
+// itself (sandstorm-admin-wrapper) or a Logs directory, in the same way the watcher picks them
+func serverLogFiles(logPath string) ([]string, error) {
+	info, err := os.Stat(logPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{logPath}, nil
+	}
+
+	entries, err := os.ReadDir(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") && !strings.Contains(entry.Name(), "-backup-") {
+			files = append(files, filepath.Join(logPath, entry.Name()))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/config"
+)
+
+// TestArchiveServerLogs_CompressesGameBackups checks the game's own backups are gzipped and pruned
+// while the active log, which the game server keeps writing to, is left alone
+func TestArchiveServerLogs_CompressesGameBackups(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "server-uuid.log")
+	contents := strings.Repeat("[2025.11.08-14.00.00:000][  0]LogGameplayEvents: Display: line\n", 100)
+	if err := os.WriteFile(logPath, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	backupPath := filepath.Join(dir, "server-uuid-backup-2025.11.07-10.00.00.log")
+	if err := os.WriteFile(backupPath, []byte("previous session\n"), 0644); err != nil {
+		t.Fatalf("failed to write game backup: %v", err)
+	}
+	before, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("failed to stat log: %v", err)
+	}
+
+	servers := []config.ServerConfig{{Name: "Main", LogPath: logPath, Enabled: true}}
+	if compressed := ArchiveServerLogs(servers, 5, slog.Default()); compressed != 1 {
+		t.Fatalf("Expected the game backup to be compressed, got %d", compressed)
+	}
+
+	if got := readGzip(t, backupPath+".gz"); got != "previous session\n" {
+		t.Errorf("Game backup archive = %q", got)
+	}
+	if _, err := os.Stat(backupPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the uncompressed game backup to be removed, stat err = %v", err)
+	}
+
+	after, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("Expected the active log at %s: %v", logPath, err)
+	}
+	if !os.SameFile(before, after) || after.Size() != int64(len(contents)) {
+		t.Error("Expected the active log to be left as it was")
+	}
+	if archives, _ := filepath.Glob(filepath.Join(dir, "server-uuid.log.*")); len(archives) != 0 {
+		t.Errorf("Expected no archive of the active log, got %v", archives)
+	}
+
+	// Nothing left to compress on the next run
+	if compressed := ArchiveServerLogs(servers, 5, slog.Default()); compressed != 0 {
+		t.Errorf("Expected nothing to archive on the second run, got %d", compressed)
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("failed to read archive %s: %v", path, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress %s: %v", path, err)
+	}
+	return string(data)
+}
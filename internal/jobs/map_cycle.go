@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/util"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// rconSender sends an RCON command to a server by its external ID
+type rconSender interface {
+	SendRconCommand(serverID string, command string) (string, error)
+}
+
+// MapCycler switches servers to the scenarios their map cycle rules pick for the current player
+// count and time. A server is only switched when the scenario it is playing is known and not one
+// of the rule's, and not again within its cooldown, so a count hovering around a rule's bound
+// does not keep sending it back and forth.
+type MapCycler struct {
+	app    core.App
+	rcon   rconSender
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu         sync.Mutex
+	lastSwitch map[string]time.Time // server external ID -> last switch sent
+	next       map[string]int       // server external ID and rule name -> next scenario of the rule
+}
+
+// NewMapCycler creates a map cycler that sends travel commands through rcon
+func NewMapCycler(app core.App, rcon rconSender) *MapCycler {
+	return &MapCycler{
+		app:        app,
+		rcon:       rcon,
+		logger:     app.Logger().With("component", "MAP_CYCLE"),
+		now:        time.Now,
+		lastSwitch: make(map[string]time.Time),
+		next:       make(map[string]int),
+	}
+}
+
+// Check applies a server's map cycle to its current player count (server external ID). Returns the
+// scenario the server was sent to, or "" when it stays on its map.
+func (c *MapCycler) Check(serverID string, cycle config.MapCycleConfig, players int) (string, error) {
+	if !cycle.Enabled {
+		return "", nil
+	}
+
+	now := c.now()
+	ruleIndex := slices.IndexFunc(cycle.Rules, func(rule config.MapCycleRule) bool {
+		return rule.Matches(players, now)
+	})
+	if ruleIndex < 0 {
+		return "", nil
+	}
+	rule := cycle.Rules[ruleIndex]
+
+	current, err := c.currentScenario(serverID)
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		// Nothing known to be playing, e.g. the tracker has not seen a map load yet
+		return "", nil
+	}
+	if slices.ContainsFunc(rule.Scenarios, func(scenario string) bool { return strings.EqualFold(scenario, current) }) {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if last, ok := c.lastSwitch[serverID]; ok && now.Sub(last) < cycle.Cooldown() {
+		return "", nil
+	}
+
+	key := serverID + "\x00" + ruleKey(rule, ruleIndex)
+	scenario := rule.Scenarios[c.next[key]%len(rule.Scenarios)]
+	command := cycle.TravelCommandFor(scenario)
+	if _, err := c.rcon.SendRconCommand(serverID, command); err != nil {
+		return "", fmt.Errorf("failed to send %q: %w", command, err)
+	}
+	c.lastSwitch[serverID] = now
+	c.next[key]++
+
+	c.logger.Info("Switched map for player count", "server_id", serverID, "rule", ruleKey(rule, ruleIndex),
+		"players", players, "from", current, "to", scenario)
+	return scenario, nil
+}
+
+// currentScenario returns the scenario of the server's active match, or "" without one
+func (c *MapCycler) currentScenario(serverID string) (string, error) {
+	server, err := c.app.FindFirstRecordByData("servers", "external_id", serverID)
+	if err != nil {
+		return "", nil
+	}
+	match, err := getActiveMatchForServer(c.app, server.Id)
+	if err != nil || match == nil {
+		return "", err
+	}
+	return match.GetString("scenario"), nil
+}
+
+// ruleKey names a rule in the logs and rotation state, by its name or its position
+func ruleKey(rule config.MapCycleRule, index int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("rule %d", index)
+}
+
+// humanPlayers returns the players on a server in an A2S snapshot, leaving out bots. ok is false
+// when the snapshot cannot be trusted, e.g. the last query failed.
+func humanPlayers(snapshot a2s.Snapshot) (players int, ok bool) {
+	if snapshot.Error != nil || snapshot.Info == nil {
+		return 0, false
+	}
+	return max(int(snapshot.Info.Players)-int(snapshot.Info.Bots), 0), true
+}
+
+// RegisterMapCycler sets up a cron job that applies the map cycle of every server that has one
+// enabled each minute, from the server's cached A2S snapshot. The servers are queried by the
+// A2S poll job.
+func RegisterMapCycler(app AppInterface, cfg *config.Config, cycler *MapCycler) {
+	logger := app.Logger().With("component", "JOBS")
+
+	managed := 0
+	for _, serverCfg := range cfg.Servers {
+		if serverCfg.Enabled && serverCfg.MapCycle.Enabled {
+			managed++
+		}
+	}
+	if managed == 0 {
+		return
+	}
+
+	app.Cron().MustAdd("map_cycle", "* * * * *", func() {
+		pool := app.GetA2SPool()
+		if pool == nil {
+			return
+		}
+
+		for _, serverCfg := range cfg.Servers {
+			if !serverCfg.Enabled || !serverCfg.MapCycle.Enabled {
+				continue
+			}
+
+			queryAddr := serverCfg.QueryAddress
+			if queryAddr == "" {
+				queryAddr = serverCfg.RconAddress
+			}
+			serverID, err := util.GetServerIdFromPath(serverCfg.LogPath)
+			if err != nil {
+				continue
+			}
+
+			snapshot, err := pool.Snapshot(queryAddr)
+			if err != nil {
+				continue
+			}
+			players, ok := humanPlayers(snapshot)
+			if !ok {
+				continue
+			}
+
+			if _, err := cycler.Check(serverID, serverCfg.MapCycle, players); err != nil {
+				logger.Warn("Failed to switch map", "server", serverCfg.Name, "server_id", serverID, "error", err)
+			}
+		}
+	})
+
+	logger.Info("Registered cron job to cycle maps by player count", "servers", managed)
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// recordingRcon records the RCON commands sent to each server
+type recordingRcon struct {
+	commands map[string][]string
+}
+
+func (r *recordingRcon) SendRconCommand(serverID string, command string) (string, error) {
+	r.commands[serverID] = append(r.commands[serverID], command)
+	return "", nil
+}
+
+// TestMapCycler_LowPlayerCount plays a big map on a near-empty server and checks the quiet rule
+// sends it to the small map once, then waits out the cooldown
+func TestMapCycler_LowPlayerCount(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-map-cycle"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverID, "Map Cycle Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	mapName, scenario := "Crossing", "Scenario_Crossing_Push_Security"
+	startTime := time.Now()
+	if _, err := database.CreateMatch(ctx, testApp, serverID, &mapName, &scenario, &startTime); err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	quietMax := 4
+	cycle := config.MapCycleConfig{
+		Enabled: true,
+		Rules: []config.MapCycleRule{
+			{Name: "quiet", MaxPlayers: &quietMax, Scenarios: []string{"Scenario_Farmhouse_Checkpoint_Security", "Scenario_Hideout_Checkpoint_Security"}},
+			{Name: "busy", MinPlayers: 12, Scenarios: []string{"Scenario_Crossing_Push_Security"}},
+		},
+	}
+
+	rcon := &recordingRcon{commands: map[string][]string{}}
+	cycler := NewMapCycler(testApp, rcon)
+	now := time.Date(2025, 11, 8, 14, 0, 0, 0, time.Local)
+	cycler.now = func() time.Time { return now }
+
+	// A count between the rules, or one the current map already suits, changes nothing
+	for _, players := range []int{8, 14} {
+		if switched, err := cycler.Check(serverID, cycle, players); err != nil || switched != "" {
+			t.Fatalf("Check(%d players) = %q, %v; want no switch", players, switched, err)
+		}
+	}
+
+	switched, err := cycler.Check(serverID, cycle, 2)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if switched != "Scenario_Farmhouse_Checkpoint_Security" {
+		t.Errorf("Expected a switch to the first quiet scenario, got %q", switched)
+	}
+	want := []string{"travelscenario Scenario_Farmhouse_Checkpoint_Security"}
+	if got := rcon.commands[serverID]; len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("Expected RCON commands %v, got %v", want, got)
+	}
+
+	// Until the map loads the server still reports the old scenario; the cooldown holds it
+	now = now.Add(5 * time.Minute)
+	if switched, err := cycler.Check(serverID, cycle, 2); err != nil || switched != "" {
+		t.Errorf("Expected no switch within the cooldown, got %q, %v", switched, err)
+	}
+
+	// After the cooldown the rule's scenarios are rotated through
+	now = now.Add(cycle.Cooldown())
+	if switched, _ := cycler.Check(serverID, cycle, 2); switched != "Scenario_Hideout_Checkpoint_Security" {
+		t.Errorf("Expected the next quiet scenario after the cooldown, got %q", switched)
+	}
+
+	// A server without the map cycle enabled is left alone
+	cycle.Enabled = false
+	now = now.Add(time.Hour)
+	if switched, _ := cycler.Check(serverID, cycle, 0); switched != "" {
+		t.Errorf("Expected no switch with the map cycle disabled, got %q", switched)
+	}
+	if got := len(rcon.commands[serverID]); got != 2 {
+		t.Errorf("Expected 2 RCON commands in all, got %d", got)
+	}
+}
+
+func TestMapCycleRule_Hours(t *testing.T) {
+	rule := config.MapCycleRule{Hours: "22-6"}
+	for hour, want := range map[int]bool{21: false, 22: true, 23: true, 0: true, 5: true, 6: false, 12: false} {
+		at := time.Date(2025, 11, 8, hour, 30, 0, 0, time.Local)
+		if got := rule.Matches(10, at); got != want {
+			t.Errorf("Matches at %02d:30 = %t, want %t", hour, got, want)
+		}
+	}
+}
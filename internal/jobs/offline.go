@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/events"
+	"sandstorm-tracker/internal/util"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// OfflineMonitor raises a server_offline event once a server's A2S queries have failed
+// threshold times in a row, and a server_online event with the downtime once it has answered
+// recoveries times in a row. A server is reported once per outage, so one that keeps failing or
+// flaps around either threshold does not raise more alerts. The reported outages are restored
+// from those events, so a restart in the middle of one neither repeats the alert nor loses the
+// recovery.
+type OfflineMonitor struct {
+	app        core.App
+	logger     *slog.Logger
+	creator    *events.Creator
+	threshold  int
+	recoveries int
+
+	mu         sync.Mutex
+	loaded     map[string]bool      // servers whose last outage was looked up in the events
+	offline    map[string]time.Time // server external ID -> first failed query of the reported outage
+	recovering map[string]recovery  // servers reported offline that are answering again
+}
+
+// recovery is the run of successful queries of a server reported offline
+type recovery struct {
+	since     time.Time // First successful query of the run
+	lastQuery time.Time // Latest query counted, so a snapshot checked twice counts once
+	successes int
+}
+
+// NewOfflineMonitor creates a monitor that reports servers offline after threshold failed
+// queries in a row, and back online after recoveries successful ones
+func NewOfflineMonitor(app core.App, threshold, recoveries int) *OfflineMonitor {
+	return &OfflineMonitor{
+		app:        app,
+		logger:     app.Logger().With("component", "OFFLINE_ALERTS"),
+		creator:    events.NewCreator(app),
+		threshold:  threshold,
+		recoveries: recoveries,
+		loaded:     make(map[string]bool),
+		offline:    make(map[string]time.Time),
+		recovering: make(map[string]recovery),
+	}
+}
+
+// Check applies a server's latest A2S snapshot (server external ID), recording a server_offline
+// or server_online event when its state changes. Snapshots of a server never queried are ignored.
+func (m *OfflineMonitor) Check(serverID string, snapshot a2s.Snapshot) error {
+	if snapshot.LastQuery.IsZero() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loaded[serverID] {
+		if err := m.loadOutage(serverID); err != nil {
+			return err
+		}
+		m.loaded[serverID] = true
+	}
+
+	since, reported := m.offline[serverID]
+
+	if snapshot.Error == nil {
+		if !reported {
+			return nil
+		}
+		run := m.recovering[serverID]
+		if run.successes == 0 {
+			run.since = snapshot.LastSuccess
+		}
+		if !snapshot.LastQuery.Equal(run.lastQuery) {
+			run.lastQuery = snapshot.LastQuery
+			run.successes++
+		}
+		if run.successes < m.recoveries {
+			m.recovering[serverID] = run
+			return nil
+		}
+
+		delete(m.offline, serverID)
+		delete(m.recovering, serverID)
+		downtime := run.since.Sub(since)
+		m.logger.Info("Server back online", "server_id", serverID, "downtime", downtime)
+		return m.creator.CreateServerOnlineEvent(serverID, events.ServerOnlineData{
+			OfflineSince:    since,
+			RecoveredAt:     run.since,
+			DowntimeSeconds: int(downtime.Seconds()),
+		})
+	}
+
+	// A failure cuts a recovery short
+	delete(m.recovering, serverID)
+
+	if reported || snapshot.Failures < m.threshold {
+		return nil
+	}
+	m.offline[serverID] = snapshot.FailedSince
+	m.logger.Warn("Server appears offline", "server_id", serverID, "failures", snapshot.Failures, "error", snapshot.Error)
+	return m.creator.CreateServerOfflineEvent(serverID, events.ServerOfflineData{
+		Failures:  snapshot.Failures,
+		Since:     snapshot.FailedSince,
+		LastError: snapshot.Error.Error(),
+	})
+}
+
+// loadOutage restores a server's outage when its latest server_offline or server_online event
+// is an offline one
+func (m *OfflineMonitor) loadOutage(serverID string) error {
+	records, err := m.app.FindRecordsByFilter(
+		"events",
+		"server.external_id = {:server} && (type = {:offline} || type = {:online})",
+		"-created",
+		1,
+		0,
+		dbx.Params{"server": serverID, "offline": events.TypeServerOffline, "online": events.TypeServerOnline},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find the last outage of server %s: %w", serverID, err)
+	}
+	if len(records) == 0 || records[0].GetString("type") != events.TypeServerOffline {
+		return nil
+	}
+
+	var data events.ServerOfflineData
+	if err := json.Unmarshal([]byte(records[0].GetString("data")), &data); err != nil {
+		return fmt.Errorf("failed to parse the last outage of server %s: %w", serverID, err)
+	}
+	m.offline[serverID] = data.Since
+	return nil
+}
+
+// RegisterOfflineMonitor sets up a cron job that passes every configured server's cached A2S
+// snapshot to the monitor each minute. The servers are queried by the A2S poll job.
+func RegisterOfflineMonitor(app AppInterface, cfg *config.Config, monitor *OfflineMonitor) {
+	logger := app.Logger().With("component", "JOBS")
+
+	app.Cron().MustAdd("a2s_offline_alerts", "* * * * *", func() {
+		pool := app.GetA2SPool()
+		if pool == nil {
+			return
+		}
+
+		for _, serverCfg := range cfg.Servers {
+			if !serverCfg.Enabled {
+				continue
+			}
+
+			queryAddr := serverCfg.QueryAddress
+			if queryAddr == "" {
+				queryAddr = serverCfg.RconAddress
+			}
+			if queryAddr == "" {
+				continue
+			}
+
+			serverID, err := util.GetServerIdFromPath(serverCfg.LogPath)
+			if err != nil {
+				continue
+			}
+
+			// Every failed query of the pool counts towards the threshold, whichever job sent it
+			snapshot, err := pool.Snapshot(queryAddr)
+			if err != nil {
+				continue
+			}
+			if err := monitor.Check(serverID, snapshot); err != nil {
+				logger.Error("Failed to record server availability", "server", serverCfg.Name, "error", err)
+			}
+		}
+	})
+
+	logger.Info("Registered cron job to alert on offline servers", "failures", monitor.threshold, "recoveries", monitor.recoveries)
+}
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	"sandstorm-tracker/internal/handlers"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestOfflineMonitor_AlertsOncePerOutage fails a server's A2S queries past the threshold and
+// checks one offline alert is posted, even across a restart, then one recovery with the downtime
+// once it has answered enough times in a row
+func TestOfflineMonitor_AlertsOncePerOutage(t *testing.T) {
+	var mu sync.Mutex
+	var titles []string
+	var descriptions []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var message handlers.WebhookMessage
+		if err := json.Unmarshal(body, &message); err == nil && len(message.Embeds) == 1 {
+			mu.Lock()
+			titles = append(titles, message.Embeds[0].Title)
+			descriptions = append(descriptions, message.Embeds[0].Description)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	serverID := "test-server-offline"
+	if _, err := database.GetOrCreateServer(context.Background(), testApp, serverID, "Offline Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	collection, err := testApp.FindCollectionByNameOrId("notification_webhooks")
+	if err != nil {
+		t.Fatalf("failed to find notification_webhooks collection: %v", err)
+	}
+	record := core.NewRecord(collection)
+	record.Set("url", webhook.URL)
+	record.Set("enabled", true)
+	record.Set("events", []string{handlers.NotifyServerStatus})
+	if err := testApp.Save(record); err != nil {
+		t.Fatalf("failed to save webhook: %v", err)
+	}
+
+	notifier := handlers.NewNotifier(testApp)
+	notifier.RegisterHooks()
+	monitor := NewOfflineMonitor(testApp, 3, 2)
+
+	// Polled once a minute: up, then down for 5 polls, then flapping before it is up again
+	start := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	lastSuccess := start
+	snapshot := a2s.Snapshot{LastQuery: start, LastSuccess: start}
+	check := func(minute int, failed bool) {
+		t.Helper()
+		snapshot.LastQuery = start.Add(time.Duration(minute) * time.Minute)
+		if failed {
+			if snapshot.Failures == 0 {
+				snapshot.FailedSince = snapshot.LastQuery
+			}
+			snapshot.Failures++
+			snapshot.Error = errors.New("i/o timeout")
+		} else {
+			lastSuccess = snapshot.LastQuery
+			snapshot.LastSuccess = lastSuccess
+			snapshot.Failures = 0
+			snapshot.FailedSince = time.Time{}
+			snapshot.Error = nil
+		}
+		if err := monitor.Check(serverID, snapshot); err != nil {
+			t.Fatalf("check at minute %d failed: %v", minute, err)
+		}
+	}
+
+	countEvents := func(eventType string) int {
+		t.Helper()
+		records, err := testApp.FindRecordsByFilter("events", "type = {:type}", "", -1, 0, map[string]any{"type": eventType})
+		if err != nil {
+			t.Fatalf("failed to find events: %v", err)
+		}
+		return len(records)
+	}
+
+	check(0, false)
+	check(1, true)
+	check(2, true)
+	if got := countEvents(events.TypeServerOffline); got != 0 {
+		t.Fatalf("Expected no offline alert below the threshold, got %d", got)
+	}
+	for minute := 3; minute <= 5; minute++ {
+		check(minute, true)
+	}
+	if got := countEvents(events.TypeServerOffline); got != 1 {
+		t.Fatalf("Expected one offline alert, got %d", got)
+	}
+
+	// A restart picks the outage up from the events rather than alerting again
+	monitor = NewOfflineMonitor(testApp, 3, 2)
+	check(6, true)
+	if got := countEvents(events.TypeServerOffline); got != 1 {
+		t.Fatalf("Expected the restarted monitor not to repeat the alert, got %d alerts", got)
+	}
+
+	// One answer between failures is not a recovery
+	check(7, false)
+	check(7, false) // the same snapshot checked twice counts once
+	check(8, true)
+	if got := countEvents(events.TypeServerOnline); got != 0 {
+		t.Fatalf("Expected no recovery from a single answer, got %d", got)
+	}
+
+	check(9, false)
+	check(10, false)
+	if got := countEvents(events.TypeServerOnline); got != 1 {
+		t.Fatalf("Expected one recovery, got %d", got)
+	}
+
+	notifier.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(titles) != 2 {
+		t.Fatalf("Expected 2 webhook posts, got %d: %v", len(titles), titles)
+	}
+	if !strings.Contains(titles[0], "appears offline") || !strings.Contains(descriptions[0], "3 queries") {
+		t.Errorf("Unexpected offline alert: %q %q", titles[0], descriptions[0])
+	}
+	// Down from the first failed query at minute 1 to the first of the answers in a row at minute 9
+	if !strings.Contains(titles[1], "back online") || descriptions[1] != "Offline for 8m0s" {
+		t.Errorf("Unexpected recovery: %q %q", titles[1], descriptions[1])
+	}
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PresenceReconciler cross-checks A2S player lists against match_player_stats.is_currently_connected.
+// Connection state normally comes from parsed login and leave events, so a missed disconnect
+// (tracker crash, log gap) leaves a player "connected" forever. Players missing from A2S for
+// longer than the grace period are disconnected; players present get last_seen_at refreshed.
+type PresenceReconciler struct {
+	app    core.App
+	logger *slog.Logger
+	grace  time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	absentSince map[string]time.Time // match_player_stats ID -> first snapshot the player was missing from
+}
+
+// NewPresenceReconciler creates a reconciler that disconnects players after grace without an A2S sighting
+func NewPresenceReconciler(app core.App, grace time.Duration) *PresenceReconciler {
+	return &PresenceReconciler{
+		app:         app,
+		logger:      app.Logger().With("component", "PRESENCE"),
+		grace:       grace,
+		now:         time.Now,
+		absentSince: make(map[string]time.Time),
+	}
+}
+
+// Reconcile applies one A2S player snapshot to the active match of a server (servers record ID).
+// Players are matched by name, the only identity A2S reports, against the name they use in the
+// match (a player renamed since shows under the new name in players but not on the server).
+// Returns the number of players disconnected.
+func (r *PresenceReconciler) Reconcile(serverRecordID string, players []a2s.Player) (int, error) {
+	match, err := getActiveMatchForServer(r.app, serverRecordID)
+	if err != nil || match == nil {
+		return 0, err
+	}
+
+	records, err := r.app.FindRecordsByFilter(
+		"match_player_stats",
+		"match = {:match} && is_currently_connected = true",
+		"",
+		-1,
+		0,
+		dbx.Params{"match": match.Id},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	online := make(map[string]bool, len(players))
+	for _, player := range players {
+		online[strings.TrimSpace(player.Name)] = true
+	}
+
+	now := r.now()
+	disconnected := 0
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, record := range records {
+		playerID := record.GetString("player")
+		name := record.GetString("player_name")
+		if name == "" {
+			// Rows from before the match name was kept
+			player, err := r.app.FindRecordById("players", playerID)
+			if err != nil {
+				continue
+			}
+			name = player.GetString("name")
+		}
+
+		if online[name] {
+			delete(r.absentSince, record.Id)
+			record.Set("last_seen_at", now)
+			if err := r.app.Save(record); err != nil {
+				r.logger.Debug("Failed to update last seen", "stats", record.Id, "error", err)
+			}
+			continue
+		}
+
+		since, seen := r.absentSince[record.Id]
+		if !seen {
+			r.absentSince[record.Id] = now
+			continue
+		}
+		if now.Sub(since) < r.grace {
+			continue
+		}
+
+		// Leave time is the last sighting when there is one, otherwise the first snapshot without them
+		leftAt := since
+		if lastSeen := record.GetDateTime("last_seen_at"); !lastSeen.IsZero() {
+			leftAt = lastSeen.Time()
+		}
+		if err := database.DisconnectPlayerFromMatch(context.Background(), r.app, match.Id, playerID, &leftAt); err != nil {
+			r.logger.Warn("Failed to disconnect stale player", "player", name, "match", match.Id, "error", err)
+			continue
+		}
+		delete(r.absentSince, record.Id)
+		disconnected++
+		r.logger.Info("Disconnected player missing from A2S", "player", name, "match", match.Id, "absentFor", now.Sub(since))
+	}
+
+	// Forget players that disconnected through the log in the meantime
+	connected := make(map[string]bool, len(records))
+	for _, record := range records {
+		connected[record.Id] = true
+	}
+	for id := range r.absentSince {
+		if !connected[id] {
+			delete(r.absentSince, id)
+		}
+	}
+
+	return disconnected, nil
+}
+
+// maxPresenceSnapshotAge is the oldest A2S snapshot the reconciler trusts. The A2S poll job
+// refreshes snapshots every minute, so anything older means it has stopped reaching the server.
+const maxPresenceSnapshotAge = 3 * time.Minute
+
+// RegisterPresenceReconciler sets up a cron job that reconciles connected players against the
+// cached A2S snapshots every minute. The servers are queried by the A2S poll job.
+func RegisterPresenceReconciler(app AppInterface, cfg *config.Config, reconciler *PresenceReconciler) {
+	logger := app.Logger().With("component", "JOBS")
+
+	app.Cron().MustAdd("a2s_presence", "* * * * *", func() {
+		pool := app.GetA2SPool()
+		if pool == nil {
+			return
+		}
+
+		for _, serverCfg := range cfg.Servers {
+			if !serverCfg.Enabled {
+				continue
+			}
+
+			queryAddr := serverCfg.QueryAddress
+			if queryAddr == "" {
+				queryAddr = serverCfg.RconAddress
+			}
+
+			snapshot, err := pool.Snapshot(queryAddr)
+			if err != nil || !isReliablePlayerSnapshot(snapshot, reconciler.now()) {
+				continue
+			}
+
+			serverRecord, err := getOrCreateServerFromConfig(app, logger, serverCfg)
+			if err != nil {
+				logger.Error("Failed to get server record", "server", serverCfg.Name, "error", err)
+				continue
+			}
+
+			if _, err := reconciler.Reconcile(serverRecord.Id, snapshot.Players); err != nil {
+				logger.Error("Failed to reconcile connected players", "server", serverCfg.Name, "error", err)
+			}
+		}
+	})
+
+	logger.Info("Registered cron job to reconcile connected players with A2S", "grace", reconciler.grace)
+}
+
+// isReliablePlayerSnapshot reports whether an A2S snapshot can be trusted to list everyone online.
+// A failed or old query, a failed player query, or an empty list while the server info reports
+// players must not disconnect anyone.
+func isReliablePlayerSnapshot(snapshot a2s.Snapshot, now time.Time) bool {
+	if snapshot.State() != a2s.SnapshotOK || snapshot.PlayersErr != nil || snapshot.Age(now) > maxPresenceSnapshotAge {
+		return false
+	}
+	if len(snapshot.Players) == 0 && snapshot.Info != nil && snapshot.Info.Players > 0 {
+		return false
+	}
+	return true
+}
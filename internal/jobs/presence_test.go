@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// TestPresenceReconciler_DisconnectsMissingPlayers leaves a player "connected" in the stats
+// after a missed disconnect and checks A2S snapshots without them disconnect them once the
+// grace period has passed
+func TestPresenceReconciler_DisconnectsMissingPlayers(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-presence"
+
+	serverRecordID, err := database.GetOrCreateServer(ctx, testApp, serverID, "Presence Test Server", "test/path")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	mapName := "Farmhouse"
+	match, err := database.CreateMatch(ctx, testApp, serverID, &mapName, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	for _, p := range []struct{ steamID, name string }{
+		{"76561198995742987", "ArmoredBear"},
+		{"76561198995742956", "Rabbit"},
+	} {
+		player, err := database.GetOrCreatePlayerBySteamID(ctx, testApp, p.steamID, p.name)
+		if err != nil {
+			t.Fatalf("failed to create player: %v", err)
+		}
+		if err := database.UpsertMatchPlayerStats(ctx, testApp, match.ID, player.ID, nil, nil); err != nil {
+			t.Fatalf("failed to create match stats: %v", err)
+		}
+	}
+
+	// ArmoredBear has been renamed since, but is still on the server under the name used in the match
+	if _, err := testApp.DB().Update("players", dbx.Params{"name": "PolarBear"}, dbx.HashExp{"external_id": "76561198995742987"}).Execute(); err != nil {
+		t.Fatalf("failed to rename player: %v", err)
+	}
+
+	isConnected := func(name string) bool {
+		t.Helper()
+		stats, err := testApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player_name = {:name}",
+			dbx.Params{"match": match.ID, "name": name})
+		if err != nil {
+			t.Fatalf("failed to find stats for %s: %v", name, err)
+		}
+		return stats.GetBool("is_currently_connected")
+	}
+
+	grace := 2 * time.Minute
+	now := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	reconciler := NewPresenceReconciler(testApp, grace)
+	reconciler.now = func() time.Time { return now }
+
+	// Rabbit's disconnect was missed: only ArmoredBear is still on the server
+	snapshot := []a2s.Player{{Name: "ArmoredBear"}}
+
+	reconcileAt := func(at time.Time) int {
+		t.Helper()
+		now = at
+		disconnected, err := reconciler.Reconcile(serverRecordID, snapshot)
+		if err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+		return disconnected
+	}
+
+	start := now
+	if n := reconcileAt(start); n != 0 {
+		t.Errorf("Expected no disconnects on first miss, got %d", n)
+	}
+	if n := reconcileAt(start.Add(grace - time.Second)); n != 0 {
+		t.Errorf("Expected no disconnects within the grace period, got %d", n)
+	}
+	if !isConnected("Rabbit") {
+		t.Fatal("Expected Rabbit to stay connected within the grace period")
+	}
+
+	if n := reconcileAt(start.Add(grace)); n != 1 {
+		t.Errorf("Expected 1 disconnect after the grace period, got %d", n)
+	}
+	if isConnected("Rabbit") {
+		t.Error("Expected Rabbit to be disconnected after the grace period")
+	}
+	if !isConnected("ArmoredBear") {
+		t.Error("Expected ArmoredBear to stay connected while present in A2S")
+	}
+
+	seen, err := testApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && is_currently_connected = true",
+		dbx.Params{"match": match.ID})
+	if err != nil {
+		t.Fatalf("failed to find connected stats: %v", err)
+	}
+	if got := seen.GetDateTime("last_seen_at").Time(); !got.Equal(start.Add(grace)) {
+		t.Errorf("Expected last_seen_at %v, got %v", start.Add(grace), got)
+	}
+}
+
+func TestIsReliablePlayerSnapshot(t *testing.T) {
+	now := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	queried := now.Add(-time.Minute)
+	ok := func(snapshot a2s.Snapshot) a2s.Snapshot {
+		snapshot.LastQuery, snapshot.LastSuccess = queried, queried
+		return snapshot
+	}
+
+	tests := []struct {
+		name     string
+		snapshot a2s.Snapshot
+		want     bool
+	}{
+		{"offline", a2s.Snapshot{LastQuery: queried, LastSuccess: queried.Add(-time.Minute), Error: errors.New("i/o timeout")}, false},
+		{"never queried", a2s.Snapshot{}, false},
+		{"player query failed", ok(a2s.Snapshot{Players: []a2s.Player{{Name: "ArmoredBear"}}, PlayersErr: errors.New("i/o timeout")}), false},
+		{"empty list but info reports players", ok(a2s.Snapshot{Info: &a2s.ServerInfo{Players: 3}}), false},
+		{"empty server", ok(a2s.Snapshot{Info: &a2s.ServerInfo{Players: 0}}), true},
+		{"players listed", ok(a2s.Snapshot{Players: []a2s.Player{{Name: "ArmoredBear"}}}), true},
+		{"too old", a2s.Snapshot{LastQuery: now.Add(-time.Hour), LastSuccess: now.Add(-time.Hour), Players: []a2s.Player{{Name: "ArmoredBear"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isReliablePlayerSnapshot(tt.snapshot, now); got != tt.want {
+				t.Errorf("isReliablePlayerSnapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"sandstorm-tracker/internal/config"
 	"sandstorm-tracker/internal/ghupdate"
+	"sandstorm-tracker/internal/rcon"
 
 	"github.com/pocketbase/pocketbase/core"
 	// "sandstorm-tracker/internal/parser"
@@ -107,7 +108,7 @@ func isAllServersIdle(ctx context.Context, app AppInterface, cfg *config.Config,
 			return false, nil
 		}
 
-		players := parseRconListPlayers(response)
+		players := rcon.ParseListPlayers(response)
 		if len(players) > 0 {
 			logger.Info("Found players on server", slog.Any("players", players))
 			return false, nil
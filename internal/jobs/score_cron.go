@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"sandstorm-tracker/internal/a2s"
 	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/rcon"
 	"sandstorm-tracker/internal/util"
 	"time"
 
@@ -63,7 +64,7 @@ func RegisterScoreUpdaterForServer(app AppInterface, cfg *config.Config, serverI
 	}
 
 	if serverCfg == nil {
-		logger.Warn("Could not find server config", "serverID", serverID)
+		logger.Warn("Could not find server config", "server_id", serverID)
 		return
 	}
 
@@ -139,7 +140,7 @@ func RegisterScoreUpdaterForServer(app AppInterface, cfg *config.Config, serverI
 		processServerStatus(ctx, app, logger, *serverCfg, status)
 	})
 
-	logger.Info("Registered cron job for server", "serverID", serverID, "serverName", serverCfg.Name)
+	logger.Info("Registered cron job for server", "server_id", serverID, "serverName", serverCfg.Name)
 }
 
 // UnregisterScoreUpdaterForServer removes the cron job for a specific server
@@ -150,7 +151,7 @@ func UnregisterScoreUpdaterForServer(app AppInterface, serverID string) {
 	jobName := fmt.Sprintf("rcon_player_scores_%s", serverID)
 
 	scheduler.Remove(jobName)
-	logger.Info("Unregistered cron job for server", "serverID", serverID)
+	logger.Info("Unregistered cron job for server", "server_id", serverID)
 }
 
 // processServerStatus processes a single server's A2S query result
@@ -316,7 +317,7 @@ func getOrCreateServerFromConfig(pbApp core.App, logger *slog.Logger, cfg config
 		return nil, err
 	}
 
-	logger.Info("Created server record", "server", cfg.Name, "serverID", serverID)
+	logger.Info("Created server record", "server", cfg.Name, "server_id", serverID)
 	return record, nil
 }
 
@@ -348,8 +349,9 @@ func findPlayerByName(pbApp core.App, name string) (*core.Record, error) {
 	return record, err
 }
 
-// updatePlayerMatchScore updates a player's score in the match_player_stats table
-func updatePlayerMatchScore(pbApp core.App, logger *slog.Logger, matchID, playerID string, score int32) error {
+// updatePlayerMatchScore updates a player's score and latest ping in the match_player_stats table.
+// A negative ping means the listplayers response did not report one.
+func updatePlayerMatchScore(pbApp core.App, logger *slog.Logger, matchID, playerID string, score int32, ping int) error {
 	collection, err := pbApp.FindCollectionByNameOrId("match_player_stats")
 	if err != nil {
 		return fmt.Errorf("failed to find collection: %w", err)
@@ -388,6 +390,10 @@ func updatePlayerMatchScore(pbApp core.App, logger *slog.Logger, matchID, player
 		record.Set("total_play_time", playTimeSeconds)
 	}
 
+	if ping >= 0 {
+		record.Set("ping", ping)
+	}
+
 	if err := pbApp.Save(record); err != nil {
 		return fmt.Errorf("failed to save record: %w", err)
 	}
@@ -395,88 +401,23 @@ func updatePlayerMatchScore(pbApp core.App, logger *slog.Logger, matchID, player
 	return nil
 }
 
-// RconPlayer represents a player from RCON listplayers response
-type RconPlayer struct {
-	Name  string
-	Score int32
-	NetID string
-}
-
 // queryPlayersViaRcon queries players using RCON listplayers command
-func queryPlayersViaRcon(app AppInterface, serverID string) ([]RconPlayer, error) {
+func queryPlayersViaRcon(app AppInterface, serverID string) ([]rcon.PlayerEntry, error) {
 	response, err := app.SendRconCommand(serverID, "listplayers")
 	if err != nil {
 		return nil, err
 	}
 
-	app.Logger().Info("RCON listplayers response", "component", "SCORE_DEBOUNCER", "serverID", serverID, "response", response)
-	players := parseRconListPlayers(response)
-	app.Logger().Info("Parsed RCON players", "component", "SCORE_DEBOUNCER", "serverID", serverID, "count", len(players))
+	app.Logger().Info("RCON listplayers response", "component", "SCORE_DEBOUNCER", "server_id", serverID, "response", response)
+	players := rcon.ParseListPlayers(response)
+	app.Logger().Info("Parsed RCON players", "component", "SCORE_DEBOUNCER", "server_id", serverID, "count", len(players))
 
 	return players, nil
 }
 
-// parseRconListPlayers parses the RCON listplayers response
-// Format: All player data is on one line, pipe-separated
-// Each player: ID\t | Name\t | NetID\t | IP\t | Score\t | ...
-func parseRconListPlayers(response string) []RconPlayer {
-	players := []RconPlayer{}
-
-	lines := splitLines(response)
-	for _, line := range lines {
-		// Skip header and separator lines
-		if len(line) < 10 || line[0] == '=' || containsString(line, "ID") && containsString(line, "Name") {
-			continue
-		}
-
-		// All player data is on one line, pipe-separated
-		// Split by pipe to get all fields
-		parts := splitOnPipe(line)
-
-		// Process fields in groups of 6 (ID, Name, NetID, IP, Score, ...)
-		// But the actual format might have extra fields, so we look for patterns
-		for i := 0; i+4 < len(parts); i += 5 {
-			// Extract and trim fields (accounting for tabs)
-			idStr := trimSpace(parts[i])
-			name := trimSpace(parts[i+1])
-			netID := trimSpace(parts[i+2])
-			// parts[i+3] is IP, skip it
-			scoreStr := trimSpace(parts[i+4])
-
-			// Skip if ID is empty or 0 (invalid entries)
-			if idStr == "" || idStr == "0" {
-				continue
-			}
-
-			// Skip if name is empty, matches default classes, or is a number
-			if name == "" || name == "Observer" || name == "Commander" || name == "Marksman" || name == "0" || name == "1" || name == "2" {
-				continue
-			}
-
-			// Skip if NetID is empty or invalid (accept Steam, Epic, and other platforms)
-			// Valid NetIDs contain platform identifiers like "SteamNWI:", "EOS:", etc.
-			if netID == "" || netID == "0" || containsString(netID, "None:INVALID") {
-				continue
-			}
-
-			// Parse score
-			score := int32(0)
-			fmt.Sscanf(scoreStr, "%d", &score)
-
-			players = append(players, RconPlayer{
-				Name:  name,
-				Score: score,
-				NetID: netID,
-			})
-		}
-	}
-
-	return players
-}
-
 // updatePlayersFromRcon updates player scores from RCON data
 // All updates are wrapped in a transaction for consistency
-func updatePlayersFromRcon(app AppInterface, logger *slog.Logger, matchID string, players []RconPlayer) {
+func updatePlayersFromRcon(app AppInterface, logger *slog.Logger, matchID string, players []rcon.PlayerEntry) {
 	successCount := 0
 
 	err := app.RunInTransaction(func(txApp core.App) error {
@@ -490,7 +431,7 @@ func updatePlayersFromRcon(app AppInterface, logger *slog.Logger, matchID string
 			}
 
 			// Update match score
-			err = updatePlayerMatchScore(txApp, logger, matchID, playerRecord.Id, player.Score)
+			err = updatePlayerMatchScore(txApp, logger, matchID, playerRecord.Id, player.Score, player.Ping)
 			if err != nil {
 				// Return error to rollback all updates in this batch
 				return fmt.Errorf("failed to update score for player %s: %w", player.Name, err)
@@ -509,77 +450,3 @@ func updatePlayersFromRcon(app AppInterface, logger *slog.Logger, matchID string
 		logger.Info("Updated scores for players", "count", successCount)
 	}
 }
-
-// Helper string functions
-func splitLines(s string) []string {
-	lines := []string{}
-	current := ""
-	for _, c := range s {
-		if c == '\n' || c == '\r' {
-			if len(current) > 0 {
-				lines = append(lines, current)
-				current = ""
-			}
-		} else {
-			current += string(c)
-		}
-	}
-	if len(current) > 0 {
-		lines = append(lines, current)
-	}
-	return lines
-}
-
-func splitOnPipe(s string) []string {
-	parts := []string{}
-	current := ""
-	for _, c := range s {
-		if c == '|' {
-			parts = append(parts, current)
-			current = ""
-		} else {
-			current += string(c)
-		}
-	}
-	parts = append(parts, current)
-	return parts
-}
-
-func trimSpace(s string) string {
-	// Trim leading/trailing whitespace
-	start := 0
-	end := len(s)
-
-	for start < len(s) && (s[start] == ' ' || s[start] == '\t') {
-		start++
-	}
-
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t') {
-		end--
-	}
-
-	if start >= end {
-		return ""
-	}
-	return s[start:end]
-}
-
-func containsString(s, substr string) bool {
-	return len(s) >= len(substr) && indexOf(s, substr) >= 0
-}
-
-func indexOf(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		match := true
-		for j := 0; j < len(substr); j++ {
-			if s[i+j] != substr[j] {
-				match = false
-				break
-			}
-		}
-		if match {
-			return i
-		}
-	}
-	return -1
-}
@@ -1,70 +1,14 @@
 package jobs
 
 import (
+	"context"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
-func TestParseRconListPlayers(t *testing.T) {
-	// Real response format from RCON listplayers with tabs
-	response := "ID\t | Name\t\t\t\t | NetID\t\t\t | IP\t\t\t | Score\t\t |\n" +
-		"===============================================================================\n" +
-		"0\t | \t\t\t\t | None:INVALID\t | \t | 0\t\t | " +
-		"256\t | ArmoredBear\t | SteamNWI:76561198995742987\t | 127.0.0.1\t | 150\t\t | " +
-		"0\t | Observer\t\t | None:INVALID\t | \t | 10\t\t | " +
-		"0\t | Commander\t | None:INVALID\t | \t | 0\t\t | " +
-		"0\t | Marksman\t\t | None:INVALID\t | \t | 0\t\t | "
-
-	t.Logf("Raw response: %q", response)
-	players := parseRconListPlayers(response)
-
-	if len(players) != 1 {
-		t.Errorf("Expected 1 player, got %d", len(players))
-		for i, p := range players {
-			t.Logf("Player %d: Name=%s, Score=%d, NetID=%s", i, p.Name, p.Score, p.NetID)
-		}
-		// Debug: print lines
-		lines := splitLines(response)
-		t.Logf("Total lines: %d", len(lines))
-		for i, line := range lines {
-			t.Logf("Line %d (len=%d): %q", i, len(line), line)
-		}
-		return
-	}
-
-	player := players[0]
-	if player.Name != "ArmoredBear" {
-		t.Errorf("Expected name 'ArmoredBear', got '%s'", player.Name)
-	}
-
-	if player.Score != 150 {
-		t.Errorf("Expected score 150, got %d", player.Score)
-	}
-
-	if player.NetID != "SteamNWI:76561198995742987" {
-		t.Errorf("Expected NetID 'SteamNWI:76561198995742987', got '%s'", player.NetID)
-	}
-}
-
-func TestParseRconListPlayers_SkipsInvalidEntries(t *testing.T) {
-	// Test that invalid entries are properly filtered out
-	response := "ID\t | Name\t\t\t\t | NetID\t\t\t | IP\t\t\t | Score\t\t |\n" +
-		"===============================================================================\n" +
-		"0\t | Observer\t\t | None:INVALID\t | \t | 0\t\t | " +
-		"0\t | Commander\t | None:INVALID\t | \t | 0\t\t | " +
-		"0\t | Marksman\t\t | None:INVALID\t | \t | 0\t\t | "
-
-	players := parseRconListPlayers(response)
-
-	if len(players) != 0 {
-		t.Errorf("Expected 0 players, got %d", len(players))
-		for i, p := range players {
-			t.Logf("Unexpected player %d: Name=%s, Score=%d, NetID=%s", i, p.Name, p.Score, p.NetID)
-		}
-	}
-}
-
 func TestScoreDebouncer_TriggerScoreUpdateFixed(t *testing.T) {
 	// Test that TriggerScoreUpdateFixed creates a timer with fixed delay
 	// and clears the firstTriggerAt (indicating it's not using debounce logic)
@@ -147,3 +91,101 @@ func TestScoreDebouncer_FixedDelayReplacesDebounce(t *testing.T) {
 
 	t.Log("Fixed delay successfully cancelled and replaced debounce timer")
 }
+
+// newCountingDebouncer returns a debouncer whose updates only count per-server calls
+func newCountingDebouncer(debounceWindow, maxWait, minInterval time.Duration) (*ScoreDebouncer, *atomic.Int32) {
+	var updates atomic.Int32
+	return &ScoreDebouncer{
+		timers:         make(map[string]*time.Timer),
+		firstTriggerAt: make(map[string]time.Time),
+		lastRunAt:      make(map[string]time.Time),
+		running:        make(map[string]*sync.Mutex),
+		logger:         slog.Default(),
+		debounceWindow: debounceWindow,
+		maxWait:        maxWait,
+		minInterval:    minInterval,
+		update:         func(serverID string) { updates.Add(1) },
+	}, &updates
+}
+
+func TestScoreDebouncer_CoalescesRapidTriggers(t *testing.T) {
+	debouncer, updates := newCountingDebouncer(100*time.Millisecond, time.Second, 0)
+	defer debouncer.Stop()
+
+	for i := 0; i < 50; i++ {
+		debouncer.TriggerScoreUpdate("test-server")
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	if got := updates.Load(); got != 1 {
+		t.Errorf("Expected 50 rapid triggers to result in 1 update, got %d", got)
+	}
+}
+
+func TestScoreDebouncer_CapsUpdateFrequency(t *testing.T) {
+	// Events arrive faster than the debounce window for ~600ms; maxWait forces an
+	// update every 100ms but minInterval only allows one every 250ms
+	debouncer, updates := newCountingDebouncer(50*time.Millisecond, 100*time.Millisecond, 250*time.Millisecond)
+	defer debouncer.Stop()
+
+	deadline := time.Now().Add(600 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		debouncer.TriggerScoreUpdate("test-server")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := updates.Load(); got < 1 || got > 3 {
+		t.Errorf("Expected between 1 and 3 updates under constant load, got %d", got)
+	}
+}
+
+func TestScoreDebouncer_ExecuteImmediatelyJumpsQueue(t *testing.T) {
+	debouncer, updates := newCountingDebouncer(200*time.Millisecond, time.Second, time.Second)
+	defer debouncer.Stop()
+
+	debouncer.TriggerScoreUpdate("test-server")
+	debouncer.ExecuteImmediately("test-server")
+
+	if got := updates.Load(); got != 1 {
+		t.Fatalf("Expected ExecuteImmediately to update synchronously, got %d updates", got)
+	}
+
+	// The pending debounced update was cancelled, not run a second time
+	time.Sleep(300 * time.Millisecond)
+	if got := updates.Load(); got != 1 {
+		t.Errorf("Expected the pending update to be cancelled, got %d updates", got)
+	}
+
+	// minInterval does not hold back an immediate update
+	debouncer.ExecuteImmediately("test-server")
+	if got := updates.Load(); got != 2 {
+		t.Errorf("Expected a second immediate update, got %d updates", got)
+	}
+}
+
+func TestScoreDebouncer_FlushRunsPendingUpdates(t *testing.T) {
+	debouncer, updates := newCountingDebouncer(time.Minute, time.Minute, 0)
+	defer debouncer.Stop()
+
+	debouncer.TriggerScoreUpdate("server-a")
+	debouncer.TriggerScoreUpdateFixed("server-b", time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := debouncer.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := updates.Load(); got != 2 {
+		t.Fatalf("Expected both pending updates to run on flush, got %d updates", got)
+	}
+
+	// Nothing is left pending to run a second time
+	if err := debouncer.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := updates.Load(); got != 2 {
+		t.Errorf("Expected no further updates, got %d updates", got)
+	}
+}
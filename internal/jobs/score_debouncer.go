@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"context"
 	"log/slog"
 	"sync"
 	"time"
@@ -13,36 +14,47 @@ import (
 // When game events occur (kills, objectives, etc), we trigger a score update
 // The update is debounced so multiple events within the debounce window
 // result in only one RCON query and database update
-// If events keep happening rapidly, scores will still update after maxWait duration
+// If events keep happening rapidly, scores will still update after maxWait duration,
+// and never more often than once per minInterval per server
 type ScoreDebouncer struct {
 	app            AppInterface
 	cfg            *config.Config
 	logger         *slog.Logger
 	debounceWindow time.Duration
 	maxWait        time.Duration // Maximum time to wait before forcing an update
+	minInterval    time.Duration // Minimum time between two debounced updates of the same server
+	update         func(serverID string)
 
 	mu             sync.Mutex
 	timers         map[string]*time.Timer // serverID -> debounce timer
 	firstTriggerAt map[string]time.Time   // serverID -> time of first trigger in current window
+	lastRunAt      map[string]time.Time   // serverID -> start of the last update
+	running        map[string]*sync.Mutex // serverID -> held while an update runs
 }
 
 // NewScoreDebouncer creates a new score debouncer
 // maxWait should typically be 2-3x the debounceWindow to ensure updates during continuous activity
-func NewScoreDebouncer(app AppInterface, cfg *config.Config, debounceWindow time.Duration, maxWait time.Duration) *ScoreDebouncer {
-	return &ScoreDebouncer{
+func NewScoreDebouncer(app AppInterface, cfg *config.Config, debounceWindow, maxWait, minInterval time.Duration) *ScoreDebouncer {
+	d := &ScoreDebouncer{
 		app:            app,
 		cfg:            cfg,
 		logger:         app.Logger().With("component", "SCORE_DEBOUNCER"),
 		debounceWindow: debounceWindow,
 		maxWait:        maxWait,
+		minInterval:    minInterval,
 		timers:         make(map[string]*time.Timer),
 		firstTriggerAt: make(map[string]time.Time),
+		lastRunAt:      make(map[string]time.Time),
+		running:        make(map[string]*sync.Mutex),
 	}
+	d.update = d.executeScoreUpdate
+	return d
 }
 
 // TriggerScoreUpdate signals that a score-affecting event occurred for a server
 // The actual score update will be debounced and executed after the debounce window
 // However, if events keep happening, update will be forced after maxWait duration
+// Either way it is held back until minInterval has passed since the previous update
 func (d *ScoreDebouncer) TriggerScoreUpdate(serverID string) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -57,50 +69,27 @@ func (d *ScoreDebouncer) TriggerScoreUpdate(serverID string) {
 		firstTrigger = now
 	}
 
-	// Calculate time since first trigger
-	timeSinceFirst := now.Sub(firstTrigger)
-
-	// If we've exceeded maxWait, force update immediately
-	if timeSinceFirst >= d.maxWait {
-		d.logger.Debug("Max wait exceeded, forcing score update",
-			"serverID", serverID, "timeSinceFirst", timeSinceFirst)
+	// Push the update back by the debounce window, but not past maxWait after the first trigger
+	fireAt := now.Add(d.debounceWindow)
+	if deadline := firstTrigger.Add(d.maxWait); fireAt.After(deadline) {
+		fireAt = deadline
+	}
 
-		// Stop existing timer if any
-		if timer, exists := d.timers[serverID]; exists {
-			timer.Stop()
+	// Under constant load, cap how often the same server is updated
+	if lastRun, ok := d.lastRunAt[serverID]; ok {
+		if earliest := lastRun.Add(d.minInterval); fireAt.Before(earliest) {
+			fireAt = earliest
 		}
-
-		// Execute immediately in goroutine
-		go func() {
-			d.executeScoreUpdate(serverID)
-
-			// Clean up
-			d.mu.Lock()
-			delete(d.timers, serverID)
-			delete(d.firstTriggerAt, serverID)
-			d.mu.Unlock()
-		}()
-
-		return
-	}
-	// Normal debounce behavior - stop existing timer if any
-	if timer, exists := d.timers[serverID]; exists {
-		timer.Stop()
 	}
 
-	// Create a new timer that will execute the score update after the debounce window
-	d.timers[serverID] = time.AfterFunc(d.debounceWindow, func() {
-		d.executeScoreUpdate(serverID)
-
-		// Clean up the timer and first trigger time
-		d.mu.Lock()
-		delete(d.timers, serverID)
-		delete(d.firstTriggerAt, serverID)
-		d.mu.Unlock()
-	})
+	delay := fireAt.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	d.schedule(serverID, delay)
 
 	d.logger.Debug("Score update triggered",
-		"serverID", serverID, "debounce", d.debounceWindow, "timeSinceFirst", timeSinceFirst)
+		"server_id", serverID, "delay", delay, "timeSinceFirst", now.Sub(firstTrigger))
 }
 
 // TriggerScoreUpdateFixed triggers a score update with a fixed delay, ignoring debounce logic
@@ -110,26 +99,58 @@ func (d *ScoreDebouncer) TriggerScoreUpdateFixed(serverID string, delay time.Dur
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	// Clear the first trigger time since we're using fixed delay
+	delete(d.firstTriggerAt, serverID)
+
+	d.schedule(serverID, delay)
+
+	d.logger.Debug("Score update scheduled with fixed delay",
+		"server_id", serverID, "delay", delay)
+}
+
+// schedule replaces the server's pending timer with one that runs the update after delay
+// The caller must hold d.mu
+func (d *ScoreDebouncer) schedule(serverID string, delay time.Duration) {
 	// Stop existing timer if any
 	if timer, exists := d.timers[serverID]; exists {
 		timer.Stop()
 	}
 
-	// Clear the first trigger time since we're using fixed delay
-	delete(d.firstTriggerAt, serverID)
-
-	// Create a new timer with the specified fixed delay
-	d.timers[serverID] = time.AfterFunc(delay, func() {
-		d.executeScoreUpdate(serverID)
-
-		// Clean up the timer
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
 		d.mu.Lock()
+		// A timer that already fired when it was replaced must not run a second update
+		if d.timers[serverID] != timer {
+			d.mu.Unlock()
+			return
+		}
 		delete(d.timers, serverID)
+		delete(d.firstTriggerAt, serverID)
 		d.mu.Unlock()
+
+		d.run(serverID)
 	})
+	d.timers[serverID] = timer
+}
 
-	d.logger.Debug("Score update scheduled with fixed delay",
-		"serverID", serverID, "delay", delay)
+// run executes the score update, one at a time per server
+func (d *ScoreDebouncer) run(serverID string) {
+	d.mu.Lock()
+	lock, ok := d.running[serverID]
+	if !ok {
+		lock = &sync.Mutex{}
+		d.running[serverID] = lock
+	}
+	d.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	d.mu.Lock()
+	d.lastRunAt[serverID] = time.Now()
+	d.mu.Unlock()
+
+	d.update(serverID)
 }
 
 // executeScoreUpdate performs the actual RCON query and database update
@@ -153,11 +174,11 @@ func (d *ScoreDebouncer) executeScoreUpdate(serverID string) {
 	}
 
 	if serverCfg == nil {
-		d.logger.Warn("Could not find server config for score update", "serverID", serverID)
+		d.logger.Warn("Could not find server config for score update", "server_id", serverID)
 		return
 	}
 
-	d.logger.Info("Executing score update", "server", serverCfg.Name, "serverID", serverID, "component", "SCORE_DEBOUNCER")
+	d.logger.Info("Executing score update", "server", serverCfg.Name, "server_id", serverID, "component", "SCORE_DEBOUNCER")
 
 	// Find or create server record
 	serverRecord, err := getOrCreateServerFromConfig(d.app, d.logger, *serverCfg)
@@ -178,19 +199,19 @@ func (d *ScoreDebouncer) executeScoreUpdate(serverID string) {
 		d.logger.Info("No active match found for server, skipping score update", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name)
 		return
 	}
-	d.logger.Debug("Found active match", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name, "matchID", activeMatch.Id)
+	d.logger.Debug("Found active match", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name, "match_id", activeMatch.Id)
 
 	// Query players via RCON
-	d.logger.Debug("Querying players via RCON", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name, "serverID", serverID)
+	d.logger.Debug("Querying players via RCON", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name, "server_id", serverID)
 	players, err := queryPlayersViaRcon(d.app, serverID)
 	if err != nil {
-		d.logger.Error("Failed to query players via RCON", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name, "serverID", serverID, "error", err)
+		d.logger.Error("Failed to query players via RCON", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name, "server_id", serverID, "error", err)
 		return
 	}
 
 	// Update player scores in database
 	if len(players) > 0 {
-		d.logger.Info("Updating player scores", "component", "SCORE_DEBOUNCER", "count", len(players), "server", serverCfg.Name, "matchID", activeMatch.Id)
+		d.logger.Info("Updating player scores", "component", "SCORE_DEBOUNCER", "count", len(players), "server", serverCfg.Name, "match_id", activeMatch.Id)
 		updatePlayersFromRcon(d.app, d.logger, activeMatch.Id, players)
 		d.logger.Info("Finished updating player scores", "component", "SCORE_DEBOUNCER", "server", serverCfg.Name)
 	} else {
@@ -199,7 +220,8 @@ func (d *ScoreDebouncer) executeScoreUpdate(serverID string) {
 }
 
 // ExecuteImmediately cancels any pending debounce and executes the score update right now
-// Used for game over events when we want final scores immediately
+// Used for round and game over events when we want final scores immediately
+// It skips the debounce and minInterval limits and only waits for an update already in progress
 func (d *ScoreDebouncer) ExecuteImmediately(serverID string) {
 	d.mu.Lock()
 	// Stop existing timer if any
@@ -210,8 +232,58 @@ func (d *ScoreDebouncer) ExecuteImmediately(serverID string) {
 	delete(d.firstTriggerAt, serverID)
 	d.mu.Unlock()
 
-	d.logger.Info("Executing immediate score update", "serverID", serverID)
-	d.executeScoreUpdate(serverID)
+	d.logger.Info("Executing immediate score update", "server_id", serverID)
+	d.run(serverID)
+}
+
+// Flush runs every pending score update now rather than at its scheduled time, then waits for
+// them and any update already in progress to finish. Used on shutdown so scores from the last
+// events are not lost. Returns ctx's error if it is done first; the updates keep running.
+func (d *ScoreDebouncer) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	pending := make([]string, 0, len(d.timers))
+	for serverID, timer := range d.timers {
+		timer.Stop()
+		pending = append(pending, serverID)
+	}
+	d.timers = make(map[string]*time.Timer)
+	d.firstTriggerAt = make(map[string]time.Time)
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for _, serverID := range pending {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.logger.Info("Flushing pending score update", "server_id", serverID)
+				d.run(serverID)
+			}()
+		}
+		wg.Wait()
+
+		// Wait out updates started before the flush
+		d.mu.Lock()
+		running := make([]*sync.Mutex, 0, len(d.running))
+		for _, lock := range d.running {
+			running = append(running, lock)
+		}
+		d.mu.Unlock()
+		for _, lock := range running {
+			lock.Lock()
+			lock.Unlock()
+		}
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // Stop cancels all pending score updates
@@ -221,8 +293,9 @@ func (d *ScoreDebouncer) Stop() {
 
 	for serverID, timer := range d.timers {
 		timer.Stop()
-		d.logger.Debug("Stopped pending score update", "serverID", serverID)
+		d.logger.Debug("Stopped pending score update", "server_id", serverID)
 	}
 
 	d.timers = make(map[string]*time.Timer)
+	d.firstTriggerAt = make(map[string]time.Time)
 }
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/database"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterStaleMatchCloser sets up a cron job that closes active matches with no activity for
+// longer than idle every 5 minutes (see database.CloseStaleMatches). snapshots returns the cached
+// A2S snapshots by server external ID; servers answering them keep their match.
+func RegisterStaleMatchCloser(app core.App, idle time.Duration, snapshots func() map[string]a2s.Snapshot, logger *slog.Logger) {
+	app.Cron().MustAdd("close_stale_matches", "*/5 * * * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		closed, err := database.CloseStaleMatches(ctx, app, idle, a2s.OnlineServers(snapshots()), time.Now())
+		if err != nil {
+			logger.Error("Failed to close stale matches", "error", err)
+		}
+		for _, match := range closed {
+			logger.Info("Closed stale match", "match", match.MatchID, "server", match.ServerID,
+				"status", match.Status, "lastActivity", match.LastActivity)
+		}
+	})
+
+	logger.Info("Registered cron job to close stale matches every 5 minutes", "idle", idle)
+}
@@ -1,15 +1,20 @@
 package loader
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
 
+	"sandstorm-tracker/internal/database"
 	"sandstorm-tracker/internal/parser"
 
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 )
 
 // LoadLogsFromPath loads log files from the specified path into the database.
@@ -41,3 +46,133 @@ func LoadLogsFromPath(app *pocketbase.PocketBase, logPath string, serverID strin
 	logger.Info("Log file loaded successfully", "path", logPath)
 	return nil
 }
+
+// ReplayResult summarizes a replayed log file
+type ReplayResult struct {
+	LinesProcessed int
+	StartLine      int    // Line of the map event the replay started after (0 when replaying the whole file)
+	Map            string // Map the replay started on, when started mid-file
+}
+
+// ReplayLogFile streams a historical log file through the parser in catchup mode, so events are recorded
+// without RCON side effects or score updates. Handlers must already be registered on app.
+// When since is set, only the slice starting at the last map event before since is replayed; the match
+// for that map is established first (like startup catch-up) so the slice lands in the right match.
+func ReplayLogFile(ctx context.Context, app core.App, logParser *parser.LogParser, logPath, serverID string, since time.Time) (*ReplayResult, error) {
+	logger := app.Logger().With("COMPONENT", "LOG_REPLAY")
+
+	file, err := parser.OpenLogFile(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := database.GetOrCreateServer(ctx, app, serverID, serverID, logPath); err != nil {
+		return nil, fmt.Errorf("failed to get or create server: %w", err)
+	}
+
+	result := &ReplayResult{}
+
+	if !since.IsZero() {
+		mapName, scenario, mapTime, lineNum, err := logParser.FindLastMapEvent(logPath, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find map event before %s: %w", since.Format(time.RFC3339), err)
+		}
+		result.StartLine = lineNum
+		result.Map = mapName
+
+		if err := bootstrapMatch(ctx, app, logger, serverID, mapName, scenario, mapTime); err != nil {
+			return nil, err
+		}
+	}
+
+	catchupCtx := parser.WithCatchupMode(ctx)
+
+	scanner := bufio.NewScanner(parser.NewLogReader(file))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		// The map event line itself is skipped - the match was created above
+		if result.StartLine > 0 && lineNum <= result.StartLine {
+			lineNum++
+			continue
+		}
+
+		if err := logParser.ParseAndProcess(catchupCtx, scanner.Text(), serverID, logPath); err != nil {
+			logger.Debug("Error processing line in replay", "lineNum", lineNum, "error", err)
+		}
+		result.LinesProcessed++
+		lineNum++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	logger.Info("Log file replayed", "path", logPath, "server_id", serverID, "lines", result.LinesProcessed, "startLine", result.StartLine)
+	return result, nil
+}
+
+// ReplayLogFileFrom replays a log file in catchup mode from a byte offset, which must be the start
+// of a line (such as the offset the watcher saved for the server). The map the server was on is
+// taken from the last map event before the offset and its match made the active one first, so
+// the lines replayed are recorded in that match rather than whichever match happens to be active.
+func ReplayLogFileFrom(ctx context.Context, app core.App, logParser *parser.LogParser, logPath, serverID string, offset int64) (*ReplayResult, error) {
+	logger := app.Logger().With("COMPONENT", "LOG_REPLAY")
+
+	file, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := database.GetOrCreateServer(ctx, app, serverID, serverID, logPath); err != nil {
+		return nil, fmt.Errorf("failed to get or create server: %w", err)
+	}
+
+	mapName, scenario, mapTime, lineNum, err := logParser.FindLastMapEventBeforeOffset(logPath, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find map event before offset %d: %w", offset, err)
+	}
+	if err := bootstrapMatch(ctx, app, logger, serverID, mapName, scenario, mapTime); err != nil {
+		return nil, err
+	}
+	result := &ReplayResult{StartLine: lineNum, Map: mapName}
+
+	encoding := parser.DetectLogFileEncoding(file)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to offset %d: %w", offset, err)
+	}
+
+	catchupCtx := parser.WithCatchupMode(ctx)
+	reader := parser.NewLogLineReader(file, encoding)
+	for {
+		line, _, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to read log file: %w", err)
+		}
+
+		if err := logParser.ParseAndProcess(catchupCtx, line, serverID, logPath); err != nil {
+			logger.Debug("Error processing line in replay", "offset", offset, "error", err)
+		}
+		result.LinesProcessed++
+	}
+
+	logger.Info("Log file replayed", "path", logPath, "server_id", serverID, "lines", result.LinesProcessed, "offset", offset, "map", mapName)
+	return result, nil
+}
+
+// bootstrapMatch makes the match started by the map event a mid-file replay begins after the
+// server's active match, since the replay never sees that event itself
+func bootstrapMatch(ctx context.Context, app core.App, logger *slog.Logger, serverID, mapName, scenario string, mapTime time.Time) error {
+	created, err := database.EnsureActiveMatch(ctx, app, serverID, mapName, scenario, mapTime)
+	if err != nil {
+		return fmt.Errorf("failed to create match: %w", err)
+	}
+	if created {
+		logger.Info("Created match for replay", "server_id", serverID, "map", mapName, "scenario", scenario)
+	}
+	return nil
+}
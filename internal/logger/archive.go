@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CompressFile gzips a file to <file>.gz and removes the original.
+// The archive keeps the original's modification time so backups still prune oldest first.
+func CompressFile(path string) (string, error) {
+	if strings.HasSuffix(path, ".gz") {
+		return path, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	// Write to a temp file and rename so a crash never leaves a truncated archive
+	archive := path + ".gz"
+	tmp := archive + ".tmp"
+	if err := writeGzip(tmp, src); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, archive); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	_ = os.Chtimes(archive, info.ModTime(), info.ModTime())
+
+	src.Close()
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove %s after compressing it: %w", path, err)
+	}
+	return archive, nil
+}
+
+// writeGzip writes the gzip-compressed contents of src to path
+func writeGzip(path string, src io.Reader) error {
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
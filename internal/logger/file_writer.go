@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,17 +19,16 @@ type RotationPolicy struct {
 // FileWriter handles writing logs to files with rotation
 // Production-grade: thread-safe, non-blocking, efficient buffering
 type FileWriter struct {
-	filePath  string
-	file      *os.File
-	policy    RotationPolicy
-	fileSize  int64 // Current file size
-	fileTime  time.Time
-	writesCh  chan []byte    // Async write channel for non-blocking writes
-	closeCh   chan struct{}  // Signal to stop the writer goroutine
-	wg        sync.WaitGroup // Wait for goroutine to finish
-	mu        sync.RWMutex   // Protect file handle
-	err       error          // Last error encountered
-	rotateDir string         // Directory for rotated logs
+	filePath string
+	file     *os.File
+	policy   RotationPolicy
+	fileSize int64 // Current file size
+	fileTime time.Time
+	writesCh chan []byte    // Async write channel for non-blocking writes
+	closeCh  chan struct{}  // Signal to stop the writer goroutine
+	wg       sync.WaitGroup // Wait for goroutine to finish
+	mu       sync.RWMutex   // Protect file handle
+	err      error          // Last error encountered
 }
 
 // NewFileWriter creates a production-ready file writer with async writes
@@ -40,12 +40,11 @@ func NewFileWriter(filePath string, policy RotationPolicy) (*FileWriter, error)
 	}
 
 	fw := &FileWriter{
-		filePath:  filePath,
-		policy:    policy,
-		fileTime:  time.Now(),
-		writesCh:  make(chan []byte, 1000), // 1000-entry buffer for async writes
-		closeCh:   make(chan struct{}),
-		rotateDir: dir,
+		filePath: filePath,
+		policy:   policy,
+		fileTime: time.Now(),
+		writesCh: make(chan []byte, 1000), // 1000-entry buffer for async writes
+		closeCh:  make(chan struct{}),
 	}
 
 	// Open initial file
@@ -188,15 +187,58 @@ func (fw *FileWriter) rotate() error {
 		fw.file.Close()
 	}
 
+	// Move current file to backup
+	if _, err := moveToBackup(fw.filePath, fw.fileTime); err != nil {
+		return err
+	}
+
+	// Clean old backups
+	cleanOldBackups(fw.filePath, fw.policy.MaxBackups)
+
+	// Open new file
+	if err := fw.openFile(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RotateFile rotates a log file written by another process, which cannot be rotated while it is open.
+// The file is moved to a timestamped backup once it has reached policy.MaxSize, and backups beyond
+// policy.MaxBackups are removed. Returns whether the file was rotated.
+func RotateFile(filePath string, policy RotationPolicy) (bool, error) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	if policy.MaxSize <= 0 || info.Size() < policy.MaxSize {
+		return false, nil
+	}
+
+	if _, err := moveToBackup(filePath, info.ModTime()); err != nil {
+		return false, err
+	}
+	cleanOldBackups(filePath, policy.MaxBackups)
+
+	return true, nil
+}
+
+// moveToBackup renames a log file to <file>.<timestamp>, adding a counter if that backup exists,
+// and returns the backup's path
+func moveToBackup(filePath string, fileTime time.Time) (string, error) {
 	// Generate timestamped backup filename
-	timestamp := fw.fileTime.Format("20060102-150405")
-	backupPath := fmt.Sprintf("%s.%s", fw.filePath, timestamp)
+	timestamp := fileTime.Format("20060102-150405")
+	backupPath := fmt.Sprintf("%s.%s", filePath, timestamp)
 
 	// Ensure backup doesn't already exist
 	if _, err := os.Stat(backupPath); err == nil {
 		// File exists, add counter
 		for i := 1; i < 1000; i++ {
-			counterPath := fmt.Sprintf("%s.%s-%d", fw.filePath, timestamp, i)
+			counterPath := fmt.Sprintf("%s.%s-%d", filePath, timestamp, i)
 			if _, err := os.Stat(counterPath); os.IsNotExist(err) {
 				backupPath = counterPath
 				break
@@ -204,20 +246,10 @@ func (fw *FileWriter) rotate() error {
 		}
 	}
 
-	// Move current file to backup
-	if err := os.Rename(fw.filePath, backupPath); err != nil {
-		return fmt.Errorf("failed to rotate log file: %w", err)
-	}
-
-	// Clean old backups
-	fw.cleanOldBackups()
-
-	// Open new file
-	if err := fw.openFile(); err != nil {
-		return err
+	if err := os.Rename(filePath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to rotate log file: %w", err)
 	}
-
-	return nil
+	return backupPath, nil
 }
 
 // openFile opens the log file for writing
@@ -242,14 +274,19 @@ func (fw *FileWriter) openFile() error {
 	return nil
 }
 
-// cleanOldBackups removes old backup files exceeding MaxBackups limit
-func (fw *FileWriter) cleanOldBackups() {
-	if fw.policy.MaxBackups <= 0 {
+// cleanOldBackups removes old backup files exceeding the maxBackups limit
+func cleanOldBackups(filePath string, maxBackups int) {
+	PruneBackups(filepath.Dir(filePath), []string{filepath.Base(filePath) + "."}, maxBackups)
+}
+
+// PruneBackups removes the oldest files in dir whose names start with one of prefixes,
+// keeping the newest maxBackups of them (0 = keep all)
+func PruneBackups(dir string, prefixes []string, maxBackups int) {
+	if maxBackups <= 0 {
 		return
 	}
 
-	// List files in the log directory
-	entries, err := os.ReadDir(fw.rotateDir)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return
 	}
@@ -261,13 +298,12 @@ func (fw *FileWriter) cleanOldBackups() {
 	}
 	var backups []backup
 
-	baseFileName := filepath.Base(fw.filePath)
 	for _, entry := range entries {
-		if !entry.IsDir() && filepath.HasPrefix(entry.Name(), baseFileName+".") {
+		if !entry.IsDir() && hasAnyPrefix(entry.Name(), prefixes) {
 			info, err := entry.Info()
 			if err == nil {
 				backups = append(backups, backup{
-					name: filepath.Join(fw.rotateDir, entry.Name()),
+					name: filepath.Join(dir, entry.Name()),
 					time: info.ModTime(),
 				})
 			}
@@ -275,7 +311,7 @@ func (fw *FileWriter) cleanOldBackups() {
 	}
 
 	// If we exceed max backups, delete oldest ones
-	if len(backups) > fw.policy.MaxBackups {
+	if len(backups) > maxBackups {
 		// Sort by modification time (oldest first)
 		// Simple bubble sort for small lists
 		for i := 0; i < len(backups); i++ {
@@ -287,8 +323,18 @@ func (fw *FileWriter) cleanOldBackups() {
 		}
 
 		// Delete oldest files
-		for i := 0; i < len(backups)-fw.policy.MaxBackups; i++ {
+		for i := 0; i < len(backups)-maxBackups; i++ {
 			_ = os.Remove(backups[i].name)
 		}
 	}
 }
+
+// hasAnyPrefix reports whether name starts with one of prefixes
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
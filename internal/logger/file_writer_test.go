@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "server.stdout.log")
+	policy := RotationPolicy{MaxSize: 10, MaxBackups: 2}
+
+	backups := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read log directory: %v", err)
+		}
+		var names []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), "server.stdout.log.") {
+				names = append(names, entry.Name())
+			}
+		}
+		return names
+	}
+
+	if rotated, err := RotateFile(logPath, policy); err != nil || rotated {
+		t.Fatalf("missing file: rotated=%v err=%v", rotated, err)
+	}
+
+	if err := os.WriteFile(logPath, []byte("123456789"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	if rotated, err := RotateFile(logPath, policy); err != nil || rotated {
+		t.Fatalf("file below threshold: rotated=%v err=%v", rotated, err)
+	}
+
+	// Roll the file at the threshold, three times, with distinct ages
+	start := time.Date(2025, 11, 8, 14, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if err := os.WriteFile(logPath, []byte("1234567890"), 0644); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+		modTime := start.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(logPath, modTime, modTime); err != nil {
+			t.Fatalf("failed to set log time: %v", err)
+		}
+
+		rotated, err := RotateFile(logPath, policy)
+		if err != nil || !rotated {
+			t.Fatalf("file at threshold: rotated=%v err=%v", rotated, err)
+		}
+		if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+			t.Fatal("rotated file should have been moved away")
+		}
+	}
+
+	// Only the two newest backups are kept
+	got := backups()
+	want := []string{
+		"server.stdout.log." + start.Add(time.Hour).Format("20060102-150405"),
+		"server.stdout.log." + start.Add(2*time.Hour).Format("20060102-150405"),
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected backups\n got: %v\nwant: %v", got, want)
+	}
+}
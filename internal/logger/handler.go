@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats are the accepted log formats: "text" for people, "json" for log shippers
+var Formats = []string{"text", "json"}
+
+// ParseLevel parses a level name ("debug", "info", "warn", "error"), case-insensitively
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", name)
+	}
+	return level, nil
+}
+
+// NewHandler returns a handler writing records at level and above to w, as one JSON object per
+// line in the "json" format and as key=value pairs otherwise
+func NewHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, "json") {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// TeeHandler sends every record to each of its handlers that is enabled for its level
+type TeeHandler struct {
+	handlers []slog.Handler
+}
+
+// NewTeeHandler returns a handler writing to all of handlers
+func NewTeeHandler(handlers ...slog.Handler) *TeeHandler {
+	return &TeeHandler{handlers: handlers}
+}
+
+func (t *TeeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TeeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *TeeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return &TeeHandler{handlers: handlers}
+}
+
+func (t *TeeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &TeeHandler{handlers: handlers}
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestNewHandler_JSON checks JSON mode writes one parseable object per line carrying the
+// standard keys and the fields event logs are tagged with
+func TestNewHandler_JSON(t *testing.T) {
+	var console, other bytes.Buffer
+	log := slog.New(NewTeeHandler(
+		NewHandler(&console, "json", slog.LevelInfo),
+		NewHandler(&other, "text", slog.LevelDebug),
+	)).With("component", "GAME_EVENTS", "event_type", "player_kill")
+
+	log.Info("Processing kill event", "server_id", "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde", "match_id", "m1")
+	log.Debug("Below the console level")
+	log.WithGroup("rcon").Warn("Command failed", "server_id", "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde")
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(&console)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line is not JSON: %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 JSON lines at info and above, got %d", len(lines))
+	}
+
+	for key, want := range map[string]any{
+		"level":      "INFO",
+		"msg":        "Processing kill event",
+		"component":  "GAME_EVENTS",
+		"event_type": "player_kill",
+		"server_id":  "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde",
+		"match_id":   "m1",
+	} {
+		if lines[0][key] != want {
+			t.Errorf("%s = %v, want %v", key, lines[0][key], want)
+		}
+	}
+	if _, ok := lines[0]["time"]; !ok {
+		t.Error("Expected a time key")
+	}
+	if group, ok := lines[1]["rcon"].(map[string]any); !ok || group["server_id"] == nil {
+		t.Errorf("Expected the group's fields under rcon, got %v", lines[1])
+	}
+
+	// The other handler gets every record at its own level, in its own format
+	if got := strings.Count(other.String(), "\n"); got != 3 {
+		t.Errorf("Expected 3 text lines, got %d: %s", got, other.String())
+	}
+	if !strings.Contains(other.String(), "event_type=player_kill") {
+		t.Errorf("Expected text output with the event type, got %s", other.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError} {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
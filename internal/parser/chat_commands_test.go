@@ -2,9 +2,16 @@ package parser
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"testing"
 	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
 )
 
 func TestChatCommandParsing(t *testing.T) {
@@ -167,3 +174,96 @@ func TestUnsupportedCommandsIgnored(t *testing.T) {
 		})
 	}
 }
+
+func TestChatMessageEvents(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	lines := []string{
+		"[2025.10.21-20.09.21:472][427]LogChat: Display: ArmoredBear(76561198995742987) Global Chat: hello world",
+		"[2025.10.21-20.09.25:100][430]LogChat: Display: Player3(76561198995742990) Team Chat: push B",
+		"[2025.10.21-20.09.30:200][435]LogChat: Display: Player4(76561198995742991) Team 1 Chat: smoke out",
+		"[2025.10.21-20.09.35:300][440]LogChat: Display: ArmoredBear(76561198995742987) Global Chat: !stats",
+	}
+
+	processLines := func(t *testing.T, serverID string, storeChat bool) {
+		t.Helper()
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverID, serverID, "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		parser := NewLogParser(testApp, testApp.Logger())
+		parser.SetStoreChatMessages(storeChat)
+		for _, line := range lines {
+			if err := parser.ParseAndProcess(ctx, line, serverID, "test.log"); err != nil {
+				t.Fatalf("failed to process log line: %v", err)
+			}
+		}
+	}
+
+	findEvents := func(t *testing.T, serverID, eventType string) []string {
+		t.Helper()
+		server, err := testApp.FindFirstRecordByFilter("servers", "external_id = {:id}", map[string]any{"id": serverID})
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		records, err := testApp.FindRecordsByFilter("events", "type = {:type} && server = {:server}", "created", 0, 0,
+			map[string]any{"type": eventType, "server": server.Id})
+		if err != nil {
+			t.Fatalf("failed to find %s events: %v", eventType, err)
+		}
+		data := make([]string, len(records))
+		for i, record := range records {
+			data[i] = record.GetString("data")
+		}
+		return data
+	}
+
+	t.Run("global vs team when enabled", func(t *testing.T) {
+		serverID := "test-server-chat-enabled"
+		processLines(t, serverID, true)
+
+		messages := findEvents(t, serverID, events.TypeChatMessage)
+		if len(messages) != len(lines) {
+			t.Fatalf("expected %d chat_message events, got %d", len(lines), len(messages))
+		}
+
+		want := []struct {
+			player, steamID, channel, message string
+		}{
+			{"ArmoredBear", "76561198995742987", "global", "hello world"},
+			{"Player3", "76561198995742990", "team", "push B"},
+			{"Player4", "76561198995742991", "team", "smoke out"},
+			{"ArmoredBear", "76561198995742987", "global", "!stats"},
+		}
+		for i, w := range want {
+			var data events.ChatMessageData
+			if err := json.Unmarshal([]byte(messages[i]), &data); err != nil {
+				t.Fatalf("failed to decode chat_message data: %v", err)
+			}
+			if data.PlayerName != w.player || data.SteamID != w.steamID || data.Channel != w.channel || data.Message != w.message {
+				t.Errorf("message %d = %+v, want %+v", i, data, w)
+			}
+		}
+
+		// Commands are still dispatched separately
+		if commands := findEvents(t, serverID, events.TypeChatCommand); len(commands) != 1 {
+			t.Errorf("expected 1 chat_command event, got %d", len(commands))
+		}
+	})
+
+	t.Run("nothing stored when disabled", func(t *testing.T) {
+		serverID := "test-server-chat-disabled"
+		processLines(t, serverID, false)
+
+		if messages := findEvents(t, serverID, events.TypeChatMessage); len(messages) != 0 {
+			t.Errorf("expected no chat_message events, got %d", len(messages))
+		}
+		if commands := findEvents(t, serverID, events.TypeChatCommand); len(commands) != 1 {
+			t.Errorf("expected 1 chat_command event, got %d", len(commands))
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sandstorm-tracker/internal/util"
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// logFile is an opened log file, possibly read through a gzip decompressor
+type logFile struct {
+	io.Reader
+	gz   *gzip.Reader
+	file *os.File
+}
+
+func (f *logFile) Close() error {
+	if f.gz != nil {
+		f.gz.Close()
+	}
+	return f.file.Close()
+}
+
+// OpenLogFile opens a historical log file for reading from its start. Logs archived by the
+// log archiver (named .gz, or starting with the gzip magic bytes) are decompressed on the fly,
+// so callers scan them like any other log; wrap the result in NewLogReader as usual.
+func OpenLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(file)
+	head, _ := br.Peek(len(gzipMagic))
+	if !strings.HasSuffix(path, ".gz") && !bytes.Equal(head, gzipMagic) {
+		return &logFile{Reader: br, file: file}, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return &logFile{Reader: gz, gz: gz, file: file}, nil
+}
+
+// logFileServerID returns the server ID a log file is named after, also for archived and backup
+// logs (see util.ServerIDFromLogName)
+func logFileServerID(path string) string {
+	return util.ServerIDFromLogName(path)
+}
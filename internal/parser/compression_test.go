@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"compress/gzip"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeGzipped compresses a log into dir under name, the way the log archiver stores old logs
+func writeGzipped(t *testing.T, src, dir, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", src, err)
+	}
+
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("failed to compress %s: %v", src, err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to compress %s: %v", src, err)
+	}
+	return path
+}
+
+// TestGzippedLogMatchesPlaintext checks an archived log gives the same map event and creation
+// time as its plaintext form, whether it is recognised by its .gz name or only by its contents
+func TestGzippedLogMatchesPlaintext(t *testing.T) {
+	src := filepath.Join("test_data", "full.log")
+	dir := t.TempDir()
+	archives := map[string]string{
+		"named .gz":     writeGzipped(t, src, dir, "full.log.gz"),
+		"gzip contents": writeGzipped(t, src, dir, "full-backup-2025.11.08-17.51.10.log"),
+	}
+
+	parser := NewLogParser(nil, slog.Default())
+	before := time.Now()
+
+	wantMap, wantScenario, wantTime, wantLine, err := parser.FindLastMapEvent(src, before)
+	if err != nil {
+		t.Fatalf("FindLastMapEvent on the plaintext log failed: %v", err)
+	}
+	if wantMap != "Town" || wantScenario != "Scenario_Hideout_Push_Security" {
+		t.Fatalf("Expected the travel to Town push, got %s %s", wantMap, wantScenario)
+	}
+	wantCreated, err := parser.ExtractLogFileCreationTime(src)
+	if err != nil {
+		t.Fatalf("ExtractLogFileCreationTime on the plaintext log failed: %v", err)
+	}
+
+	for name, path := range archives {
+		t.Run(name, func(t *testing.T) {
+			mapName, scenario, timestamp, line, err := parser.FindLastMapEvent(path, before)
+			if err != nil {
+				t.Fatalf("FindLastMapEvent failed: %v", err)
+			}
+			if mapName != wantMap || scenario != wantScenario || !timestamp.Equal(wantTime) || line != wantLine {
+				t.Errorf("FindLastMapEvent = %s %s %v line %d, want %s %s %v line %d",
+					mapName, scenario, timestamp, line, wantMap, wantScenario, wantTime, wantLine)
+			}
+
+			created, err := parser.ExtractLogFileCreationTime(path)
+			if err != nil {
+				t.Fatalf("ExtractLogFileCreationTime failed: %v", err)
+			}
+			if !created.Equal(wantCreated) {
+				t.Errorf("Creation time = %v, want %v", created, wantCreated)
+			}
+		})
+	}
+}
+
+func TestOpenLogFile_CorruptArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.log.gz")
+	if err := os.WriteFile(path, []byte("not gzip"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if _, err := OpenLogFile(path); err == nil {
+		t.Error("Expected an error opening a .gz log that is not gzip")
+	}
+}
+
+func TestLogFileServerID(t *testing.T) {
+	tests := map[string]string{
+		"/logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde.log":                               "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde",
+		"/logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde.log.gz":                            "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde",
+		"/logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde.log.20251110-205831.gz":            "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde",
+		"/logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde-backup-2025.11.10-20.58.31.log.gz": "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde",
+		"/logs/1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde-backup-2025.11.10-20.58.31.log":    "1d6407b7-f51b-4b1d-ad9e-faabbfbb7dde",
+	}
+	for path, want := range tests {
+		if got := logFileServerID(path); got != want {
+			t.Errorf("logFileServerID(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sandstorm-tracker/internal/events"
+)
+
+// crashReportWindow is how long after a crash is detected further error lines are taken to be
+// the rest of its report (the callstack and so on) rather than a new crash
+const crashReportWindow = 30 * time.Second
+
+// maxCrashReasonLength caps the crash reason kept from a log line
+const maxCrashReasonLength = 500
+
+// tryProcessServerCrash handles the fatal error and assertion lines a server writes as it crashes,
+// so the active match is ended straight away rather than when the next log file is opened.
+// A crash report spans many lines; only the first one with a message emits a server_crash event.
+// Examples:
+//
+//	[2025.11.10-21.30.02:114][512]LogWindows: Error: Fatal error: [File:...GenericPlatformMemory.cpp] [Line: 200]
+//	[2025.11.10-21.30.02:114][512]LogOutputDevice: Error: Assertion failed: IsValid() [File:...] [Line: 42]
+func (p *LogParser) tryProcessServerCrash(ctx context.Context, line string, timestamp time.Time, serverID string) bool {
+	matches := p.patterns.ServerCrash.FindStringSubmatch(line)
+	if len(matches) < 5 {
+		return false
+	}
+
+	reason := strings.TrimSpace(matches[2] + matches[3] + matches[4])
+	// The "=== Critical error: ===" banner opens the report but says nothing about the cause
+	if reason == "" || strings.HasPrefix(reason, "===") {
+		return true
+	}
+	if !p.startCrashReport(serverID, timestamp) {
+		return true
+	}
+	if len(reason) > maxCrashReasonLength {
+		reason = reason[:maxCrashReasonLength]
+	}
+
+	p.logger.Warn("Server crash detected", "server_id", serverID, "reason", reason)
+
+	if p.eventCreator != nil {
+		err := p.creator(ctx).CreateEvent(events.TypeServerCrash, serverID, map[string]interface{}{
+			"reason":     reason,
+			"timestamp":  timestamp,
+			"is_catchup": isCatchupMode(ctx),
+		})
+		if err != nil {
+			p.logger.Error("Failed to create server crash event",
+				"server_id", serverID, "error", err.Error())
+		}
+	}
+
+	return true
+}
+
+// startCrashReport reports whether an error line at timestamp starts a new crash report for a
+// server, rather than continuing one detected within crashReportWindow
+func (p *LogParser) startCrashReport(serverID string, timestamp time.Time) bool {
+	p.crashesMu.Lock()
+	defer p.crashesMu.Unlock()
+
+	if last, ok := p.lastCrashes[serverID]; ok {
+		if since := timestamp.Sub(last); since >= 0 && since <= crashReportWindow {
+			return false
+		}
+	}
+	p.lastCrashes[serverID] = timestamp
+	return true
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// A Windows server running out of memory mid-match, as written to the log
+var fatalErrorExcerpt = []string{
+	`[2025.11.10-21.29.58:201][480]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M4A1_C_2147480587`,
+	`[2025.11.10-21.30.02:114][512]LogMemory: Warning: Freeing 33554432 bytes from backup pool to handle out of memory.`,
+	`[2025.11.10-21.30.02:114][512]LogWindows: Error: === Critical error: ===`,
+	`[2025.11.10-21.30.02:114][512]LogWindows: Error: `,
+	`[2025.11.10-21.30.02:114][512]LogWindows: Error: Fatal error: [File:D:/Build/++UE4/Sync/Engine/Source/Runtime/Core/Private/GenericPlatform/GenericPlatformMemory.cpp] [Line: 200] `,
+	`[2025.11.10-21.30.02:114][512]LogWindows: Error: Ran out of memory allocating 1048576 bytes with alignment 0`,
+	`[2025.11.10-21.30.02:114][512]LogWindows: Error: `,
+	`[2025.11.10-21.30.02:115][512]LogWindows: Error: [Callstack] 0x00007ff7d3a1c2e0 InsurgencyServer-Win64-Shipping.exe!UnknownFunction []`,
+	`[2025.11.10-21.30.02:115][512]LogWindows: Error: [Callstack] 0x00007ff7d3a1b9f1 InsurgencyServer-Win64-Shipping.exe!UnknownFunction []`,
+	`[2025.11.10-21.30.02:131][512]LogExit: Executing StaticShutdownAfterError`,
+}
+
+func TestServerCrashEvents(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+
+	findCrashes := func(t *testing.T, serverExternalID string) []events.ServerCrashData {
+		t.Helper()
+		server, err := testApp.FindFirstRecordByFilter("servers", "external_id = {:id}", map[string]any{"id": serverExternalID})
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		records, err := testApp.FindRecordsByFilter("events", "type = 'server_crash' && server = {:server}", "created", 0, 0, map[string]any{"server": server.Id})
+		if err != nil {
+			t.Fatalf("failed to find server_crash events: %v", err)
+		}
+		crashes := make([]events.ServerCrashData, len(records))
+		for i, record := range records {
+			if err := json.Unmarshal([]byte(record.GetString("data")), &crashes[i]); err != nil {
+				t.Fatalf("failed to decode server_crash data: %v", err)
+			}
+		}
+		return crashes
+	}
+
+	process := func(t *testing.T, parser *LogParser, serverExternalID string, lines []string) {
+		t.Helper()
+		for _, line := range lines {
+			if err := parser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+				t.Fatalf("failed to process log line: %v", err)
+			}
+		}
+	}
+
+	newServer := func(t *testing.T, serverExternalID string) *LogParser {
+		t.Helper()
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Crash Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		return NewLogParser(testApp, testApp.Logger())
+	}
+
+	t.Run("fatal error report", func(t *testing.T) {
+		serverExternalID := "test-server-crash-fatal"
+		parser := newServer(t, serverExternalID)
+		process(t, parser, serverExternalID, fatalErrorExcerpt)
+
+		crashes := findCrashes(t, serverExternalID)
+		if len(crashes) != 1 {
+			t.Fatalf("expected 1 server_crash event for the whole report, got %d", len(crashes))
+		}
+		if !strings.HasPrefix(crashes[0].Reason, "Fatal error: [File:") || !strings.Contains(crashes[0].Reason, "[Line: 200]") {
+			t.Errorf("unexpected crash reason: %q", crashes[0].Reason)
+		}
+		if crashes[0].Timestamp.Minute() != 30 || crashes[0].Timestamp.Second() != 2 {
+			t.Errorf("expected the crash at the fatal error's timestamp, got %v", crashes[0].Timestamp)
+		}
+	})
+
+	t.Run("assertion", func(t *testing.T) {
+		serverExternalID := "test-server-crash-assert"
+		parser := newServer(t, serverExternalID)
+		process(t, parser, serverExternalID, []string{
+			`[2025.11.10-22.01.45:007][ 90]LogCore: Error: appError called: Assertion failed: IsValid(Controller) [File:./Runtime/Engine/Private/Pawn.cpp] [Line: 412]`,
+		})
+
+		crashes := findCrashes(t, serverExternalID)
+		if len(crashes) != 1 {
+			t.Fatalf("expected 1 server_crash event, got %d", len(crashes))
+		}
+		if want := "Assertion failed: IsValid(Controller) [File:./Runtime/Engine/Private/Pawn.cpp] [Line: 412]"; crashes[0].Reason != want {
+			t.Errorf("expected reason %q, got %q", want, crashes[0].Reason)
+		}
+	})
+
+	t.Run("later crash is reported again", func(t *testing.T) {
+		serverExternalID := "test-server-crash-twice"
+		parser := newServer(t, serverExternalID)
+		process(t, parser, serverExternalID, []string{
+			`[2025.11.10-22.01.45:007][ 90]LogWindows: Error: Fatal error: first`,
+			`[2025.11.10-22.01.46:007][ 91]LogWindows: Error: Fatal error: same report`,
+			`[2025.11.10-23.15.00:000][ 12]LogOnline: Fatal: second`,
+		})
+
+		crashes := findCrashes(t, serverExternalID)
+		if len(crashes) != 2 {
+			t.Fatalf("expected 2 server_crash events, got %d", len(crashes))
+		}
+		if crashes[1].Reason != "second" {
+			t.Errorf("expected the second crash's reason, got %q", crashes[1].Reason)
+		}
+	})
+
+	t.Run("ordinary errors are not crashes", func(t *testing.T) {
+		serverExternalID := "test-server-crash-none"
+		parser := newServer(t, serverExternalID)
+		process(t, parser, serverExternalID, []string{
+			`[2025.11.10-22.01.45:007][ 90]LogNet: Error: UChannel::ReceivedSequencedBunch: Bunch.bClose == true. ChIndex == 0. Calling ConditionalCleanUp.`,
+			`[2025.11.10-22.01.46:007][ 91]LogChat: Display: ArmoredBear(76561198995742987) Global Chat: Fatal error lol`,
+		})
+
+		if crashes := findCrashes(t, serverExternalID); len(crashes) != 0 {
+			t.Fatalf("expected no server_crash events, got %d", len(crashes))
+		}
+	})
+}
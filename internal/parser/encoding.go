@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// LogEncoding is the text encoding of a log file on disk
+type LogEncoding int
+
+const (
+	EncodingUTF8 LogEncoding = iota
+	EncodingUTF16LE
+	EncodingUTF16BE
+)
+
+func (e LogEncoding) String() string {
+	switch e {
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	default:
+		return "UTF-8"
+	}
+}
+
+// DetectLogEncoding sniffs the encoding of a log file from its first bytes.
+// Some Windows servers write UTF-16 logs, with or without a BOM; without one the
+// null high (or low) bytes of the ASCII log prefix give the byte order away.
+// Anything else is read as UTF-8.
+func DetectLogEncoding(head []byte) LogEncoding {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	case len(head) >= 4 && head[0] != 0 && head[1] == 0 && head[2] != 0 && head[3] == 0:
+		return EncodingUTF16LE
+	case len(head) >= 4 && head[0] == 0 && head[1] != 0 && head[2] == 0 && head[3] != 0:
+		return EncodingUTF16BE
+	}
+	return EncodingUTF8
+}
+
+// DetectLogFileEncoding sniffs the encoding from the start of the file, regardless of its read position
+func DetectLogFileEncoding(file io.ReaderAt) LogEncoding {
+	head := make([]byte, 4)
+	n, _ := file.ReadAt(head, 0)
+	return DetectLogEncoding(head[:n])
+}
+
+// decoder returns the UTF-8 decoder for the encoding, or nil for UTF-8.
+// A leading BOM is dropped; reads starting mid-file fall back to the sniffed byte order.
+func (e LogEncoding) decoder() *encoding.Decoder {
+	switch e {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	}
+	return nil
+}
+
+// NewLogReader wraps a log file read from its start so it yields UTF-8 whatever encoding
+// the server wrote it in, ready for line scanning
+func NewLogReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	if dec := DetectLogEncoding(head).decoder(); dec != nil {
+		return transform.NewReader(br, dec)
+	}
+	return br
+}
+
+// LogLineReader reads complete lines from a log file positioned at a byte offset, decoding
+// them to UTF-8 and reporting how many bytes each line took on disk so callers can keep
+// tracking byte offsets into the file
+type LogLineReader struct {
+	reader   *bufio.Reader
+	encoding LogEncoding
+	decoder  *encoding.Decoder
+}
+
+// NewLogLineReader returns a line reader for r in the given encoding
+func NewLogLineReader(r io.Reader, enc LogEncoding) *LogLineReader {
+	return &LogLineReader{
+		reader:   bufio.NewReader(r),
+		encoding: enc,
+		decoder:  enc.decoder(),
+	}
+}
+
+// ReadLine returns the next line without its line ending, and its size in bytes on disk.
+// A trailing partial line is not returned: err is io.EOF and size is 0, so the caller
+// can pick it up once the rest of the line is written.
+func (r *LogLineReader) ReadLine() (line string, size int, err error) {
+	var raw []byte
+	if r.decoder == nil {
+		raw, err = r.reader.ReadBytes('\n')
+	} else {
+		raw, err = r.readUTF16Line()
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	if r.decoder != nil {
+		decoded, err := r.decoder.Bytes(raw)
+		if err != nil {
+			return "", 0, err
+		}
+		return strings.TrimRight(string(decoded), "\r\n"), len(raw), nil
+	}
+	return strings.TrimRight(string(raw), "\r\n"), len(raw), nil
+}
+
+// readUTF16Line reads two-byte code units up to and including the newline unit.
+// Splitting on a single '\n' byte would cut lines at code units that merely contain 0x0A.
+func (r *LogLineReader) readUTF16Line() ([]byte, error) {
+	newline := [2]byte{'\n', 0}
+	if r.encoding == EncodingUTF16BE {
+		newline = [2]byte{0, '\n'}
+	}
+
+	var raw []byte
+	var unit [2]byte
+	for {
+		if _, err := io.ReadFull(r.reader, unit[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		raw = append(raw, unit[:]...)
+		if unit == newline {
+			return raw, nil
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// writeUTF16LE re-encodes a UTF-8 log the way Windows tools write it: UTF-16LE with a BOM and CRLF line endings
+func writeUTF16LE(t *testing.T, src string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", src, err)
+	}
+	crlf := strings.ReplaceAll(string(data), "\n", "\r\n")
+
+	encoded, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String(crlf)
+	if err != nil {
+		t.Fatalf("failed to encode UTF-16LE: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), strings.TrimSuffix(filepath.Base(src), ".log")+".utf16.log")
+	if err := os.WriteFile(path, []byte(encoded), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestDetectLogEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want LogEncoding
+	}{
+		{"UTF-8", []byte("Log file open"), EncodingUTF8},
+		{"UTF-8 BOM", []byte{0xEF, 0xBB, 0xBF, 'L'}, EncodingUTF8},
+		{"UTF-16LE BOM", []byte{0xFF, 0xFE, 'L', 0}, EncodingUTF16LE},
+		{"UTF-16BE BOM", []byte{0xFE, 0xFF, 0, 'L'}, EncodingUTF16BE},
+		{"UTF-16LE without BOM", []byte{'L', 0, 'o', 0}, EncodingUTF16LE},
+		{"UTF-16BE without BOM", []byte{0, 'L', 0, 'o'}, EncodingUTF16BE},
+		{"empty", nil, EncodingUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLogEncoding(tt.head); got != tt.want {
+				t.Errorf("DetectLogEncoding(%v) = %s, want %s", tt.head, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUTF16LogParsesLikeUTF8 feeds the same log as UTF-8 and as UTF-16LE and expects identical events
+func TestUTF16LogParsesLikeUTF8(t *testing.T) {
+	utf8Path := "test_data/full-2.log"
+	utf16Path := writeUTF16LE(t, utf8Path)
+
+	parseEvents := func(path string) []string {
+		testApp, err := tests.NewTestApp(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		defer testApp.Cleanup()
+
+		ctx := context.Background()
+		serverID := "test-server-encoding"
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverID, "Encoding Test Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Error opening log: %v", err)
+		}
+		defer file.Close()
+
+		parser := NewLogParser(testApp, testApp.Logger())
+		scanner := bufio.NewScanner(NewLogReader(file))
+		for scanner.Scan() {
+			parser.ParseAndProcess(ctx, scanner.Text(), serverID, "test.log")
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("Error scanning log: %v", err)
+		}
+
+		records, err := testApp.FindRecordsByFilter("events", "", "created,id", -1, 0)
+		if err != nil {
+			t.Fatalf("Failed to query events: %v", err)
+		}
+		var events []string
+		for _, record := range records {
+			events = append(events, record.GetString("type")+" "+record.GetString("data"))
+		}
+		// Events created within the same millisecond have no stable order
+		sort.Strings(events)
+		return events
+	}
+
+	want := parseEvents(utf8Path)
+	got := parseEvents(utf16Path)
+
+	if len(want) < 20 {
+		t.Fatalf("Expected the UTF-8 log to produce events, got %d", len(want))
+	}
+	if len(got) != len(want) {
+		t.Fatalf("UTF-16LE log produced %d events, UTF-8 log produced %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d differs:\n UTF-16LE: %s\n UTF-8:    %s", i, got[i], want[i])
+		}
+	}
+
+	parser := NewLogParser(nil, slog.Default())
+	wantTime, err := parser.ExtractLogFileCreationTime(utf8Path)
+	if err != nil {
+		t.Fatalf("Failed to extract creation time from UTF-8 log: %v", err)
+	}
+	gotTime, err := parser.ExtractLogFileCreationTime(utf16Path)
+	if err != nil {
+		t.Fatalf("Failed to extract creation time from UTF-16LE log: %v", err)
+	}
+	if !gotTime.Equal(wantTime) {
+		t.Errorf("Creation time = %v, want %v", gotTime, wantTime)
+	}
+}
+
+// TestLogLineReaderOffsets checks line sizes are reported in file bytes, so a UTF-16 log can be
+// resumed from a saved offset the way the watcher does
+func TestLogLineReaderOffsets(t *testing.T) {
+	path := writeUTF16LE(t, "test_data/normal.log")
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Error opening log: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Error getting file info: %v", err)
+	}
+
+	enc := DetectLogFileEncoding(file)
+	if enc != EncodingUTF16LE {
+		t.Fatalf("Expected UTF-16LE, got %s", enc)
+	}
+
+	reader := NewLogLineReader(file, enc)
+	var lines []string
+	var offsets []int64
+	var offset int64
+	for {
+		line, size, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading line: %v", err)
+		}
+		offset += int64(size)
+		lines = append(lines, line)
+		offsets = append(offsets, offset)
+	}
+
+	if offset != info.Size() {
+		t.Fatalf("Line sizes add up to %d bytes, file is %d", offset, info.Size())
+	}
+	if !strings.HasPrefix(lines[0], "Log file open") {
+		t.Errorf("First line was not decoded: %q", lines[0])
+	}
+
+	// Resume halfway through, as after a restart
+	resumeAt := len(lines) / 2
+	if _, err := file.Seek(offsets[resumeAt-1], io.SeekStart); err != nil {
+		t.Fatalf("Error seeking: %v", err)
+	}
+	reader = NewLogLineReader(file, DetectLogFileEncoding(file))
+	line, _, err := reader.ReadLine()
+	if err != nil {
+		t.Fatalf("Error reading resumed line: %v", err)
+	}
+	if line != lines[resumeAt] {
+		t.Errorf("Resumed line = %q, want %q", line, lines[resumeAt])
+	}
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BotSteamID is the ID the game logs for AI actors, e.g. Rifleman[INVALID, team 1]
+const BotSteamID = "INVALID"
+
+// defaultIgnoreList ignores only BotSteamID
+var defaultIgnoreList, _ = NewIgnoreList(nil, nil, nil, nil)
+
+// IgnoreList decides which actors in the log are AI or system actors rather than players.
+// Ignored actors are never tracked: the parser gives them BotSteamID, which the event handlers
+// already skip, and drops their logins and joins. BotSteamID is always ignored.
+type IgnoreList struct {
+	ids      map[string]bool
+	names    map[string]bool
+	prefixes []string
+	patterns []*regexp.Regexp
+}
+
+// NewIgnoreList builds an ignore list from extra IDs, exact names and name prefixes (both
+// case-insensitive) and regular expressions matched against names
+func NewIgnoreList(ids, names, prefixes, patterns []string) (*IgnoreList, error) {
+	l := &IgnoreList{
+		ids:   map[string]bool{BotSteamID: true},
+		names: make(map[string]bool, len(names)),
+	}
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			l.ids[id] = true
+		}
+	}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			l.names[strings.ToLower(name)] = true
+		}
+	}
+	for _, prefix := range prefixes {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			l.prefixes = append(l.prefixes, strings.ToLower(prefix))
+		}
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+		l.patterns = append(l.patterns, re)
+	}
+	return l, nil
+}
+
+// Ignores reports whether the actor with this name and ID (empty when the line has none) is
+// an AI or system actor
+func (l *IgnoreList) Ignores(name, id string) bool {
+	if l.ids[id] {
+		return true
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if lower == "" {
+		return false
+	}
+	if l.names[lower] {
+		return true
+	}
+	for _, prefix := range l.prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	for _, re := range l.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIgnoreList sets the AI and system actors that are never tracked as players. A nil list
+// resets to ignoring only BotSteamID.
+func (p *LogParser) SetIgnoreList(l *IgnoreList) {
+	if l == nil {
+		l = defaultIgnoreList
+	}
+	p.ignoreMu.Lock()
+	defer p.ignoreMu.Unlock()
+	p.ignore = l
+}
+
+// ignores reports whether the parser's ignore list covers the actor
+func (p *LogParser) ignores(name, id string) bool {
+	p.ignoreMu.RLock()
+	defer p.ignoreMu.RUnlock()
+	return p.ignore.Ignores(name, id)
+}
+
+// parseActors parses a player section like ParseKillerSection, giving ignored actors BotSteamID
+func (p *LogParser) parseActors(section string) []killer {
+	actors := ParseKillerSection(section)
+	for i, actor := range actors {
+		if p.ignores(actor.Name, actor.SteamID) {
+			actors[i].SteamID = BotSteamID
+		}
+	}
+	return actors
+}
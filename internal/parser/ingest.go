@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"sync"
+	"time"
+)
+
+// UnmatchedLinesKept is how many of the latest unmatched log lines are kept for IngestStats
+const UnmatchedLinesKept = 50
+
+// IngestStats reports how the parser is keeping up with the logs since the tracker started
+type IngestStats struct {
+	LinesProcessed int64            `json:"lines_processed"` // Non-empty lines handed to the parser
+	Matched        map[string]int64 `json:"matched"`         // Lines recognised, by line type
+	Unmatched      int64            `json:"unmatched"`       // Lines with a valid timestamp that no pattern recognised
+	ParseErrors    int64            `json:"parse_errors"`    // Lines whose timestamp could not be parsed
+	// The latest unmatched lines, oldest first, to spot log format changes that need new patterns
+	RecentUnmatched []UnmatchedLine `json:"recent_unmatched"`
+}
+
+// UnmatchedLine is a timestamped log line no pattern recognised
+type UnmatchedLine struct {
+	ServerID string    `json:"server_id"`
+	Line     string    `json:"line"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+// ingestStats counts the lines the parser processes; safe for concurrent use
+type ingestStats struct {
+	mu          sync.Mutex
+	lines       int64
+	matched     map[string]int64
+	unmatched   int64
+	parseErrors int64
+	recent      []UnmatchedLine // Ring buffer of UnmatchedLinesKept lines
+	next        int             // Where the next unmatched line goes in recent
+}
+
+func newIngestStats() *ingestStats {
+	return &ingestStats{
+		matched: make(map[string]int64),
+		recent:  make([]UnmatchedLine, 0, UnmatchedLinesKept),
+	}
+}
+
+func (s *ingestStats) lineProcessed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines++
+}
+
+func (s *ingestStats) lineMatched(lineType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matched[lineType]++
+}
+
+func (s *ingestStats) parseError() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parseErrors++
+}
+
+func (s *ingestStats) lineUnmatched(serverID, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unmatched++
+
+	entry := UnmatchedLine{ServerID: serverID, Line: line, SeenAt: time.Now()}
+	if len(s.recent) < UnmatchedLinesKept {
+		s.recent = append(s.recent, entry)
+		return
+	}
+	s.recent[s.next] = entry
+	s.next = (s.next + 1) % UnmatchedLinesKept
+}
+
+// snapshot copies the counters and unmatched lines, oldest line first
+func (s *ingestStats) snapshot() IngestStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := IngestStats{
+		LinesProcessed:  s.lines,
+		Matched:         make(map[string]int64, len(s.matched)),
+		Unmatched:       s.unmatched,
+		ParseErrors:     s.parseErrors,
+		RecentUnmatched: make([]UnmatchedLine, 0, len(s.recent)),
+	}
+	for lineType, count := range s.matched {
+		stats.Matched[lineType] = count
+	}
+	stats.RecentUnmatched = append(stats.RecentUnmatched, s.recent[s.next:]...)
+	stats.RecentUnmatched = append(stats.RecentUnmatched, s.recent[:s.next]...)
+	return stats
+}
+
+// IngestStats returns the parser's line counters and the latest unmatched lines
+func (p *LogParser) IngestStats() IngestStats {
+	return p.ingest.snapshot()
+}
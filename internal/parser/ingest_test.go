@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestIngestStats(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverExternalID := "test-server-ingest"
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Ingest Server", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	parser := NewLogParser(testApp, testApp.Logger())
+	lines := []string{
+		"Log file open, 11/10/25 20:58:31",
+		`[2025.11.10-21.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Hideout_Checkpoint_Security?MaxPlayers=28?Lighting=Day`,
+		`[2025.11.10-21.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.10-21.02.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.10-21.03.00:000][ 30]LogGameplayEvents: Display: Round 1 Over: Team 0 won (win reason: Elimination)`,
+		`[2025.11.10-21.04.00:000][ 40]LogStreaming: Display: Flushing async loaders.`,
+		`[2025.11.10-21.05.00:000][ 50]LogNet: NotifyAcceptingConnection accepted from: 10.0.0.5:51234`,
+		`[2025.13.45-99.05.00:000][ 60]LogGameplayEvents: Display: Round 2 started`, // Impossible date
+		`   `,
+		`Stack: InsurgencyServer-Win64-Shipping.exe!UnknownFunction`, // No timestamp
+	}
+	for _, line := range lines {
+		if err := parser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+			t.Fatalf("failed to process log line: %v", err)
+		}
+	}
+
+	stats := parser.IngestStats()
+	if stats.LinesProcessed != 9 {
+		t.Errorf("expected 9 non-empty lines processed, got %d", stats.LinesProcessed)
+	}
+	wantMatched := map[string]int64{
+		events.TypeLogFileCreated: 1,
+		events.TypeMapLoad:        1,
+		events.TypePlayerKill:     2,
+		events.TypeRoundEnd:       1,
+	}
+	if len(stats.Matched) != len(wantMatched) {
+		t.Errorf("expected matched %v, got %v", wantMatched, stats.Matched)
+	}
+	for lineType, want := range wantMatched {
+		if stats.Matched[lineType] != want {
+			t.Errorf("expected %d %s lines, got %d", want, lineType, stats.Matched[lineType])
+		}
+	}
+	if stats.Unmatched != 2 {
+		t.Errorf("expected 2 unmatched lines, got %d", stats.Unmatched)
+	}
+	if stats.ParseErrors != 1 {
+		t.Errorf("expected 1 parse error, got %d", stats.ParseErrors)
+	}
+	if len(stats.RecentUnmatched) != 2 || stats.RecentUnmatched[0].Line != lines[5] || stats.RecentUnmatched[1].Line != lines[6] {
+		t.Fatalf("expected the unmatched lines oldest first, got %+v", stats.RecentUnmatched)
+	}
+	if stats.RecentUnmatched[0].ServerID != serverExternalID {
+		t.Errorf("expected server %q, got %q", serverExternalID, stats.RecentUnmatched[0].ServerID)
+	}
+
+	t.Run("only the latest unmatched lines are kept", func(t *testing.T) {
+		for i := 0; i < UnmatchedLinesKept+5; i++ {
+			line := fmt.Sprintf(`[2025.11.10-22.00.00:%03d][ 70]LogTemp: Display: unmatched %d`, i, i)
+			if err := parser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+				t.Fatalf("failed to process log line: %v", err)
+			}
+		}
+
+		stats := parser.IngestStats()
+		if stats.Unmatched != int64(2+UnmatchedLinesKept+5) {
+			t.Errorf("expected every unmatched line counted, got %d", stats.Unmatched)
+		}
+		if len(stats.RecentUnmatched) != UnmatchedLinesKept {
+			t.Fatalf("expected %d recent lines, got %d", UnmatchedLinesKept, len(stats.RecentUnmatched))
+		}
+		first := fmt.Sprintf(`[2025.11.10-22.00.00:%03d][ 70]LogTemp: Display: unmatched %d`, 5, 5)
+		last := fmt.Sprintf(`[2025.11.10-22.00.00:%03d][ 70]LogTemp: Display: unmatched %d`, UnmatchedLinesKept+4, UnmatchedLinesKept+4)
+		if stats.RecentUnmatched[0].Line != first || stats.RecentUnmatched[UnmatchedLinesKept-1].Line != last {
+			t.Errorf("expected lines %q to %q, got %q to %q", first, last,
+				stats.RecentUnmatched[0].Line, stats.RecentUnmatched[UnmatchedLinesKept-1].Line)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sandstorm-tracker/internal/events"
+)
+
+// killAssistWindow is how long after a kill an assist line for the same victim is taken to
+// belong to it
+const killAssistWindow = 2 * time.Second
+
+// recentKill is a server's last kill, kept for assist lines that follow it
+type recentKill struct {
+	credited  map[string]bool // Steam IDs already credited for the kill, as killer or assister
+	victim    killer
+	weapon    string
+	timestamp time.Time
+}
+
+// rememberKill keeps a server's latest kill for the assist lines that may follow it
+func (p *LogParser) rememberKill(serverID string, killers []killer, victim killer, weapon string, timestamp time.Time) {
+	credited := make(map[string]bool, len(killers))
+	for _, k := range killers {
+		credited[k.SteamID] = true
+	}
+
+	p.lastKillsMu.Lock()
+	defer p.lastKillsMu.Unlock()
+	p.lastKills[serverID] = &recentKill{credited: credited, victim: victim, weapon: weapon, timestamp: timestamp}
+}
+
+// sameVictim reports whether two actors are the same victim. Bots all share the INVALID ID,
+// so they are told apart by name.
+func sameVictim(a, b killer) bool {
+	if a.SteamID != b.SteamID {
+		return false
+	}
+	return a.SteamID != BotSteamID || a.Name == b.Name
+}
+
+// tryProcessKillAssist handles assist lines that some server configs write after the kill,
+// instead of listing every killer on the kill line. The assisters are credited on the kill the
+// server logged just before for the same victim; an assist with no such kill is dropped.
+// Example:
+//
+//	[2025.11.10-21.05.12:301][120]LogGameplayEvents: Display: Rabbit[76561198995742956, team 0] assisted in killing Marksman[INVALID, team 1]
+func (p *LogParser) tryProcessKillAssist(ctx context.Context, line string, timestamp time.Time, serverID string) bool {
+	matches := p.patterns.KillAssist.FindStringSubmatch(line)
+	if len(matches) < 4 {
+		return false
+	}
+
+	assisters := p.parseActors(strings.TrimSpace(matches[2]))
+	victims := p.parseActors(strings.TrimSpace(matches[3]))
+	if len(assisters) == 0 || len(victims) == 0 {
+		return true
+	}
+	victim := victims[0]
+
+	p.lastKillsMu.Lock()
+	kill, ok := p.lastKills[serverID]
+	if ok {
+		since := timestamp.Sub(kill.timestamp)
+		ok = since >= 0 && since <= killAssistWindow && sameVictim(kill.victim, victim)
+	}
+	var credited []events.Killer
+	if ok {
+		for _, a := range assisters {
+			if a.SteamID == BotSteamID || kill.credited[a.SteamID] {
+				continue
+			}
+			kill.credited[a.SteamID] = true
+			credited = append(credited, events.Killer{SteamID: a.SteamID, PlayerName: a.Name, Team: a.Team})
+		}
+	}
+	p.lastKillsMu.Unlock()
+
+	if !ok {
+		p.logger.Debug("Assist without a matching kill", "server_id", serverID, "victim", victim.Name)
+		return true
+	}
+	if len(credited) == 0 || p.eventCreator == nil {
+		return true
+	}
+
+	err := p.creator(ctx).CreateKillAssistEvent(serverID, events.KillAssistData{
+		Assisters: credited,
+		Victim:    events.Victim{SteamID: victim.SteamID, PlayerName: victim.Name, Team: victim.Team},
+		Weapon:    kill.weapon,
+		Timestamp: timestamp,
+		IsCatchup: isCatchupMode(ctx),
+	})
+	if err != nil {
+		p.logger.Error("Failed to create kill assist event",
+			"server_id", serverID,
+			"victim", victim.Name,
+			"error", err.Error())
+	}
+	return true
+}
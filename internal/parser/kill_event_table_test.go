@@ -152,3 +152,58 @@ func TestParseKillEventTableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKillEventHitRegion(t *testing.T) {
+	cases := []struct {
+		name       string
+		logLine    string
+		weapon     string
+		hitRegion  string
+		isHeadshot bool
+	}{
+		{
+			name:    "plain kill line",
+			logLine: `[2025.10.04-14.31.05:706][800]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+			weapon:  "M16A4",
+		},
+		{
+			name:       "headshot suffix",
+			logLine:    `[2025.10.04-14.31.05:706][800]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419 (Headshot)`,
+			weapon:     "M16A4",
+			hitRegion:  "Head",
+			isHeadshot: true,
+		},
+		{
+			name:       "hit zone head",
+			logLine:    `[2025.10.04-14.31.05:706][800]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419 (HitZone: Head)`,
+			weapon:     "M16A4",
+			hitRegion:  "Head",
+			isHeadshot: true,
+		},
+		{
+			name:      "hit zone body",
+			logLine:   `[2025.10.04-14.31.05:706][800]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419 (HitZone: Chest)`,
+			weapon:    "M16A4",
+			hitRegion: "Chest",
+		},
+	}
+
+	patterns := parser.NewLogPatterns()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches := patterns.PlayerKill.FindStringSubmatch(tc.logLine)
+			if len(matches) < 6 {
+				t.Fatalf("Kill regex did not match log line: %s", tc.logLine)
+			}
+
+			if weapon := parser.CleanWeaponName(matches[4]); weapon != tc.weapon {
+				t.Errorf("expected weapon %q, got %q", tc.weapon, weapon)
+			}
+
+			hitRegion, isHeadshot := parser.ParseHitRegion(matches[5])
+			if hitRegion != tc.hitRegion || isHeadshot != tc.isHeadshot {
+				t.Errorf("expected region %q headshot %v, got %q %v", tc.hitRegion, tc.isHeadshot, hitRegion, isHeadshot)
+			}
+		})
+	}
+}
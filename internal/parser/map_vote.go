@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"sandstorm-tracker/internal/events"
+)
+
+// Map vote outcomes
+const (
+	mapVoteOutcomeMajority = "majority"
+	mapVoteOutcomeDeadline = "deadline"
+	mapVoteOutcomeRandom   = "random"
+)
+
+// pendingMapVote accumulates a server's end-of-match vote across log lines.
+// The vote manager logs the options and the tally but not the winning map,
+// so the vote is only emitted once the following map travel is seen.
+type pendingMapVote struct {
+	startedAt  time.Time
+	endedAt    time.Time
+	options    []events.MapVoteOption
+	collecting bool // Option lines follow the "New Vote Options:" header
+	outcome    string
+	voteShare  *float64
+	threshold  *float64
+}
+
+// tryProcessMapVote tracks map vote start, offered options and the tally
+func (p *LogParser) tryProcessMapVote(ctx context.Context, line string, timestamp time.Time, serverID string) bool {
+	if !strings.Contains(line, "LogMapVoteManager:") {
+		return false
+	}
+
+	p.mapVotesMu.Lock()
+	defer p.mapVotesMu.Unlock()
+
+	vote := p.mapVotes[serverID]
+
+	if matches := p.patterns.MapVoteStart.FindStringSubmatch(line); len(matches) >= 3 {
+		p.logger.Debug("Map vote started", "server_id", serverID, "pool", matches[2])
+		p.mapVotes[serverID] = &pendingMapVote{startedAt: timestamp}
+		return true
+	}
+
+	if p.patterns.MapVote.MatchString(line) {
+		if vote == nil {
+			vote = &pendingMapVote{startedAt: timestamp}
+			p.mapVotes[serverID] = vote
+		}
+		vote.options = nil
+		vote.collecting = true
+		return true
+	}
+
+	if matches := p.patterns.MapVoteOption.FindStringSubmatch(line); len(matches) >= 5 {
+		if vote == nil || !vote.collecting {
+			return true
+		}
+		id, err := strconv.Atoi(matches[2])
+		if err != nil {
+			return true
+		}
+		vote.options = append(vote.options, events.MapVoteOption{
+			ID:       id,
+			Map:      matches[3],
+			Scenario: matches[4],
+		})
+		return true
+	}
+
+	if matches := p.patterns.MapVoteMajority.FindStringSubmatch(line); len(matches) >= 4 {
+		if vote == nil {
+			return true
+		}
+		vote.collecting = false
+		vote.outcome = mapVoteOutcomeMajority
+		vote.endedAt = timestamp
+		if share, err := strconv.ParseFloat(matches[2], 64); err == nil {
+			vote.voteShare = &share
+		}
+		if threshold, err := strconv.ParseFloat(matches[3], 64); err == nil {
+			vote.threshold = &threshold
+		}
+		p.logger.Debug("Map vote majority reached", "server_id", serverID, "share", matches[2], "threshold", matches[3])
+		return true
+	}
+
+	if p.patterns.MapVoteDeadline.MatchString(line) {
+		if vote == nil {
+			return true
+		}
+		vote.collecting = false
+		vote.outcome = mapVoteOutcomeDeadline
+		vote.endedAt = timestamp
+		p.logger.Debug("Map vote deadline hit", "server_id", serverID)
+		return true
+	}
+
+	if p.patterns.MapVoteNoVotes.MatchString(line) {
+		if vote == nil {
+			return true
+		}
+		vote.outcome = mapVoteOutcomeRandom
+		if vote.endedAt.IsZero() {
+			vote.endedAt = timestamp
+		}
+		return true
+	}
+
+	return false
+}
+
+// completeMapVote emits the server's concluded map vote with the map it travelled to as winner.
+// A travel without a concluded vote (e.g. admin travel) discards any partial vote.
+func (p *LogParser) completeMapVote(ctx context.Context, serverID, mapName, scenario string, timestamp time.Time) {
+	p.mapVotesMu.Lock()
+	vote := p.mapVotes[serverID]
+	delete(p.mapVotes, serverID)
+	p.mapVotesMu.Unlock()
+
+	if vote == nil || vote.outcome == "" {
+		return
+	}
+
+	winner := events.MapVoteOption{ID: -1, Map: mapName, Scenario: scenario}
+	for _, option := range vote.options {
+		if strings.EqualFold(option.Scenario, scenario) {
+			winner = option
+			break
+		}
+	}
+
+	p.logger.Debug("Map vote completed", "server_id", serverID, "winner", winner.Map, "scenario", winner.Scenario, "outcome", vote.outcome)
+
+	if p.eventCreator == nil {
+		return
+	}
+
+	err := p.creator(ctx).CreateMapVoteEvent(serverID, events.MapVoteData{
+		Options:   vote.options,
+		Winner:    winner,
+		Outcome:   vote.outcome,
+		VoteShare: vote.voteShare,
+		Threshold: vote.threshold,
+		StartedAt: vote.startedAt,
+		EndedAt:   vote.endedAt,
+		IsCatchup: isCatchupMode(ctx),
+	})
+	if err != nil {
+		p.logger.Error("Failed to create map vote event",
+			"server_id", serverID,
+			"winner", winner.Scenario,
+			"error", err.Error(),
+		)
+	}
+}
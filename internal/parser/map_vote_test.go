@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestMapVoteEvents(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+
+	findMapVotes := func(t *testing.T, serverExternalID string) []events.MapVoteData {
+		t.Helper()
+		server, err := testApp.FindFirstRecordByFilter("servers", "external_id = {:id}", map[string]any{"id": serverExternalID})
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		records, err := testApp.FindRecordsByFilter("events", "type = 'map_vote' && server = {:server}", "created", 0, 0, map[string]any{"server": server.Id})
+		if err != nil {
+			t.Fatalf("failed to find map_vote events: %v", err)
+		}
+		votes := make([]events.MapVoteData, len(records))
+		for i, record := range records {
+			if err := json.Unmarshal([]byte(record.GetString("data")), &votes[i]); err != nil {
+				t.Fatalf("failed to decode map_vote data: %v", err)
+			}
+		}
+		return votes
+	}
+
+	process := func(t *testing.T, ctx context.Context, parser *LogParser, serverExternalID string, lines []string) {
+		t.Helper()
+		for _, line := range lines {
+			if err := parser.ParseAndProcess(ctx, line, serverExternalID, "test.log"); err != nil {
+				t.Fatalf("failed to process log line: %v", err)
+			}
+		}
+	}
+
+	t.Run("majority vote", func(t *testing.T) {
+		serverExternalID := "test-server-vote-majority"
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Vote Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		parser := NewLogParser(testApp, testApp.Logger())
+		process(t, ctx, parser, serverExternalID, []string{
+			`[2025.11.10-21.12.45:402][ 38]LogMapVoteManager: Display: Starting map vote, 63 maps in pool.`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: Existing Vote Options:`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: New Vote Options:`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: ID:46 Map:PowerPlant Scenario:Scenario_PowerPlant_FFA ScenarioAsset: Opts:`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: ID:60 Map:Bab Scenario:Scenario_Bab_Push_Insurgents ScenarioAsset: Opts:`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: ID:7 Map:Oilfield Scenario:Scenario_Refinery_Push_Insurgents ScenarioAsset: Opts:`,
+			`[2025.11.10-21.12.54:748][601]LogMapVoteManager: Display: Majority check completed, 1.00 of 0.60 voted for the winning option(s).`,
+		})
+
+		if votes := findMapVotes(t, serverExternalID); len(votes) != 0 {
+			t.Fatalf("expected no map_vote event before map travel, got %d", len(votes))
+		}
+
+		process(t, ctx, parser, serverExternalID, []string{
+			`[2025.11.10-21.12.58:822][846]LogGameMode: ProcessServerTravel: Oilfield?Scenario=Scenario_Refinery_Push_Insurgents?Game=?`,
+		})
+
+		votes := findMapVotes(t, serverExternalID)
+		if len(votes) != 1 {
+			t.Fatalf("expected 1 map_vote event, got %d", len(votes))
+		}
+		vote := votes[0]
+
+		if len(vote.Options) != 3 {
+			t.Fatalf("expected 3 options, got %d: %+v", len(vote.Options), vote.Options)
+		}
+		if vote.Options[1].ID != 60 || vote.Options[1].Map != "Bab" || vote.Options[1].Scenario != "Scenario_Bab_Push_Insurgents" {
+			t.Errorf("unexpected option: %+v", vote.Options[1])
+		}
+		if vote.Winner.ID != 7 || vote.Winner.Map != "Oilfield" {
+			t.Errorf("expected winner ID 7 Oilfield, got %+v", vote.Winner)
+		}
+		if vote.Outcome != "majority" {
+			t.Errorf("expected outcome majority, got %s", vote.Outcome)
+		}
+		if vote.VoteShare == nil || *vote.VoteShare != 1.0 || vote.Threshold == nil || *vote.Threshold != 0.6 {
+			t.Errorf("unexpected tally: share=%v threshold=%v", vote.VoteShare, vote.Threshold)
+		}
+		if vote.IsCatchup {
+			t.Error("expected is_catchup to be false")
+		}
+		if !vote.EndedAt.After(vote.StartedAt) {
+			t.Errorf("expected ended_at after started_at, got %v / %v", vote.StartedAt, vote.EndedAt)
+		}
+	})
+
+	t.Run("no votes during catchup", func(t *testing.T) {
+		serverExternalID := "test-server-vote-random"
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Vote Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		parser := NewLogParser(testApp, testApp.Logger())
+		catchupCtx := context.WithValue(ctx, isCatchupModeKey, true)
+		process(t, catchupCtx, parser, serverExternalID, []string{
+			`[2025.10.21-20.12.06:450][ 10]LogMapVoteManager: Display: Starting map vote, 63 maps in pool.`,
+			`[2025.10.21-20.12.06:460][ 10]LogMapVoteManager: Display: New Vote Options:`,
+			`[2025.10.21-20.12.06:460][ 10]LogMapVoteManager: Display: ID:38 Map:Sinjar Scenario:Scenario_Hillside_Push_security ScenarioAsset: Opts:`,
+			`[2025.10.21-20.12.38:586][206]LogMapVoteManager: Deadline hit, finding winning map vote.`,
+			`[2025.10.21-20.12.38:586][206]LogMapVoteManager: Warning: No map votes, picking random map.`,
+			`[2025.10.21-20.12.42:785][454]LogGameMode: ProcessServerTravel: Town?Scenario=Scenario_Hideout_Skirmish?Game=CheckpointHardcore`,
+		})
+
+		votes := findMapVotes(t, serverExternalID)
+		if len(votes) != 1 {
+			t.Fatalf("expected 1 map_vote event, got %d", len(votes))
+		}
+		vote := votes[0]
+
+		if vote.Outcome != "random" {
+			t.Errorf("expected outcome random, got %s", vote.Outcome)
+		}
+		if vote.Winner.ID != -1 || vote.Winner.Scenario != "Scenario_Hideout_Skirmish" {
+			t.Errorf("expected unlisted winner Scenario_Hideout_Skirmish, got %+v", vote.Winner)
+		}
+		if vote.VoteShare != nil {
+			t.Errorf("expected no vote share without a majority, got %v", *vote.VoteShare)
+		}
+		if !vote.IsCatchup {
+			t.Error("expected is_catchup to be true")
+		}
+	})
+
+	t.Run("travel without vote", func(t *testing.T) {
+		serverExternalID := "test-server-vote-admin"
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverExternalID, "Vote Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		parser := NewLogParser(testApp, testApp.Logger())
+		process(t, ctx, parser, serverExternalID, []string{
+			`[2025.11.10-21.12.45:402][ 38]LogMapVoteManager: Display: Starting map vote, 63 maps in pool.`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: New Vote Options:`,
+			`[2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: ID:7 Map:Oilfield Scenario:Scenario_Refinery_Push_Insurgents ScenarioAsset: Opts:`,
+			`[2025.11.10-21.12.50:000][100]LogGameMode: ProcessServerTravel: Town?Scenario=Scenario_Hideout_Skirmish?Game=CheckpointHardcore`,
+			`[2025.11.10-21.12.58:000][200]LogMapVoteManager: Display: Majority check completed, 1.00 of 0.60 voted for the winning option(s).`,
+			`[2025.11.10-21.13.05:000][300]LogGameMode: ProcessServerTravel: Oilfield?Scenario=Scenario_Refinery_Push_Insurgents?Game=?`,
+		})
+
+		if votes := findMapVotes(t, serverExternalID); len(votes) != 0 {
+			t.Errorf("expected unfinished vote to be discarded by travel, got %d map_vote events", len(votes))
+		}
+	})
+}
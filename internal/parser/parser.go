@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 
 	// "log"
 	"log/slog"
@@ -11,9 +12,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"sandstorm-tracker/internal/events"
+	"sandstorm-tracker/internal/util"
 
 	"github.com/pocketbase/pocketbase/core"
 )
@@ -23,19 +26,46 @@ type contextKey string
 
 const isCatchupModeKey contextKey = "isCatchupMode"
 
+// Context key for the dedup key of the log line being processed
+const dedupKeyKey contextKey = "dedupKey"
+
 // isCatchupMode checks if the context indicates catchup mode
 func isCatchupMode(ctx context.Context) bool {
 	isCatchup, _ := ctx.Value(isCatchupModeKey).(bool)
 	return isCatchup
 }
 
+// WithCatchupMode marks ctx as catchup mode: events are still recorded,
+// but handlers skip side effects such as RCON commands and score updates
+func WithCatchupMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, isCatchupModeKey, true)
+}
+
 // LogParser handles parsing log lines and writing directly to database
 type LogParser struct {
-	pbApp              core.App
-	logger             *slog.Logger
-	patterns           *logPatterns
-	lastMapTravelTimes map[string]time.Time // Track last map travel time per server to ignore reconnects
-	eventCreator       *events.Creator      // Creates event records for hook-based processing
+	pbApp                    core.App
+	logger                   *slog.Logger
+	patterns                 *logPatterns
+	lastMapTravelTimes       map[string]time.Time // Track last map travel time per server to ignore reconnects
+	mapTravelMu              sync.RWMutex
+	mapTravelReconnectWindow time.Duration             // Disconnects this soon after a map travel are reconnects, not leaves
+	eventCreator             *events.Creator           // Creates event records for hook-based processing
+	locations                map[string]*time.Location // Per-server timezone the game server writes log timestamps in
+	locationsMu              sync.RWMutex
+	mapVotes                 map[string]*pendingMapVote // In-progress map vote per server, completed on the next map travel
+	mapVotesMu               sync.Mutex
+	sessions                 map[string]*playerSessions // Recent logins and leaves per server, to recognise team swaps
+	sessionsMu               sync.Mutex
+	teamSwapWindow           time.Duration        // A rejoin this soon after leaving is a team swap, not a new session
+	lastCrashes              map[string]time.Time // Log timestamp of the last crash detected per server
+	crashesMu                sync.Mutex
+	lastKills                map[string]*recentKill // Last kill per server, for assists logged on their own line
+	lastKillsMu              sync.Mutex
+	ingest                   *ingestStats // Line counters for IngestStats
+	storeChatMessages        bool         // Emit chat_message events for every chat line (opt-in via config)
+	trackWeaponFire          bool         // Emit weapon_fire events from verbose gameplay logging (opt-in via config)
+	ignore                   *IgnoreList  // AI and system actors never tracked as players
+	ignoreMu                 sync.RWMutex
 }
 
 // logPatterns contains compiled regex patterns for log parsing
@@ -43,6 +73,8 @@ type logPatterns struct {
 	LogFileOpen      *regexp.Regexp // Log file open timestamp (first line of log)
 	CommandLine      *regexp.Regexp
 	PlayerKill       *regexp.Regexp
+	KillAssist       *regexp.Regexp // Assisters logged on their own line after the kill
+	WeaponFire       *regexp.Regexp // Shots fired and hits per weapon (verbose LogGameplayEvents only)
 	PlayerLogin      *regexp.Regexp // Login request (earliest connection event)
 	PlayerRegister   *regexp.Regexp // ServerRegisterClient (pre-match)
 	PlayerJoin       *regexp.Regexp // Join succeeded (in-match)
@@ -51,11 +83,18 @@ type logPatterns struct {
 	RoundStart       *regexp.Regexp
 	RoundEnd         *regexp.Regexp
 	GameOver         *regexp.Regexp
+	ServerCrash      *regexp.Regexp // Fatal error or assertion written as the server crashes
 	MapLoad          *regexp.Regexp
 	MapTravel        *regexp.Regexp
 	// DifficultyChange   *regexp.Regexp // Not currently used
-	MapVote            *regexp.Regexp
+	MapVoteStart       *regexp.Regexp // Vote start (pool size)
+	MapVote            *regexp.Regexp // Header preceding the offered options
+	MapVoteOption      *regexp.Regexp // One offered option
+	MapVoteMajority    *regexp.Regexp // Vote tally when a majority was reached
+	MapVoteDeadline    *regexp.Regexp // Vote timed out before a majority
+	MapVoteNoVotes     *regexp.Regexp // Nobody voted, server picks a random map
 	ChatCommand        *regexp.Regexp
+	ChatMessage        *regexp.Regexp // Any global or team chat line (including !commands)
 	RconCommand        *regexp.Regexp
 	ObjectiveDestroyed *regexp.Regexp
 	ObjectiveCaptured  *regexp.Regexp
@@ -71,14 +110,23 @@ func NewLogPatterns() *logPatterns {
 
 		CommandLine: regexp.MustCompile(`LogInit: Command Line:\s+(\w+)\?Scenario=([^?]+)\?MaxPlayers=(\d+)\?Game=([^?]+)\?Lighting=(\w+).*?-Hostname="([^"]+)"`),
 		// Kill events - always provide consistent capture groups for killer/victim/weapon fields
-		// PlayerKill: timestamp, killerSection, victimName, victimSteam, victimTeam, weapon
-		PlayerKill: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogGameplayEvents: Display: (.+?) killed (.+?) with (.+)$`),
+		// PlayerKill: timestamp, killerSection, victimSection, weapon, optional hit suffix
+		// Some server configurations append the hit region, e.g. "with BP_Firearm_M4A1_C_123 (Headshot)" or "(HitZone: Head)"
+		PlayerKill: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogGameplayEvents: Display: (.+?) killed (.+?) with (.+?)(?: \((Headshot|[Hh]it(?:[Zz]one|[Rr]egion)?: ?\w+)\))?$`),
+		// Some configs log assisters on a line of their own right after the kill
+		// KillAssist: timestamp, assisterSection, victimSection
+		// Example: [2025.11.10-21.05.12:301][120]LogGameplayEvents: Display: Rabbit[76561198995742956, team 0] assisted in killing Marksman[INVALID, team 1]
+		KillAssist: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogGameplayEvents: Display: (.+?) assisted in killing (.+?)$`),
+		// Weapon fire is only written when LogGameplayEvents is raised to Verbose; default logging never has it
+		// WeaponFire: timestamp, playerSection, shots, weapon, hits
+		// Example: [2025.11.10-21.05.12:301][120]LogGameplayEvents: Verbose: ArmoredBear[76561198995742987, team 0] fired 30 shots with BP_Firearm_M4A1_C_2147480587 (7 hits)
+		WeaponFire: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogGameplayEvents: Verbose: (.+?) fired (\d+) shots? with (.+?) \((\d+) hits?\)$`),
 
 		// Player connection events - three stages:
 		// 1. PlayerLogin: [timestamp][id]LogNet: Login request (earliest connection event with name & Steam ID)
 		// 2. PlayerRegister: [timestamp][id]LogEOSAntiCheat: ServerRegisterClient (happens after login)
 		// 3. PlayerJoin: [timestamp][id]LogNet: Join succeeded (happens when player actually in match)
-		PlayerLogin:    regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogNet: Login request:.*\?Name=(.+?) userId: \w+:(\d+) platform: (\w+)`),
+		PlayerLogin:    regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogNet: Login request:.*\?Name=(.+?) userId: \w+:(\w+) platform: (\w+)`),
 		PlayerRegister: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogEOSAntiCheat: Display: ServerRegisterClient: Client: \((\d+)\) Result: \(EOS_Success\)`),
 		PlayerJoin:     regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogNet: Join succeeded: ([^\r\n]+)`),
 		// Player connection event - accepts post-challenge connection (IP capture)
@@ -94,6 +142,11 @@ func NewLogPatterns() *logPatterns {
 
 		GameOver: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogSession: Display: AINSGameSession::HandleMatchHasEnded`),
 
+		// Crash reports: every LogWindows error, any Fatal verbosity line, and fatal errors or
+		// assertions logged as errors by other categories (e.g. "LogCore: Error: appError called: Assertion failed: ...")
+		// ServerCrash: timestamp, then the message in whichever of groups 2-4 matched
+		ServerCrash: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\](?:LogWindows: Error: (.*)|\w+: Fatal: (.*)|\w+: Error: .*?((?:Fatal error|Assertion failed).*))$`),
+
 		// Map and server events
 		// Handles both cases: with Game= parameter and without
 		// Capture groups: 1=timestamp, 2=mapName, 3=scenario, 4=maxPlayers, 5=game (optional), 6=lighting
@@ -104,11 +157,25 @@ func NewLogPatterns() *logPatterns {
 
 		// DifficultyChange: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogAI: Warning: AI difficulty set to ([0-9.]+)`), // Not currently used
 
-		MapVote: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Display: New Vote Options:`),
+		// Map vote events (end of match). The winning map is not logged by the vote manager;
+		// it is taken from the ProcessServerTravel line that follows the vote
+		// Example: [2025.11.10-21.12.45:402][ 38]LogMapVoteManager: Display: Starting map vote, 63 maps in pool.
+		MapVoteStart: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Display: Starting map vote, (\d+) maps in pool`),
+		MapVote:      regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Display: New Vote Options:`),
+		// Example: [2025.11.10-21.12.45:415][ 38]LogMapVoteManager: Display: ID:7 Map:Oilfield Scenario:Scenario_Refinery_Push_Insurgents ScenarioAsset: Opts:
+		MapVoteOption: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Display: ID:(\d+) Map:(\S+) Scenario:(\S+)`),
+		// Example: [2025.11.10-21.12.54:748][601]LogMapVoteManager: Display: Majority check completed, 1.00 of 0.60 voted for the winning option(s).
+		MapVoteMajority: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Display: Majority check completed, ([0-9.]+) of ([0-9.]+) voted for the winning option`),
+		MapVoteDeadline: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Deadline hit, finding winning map vote`),
+		MapVoteNoVotes:  regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogMapVoteManager: Warning: No map votes, picking random map`),
 
 		// Chat and RCON events
 		ChatCommand: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogChat: Display: ([^(]+)\((\d+)\) Global Chat: (!.+)`),
 
+		// ChatMessage: timestamp, playerName, steamID, channel (Global/Team), message
+		// Team chat may carry the team number, e.g. "Team 1 Chat:"
+		ChatMessage: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogChat: Display: ([^(]+)\((\d+)\) (Global|Team)(?: \d+)? Chat: (.*)`),
+
 		RconCommand: regexp.MustCompile(`\[(\d{4}\.\d{2}\.\d{2}-\d{2}\.\d{2}\.\d{2}:\d{1,3})\]\[\s*\d+\]LogRcon: ([^<]+)<< (.+)`),
 
 		// Objective events
@@ -123,18 +190,6 @@ func NewLogPatterns() *logPatterns {
 	}
 }
 
-// extractPlayerTeam extracts the player team from a scenario string
-// Returns "Security" or "Insurgents" based on scenario suffix, or empty string if undetermined
-func extractPlayerTeam(scenario string) string {
-	if strings.HasSuffix(scenario, "_Security") {
-		return "Security"
-	}
-	if strings.HasSuffix(scenario, "_Insurgents") {
-		return "Insurgents"
-	}
-	return ""
-}
-
 // extractMapTitle extracts a friendly map title from scenario name
 // Example: Scenario_Hideout_Checkpoint_Security -> Hideout
 func extractMapTitle(scenario string) string {
@@ -167,7 +222,7 @@ func (p *LogParser) tryProcessMapTravel(ctx context.Context, line string, timest
 	gameMode := strings.TrimSpace(matches[4])
 
 	// Extract player team from scenario
-	playerTeam := extractPlayerTeam(scenario)
+	_, playerTeam := util.NormalizeScenario(scenario)
 	var playerTeamPtr *string
 	if playerTeam != "" {
 		playerTeamPtr = &playerTeam
@@ -176,13 +231,17 @@ func (p *LogParser) tryProcessMapTravel(ctx context.Context, line string, timest
 	// Extract title from scenario
 	title := extractMapTitle(scenario)
 
-	p.logger.Debug("Map travel detected", "map", mapName, "scenario", scenario, "gameMode", gameMode, "serverID", serverID)
+	p.logger.Debug("Map travel detected", "map", mapName, "scenario", scenario, "gameMode", gameMode, "server_id", serverID)
 
 	// Track this map travel time so we can ignore immediate disconnects/reconnects
-	p.lastMapTravelTimes[serverID] = timestamp
+	p.recordMapTravel(serverID, timestamp)
+
+	// A concluded map vote is completed by this travel; emit it first so the vote is
+	// attributed to the match that is ending
+	p.completeMapVote(ctx, serverID, mapName, scenario, timestamp)
 
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreateEvent(events.TypeMapTravel, serverID, map[string]interface{}{
+		err := p.creator(ctx).CreateEvent(events.TypeMapTravel, serverID, map[string]interface{}{
 			"map":         mapName,
 			"scenario":    scenario,
 			"player_team": playerTeamPtr,
@@ -200,44 +259,174 @@ func (p *LogParser) tryProcessMapTravel(ctx context.Context, line string, timest
 	return true
 }
 
+// DefaultMapTravelReconnectWindow is how long after a map travel disconnects are treated as the
+// server reconnecting players rather than players leaving, unless configured otherwise
+const DefaultMapTravelReconnectWindow = 30 * time.Second
+
+// recordMapTravel remembers when a server last travelled to a new map
+func (p *LogParser) recordMapTravel(serverID string, timestamp time.Time) {
+	p.mapTravelMu.Lock()
+	defer p.mapTravelMu.Unlock()
+	p.lastMapTravelTimes[serverID] = timestamp
+}
+
+// timeSinceMapTravel returns how long after the server's last map travel timestamp is.
+// ok is false when the server has not travelled yet or timestamp is before the travel.
+func (p *LogParser) timeSinceMapTravel(serverID string, timestamp time.Time) (time.Duration, bool) {
+	p.mapTravelMu.RLock()
+	lastTravelTime, exists := p.lastMapTravelTimes[serverID]
+	p.mapTravelMu.RUnlock()
+
+	if !exists {
+		return 0, false
+	}
+	since := timestamp.Sub(lastTravelTime)
+	return since, since >= 0
+}
+
+// isMapTravelReconnect reports whether a disconnect at timestamp falls within the reconnect window
+// after the server's last map travel, and how long after the travel it is
+func (p *LogParser) isMapTravelReconnect(serverID string, timestamp time.Time) (time.Duration, bool) {
+	since, ok := p.timeSinceMapTravel(serverID, timestamp)
+	return since, ok && since < p.mapTravelReconnectWindow
+}
+
 // NewLogParser creates a new log parser with PocketBase app
 func NewLogParser(pbApp core.App, logger *slog.Logger) *LogParser {
 	return &LogParser{
-		patterns:           NewLogPatterns(),
-		pbApp:              pbApp,
-		logger:             logger,
-		lastMapTravelTimes: make(map[string]time.Time),
-		eventCreator:       events.NewCreator(pbApp), // Initialize event creator for dual-write phase
+		patterns:                 NewLogPatterns(),
+		pbApp:                    pbApp,
+		logger:                   logger,
+		lastMapTravelTimes:       make(map[string]time.Time),
+		mapTravelReconnectWindow: DefaultMapTravelReconnectWindow,
+		eventCreator:             events.NewCreator(pbApp), // Initialize event creator for dual-write phase
+		locations:                make(map[string]*time.Location),
+		mapVotes:                 make(map[string]*pendingMapVote),
+		sessions:                 make(map[string]*playerSessions),
+		teamSwapWindow:           DefaultTeamSwapWindow,
+		lastCrashes:              make(map[string]time.Time),
+		lastKills:                make(map[string]*recentKill),
+		ingest:                   newIngestStats(),
+		ignore:                   defaultIgnoreList,
 	}
 }
 
+// SetServerLocation sets the timezone used to parse log timestamps for a server.
+// Game servers write timestamps in their own local time, which may differ from the
+// tracker's timezone when servers are hosted elsewhere. A nil location resets to time.Local.
+func (p *LogParser) SetServerLocation(serverID string, loc *time.Location) {
+	p.locationsMu.Lock()
+	defer p.locationsMu.Unlock()
+	if loc == nil {
+		delete(p.locations, serverID)
+		return
+	}
+	p.locations[serverID] = loc
+}
+
+// SetStoreChatMessages enables emitting a chat_message event for every global/team chat line.
+// Must be called before log processing starts. !commands are dispatched regardless.
+func (p *LogParser) SetStoreChatMessages(enabled bool) {
+	p.storeChatMessages = enabled
+}
+
+// SetMapTravelReconnectWindow sets how long after a map travel disconnects are ignored as players
+// reconnecting to the new map. A window of zero or less restores the 30 second default.
+// Must be called before log processing starts.
+func (p *LogParser) SetMapTravelReconnectWindow(window time.Duration) {
+	if window <= 0 {
+		window = DefaultMapTravelReconnectWindow
+	}
+	p.mapTravelReconnectWindow = window
+}
+
+// SetTrackWeaponFire enables emitting a weapon_fire event for every shots-fired line.
+// The lines only exist when the server logs LogGameplayEvents at Verbose; without them this is a no-op.
+// Must be called before log processing starts.
+func (p *LogParser) SetTrackWeaponFire(enabled bool) {
+	p.trackWeaponFire = enabled
+}
+
+// FlushEvents waits for events queued for retry to be saved or given up on (see events.Creator.Flush)
+func (p *LogParser) FlushEvents(ctx context.Context) error {
+	if p.eventCreator == nil {
+		return nil
+	}
+	return p.eventCreator.Flush(ctx)
+}
+
+// DroppedEvents returns the number of events given up on after their insert kept failing, by event type
+func (p *LogParser) DroppedEvents() map[string]int {
+	if p.eventCreator == nil {
+		return nil
+	}
+	return p.eventCreator.DroppedEvents()
+}
+
+// locationFor returns the timezone for a server's log timestamps (defaults to time.Local)
+func (p *LogParser) locationFor(serverID string) *time.Location {
+	p.locationsMu.RLock()
+	defer p.locationsMu.RUnlock()
+	if loc, ok := p.locations[serverID]; ok {
+		return loc
+	}
+	return time.Local
+}
+
 // ExtractLogFileCreationTime reads the first line of a log file and extracts the creation timestamp
+// The wall clock is returned as-is in UTC: it is only used as an identity marker for rotation
+// detection, so it is never shifted by timezone and cannot land in a DST gap or overlap
 // Returns the timestamp or error if not found
 func (p *LogParser) ExtractLogFileCreationTime(logFilePath string) (time.Time, error) {
-	file, err := os.Open(logFilePath)
+	file, err := OpenLogFile(logFilePath)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(NewLogReader(file))
 	if !scanner.Scan() {
 		return time.Time{}, fmt.Errorf("log file is empty")
 	}
 
 	firstLine := scanner.Text()
-	matches := p.patterns.LogFileOpen.FindStringSubmatch(firstLine)
-	if len(matches) < 2 {
+	timestamp, _, ok, err := p.parseLogFileOpen(firstLine, time.UTC)
+	if !ok {
 		return time.Time{}, fmt.Errorf("first line does not match log file open pattern: %s", firstLine)
 	}
+	return timestamp, err
+}
+
+// parseLogFileOpen extracts the creation timestamp from a "Log file open" line as a wall clock
+// in loc, resolving DST transitions like parseTimestamp. Reports whether the line is a log file
+// open line.
+func (p *LogParser) parseLogFileOpen(line string, loc *time.Location) (time.Time, dstResolution, bool, error) {
+	matches := p.patterns.LogFileOpen.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return time.Time{}, dstNone, false, nil
+	}
 
 	// Parse timestamp: "11/10/25 20:58:31"
-	timestamp, err := time.Parse("01/02/06 15:04:05", matches[1])
+	wall, err := time.Parse("01/02/06 15:04:05", matches[1])
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse log file timestamp: %w", err)
+		return time.Time{}, dstNone, true, fmt.Errorf("failed to parse log file timestamp: %w", err)
 	}
 
-	return timestamp, nil
+	// Go maps two-digit years 69-99 to the 1900s; Insurgency: Sandstorm logs can only be
+	// from this century, so pin the century to 20xx
+	timestamp, resolution := dateInLocation(2000+wall.Year()%100, wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+
+	return timestamp, resolution, true, nil
+}
+
+// creator returns the event creator for the log line being processed, deduplicating
+// its events when ParseAndProcess attached a dedup key to ctx
+func (p *LogParser) creator(ctx context.Context) *events.Creator {
+	if key, ok := ctx.Value(dedupKeyKey).(string); ok && key != "" && p.eventCreator != nil {
+		return p.eventCreator.WithDedupKey(key)
+	}
+	return p.eventCreator
 }
 
 // ParseAndProcess parses a log line and writes to database if it's a recognized event
@@ -246,6 +435,28 @@ func (p *LogParser) ParseAndProcess(ctx context.Context, line string, serverID s
 	if line == "" {
 		return nil
 	}
+	p.ingest.lineProcessed()
+
+	// The first line of a log carries its own timestamp format instead of the bracketed one,
+	// but is in the server's local time like every other line
+	loc := p.locationFor(serverID)
+	if timestamp, resolution, ok, err := p.parseLogFileOpen(line, loc); ok {
+		if err != nil {
+			p.ingest.parseError()
+			return nil // Skip lines with invalid timestamp
+		}
+		if resolution != dstNone {
+			p.logger.Debug("Log file open timestamp falls inside a DST transition",
+				"server_id", serverID,
+				"location", loc.String(),
+				"resolution", resolution.String(),
+				"resolved", timestamp.Format(time.RFC3339))
+		}
+		ctx = context.WithValue(ctx, dedupKeyKey, events.DedupKey(serverID, timestamp, line))
+		p.tryProcessLogFileOpen(ctx, line, timestamp, serverID)
+		p.ingest.lineMatched(events.TypeLogFileCreated)
+		return nil
+	}
 
 	// Extract timestamp first
 	timestampMatches := p.patterns.Timestamp.FindStringSubmatch(line)
@@ -253,77 +464,118 @@ func (p *LogParser) ParseAndProcess(ctx context.Context, line string, serverID s
 		return nil // Skip lines without proper timestamp
 	}
 
-	timestamp, err := parseTimestamp(timestampMatches[1])
+	timestamp, err := p.parseServerTimestamp(timestampMatches[1], loc, serverID)
 	if err != nil {
+		p.ingest.parseError()
 		return nil // Skip lines with invalid timestamp
 	}
 
+	// Events created from this line are keyed by it, so reprocessing the line is a no-op
+	ctx = context.WithValue(ctx, dedupKeyKey, events.DedupKey(serverID, timestamp, line))
+
 	// Try each event type and process immediately
 	// NOTE: Check objectives BEFORE kills to prevent objectives from being counted as kills
 
 	if p.tryProcessMapTravel(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeMapTravel)
 		return nil
 	}
 
 	if p.tryProcessMapLoad(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeMapLoad)
 		return nil
 	}
 
 	if p.tryProcessObjectiveDestroyed(ctx, line, timestamp, serverID, logFilePath) {
+		p.ingest.lineMatched(events.TypeObjectiveDestroyed)
 		return nil
 	}
 
 	if p.tryProcessObjectiveCaptured(ctx, line, timestamp, serverID, logFilePath) {
+		p.ingest.lineMatched(events.TypeObjectiveCaptured)
 		return nil
 	}
 
 	if p.tryProcessKillEvent(ctx, line, timestamp, serverID, logFilePath) {
+		p.ingest.lineMatched(events.TypePlayerKill)
+		return nil
+	}
+
+	if p.tryProcessKillAssist(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeKillAssist)
+		return nil
+	}
+
+	if p.tryProcessWeaponFire(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeWeaponFire)
 		return nil
 	}
 
 	if p.tryProcessPlayerLogin(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypePlayerLogin)
 		return nil
 	}
 
 	if p.tryProcessPlayerConnection(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypePlayerConnection)
 		return nil
 	}
 
 	if p.tryProcessPlayerRegister(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched("player_register")
 		return nil
 	}
 
 	if p.tryProcessPlayerJoin(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypePlayerJoin)
 		return nil
 	}
 
 	if p.tryProcessPlayerDisconnect(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypePlayerLeave)
 		return nil
 	}
 
 	if p.tryProcessRoundStart(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeRoundStart)
 		return nil
 	}
 
 	if p.tryProcessRoundEnd(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeRoundEnd)
 		return nil
 	}
 
 	if p.tryProcessGameOver(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeGameOver)
 		return nil
 	}
 
-	if p.tryProcessLogFileOpen(ctx, line, timestamp, serverID) {
+	if p.tryProcessServerCrash(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeServerCrash)
+		return nil
+	}
+
+	// Chat messages are recorded first; !commands then fall through to command dispatch
+	if p.tryProcessChatMessage(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeChatMessage)
 		return nil
 	}
 
 	if p.tryProcessChatCommand(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeChatCommand)
+		return nil
+	}
+
+	if p.tryProcessMapVote(ctx, line, timestamp, serverID) {
+		p.ingest.lineMatched(events.TypeMapVote)
 		return nil
 	}
 
 	// Add other event types as needed (round start/end, etc.)
 	// For now, we're focusing on the core stat-tracking events
 
+	p.ingest.lineUnmatched(serverID, line)
 	return nil
 }
 
@@ -335,16 +587,19 @@ func (p *LogParser) tryProcessLogFileOpen(ctx context.Context, line string, time
 		return false
 	}
 
-	p.logger.Debug("Log file created", "serverID", serverID, "timestamp", timestamp)
+	p.logger.Debug("Log file created", "server_id", serverID, "timestamp", timestamp)
+
+	// A new log file means the server restarted, so nobody from the last one is still on it
+	p.resetSessions(serverID)
 
 	// Emit log file created event for handler to process
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreateEvent(events.TypeLogFileCreated, serverID, map[string]interface{}{
+		err := p.creator(ctx).CreateEvent(events.TypeLogFileCreated, serverID, map[string]interface{}{
 			"timestamp": timestamp,
 		})
 		if err != nil {
 			p.logger.Error("Failed to create log file created event",
-				"server", serverID,
+				"server_id", serverID,
 				"timestamp", timestamp,
 				"error", err,
 			)
@@ -365,7 +620,7 @@ func (p *LogParser) tryProcessPlayerConnection(ctx context.Context, line string,
 
 	ip := matches[2]
 
-	p.logger.Debug("Player connection from IP", "ip", ip, "serverID", serverID)
+	p.logger.Debug("Player connection from IP", "ip", ip, "server_id", serverID)
 
 	// Store IP in app store with key format: "serverID:lastIP"
 	// This will be used when the next player_login event occurs
@@ -376,7 +631,7 @@ func (p *LogParser) tryProcessPlayerConnection(ctx context.Context, line string,
 	if existingIP != nil {
 		// Another connection came in before the previous one logged in
 		// Discard both to avoid confusion about which IP connects to which player
-		p.logger.Debug("Discarding duplicate connection IP - unable to match with player", "serverID", serverID)
+		p.logger.Debug("Discarding duplicate connection IP - unable to match with player", "server_id", serverID)
 		p.pbApp.Store().Set(storeKey, nil)
 		return true
 	}
@@ -397,15 +652,16 @@ func (p *LogParser) tryProcessKillEvent(ctx context.Context, line string, timest
 	killerSection := strings.TrimSpace(matches[2])
 	victimSection := strings.TrimSpace(matches[3])
 	weapon := matches[4]
+	hitRegion, isHeadshot := ParseHitRegion(matches[5])
 
 	// Parse killer section
-	killers := ParseKillerSection(killerSection)
+	killers := p.parseActors(killerSection)
 	if len(killers) == 0 {
 		return true // Parsed but no valid killers (AI suicide)
 	}
 
 	// Parse victim section as a single Killer struct
-	victimParsed := ParseKillerSection(victimSection)
+	victimParsed := p.parseActors(victimSection)
 	var victim killer
 	if len(victimParsed) > 0 {
 		victim = victimParsed[0]
@@ -415,6 +671,9 @@ func (p *LogParser) tryProcessKillEvent(ctx context.Context, line string, timest
 	// Determine if this is PvP (player victim) or PvE (bot victim)
 	isPvP := victim.SteamID != "INVALID"
 
+	// Assisters may follow on lines of their own
+	p.rememberKill(serverID, killers, victim, weapon, timestamp)
+
 	// EVENT-DRIVEN ARCHITECTURE: Create event records for hook-based processing
 	// Hooks handle all database updates, player creation, scoring, and ML classification
 	if p.eventCreator != nil && len(killers) > 0 {
@@ -430,11 +689,14 @@ func (p *LogParser) tryProcessKillEvent(ctx context.Context, line string, timest
 		// Emit a single event with all killers in the array (as []killer)
 		// Note: weapon is passed raw (unsanitized) - UpsertMatchWeaponStats will handle
 		// cleaning the name and extracting the weapon type internally
-		err := p.eventCreator.CreateEvent(events.TypePlayerKill, serverID, map[string]interface{}{
-			"killers":    killersArr,
-			"victim":     victim,
-			"weapon":     weapon,
-			"is_catchup": isCatchupMode(ctx),
+		err := p.creator(ctx).CreateEvent(events.TypePlayerKill, serverID, map[string]interface{}{
+			"killers":     killersArr,
+			"victim":      victim,
+			"weapon":      weapon,
+			"hit_region":  hitRegion,
+			"is_headshot": isHeadshot,
+			"timestamp":   timestamp,
+			"is_catchup":  isCatchupMode(ctx),
 		})
 		if err != nil {
 			p.logger.Error("Failed to create kill event",
@@ -454,6 +716,48 @@ func (p *LogParser) tryProcessKillEvent(ctx context.Context, line string, timest
 	return true
 }
 
+// tryProcessWeaponFire parses verbose shots-fired lines when weapon fire tracking is enabled
+func (p *LogParser) tryProcessWeaponFire(ctx context.Context, line string, timestamp time.Time, serverID string) bool {
+	if !p.trackWeaponFire {
+		return false
+	}
+
+	matches := p.patterns.WeaponFire.FindStringSubmatch(line)
+	if len(matches) < 6 {
+		return false
+	}
+
+	shooters := p.parseActors(strings.TrimSpace(matches[2]))
+	if len(shooters) == 0 || shooters[0].SteamID == "INVALID" {
+		return true // Bots firing are not tracked
+	}
+	shooter := shooters[0]
+	shots, _ := strconv.Atoi(matches[3])
+	hits, _ := strconv.Atoi(matches[5])
+	weapon := matches[4]
+
+	if p.eventCreator != nil {
+		err := p.creator(ctx).CreateWeaponFireEvent(serverID, events.WeaponFireData{
+			SteamID:    shooter.SteamID,
+			PlayerName: shooter.Name,
+			Team:       shooter.Team,
+			Weapon:     weapon,
+			Shots:      shots,
+			Hits:       hits,
+			Timestamp:  timestamp,
+			IsCatchup:  isCatchupMode(ctx),
+		})
+		if err != nil {
+			p.logger.Error("Failed to create weapon fire event",
+				"player", shooter.Name,
+				"weapon", weapon,
+				"error", err.Error())
+		}
+	}
+
+	return true
+}
+
 // tryProcessPlayerLogin parses Login request events (earliest connection event)
 // This event happens first when a player connects to the server, before ServerRegisterClient
 // Example: [2025.11.10-20.58.50:166][881]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI
@@ -465,17 +769,21 @@ func (p *LogParser) tryProcessPlayerLogin(ctx context.Context, line string, time
 
 	// Group 1: timestamp
 	// Group 2: player name
-	// Group 3: Steam ID (or other platform ID)
+	// Group 3: Steam ID, or the platform's ID (Epic IDs are hex)
 	// Group 4: platform (SteamNWI, Epic, etc.)
 	playerName := strings.TrimSpace(matches[2])
 	steamID := strings.TrimSpace(matches[3])
 	platform := strings.TrimSpace(matches[4])
+	if p.ignores(playerName, steamID) {
+		return true // AI or system actor
+	}
 
-	p.logger.Debug("Player login request", "playerName", playerName, "steamID", steamID, "platform", platform, "serverID", serverID)
+	p.logger.Debug("Player login request", "playerName", playerName, "steamID", steamID, "platform", platform, "server_id", serverID)
+	p.recordLogin(serverID, playerName, steamID, timestamp)
 
 	// Create player_login event (handler will create/update player record)
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreatePlayerLoginEvent(serverID, playerName, steamID, platform, isCatchupMode(ctx))
+		err := p.creator(ctx).CreatePlayerLoginEvent(serverID, playerName, steamID, platform, isCatchupMode(ctx))
 		if err != nil {
 			p.logger.Debug("Failed to create player_login event", "error", err)
 		}
@@ -496,7 +804,7 @@ func (p *LogParser) tryProcessPlayerRegister(ctx context.Context, line string, t
 	// Group 1: timestamp
 	// Group 2: Steam ID
 	steamID := strings.TrimSpace(matches[2])
-	p.logger.Debug("Player registered (pre-match)", "steamID", steamID, "serverID", serverID)
+	p.logger.Debug("Player registered (pre-match)", "steamID", steamID, "server_id", serverID)
 
 	// We don't create the player here - wait for the LogNet "Join succeeded" event
 	// which will have the player's name
@@ -515,10 +823,23 @@ func (p *LogParser) tryProcessPlayerJoin(ctx context.Context, line string, times
 	// Group 1: timestamp
 	// Group 2: player name
 	playerName := strings.TrimSpace(matches[2])
+	if p.ignores(playerName, "") {
+		return true // AI or system actor
+	}
+
+	// Switching teams leaves and rejoins the match; the handler keeps the player's session going
+	sinceLeave, teamSwap := p.isTeamSwapRejoin(serverID, playerName, timestamp)
+	if teamSwap {
+		p.logger.Debug("Player rejoined after a team swap", "playerName", playerName, "secondsAfterLeave", sinceLeave.Seconds())
+	}
 
 	// Create player join event (handler will ensure player exists, add to match, and send RCON message)
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreatePlayerJoinEvent(serverID, playerName, isCatchupMode(ctx))
+		err := p.creator(ctx).CreatePlayerJoinEvent(serverID, events.PlayerJoinData{
+			PlayerName: playerName,
+			TeamSwap:   teamSwap,
+			IsCatchup:  isCatchupMode(ctx),
+		})
 		if err != nil {
 			p.logger.Debug("Failed to create player_join event", "error", err)
 		}
@@ -540,20 +861,21 @@ func (p *LogParser) tryProcessPlayerDisconnect(ctx context.Context, line string,
 	// Check if this disconnect occurred shortly after a map travel
 	// If so, it's just the server reconnecting players during map change, not a real disconnect
 	isMapTravelDisconnect := false
-	if lastTravelTime, exists := p.lastMapTravelTimes[serverID]; exists {
-		timeSinceTravel := timestamp.Sub(lastTravelTime)
-		if timeSinceTravel >= 0 && timeSinceTravel < 30*time.Second {
-			// This is a temporary disconnect during map travel, ignore it for database updates
-			// but don't return early - we still need to handle it below
-			p.logger.Debug("Ignoring disconnect for player during map travel", "steamID", steamID, "secondsAfterTravel", timeSinceTravel.Seconds())
-			isMapTravelDisconnect = true
-		}
+	if timeSinceTravel, ok := p.isMapTravelReconnect(serverID, timestamp); ok {
+		// This is a temporary disconnect during map travel, ignore it for database updates
+		// but don't return early - we still need to handle it below
+		p.logger.Debug("Ignoring disconnect for player during map travel", "steamID", steamID, "secondsAfterTravel", timeSinceTravel.Seconds())
+		isMapTravelDisconnect = true
+	}
+
+	if !isMapTravelDisconnect {
+		p.recordLeave(serverID, steamID, timestamp)
 	}
 
 	// Only create leave event if this is a real disconnect (not map travel)
 	if !isMapTravelDisconnect && p.eventCreator != nil {
 		// Create player_leave event with raw Steam ID (handler will do player lookup)
-		err := p.eventCreator.CreatePlayerLeaveEvent(serverID, steamID, "")
+		err := p.creator(ctx).CreatePlayerLeaveEvent(serverID, steamID, "")
 		if err != nil {
 			p.logger.Debug("Failed to create player_leave event", "error", err)
 		}
@@ -578,11 +900,11 @@ func (p *LogParser) tryProcessRoundStart(ctx context.Context, line string, times
 		return true
 	}
 
-	p.logger.Debug("Round started on server", "roundNum", roundNum, "serverID", serverID)
+	p.logger.Debug("Round started on server", "roundNum", roundNum, "server_id", serverID)
 
 	// Emit round start event - handler will reset round objectives
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreateRoundStartEvent(serverID, "", roundNum)
+		err := p.creator(ctx).CreateRoundStartEvent(serverID, "", roundNum)
 		if err != nil {
 			p.logger.Error("Failed to create round start event",
 				"round", roundNum, "error", err.Error())
@@ -609,10 +931,10 @@ func (p *LogParser) tryProcessRoundEnd(ctx context.Context, line string, timesta
 	}
 
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreateRoundEndEvent(serverID, "", 0, winningTeam, isCatchupMode(ctx))
+		err := p.creator(ctx).CreateRoundEndEvent(serverID, "", 0, winningTeam, isCatchupMode(ctx))
 		if err != nil {
 			p.logger.Error("Failed to create round end event",
-				"winningTeam", winningTeam, "serverID", serverID, "error", err.Error())
+				"winningTeam", winningTeam, "server_id", serverID, "error", err.Error())
 		}
 	}
 
@@ -627,16 +949,16 @@ func (p *LogParser) tryProcessGameOver(ctx context.Context, line string, timesta
 		return false
 	}
 
-	p.logger.Debug("Game over detected", "serverID", serverID)
+	p.logger.Debug("Game over detected", "server_id", serverID)
 
 	// Emit game over event - handler will finalize match
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreateEvent(events.TypeGameOver, serverID, map[string]interface{}{
+		err := p.creator(ctx).CreateEvent(events.TypeGameOver, serverID, map[string]interface{}{
 			"is_catchup": isCatchupMode(ctx),
 		})
 		if err != nil {
 			p.logger.Error("Failed to create game over event",
-				"serverID", serverID, "error", err.Error())
+				"server_id", serverID, "error", err.Error())
 		}
 	}
 
@@ -656,7 +978,7 @@ func (p *LogParser) tryProcessMapLoad(ctx context.Context, line string, timestam
 	gameMode := strings.TrimSpace(matches[5])
 
 	// Extract player team from scenario
-	playerTeam := extractPlayerTeam(scenario)
+	_, playerTeam := util.NormalizeScenario(scenario)
 	var playerTeamPtr *string
 	if playerTeam != "" {
 		playerTeamPtr = &playerTeam
@@ -667,7 +989,7 @@ func (p *LogParser) tryProcessMapLoad(ctx context.Context, line string, timestam
 
 	if p.eventCreator != nil {
 		// Emit map load event - handler will create new match and start event
-		err := p.eventCreator.CreateEvent(events.TypeMapLoad, serverID, map[string]interface{}{
+		err := p.creator(ctx).CreateEvent(events.TypeMapLoad, serverID, map[string]interface{}{
 			"map":         mapName,
 			"scenario":    scenario,
 			"timestamp":   timestamp,
@@ -746,6 +1068,24 @@ func ParseKillerSection(killerSection string) []killer {
 	return killers
 }
 
+// ParseHitRegion extracts the hit region from an optional kill suffix ("Headshot" or "HitZone: Head")
+// Returns an empty region when the log line carried no suffix
+func ParseHitRegion(suffix string) (region string, isHeadshot bool) {
+	suffix = strings.TrimSpace(suffix)
+	if suffix == "" {
+		return "", false
+	}
+
+	if strings.EqualFold(suffix, "Headshot") {
+		return "Head", true
+	}
+
+	if idx := strings.Index(suffix, ":"); idx != -1 {
+		region = strings.TrimSpace(suffix[idx+1:])
+	}
+	return region, strings.EqualFold(region, "Head")
+}
+
 func CleanWeaponName(weapon string) string {
 	weapon = strings.TrimSpace(weapon)
 	weapon = strings.TrimPrefix(weapon, "BP_")
@@ -787,50 +1127,81 @@ func CleanWeaponName(weapon string) string {
 	return weapon
 }
 
-func parseTimestamp(ts string) (time.Time, error) {
+// ParseLogTimestamp parses a log timestamp (2025.10.04-15.23.38:790) as wall-clock time in the
+// game server's location, with the same DST rule as the parser (see dateInLocation)
+func ParseLogTimestamp(ts string, loc *time.Location) (time.Time, error) {
+	timestamp, _, err := parseTimestamp(ts, loc)
+	return timestamp, err
+}
+
+// parseTimestamp parses a log timestamp as wall-clock time in the given location
+// Wall-clock times inside a DST transition are resolved deterministically (see dateInLocation)
+// and the returned dstResolution reports which rule was applied
+func parseTimestamp(ts string, loc *time.Location) (time.Time, dstResolution, error) {
 	// Format: 2025.10.04-15.23.38:790
 	// Handle variable length milliseconds by using a custom parsing approach
 
 	// Split on the colon to separate the milliseconds
 	colonIdx := strings.LastIndex(ts, ":")
 	if colonIdx == -1 {
-		return time.Time{}, fmt.Errorf("invalid timestamp format: %s", ts)
+		return time.Time{}, dstNone, fmt.Errorf("invalid timestamp format: %s", ts)
 	}
 
 	dateTimePart := ts[:colonIdx]
 	msPart := ts[colonIdx+1:]
 
-	// Parse the date/time part in local timezone (log timestamps are in server's local time)
-	dt, err := time.ParseInLocation("2006.01.02-15.04.05", dateTimePart, time.Local)
+	// Parse the date/time fields as a plain wall clock first (UTC has no DST gaps or overlaps)
+	wall, err := time.Parse("2006.01.02-15.04.05", dateTimePart)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse datetime part: %w", err)
+		return time.Time{}, dstNone, fmt.Errorf("failed to parse datetime part: %w", err)
 	}
 
 	// Parse milliseconds and add to the time
 	ms, err := strconv.Atoi(msPart)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse milliseconds: %w", err)
+		return time.Time{}, dstNone, fmt.Errorf("failed to parse milliseconds: %w", err)
 	}
 
+	// Place the wall clock in the game server's timezone (log timestamps are in server's local time)
+	dt, resolution := dateInLocation(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+
 	// Add milliseconds to the parsed time
-	return dt.Add(time.Duration(ms) * time.Millisecond), nil
+	return dt.Add(time.Duration(ms) * time.Millisecond), resolution, nil
+}
+
+// parseServerTimestamp parses a log timestamp in loc and logs when a DST rule had to be applied
+func (p *LogParser) parseServerTimestamp(ts string, loc *time.Location, serverID string) (time.Time, error) {
+	timestamp, resolution, err := parseTimestamp(ts, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if resolution != dstNone {
+		p.logger.Debug("Log timestamp falls inside a DST transition",
+			"server_id", serverID,
+			"raw", ts,
+			"location", loc.String(),
+			"resolution", resolution.String(),
+			"resolved", timestamp.Format(time.RFC3339Nano))
+	}
+
+	return timestamp, nil
 }
 
-// findLastMapEvent searches backwards through a log file to find the most recent map event (MapTravel or MapLoad)
 // FindLastMapEvent finds the most recent map event in a log file before the given time.
 // Prioritizes MapTravel (runtime map change) over MapLoad (initial server start) since the server may not be on default map
 // Returns map name, scenario, timestamp, and line number where the event was found, or error if not found
 func (p *LogParser) FindLastMapEvent(logFilePath string, beforeTime time.Time) (mapName, scenario string, timestamp time.Time, lineNumber int, err error) {
-	file, err := os.Open(logFilePath)
+	file, err := OpenLogFile(logFilePath)
 	if err != nil {
 		return "", "", time.Time{}, 0, fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer file.Close()
 
 	// Read file in reverse to find the last map event before the given time
-	// For simplicity, we'll read all lines and process from end to start
+	// For simplicity, we'll read all lines and process from end to start (archives are decompressed as they are read)
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(NewLogReader(file))
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
@@ -838,54 +1209,82 @@ func (p *LogParser) FindLastMapEvent(logFilePath string, beforeTime time.Time) (
 		return "", "", time.Time{}, 0, fmt.Errorf("failed to read log file: %w", err)
 	}
 
+	mapName, scenario, timestamp, lineNumber, ok := p.lastMapEvent(lines, logFileServerID(logFilePath), beforeTime)
+	if !ok {
+		return "", "", time.Time{}, 0, fmt.Errorf("no map event found before %v", beforeTime)
+	}
+	return mapName, scenario, timestamp, lineNumber, nil
+}
+
+// FindLastMapEventBeforeOffset finds the most recent map event in the complete lines of a log file
+// ending at or before a byte offset, such as the watcher's saved offset, so a replay starting at
+// the offset knows which map it is on. Returns the same values as FindLastMapEvent.
+func (p *LogParser) FindLastMapEventBeforeOffset(logFilePath string, offset int64) (mapName, scenario string, timestamp time.Time, lineNumber int, err error) {
+	file, err := os.Open(logFilePath)
+	if err != nil {
+		return "", "", time.Time{}, 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	// Offsets count bytes on disk, so lines are read with their encoded sizes
+	var lines []string
+	var read int64
+	reader := NewLogLineReader(file, DetectLogFileEncoding(file))
+	for {
+		line, size, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", time.Time{}, 0, fmt.Errorf("failed to read log file: %w", err)
+		}
+		if read += int64(size); read > offset {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	mapName, scenario, timestamp, lineNumber, ok := p.lastMapEvent(lines, logFileServerID(logFilePath), time.Time{})
+	if !ok {
+		return "", "", time.Time{}, 0, fmt.Errorf("no map event found before offset %d", offset)
+	}
+	return mapName, scenario, timestamp, lineNumber, nil
+}
+
+// lastMapEvent searches lines backwards for the most recent MapTravel or MapLoad event, skipping
+// events after beforeTime unless it is zero. Reports false when there is none.
+func (p *LogParser) lastMapEvent(lines []string, serverID string, beforeTime time.Time) (mapName, scenario string, timestamp time.Time, lineNumber int, ok bool) {
+	loc := p.locationFor(serverID)
+
 	// Search backwards through lines, checking MapTravel first, then MapLoad
 	for i := len(lines) - 1; i >= 0; i-- {
 		line := lines[i]
 
-		// Try MapTravel pattern first (preferred as it means server is not on default map)
+		// Try MapTravel pattern first (preferred as it means server is not on default map),
+		// then MapLoad as fallback
 		matches := p.patterns.MapTravel.FindStringSubmatch(line)
-		if len(matches) >= 4 {
-			// Parse timestamp
-			ts, err := parseTimestamp(matches[1])
-			if err != nil {
+		if len(matches) < 4 {
+			matches = p.patterns.MapLoad.FindStringSubmatch(line)
+			if len(matches) < 5 {
 				continue
 			}
-
-			// Only consider events before the target time
-			if ts.After(beforeTime) {
-				continue
-			}
-
-			// Found MapTravel event!
-			mapName = strings.TrimSpace(matches[2])
-			scenario = strings.TrimSpace(matches[3])
-			timestamp = ts
-			return mapName, scenario, timestamp, i, nil
 		}
 
-		// Try MapLoad pattern as fallback
-		matches = p.patterns.MapLoad.FindStringSubmatch(line)
-		if len(matches) >= 5 {
-			// Parse timestamp
-			ts, err := parseTimestamp(matches[1])
-			if err != nil {
-				continue
-			}
-
-			// Only consider events before the target time
-			if ts.After(beforeTime) {
-				continue
-			}
+		// Parse timestamp
+		ts, err := p.parseServerTimestamp(matches[1], loc, serverID)
+		if err != nil {
+			continue
+		}
 
-			// Found MapLoad event!
-			mapName = strings.TrimSpace(matches[2])
-			scenario = strings.TrimSpace(matches[3])
-			timestamp = ts
-			return mapName, scenario, timestamp, i, nil
+		// Only consider events before the target time
+		if !beforeTime.IsZero() && ts.After(beforeTime) {
+			continue
 		}
+
+		return strings.TrimSpace(matches[2]), strings.TrimSpace(matches[3]), ts, i, true
 	}
 
-	return "", "", time.Time{}, 0, fmt.Errorf("no map event found before %v", beforeTime)
+	return "", "", time.Time{}, 0, false
 }
 
 // tryProcessObjectiveDestroyed parses and processes objective destroyed events
@@ -900,7 +1299,7 @@ func (p *LogParser) tryProcessObjectiveDestroyed(ctx context.Context, line strin
 	playerSection := strings.TrimSpace(matches[5])
 
 	// Parse player section (can have multiple players)
-	killers := ParseKillerSection(playerSection)
+	killers := p.parseActors(playerSection)
 	if len(killers) == 0 {
 		return true // Parsed but no valid players
 	}
@@ -922,12 +1321,13 @@ func (p *LogParser) tryProcessObjectiveDestroyed(ctx context.Context, line strin
 
 		if len(objectivePlayers) > 0 {
 			// Create single event with all players
-			err := p.eventCreator.CreateObjectiveDestroyedEvent(
+			err := p.creator(ctx).CreateObjectiveDestroyedEvent(
 				serverID,
 				"",
 				objectiveNum,
 				objectivePlayers,
 				team,
+				timestamp,
 				isCatchupMode(ctx),
 			)
 			if err != nil {
@@ -952,7 +1352,7 @@ func (p *LogParser) tryProcessObjectiveCaptured(ctx context.Context, line string
 	playerSection := strings.TrimSpace(matches[5])
 
 	// Parse player section (can have multiple players)
-	killers := ParseKillerSection(playerSection)
+	killers := p.parseActors(playerSection)
 	if len(killers) == 0 {
 		return true // Parsed but no valid players
 	}
@@ -974,12 +1374,13 @@ func (p *LogParser) tryProcessObjectiveCaptured(ctx context.Context, line string
 
 		if len(objectivePlayers) > 0 {
 			// Create single event with all players
-			err := p.eventCreator.CreateObjectiveCapturedEvent(
+			err := p.creator(ctx).CreateObjectiveCapturedEvent(
 				serverID,
 				"",
 				objectiveNum,
 				objectivePlayers,
 				team,
+				timestamp,
 				isCatchupMode(ctx),
 			)
 			if err != nil {
@@ -992,6 +1393,38 @@ func (p *LogParser) tryProcessObjectiveCaptured(ctx context.Context, line string
 	return true
 }
 
+// tryProcessChatMessage records global and team chat lines when chat storage is enabled
+// Returns false for !commands so they are still dispatched by tryProcessChatCommand
+func (p *LogParser) tryProcessChatMessage(ctx context.Context, line string, timestamp time.Time, serverID string) bool {
+	if !p.storeChatMessages {
+		return false
+	}
+
+	matches := p.patterns.ChatMessage.FindStringSubmatch(line)
+	if len(matches) < 6 {
+		return false
+	}
+
+	playerName := strings.TrimSpace(matches[2])
+	steamID := strings.TrimSpace(matches[3])
+	channel := strings.ToLower(matches[4])
+	message := strings.TrimSpace(matches[5])
+
+	if p.eventCreator != nil {
+		err := p.creator(ctx).CreateChatMessageEvent(serverID, steamID, playerName, channel, message, timestamp, isCatchupMode(ctx))
+		if err != nil {
+			p.logger.Error("Failed to create chat message event",
+				"player", playerName,
+				"steam_id", steamID,
+				"channel", channel,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	return !strings.HasPrefix(message, "!")
+}
+
 // tryProcessChatCommand parses chat commands and emits events for handling
 func (p *LogParser) tryProcessChatCommand(ctx context.Context, line string, timestamp time.Time, serverID string) bool {
 	matches := p.patterns.ChatCommand.FindStringSubmatch(line)
@@ -1007,7 +1440,7 @@ func (p *LogParser) tryProcessChatCommand(ctx context.Context, line string, time
 
 	// Emit chat command event for handler to process
 	if p.eventCreator != nil {
-		err := p.eventCreator.CreateChatCommandEvent(
+		err := p.creator(ctx).CreateChatCommandEvent(
 			serverID,
 			steamID,
 			playerName,
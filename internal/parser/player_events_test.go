@@ -2,12 +2,17 @@ package parser
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"sync"
 	"testing"
+	"time"
 
 	"sandstorm-tracker/internal/database"
 
 	_ "sandstorm-tracker/migrations"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/tests"
 )
 
@@ -163,6 +168,194 @@ func TestPlayerDisconnectEdgeCases(t *testing.T) {
 	})
 }
 
+const (
+	raceServers = 4
+	raceTravels = 5
+)
+
+// processTravelsConcurrently feeds map travels and disconnects for raceServers servers from
+// one goroutine each, as multi-server tailing does. Each server travels raceTravels times on its
+// own schedule, with one disconnect inside and one outside the reconnect window after each
+// travel. The last server never travels.
+func processTravelsConcurrently(t *testing.T, parser *LogParser) {
+	t.Helper()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, raceServers*raceTravels*3)
+	for s := 0; s < raceServers; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			serverID := fmt.Sprintf("race-server-%d", s)
+
+			for i := 0; i < raceTravels; i++ {
+				minute := fmt.Sprintf("12.%02d", s*10+i)
+				steamID := fmt.Sprintf("7656119800000%d%03d", s, i)
+				var lines []string
+				if s < raceServers-1 {
+					lines = append(lines, `[2025.11.15-`+minute+`.00:000][400]LogGameMode: ProcessServerTravel: Oilfield?Scenario=Scenario_Refinery_Push_Insurgents?Game=`)
+				}
+				lines = append(lines,
+					// Within the window: a reconnect during map travel
+					`[2025.11.15-`+minute+`.10:000][401]LogEOSAntiCheat: Display: ServerUnregisterClient: UserId (`+steamID+`), Result: (EOS_Success)`,
+					// Outside the window: a real disconnect
+					`[2025.11.15-`+minute+`.45:000][402]LogEOSAntiCheat: Display: ServerUnregisterClient: UserId (`+steamID+`), Result: (EOS_Success)`,
+				)
+
+				for _, line := range lines {
+					if err := parser.ParseAndProcess(ctx, line, serverID, "test.log"); err != nil {
+						errs <- err
+					}
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Failed to process line: %v", err)
+	}
+}
+
+// TestMapTravelTimesConcurrent checks the per-server map travel times stay consistent when
+// servers are parsed concurrently. Events are not created, so no database access orders the
+// goroutines and -race sees every unsynchronized access to the parser's state.
+func TestMapTravelTimesConcurrent(t *testing.T) {
+	parser := NewLogParser(nil, slog.Default())
+	parser.eventCreator = nil
+
+	processTravelsConcurrently(t, parser)
+
+	for s := 0; s < raceServers; s++ {
+		serverID := fmt.Sprintf("race-server-%d", s)
+		lastTravel := time.Date(2025, 11, 15, 12, s*10+raceTravels-1, 0, 0, time.Local)
+
+		since, ok := parser.timeSinceMapTravel(serverID, lastTravel.Add(10*time.Second))
+		if s == raceServers-1 {
+			if ok {
+				t.Errorf("%s never travelled but has a map travel %v ago", serverID, since)
+			}
+			continue
+		}
+		if !ok || since != 10*time.Second {
+			t.Errorf("%s: expected last travel 10s before, got %v (ok=%v)", serverID, since, ok)
+		}
+	}
+}
+
+// TestMapTravelSuppressionConcurrent checks each server keeps its own 30 second
+// reconnect-suppression window when servers are parsed concurrently
+func TestMapTravelSuppressionConcurrent(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	for s := 0; s < raceServers; s++ {
+		if _, err := database.GetOrCreateServer(ctx, testApp, fmt.Sprintf("race-server-%d", s), "Race Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+	}
+
+	processTravelsConcurrently(t, NewLogParser(testApp, testApp.Logger()))
+
+	for s := 0; s < raceServers; s++ {
+		serverID := fmt.Sprintf("race-server-%d", s)
+		leaves, err := testApp.CountRecords("events", dbx.NewExp("type = 'player_leave' AND server = (SELECT id FROM servers WHERE external_id = {:server})", dbx.Params{"server": serverID}))
+		if err != nil {
+			t.Fatalf("Failed to count leave events: %v", err)
+		}
+
+		want := int64(raceTravels) // Only the disconnects outside the window
+		if s == raceServers-1 {
+			want = 2 * raceTravels // No travel, nothing suppressed
+		}
+		if leaves != want {
+			t.Errorf("%s: expected %d leave events, got %d", serverID, want, leaves)
+		}
+	}
+}
+
+// TestMapTravelReconnectWindow checks disconnects are suppressed up to, but not at, the configured
+// window after a map travel, and that the window is read by the disconnect handling
+func TestMapTravelReconnectWindow(t *testing.T) {
+	ctx := context.Background()
+	travelLine := `[2025.11.15-12.10.00:000][400]LogGameMode: ProcessServerTravel: Oilfield?Scenario=Scenario_Refinery_Push_Insurgents?Game=`
+	travel := time.Date(2025, 11, 15, 12, 10, 0, 0, time.Local)
+
+	windows := []struct {
+		name   string
+		window time.Duration
+		want   time.Duration
+	}{
+		{"default", 0, DefaultMapTravelReconnectWindow},
+		{"fast maps", 10 * time.Second, 10 * time.Second},
+		{"slow-loading maps", 90 * time.Second, 90 * time.Second},
+	}
+	for _, tt := range windows {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewLogParser(nil, slog.Default())
+			parser.eventCreator = nil
+			parser.SetMapTravelReconnectWindow(tt.window)
+
+			if err := parser.ParseAndProcess(ctx, travelLine, "window-server", "test.log"); err != nil {
+				t.Fatalf("Failed to process map travel: %v", err)
+			}
+
+			if _, ok := parser.isMapTravelReconnect("window-server", travel.Add(tt.want-time.Millisecond)); !ok {
+				t.Errorf("Expected a disconnect just inside the %v window to be suppressed", tt.want)
+			}
+			if _, ok := parser.isMapTravelReconnect("window-server", travel.Add(tt.want)); ok {
+				t.Errorf("Expected a disconnect at the end of the %v window to count as a leave", tt.want)
+			}
+			if _, ok := parser.isMapTravelReconnect("window-server", travel.Add(-time.Second)); ok {
+				t.Error("Expected a disconnect before the travel to count as a leave")
+			}
+			if _, ok := parser.isMapTravelReconnect("other-server", travel.Add(time.Second)); ok {
+				t.Error("Expected another server's disconnects not to be suppressed")
+			}
+		})
+	}
+
+	// A reconnect 45s after the travel is only a leave with the default window
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	disconnectLine := `[2025.11.15-12.10.45:000][401]LogEOSAntiCheat: Display: ServerUnregisterClient: UserId (76561198111111111), Result: (EOS_Success)`
+	for _, window := range []time.Duration{DefaultMapTravelReconnectWindow, 60 * time.Second} {
+		serverID := fmt.Sprintf("window-server-%d", int(window.Seconds()))
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverID, "Window Server", "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		parser := NewLogParser(testApp, testApp.Logger())
+		parser.SetMapTravelReconnectWindow(window)
+		for _, line := range []string{travelLine, disconnectLine} {
+			if err := parser.ParseAndProcess(ctx, line, serverID, "test.log"); err != nil {
+				t.Fatalf("Failed to process line: %v", err)
+			}
+		}
+
+		leaves, err := testApp.CountRecords("events", dbx.NewExp("type = 'player_leave' AND server = (SELECT id FROM servers WHERE external_id = {:server})", dbx.Params{"server": serverID}))
+		if err != nil {
+			t.Fatalf("Failed to count leave events: %v", err)
+		}
+		want := int64(1)
+		if window > 45*time.Second {
+			want = 0
+		}
+		if leaves != want {
+			t.Errorf("window %v: expected %d leave events, got %d", window, want, leaves)
+		}
+	}
+}
+
 // TestObjectiveEvents tests objective destroyed and captured events
 func TestObjectiveEvents(t *testing.T) {
 	testApp, err := tests.NewTestApp(t.TempDir())
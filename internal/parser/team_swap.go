@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"time"
+)
+
+// DefaultTeamSwapWindow is how soon after leaving a player's rejoin is treated as a team swap
+// rather than a new session, unless configured otherwise
+const DefaultTeamSwapWindow = 15 * time.Second
+
+// playerSessions tracks a server's recent logins and leaves to recognise team swaps.
+// Switching teams mid-match shows up in the log as a leave followed straight away by a join,
+// and join lines only carry the player's name, so logins map names back to Steam IDs.
+// A player who left and didn't rejoin within the team swap window is forgotten, so the maps only
+// hold the players on the server.
+type playerSessions struct {
+	steamIDs map[string]string    // Player name -> Steam ID from the latest login
+	logins   map[string]time.Time // Steam ID -> log timestamp of the player's latest login
+	leaves   map[string]time.Time // Steam ID -> log timestamp of the player's last leave
+}
+
+// SetTeamSwapWindow sets how soon after leaving a rejoin counts as a team swap.
+// A window of zero or less restores the 15 second default. Must be called before log processing starts.
+func (p *LogParser) SetTeamSwapWindow(window time.Duration) {
+	if window <= 0 {
+		window = DefaultTeamSwapWindow
+	}
+	p.teamSwapWindow = window
+}
+
+// sessionsFor returns a server's session tracking; callers must hold p.sessionsMu
+func (p *LogParser) sessionsFor(serverID string) *playerSessions {
+	sessions, ok := p.sessions[serverID]
+	if !ok {
+		sessions = &playerSessions{
+			steamIDs: make(map[string]string),
+			logins:   make(map[string]time.Time),
+			leaves:   make(map[string]time.Time),
+		}
+		p.sessions[serverID] = sessions
+	}
+	return sessions
+}
+
+// recordLogin remembers which Steam ID a player name logged in with
+func (p *LogParser) recordLogin(serverID, playerName, steamID string, timestamp time.Time) {
+	p.sessionsMu.Lock()
+	defer p.sessionsMu.Unlock()
+
+	sessions := p.sessionsFor(serverID)
+	sessions.steamIDs[playerName] = steamID
+	sessions.logins[steamID] = timestamp
+}
+
+// recordLeave remembers when a player left, for a rejoin to be matched against, and forgets
+// the players whose leave is too old to be matched any more
+func (p *LogParser) recordLeave(serverID, steamID string, timestamp time.Time) {
+	p.sessionsMu.Lock()
+	defer p.sessionsMu.Unlock()
+
+	sessions := p.sessionsFor(serverID)
+	p.pruneSessions(sessions, timestamp)
+	sessions.leaves[steamID] = timestamp
+}
+
+// pruneSessions drops leaves older than the team swap window at now, with the logins and names
+// of the players who haven't logged in again since; callers must hold p.sessionsMu
+func (p *LogParser) pruneSessions(sessions *playerSessions, now time.Time) {
+	for steamID, leftAt := range sessions.leaves {
+		if now.Sub(leftAt) < p.teamSwapWindow {
+			continue
+		}
+		delete(sessions.leaves, steamID)
+
+		if loggedIn, ok := sessions.logins[steamID]; ok && loggedIn.After(leftAt) {
+			continue
+		}
+		delete(sessions.logins, steamID)
+		for name, id := range sessions.steamIDs {
+			if id == steamID {
+				delete(sessions.steamIDs, name)
+			}
+		}
+	}
+}
+
+// resetSessions forgets every player of a server, when it starts a new log file after a restart
+func (p *LogParser) resetSessions(serverID string) {
+	p.sessionsMu.Lock()
+	defer p.sessionsMu.Unlock()
+	delete(p.sessions, serverID)
+}
+
+// isTeamSwapRejoin reports whether a player joining at timestamp left within the team swap
+// window, and how long ago. The leave is consumed so a later join starts a new session.
+func (p *LogParser) isTeamSwapRejoin(serverID, playerName string, timestamp time.Time) (time.Duration, bool) {
+	p.sessionsMu.Lock()
+	defer p.sessionsMu.Unlock()
+
+	sessions := p.sessionsFor(serverID)
+	steamID, ok := sessions.steamIDs[playerName]
+	if !ok {
+		return 0, false
+	}
+	leftAt, ok := sessions.leaves[steamID]
+	if !ok {
+		return 0, false
+	}
+	delete(sessions.leaves, steamID)
+
+	since := timestamp.Sub(leftAt)
+	return since, since >= 0 && since < p.teamSwapWindow
+}
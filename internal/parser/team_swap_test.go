@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestTeamSwapSessionsForgetPlayers(t *testing.T) {
+	p := NewLogParser(nil, slog.Default())
+	start := time.Date(2025, 11, 15, 12, 0, 0, 0, time.UTC)
+
+	p.recordLogin("server-1", "ArmoredBear", "76561198995742987", start)
+	p.recordLogin("server-1", "PolarBear", "76561198995742956", start)
+	p.recordLeave("server-1", "76561198995742987", start.Add(time.Minute))
+
+	// The next leave forgets ArmoredBear, who never came back
+	p.recordLeave("server-1", "76561198995742956", start.Add(5*time.Minute))
+	sessions := p.sessions["server-1"]
+	if _, ok := sessions.steamIDs["ArmoredBear"]; ok {
+		t.Error("ArmoredBear's name is still tracked after leaving")
+	}
+	if len(sessions.logins) != 1 || len(sessions.leaves) != 1 {
+		t.Errorf("sessions hold %d logins and %d leaves, want only PolarBear's", len(sessions.logins), len(sessions.leaves))
+	}
+
+	// A new log file forgets everyone
+	p.resetSessions("server-1")
+	if _, ok := p.sessions["server-1"]; ok {
+		t.Error("sessions kept after resetSessions()")
+	}
+}
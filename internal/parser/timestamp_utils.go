@@ -126,3 +126,67 @@ func UpdateLogTimestamps(lines []string, baseTime time.Time) ([]string, error) {
 
 	return result, nil
 }
+
+// dstResolution describes how a wall-clock log time was mapped to an instant
+type dstResolution int
+
+const (
+	dstNone        dstResolution = iota // Wall clock maps to exactly one instant
+	dstAmbiguous                        // Wall clock occurs twice (fall back) - earlier instant chosen
+	dstNonexistent                      // Wall clock skipped (spring forward) - shifted forward by the gap
+)
+
+func (r dstResolution) String() string {
+	switch r {
+	case dstAmbiguous:
+		return "ambiguous (fall back): using first occurrence"
+	case dstNonexistent:
+		return "nonexistent (spring forward): shifted forward past the gap"
+	default:
+		return "none"
+	}
+}
+
+// dateInLocation builds the instant for a wall-clock time in loc with a deterministic
+// rule for DST transitions, since time.Date leaves the choice unspecified:
+//   - ambiguous times (the repeated hour when clocks fall back) resolve to the earlier instant
+//   - nonexistent times (the skipped hour when clocks spring forward) are interpreted with the
+//     offset in effect before the transition, which lands them just after the gap
+func dateInLocation(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) (time.Time, dstResolution) {
+	wall := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+
+	// Offsets a day either side of the wall clock cover any single DST transition
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	offsets := []int{offsetBefore}
+	if offsetAfter != offsetBefore {
+		offsets = append(offsets, offsetAfter)
+	}
+
+	var matches []time.Time
+	for _, offset := range offsets {
+		candidate := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if sameWallClock(candidate, wall) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc), dstNonexistent
+	case 1:
+		return matches[0], dstNone
+	default:
+		if matches[1].Before(matches[0]) {
+			return matches[1], dstAmbiguous
+		}
+		return matches[0], dstAmbiguous
+	}
+}
+
+// sameWallClock reports whether t shows the same date and clock reading as wall
+func sameWallClock(t, wall time.Time) bool {
+	return t.Year() == wall.Year() && t.Month() == wall.Month() && t.Day() == wall.Day() &&
+		t.Hour() == wall.Hour() && t.Minute() == wall.Minute() && t.Second() == wall.Second()
+}
@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestParseTimestamp_Location(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	ts := "2025.11.10-20.58.50:166"
+
+	utcTime, _, err := parseTimestamp(ts, time.UTC)
+	if err != nil {
+		t.Fatalf("parseTimestamp(UTC) error = %v", err)
+	}
+	denverTime, _, err := parseTimestamp(ts, denver)
+	if err != nil {
+		t.Fatalf("parseTimestamp(Denver) error = %v", err)
+	}
+
+	want := time.Date(2025, 11, 10, 20, 58, 50, 166000000, time.UTC)
+	if !utcTime.Equal(want) {
+		t.Errorf("UTC parse = %v, want %v", utcTime, want)
+	}
+
+	// Denver is UTC-7 in November (MST), so the same wall clock is 7 hours later in UTC
+	if diff := denverTime.Sub(utcTime); diff != 7*time.Hour {
+		t.Errorf("Denver - UTC = %v, want 7h", diff)
+	}
+	if got := denverTime.UTC().Format("2006-01-02 15:04:05"); got != "2025-11-11 03:58:50" {
+		t.Errorf("Denver parse in UTC = %s, want 2025-11-11 03:58:50", got)
+	}
+}
+
+func TestParseAndProcess_ServerLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	logLine := `[2025.10.04-21.18.15:445][  0]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Hideout_Checkpoint_Security?MaxPlayers=10?Game=CheckpointHardcore?Lighting=Day`
+
+	parseMapLoadTime := func(serverID string, loc *time.Location) time.Time {
+		t.Helper()
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverID, serverID, "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		parser := NewLogParser(testApp, testApp.Logger())
+		parser.SetServerLocation(serverID, loc)
+		if err := parser.ParseAndProcess(ctx, logLine, serverID, "test.log"); err != nil {
+			t.Fatalf("failed to process log line: %v", err)
+		}
+
+		events, err := testApp.FindRecordsByFilter("events", "type = 'map_load'", "-created", 1, 0)
+		if err != nil || len(events) == 0 {
+			t.Fatalf("failed to find map_load event: %v", err)
+		}
+
+		var data struct {
+			Timestamp time.Time `json:"timestamp"`
+		}
+		if err := json.Unmarshal([]byte(events[0].GetString("data")), &data); err != nil {
+			t.Fatalf("failed to decode event data: %v", err)
+		}
+		return data.Timestamp
+	}
+
+	utcTime := parseMapLoadTime("tz-server-utc", time.UTC)
+	tokyoTime := parseMapLoadTime("tz-server-tokyo", tokyo)
+
+	// Tokyo is UTC+9, so the same wall clock happened 9 hours earlier
+	if diff := utcTime.Sub(tokyoTime); diff != 9*time.Hour {
+		t.Errorf("UTC - Tokyo = %v, want 9h (utc=%v tokyo=%v)", diff, utcTime.UTC(), tokyoTime.UTC())
+	}
+}
+
+func TestParseAndProcess_LogFileOpenServerLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	if _, err := database.GetOrCreateServer(ctx, testApp, "tz-server-tokyo", "tz-server-tokyo", "test/path"); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	parser := NewLogParser(testApp, testApp.Logger())
+	parser.SetServerLocation("tz-server-tokyo", tokyo)
+	if err := parser.ParseAndProcess(ctx, "Log file open, 11/10/25 20:58:31", "tz-server-tokyo", "test.log"); err != nil {
+		t.Fatalf("failed to process log line: %v", err)
+	}
+
+	events, err := testApp.FindRecordsByFilter("events", "type = 'log_file_created'", "-created", 1, 0)
+	if err != nil || len(events) == 0 {
+		t.Fatalf("failed to find log_file_created event: %v", err)
+	}
+
+	var data struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(events[0].GetString("data")), &data); err != nil {
+		t.Fatalf("failed to decode event data: %v", err)
+	}
+
+	// The wall clock is in the server's timezone, like the bracketed timestamps
+	if want := time.Date(2025, 11, 10, 11, 58, 31, 0, time.UTC); !data.Timestamp.Equal(want) {
+		t.Errorf("log file open timestamp = %v, want %v", data.Timestamp.UTC(), want)
+	}
+}
+
+func TestParseTimestamp_DSTTransitions(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skipf("timezone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		ts             string
+		wantUTC        string
+		wantResolution dstResolution
+	}{
+		{
+			name:           "regular time",
+			ts:             "2025.11.10-20.58.50:166",
+			wantUTC:        "2025-11-11T03:58:50.166Z",
+			wantResolution: dstNone,
+		},
+		{
+			// 01:30 happens twice on 2025-11-02 (MDT then MST); first occurrence is MDT (UTC-6)
+			name:           "fall back ambiguous hour",
+			ts:             "2025.11.02-01.30.00:250",
+			wantUTC:        "2025-11-02T07:30:00.25Z",
+			wantResolution: dstAmbiguous,
+		},
+		{
+			// 02:30 never happens on 2025-03-09; read with the pre-transition MST offset (UTC-7)
+			name:           "spring forward nonexistent hour",
+			ts:             "2025.03.09-02.30.00:000",
+			wantUTC:        "2025-03-09T09:30:00Z",
+			wantResolution: dstNonexistent,
+		},
+		{
+			name:           "just after spring forward",
+			ts:             "2025.03.09-03.00.00:000",
+			wantUTC:        "2025-03-09T09:00:00Z",
+			wantResolution: dstNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, resolution, err := parseTimestamp(tt.ts, denver)
+			if err != nil {
+				t.Fatalf("parseTimestamp() error = %v", err)
+			}
+			if utc := got.UTC().Format(time.RFC3339Nano); utc != tt.wantUTC {
+				t.Errorf("parseTimestamp() = %s, want %s", utc, tt.wantUTC)
+			}
+			if resolution != tt.wantResolution {
+				t.Errorf("resolution = %v, want %v", resolution, tt.wantResolution)
+			}
+
+			// Parsing must be deterministic across repeated calls
+			again, _, _ := parseTimestamp(tt.ts, denver)
+			if !again.Equal(got) {
+				t.Errorf("parseTimestamp() not deterministic: %v vs %v", got, again)
+			}
+
+			// Catch-up reads timestamps through the exported wrapper, with the same rule
+			if exported, err := ParseLogTimestamp(tt.ts, denver); err != nil || !exported.Equal(got) {
+				t.Errorf("ParseLogTimestamp() = %v, %v, want %v", exported, err, got)
+			}
+		})
+	}
+}
+
+func TestExtractLogFileCreationTime_PinsCentury(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantUTC string
+	}{
+		{"current year", "Log file open, 11/10/25 20:58:31", "2025-11-10T20:58:31Z"},
+		{"two-digit year above 68", "Log file open, 01/02/75 08:00:00", "2075-01-02T08:00:00Z"},
+		{"inside fall back hour stays wall clock", "Log file open, 11/02/25 01:30:00", "2025-11-02T01:30:00Z"},
+	}
+
+	parser := NewLogParser(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "test.log")
+			if err := os.WriteFile(logFile, []byte(tt.line+"\n"), 0644); err != nil {
+				t.Fatalf("failed to write log file: %v", err)
+			}
+
+			got, err := parser.ExtractLogFileCreationTime(logFile)
+			if err != nil {
+				t.Fatalf("ExtractLogFileCreationTime() error = %v", err)
+			}
+			if s := got.Format(time.RFC3339); s != tt.wantUTC {
+				t.Errorf("ExtractLogFileCreationTime() = %s, want %s", s, tt.wantUTC)
+			}
+		})
+	}
+}
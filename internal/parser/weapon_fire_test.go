@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+func TestWeaponFireParsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		logLine string
+		want    bool
+	}{
+		{
+			name:    "shots with hits",
+			logLine: "[2025.11.10-21.05.12:301][120]LogGameplayEvents: Verbose: ArmoredBear[76561198995742987, team 0] fired 30 shots with BP_Firearm_M4A1_C_2147480587 (7 hits)",
+			want:    true,
+		},
+		{
+			name:    "single shot and hit",
+			logLine: "[2025.11.10-21.05.14:002][125]LogGameplayEvents: Verbose: Kestrel[76561198995742999, team 1] fired 1 shot with BP_Firearm_Mosin_C_2147480100 (1 hit)",
+			want:    true,
+		},
+		{
+			name:    "kill line at default verbosity",
+			logLine: "[2025.11.10-21.05.12:301][120]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M4A1_C_2147480587",
+			want:    false,
+		},
+		{
+			name:    "fire line without hit count",
+			logLine: "[2025.11.10-21.05.12:301][120]LogGameplayEvents: Verbose: ArmoredBear[76561198995742987, team 0] fired 30 shots with BP_Firearm_M4A1_C_2147480587",
+			want:    false,
+		},
+	}
+
+	parser := &LogParser{
+		patterns: NewLogPatterns(),
+		logger:   slog.Default(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := parser.patterns.WeaponFire.FindStringSubmatch(tt.logLine)
+			if got := len(matches) >= 6; got != tt.want {
+				t.Errorf("WeaponFire pattern match = %v, want %v (matches: %v)", got, tt.want, matches)
+			}
+		})
+	}
+}
+
+func TestWeaponFireEvents(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	fireLines := []string{
+		"[2025.11.10-21.05.12:301][120]LogGameplayEvents: Verbose: ArmoredBear[76561198995742987, team 0] fired 30 shots with BP_Firearm_M4A1_C_2147480587 (7 hits)",
+		"[2025.11.10-21.05.13:450][122]LogGameplayEvents: Verbose: Rifleman[INVALID, team 1] fired 12 shots with BP_Firearm_AKM_C_2147480001 (2 hits)",
+		"[2025.11.10-21.05.14:002][125]LogGameplayEvents: Verbose: Kestrel[76561198995742999, team 1] fired 1 shot with BP_Firearm_Mosin_C_2147480100 (1 hit)",
+	}
+	// A log at default verbosity: kills but no shots fired
+	defaultLines := []string{
+		"[2025.11.10-21.05.12:301][120]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M4A1_C_2147480587",
+		"[2025.11.10-21.06.00:000][200]LogChat: Display: ArmoredBear(76561198995742987) Global Chat: nice",
+	}
+
+	processLines := func(t *testing.T, serverID string, track bool, lines []string) []string {
+		t.Helper()
+		if _, err := database.GetOrCreateServer(ctx, testApp, serverID, serverID, "test/path"); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		parser := NewLogParser(testApp, testApp.Logger())
+		parser.SetTrackWeaponFire(track)
+		for _, line := range lines {
+			if err := parser.ParseAndProcess(ctx, line, serverID, "test.log"); err != nil {
+				t.Fatalf("failed to process log line: %v", err)
+			}
+		}
+
+		server, err := testApp.FindFirstRecordByFilter("servers", "external_id = {:id}", map[string]any{"id": serverID})
+		if err != nil {
+			t.Fatalf("failed to find server: %v", err)
+		}
+		records, err := testApp.FindRecordsByFilter("events", "type = {:type} && server = {:server}", "created", 0, 0,
+			map[string]any{"type": events.TypeWeaponFire, "server": server.Id})
+		if err != nil {
+			t.Fatalf("failed to find weapon_fire events: %v", err)
+		}
+		data := make([]string, len(records))
+		for i, record := range records {
+			data[i] = record.GetString("data")
+		}
+		return data
+	}
+
+	t.Run("player shots when enabled", func(t *testing.T) {
+		fired := processLines(t, "test-server-fire-enabled", true, fireLines)
+		if len(fired) != 2 {
+			t.Fatalf("expected 2 weapon_fire events (bots skipped), got %d", len(fired))
+		}
+
+		want := []events.WeaponFireData{
+			{SteamID: "76561198995742987", PlayerName: "ArmoredBear", Team: 0, Weapon: "BP_Firearm_M4A1_C_2147480587", Shots: 30, Hits: 7},
+			{SteamID: "76561198995742999", PlayerName: "Kestrel", Team: 1, Weapon: "BP_Firearm_Mosin_C_2147480100", Shots: 1, Hits: 1},
+		}
+		for i, w := range want {
+			var data events.WeaponFireData
+			if err := json.Unmarshal([]byte(fired[i]), &data); err != nil {
+				t.Fatalf("failed to decode weapon_fire data: %v", err)
+			}
+			if data.SteamID != w.SteamID || data.PlayerName != w.PlayerName || data.Team != w.Team ||
+				data.Weapon != w.Weapon || data.Shots != w.Shots || data.Hits != w.Hits {
+				t.Errorf("event %d = %+v, want %+v", i, data, w)
+			}
+		}
+	})
+
+	t.Run("nothing emitted when disabled", func(t *testing.T) {
+		if fired := processLines(t, "test-server-fire-disabled", false, fireLines); len(fired) != 0 {
+			t.Errorf("expected no weapon_fire events when disabled, got %d", len(fired))
+		}
+	})
+
+	t.Run("no-op without verbose lines", func(t *testing.T) {
+		if fired := processLines(t, "test-server-fire-default-log", true, defaultLines); len(fired) != 0 {
+			t.Errorf("expected no weapon_fire events from a default log, got %d", len(fired))
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package rcon
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PlayerEntry is a player row from a listplayers response
+type PlayerEntry struct {
+	Name    string
+	NetID   string // Platform ID as reported, e.g. SteamNWI:76561198995742987 or EOS:...
+	SteamID string // Steam ID from NetID, empty for other platforms
+	Ping    int    // -1 when the response has no ping column
+	Score   int32
+	Team    string // Empty when the response has no team column
+}
+
+// defaultListPlayersColumns is the stock Insurgency: Sandstorm layout, used when no header is found
+var defaultListPlayersColumns = []string{"id", "name", "netid", "ip", "score"}
+
+// placeholderNames are the bot/role rows listplayers reports next to real players
+var placeholderNames = map[string]bool{
+	"Observer":  true,
+	"Commander": true,
+	"Marksman":  true,
+}
+
+// ParseListPlayers parses a listplayers response into the real players on the server.
+//
+// The stock format is a header row, a ===== separator and then every player's
+// pipe-separated fields, often all on a single line:
+//
+//	ID | Name | NetID | IP | Score |
+//	===============================
+//	0 | | None:INVALID | | 0 | 256 | ArmoredBear | SteamNWI:765... | 127.0.0.1 | 150 |
+//
+// Columns are taken from the header, so responses with extra columns such as Ping or
+// Team are parsed too. Bot and placeholder rows (ID 0, INVALID net IDs) are skipped.
+func ParseListPlayers(response string) []PlayerEntry {
+	columns := defaultListPlayersColumns
+	var fields []string
+
+	for _, line := range strings.FieldsFunc(response, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if strings.TrimSpace(parts[len(parts)-1]) == "" {
+			parts = parts[:len(parts)-1]
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		if isListPlayersHeader(parts) {
+			columns = make([]string, len(parts))
+			for i, part := range parts {
+				columns[i] = strings.ToLower(part)
+			}
+			continue
+		}
+
+		fields = append(fields, parts...)
+	}
+
+	index := func(names ...string) int {
+		for i, column := range columns {
+			for _, name := range names {
+				if column == name {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	idCol := index("id")
+	nameCol := index("name")
+	netIDCol := index("netid", "uniqueid", "steamid")
+	scoreCol := index("score")
+	pingCol := index("ping")
+	teamCol := index("team", "teamid")
+
+	if nameCol < 0 || netIDCol < 0 {
+		return []PlayerEntry{}
+	}
+
+	players := []PlayerEntry{}
+	for i := 0; i+len(columns) <= len(fields); {
+		row := fields[i : i+len(columns)]
+
+		// A row always starts with a numeric ID; skip stray fields until one does
+		if idCol >= 0 {
+			if _, err := strconv.Atoi(row[idCol]); err != nil {
+				i++
+				continue
+			}
+		}
+		i += len(columns)
+
+		if idCol >= 0 && row[idCol] == "0" {
+			continue
+		}
+
+		name := row[nameCol]
+		netID := row[netIDCol]
+		if name == "" || placeholderNames[name] || isNumber(name) {
+			continue
+		}
+		if netID == "" || netID == "0" || strings.Contains(netID, "INVALID") {
+			continue
+		}
+
+		player := PlayerEntry{
+			Name:    name,
+			NetID:   netID,
+			SteamID: steamIDFromNetID(netID),
+			Ping:    -1,
+		}
+		if scoreCol >= 0 {
+			if score, err := strconv.ParseInt(row[scoreCol], 10, 32); err == nil {
+				player.Score = int32(score)
+			}
+		}
+		if pingCol >= 0 {
+			if ping, err := strconv.Atoi(row[pingCol]); err == nil {
+				player.Ping = ping
+			}
+		}
+		if teamCol >= 0 {
+			player.Team = row[teamCol]
+		}
+
+		players = append(players, player)
+	}
+
+	return players
+}
+
+// isListPlayersHeader reports whether the fields are the column header row
+func isListPlayersHeader(parts []string) bool {
+	hasID, hasName := false, false
+	for _, part := range parts {
+		switch strings.ToLower(part) {
+		case "id":
+			hasID = true
+		case "name":
+			hasName = true
+		}
+	}
+	return hasID && hasName
+}
+
+// steamIDFromNetID extracts the 17 digit Steam ID from a NetID such as SteamNWI:76561198995742987
+func steamIDFromNetID(netID string) string {
+	platform, id, ok := strings.Cut(netID, ":")
+	if !ok || !strings.HasPrefix(strings.ToLower(platform), "steam") || len(id) != 17 || !isNumber(id) {
+		return ""
+	}
+	return id
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 64)
+	return err == nil
+}
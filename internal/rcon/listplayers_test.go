@@ -0,0 +1,85 @@
+package rcon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseListPlayers(t *testing.T) {
+	// Real response format from RCON listplayers with tabs
+	response := "ID\t | Name\t\t\t\t | NetID\t\t\t | IP\t\t\t | Score\t\t |\n" +
+		"===============================================================================\n" +
+		"0\t | \t\t\t\t | None:INVALID\t | \t | 0\t\t | " +
+		"256\t | ArmoredBear\t | SteamNWI:76561198995742987\t | 127.0.0.1\t | 150\t\t | " +
+		"0\t | Observer\t\t | None:INVALID\t | \t | 10\t\t | " +
+		"0\t | Commander\t | None:INVALID\t | \t | 0\t\t | " +
+		"0\t | Marksman\t\t | None:INVALID\t | \t | 0\t\t | "
+
+	players := ParseListPlayers(response)
+
+	want := []PlayerEntry{{
+		Name:    "ArmoredBear",
+		NetID:   "SteamNWI:76561198995742987",
+		SteamID: "76561198995742987",
+		Ping:    -1,
+		Score:   150,
+	}}
+	if !reflect.DeepEqual(players, want) {
+		t.Errorf("unexpected players\n got: %+v\nwant: %+v", players, want)
+	}
+}
+
+func TestParseListPlayers_SkipsInvalidEntries(t *testing.T) {
+	// Test that invalid entries are properly filtered out
+	response := "ID\t | Name\t\t\t\t | NetID\t\t\t | IP\t\t\t | Score\t\t |\n" +
+		"===============================================================================\n" +
+		"0\t | Observer\t\t | None:INVALID\t | \t | 0\t\t | " +
+		"0\t | Commander\t | None:INVALID\t | \t | 0\t\t | " +
+		"0\t | Marksman\t\t | None:INVALID\t | \t | 0\t\t | "
+
+	if players := ParseListPlayers(response); len(players) != 0 {
+		t.Errorf("Expected 0 players, got %+v", players)
+	}
+}
+
+func TestParseListPlayers_Formats(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		want     []PlayerEntry
+	}{
+		{
+			name: "one row per line with ping and team columns",
+			response: "ID\t | Name\t\t | NetID\t\t\t | IP\t\t | Score\t | Ping\t | Team\t |\r\n" +
+				"=========================================================================\r\n" +
+				"256\t | ArmoredBear\t | SteamNWI:76561198995742987\t | 10.0.0.2\t | 150\t | 48\t | Security\t |\r\n" +
+				"257\t | Kestrel\t | EOS:0002a4f1c9d84e8b9b0d6f3e2a1c7b5d\t | 10.0.0.3\t | 35\t | 112\t | Insurgents\t |\r\n" +
+				"0\t | Marksman\t | None:INVALID\t | \t | 0\t | 0\t | Insurgents\t |\r\n",
+			want: []PlayerEntry{
+				{Name: "ArmoredBear", NetID: "SteamNWI:76561198995742987", SteamID: "76561198995742987", Ping: 48, Score: 150, Team: "Security"},
+				{Name: "Kestrel", NetID: "EOS:0002a4f1c9d84e8b9b0d6f3e2a1c7b5d", Ping: 112, Score: 35, Team: "Insurgents"},
+			},
+		},
+		{
+			name: "no header and a stray field before the first row",
+			response: "Players: | 0\t | \t | None:INVALID\t | \t | 0\t | " +
+				"258\t | Two Words\t | SteamNWI:76561198000000001\t | 10.0.0.4\t | -20\t | ",
+			want: []PlayerEntry{
+				{Name: "Two Words", NetID: "SteamNWI:76561198000000001", SteamID: "76561198000000001", Ping: -1, Score: -20},
+			},
+		},
+		{
+			name:     "empty server",
+			response: "ID\t | Name\t | NetID\t | IP\t | Score\t |\n=============\n",
+			want:     []PlayerEntry{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseListPlayers(tt.response); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected players\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package rcon
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net"
@@ -8,12 +9,13 @@ import (
 	"time"
 )
 
-// ClientPool manages RCON connections to multiple servers
+// ClientPool manages RCON connections to multiple servers.
+// Each server gets at most one authenticated connection; concurrent commands to the same server
+// take turns on it so their packets never interleave.
 type ClientPool struct {
-	clients map[string]*RconClient
-	configs map[string]*ServerConfig
+	servers map[string]*pooledServer
 	logger  *slog.Logger
-	mu      sync.RWMutex
+	mu      sync.RWMutex // Guards servers and the connection state of each entry
 }
 
 // ServerConfig contains the configuration for an RCON server
@@ -21,13 +23,26 @@ type ServerConfig struct {
 	Address  string
 	Password string
 	Timeout  time.Duration
+
+	MaxResponseBytes int // See ClientConfig.MaxResponseBytes; zero uses DefaultMaxResponseBytes
+}
+
+// pooledServer is a server's configuration and its connection state
+type pooledServer struct {
+	config *ServerConfig
+
+	cmdMu sync.Mutex // Held while connecting or running a command
+
+	client     *RconClient // nil until connected, and again after the connection fails
+	lastUsed   time.Time
+	errorCount int
+	lastError  string
 }
 
 // NewClientPool creates a new RCON client pool
 func NewClientPool(logger *slog.Logger) *ClientPool {
 	return &ClientPool{
-		clients: make(map[string]*RconClient),
-		configs: make(map[string]*ServerConfig),
+		servers: make(map[string]*pooledServer),
 		logger:  logger,
 	}
 }
@@ -41,7 +56,11 @@ func (p *ClientPool) AddServer(serverID string, config *ServerConfig) {
 		config.Timeout = 5 * time.Second
 	}
 
-	p.configs[serverID] = config
+	if server, exists := p.servers[serverID]; exists {
+		server.config = config
+		return
+	}
+	p.servers[serverID] = &pooledServer{config: config}
 }
 
 // RemoveServer removes a server from the pool and closes its connection
@@ -49,64 +68,84 @@ func (p *ClientPool) RemoveServer(serverID string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if client, exists := p.clients[serverID]; exists {
-		client.Conn.Close()
-		delete(p.clients, serverID)
+	if server, exists := p.servers[serverID]; exists && server.client != nil {
+		server.client.Conn.Close()
 	}
 
-	delete(p.configs, serverID)
+	delete(p.servers, serverID)
 }
 
-// GetClient returns an RCON client for the specified server, creating it if needed
-func (p *ClientPool) GetClient(serverID string) (*RconClient, error) {
+// server returns the pool entry for a configured server
+func (p *ClientPool) server(serverID string) (*pooledServer, error) {
 	p.mu.RLock()
-	// Return existing client if available
-	if client, exists := p.clients[serverID]; exists {
-		p.mu.RUnlock()
-		return client, nil
-	}
+	defer p.mu.RUnlock()
 
-	// Get server config (hold minimal lock)
-	config, exists := p.configs[serverID]
+	server, exists := p.servers[serverID]
 	if !exists {
 		if p.logger != nil {
 			p.logger.Error("Server not configured in RCON pool",
-				"server", serverID,
+				"server_id", serverID,
 				"available_servers", fmt.Sprintf("%v", p.listConfiguredServersLocked()))
 		}
-		p.mu.RUnlock()
 		return nil, fmt.Errorf("no configuration found for server: %s", serverID)
 	}
+	return server, nil
+}
+
+// GetClient returns an RCON client for the specified server, creating it if needed.
+// Commands sent on the returned client directly are not serialized with SendCommand; prefer SendCommand.
+func (p *ClientPool) GetClient(serverID string) (*RconClient, error) {
+	server, err := p.server(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	server.cmdMu.Lock()
+	defer server.cmdMu.Unlock()
 
+	return p.connect(serverID, server)
+}
+
+// connect returns the server's connection, dialing and authenticating a new one if there is none.
+// Must be called with server.cmdMu held, which keeps two callers from connecting at once.
+func (p *ClientPool) connect(serverID string, server *pooledServer) (*RconClient, error) {
+	p.mu.RLock()
+	client := server.client
 	// Copy config to avoid holding lock during network I/O
-	configCopy := *config
+	configCopy := *server.config
 	p.mu.RUnlock()
 
-	// Create new client outside of lock (allows other goroutines to access pool)
+	if client != nil {
+		return client, nil
+	}
+
 	client, err := p.createClient(serverID, &configCopy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create RCON client for %s: %w", serverID, err)
+		err = fmt.Errorf("failed to create RCON client for %s: %w", serverID, err)
+		p.recordError(server, err)
+		return nil, err
 	}
 
-	// Acquire lock to store client
 	p.mu.Lock()
-	// Double-check another goroutine didn't create it while we were creating ours
-	if existing, exists := p.clients[serverID]; exists {
-		p.mu.Unlock()
-		client.Conn.Close() // Close our redundant client
-		return existing, nil
-	}
-
-	p.clients[serverID] = client
+	server.client = client
 	p.mu.Unlock()
 
 	if p.logger != nil {
-		p.logger.Info("Created RCON client", "server", serverID, "address", configCopy.Address)
+		p.logger.Info("Created RCON client", "server_id", serverID, "address", configCopy.Address)
 	}
 
 	return client, nil
 }
 
+// recordError counts a failed connection attempt or command against a server
+func (p *ClientPool) recordError(server *pooledServer, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	server.errorCount++
+	server.lastError = err.Error()
+}
+
 // createClient creates and authenticates a new RCON client
 func (p *ClientPool) createClient(serverID string, config *ServerConfig) (*RconClient, error) {
 	conn, err := net.DialTimeout("tcp", config.Address, config.Timeout)
@@ -118,34 +157,57 @@ func (p *ClientPool) createClient(serverID string, config *ServerConfig) (*RconC
 	if p.logger != nil {
 		rconConfig.Logger = p.logger
 	}
+	if config.MaxResponseBytes > 0 {
+		rconConfig.MaxResponseBytes = config.MaxResponseBytes
+	}
 
 	client := NewRconClient(conn, rconConfig)
 
-	if !client.Auth(config.Password) {
+	if err := client.AuthContext(context.Background(), config.Password); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("authentication failed")
+		return nil, err
 	}
 
 	return client, nil
 }
 
-// SendCommand sends an RCON command to a specific server
+// SendCommand sends an RCON command to a specific server.
+// Commands to the same server run one at a time. A command that fails closes the connection,
+// and the next command reconnects; failed commands are not retried since they may have run.
 func (p *ClientPool) SendCommand(serverID string, command string) (string, error) {
-	client, err := p.GetClient(serverID)
+	server, err := p.server(serverID)
+	if err != nil {
+		return "", err
+	}
+
+	server.cmdMu.Lock()
+	defer server.cmdMu.Unlock()
+
+	client, err := p.connect(serverID, server)
 	if err != nil {
 		return "", err
 	}
 
 	response, err := client.Send(command)
+
+	p.mu.Lock()
+	server.lastUsed = time.Now()
 	if err != nil {
-		// If command fails, remove the client so it gets recreated on next attempt
-		p.mu.Lock()
-		delete(p.clients, serverID)
-		p.mu.Unlock()
+		server.errorCount++
+		server.lastError = err.Error()
+		// Drop the connection so it gets recreated on the next attempt
+		if server.client == client {
+			server.client = nil
+		}
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		client.Conn.Close()
 
 		if p.logger != nil {
 			p.logger.Error("RCON command failed, removed client from pool",
-				"server", serverID,
+				"server_id", serverID,
 				"command", command,
 				"error", err)
 		}
@@ -156,19 +218,68 @@ func (p *ClientPool) SendCommand(serverID string, command string) (string, error
 	return response, nil
 }
 
+// BroadcastCommand sends a command to every configured server concurrently
+// Each server gets its own timeout so one slow or unreachable server can't block the others
+// Returns the result per server ID (nil error on success)
+func (p *ClientPool) BroadcastCommand(command string) map[string]error {
+	p.mu.RLock()
+	timeouts := make(map[string]time.Duration, len(p.servers))
+	for serverID, server := range p.servers {
+		// Allow time to connect and to read the response
+		timeouts[serverID] = 2 * server.config.Timeout
+	}
+	p.mu.RUnlock()
+
+	type result struct {
+		serverID string
+		err      error
+	}
+
+	results := make(chan result, len(timeouts))
+	for serverID, timeout := range timeouts {
+		go func(serverID string, timeout time.Duration) {
+			done := make(chan error, 1)
+			go func() {
+				_, err := p.SendCommand(serverID, command)
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				results <- result{serverID: serverID, err: err}
+			case <-time.After(timeout):
+				results <- result{serverID: serverID, err: fmt.Errorf("timed out after %s", timeout)}
+			}
+		}(serverID, timeout)
+	}
+
+	errs := make(map[string]error, len(timeouts))
+	for range timeouts {
+		r := <-results
+		errs[r.serverID] = r.err
+		if r.err != nil && p.logger != nil {
+			p.logger.Warn("RCON broadcast failed for server", "server", r.serverID, "command", command, "error", r.err)
+		}
+	}
+
+	return errs
+}
+
 // CloseAll closes all RCON connections in the pool
 func (p *ClientPool) CloseAll() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for serverID, client := range p.clients {
-		client.Conn.Close()
+	for serverID, server := range p.servers {
+		if server.client == nil {
+			continue
+		}
+		server.client.Conn.Close()
+		server.client = nil
 		if p.logger != nil {
-			p.logger.Info("Closed RCON client", "server", serverID)
+			p.logger.Info("Closed RCON client", "server_id", serverID)
 		}
 	}
-
-	p.clients = make(map[string]*RconClient)
 }
 
 // ListServers returns all server IDs in the pool
@@ -176,18 +287,13 @@ func (p *ClientPool) ListServers() []string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	servers := make([]string, 0, len(p.configs))
-	for serverID := range p.configs {
-		servers = append(servers, serverID)
-	}
-
-	return servers
+	return p.listConfiguredServersLocked()
 }
 
 // listConfiguredServersLocked returns all configured server IDs (must be called with lock held)
 func (p *ClientPool) listConfiguredServersLocked() []string {
-	servers := make([]string, 0, len(p.configs))
-	for serverID := range p.configs {
+	servers := make([]string, 0, len(p.servers))
+	for serverID := range p.servers {
 		servers = append(servers, serverID)
 	}
 	return servers
@@ -198,6 +304,46 @@ func (p *ClientPool) IsConnected(serverID string) bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	_, exists := p.clients[serverID]
-	return exists
+	server, exists := p.servers[serverID]
+	return exists && server.client != nil
+}
+
+// HealthCounts counts the servers that are connected or have not failed yet as healthy, and
+// those whose last connection or command failed without a reconnect since as unhealthy
+func (p *ClientPool) HealthCounts() (healthy, unhealthy int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, server := range p.servers {
+		if server.client == nil && server.lastError != "" {
+			unhealthy++
+		} else {
+			healthy++
+		}
+	}
+	return healthy, unhealthy
+}
+
+// Status reports each server's connection state for the health endpoint, keyed by server ID:
+// whether it is connected, when it last ran a command, and how many connections or commands failed
+func (p *ClientPool) Status() map[string]any {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := make(map[string]any, len(p.servers))
+	for serverID, server := range p.servers {
+		var lastUsed *time.Time
+		if !server.lastUsed.IsZero() {
+			t := server.lastUsed
+			lastUsed = &t
+		}
+		status[serverID] = map[string]any{
+			"address":     server.config.Address,
+			"connected":   server.client != nil,
+			"last_used":   lastUsed,
+			"error_count": server.errorCount,
+			"last_error":  server.lastError,
+		}
+	}
+	return status
 }
@@ -0,0 +1,347 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServerMode controls how a fake RCON server answers
+type fakeServerMode int
+
+const (
+	fakeServerOK       fakeServerMode = iota // Authenticates and answers commands
+	fakeServerBadAuth                        // Rejects authentication
+	fakeServerSilent                         // Accepts connections but never answers
+	fakeServerLarge                          // Answers commands with fakeLargeResponse, split over packets
+)
+
+// fakeLargeResponse is the response fakeServerLarge sends, in 1000 byte packets
+var fakeLargeResponse = strings.Repeat("x", 4000)
+
+// startFakeRconServer starts a minimal Sandstorm-style RCON server and returns its address.
+// Every command received is sent on the commands channel.
+func startFakeRconServer(t *testing.T, mode fakeServerMode, commands chan<- string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeRcon(conn, mode, commands)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveFakeRcon(conn net.Conn, mode fakeServerMode, commands chan<- string) {
+	defer conn.Close()
+
+	for {
+		sizeBytes := make([]byte, 4)
+		if _, err := io.ReadFull(conn, sizeBytes); err != nil {
+			return
+		}
+		packet := make([]byte, binary.LittleEndian.Uint32(sizeBytes))
+		if _, err := io.ReadFull(conn, packet); err != nil {
+			return
+		}
+
+		id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+		packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+		payload := strings.TrimRight(string(packet[8:]), "\x00")
+
+		if mode == fakeServerSilent {
+			continue
+		}
+
+		switch packetType {
+		case 3: // Auth
+			if mode == fakeServerBadAuth {
+				conn.Write(fakeServerPacket(-1, 2, ""))
+				return
+			}
+			conn.Write(fakeServerPacket(id, 2, ""))
+		case 2: // Command
+			commands <- payload
+			if mode == fakeServerLarge {
+				for i := 0; i < len(fakeLargeResponse); i += 1000 {
+					conn.Write(fakeServerPacket(id, 0, fakeLargeResponse[i:i+1000]))
+				}
+				continue
+			}
+			conn.Write(fakeServerPacket(id, 0, "ok: "+payload))
+		case 0: // Empty packet confirming the response was fully received
+			conn.Write(fakeServerPacket(id, 0, ""))
+		}
+	}
+}
+
+// fakeServerPacket builds a server packet (payload followed by two null terminators)
+func fakeServerPacket(id int32, packetType int32, payload string) []byte {
+	body := append([]byte(payload), 0x00, 0x00)
+	buffer := new(bytes.Buffer)
+	binary.Write(buffer, binary.LittleEndian, int32(8+len(body)))
+	binary.Write(buffer, binary.LittleEndian, id)
+	binary.Write(buffer, binary.LittleEndian, packetType)
+	buffer.Write(body)
+	return buffer.Bytes()
+}
+
+func TestClientPool_BroadcastCommand(t *testing.T) {
+	commands := make(chan string, 10)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewClientPool(logger)
+	defer pool.CloseAll()
+
+	pool.AddServer("server-ok", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerOK, commands),
+		Password: "secret",
+		Timeout:  time.Second,
+	})
+	pool.AddServer("server-bad-auth", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerBadAuth, commands),
+		Password: "wrong",
+		Timeout:  time.Second,
+	})
+
+	results := pool.BroadcastCommand("say Server restart in 5 minutes")
+
+	if len(results) != 2 {
+		t.Fatalf("expected results for 2 servers, got %d: %v", len(results), results)
+	}
+	if err := results["server-ok"]; err != nil {
+		t.Errorf("server-ok: unexpected error: %v", err)
+	}
+	if err := results["server-bad-auth"]; err == nil {
+		t.Error("server-bad-auth: expected an error")
+	}
+
+	select {
+	case got := <-commands:
+		if got != "say Server restart in 5 minutes" {
+			t.Errorf("server received %q", got)
+		}
+	default:
+		t.Error("expected the healthy server to receive the command")
+	}
+}
+
+func TestClientPool_BroadcastCommand_Timeout(t *testing.T) {
+	commands := make(chan string, 10)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewClientPool(logger)
+	defer pool.CloseAll()
+
+	pool.AddServer("server-ok", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerOK, commands),
+		Password: "secret",
+		Timeout:  5 * time.Second,
+	})
+	pool.AddServer("server-silent", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerSilent, commands),
+		Password: "secret",
+		Timeout:  100 * time.Millisecond,
+	})
+
+	start := time.Now()
+	results := pool.BroadcastCommand("listplayers")
+	elapsed := time.Since(start)
+
+	if err := results["server-ok"]; err != nil {
+		t.Errorf("server-ok: unexpected error: %v", err)
+	}
+	if err := results["server-silent"]; err == nil {
+		t.Error("server-silent: expected a timeout error")
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("broadcast took %v; a silent server should not block the others", elapsed)
+	}
+}
+
+func TestClientPool_GetClientReusesConnection(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewClientPool(logger)
+	defer pool.CloseAll()
+
+	pool.AddServer("server-ok", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerOK, make(chan string, 10)),
+		Password: "secret",
+		Timeout:  time.Second,
+	})
+
+	if pool.IsConnected("server-ok") {
+		t.Fatal("expected no connection before the first checkout")
+	}
+
+	first, err := pool.GetClient("server-ok")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	second, err := pool.GetClient("server-ok")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	if first != second {
+		t.Error("expected the same client to be handed out while its connection is alive")
+	}
+	if !pool.IsConnected("server-ok") {
+		t.Error("expected the server to be connected")
+	}
+
+	if _, err := pool.GetClient("unknown"); err == nil {
+		t.Error("expected an error for a server that is not configured")
+	}
+}
+
+func TestClientPool_ReconnectsDeadConnection(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewClientPool(logger)
+	defer pool.CloseAll()
+
+	pool.AddServer("server-ok", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerOK, make(chan string, 10)),
+		Password: "secret",
+		Timeout:  time.Second,
+	})
+
+	client, err := pool.GetClient("server-ok")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	// Kill the connection under the pool
+	client.Conn.Close()
+
+	if _, err := pool.SendCommand("server-ok", "listplayers"); err == nil {
+		t.Fatal("expected the command on the dead connection to fail")
+	}
+	if pool.IsConnected("server-ok") {
+		t.Error("expected the dead connection to be dropped")
+	}
+
+	response, err := pool.SendCommand("server-ok", "listplayers")
+	if err != nil {
+		t.Fatalf("expected the pool to reconnect, got: %v", err)
+	}
+	if response != "ok: listplayers" {
+		t.Errorf("unexpected response %q", response)
+	}
+
+	reconnected, err := pool.GetClient("server-ok")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	if reconnected == client {
+		t.Error("expected a new client after reconnecting")
+	}
+}
+
+func TestClientPool_SerializesConcurrentCommands(t *testing.T) {
+	const callers = 20
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewClientPool(logger)
+	defer pool.CloseAll()
+
+	pool.AddServer("server-ok", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerOK, make(chan string, callers)),
+		Password: "secret",
+		Timeout:  time.Second,
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func(command string) {
+			defer wg.Done()
+			response, err := pool.SendCommand("server-ok", command)
+			if err != nil {
+				errs <- fmt.Errorf("%s: %w", command, err)
+				return
+			}
+			// Interleaved packets would hand a caller another caller's response
+			if response != "ok: "+command {
+				errs <- fmt.Errorf("%s: got response %q", command, response)
+			}
+		}(fmt.Sprintf("say %d", i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestClientPool_Status(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewClientPool(logger)
+	defer pool.CloseAll()
+
+	okAddress := startFakeRconServer(t, fakeServerOK, make(chan string, 10))
+	pool.AddServer("server-ok", &ServerConfig{
+		Address:  okAddress,
+		Password: "secret",
+		Timeout:  time.Second,
+	})
+	pool.AddServer("server-bad-auth", &ServerConfig{
+		Address:  startFakeRconServer(t, fakeServerBadAuth, make(chan string, 10)),
+		Password: "wrong",
+		Timeout:  time.Second,
+	})
+
+	before := time.Now()
+	if _, err := pool.SendCommand("server-ok", "listplayers"); err != nil {
+		t.Fatalf("SendCommand failed: %v", err)
+	}
+	if _, err := pool.SendCommand("server-bad-auth", "listplayers"); err == nil {
+		t.Fatal("expected server-bad-auth to fail")
+	}
+
+	status := pool.Status()
+	if len(status) != 2 {
+		t.Fatalf("expected status for 2 servers, got %v", status)
+	}
+
+	ok := status["server-ok"].(map[string]any)
+	if ok["address"] != okAddress || ok["connected"] != true || ok["error_count"] != 0 {
+		t.Errorf("unexpected status for server-ok: %v", ok)
+	}
+	if lastUsed, _ := ok["last_used"].(*time.Time); lastUsed == nil || lastUsed.Before(before) {
+		t.Errorf("expected server-ok last_used after %v, got %v", before, ok["last_used"])
+	}
+
+	bad := status["server-bad-auth"].(map[string]any)
+	if bad["connected"] != false || bad["error_count"] != 1 || bad["last_error"] == "" {
+		t.Errorf("unexpected status for server-bad-auth: %v", bad)
+	}
+	if lastUsed, _ := bad["last_used"].(*time.Time); lastUsed != nil {
+		t.Errorf("expected no last_used for a server that never connected, got %v", lastUsed)
+	}
+
+	if healthy, unhealthy := pool.HealthCounts(); healthy != 1 || unhealthy != 1 {
+		t.Errorf("HealthCounts() = %d, %d, want 1 healthy and 1 unhealthy", healthy, unhealthy)
+	}
+}
@@ -3,6 +3,7 @@ package rcon
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -10,22 +11,44 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultMaxResponseBytes caps a command's response when ClientConfig.MaxResponseBytes is not set.
+// Far more than any Sandstorm command prints (maps * is a few KB), but it bounds memory use.
+const DefaultMaxResponseBytes = 4 << 20
+
 // Config struct for RCON client
 type ClientConfig struct {
 	Timeout time.Duration
 	Logger  *slog.Logger
+
+	// MaxResponseBytes is the largest response a command may return, across all its packets.
+	// Zero or less uses DefaultMaxResponseBytes. See ResponseTooLargeError.
+	MaxResponseBytes int
 }
 
 func DefaultConfig() *ClientConfig {
 	return &ClientConfig{
-		Timeout: 5 * time.Second,
-		Logger:  slog.Default(), // Use Go's default slog logger
+		Timeout:          5 * time.Second,
+		Logger:           slog.Default(), // Use Go's default slog logger
+		MaxResponseBytes: DefaultMaxResponseBytes,
 	}
 }
 
+// ResponseTooLargeError is returned when a response, or a single packet, is larger than
+// ClientConfig.MaxResponseBytes. The rest of the response is left unread, so the connection
+// should be closed.
+type ResponseTooLargeError struct {
+	Limit int // The MaxResponseBytes in effect
+	Size  int // Bytes of the response seen when it went over the limit
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("rcon response of at least %d bytes exceeds the %d byte limit", e.Size, e.Limit)
+}
+
 type RconPacket struct {
 	Size    int32
 	ID      int32
@@ -33,11 +56,11 @@ type RconPacket struct {
 	Payload string
 }
 
-var idCounter int32 = 0
+var idCounter atomic.Int32
 
+// generateID returns a unique packet ID (safe for concurrent clients)
 func generateID() int32 {
-	idCounter++
-	return idCounter
+	return idCounter.Add(1)
 }
 
 // RconClient wraps a connection and config for testability
@@ -53,6 +76,14 @@ func NewRconClient(conn net.Conn, config *ClientConfig) *RconClient {
 	return &RconClient{Conn: conn, Config: config}
 }
 
+// maxResponseBytes returns the response size limit in effect
+func (c *RconClient) maxResponseBytes() int {
+	if c.Config.MaxResponseBytes > 0 {
+		return c.Config.MaxResponseBytes
+	}
+	return DefaultMaxResponseBytes
+}
+
 // SetLogger allows changing the logger at runtime
 func (c *RconClient) SetLogger(logger *slog.Logger) {
 	c.Config.Logger = logger
@@ -60,25 +91,32 @@ func (c *RconClient) SetLogger(logger *slog.Logger) {
 
 // Auth authenticates with the RCON server
 func (c *RconClient) Auth(password string) bool {
+	return c.AuthContext(context.Background(), password) == nil
+}
+
+// AuthContext authenticates with the RCON server, giving up when ctx is done.
+// Returns ctx.Err() when cancelled or past its deadline.
+func (c *RconClient) AuthContext(ctx context.Context, password string) (err error) {
+	defer c.watchContext(ctx)()
+	defer func() { err = contextError(ctx, err) }()
+
 	authID := generateID()
 	authPacket := BuildPacket(authID, 3, password)
-	_, err := c.Conn.Write(authPacket)
-	if err != nil {
+	if err := c.write(ctx, authPacket); err != nil {
 		c.Config.Logger.Error("Error sending authentication packet", "error", err)
-		return false
+		return fmt.Errorf("error sending authentication packet: %w", err)
 	}
-	responsePacket, err := c.ReadPacket()
+	responsePacket, err := c.readPacket(ctx)
 	if err != nil {
 		c.Config.Logger.Error("Error reading authentication response", "error", err)
-		return false
+		return fmt.Errorf("error reading authentication response: %w", err)
 	}
 	if responsePacket.ID == authID && responsePacket.Type == 2 {
 		c.Config.Logger.Info("Authentication successful")
-		return true
-	} else {
-		c.Config.Logger.Error("Authentication failed")
-		return false
+		return nil
 	}
+	c.Config.Logger.Error("Authentication failed")
+	return fmt.Errorf("authentication failed")
 }
 
 // Repl provides a simple interactive shell for RCON
@@ -130,11 +168,20 @@ func (c *RconClient) SendAndPrint(command string) error {
 
 // Send sends a command and returns the response
 func (c *RconClient) Send(command string) (string, error) {
+	return c.SendContext(context.Background(), command)
+}
+
+// SendContext sends a command and returns the response, giving up when ctx is done.
+// Every read and write also times out after Config.Timeout. Returns ctx.Err() when cancelled
+// or past its deadline; the connection may then hold part of a response and should be closed.
+func (c *RconClient) SendContext(ctx context.Context, command string) (output string, err error) {
+	defer c.watchContext(ctx)()
+	defer func() { err = contextError(ctx, err) }()
+
 	commandID := generateID()
 	commandPacket := BuildPacket(commandID, 2, command)
 	c.Config.Logger.Debug("Sending command", "command", command)
-	_, err := c.Conn.Write(commandPacket)
-	if err != nil {
+	if err := c.write(ctx, commandPacket); err != nil {
 		return "", fmt.Errorf("error sending command packet: %s", err.Error())
 	}
 
@@ -143,7 +190,7 @@ func (c *RconClient) Send(command string) (string, error) {
 		sentEmptyPacket bool
 	)
 	for {
-		responsePacket, err := c.ReadPacket()
+		responsePacket, err := c.readPacket(ctx)
 		if err != nil {
 			return "", fmt.Errorf("error reading command response: %w", err)
 		}
@@ -157,12 +204,16 @@ func (c *RconClient) Send(command string) (string, error) {
 			if sentEmptyPacket && responsePacket.Payload == "" {
 				break
 			}
+			if size := fullPayload.Len() + len(responsePacket.Payload); size > c.maxResponseBytes() {
+				c.Config.Logger.Warn("RCON response exceeds the size limit, giving up on it",
+					"command", command, "limit", c.maxResponseBytes(), "size", size)
+				return "", &ResponseTooLargeError{Limit: c.maxResponseBytes(), Size: size}
+			}
 			fullPayload.WriteString(responsePacket.Payload)
 			if !sentEmptyPacket {
 				emptyPacket := BuildPacket(commandID, 0, "")
 				c.Config.Logger.Debug("Sending empty packet to confirm response fully received")
-				_, err = c.Conn.Write(emptyPacket)
-				if err != nil {
+				if err := c.write(ctx, emptyPacket); err != nil {
 					return fullPayload.String(), fmt.Errorf("error sending empty packet: %s", err.Error())
 				}
 				sentEmptyPacket = true
@@ -175,6 +226,66 @@ func (c *RconClient) Send(command string) (string, error) {
 	return fullPayload.String(), nil
 }
 
+// deadline returns the deadline for one read or write: Config.Timeout from now, or the
+// context's deadline when that is sooner
+func (c *RconClient) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.Config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+	return deadline
+}
+
+// contextError reports a failure caused by ctx as ctx's error. The connection deadline can pass
+// a moment before the context notices its own deadline, so that counts as the context too.
+func contextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// watchContext unblocks pending reads and writes as soon as ctx is done by moving the
+// connection's deadline into the past. The returned function stops watching.
+func (c *RconClient) watchContext(ctx context.Context) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	stopped := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			c.Conn.SetDeadline(time.Unix(1, 0))
+		case <-stopped:
+		}
+	}()
+
+	return func() {
+		close(stopped)
+		<-exited
+	}
+}
+
+// write sends a packet within the deadline
+func (c *RconClient) write(ctx context.Context, packet []byte) error {
+	c.Conn.SetWriteDeadline(c.deadline(ctx))
+	// Checked after setting the deadline so a cancel can't be overwritten by it
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := c.Conn.Write(packet)
+	return err
+}
+
 // BuildPacket creates a binary RCON packet
 func BuildPacket(id int32, packetType int32, payload string) []byte {
 	payloadBytes := []byte(payload)
@@ -190,7 +301,16 @@ func BuildPacket(id int32, packetType int32, payload string) []byte {
 
 // ReadPacket reads a packet from the connection
 func (c *RconClient) ReadPacket() (*RconPacket, error) {
-	c.Conn.SetReadDeadline(time.Now().Add(c.Config.Timeout))
+	return c.readPacket(context.Background())
+}
+
+// readPacket reads a packet from the connection within the deadline
+func (c *RconClient) readPacket(ctx context.Context) (*RconPacket, error) {
+	c.Conn.SetReadDeadline(c.deadline(ctx))
+	// Checked after setting the deadline so a cancel can't be overwritten by it
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	sizeBytes := make([]byte, 4)
 	_, err := io.ReadFull(c.Conn, sizeBytes)
@@ -199,6 +319,14 @@ func (c *RconClient) ReadPacket() (*RconPacket, error) {
 		return nil, err
 	}
 	packetSize := int32(binary.LittleEndian.Uint32(sizeBytes))
+	if packetSize < 10 {
+		return nil, fmt.Errorf("packet too short")
+	}
+	// ID, type and the two null terminators around the payload
+	if payloadSize := int(packetSize) - 10; payloadSize > c.maxResponseBytes() {
+		c.Config.Logger.Warn("RCON packet exceeds the response size limit", "limit", c.maxResponseBytes(), "size", payloadSize)
+		return nil, &ResponseTooLargeError{Limit: c.maxResponseBytes(), Size: payloadSize}
+	}
 
 	packetBytes := make([]byte, packetSize)
 	_, err = io.ReadFull(c.Conn, packetBytes)
@@ -207,9 +335,6 @@ func (c *RconClient) ReadPacket() (*RconPacket, error) {
 		return nil, err
 	}
 
-	if len(packetBytes) < 10 {
-		return nil, fmt.Errorf("packet too short")
-	}
 	packetID := int32(binary.LittleEndian.Uint32(packetBytes[0:4]))
 	packetType := int32(binary.LittleEndian.Uint32(packetBytes[4:8]))
 	payload := string(packetBytes[8 : len(packetBytes)-2]) // Exclude the two null terminators
@@ -2,7 +2,11 @@ package rcon
 
 import (
 	// "log"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"os"
 	"os/exec"
@@ -91,3 +95,113 @@ func TestRconClientWithSpawnedServer(t *testing.T) {
 		t.Error("Expected non-empty response from server")
 	}
 }
+
+// dialFakeRcon connects a client with a long timeout to a fake RCON server
+func dialFakeRcon(t *testing.T, mode fakeServerMode) *RconClient {
+	t.Helper()
+
+	addr := startFakeRconServer(t, mode, make(chan string, 10))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewRconClient(conn, &ClientConfig{
+		Timeout: 10 * time.Second,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+}
+
+func TestRconClient_SendContext(t *testing.T) {
+	client := dialFakeRcon(t, fakeServerOK)
+
+	if err := client.AuthContext(context.Background(), "secret"); err != nil {
+		t.Fatalf("AuthContext failed: %v", err)
+	}
+	resp, err := client.SendContext(context.Background(), "listplayers")
+	if err != nil {
+		t.Fatalf("SendContext failed: %v", err)
+	}
+	if resp != "ok: listplayers" {
+		t.Errorf("Expected %q, got %q", "ok: listplayers", resp)
+	}
+}
+
+func TestRconClient_SendContext_Cancelled(t *testing.T) {
+	client := dialFakeRcon(t, fakeServerSilent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.SendContext(ctx, "listplayers")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	// The client timeout is 10 seconds; cancelling must not wait for it
+	if elapsed > time.Second {
+		t.Errorf("Send took %v to abort after cancel", elapsed)
+	}
+}
+
+func TestRconClient_AuthContext_Deadline(t *testing.T) {
+	client := dialFakeRcon(t, fakeServerSilent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.AuthContext(ctx, "secret")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Auth took %v to give up after the deadline", elapsed)
+	}
+}
+
+func TestRconClient_SendContext_MaxResponseBytes(t *testing.T) {
+	client := dialFakeRcon(t, fakeServerLarge)
+	if err := client.AuthContext(context.Background(), "secret"); err != nil {
+		t.Fatalf("AuthContext failed: %v", err)
+	}
+
+	// Within the default limit the packets are put back together
+	resp, err := client.SendContext(context.Background(), "maps *")
+	if err != nil {
+		t.Fatalf("SendContext failed: %v", err)
+	}
+	if resp != fakeLargeResponse {
+		t.Errorf("Expected the %d byte response, got %d bytes", len(fakeLargeResponse), len(resp))
+	}
+
+	// Over the limit the response is refused rather than truncated
+	client = dialFakeRcon(t, fakeServerLarge)
+	client.Config.MaxResponseBytes = 2500
+	if err := client.AuthContext(context.Background(), "secret"); err != nil {
+		t.Fatalf("AuthContext failed: %v", err)
+	}
+	resp, err = client.SendContext(context.Background(), "maps *")
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("Expected a ResponseTooLargeError, got %v (%d bytes)", err, len(resp))
+	}
+	if tooLarge.Limit != 2500 || tooLarge.Size != 3000 || resp != "" {
+		t.Errorf("Expected the limit to trip at 3000 of 2500 bytes with no output, got %+v and %d bytes", tooLarge, len(resp))
+	}
+
+	// A single packet over the limit is refused before it is read
+	client = dialFakeRcon(t, fakeServerLarge)
+	client.Config.MaxResponseBytes = 500
+	if err := client.AuthContext(context.Background(), "secret"); err != nil {
+		t.Fatalf("AuthContext failed: %v", err)
+	}
+	if _, err := client.SendContext(context.Background(), "maps *"); !errors.As(err, &tooLarge) || tooLarge.Size != 1000 {
+		t.Errorf("Expected a ResponseTooLargeError for the 1000 byte packet, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package servermgr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// KnownMutators are the official mutators the server loads by name from -Mutators=
+var KnownMutators = []string{
+	"AllYouCanEat", "AntiMaterielRiflesOnly", "BoltActionsOnly", "Broke", "BulletSponge",
+	"Competitive", "CompetitiveLoadouts", "FastMovement", "Frenzy", "FullyLoaded",
+	"Guerrillas", "Gunslingers", "Hardcore", "HeadshotOnly", "HotPotato",
+	"LockedAim", "NoAim", "NoDrops", "PistolsOnly", "Poor",
+	"ShotgunsOnly", "SlowCaptureTimes", "SlowMovement", "SoldierOfFortune", "SpecialOperations",
+	"Strapped", "Ultralethal", "Vampirism", "Warlords",
+}
+
+// KnownRuleSets are the official values of server_rule_set
+var KnownRuleSets = []string{"OfficialRules", "CompetitiveRules", "CompetitiveFirefightRules"}
+
+// Catalog is the set of mutator and ruleset names a config is checked against.
+// Mod mutators and custom rulesets are added with Config.KnownMutators / Config.KnownRuleSets.
+type Catalog struct {
+	Mutators []string
+	RuleSets []string
+}
+
+// NewCatalog returns the official names plus any extra ones
+func NewCatalog(extraMutators, extraRuleSets []string) Catalog {
+	return Catalog{
+		Mutators: append(slices.Clone(KnownMutators), extraMutators...),
+		RuleSets: append(slices.Clone(KnownRuleSets), extraRuleSets...),
+	}
+}
+
+// Warnings lists the mutators and ruleset of config that are not in the catalog.
+// The server ignores a mutator it can't find instead of failing, so these are warnings
+// rather than validation problems: a mod mutator missing from the catalog still loads.
+func (c Catalog) Warnings(config SAWServerConfig) []string {
+	var warnings []string
+
+	for _, mutator := range configMutators(config) {
+		if slices.Contains(c.Mutators, mutator) {
+			continue
+		}
+		if match, ok := matchFold(c.Mutators, mutator); ok {
+			warnings = append(warnings, fmt.Sprintf("mutator %q is not a known mutator, did you mean %q?", mutator, match))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("mutator %q is not a known mutator and may not load", mutator))
+		}
+	}
+
+	if ruleSet := strings.TrimSpace(config.ServerRuleSet); ruleSet != "" && ruleSet != "None" && !slices.Contains(c.RuleSets, ruleSet) {
+		if match, ok := matchFold(c.RuleSets, ruleSet); ok {
+			warnings = append(warnings, fmt.Sprintf("server_rule_set %q is not a known ruleset, did you mean %q?", ruleSet, match))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("server_rule_set %q is not one of %s", ruleSet, strings.Join(c.RuleSets, ", ")))
+		}
+	}
+
+	return warnings
+}
+
+// configMutators returns the mutators passed to the server, from the list and the custom comma-separated value
+func configMutators(config SAWServerConfig) []string {
+	var mutators []string
+	for _, mutator := range config.ServerMutators {
+		if mutator = strings.TrimSpace(mutator); mutator != "" {
+			mutators = append(mutators, mutator)
+		}
+	}
+	for _, mutator := range strings.Split(config.ServerMutatorsCustom, ",") {
+		if mutator = strings.TrimSpace(mutator); mutator != "" {
+			mutators = append(mutators, mutator)
+		}
+	}
+	return mutators
+}
+
+// matchFold returns the name in names that equals value ignoring case
+func matchFold(names []string, value string) (string, bool) {
+	for _, name := range names {
+		if strings.EqualFold(name, value) {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,94 @@
+package servermgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCatalogWarnings(t *testing.T) {
+	cases := []struct {
+		name    string
+		catalog Catalog
+		modify  func(*SAWServerConfig)
+		want    []string
+	}{
+		{
+			name:    "known mutators and ruleset",
+			catalog: NewCatalog(nil, nil),
+			modify: func(c *SAWServerConfig) {
+				c.ServerMutators = []string{"Hardcore", "NoAim"}
+				c.ServerMutatorsCustom = "HeadshotOnly, Vampirism"
+				c.ServerRuleSet = "CompetitiveRules"
+			},
+		},
+		{
+			name:    "no ruleset",
+			catalog: NewCatalog(nil, nil),
+			modify: func(c *SAWServerConfig) {
+				c.ServerRuleSet = "None"
+			},
+		},
+		{
+			name:    "unknown mutators",
+			catalog: NewCatalog(nil, nil),
+			modify: func(c *SAWServerConfig) {
+				c.ServerMutators = []string{"Hardcor"}
+				c.ServerMutatorsCustom = "Hardcore,SuperSpeed"
+			},
+			want: []string{
+				`mutator "Hardcor" is not a known mutator and may not load`,
+				`mutator "SuperSpeed" is not a known mutator and may not load`,
+			},
+		},
+		{
+			name:    "case mismatch suggests the known name",
+			catalog: NewCatalog(nil, nil),
+			modify: func(c *SAWServerConfig) {
+				c.ServerMutators = []string{"noaim"}
+				c.ServerRuleSet = "officialrules"
+			},
+			want: []string{
+				`mutator "noaim" is not a known mutator, did you mean "NoAim"?`,
+				`server_rule_set "officialrules" is not a known ruleset, did you mean "OfficialRules"?`,
+			},
+		},
+		{
+			name:    "unknown ruleset",
+			catalog: NewCatalog(nil, nil),
+			modify: func(c *SAWServerConfig) {
+				c.ServerRuleSet = "HouseRules"
+			},
+			want: []string{
+				`server_rule_set "HouseRules" is not one of OfficialRules, CompetitiveRules, CompetitiveFirefightRules`,
+			},
+		},
+		{
+			name:    "catalog extended with mod names",
+			catalog: NewCatalog([]string{"SuperSpeed"}, []string{"HouseRules"}),
+			modify: func(c *SAWServerConfig) {
+				c.ServerMutatorsCustom = "SuperSpeed"
+				c.ServerRuleSet = "HouseRules"
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validSAWConfig()
+			tt.modify(&config)
+
+			got := tt.catalog.Warnings(config)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected warnings\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCatalogDoesNotModifyDefaults(t *testing.T) {
+	before := len(KnownMutators)
+	NewCatalog([]string{"SuperSpeed"}, nil)
+	if len(KnownMutators) != before {
+		t.Errorf("KnownMutators changed from %d to %d entries", before, len(KnownMutators))
+	}
+}
@@ -0,0 +1,47 @@
+package servermgr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sandstorm-tracker/internal/logger"
+)
+
+// DefaultConsoleLogRotation is used for console logs when no rotation policy is configured
+var DefaultConsoleLogRotation = logger.RotationPolicy{
+	MaxSize:    50 * 1024 * 1024,
+	MaxBackups: 5,
+}
+
+// ConsoleLogPaths returns the files a detached server's stdout and stderr are redirected to
+func ConsoleLogPaths(dir, serverID string) (stdout, stderr string) {
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+	return filepath.Join(dir, serverID+".stdout.log"), filepath.Join(dir, serverID+".stderr.log")
+}
+
+// CaptureConsoleLogs redirects a detached launch's stdout and stderr into dir.
+// The server holds the files open while it runs, so they are rotated here, before each start,
+// once they have reached the policy's size threshold.
+func CaptureConsoleLogs(command *LaunchCommand, dir, serverID string, policy logger.RotationPolicy) error {
+	if policy == (logger.RotationPolicy{}) {
+		policy = DefaultConsoleLogRotation
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create console log directory: %w", err)
+	}
+
+	stdout, stderr := ConsoleLogPaths(dir, serverID)
+	for _, path := range []string{stdout, stderr} {
+		if _, err := logger.RotateFile(path, policy); err != nil {
+			return err
+		}
+	}
+
+	command.StdoutPath = stdout
+	command.StderrPath = stderr
+	return nil
+}
@@ -0,0 +1,179 @@
+package servermgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// AppliedConfigsFile is where the config each server was last started with is kept, in the data directory
+const AppliedConfigsFile = "applied-configs.json"
+
+// redactedFields are compared but never printed
+var redactedFields = map[string]bool{
+	"server_password":      true,
+	"server_rcon_password": true,
+}
+
+// FieldChange is one server-configs.json field that differs from the applied config
+type FieldChange struct {
+	Field string `json:"field"` // JSON name in server-configs.json
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ServerChanges lists the changed fields of a server
+type ServerChanges struct {
+	ServerID string        `json:"server_id"`
+	Fields   []FieldChange `json:"fields"`
+}
+
+// ConfigDiff is what would change if every server were started from the current server-configs.json
+type ConfigDiff struct {
+	Added   []string        `json:"added"`   // In server-configs.json but never started
+	Removed []string        `json:"removed"` // Started before but gone from server-configs.json
+	Changed []ServerChanges `json:"changed"`
+}
+
+// HasChanges reports whether the current configs differ from the applied ones
+func (d ConfigDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// DiffConfigs compares the configs servers were last started with to the current configs.
+// Servers and fields are listed in a stable order; passwords are reported as changed without their values.
+func DiffConfigs(applied, current map[string]SAWServerConfig) ConfigDiff {
+	diff := ConfigDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []ServerChanges{},
+	}
+
+	for _, serverID := range sortedKeys(current) {
+		old, ok := applied[serverID]
+		if !ok {
+			diff.Added = append(diff.Added, serverID)
+			continue
+		}
+		if fields := diffFields(old, current[serverID]); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, ServerChanges{ServerID: serverID, Fields: fields})
+		}
+	}
+	for _, serverID := range sortedKeys(applied) {
+		if _, ok := current[serverID]; !ok {
+			diff.Removed = append(diff.Removed, serverID)
+		}
+	}
+
+	return diff
+}
+
+// diffFields returns the fields that differ between two configs, in struct order
+func diffFields(old, new SAWServerConfig) []FieldChange {
+	var changes []FieldChange
+
+	oldValue := reflect.ValueOf(old)
+	newValue := reflect.ValueOf(new)
+	configType := oldValue.Type()
+	for i := range configType.NumField() {
+		field := strings.Split(configType.Field(i).Tag.Get("json"), ",")[0]
+		before := fieldString(oldValue.Field(i))
+		after := fieldString(newValue.Field(i))
+		if before == after {
+			continue
+		}
+		if redactedFields[field] {
+			before, after = redact(before), redact(after)
+		}
+		changes = append(changes, FieldChange{Field: field, Old: before, New: after})
+	}
+
+	return changes
+}
+
+// redact hides a secret, keeping whether it is set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "(set)"
+}
+
+// fieldString formats a config field for comparison; lists such as mutators are joined in order
+func fieldString(value reflect.Value) string {
+	if value.Kind() == reflect.Slice {
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(value.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// LoadAppliedConfigs reads the configs servers were last started with. A missing file means
+// nothing has been started yet.
+func LoadAppliedConfigs(dataDir string) (map[string]SAWServerConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, AppliedConfigsFile))
+	if os.IsNotExist(err) {
+		return map[string]SAWServerConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied configs: %w", err)
+	}
+
+	configs := map[string]SAWServerConfig{}
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse applied configs: %w", err)
+	}
+	return configs, nil
+}
+
+// SaveAppliedConfig records the config a server was just started with
+func SaveAppliedConfig(dataDir, serverID string, config SAWServerConfig) error {
+	configs, err := LoadAppliedConfigs(dataDir)
+	if err != nil {
+		return err
+	}
+	configs[serverID] = config
+
+	data, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so a crash never leaves a half-written snapshot
+	path := filepath.Join(dataDir, AppliedConfigsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// PrintConfigDiff describes a config diff for the diff command
+func PrintConfigDiff(diff ConfigDiff) {
+	if !diff.HasChanges() {
+		fmt.Println("No changes: every server matches the config it was last started with")
+		return
+	}
+
+	for _, serverID := range diff.Added {
+		fmt.Printf("+ %s (not started yet)\n", serverID)
+	}
+	for _, serverID := range diff.Removed {
+		fmt.Printf("- %s (no longer in server-configs.json)\n", serverID)
+	}
+	for _, server := range diff.Changed {
+		fmt.Printf("~ %s\n", server.ServerID)
+		for _, change := range server.Fields {
+			fmt.Printf("    %s: %q -> %q\n", change.Field, change.Old, change.New)
+		}
+	}
+}
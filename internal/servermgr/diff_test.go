@@ -0,0 +1,174 @@
+package servermgr
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/spf13/cobra"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	unchanged := validSAWConfig()
+
+	before := validSAWConfig()
+	before.ServerMutators = []string{"HardcoreMode"}
+	before.ServerRconPassword = "old-secret"
+
+	after := before
+	after.ServerGamePort = "27103"
+	after.ServerDefaultMap = "Farmhouse"
+	after.ServerMutators = []string{"HardcoreMode", "NoAim"}
+	after.ServerRconPassword = "new-secret"
+
+	applied := map[string]SAWServerConfig{
+		"server-1": unchanged,
+		"server-2": before,
+		"server-3": validSAWConfig(),
+	}
+	current := map[string]SAWServerConfig{
+		"server-1": unchanged,
+		"server-2": after,
+		"server-4": validSAWConfig(),
+	}
+
+	diff := DiffConfigs(applied, current)
+
+	if !diff.HasChanges() {
+		t.Fatal("expected changes")
+	}
+	if !reflect.DeepEqual(diff.Added, []string{"server-4"}) {
+		t.Errorf("Added = %v, want [server-4]", diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"server-3"}) {
+		t.Errorf("Removed = %v, want [server-3]", diff.Removed)
+	}
+
+	want := []ServerChanges{{
+		ServerID: "server-2",
+		Fields: []FieldChange{
+			{Field: "server_default_map", Old: "Ministry", New: "Farmhouse"},
+			{Field: "server_mutators", Old: "HardcoreMode", New: "HardcoreMode,NoAim"},
+			{Field: "server_game_port", Old: "27102", New: "27103"},
+			// Passwords are reported as changed without their values
+			{Field: "server_rcon_password", Old: "(set)", New: "(set)"},
+		},
+	}}
+	if !reflect.DeepEqual(diff.Changed, want) {
+		t.Errorf("Changed = %+v\nwant %+v", diff.Changed, want)
+	}
+
+	if DiffConfigs(current, current).HasChanges() {
+		t.Error("expected no changes when comparing configs to themselves")
+	}
+}
+
+func TestAppliedConfigs(t *testing.T) {
+	dataDir := t.TempDir()
+
+	applied, err := LoadAppliedConfigs(dataDir)
+	if err != nil {
+		t.Fatalf("LoadAppliedConfigs without a snapshot: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no applied configs before any start, got %v", applied)
+	}
+
+	first := validSAWConfig()
+	second := validSAWConfig()
+	second.ServerGamePort = "27202"
+	if err := SaveAppliedConfig(dataDir, "server-1", first); err != nil {
+		t.Fatalf("SaveAppliedConfig: %v", err)
+	}
+	if err := SaveAppliedConfig(dataDir, "server-2", second); err != nil {
+		t.Fatalf("SaveAppliedConfig: %v", err)
+	}
+
+	applied, err = LoadAppliedConfigs(dataDir)
+	if err != nil {
+		t.Fatalf("LoadAppliedConfigs: %v", err)
+	}
+	want := map[string]SAWServerConfig{"server-1": first, "server-2": second}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied configs = %+v\nwant %+v", applied, want)
+	}
+}
+
+// TestDiffEndpoint checks the diff API needs a superuser and always reads the configured SAW
+// install and data directory, whatever saw_path the request names
+func TestDiffEndpoint(t *testing.T) {
+	sawPath := t.TempDir()
+	configDir := filepath.Join(sawPath, "admin-interface", "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := json.Marshal(map[string]SAWServerConfig{"server-1": validSAWConfig()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "server-configs.json"), configs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dataDir := t.TempDir()
+
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create test app: %v", err)
+	}
+	defer baseApp.Cleanup()
+
+	superusers, err := baseApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	if err := baseApp.Save(superuser); err != nil {
+		t.Fatal(err)
+	}
+	token, err := superuser.NewAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		if _, err := Register(testApp, &cobra.Command{}, Config{DefaultSAWPath: sawPath, DataDir: dataDir}); err != nil {
+			t.Fatalf("failed to register plugin: %v", err)
+		}
+		return testApp
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "requires a superuser",
+			Method:          http.MethodGet,
+			URL:             "/api/server/diff",
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+		},
+		{
+			Name:            "reads the configured SAW install",
+			Method:          http.MethodGet,
+			URL:             "/api/server/diff?saw_path=" + t.TempDir(),
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusOK,
+			ExpectedContent: []string{`"has_changes":true`, `"added":["server-1"]`},
+			TestAppFactory:  setup,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
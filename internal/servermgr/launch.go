@@ -0,0 +1,277 @@
+package servermgr
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// LaunchCommand is the process StartServer spawns for a server
+type LaunchCommand struct {
+	Executable string   `json:"executable"`
+	Args       []string `json:"args"`
+	WorkDir    string   `json:"work_dir"`
+	StdoutPath string   `json:"stdout_path,omitempty"` // Console output of a detached server, see CaptureConsoleLogs
+	StderrPath string   `json:"stderr_path,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"` // Unknown mutators, ruleset or custom arg variables
+}
+
+// BuildLaunchCommand resolves the server executable and builds the travel URL and
+// arguments for a server config. It has no side effects, so it doubles as a dry run.
+func BuildLaunchCommand(serverID string, config SAWServerConfig, sawPath string, showLogs bool) (*LaunchCommand, error) {
+	sawPath = strings.ReplaceAll(sawPath, "\\", "/")
+
+	serverExe := os.Getenv("INSURGENCY_SERVER_PATH")
+	if serverExe == "" {
+		serverExe = filepath.Join(sawPath, "sandstorm-server", "Insurgency", "Binaries", "Win64", "InsurgencyServer-Win64-Shipping.exe")
+	}
+
+	absServerExe, err := filepath.Abs(serverExe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for server executable: %w", err)
+	}
+	serverExe = absServerExe
+
+	if _, err := os.Stat(serverExe); os.IsNotExist(err) {
+		return nil, fmt.Errorf("server executable not found at: %s", serverExe)
+	}
+
+	absSAWPath, err := filepath.Abs(sawPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for SAW: %w", err)
+	}
+
+	// Custom args can refer to the config with ${name} variables, see TemplateVars
+	vars := TemplateVars(serverID, config, absSAWPath)
+	customTravelArgs, warnings := ExpandTemplate(config.ServerCustomTravelArgs, vars)
+	customServerArgs, serverArgWarnings := ExpandArgs(config.ServerCustomServerArgs, vars)
+	warnings = append(warnings, serverArgWarnings...)
+
+	// Build scenario name - for Checkpoint and Push modes, include the side
+	scenarioName := fmt.Sprintf("Scenario_%s_%s", config.ServerDefaultMap, config.ServerScenarioMode)
+	if config.ServerScenarioMode == "Checkpoint" || config.ServerScenarioMode == "Push" {
+		scenarioName += "_" + config.ServerDefaultSide
+	}
+	travelArgs := config.ServerDefaultMap + "?Scenario=" + scenarioName
+
+	if config.ServerMaxPlayers != "" {
+		travelArgs += "?MaxPlayers=" + config.ServerMaxPlayers
+	}
+
+	// Add Game mode if specified
+	if config.ServerGameMode != "" && config.ServerGameMode != "None" {
+		travelArgs += "?Game=" + config.ServerGameMode
+	}
+
+	// Add password if specified
+	if config.ServerPassword != "" {
+		travelArgs += "?Password=" + config.ServerPassword
+	}
+
+	if config.ServerLightingDay == "true" {
+		travelArgs += "?Lighting=Day"
+	} else {
+		travelArgs += "?Lighting=Night"
+	}
+
+	if customTravelArgs != "" {
+		travelArgs += "?" + customTravelArgs
+	}
+
+	args := []string{
+		travelArgs,
+		"-Hostname=" + config.ServerHostname,
+		"-MaxPlayers=" + config.ServerMaxPlayers,
+		"-Port=" + config.ServerGamePort,
+		"-QueryPort=" + config.ServerQueryPort,
+		"-LogCmds=LogGameplayEvents Log",
+		"-LOCALLOGTIMES",
+		"-AdminList=Admins",
+		"-MapCycle=MapCycle",
+	}
+
+	if showLogs {
+		args = append(args, "-stdout")
+	} else {
+		args = append(args, "-log="+serverID+".log")
+	}
+
+	if len(config.ServerMutators) > 0 {
+		mutators := strings.Join(config.ServerMutators, ",")
+		args = append(args, "-Mutators="+mutators)
+	}
+	if config.ServerMutatorsCustom != "" {
+		args = append(args, "-Mutators="+config.ServerMutatorsCustom)
+	}
+
+	if config.ServerCheats == "true" {
+		args = append(args, "-CmdServerCheats")
+	}
+
+	args = append(args, customServerArgs...)
+
+	return &LaunchCommand{
+		Executable: serverExe,
+		Args:       args,
+		WorkDir:    absSAWPath,
+		Warnings:   warnings,
+	}, nil
+}
+
+// splitArgs splits custom server args on whitespace, keeping double or single quoted
+// sections together: -motd="Welcome all" -log becomes [-motd=Welcome all, -log]
+func splitArgs(value string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range value {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// CommandLine renders the executable and arguments as the raw command line the server receives
+func (c *LaunchCommand) CommandLine() string {
+	return quoteArg(c.Executable) + " " + c.argumentList()
+}
+
+// StartProcessScript is the PowerShell script that launches the server detached and prints its PID.
+// Every argument is a separate literal in an -ArgumentList array; Start-Process joins them with spaces,
+// so each element carries its own command line quoting.
+func (c *LaunchCommand) StartProcessScript() string {
+	elements := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		elements[i] = psQuote(unrealQuoteArg(arg))
+	}
+	redirect := ""
+	if c.StdoutPath != "" && c.StderrPath != "" {
+		redirect = fmt.Sprintf(" -RedirectStandardOutput %s -RedirectStandardError %s", psQuote(c.StdoutPath), psQuote(c.StderrPath))
+	}
+	return fmt.Sprintf("$proc = Start-Process -FilePath %s -ArgumentList @(%s) -WorkingDirectory %s%s -WindowStyle Hidden -PassThru; Write-Output $proc.Id",
+		psQuote(c.Executable), strings.Join(elements, ", "), psQuote(c.WorkDir), redirect)
+}
+
+// PowerShellCommand returns the powershell invocation that runs StartProcessScript.
+// The script is passed with -EncodedCommand so it is not re-quoted on its way to PowerShell.
+func (c *LaunchCommand) PowerShellCommand() *exec.Cmd {
+	script := utf16.Encode([]rune(c.StartProcessScript()))
+	encoded := make([]byte, 2*len(script))
+	for i, unit := range script {
+		binary.LittleEndian.PutUint16(encoded[2*i:], unit)
+	}
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-EncodedCommand", base64.StdEncoding.EncodeToString(encoded))
+}
+
+// ForegroundCommand returns the command that runs the server attached to the console
+func (c *LaunchCommand) ForegroundCommand() *exec.Cmd {
+	cmd := exec.Command(c.Executable, c.Args...)
+	cmd.Dir = c.WorkDir
+	setCommandLine(cmd, c.CommandLine())
+	return cmd
+}
+
+func (c *LaunchCommand) argumentList() string {
+	quoted := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		quoted[i] = unrealQuoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// unrealQuoteArg quotes an argument the way Unreal parses its command line.
+// Unreal reads -Key=Value pairs from the raw command line, so a value with spaces
+// must be quoted after the '=' (-Hostname="My Server"), not around the whole argument.
+func unrealQuoteArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t") {
+		return arg
+	}
+	if key, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(key, "-") && !strings.ContainsAny(key, " \t") {
+		return key + `="` + value + `"`
+	}
+	return `"` + arg + `"`
+}
+
+// quoteArg quotes an argument following the Windows command line rules (see syscall.EscapeArg)
+func quoteArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			backslashes++
+		case '"':
+			// Backslashes before a quote are doubled and the quote itself escaped
+			b.WriteString(strings.Repeat(`\`, backslashes+1))
+			backslashes = 0
+			b.WriteByte('"')
+			continue
+		default:
+			backslashes = 0
+		}
+		b.WriteByte(arg[i])
+	}
+	// Backslashes before the closing quote must be doubled
+	b.WriteString(strings.Repeat(`\`, backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// psQuote wraps a value in a PowerShell single-quoted (literal) string
+func psQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// PrintLaunchCommand describes a launch for a dry run
+func PrintLaunchCommand(serverID string, command *LaunchCommand, showLogs bool) {
+	fmt.Printf("Dry run: server %s would be started with\n", serverID)
+	fmt.Printf("  Working directory: %s\n", command.WorkDir)
+	fmt.Printf("  Executable:        %s\n", command.Executable)
+	fmt.Println("  Arguments:")
+	for i, arg := range command.Args {
+		fmt.Printf("    [%d] %s\n", i, arg)
+	}
+	fmt.Printf("  Command line:      %s\n", command.CommandLine())
+	if command.StdoutPath != "" {
+		fmt.Printf("  Console output:    %s, %s\n", command.StdoutPath, command.StderrPath)
+	}
+	if !showLogs {
+		fmt.Printf("  PowerShell:        %s\n", command.StartProcessScript())
+	}
+	for _, warning := range command.Warnings {
+		fmt.Printf("  Warning:           %s\n", warning)
+	}
+}
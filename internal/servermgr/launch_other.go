@@ -0,0 +1,8 @@
+//go:build !windows
+
+package servermgr
+
+import "os/exec"
+
+// setCommandLine is a no-op outside Windows, where arguments are passed as an argv array
+func setCommandLine(cmd *exec.Cmd, line string) {}
@@ -0,0 +1,225 @@
+package servermgr
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"sandstorm-tracker/internal/logger"
+)
+
+func TestBuildLaunchCommand(t *testing.T) {
+	sawPath := t.TempDir()
+	serverExe := filepath.Join(sawPath, "Insurgency Server", "InsurgencyServer.exe")
+	if err := os.MkdirAll(filepath.Dir(serverExe), 0755); err != nil {
+		t.Fatalf("failed to create server directory: %v", err)
+	}
+	if err := os.WriteFile(serverExe, nil, 0755); err != nil {
+		t.Fatalf("failed to create server executable: %v", err)
+	}
+	t.Setenv("INSURGENCY_SERVER_PATH", serverExe)
+
+	config := SAWServerConfig{
+		ServerHostname:         "My Test Server",
+		ServerDefaultMap:       "Ministry",
+		ServerScenarioMode:     "Checkpoint",
+		ServerDefaultSide:      "Security",
+		ServerGameMode:         "CheckpointHardcore",
+		ServerMaxPlayers:       "20",
+		ServerGamePort:         "27102",
+		ServerQueryPort:        "27131",
+		ServerMutators:         []string{"Hardcore", "NoAim"},
+		ServerCustomServerArgs: "-ruleset=OfficialRules -mods",
+	}
+
+	command, err := BuildLaunchCommand("test-server", config, sawPath, false)
+	if err != nil {
+		t.Fatalf("BuildLaunchCommand failed: %v", err)
+	}
+
+	wantArgs := []string{
+		"Ministry?Scenario=Scenario_Ministry_Checkpoint_Security?MaxPlayers=20?Game=CheckpointHardcore?Lighting=Night",
+		"-Hostname=My Test Server",
+		"-MaxPlayers=20",
+		"-Port=27102",
+		"-QueryPort=27131",
+		"-LogCmds=LogGameplayEvents Log",
+		"-LOCALLOGTIMES",
+		"-AdminList=Admins",
+		"-MapCycle=MapCycle",
+		"-log=test-server.log",
+		"-Mutators=Hardcore,NoAim",
+		"-ruleset=OfficialRules",
+		"-mods",
+	}
+	if !reflect.DeepEqual(command.Args, wantArgs) {
+		t.Errorf("unexpected args\n got: %q\nwant: %q", command.Args, wantArgs)
+	}
+	if command.Executable != serverExe {
+		t.Errorf("expected executable %s, got %s", serverExe, command.Executable)
+	}
+	if command.WorkDir != sawPath {
+		t.Errorf("expected work dir %s, got %s", sawPath, command.WorkDir)
+	}
+
+	// Unreal reads -Key="value" pairs from the raw command line
+	wantLine := `"` + serverExe + `" ` + wantArgs[0] +
+		` -Hostname="My Test Server" -MaxPlayers=20 -Port=27102 -QueryPort=27131 -LogCmds="LogGameplayEvents Log"` +
+		` -LOCALLOGTIMES -AdminList=Admins -MapCycle=MapCycle -log=test-server.log -Mutators=Hardcore,NoAim -ruleset=OfficialRules -mods`
+	if got := command.CommandLine(); got != wantLine {
+		t.Errorf("unexpected command line\n got: %s\nwant: %s", got, wantLine)
+	}
+}
+
+func TestLaunchCommandQuoting(t *testing.T) {
+	serverExe := filepath.Join(t.TempDir(), "InsurgencyServer.exe")
+	if err := os.WriteFile(serverExe, nil, 0755); err != nil {
+		t.Fatalf("failed to create server executable: %v", err)
+	}
+	t.Setenv("INSURGENCY_SERVER_PATH", serverExe)
+
+	config := validSAWConfig()
+	config.ServerHostname = "Bob's Server 24/7"
+	config.ServerCustomServerArgs = `-motd="Welcome, it's 24/7"  -ruleset='Official Rules' -NoEAC`
+
+	command, err := BuildLaunchCommand("quoting", config, "C:/SAW", false)
+	if err != nil {
+		t.Fatalf("BuildLaunchCommand failed: %v", err)
+	}
+
+	// Quoted custom args stay one argument each
+	wantTail := []string{"-motd=Welcome, it's 24/7", "-ruleset=Official Rules", "-NoEAC"}
+	if got := command.Args[len(command.Args)-3:]; !reflect.DeepEqual(got, wantTail) {
+		t.Errorf("unexpected custom args\n got: %q\nwant: %q", got, wantTail)
+	}
+	if command.Args[1] != "-Hostname=Bob's Server 24/7" {
+		t.Errorf("unexpected hostname arg: %q", command.Args[1])
+	}
+
+	line := command.CommandLine()
+	for _, want := range []string{
+		` -Hostname="Bob's Server 24/7" `,
+		` -motd="Welcome, it's 24/7" -ruleset="Official Rules" -NoEAC`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %s in command line: %s", want, line)
+		}
+	}
+
+	// Each argument is its own PowerShell literal with single quotes doubled
+	script := command.StartProcessScript()
+	for _, want := range []string{
+		`, '-Hostname="Bob''s Server 24/7"', `,
+		`, '-motd="Welcome, it''s 24/7"', '-ruleset="Official Rules"', '-NoEAC')`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected %s in script: %s", want, script)
+		}
+	}
+
+	// The script reaches PowerShell base64 encoded, never re-quoted
+	ps := command.PowerShellCommand()
+	encoded := ps.Args[len(ps.Args)-1]
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("failed to decode -EncodedCommand: %v", err)
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(raw[2*i:])
+	}
+	if decoded := string(utf16.Decode(units)); decoded != script {
+		t.Errorf("encoded command does not round trip:\n got: %s\nwant: %s", decoded, script)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	cases := map[string][]string{
+		"":                              nil,
+		"-log -NoEAC":                   {"-log", "-NoEAC"},
+		`-motd="a b"   -x`:              {"-motd=a b", "-x"},
+		`-name='it"s' "-quoted arg" ""`: {`-name=it"s`, "-quoted arg", ""},
+	}
+	for value, want := range cases {
+		if got := splitArgs(value); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	cases := map[string]string{
+		"":                  `""`,
+		"-Port=27102":       "-Port=27102",
+		"-Hostname=My Srv":  `"-Hostname=My Srv"`,
+		`say "hi"`:          `"say \"hi\""`,
+		`C:\Program Files\`: `"C:\Program Files\\"`,
+		`a\"b c`:            `"a\\\"b c"`,
+	}
+	for arg, want := range cases {
+		if got := quoteArg(arg); got != want {
+			t.Errorf("quoteArg(%q) = %s, want %s", arg, got, want)
+		}
+	}
+
+	if got := psQuote(`C:\It's Here`); got != `'C:\It''s Here'` {
+		t.Errorf("psQuote did not escape the single quote: %s", got)
+	}
+}
+
+func TestDryRunServerHasNoSideEffects(t *testing.T) {
+	sawPath := t.TempDir()
+	serverExe := filepath.Join(sawPath, "InsurgencyServer.exe")
+	if err := os.WriteFile(serverExe, nil, 0755); err != nil {
+		t.Fatalf("failed to create server executable: %v", err)
+	}
+	t.Setenv("INSURGENCY_SERVER_PATH", serverExe)
+
+	plugin := &Plugin{config: Config{DataDir: t.TempDir()}, servers: make(map[string]*ManagedServer)}
+	serverID := "test-server-dry-run"
+
+	command, err := plugin.DryRunServer(serverID, validSAWConfig(), sawPath, false)
+	if err != nil {
+		t.Fatalf("DryRunServer failed: %v", err)
+	}
+	if len(command.Args) == 0 {
+		t.Fatal("expected dry run to build arguments")
+	}
+
+	if _, err := os.Stat(plugin.getPIDFilePath(serverID)); !os.IsNotExist(err) {
+		t.Error("dry run must not write a PID file")
+	}
+	if _, err := os.Stat(filepath.Join(sawPath, "server-config")); !os.IsNotExist(err) {
+		t.Error("dry run must not apply server config files")
+	}
+	if len(plugin.servers) != 0 {
+		t.Error("dry run must not register a managed server")
+	}
+}
+
+func TestCaptureConsoleLogs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "servers")
+	command := &LaunchCommand{Executable: "server.exe", Args: []string{"-log"}, WorkDir: "C:/SAW"}
+
+	if err := CaptureConsoleLogs(command, dir, "console", logger.RotationPolicy{}); err != nil {
+		t.Fatalf("CaptureConsoleLogs failed: %v", err)
+	}
+
+	stdout, stderr := ConsoleLogPaths(dir, "console")
+	if command.StdoutPath != stdout || command.StderrPath != stderr {
+		t.Fatalf("unexpected console log paths: %s, %s", command.StdoutPath, command.StderrPath)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("console log directory was not created: %v", err)
+	}
+
+	want := " -RedirectStandardOutput " + psQuote(stdout) + " -RedirectStandardError " + psQuote(stderr) + " "
+	if script := command.StartProcessScript(); !strings.Contains(script, want) {
+		t.Errorf("expected %s in script: %s", want, script)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package servermgr
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setCommandLine passes line to the process verbatim instead of letting Go re-quote the arguments
+func setCommandLine(cmd *exec.Cmd, line string) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: line}
+}
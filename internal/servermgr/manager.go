@@ -90,6 +90,16 @@ func (sm *ServerManager) StartServer(serverID string, config SAWServerConfig, sa
 		return fmt.Errorf("server %s is already running", serverID)
 	}
 
+	running := make(map[string]SAWServerConfig)
+	for id, server := range sm.servers {
+		if id != serverID && server.IsRunning {
+			running[id] = server.Config
+		}
+	}
+	if err := validateForStart(serverID, config, running); err != nil {
+		return err
+	}
+
 	// Normalize path
 	sawPath = strings.ReplaceAll(sawPath, "\\", "/")
 
@@ -111,6 +121,20 @@ func (sm *ServerManager) StartServer(serverID string, config SAWServerConfig, sa
 		return fmt.Errorf("server executable not found at: %s", serverExe)
 	}
 
+	// Get absolute path for SAW directory
+	absSAWPath, err := filepath.Abs(sawPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for SAW: %w", err)
+	}
+
+	// Expand ${name} variables in the custom args
+	vars := TemplateVars(serverID, config, absSAWPath)
+	customTravelArgs, warnings := ExpandTemplate(config.ServerCustomTravelArgs, vars)
+	customServerArgs, serverArgWarnings := ExpandArgs(config.ServerCustomServerArgs, vars)
+	for _, warning := range append(warnings, serverArgWarnings...) {
+		sm.logger.Warn("Server config warning", "server_id", serverID, "warning", warning)
+	}
+
 	// Build the map/scenario travel string
 	scenarioName := fmt.Sprintf("Scenario_%s_%s", config.ServerDefaultMap, config.ServerScenarioMode)
 	travelArgs := config.ServerDefaultMap + "?Scenario=" + scenarioName
@@ -127,8 +151,8 @@ func (sm *ServerManager) StartServer(serverID string, config SAWServerConfig, sa
 	}
 
 	// Add custom travel args
-	if config.ServerCustomTravelArgs != "" {
-		travelArgs += "?" + config.ServerCustomTravelArgs
+	if customTravelArgs != "" {
+		travelArgs += "?" + customTravelArgs
 	}
 
 	// Build server arguments
@@ -171,19 +195,10 @@ func (sm *ServerManager) StartServer(serverID string, config SAWServerConfig, sa
 	}
 
 	// Add custom server args
-	if config.ServerCustomServerArgs != "" {
-		customArgs := strings.Fields(config.ServerCustomServerArgs)
-		args = append(args, customArgs...)
-	}
-
-	// Get absolute path for SAW directory
-	absSAWPath, err := filepath.Abs(sawPath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path for SAW: %w", err)
-	}
+	args = append(args, customServerArgs...)
 
 	sm.logger.Info("Starting Insurgency server",
-		"serverID", serverID,
+		"server_id", serverID,
 		"name", config.ServerHostname,
 		"executable", serverExe,
 		"workDir", absSAWPath,
@@ -229,9 +244,9 @@ func (sm *ServerManager) monitorServer(serverID string, cmd *exec.Cmd) {
 	if server, exists := sm.servers[serverID]; exists {
 		server.IsRunning = false
 		if err != nil {
-			sm.logger.Error("Server exited with error", "serverID", serverID, "error", err)
+			sm.logger.Error("Server exited with error", "server_id", serverID, "error", err)
 		} else {
-			sm.logger.Info("Server stopped", "serverID", serverID)
+			sm.logger.Info("Server stopped", "server_id", serverID)
 		}
 	}
 }
@@ -254,7 +269,7 @@ func (sm *ServerManager) StopServer(serverID string) error {
 		return fmt.Errorf("server %s has no process", serverID)
 	}
 
-	sm.logger.Info("Stopping server", "serverID", serverID)
+	sm.logger.Info("Stopping server", "server_id", serverID)
 
 	// Kill the process
 	if err := server.Cmd.Process.Kill(); err != nil {
@@ -297,7 +312,7 @@ func (sm *ServerManager) StopAll() {
 
 	for id, server := range sm.servers {
 		if server.IsRunning && server.Cmd != nil && server.Cmd.Process != nil {
-			sm.logger.Info("Stopping server", "serverID", id)
+			sm.logger.Info("Stopping server", "server_id", id)
 			server.Cmd.Process.Kill()
 			server.IsRunning = false
 		}
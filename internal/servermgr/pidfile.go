@@ -0,0 +1,135 @@
+package servermgr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pidLockTimeout is how long PID file access waits for another command, e.g. a start --all
+// still launching the server, before giving up
+const pidLockTimeout = 30 * time.Second
+
+// lockPIDPath takes an exclusive advisory lock on the PID file at path, through a <path>.lock
+// next to it, waiting up to timeout for another process or goroutine holding it. The lock is
+// held by the open file and released by the returned function or when the process exits.
+func lockPIDPath(path string, timeout time.Duration) (func(), error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create PID lock directory: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open PID lock: %w", err)
+		}
+
+		err = lockFile(file)
+		if err == nil {
+			// removePIDLock deletes the lock file along with the PID file; a lock taken on a
+			// file that was deleted meanwhile guards nothing, so start over on the new one
+			if isLockFileCurrent(file, lockPath) {
+				return func() {
+					unlockFile(file)
+					file.Close()
+				}, nil
+			}
+			unlockFile(file)
+			file.Close()
+			continue
+		}
+		file.Close()
+		if !errors.Is(err, errLocked) {
+			return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s, another command is using this server", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// isLockFileCurrent reports whether the open lock file is still the one at lockPath
+func isLockFileCurrent(file *os.File, lockPath string) bool {
+	opened, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(lockPath)
+	return err == nil && os.SameFile(opened, current)
+}
+
+// removePIDLock removes the <path>.lock of a PID file once the PID file itself is gone, so
+// stopped servers leave nothing behind. Callers hold the lock; waiters notice the file was
+// removed and lock the next one. Windows refuses to remove the open file, which leaves it for
+// the next start to reuse.
+func removePIDLock(path string) {
+	os.Remove(path + ".lock")
+}
+
+// writePIDFile writes pid to path atomically: readers see the old file or the new one, never
+// a partly written PID
+func writePIDFile(path string, pid int) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.Itoa(pid)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readPIDFile reads the PID written by writePIDFile
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file format: %q", data)
+	}
+	return pid, nil
+}
+
+// isServerProcessName reports whether a process name (or executable path) is an Insurgency
+// server, e.g. InsurgencyServer-Win64-Shipping.exe. Linux's ps truncates names to 15
+// characters, so the prefix checked is "InsurgencyServe".
+func isServerProcessName(name string) bool {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	return strings.HasPrefix(strings.ToLower(name), "insurgencyserve")
+}
+
+// isServerProcess reports whether pid is a running Insurgency server. A PID file can outlive
+// its server, and the OS can hand the PID to an unrelated process, which must not be
+// mistaken for the server or stopped in its place.
+func isServerProcess(pid int) bool {
+	name, err := processName(pid)
+	return err == nil && isServerProcessName(name)
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package servermgr
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var errLocked = syscall.EWOULDBLOCK
+
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errLocked
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// processName returns the name of the running process pid. A variable so tests can
+// stand in for a running server.
+var processName = func(pid int) (string, error) {
+	output, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(string(output))
+	if name == "" {
+		return "", errors.New("process not found")
+	}
+	return name, nil
+}
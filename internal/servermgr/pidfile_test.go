@@ -0,0 +1,176 @@
+package servermgr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWritePIDFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server-1.pid")
+
+	if err := writePIDFile(path, 1234); err != nil {
+		t.Fatalf("writePIDFile() error = %v", err)
+	}
+	if err := writePIDFile(path, 56789); err != nil {
+		t.Fatalf("writePIDFile() error = %v", err)
+	}
+
+	pid, err := readPIDFile(path)
+	if err != nil {
+		t.Fatalf("readPIDFile() error = %v", err)
+	}
+	if pid != 56789 {
+		t.Errorf("readPIDFile() = %d, want 56789", pid)
+	}
+
+	// The temporary file is renamed over the PID file, leaving nothing else behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("directory holds %v, want only the PID file", names)
+	}
+
+	// A PID file cut short, as the plain writes before could leave it
+	if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPIDFile(path); err == nil {
+		t.Error("readPIDFile() accepted an empty PID file")
+	}
+}
+
+func TestLockPIDPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server-1.pid")
+
+	unlock, err := lockPIDPath(path, time.Second)
+	if err != nil {
+		t.Fatalf("lockPIDPath() error = %v", err)
+	}
+
+	if _, err := lockPIDPath(path, 100*time.Millisecond); err == nil {
+		t.Fatal("lockPIDPath() took a lock that is already held")
+	}
+
+	// A waiter gets the lock once it is released
+	release := unlock
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release()
+	}()
+	unlock, err = lockPIDPath(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("lockPIDPath() after release error = %v", err)
+	}
+
+	// A waiter whose lock file is removed by the holder locks the next one, which still
+	// keeps everyone else out
+	locked := make(chan func())
+	go func() {
+		unlockNext, err := lockPIDPath(path, 5*time.Second)
+		if err != nil {
+			t.Errorf("lockPIDPath() after removal error = %v", err)
+		}
+		locked <- unlockNext
+	}()
+	time.Sleep(100 * time.Millisecond)
+	removePIDLock(path)
+	unlock()
+	unlock = <-locked
+	if unlock == nil {
+		return
+	}
+	if _, err := lockPIDPath(path, 100*time.Millisecond); err == nil {
+		t.Error("lockPIDPath() took a lock that is already held after the lock file was removed")
+	}
+	unlock()
+}
+
+func TestRemovePIDFile(t *testing.T) {
+	dataDir := t.TempDir()
+	plugin := &Plugin{config: Config{DataDir: dataDir}, servers: make(map[string]*ManagedServer)}
+
+	unlock, err := plugin.lockPIDFile("server-1")
+	if err != nil {
+		t.Fatalf("lockPIDFile() error = %v", err)
+	}
+	if err := plugin.savePIDFile("server-1", 1234); err != nil {
+		t.Fatalf("savePIDFile() error = %v", err)
+	}
+	if err := plugin.removePIDFile("server-1"); err != nil {
+		t.Fatalf("removePIDFile() error = %v", err)
+	}
+	unlock()
+
+	// Neither the PID file nor its lock is left behind
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("data directory holds %d files after removePIDFile, want none", len(entries))
+	}
+}
+
+func TestStartServer_AlreadyRunning(t *testing.T) {
+	// Stand the test process in for a running server
+	serverPID := os.Getpid()
+	realProcessName := processName
+	processName = func(pid int) (string, error) {
+		if pid == serverPID {
+			return "InsurgencyServer-Linux-Shipping", nil
+		}
+		return realProcessName(pid)
+	}
+	defer func() { processName = realProcessName }()
+
+	plugin := &Plugin{config: Config{DataDir: t.TempDir()}, servers: make(map[string]*ManagedServer)}
+	if err := plugin.savePIDFile("server-1", serverPID); err != nil {
+		t.Fatalf("savePIDFile() error = %v", err)
+	}
+
+	err := plugin.StartServer("server-1", validSAWConfig(), t.TempDir(), false)
+	want := fmt.Sprintf("server server-1 is already running (PID: %d)", serverPID)
+	if err == nil || err.Error() != want {
+		t.Fatalf("StartServer() error = %v, want %q", err, want)
+	}
+	if pid, err := plugin.loadPIDFile("server-1"); err != nil || pid != serverPID {
+		t.Errorf("loadPIDFile() = %d, %v, want the running server's PID kept", pid, err)
+	}
+}
+
+func TestIsServerProcessName(t *testing.T) {
+	cases := map[string]bool{
+		`C:\SAW\sandstorm-server\Insurgency\Binaries\Win64\InsurgencyServer-Win64-Shipping.exe`: true,
+		"InsurgencyServer-Linux-Shipping": true,
+		"InsurgencyServe":                 true, // truncated by ps on Linux
+		"insurgencyserver-win64-shipping": true,
+		"chrome.exe":                      false,
+		"servermgr.test":                  false,
+		"":                                false,
+	}
+	for name, want := range cases {
+		if got := isServerProcessName(name); got != want {
+			t.Errorf("isServerProcessName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestIsServerProcess_RejectsOtherProcess(t *testing.T) {
+	// The test binary is running but is not an Insurgency server, like a PID the OS recycled
+	if isServerProcess(os.Getpid()) {
+		t.Error("isServerProcess() trusted a process that is not an Insurgency server")
+	}
+	if _, err := processName(os.Getpid()); err != nil {
+		t.Errorf("processName() of the test process error = %v", err)
+	}
+}
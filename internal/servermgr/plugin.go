@@ -2,13 +2,18 @@ package servermgr
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 
+	"sandstorm-tracker/internal/adminauth"
+	"sandstorm-tracker/internal/logger"
+
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/spf13/cobra"
@@ -18,6 +23,25 @@ import (
 type Config struct {
 	// DefaultSAWPath is the default path to Sandstorm Admin Wrapper installation
 	DefaultSAWPath string
+
+	// ConsoleLogDir captures the stdout/stderr of detached servers into
+	// <dir>/<serverID>.stdout.log and .stderr.log (empty = not captured)
+	ConsoleLogDir string
+
+	// ConsoleLogRotation controls console log rotation on server start (zero = DefaultConsoleLogRotation)
+	ConsoleLogRotation logger.RotationPolicy
+
+	// KnownMutators and KnownRuleSets extend the catalog configs are checked against
+	// (mod mutators, custom rulesets); unknown names only produce warnings
+	KnownMutators []string
+	KnownRuleSets []string
+
+	// DataDir holds the PID files and the configs servers were last started with (empty = "data")
+	DataDir string
+
+	// Logger receives the plugin's logs (nil = app.Logger()); pass the application's logger so
+	// they share its format
+	Logger *slog.Logger
 }
 
 // Plugin manages Insurgency server processes as a PocketBase plugin
@@ -62,6 +86,22 @@ func Register(app core.App, rootCmd *cobra.Command, config Config) (*Plugin, err
 	return p, nil
 }
 
+// dataDir returns the configured data directory, or "data"
+func (p *Plugin) dataDir() string {
+	if p.config.DataDir != "" {
+		return p.config.DataDir
+	}
+	return "data"
+}
+
+// logger returns the configured logger, or the app's
+func (p *Plugin) logger() *slog.Logger {
+	if p.config.Logger != nil {
+		return p.config.Logger
+	}
+	return p.app.Logger()
+}
+
 // registerCommands adds server management CLI commands
 func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 	serverCmd := &cobra.Command{
@@ -79,6 +119,7 @@ func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 			showLogs, _ := cmd.Flags().GetBool("logs")
 			sawPath, _ := cmd.Flags().GetString("saw-path")
 			startAll, _ := cmd.Flags().GetBool("all")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 			if sawPath == "" {
 				sawPath = p.config.DefaultSAWPath
@@ -99,6 +140,26 @@ func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 
 			// Start all servers if --all flag is set
 			if startAll {
+				// Refuse to start anything while a config is broken or a port is taken
+				isRunning := func(serverID string) bool {
+					pid, err := p.loadPIDFile(serverID)
+					return err == nil && p.isProcessRunning(pid)
+				}
+				if err := PreflightStartAll(configs, isRunning); err != nil {
+					return err
+				}
+
+				if dryRun {
+					for _, serverID := range sortedKeys(configs) {
+						command, err := p.DryRunServer(serverID, configs[serverID], sawPath, false)
+						if err != nil {
+							return err
+						}
+						PrintLaunchCommand(serverID, command, false)
+					}
+					return nil
+				}
+
 				fmt.Printf("Starting %d server(s)...\n", len(configs))
 				successCount := 0
 				failCount := 0
@@ -144,6 +205,15 @@ func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 				return fmt.Errorf("no servers found in SAW configuration")
 			}
 
+			if dryRun {
+				command, err := p.DryRunServer(serverID, serverConfig, sawPath, showLogs)
+				if err != nil {
+					return err
+				}
+				PrintLaunchCommand(serverID, command, showLogs)
+				return nil
+			}
+
 			fmt.Printf("Starting server: %s\n", serverID)
 			if showLogs {
 				fmt.Println("Server logs will be displayed in console (Press Ctrl+C to stop)")
@@ -157,6 +227,7 @@ func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 	startCmd.Flags().Bool("logs", false, "Show server logs in console (default: log to file)")
 	startCmd.Flags().Bool("all", false, "Start all servers from SAW configuration")
 	startCmd.Flags().String("saw-path", "", "Path to Sandstorm Admin Wrapper installation")
+	startCmd.Flags().Bool("dry-run", false, "Print the executable, arguments and working directory without starting anything")
 
 	// server stop command
 	stopCmd := &cobra.Command{
@@ -193,19 +264,13 @@ func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 
 				for serverID := range configs {
 					// Check if PID file exists (server might not be running)
-					pid, err := p.loadPIDFile(serverID)
-					if err != nil {
+					if _, err := p.loadPIDFile(serverID); err != nil {
 						// No PID file, skip
 						continue
 					}
 
-					// Check if the process is actually running
-					if !p.isProcessRunning(pid) {
-						// Clean up stale PID file
-						p.app.Logger().Info("Cleaning up stale PID file", "serverID", serverID, "pid", pid)
-						if err := p.removePIDFile(serverID); err != nil {
-							p.app.Logger().Warn("Failed to remove stale PID file", "error", err)
-						}
+					// Clean up a stale PID file, unless a start is writing a new one
+					if p.removeStalePIDFile(serverID) {
 						staleCount++
 						continue
 					}
@@ -437,7 +502,32 @@ func (p *Plugin) registerCommands(rootCmd *cobra.Command) {
 	updateGameCmd.Flags().Bool("validate", false, "Validate all server files (slower but more thorough)")
 	updateGameCmd.Flags().Bool("force", false, "Force update even if servers are running (not recommended)")
 
-	serverCmd.AddCommand(startCmd, stopCmd, statusCmd, listCmd, updateSteamCmdCmd, updateGameCmd)
+	// server diff command
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show what changed in the SAW configuration since servers were started",
+		Long:  "Compare server-configs.json to the config each server was last started with, listing added and removed servers and changed fields.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sawPath, _ := cmd.Flags().GetString("saw-path")
+			if sawPath == "" {
+				sawPath = p.config.DefaultSAWPath
+			}
+			if sawPath == "" {
+				return fmt.Errorf("SAW path not provided. Use --saw-path flag or set sawPath in config")
+			}
+
+			diff, err := p.DiffSAWConfigs(sawPath)
+			if err != nil {
+				return fmt.Errorf("failed to diff SAW configs: %w", err)
+			}
+
+			PrintConfigDiff(diff)
+			return nil
+		},
+	}
+	diffCmd.Flags().String("saw-path", "", "Path to Sandstorm Admin Wrapper installation")
+
+	serverCmd.AddCommand(startCmd, stopCmd, statusCmd, listCmd, diffCmd, updateSteamCmdCmd, updateGameCmd)
 	rootCmd.AddCommand(serverCmd)
 }
 
@@ -449,6 +539,7 @@ func (p *Plugin) registerRoutes(e *core.ServeEvent) error {
 			ServerID string `json:"server_id"`
 			SAWPath  string `json:"saw_path"`
 			ShowLogs bool   `json:"show_logs"`
+			DryRun   bool   `json:"dry_run"`
 		}{}
 
 		if err := re.BindBody(&data); err != nil {
@@ -477,7 +568,28 @@ func (p *Plugin) registerRoutes(e *core.ServeEvent) error {
 			return re.NotFoundError("Server ID not found", nil)
 		}
 
+		if data.DryRun {
+			command, err := p.DryRunServer(data.ServerID, config, sawPath, data.ShowLogs)
+			if err != nil {
+				var validationErr *ValidationError
+				if errors.As(err, &validationErr) {
+					return re.BadRequestError(validationErr.Error(), nil)
+				}
+				return re.InternalServerError("Failed to build server command", err)
+			}
+			return re.JSON(200, map[string]any{
+				"success":      true,
+				"dry_run":      true,
+				"command":      command,
+				"command_line": command.CommandLine(),
+			})
+		}
+
 		if err := p.StartServer(data.ServerID, config, sawPath, data.ShowLogs); err != nil {
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				return re.BadRequestError(validationErr.Error(), nil)
+			}
 			return re.InternalServerError("Failed to start server", err)
 		}
 
@@ -485,7 +597,7 @@ func (p *Plugin) registerRoutes(e *core.ServeEvent) error {
 			"success": true,
 			"message": "Server started successfully",
 		})
-	})
+	}).Bind(adminauth.RequireAdmin())
 
 	// POST /api/server/stop - Stop a server
 	e.Router.POST("/api/server/stop", func(re *core.RequestEvent) error {
@@ -515,7 +627,7 @@ func (p *Plugin) registerRoutes(e *core.ServeEvent) error {
 			"success": true,
 			"message": "Server stopped successfully",
 		})
-	})
+	}).Bind(adminauth.RequireAdmin())
 
 	// GET /api/server/status - Get status of all managed servers
 	e.Router.GET("/api/server/status", func(re *core.RequestEvent) error {
@@ -523,7 +635,7 @@ func (p *Plugin) registerRoutes(e *core.ServeEvent) error {
 		return re.JSON(200, map[string]any{
 			"servers": servers,
 		})
-	})
+	}).Bind(adminauth.RequireAdmin())
 
 	// GET /api/server/list - List available servers from SAW
 	e.Router.GET("/api/server/list", func(re *core.RequestEvent) error {
@@ -556,13 +668,39 @@ func (p *Plugin) registerRoutes(e *core.ServeEvent) error {
 		return re.JSON(200, map[string]any{
 			"servers": serverList,
 		})
-	})
+	}).Bind(adminauth.RequireAdmin())
+
+	// GET /api/server/diff - Compare the configured SAW install's configs to the configs servers
+	// were last started with
+	e.Router.GET("/api/server/diff", func(re *core.RequestEvent) error {
+		sawPath := p.config.DefaultSAWPath
+		if sawPath == "" {
+			return re.BadRequestError("No SAW path is configured", nil)
+		}
+
+		diff, err := p.DiffSAWConfigs(sawPath)
+		if err != nil {
+			return re.BadRequestError("Failed to diff SAW configs", err)
+		}
+
+		return re.JSON(200, map[string]any{
+			"has_changes": diff.HasChanges(),
+			"added":       diff.Added,
+			"removed":     diff.Removed,
+			"changed":     diff.Changed,
+		})
+	}).Bind(adminauth.RequireAdmin())
 
 	return e.Next()
 }
 
 // LoadSAWConfigs loads server configurations from SAW installation
 func (p *Plugin) LoadSAWConfigs(sawPath string) (map[string]SAWServerConfig, error) {
+	return LoadSAWConfigs(sawPath)
+}
+
+// LoadSAWConfigs reads the server configurations from SAW's server-configs.json, by server ID
+func LoadSAWConfigs(sawPath string) (map[string]SAWServerConfig, error) {
 	sawPath = strings.ReplaceAll(sawPath, "\\", "/")
 
 	configPath := filepath.Join(sawPath, "admin-interface", "config", "server-configs.json")
@@ -580,70 +718,123 @@ func (p *Plugin) LoadSAWConfigs(sawPath string) (map[string]SAWServerConfig, err
 }
 
 // getPIDFilePath returns the path to the PID file for a server
-// PID files are stored in ./data directory next to the executable
+// PID files are stored in the data directory (./data unless configured)
 func (p *Plugin) getPIDFilePath(serverID string) string {
-	dataDir := "data"
+	dataDir := p.dataDir()
 	os.MkdirAll(dataDir, 0755)
 	return filepath.Join(dataDir, fmt.Sprintf("%s.pid", serverID))
 }
 
-// savePIDFile saves the server's PID to a file
+// lockPIDFile takes the lock guarding a server's PID file, so a start, stop or stale PID
+// cleanup in this or another process (e.g. start --all racing stop --all) sees and changes it
+// as a whole. Take it before p.mu.
+func (p *Plugin) lockPIDFile(serverID string) (func(), error) {
+	return lockPIDPath(p.getPIDFilePath(serverID), pidLockTimeout)
+}
+
+// savePIDFile saves the server's PID to a file, atomically
 func (p *Plugin) savePIDFile(serverID string, pid int) error {
-	pidFile := p.getPIDFilePath(serverID)
-	pidData := fmt.Sprintf("%d", pid)
-	return os.WriteFile(pidFile, []byte(pidData), 0644)
+	return writePIDFile(p.getPIDFilePath(serverID), pid)
 }
 
 // loadPIDFile loads the server's PID from a file
 func (p *Plugin) loadPIDFile(serverID string) (int, error) {
-	pidFile := p.getPIDFilePath(serverID)
-	data, err := os.ReadFile(pidFile)
-	if err != nil {
-		return 0, err
-	}
-
-	var pid int
-	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil {
-		return 0, fmt.Errorf("invalid PID file format: %w", err)
-	}
-
-	return pid, nil
+	return readPIDFile(p.getPIDFilePath(serverID))
 }
 
-// removePIDFile removes the PID file for a server
+// removePIDFile removes the PID file for a server, and its lock file
 func (p *Plugin) removePIDFile(serverID string) error {
 	pidFile := p.getPIDFilePath(serverID)
 	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	removePIDLock(pidFile)
 	return nil
 }
 
-// isProcessRunning checks if a process with the given PID is currently running
-func (p *Plugin) isProcessRunning(pid int) bool {
-	// Use PowerShell to check if the process exists
-	psCmd := fmt.Sprintf("Get-Process -Id %d -ErrorAction SilentlyContinue | Select-Object Id", pid)
-	cmd := exec.Command("powershell", "-Command", psCmd)
-	output, err := cmd.Output()
+// removeStalePIDFile removes a server's PID file when its process is no longer running,
+// reporting whether it did. The PID is checked under the PID file lock, so a server that was
+// just started does not lose its PID file.
+func (p *Plugin) removeStalePIDFile(serverID string) bool {
+	unlock, err := p.lockPIDFile(serverID)
 	if err != nil {
+		p.logger().Warn("Failed to lock PID file", "server_id", serverID, "error", err)
+		return false
+	}
+	defer unlock()
+
+	pid, err := p.loadPIDFile(serverID)
+	if err != nil || p.isProcessRunning(pid) {
 		return false
 	}
 
-	// If output is not empty (besides whitespace), process exists
-	return len(strings.TrimSpace(string(output))) > 0
+	p.logger().Info("Cleaning up stale PID file", "server_id", serverID, "pid", pid)
+	if err := p.removePIDFile(serverID); err != nil {
+		p.logger().Warn("Failed to remove stale PID file", "error", err)
+	}
+	return true
+}
+
+// isProcessRunning checks if the process with the given PID is a running Insurgency server
+func (p *Plugin) isProcessRunning(pid int) bool {
+	return isServerProcess(pid)
+}
+
+// runningConfigs returns the configs of the running servers other than serverID.
+// Caller must hold p.mu (read or write).
+func (p *Plugin) runningConfigs(serverID string) map[string]SAWServerConfig {
+	running := make(map[string]SAWServerConfig)
+	for id, server := range p.servers {
+		if id != serverID && server.IsRunning {
+			running[id] = server.Config
+		}
+	}
+	return running
+}
+
+// DryRunServer validates a server config and returns the command StartServer would run,
+// without applying config files, spawning the process or writing a PID file
+func (p *Plugin) DryRunServer(serverID string, config SAWServerConfig, sawPath string, showLogs bool) (*LaunchCommand, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if err := validateForStart(serverID, config, p.runningConfigs(serverID)); err != nil {
+		return nil, err
+	}
+
+	command, err := BuildLaunchCommand(serverID, config, sawPath, showLogs)
+	if err != nil {
+		return nil, err
+	}
+	if !showLogs && p.config.ConsoleLogDir != "" {
+		command.StdoutPath, command.StderrPath = ConsoleLogPaths(p.config.ConsoleLogDir, serverID)
+	}
+	command.Warnings = append(command.Warnings, p.catalog().Warnings(config)...)
+	return command, nil
+}
+
+// catalog returns the mutators and rulesets configs are checked against
+func (p *Plugin) catalog() Catalog {
+	return NewCatalog(p.config.KnownMutators, p.config.KnownRuleSets)
 }
 
 // StartServer starts an Insurgency server
 func (p *Plugin) StartServer(serverID string, config SAWServerConfig, sawPath string, showLogs bool) error {
+	unlock, err := p.lockPIDFile(serverID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	// Check for stale PID file and clean it up
 	if pid, err := p.loadPIDFile(serverID); err == nil {
 		if !p.isProcessRunning(pid) {
-			p.app.Logger().Info("Cleaning up stale PID file before starting", "serverID", serverID, "pid", pid)
+			p.logger().Info("Cleaning up stale PID file before starting", "server_id", serverID, "pid", pid)
 			if err := p.removePIDFile(serverID); err != nil {
-				p.app.Logger().Warn("Failed to remove stale PID file", "error", err)
+				p.logger().Warn("Failed to remove stale PID file", "error", err)
 			}
 		} else {
 			// Process is actually running
@@ -655,93 +846,21 @@ func (p *Plugin) StartServer(serverID string, config SAWServerConfig, sawPath st
 		return fmt.Errorf("server %s is already running", serverID)
 	}
 
-	sawPath = strings.ReplaceAll(sawPath, "\\", "/")
-
-	serverExe := os.Getenv("INSURGENCY_SERVER_PATH")
-	if serverExe == "" {
-		serverExe = filepath.Join(sawPath, "sandstorm-server", "Insurgency", "Binaries", "Win64", "InsurgencyServer-Win64-Shipping.exe")
-	}
-
-	absServerExe, err := filepath.Abs(serverExe)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path for server executable: %w", err)
-	}
-	serverExe = absServerExe
-
-	if _, err := os.Stat(serverExe); os.IsNotExist(err) {
-		return fmt.Errorf("server executable not found at: %s", serverExe)
-	}
-
-	// Build scenario name - for Checkpoint and Push modes, include the side
-	scenarioName := fmt.Sprintf("Scenario_%s_%s", config.ServerDefaultMap, config.ServerScenarioMode)
-	if config.ServerScenarioMode == "Checkpoint" || config.ServerScenarioMode == "Push" {
-		scenarioName += "_" + config.ServerDefaultSide
-	}
-	travelArgs := config.ServerDefaultMap + "?Scenario=" + scenarioName
-
-	if config.ServerMaxPlayers != "" {
-		travelArgs += "?MaxPlayers=" + config.ServerMaxPlayers
-	}
-
-	// Add Game mode if specified
-	if config.ServerGameMode != "" && config.ServerGameMode != "None" {
-		travelArgs += "?Game=" + config.ServerGameMode
-	}
-
-	// Add password if specified
-	if config.ServerPassword != "" {
-		travelArgs += "?Password=" + config.ServerPassword
-	}
-
-	if config.ServerLightingDay == "true" {
-		travelArgs += "?Lighting=Day"
-	} else {
-		travelArgs += "?Lighting=Night"
-	}
-
-	if config.ServerCustomTravelArgs != "" {
-		travelArgs += "?" + config.ServerCustomTravelArgs
-	}
-
-	args := []string{
-		travelArgs,
-		"-Hostname=" + config.ServerHostname,
-		"-MaxPlayers=" + config.ServerMaxPlayers,
-		"-Port=" + config.ServerGamePort,
-		"-QueryPort=" + config.ServerQueryPort,
-		"-LogCmds=LogGameplayEvents Log",
-		"-LOCALLOGTIMES",
-		"-AdminList=Admins",
-		"-MapCycle=MapCycle",
-	}
-
-	if showLogs {
-		args = append(args, "-stdout")
-	} else {
-		args = append(args, "-log="+serverID+".log")
-	}
-
-	if len(config.ServerMutators) > 0 {
-		mutators := strings.Join(config.ServerMutators, ",")
-		args = append(args, "-Mutators="+mutators)
-	}
-	if config.ServerMutatorsCustom != "" {
-		args = append(args, "-Mutators="+config.ServerMutatorsCustom)
-	}
-
-	if config.ServerCheats == "true" {
-		args = append(args, "-CmdServerCheats")
+	if err := validateForStart(serverID, config, p.runningConfigs(serverID)); err != nil {
+		return err
 	}
-
-	if config.ServerCustomServerArgs != "" {
-		customArgs := strings.Fields(config.ServerCustomServerArgs)
-		args = append(args, customArgs...)
+	for _, warning := range p.catalog().Warnings(config) {
+		p.logger().Warn("Server config warning", "server_id", serverID, "warning", warning)
 	}
 
-	absSAWPath, err := filepath.Abs(sawPath)
+	command, err := BuildLaunchCommand(serverID, config, sawPath, showLogs)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path for SAW: %w", err)
+		return err
 	}
+	for _, warning := range command.Warnings {
+		p.logger().Warn("Server config warning", "server_id", serverID, "warning", warning)
+	}
+	absSAWPath := command.WorkDir
 
 	// Apply server configuration before starting
 	// SAW uses sandstorm-server/Insurgency/Saved for all server instances
@@ -749,30 +868,29 @@ func (p *Plugin) StartServer(serverID string, config SAWServerConfig, sawPath st
 	localConfigDir := filepath.Join(absSAWPath, "server-config", serverID)
 
 	if err := p.applyServerConfig(serverInstancePath, localConfigDir); err != nil {
-		p.app.Logger().Warn("Failed to apply server config", "error", err)
+		p.logger().Warn("Failed to apply server config", "error", err)
 		// Continue anyway - server might work with defaults
 	}
 
-	p.app.Logger().Info("Starting Insurgency server",
-		"serverID", serverID,
+	p.logger().Info("Starting Insurgency server",
+		"server_id", serverID,
 		"name", config.ServerHostname,
-		"executable", serverExe,
+		"executable", command.Executable,
 		"workDir", absSAWPath,
 	)
 
 	// For servers without console logs, use PowerShell Start-Process to detach
 	// This ensures the server keeps running after our process exits
 	if !showLogs {
-		// Build the argument string for PowerShell
-		argString := strings.Join(args, " ")
+		if p.config.ConsoleLogDir != "" {
+			if err := CaptureConsoleLogs(command, p.config.ConsoleLogDir, serverID, p.config.ConsoleLogRotation); err != nil {
+				p.logger().Warn("Failed to capture server console output", "error", err)
+				// Continue anyway - the game's own -log file is still written
+			}
+		}
 
 		// Start process and capture PID
-		psCmd := fmt.Sprintf(`
-			$proc = Start-Process -FilePath '%s' -ArgumentList '%s' -WorkingDirectory '%s' -WindowStyle Hidden -PassThru
-			Write-Output $proc.Id
-		`, serverExe, argString, absSAWPath)
-
-		cmd := exec.Command("powershell", "-Command", psCmd)
+		cmd := command.PowerShellCommand()
 		output, err := cmd.Output()
 		if err != nil {
 			return fmt.Errorf("failed to start server: %w", err)
@@ -782,21 +900,21 @@ func (p *Plugin) StartServer(serverID string, config SAWServerConfig, sawPath st
 		pidStr := strings.TrimSpace(string(output))
 		var pid int
 		if _, err := fmt.Sscanf(pidStr, "%d", &pid); err != nil {
-			p.app.Logger().Warn("Failed to parse server PID", "output", pidStr)
+			p.logger().Warn("Failed to parse server PID", "output", pidStr)
 		} else {
 			// Save PID to file for tracking
 			if err := p.savePIDFile(serverID, pid); err != nil {
-				p.app.Logger().Warn("Failed to save PID file", "error", err)
+				p.logger().Warn("Failed to save PID file", "error", err)
 			}
 		}
 
-		p.app.Logger().Info("Server started in detached mode", "pid", pid)
+		p.logger().Info("Server started in detached mode", "pid", pid)
+		p.saveAppliedConfig(serverID, config)
 		return nil
 	}
 
 	// For console logs, use regular exec (server will stop when command exits)
-	cmd := exec.Command(serverExe, args...)
-	cmd.Dir = absSAWPath
+	cmd := command.ForegroundCommand()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -813,10 +931,32 @@ func (p *Plugin) StartServer(serverID string, config SAWServerConfig, sawPath st
 	}
 
 	go p.monitorServer(serverID, cmd)
+	p.saveAppliedConfig(serverID, config)
 
 	return nil
 }
 
+// saveAppliedConfig records the config a server was started with for DiffSAWConfigs.
+// It is kept in the data directory next to the PID files.
+func (p *Plugin) saveAppliedConfig(serverID string, config SAWServerConfig) {
+	if err := SaveAppliedConfig(p.dataDir(), serverID, config); err != nil {
+		p.logger().Warn("Failed to save applied server config", "server_id", serverID, "error", err)
+	}
+}
+
+// DiffSAWConfigs compares server-configs.json to the configs the servers were last started with
+func (p *Plugin) DiffSAWConfigs(sawPath string) (ConfigDiff, error) {
+	current, err := p.LoadSAWConfigs(sawPath)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	applied, err := LoadAppliedConfigs(p.dataDir())
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	return DiffConfigs(applied, current), nil
+}
+
 // monitorServer monitors a server process and updates status when it exits
 func (p *Plugin) monitorServer(serverID string, cmd *exec.Cmd) {
 	err := cmd.Wait()
@@ -827,41 +967,47 @@ func (p *Plugin) monitorServer(serverID string, cmd *exec.Cmd) {
 	if server, exists := p.servers[serverID]; exists {
 		server.IsRunning = false
 		if err != nil {
-			p.app.Logger().Error("Server exited with error", "serverID", serverID, "error", err)
+			p.logger().Error("Server exited with error", "server_id", serverID, "error", err)
 		} else {
-			p.app.Logger().Info("Server stopped", "serverID", serverID)
+			p.logger().Info("Server stopped", "server_id", serverID)
 		}
 	}
 }
 
 // StopServer stops a running server
 func (p *Plugin) StopServer(serverID string, sawPath string) error {
+	unlock, err := p.lockPIDFile(serverID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// First try to get PID from file
 	pid, err := p.loadPIDFile(serverID)
 	if err == nil {
 		// Check if the process is actually running
 		if !p.isProcessRunning(pid) {
-			p.app.Logger().Info("Process not running, cleaning up stale PID file", "serverID", serverID, "pid", pid)
+			p.logger().Info("Process not running, cleaning up stale PID file", "server_id", serverID, "pid", pid)
 			// Remove stale PID file
 			if err := p.removePIDFile(serverID); err != nil {
-				p.app.Logger().Warn("Failed to remove stale PID file", "error", err)
+				p.logger().Warn("Failed to remove stale PID file", "error", err)
 			}
 			return fmt.Errorf("server %s is not running (stale PID file cleaned up)", serverID)
 		}
 
 		// PID file exists and process is running, kill it
-		p.app.Logger().Info("Stopping server via PID file", "serverID", serverID, "pid", pid)
+		p.logger().Info("Stopping server via PID file", "server_id", serverID, "pid", pid)
 
 		// Use PowerShell to kill the process
 		psCmd := fmt.Sprintf("Stop-Process -Id %d -Force -ErrorAction SilentlyContinue", pid)
 		cmd := exec.Command("powershell", "-Command", psCmd)
 		if err := cmd.Run(); err != nil {
-			p.app.Logger().Warn("Failed to kill process via PID", "pid", pid, "error", err)
+			p.logger().Warn("Failed to kill process via PID", "pid", pid, "error", err)
 		}
 
 		// Remove PID file
 		if err := p.removePIDFile(serverID); err != nil {
-			p.app.Logger().Warn("Failed to remove PID file", "error", err)
+			p.logger().Warn("Failed to remove PID file", "error", err)
 		}
 
 		// Update in-memory state
@@ -891,7 +1037,7 @@ func (p *Plugin) StopServer(serverID string, sawPath string) error {
 		return fmt.Errorf("server %s has no process", serverID)
 	}
 
-	p.app.Logger().Info("Stopping server", "serverID", serverID)
+	p.logger().Info("Stopping server", "server_id", serverID)
 
 	if err := server.Cmd.Process.Kill(); err != nil {
 		return fmt.Errorf("failed to kill server process: %w", err)
@@ -933,7 +1079,7 @@ func (p *Plugin) StopAll() {
 
 	for id, server := range p.servers {
 		if server.IsRunning && server.Cmd != nil && server.Cmd.Process != nil {
-			p.app.Logger().Info("Stopping server", "serverID", id)
+			p.logger().Info("Stopping server", "server_id", id)
 			server.Cmd.Process.Kill()
 			server.IsRunning = false
 		}
@@ -949,15 +1095,6 @@ func GetPlugin(app *pocketbase.PocketBase) *Plugin {
 
 // applyServerConfig copies configuration files to the server instance directory
 func (p *Plugin) applyServerConfig(serverInstancePath, localConfigDir string) error {
-	configFiles := []string{
-		"Game.ini",
-		"Engine.ini",
-		"Admins.txt",
-		"Bans.txt",
-		"MapCycle.txt",
-		"Motd.txt",
-	}
-
 	// Ensure local config directory exists
 	if err := os.MkdirAll(localConfigDir, 0755); err != nil {
 		return fmt.Errorf("failed to create local config directory: %w", err)
@@ -983,23 +1120,23 @@ func (p *Plugin) applyServerConfig(serverInstancePath, localConfigDir string) er
 	}
 
 	// Copy each config file from local storage to server instance
-	for _, filename := range configFiles {
+	for _, filename := range ManagedServerFiles {
 		localFile := filepath.Join(localConfigDir, filename)
 		serverFile := filepath.Join(configBasePath, filename)
 
 		// If local file doesn't exist, create an empty one
 		if _, err := os.Stat(localFile); os.IsNotExist(err) {
 			if err := os.WriteFile(localFile, []byte{}, 0644); err != nil {
-				p.app.Logger().Warn("Failed to create config file", "file", filename, "error", err)
+				p.logger().Warn("Failed to create config file", "file", filename, "error", err)
 				continue
 			}
 		}
 
 		// Copy from local to server
 		if err := copyFile(localFile, serverFile); err != nil {
-			p.app.Logger().Warn("Failed to copy config file", "file", filename, "error", err)
+			p.logger().Warn("Failed to copy config file", "file", filename, "error", err)
 		} else {
-			p.app.Logger().Debug("Applied config file", "file", filename)
+			p.logger().Debug("Applied config file", "file", filename)
 		}
 	}
 
@@ -1082,7 +1219,7 @@ func (p *Plugin) UpdateSteamCMD(sawPath string) error {
 		return fmt.Errorf("steamcmd.exe not found at: %s", steamCmdPath)
 	}
 
-	p.app.Logger().Info("Updating SteamCMD", "path", steamCmdPath)
+	p.logger().Info("Updating SteamCMD", "path", steamCmdPath)
 
 	// Run steamcmd with +quit to update itself
 	cmd := exec.Command(steamCmdPath, "+quit")
@@ -1094,7 +1231,7 @@ func (p *Plugin) UpdateSteamCMD(sawPath string) error {
 		return fmt.Errorf("steamcmd update failed: %w", err)
 	}
 
-	p.app.Logger().Info("SteamCMD updated successfully")
+	p.logger().Info("SteamCMD updated successfully")
 	return nil
 }
 
@@ -1113,7 +1250,7 @@ func (p *Plugin) UpdateInsurgencyServer(sawPath string, validate bool) error {
 		return fmt.Errorf("failed to create server directory: %w", err)
 	}
 
-	p.app.Logger().Info("Updating Insurgency: Sandstorm server",
+	p.logger().Info("Updating Insurgency: Sandstorm server",
 		"steamcmd", steamCmdPath,
 		"serverPath", serverPath,
 		"validate", validate,
@@ -1146,6 +1283,6 @@ func (p *Plugin) UpdateInsurgencyServer(sawPath string, validate bool) error {
 		return fmt.Errorf("server update failed: %w", err)
 	}
 
-	p.app.Logger().Info("Insurgency: Sandstorm server updated successfully")
+	p.logger().Info("Insurgency: Sandstorm server updated successfully")
 	return nil
 }
@@ -18,12 +18,11 @@ func TestPIDFileOperations(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
-	// Use actual data directory - tests will use real PID files
-	// Clean up after tests
+	// PID files go to the temporary data directory
 	serverID := "test-server-123"
 	testPID := 12345
 	defer plugin.removePIDFile(serverID)
@@ -87,15 +86,15 @@ func TestIsProcessRunning(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
 	t.Run("CurrentProcess", func(t *testing.T) {
-		// Test with current process PID (should be running)
+		// The test process is running but is not an Insurgency server
 		currentPID := os.Getpid()
-		if !plugin.isProcessRunning(currentPID) {
-			t.Fatal("current process should be detected as running")
+		if plugin.isProcessRunning(currentPID) {
+			t.Fatal("current process should not be detected as a running server")
 		}
 	})
 
@@ -114,7 +113,7 @@ func TestGetRunningServerProcesses(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -140,7 +139,7 @@ func TestStopServerWithStalePID(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -183,7 +182,7 @@ func TestStartServerWithStalePID(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -236,45 +235,28 @@ func TestStartServerWithRunningProcess(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
 	serverID := "test-server-running"
 	defer plugin.removePIDFile(serverID)
 
-	t.Run("DetectRunningProcess", func(t *testing.T) {
-		// Use current process PID (definitely running)
+	t.Run("RecycledPID", func(t *testing.T) {
+		// A PID file pointing at a process that is not a server (here the test itself)
+		// is stale, as the PID was handed to another process after the server exited
 		currentPID := os.Getpid()
 
-		// Create PID file with current process
 		if err := plugin.savePIDFile(serverID, currentPID); err != nil {
 			t.Fatalf("failed to create PID file: %v", err)
 		}
 
-		config := SAWServerConfig{
-			ServerHostname:     "Test Server",
-			ServerDefaultMap:   "Ministry",
-			ServerScenarioMode: "Checkpoint",
-			ServerDefaultSide:  "Security",
-			ServerMaxPlayers:   "20",
-			ServerGamePort:     "27102",
-			ServerQueryPort:    "27131",
+		if !plugin.removeStalePIDFile(serverID) {
+			t.Fatal("expected the PID file of a non-server process to be removed as stale")
 		}
-
-		// Try to start server - should detect running process
-		err := plugin.StartServer(serverID, config, "", false)
-		if err == nil {
-			t.Fatal("expected error when server already running")
-		}
-
-		expectedError := fmt.Sprintf("server %s is already running (PID: %d)", serverID, currentPID)
-		if err.Error() != expectedError {
-			t.Fatalf("expected error '%s', got '%s'", expectedError, err.Error())
+		if _, err := plugin.loadPIDFile(serverID); err == nil {
+			t.Fatal("PID file still exists")
 		}
-
-		// Cleanup
-		plugin.removePIDFile(serverID)
 	})
 }
 
@@ -284,7 +266,7 @@ func TestProcessInfoParsing(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -304,7 +286,7 @@ func TestLoadSAWConfigs(t *testing.T) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: t.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -422,7 +404,7 @@ func BenchmarkPIDFileOperations(b *testing.B) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: b.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -464,7 +446,7 @@ func BenchmarkIsProcessRunning(b *testing.B) {
 
 	plugin := &Plugin{
 		app:     app,
-		config:  Config{},
+		config:  Config{DataDir: b.TempDir()},
 		servers: make(map[string]*ManagedServer),
 	}
 
@@ -0,0 +1,350 @@
+package servermgr
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"sandstorm-tracker/internal/logger"
+)
+
+// ManagedServerFiles are the files applyServerConfig copies from a server's directory under
+// <sawPath>/server-config into the server's Saved/Config on every start
+var ManagedServerFiles = []string{
+	"Game.ini",
+	"Engine.ini",
+	"Admins.txt",
+	"Bans.txt",
+	"MapCycle.txt",
+	"Motd.txt",
+}
+
+// ReadOnlyServerFiles are the ManagedServerFiles the tracker writes itself: Bans.txt is rewritten
+// from the bans collection, so edits made to it directly would be lost
+var ReadOnlyServerFiles = []string{"Bans.txt"}
+
+// ErrReadOnlyServerFile is returned by WriteServerFile for one of the ReadOnlyServerFiles
+var ErrReadOnlyServerFile = errors.New("file is written from the bans collection")
+
+// maxServerFileBackups is how many earlier versions of each file WriteServerFile keeps
+const maxServerFileBackups = 10
+
+// ServerFiles is what a server's directory under <sawPath>/server-config deploys: the files
+// applyServerConfig copies into the server on start, parsed, and the mutators it is launched with
+type ServerFiles struct {
+	ServerID string          `json:"server_id"`
+	Dir      string          `json:"dir"`
+	MapCycle []MapCycleEntry `json:"map_cycle"`
+	Motd     string          `json:"motd"`
+	Admins   []string        `json:"admins"`   // Steam IDs from Admins.txt
+	Mutators []string        `json:"mutators"` // From server-configs.json, empty when the server is not in it
+	Missing  []string        `json:"missing"`  // Files that do not exist yet; the server starts with them empty
+}
+
+// MapCycleEntry is one scenario of a MapCycle.txt
+type MapCycleEntry struct {
+	Scenario string `json:"scenario"`
+	Lighting string `json:"lighting,omitempty"` // Day or Night, empty for the scenario's default
+}
+
+// serverFileNames are the files read from a server's config directory
+var serverFileNames = []string{"MapCycle.txt", "Motd.txt", "Admins.txt"}
+
+// ReadServerFiles reads the config files deployed to a server from sawPath. Missing files are
+// listed rather than failing, as applyServerConfig creates them empty on the next start; only a
+// file that exists but cannot be read is an error.
+func ReadServerFiles(sawPath, serverID string) (*ServerFiles, error) {
+	dir, err := serverConfigDir(sawPath, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := &ServerFiles{
+		ServerID: serverID,
+		Dir:      dir,
+		MapCycle: []MapCycleEntry{},
+		Admins:   []string{},
+		Mutators: []string{},
+		Missing:  []string{},
+	}
+
+	contents := make(map[string]string, len(serverFileNames))
+	for _, name := range serverFileNames {
+		data, err := os.ReadFile(filepath.Join(files.Dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			files.Missing = append(files.Missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		contents[name] = string(data)
+	}
+
+	files.MapCycle = ParseMapCycle(contents["MapCycle.txt"])
+	files.Motd = strings.TrimSpace(strings.ReplaceAll(contents["Motd.txt"], "\r\n", "\n"))
+	files.Admins = configLines(contents["Admins.txt"])
+
+	// The mutators are launch arguments rather than a file; a missing or broken
+	// server-configs.json leaves them empty
+	if configs, err := LoadSAWConfigs(sawPath); err == nil {
+		if config, ok := configs[serverID]; ok {
+			if mutators := configMutators(config); mutators != nil {
+				files.Mutators = mutators
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// mapCycleField matches one Key="Value" pair of a MapCycle.txt entry
+var mapCycleField = regexp.MustCompile(`(\w+)\s*=\s*"?([^",)]*)"?`)
+
+// ParseMapCycle parses a MapCycle.txt. Each line is a scenario name, a travel URL such as
+// Farmhouse?Scenario=Scenario_Farmhouse_Checkpoint_Security?Lighting=Night, or an entry such
+// as (Scenario="Scenario_Farmhouse_Checkpoint_Security",Lighting="Night"). Blank lines and
+// comments are skipped.
+func ParseMapCycle(content string) []MapCycleEntry {
+	entries := []MapCycleEntry{}
+	for _, line := range configLines(content) {
+		var entry MapCycleEntry
+		switch {
+		case strings.HasPrefix(line, "("):
+			for _, field := range mapCycleField.FindAllStringSubmatch(line, -1) {
+				entry.set(field[1], field[2])
+			}
+		case strings.Contains(line, "?"):
+			parts := strings.Split(line, "?")
+			for _, part := range parts[1:] {
+				if key, value, ok := strings.Cut(part, "="); ok {
+					entry.set(key, value)
+				}
+			}
+		default:
+			entry.Scenario = line
+		}
+		if entry.Scenario != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// set applies a Key=Value field of a map cycle entry; unknown keys are ignored
+func (e *MapCycleEntry) set(key, value string) {
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "scenario":
+		e.Scenario = strings.TrimSpace(value)
+	case "lighting":
+		e.Lighting = strings.TrimSpace(value)
+	}
+}
+
+// configLines returns the non-blank, non-comment lines of a config file, trimmed
+func configLines(content string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if isConfigComment(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// isConfigComment reports whether a trimmed line of a .txt config file is blank or a comment
+func isConfigComment(line string) bool {
+	return line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#")
+}
+
+// serverConfigDir returns the directory under sawPath holding a server's config files, refusing
+// server IDs that would point outside it
+func serverConfigDir(sawPath, serverID string) (string, error) {
+	if serverID == "" || serverID != filepath.Base(serverID) || strings.HasPrefix(serverID, ".") {
+		return "", fmt.Errorf("invalid server ID %q", serverID)
+	}
+	return filepath.Join(sawPath, "server-config", serverID), nil
+}
+
+// managedServerFilePath returns the path of one of a server's ManagedServerFiles
+func managedServerFilePath(sawPath, serverID, name string) (string, error) {
+	if !slices.Contains(ManagedServerFiles, name) {
+		return "", fmt.Errorf("%q is not a managed server config file", name)
+	}
+	dir, err := serverConfigDir(sawPath, serverID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// ReadServerFile returns the content of one of a server's ManagedServerFiles. A file that does not
+// exist yet is empty, with exists false.
+func ReadServerFile(sawPath, serverID, name string) (content string, exists bool, err error) {
+	path, err := managedServerFilePath(sawPath, serverID, name)
+	if err != nil {
+		return "", false, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return string(data), true, nil
+}
+
+// WriteServerFile validates and saves one of a server's ManagedServerFiles, to be deployed on the
+// server's next start. The previous version is copied to the directory's backups folder first, as
+// <name>.<timestamp>, keeping the newest maxServerFileBackups of each file. Returns the backup's
+// path, or "" when there was no previous version. Invalid content is a *FileValidationError, and
+// one of the ReadOnlyServerFiles is ErrReadOnlyServerFile.
+func WriteServerFile(sawPath, serverID, name, content string) (backup string, err error) {
+	path, err := managedServerFilePath(sawPath, serverID, name)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(ReadOnlyServerFiles, name) {
+		return "", fmt.Errorf("%s: %w", name, ErrReadOnlyServerFile)
+	}
+	if err := ValidateServerFile(name, content); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create server config directory: %w", err)
+	}
+
+	previous, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		backupDir := filepath.Join(dir, "backups")
+		if err := os.MkdirAll(backupDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		backup = filepath.Join(backupDir, name+"."+time.Now().Format("20060102-150405.000"))
+		if err := os.WriteFile(backup, previous, 0644); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", name, err)
+		}
+		logger.PruneBackups(backupDir, []string{name + "."}, maxServerFileBackups)
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	// Write next to the file and rename over it, so the server never copies a half-written file
+	tmp, err := os.CreateTemp(dir, "."+name+".*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return backup, nil
+}
+
+// FileValidationError lists every problem found in one server config file
+type FileValidationError struct {
+	File     string
+	Problems []string
+}
+
+func (e *FileValidationError) Error() string {
+	return fmt.Sprintf("invalid %s:\n  - %s", e.File, strings.Join(e.Problems, "\n  - "))
+}
+
+// steamIDPattern matches a SteamID64
+var steamIDPattern = regexp.MustCompile(`^\d{17}$`)
+
+// ValidateServerFile checks the content of one of the ManagedServerFiles: .ini files must be
+// sections of Key=Value lines, Admins.txt must list Steam IDs and every MapCycle.txt entry must
+// name a scenario. All problems are reported together in a *FileValidationError.
+func ValidateServerFile(name, content string) error {
+	var problems []string
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	switch {
+	case strings.HasSuffix(name, ".ini"):
+		problems = iniProblems(lines)
+	case name == "Admins.txt":
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			if isConfigComment(line) {
+				continue
+			}
+			if !steamIDPattern.MatchString(line) {
+				problems = append(problems, fmt.Sprintf("line %d: %q is not a Steam ID", i+1, line))
+			}
+		}
+	case name == "MapCycle.txt":
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			if isConfigComment(line) {
+				continue
+			}
+			if len(ParseMapCycle(line)) == 0 {
+				problems = append(problems, fmt.Sprintf("line %d: no scenario in %q", i+1, line))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &FileValidationError{File: name, Problems: problems}
+	}
+	return nil
+}
+
+// iniProblems checks the lines of an Unreal .ini file: [Section] headers, and Key=Value lines
+// under a section, where the key may carry the +, -, . or ! array operators (!Key clears an array
+// and takes no value)
+func iniProblems(lines []string) []string {
+	var problems []string
+	inSection := false
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.TrimSpace(line[1:len(line)-1]) == "" {
+				problems = append(problems, fmt.Sprintf("line %d: malformed section header %q", i+1, line))
+			}
+			inSection = true
+			continue
+		}
+
+		key, _, hasValue := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !inSection:
+			problems = append(problems, fmt.Sprintf("line %d: %q is outside of a [Section]", i+1, line))
+		case !hasValue && !strings.HasPrefix(key, "!"):
+			problems = append(problems, fmt.Sprintf("line %d: expected Key=Value, got %q", i+1, line))
+		case strings.TrimLeft(key, "+-.!") == "":
+			problems = append(problems, fmt.Sprintf("line %d: missing key in %q", i+1, line))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,165 @@
+package servermgr
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMapCycle(t *testing.T) {
+	content := "; Checkpoint rotation\r\n" +
+		"Scenario_Farmhouse_Checkpoint_Security\r\n" +
+		"\r\n" +
+		"Farmhouse?Scenario=Scenario_Farmhouse_Checkpoint_Insurgents?Lighting=Night\r\n" +
+		`(Scenario="Scenario_Hideout_Checkpoint_Security",Lighting="Day")` + "\r\n" +
+		"// (Scenario=\"Scenario_Outskirts_Checkpoint_Security\")\r\n"
+
+	want := []MapCycleEntry{
+		{Scenario: "Scenario_Farmhouse_Checkpoint_Security"},
+		{Scenario: "Scenario_Farmhouse_Checkpoint_Insurgents", Lighting: "Night"},
+		{Scenario: "Scenario_Hideout_Checkpoint_Security", Lighting: "Day"},
+	}
+	if got := ParseMapCycle(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMapCycle() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadServerFiles(t *testing.T) {
+	sawPath := t.TempDir()
+	serverDir := filepath.Join(sawPath, "server-config", "server-1")
+	configDir := filepath.Join(sawPath, "admin-interface", "config")
+	for _, dir := range []string{serverDir, configDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(serverDir, "MapCycle.txt"), "Scenario_Ministry_Checkpoint_Security\n")
+	write(filepath.Join(serverDir, "Admins.txt"), "76561198995742987\n; old admin\n76561198995742956\n")
+	write(filepath.Join(configDir, "server-configs.json"),
+		`{"server-1": {"server_mutators": ["HeadshotOnly"], "server_mutators_custom": "NoAim, "}}`)
+
+	files, err := ReadServerFiles(sawPath, "server-1")
+	if err != nil {
+		t.Fatalf("ReadServerFiles() error = %v", err)
+	}
+
+	if want := []MapCycleEntry{{Scenario: "Scenario_Ministry_Checkpoint_Security"}}; !reflect.DeepEqual(files.MapCycle, want) {
+		t.Errorf("MapCycle = %+v, want %+v", files.MapCycle, want)
+	}
+	if want := []string{"76561198995742987", "76561198995742956"}; !reflect.DeepEqual(files.Admins, want) {
+		t.Errorf("Admins = %v, want %v", files.Admins, want)
+	}
+	if want := []string{"HeadshotOnly", "NoAim"}; !reflect.DeepEqual(files.Mutators, want) {
+		t.Errorf("Mutators = %v, want %v", files.Mutators, want)
+	}
+	if files.Motd != "" {
+		t.Errorf("Motd = %q, want empty", files.Motd)
+	}
+	if want := []string{"Motd.txt"}; !reflect.DeepEqual(files.Missing, want) {
+		t.Errorf("Missing = %v, want %v", files.Missing, want)
+	}
+
+	// A server without a directory or an entry in server-configs.json is empty, not an error
+	files, err = ReadServerFiles(sawPath, "server-2")
+	if err != nil {
+		t.Fatalf("ReadServerFiles() error = %v", err)
+	}
+	if len(files.MapCycle) != 0 || len(files.Admins) != 0 || len(files.Mutators) != 0 || len(files.Missing) != 3 {
+		t.Errorf("ReadServerFiles() = %+v, want empty with all files missing", files)
+	}
+
+	if _, err := ReadServerFiles(sawPath, "../server-1"); err == nil {
+		t.Error("ReadServerFiles() accepted a server ID outside the config directory")
+	}
+}
+
+func TestValidateServerFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid Game.ini",
+			file: "Game.ini",
+			content: "; Rules\r\n[/Script/Insurgency.INSGameMode]\r\nbKillFeed=True\r\n\r\n" +
+				"[/Script/Insurgency.INSMultiplayerMode]\r\n!MapCycle\r\n+MapCycle=Scenario_Farmhouse_Checkpoint_Security\r\n",
+		},
+		{name: "key outside a section", file: "Engine.ini", content: "bKillFeed=True\n", wantErr: "line 1"},
+		{name: "line without a value", file: "Game.ini", content: "[Section]\nbKillFeed\n", wantErr: "expected Key=Value"},
+		{name: "malformed section", file: "Game.ini", content: "[Section\nKey=1\n", wantErr: "malformed section header"},
+		{name: "valid Admins.txt", file: "Admins.txt", content: "76561198995742987\n; old\n"},
+		{name: "admin that is not a Steam ID", file: "Admins.txt", content: "76561198995742987\nArmoredBear\n", wantErr: "line 2"},
+		{name: "map cycle entry without a scenario", file: "MapCycle.txt", content: `(Lighting="Night")`, wantErr: "no scenario"},
+		{name: "anything goes in Motd.txt", file: "Motd.txt", content: "[Welcome]\nhave fun"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateServerFile(tc.file, tc.content)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateServerFile() error = %v", err)
+				}
+				return
+			}
+			var validationErr *FileValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ValidateServerFile() error = %v, want a *FileValidationError", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("ValidateServerFile() error = %q, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteServerFile(t *testing.T) {
+	sawPath := t.TempDir()
+
+	backup, err := WriteServerFile(sawPath, "server-1", "Game.ini", "[Section]\nKey=1\n")
+	if err != nil {
+		t.Fatalf("WriteServerFile() error = %v", err)
+	}
+	if backup != "" {
+		t.Errorf("backup = %q for a new file, want none", backup)
+	}
+
+	backup, err = WriteServerFile(sawPath, "server-1", "Game.ini", "[Section]\nKey=2\n")
+	if err != nil {
+		t.Fatalf("WriteServerFile() error = %v", err)
+	}
+	if data, err := os.ReadFile(backup); err != nil || string(data) != "[Section]\nKey=1\n" {
+		t.Errorf("backup = %q (%v), want the previous version", data, err)
+	}
+	if content, exists, err := ReadServerFile(sawPath, "server-1", "Game.ini"); err != nil || !exists || content != "[Section]\nKey=2\n" {
+		t.Errorf("ReadServerFile() = %q, %v, %v, want the new version", content, exists, err)
+	}
+
+	// Invalid content leaves the file as it was
+	if _, err := WriteServerFile(sawPath, "server-1", "Game.ini", "Key=3\n"); err == nil {
+		t.Error("WriteServerFile() saved an invalid .ini")
+	}
+	if content, _, _ := ReadServerFile(sawPath, "server-1", "Game.ini"); content != "[Section]\nKey=2\n" {
+		t.Errorf("file = %q after an invalid write, want it unchanged", content)
+	}
+
+	if _, err := WriteServerFile(sawPath, "server-1", "server-configs.json", "{}"); err == nil {
+		t.Error("WriteServerFile() wrote a file that is not managed")
+	}
+
+	if _, err := WriteServerFile(sawPath, "server-1", "Bans.txt", "76561198995742987\n"); !errors.Is(err, ErrReadOnlyServerFile) {
+		t.Errorf("WriteServerFile(Bans.txt) error = %v, want ErrReadOnlyServerFile", err)
+	}
+}
@@ -0,0 +1,73 @@
+package servermgr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var templateVarPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// TemplateVars returns the variables custom travel and server args can use as ${name}:
+// each config field by its SAW name without the server_ prefix (${max_players}, ${game_port}, ...)
+// plus the server_id, config_name and saw_path built-ins. The game and RCON passwords are left
+// out so they can't end up in a command line that is logged or shown in the process list.
+func TemplateVars(serverID string, config SAWServerConfig, sawPath string) map[string]string {
+	return map[string]string{
+		"server_id":            serverID,
+		"config_name":          config.ServerConfigName,
+		"saw_path":             sawPath,
+		"default_map":          config.ServerDefaultMap,
+		"lighting_day":         config.ServerLightingDay,
+		"default_side":         config.ServerDefaultSide,
+		"max_players":          config.ServerMaxPlayers,
+		"max_players_override": config.ServerMaxPlayersOverride,
+		"game_mode":            config.ServerGameMode,
+		"scenario_mode":        config.ServerScenarioMode,
+		"rule_set":             config.ServerRuleSet,
+		"mutators_custom":      config.ServerMutatorsCustom,
+		"cheats":               config.ServerCheats,
+		"hostname":             config.ServerHostname,
+		"game_port":            config.ServerGamePort,
+		"query_port":           config.ServerQueryPort,
+		"rcon_enabled":         config.ServerRconEnabled,
+		"rcon_port":            config.ServerRconPort,
+	}
+}
+
+// ExpandTemplate replaces each ${name} in value with its variable. Unknown variables are left
+// untouched and reported as warnings, once per name.
+func ExpandTemplate(value string, vars map[string]string) (string, []string) {
+	var warnings []string
+	warned := make(map[string]bool)
+	expanded := templateVarPattern.ReplaceAllStringFunc(value, func(match string) string {
+		name := templateVarPattern.FindStringSubmatch(match)[1]
+		if replacement, ok := vars[name]; ok {
+			return replacement
+		}
+		if !warned[name] {
+			warned[name] = true
+			warnings = append(warnings, fmt.Sprintf("unknown variable %s in custom args was left as is", match))
+		}
+		return match
+	})
+	return expanded, warnings
+}
+
+// ExpandArgs splits custom server args into arguments like splitArgs and only then expands the
+// variables in each one, so a value with spaces (like ${hostname}) stays a single argument
+func ExpandArgs(value string, vars map[string]string) ([]string, []string) {
+	var warnings []string
+	warned := make(map[string]bool)
+	args := splitArgs(value)
+	for i, arg := range args {
+		expanded, argWarnings := ExpandTemplate(arg, vars)
+		args[i] = expanded
+		for _, warning := range argWarnings {
+			if !warned[warning] {
+				warned[warning] = true
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	return args, warnings
+}
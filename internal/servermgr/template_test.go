@@ -0,0 +1,49 @@
+package servermgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	config := SAWServerConfig{
+		ServerMaxPlayers: "20",
+		ServerGamePort:   "27102",
+	}
+	vars := TemplateVars("server-1", config, "C:/SAW")
+
+	got, warnings := ExpandTemplate(`-log=${server_id}.log -Bots=${max_players} -Port2=${game_port} -x=${nope} -y=${nope} -z=${ also_not }`, vars)
+
+	want := `-log=server-1.log -Bots=20 -Port2=27102 -x=${nope} -y=${nope} -z=${ also_not }`
+	if got != want {
+		t.Errorf("unexpected expansion\n got: %s\nwant: %s", got, want)
+	}
+	wantWarnings := []string{"unknown variable ${nope} in custom args was left as is"}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("unexpected warnings\n got: %q\nwant: %q", warnings, wantWarnings)
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	config := SAWServerConfig{
+		ServerHostname:     "My Server -Cheats",
+		ServerPassword:     "secret",
+		ServerRconPassword: "rcon-secret",
+	}
+	vars := TemplateVars("server-1", config, "C:/SAW")
+
+	got, warnings := ExpandArgs(`-Name=${hostname} "-log=${server_id} a.log" -p=${password} -r=${rcon_password}`, vars)
+
+	// The hostname is expanded after splitting, so its spaces can't add arguments
+	want := []string{"-Name=My Server -Cheats", "-log=server-1 a.log", "-p=${password}", "-r=${rcon_password}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandArgs() = %q, want %q", got, want)
+	}
+	wantWarnings := []string{
+		"unknown variable ${password} in custom args was left as is",
+		"unknown variable ${rcon_password} in custom args was left as is",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("unexpected warnings\n got: %q\nwant: %q", warnings, wantWarnings)
+	}
+}
@@ -0,0 +1,253 @@
+package servermgr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KnownScenarioModes are the scenario modes StartServer can build a Scenario_<Map>_<Mode> travel string for
+var KnownScenarioModes = []string{
+	"Checkpoint", "Push", "Frontline", "Firefight", "Skirmish", "Domination",
+	"Ambush", "Outpost", "Survival", "Defusal", "TeamDeathmatch", "FFA",
+}
+
+// sidedScenarioModes need a side suffix (Scenario_<Map>_<Mode>_<Side>)
+var sidedScenarioModes = []string{"Checkpoint", "Push"}
+
+// knownSides are the valid values of server_default_side
+var knownSides = []string{"Security", "Insurgents"}
+
+// ValidationError lists every problem found in one server config
+type ValidationError struct {
+	ServerID string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	name := e.ServerID
+	if name == "" {
+		name = "(unnamed)"
+	}
+	return fmt.Sprintf("invalid server config %s:\n  - %s", name, strings.Join(e.Problems, "\n  - "))
+}
+
+// ValidateConfig checks that a server config has everything StartServer needs:
+// required fields, numeric ports in range, distinct ports and a known scenario mode.
+// All problems are reported together in a *ValidationError.
+// Mutator and ruleset names are checked separately by Catalog.Warnings, since an unknown one does not stop the server.
+func ValidateConfig(config SAWServerConfig) error {
+	var problems []string
+
+	if strings.TrimSpace(config.ServerDefaultMap) == "" {
+		problems = append(problems, "server_default_map is required")
+	}
+
+	switch mode := config.ServerScenarioMode; {
+	case mode == "":
+		problems = append(problems, "server_scenario_mode is required")
+	case !slices.Contains(KnownScenarioModes, mode):
+		problems = append(problems, fmt.Sprintf("server_scenario_mode %q is not one of %s", mode, strings.Join(KnownScenarioModes, ", ")))
+	case slices.Contains(sidedScenarioModes, mode) && !slices.Contains(knownSides, config.ServerDefaultSide):
+		problems = append(problems, fmt.Sprintf("server_default_side must be %s for %s, got %q", strings.Join(knownSides, " or "), mode, config.ServerDefaultSide))
+	}
+
+	// Unreal has no escape for a double quote inside a quoted -Key="value"
+	if strings.Contains(config.ServerHostname, `"`) {
+		problems = append(problems, "server_hostname cannot contain double quotes")
+	}
+	if strings.Contains(config.ServerPassword, `"`) {
+		problems = append(problems, "server_password cannot contain double quotes")
+	}
+
+	if config.ServerMaxPlayers == "" {
+		problems = append(problems, "server_max_players is required")
+	} else if n, err := strconv.Atoi(config.ServerMaxPlayers); err != nil || n < 1 {
+		problems = append(problems, fmt.Sprintf("server_max_players must be a positive number, got %q", config.ServerMaxPlayers))
+	}
+
+	ports := configPorts(config)
+	for _, field := range []string{"server_game_port", "server_query_port", "server_rcon_port"} {
+		value, used := ports[field]
+		if !used {
+			continue
+		}
+		if value == "" {
+			problems = append(problems, field+" is required")
+		} else if _, err := parsePort(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", field, err))
+		}
+	}
+
+	// A server cannot bind the same port twice
+	seen := make(map[string]string)
+	for _, field := range sortedKeys(ports) {
+		value := ports[field]
+		if value == "" {
+			continue
+		}
+		if other, ok := seen[value]; ok {
+			problems = append(problems, fmt.Sprintf("%s and %s both use port %s", other, field, value))
+			continue
+		}
+		seen[value] = field
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{ServerID: config.ID, Problems: problems}
+	}
+	return nil
+}
+
+// ValidateConfigs validates every config and checks that no two servers share a port.
+// The returned error joins one *ValidationError per invalid server, ordered by server ID.
+func ValidateConfigs(configs map[string]SAWServerConfig) error {
+	var errs []error
+
+	for _, serverID := range sortedKeys(configs) {
+		others := make(map[string]SAWServerConfig, len(configs)-1)
+		for otherID, other := range configs {
+			if otherID != serverID {
+				others[otherID] = other
+			}
+		}
+		if err := validateForStart(serverID, configs[serverID], others); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateForStart validates config and checks that its ports are free of the other servers' ports
+func validateForStart(serverID string, config SAWServerConfig, others map[string]SAWServerConfig) error {
+	var problems []string
+	var validationErr *ValidationError
+	if err := ValidateConfig(config); errors.As(err, &validationErr) {
+		problems = validationErr.Problems
+	}
+	problems = append(problems, portConflicts(config, others)...)
+
+	if len(problems) > 0 {
+		return &ValidationError{ServerID: serverID, Problems: problems}
+	}
+	return nil
+}
+
+// PreflightStartAll checks a set of servers before any of them is launched: every config must be
+// valid, no two servers may share a port, and no port may already be bound on this host.
+// Servers for which isRunning reports true are left out of the host check, they hold their own ports.
+func PreflightStartAll(configs map[string]SAWServerConfig, isRunning func(serverID string) bool) error {
+	stopped := make(map[string]SAWServerConfig, len(configs))
+	for serverID, config := range configs {
+		if isRunning == nil || !isRunning(serverID) {
+			stopped[serverID] = config
+		}
+	}
+	return errors.Join(ValidateConfigs(configs), CheckPortsAvailable(stopped))
+}
+
+// CheckPortsAvailable reports the ports of configs that are already bound on this host.
+// Game and query ports are UDP, the RCON port is TCP.
+func CheckPortsAvailable(configs map[string]SAWServerConfig) error {
+	var errs []error
+	for _, serverID := range sortedKeys(configs) {
+		ports := configPorts(configs[serverID])
+
+		var problems []string
+		for _, field := range sortedKeys(ports) {
+			if _, err := parsePort(ports[field]); err != nil {
+				continue // Reported by ValidateConfig
+			}
+			network := "udp"
+			if field == "server_rcon_port" {
+				network = "tcp"
+			}
+			if portInUse(network, ports[field]) {
+				problems = append(problems, fmt.Sprintf("%s %s is already in use on this host (%s)", field, ports[field], network))
+			}
+		}
+		if len(problems) > 0 {
+			errs = append(errs, &ValidationError{ServerID: serverID, Problems: problems})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// portInUse reports whether port cannot be bound on network
+func portInUse(network, port string) bool {
+	address := net.JoinHostPort("", port)
+	if network == "tcp" {
+		listener, err := net.Listen(network, address)
+		if err != nil {
+			return true
+		}
+		listener.Close()
+		return false
+	}
+
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return true
+	}
+	conn.Close()
+	return false
+}
+
+// portConflicts lists the ports of config that are already used by one of others
+func portConflicts(config SAWServerConfig, others map[string]SAWServerConfig) []string {
+	var conflicts []string
+	ports := configPorts(config)
+	for _, field := range sortedKeys(ports) {
+		value := ports[field]
+		if value == "" {
+			continue
+		}
+		for _, otherID := range sortedKeys(others) {
+			otherPorts := configPorts(others[otherID])
+			for _, otherField := range sortedKeys(otherPorts) {
+				if otherPorts[otherField] == value {
+					conflicts = append(conflicts, fmt.Sprintf("%s %s is also the %s of server %s", field, value, otherField, otherID))
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// configPorts returns the ports a server binds, keyed by config field.
+// The RCON port only counts when RCON is enabled.
+func configPorts(config SAWServerConfig) map[string]string {
+	ports := map[string]string{
+		"server_game_port":  strings.TrimSpace(config.ServerGamePort),
+		"server_query_port": strings.TrimSpace(config.ServerQueryPort),
+	}
+	if config.ServerRconEnabled == "true" {
+		ports["server_rcon_port"] = strings.TrimSpace(config.ServerRconPort)
+	}
+	return ports
+}
+
+// parsePort parses a TCP/UDP port number
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a number, got %q", value)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("must be between 1 and 65535, got %d", port)
+	}
+	return port, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+package servermgr
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func validSAWConfig() SAWServerConfig {
+	return SAWServerConfig{
+		ServerHostname:     "Test Server",
+		ServerDefaultMap:   "Ministry",
+		ServerScenarioMode: "Checkpoint",
+		ServerDefaultSide:  "Security",
+		ServerMaxPlayers:   "20",
+		ServerGamePort:     "27102",
+		ServerQueryPort:    "27131",
+		ServerRconEnabled:  "true",
+		ServerRconPort:     "27015",
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*SAWServerConfig)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *SAWServerConfig) {},
+		},
+		{
+			name: "valid without side for unsided mode and rcon disabled",
+			modify: func(c *SAWServerConfig) {
+				c.ServerScenarioMode = "Firefight"
+				c.ServerDefaultSide = ""
+				c.ServerRconEnabled = "false"
+				c.ServerRconPort = ""
+			},
+		},
+		{
+			name: "multiple problems are reported together",
+			modify: func(c *SAWServerConfig) {
+				c.ServerDefaultMap = ""
+				c.ServerMaxPlayers = "twenty"
+				c.ServerGamePort = ""
+				c.ServerQueryPort = "70000"
+				c.ServerRconPort = "rcon"
+			},
+			want: []string{
+				"server_default_map is required",
+				`server_max_players must be a positive number, got "twenty"`,
+				"server_game_port is required",
+				"server_query_port must be between 1 and 65535, got 70000",
+				`server_rcon_port must be a number, got "rcon"`,
+			},
+		},
+		{
+			name: "unknown scenario mode",
+			modify: func(c *SAWServerConfig) {
+				c.ServerScenarioMode = "Hardcore"
+			},
+			want: []string{
+				`server_scenario_mode "Hardcore" is not one of Checkpoint, Push, Frontline, Firefight, Skirmish, Domination, Ambush, Outpost, Survival, Defusal, TeamDeathmatch, FFA`,
+			},
+		},
+		{
+			name: "double quotes cannot be passed to the server",
+			modify: func(c *SAWServerConfig) {
+				c.ServerHostname = `The "Best" Server`
+				c.ServerPassword = `pa"ss`
+			},
+			want: []string{
+				"server_hostname cannot contain double quotes",
+				"server_password cannot contain double quotes",
+			},
+		},
+		{
+			name: "checkpoint needs a side and ports must differ",
+			modify: func(c *SAWServerConfig) {
+				c.ServerDefaultSide = ""
+				c.ServerQueryPort = c.ServerGamePort
+			},
+			want: []string{
+				`server_default_side must be Security or Insurgents for Checkpoint, got ""`,
+				"server_game_port and server_query_port both use port 27102",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validSAWConfig()
+			tt.modify(&config)
+
+			err := ValidateConfig(config)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("expected config to be valid, got: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.want) {
+				t.Errorf("unexpected problems\n got: %q\nwant: %q", validationErr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigs(t *testing.T) {
+	first := validSAWConfig()
+
+	second := validSAWConfig()
+	second.ServerGamePort = "27103"
+	second.ServerQueryPort = "27132"
+	second.ServerRconPort = "27016"
+
+	t.Run("distinct ports", func(t *testing.T) {
+		if err := ValidateConfigs(map[string]SAWServerConfig{"first": first, "second": second}); err != nil {
+			t.Fatalf("expected configs to be valid, got: %v", err)
+		}
+	})
+
+	t.Run("port collision is reported for both servers", func(t *testing.T) {
+		colliding := second
+		colliding.ServerQueryPort = first.ServerGamePort
+		colliding.ServerMaxPlayers = ""
+
+		err := ValidateConfigs(map[string]SAWServerConfig{"first": first, "second": colliding})
+		if err == nil {
+			t.Fatal("expected port collision to be reported")
+		}
+
+		want := "invalid server config first:\n" +
+			"  - server_game_port 27102 is also the server_query_port of server second\n" +
+			"invalid server config second:\n" +
+			"  - server_max_players is required\n" +
+			"  - server_query_port 27102 is also the server_game_port of server first"
+		if err.Error() != want {
+			t.Errorf("unexpected error\n got: %s\nwant: %s", err, want)
+		}
+	})
+}
+
+func TestStartServerRejectsInvalidConfig(t *testing.T) {
+	plugin := &Plugin{config: Config{DataDir: t.TempDir()}, servers: make(map[string]*ManagedServer)}
+	plugin.servers["running"] = &ManagedServer{ID: "running", Config: validSAWConfig(), IsRunning: true}
+
+	config := validSAWConfig()
+	config.ServerDefaultMap = ""
+
+	err := plugin.StartServer("new-server", config, t.TempDir(), false)
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	want := []string{
+		"server_default_map is required",
+		"server_game_port 27102 is also the server_game_port of server running",
+		"server_query_port 27131 is also the server_query_port of server running",
+		"server_rcon_port 27015 is also the server_rcon_port of server running",
+	}
+	if validationErr.ServerID != "new-server" || !reflect.DeepEqual(validationErr.Problems, want) {
+		t.Errorf("unexpected validation error: %+v", validationErr)
+	}
+}
+
+func TestPreflightStartAll(t *testing.T) {
+	t.Run("shared query port is reported", func(t *testing.T) {
+		first := validSAWConfig()
+		first.ServerGamePort, first.ServerQueryPort, first.ServerRconEnabled = "47102", "47131", "false"
+
+		second := first
+		second.ServerGamePort = "47103"
+
+		err := PreflightStartAll(map[string]SAWServerConfig{"first": first, "second": second}, nil)
+		if err == nil {
+			t.Fatal("expected the shared query port to be reported")
+		}
+		for _, want := range []string{
+			"server_query_port 47131 is also the server_query_port of server second",
+			"server_query_port 47131 is also the server_query_port of server first",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected %q in error:\n%s", want, err)
+			}
+		}
+	})
+
+	t.Run("port bound on the host is reported unless its server is running", func(t *testing.T) {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatalf("failed to bind a port: %v", err)
+		}
+		defer listener.Close()
+		_, boundPort, _ := net.SplitHostPort(listener.Addr().String())
+
+		config := validSAWConfig()
+		config.ServerGamePort, config.ServerQueryPort, config.ServerRconPort = "47202", "47231", boundPort
+		configs := map[string]SAWServerConfig{"busy": config}
+
+		err = PreflightStartAll(configs, func(string) bool { return false })
+		want := "invalid server config busy:\n  - server_rcon_port " + boundPort + " is already in use on this host (tcp)"
+		if err == nil || err.Error() != want {
+			t.Errorf("unexpected error\n got: %v\nwant: %s", err, want)
+		}
+
+		if err := PreflightStartAll(configs, func(string) bool { return true }); err != nil {
+			t.Errorf("expected a running server's own ports to be ignored, got: %v", err)
+		}
+	})
+}
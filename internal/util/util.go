@@ -22,7 +22,7 @@ func derefString(ptr *string) string {
 }
 
 // GetServerIdFromPath determines the server ID from a config path
-// Supports both file paths (e.g., /logs/abc-uuid.log) and directory paths (e.g., /logs)
+// Supports both file paths (e.g., /logs/abc-uuid.log, or an archived one, see ServerIDFromLogName) and directory paths (e.g., /logs)
 func GetServerIdFromPath(path string) (string, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -32,7 +32,7 @@ func GetServerIdFromPath(path string) (string, error) {
 	// If it's a file path, extract server ID from filename
 	if !info.IsDir() {
 		filename := filepath.Base(path)
-		serverID := strings.TrimSuffix(filename, ".log")
+		serverID := ServerIDFromLogName(filename)
 		if serverID == "" {
 			return "", fmt.Errorf("invalid log file name: %s", filename)
 		}
@@ -58,49 +58,89 @@ func GetServerIdFromPath(path string) (string, error) {
 	return "", fmt.Errorf("no log files found in directory: %s", path)
 }
 
-// ExtractGameMode extracts the game mode from a scenario string or simple mode string
-// Examples:
+// ServerIDFromLogName returns the server ID a log file is named after, for the active log and
+// for the archives and backups made of it:
 //
-//	"Scenario_Ministry_Checkpoint_Security" -> "Checkpoint"
-//	"Scenario_Refinery_Push_Insurgents" -> "Push"
-//	"Scenario_Town_Skirmish" -> "Skirmish"
-//	"Checkpoint" -> "Checkpoint"
-//	"Push" -> "Push"
-//	"Skirmish" -> "Skirmish"
-func ExtractGameMode(scenario string) string {
-	if scenario == "" {
-		return "Unknown"
+//	"abc-uuid.log" -> "abc-uuid"
+//	"abc-uuid.log.gz" -> "abc-uuid"
+//	"abc-uuid.log.20251110-205831.gz" -> "abc-uuid"
+//	"abc-uuid-backup-2025.11.10-20.58.31.log.gz" -> "abc-uuid"
+//
+// Names that don't end in .log are returned without their extension.
+func ServerIDFromLogName(name string) string {
+	name = strings.TrimSuffix(filepath.Base(name), ".gz")
+	if trimmed, ok := strings.CutSuffix(name, ".log"); ok {
+		name = trimmed
+	} else if i := strings.LastIndex(name, ".log."); i >= 0 {
+		name = name[:i]
+	} else {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
 	}
-
-	// Check if it's already a simple mode string
-	switch scenario {
-	case "Checkpoint", "Push", "Skirmish":
-		return scenario
+	if i := strings.LastIndex(name, "-backup-"); i >= 0 {
+		name = name[:i]
 	}
+	return name
+}
 
-	// Remove "Scenario_" prefix if present
+// scenarioSides are the suffixes naming the side players are on in sided scenarios
+var scenarioSides = []string{"Security", "Insurgents"}
+
+// NormalizeScenario splits a scenario into its game mode and the side players are on, so every
+// spelling of the same scenario (from a map load, a map travel or an already extracted mode)
+// ends up with the same mode. Examples:
+//
+//	"Scenario_Ministry_Checkpoint_Security" -> "Checkpoint", "Security"
+//	"Scenario_Refinery_Push_Insurgents" -> "Push", "Insurgents"
+//	"Scenario_Town_Skirmish" -> "Skirmish", ""
+//	"Checkpoint_Security" -> "Checkpoint", "Security"
+//	"CheckpointHardcore" -> "Checkpoint", ""
+//
+// The mode is "Unknown" when none is recognised, and the side is empty when the scenario has none.
+func NormalizeScenario(scenario string) (mode, side string) {
 	scenario, _ = strings.CutPrefix(scenario, "Scenario_")
 
-	// Split by underscore
+	for _, s := range scenarioSides {
+		if rest, ok := strings.CutSuffix(scenario, "_"+s); ok {
+			scenario, side = rest, s
+			break
+		}
+	}
+	if scenario == "" {
+		return "Unknown", side
+	}
+
+	// Either a bare mode ("Checkpoint") or map then mode ("Ministry_Checkpoint")
 	parts := strings.Split(scenario, "_")
-	if len(parts) < 2 {
-		return "Unknown"
+	candidate := parts[0]
+	if len(parts) > 1 {
+		candidate = parts[1]
 	}
 
-	// For Checkpoint and Push modes, the mode is the second part
-	// (first is the map name, second is the mode, third is the team)
-	// For Skirmish, there's no team, so it's just the second part
-	mode := parts[1]
+	// Game mode classes from travel lines carry a Hardcore suffix
+	candidate = strings.TrimSuffix(candidate, "Hardcore")
 
-	// Validate it's a known mode
-	switch mode {
+	switch candidate {
 	case "Checkpoint", "Push", "Skirmish":
-		return mode
+		return candidate, side
 	default:
-		return "Unknown"
+		return "Unknown", side
 	}
 }
 
+// ExtractGameMode extracts the game mode from a scenario string or simple mode string
+// Examples:
+//
+//	"Scenario_Ministry_Checkpoint_Security" -> "Checkpoint"
+//	"Scenario_Refinery_Push_Insurgents" -> "Push"
+//	"Scenario_Town_Skirmish" -> "Skirmish"
+//	"Checkpoint" -> "Checkpoint"
+//	"Push" -> "Push"
+//	"Skirmish" -> "Skirmish"
+func ExtractGameMode(scenario string) string {
+	mode, _ := NormalizeScenario(scenario)
+	return mode
+}
+
 // ExtractMapTitle extracts the map title from a scenario string
 // Examples:
 //
@@ -38,6 +38,42 @@ func TestGetServerIdFromPath(t *testing.T) {
 			wantID:  "550e8400-e29b-41d4-a716-446655440000",
 			wantErr: false,
 		},
+		{
+			name: "archived log file",
+			setupFunc: func() (string, func()) {
+				tmpDir := t.TempDir()
+				logFile := filepath.Join(tmpDir, "550e8400-e29b-41d4-a716-446655440000.log.gz")
+				f, _ := os.Create(logFile)
+				f.Close()
+				return logFile, func() {}
+			},
+			wantID:  "550e8400-e29b-41d4-a716-446655440000",
+			wantErr: false,
+		},
+		{
+			name: "timestamped archive of the active log",
+			setupFunc: func() (string, func()) {
+				tmpDir := t.TempDir()
+				logFile := filepath.Join(tmpDir, "550e8400-e29b-41d4-a716-446655440000.log.20251110-205831.gz")
+				f, _ := os.Create(logFile)
+				f.Close()
+				return logFile, func() {}
+			},
+			wantID:  "550e8400-e29b-41d4-a716-446655440000",
+			wantErr: false,
+		},
+		{
+			name: "archived game server backup",
+			setupFunc: func() (string, func()) {
+				tmpDir := t.TempDir()
+				logFile := filepath.Join(tmpDir, "550e8400-e29b-41d4-a716-446655440000-backup-2025.11.10-20.58.31.log.gz")
+				f, _ := os.Create(logFile)
+				f.Close()
+				return logFile, func() {}
+			},
+			wantID:  "550e8400-e29b-41d4-a716-446655440000",
+			wantErr: false,
+		},
 		{
 			name: "directory with single log file",
 			setupFunc: func() (string, func()) {
@@ -237,6 +273,37 @@ func TestExtractGameMode(t *testing.T) {
 	}
 }
 
+func TestNormalizeScenario(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		wantMode string
+		wantSide string
+	}{
+		{"Checkpoint Security", "Scenario_Ministry_Checkpoint_Security", "Checkpoint", "Security"},
+		{"Checkpoint Insurgents", "Scenario_Ministry_Checkpoint_Insurgents", "Checkpoint", "Insurgents"},
+		{"Push Insurgents", "Scenario_Refinery_Push_Insurgents", "Push", "Insurgents"},
+		{"Push Security", "Scenario_Refinery_Push_Security", "Push", "Security"},
+		{"Skirmish has no side", "Scenario_Town_Skirmish", "Skirmish", ""},
+		{"Without prefix", "Ministry_Checkpoint_Security", "Checkpoint", "Security"},
+		{"Mode and side only", "Checkpoint_Security", "Checkpoint", "Security"},
+		{"Bare mode", "Checkpoint", "Checkpoint", ""},
+		{"Hardcore game mode", "CheckpointHardcore", "Checkpoint", ""},
+		{"Unknown mode keeps side", "Scenario_Ministry_InvalidMode_Security", "Unknown", "Security"},
+		{"Map only", "Scenario_Ministry", "Unknown", ""},
+		{"Empty string", "", "Unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, side := NormalizeScenario(tt.scenario)
+			if mode != tt.wantMode || side != tt.wantSide {
+				t.Errorf("NormalizeScenario(%q) = %q, %q, want %q, %q", tt.scenario, mode, side, tt.wantMode, tt.wantSide)
+			}
+		})
+	}
+}
+
 func TestExtractMapTitle(t *testing.T) {
 	tests := []struct {
 		name     string
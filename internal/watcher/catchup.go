@@ -3,11 +3,9 @@ package watcher
 import (
 	"bufio"
 	"context"
-	"fmt"
 	"log/slog"
 	"os"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -26,6 +24,7 @@ type CatchupProcessor struct {
 	serverConfigs map[string]config.ServerConfig
 	pbApp         core.App
 	ctx           context.Context
+	onComplete    func(serverID string) // Called once a server's catch-up has been replayed
 }
 
 // NewCatchupProcessor creates a new catchup processor
@@ -53,13 +52,13 @@ func (c *CatchupProcessor) CheckStartupCatchup(filePath, serverID string) (int,
 	// Get server config to access query address
 	serverConfig, exists := c.serverConfigs[serverID]
 	if !exists {
-		c.logger.Debug("No config found for server, skipping catch-up", "serverID", serverID)
+		c.logger.Debug("No config found for server, skipping catch-up", "server_id", serverID)
 		return 0, false
 	}
 
 	// Check 1: Query A2S to verify server is online and get current map
 	if serverConfig.QueryAddress == "" {
-		c.logger.Debug("No query address configured, skipping catch-up", "serverID", serverID)
+		c.logger.Debug("No query address configured, skipping catch-up", "server_id", serverID)
 		return 0, false
 	}
 
@@ -68,17 +67,17 @@ func (c *CatchupProcessor) CheckStartupCatchup(filePath, serverID string) (int,
 
 	serverStatus, err := c.a2sPool.QueryServer(ctx, serverConfig.QueryAddress)
 	if err != nil {
-		c.logger.Debug("Server appears offline", "serverID", serverID, "error", err)
+		c.logger.Debug("Server appears offline", "server_id", serverID, "error", err)
 		return 0, false
 	}
 
 	if serverStatus == nil || serverStatus.Info == nil {
-		c.logger.Debug("Server returned no info, skipping catch-up", "serverID", serverID)
+		c.logger.Debug("Server returned no info, skipping catch-up", "server_id", serverID)
 		return 0, false
 	}
 
 	currentMap := serverStatus.Info.Map
-	c.logger.Debug("Server is online", "serverID", serverID, "currentMap", currentMap)
+	c.logger.Debug("Server is online", "server_id", serverID, "currentMap", currentMap)
 
 	// Check 2: Is file recently modified?
 	fileInfo, err := os.Stat(filePath)
@@ -90,30 +89,36 @@ func (c *CatchupProcessor) CheckStartupCatchup(filePath, serverID string) (int,
 	fileModTime := fileInfo.ModTime()
 	timeSinceModification := time.Since(fileModTime)
 
+	// Log timestamps are written in the game server's timezone
+	loc, err := serverConfig.LogLocation()
+	if err != nil {
+		loc = time.Local
+	}
+
 	// Detect if SAW is active by checking for recent RCON logs
-	sawActive := c.hasRecentRconLogs(filePath, 30*time.Second)
+	sawActive := c.hasRecentRconLogs(filePath, 30*time.Second, loc)
 
 	// Use adaptive threshold based on whether SAW is active
 	var fileModThreshold time.Duration
 	if sawActive {
 		fileModThreshold = 1 * time.Minute // SAW keeps file fresh with polling
-		c.logger.Debug("SAW detected, using 1-minute threshold", "serverID", serverID)
+		c.logger.Debug("SAW detected, using 1-minute threshold", "server_id", serverID)
 	} else {
 		fileModThreshold = 9 * time.Hour // Servers restart every 8 hours, allow some buffer
-		c.logger.Debug("No SAW detected, using 9-hour threshold", "serverID", serverID)
+		c.logger.Debug("No SAW detected, using 9-hour threshold", "server_id", serverID)
 	}
 
 	fileRecentlyModified := timeSinceModification < fileModThreshold
 
 	if !fileRecentlyModified {
-		c.logger.Debug("File not recently modified, skipping catch-up", "serverID", serverID, "minutesAgo", timeSinceModification.Minutes())
+		c.logger.Debug("File not recently modified, skipping catch-up", "server_id", serverID, "minutesAgo", timeSinceModification.Minutes())
 		return 0, false
 	}
 
 	// Check 3: Find last map event in log file
 	mapName, scenario, mapTime, startLineNum, err := c.parser.FindLastMapEvent(filePath, time.Now())
 	if err != nil {
-		c.logger.Debug("No map event found, skipping catch-up", "serverID", serverID, "error", err)
+		c.logger.Debug("No map event found, skipping catch-up", "server_id", serverID, "error", err)
 		return 0, false
 	}
 
@@ -121,61 +126,50 @@ func (c *CatchupProcessor) CheckStartupCatchup(filePath, serverID string) (int,
 	recentMapEvent := timeSinceMap < 30*time.Minute
 
 	if !recentMapEvent {
-		c.logger.Debug("Map event too old, skipping catch-up", "serverID", serverID, "minutesAgo", timeSinceMap.Minutes())
+		c.logger.Debug("Map event too old, skipping catch-up", "server_id", serverID, "minutesAgo", timeSinceMap.Minutes())
 		return 0, false
 	}
 
 	// Check 4: Does the log map match the current server map?
 	if !strings.EqualFold(mapName, currentMap) {
-		c.logger.Debug("Map mismatch, skipping catch-up", "serverID", serverID, "logMap", mapName, "serverMap", currentMap)
+		c.logger.Debug("Map mismatch, skipping catch-up", "server_id", serverID, "logMap", mapName, "serverMap", currentMap)
 		return 0, false
 	}
 
 	// All conditions met - do catch-up!
-	c.logger.Debug("Starting catch-up", "serverID", serverID, "map", mapName, "fileMod(s)", timeSinceModification.Seconds(), "mapLoad(s)", timeSinceMap.Seconds())
+	c.logger.Debug("Starting catch-up", "server_id", serverID, "map", mapName, "fileMod(s)", timeSinceModification.Seconds(), "mapLoad(s)", timeSinceMap.Seconds())
 
 	// Get current file size as the catch-up end point
 	catchupEndOffset := fileInfo.Size()
 
-	// Extract player team from scenario
-	var playerTeam *string
-	if strings.Contains(scenario, "_Security") {
-		team := "Security"
-		playerTeam = &team
-	} else if strings.Contains(scenario, "_Insurgents") {
-		team := "Insurgents"
-		playerTeam = &team
-	}
-
-	// Create match in database
-	_, err = c.pbApp.FindFirstRecordByFilter(
-		"matches",
-		"server = {:server} && end_time = ''",
-		map[string]any{"server": serverID},
-	)
-
-	// Only create match if one doesn't already exist
+	// Make the match started by the map event the active one, ending a stale match left over
+	// from before the tracker stopped
+	created, err := database.EnsureActiveMatch(c.ctx, c.pbApp, serverID, mapName, scenario, mapTime)
 	if err != nil {
-		_, err = database.CreateMatch(c.ctx, c.pbApp, serverID, &mapName, &scenario, &mapTime, playerTeam)
-		if err != nil {
-			c.logger.Debug("Failed to create match", "serverID", serverID, "error", err)
-			return 0, false
-		}
-		c.logger.Debug("Created match", "serverID", serverID, "map", mapName, "scenario", scenario)
+		c.logger.Debug("Failed to create match", "server_id", serverID, "error", err)
+		return 0, false
+	}
+	if created {
+		c.logger.Debug("Created match", "server_id", serverID, "map", mapName, "scenario", scenario)
 	} else {
-		c.logger.Debug("Active match already exists, using existing match", "serverID", serverID)
+		c.logger.Debug("Active match already exists, using existing match", "server_id", serverID)
 	}
 
 	// Process historical events from map event to current position
 	linesProcessed := c.processHistoricalEvents(filePath, serverID, startLineNum, catchupEndOffset)
 
-	c.logger.Debug("Catch-up completed", "serverID", serverID, "linesProcessed", linesProcessed, "startLine", startLineNum, "endOffset", catchupEndOffset)
+	c.logger.Debug("Catch-up completed", "server_id", serverID, "linesProcessed", linesProcessed, "startLine", startLineNum, "endOffset", catchupEndOffset)
+
+	// Replayed events skip score updates, so the caught up match is refreshed once here
+	if c.onComplete != nil {
+		c.onComplete(serverID)
+	}
 
 	return int(catchupEndOffset), true
 }
 
 // hasRecentRconLogs checks if there are recent RCON log entries (indicates SAW is active)
-func (c *CatchupProcessor) hasRecentRconLogs(filePath string, threshold time.Duration) bool {
+func (c *CatchupProcessor) hasRecentRconLogs(filePath string, threshold time.Duration, loc *time.Location) bool {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return false
@@ -183,7 +177,7 @@ func (c *CatchupProcessor) hasRecentRconLogs(filePath string, threshold time.Dur
 	defer file.Close()
 
 	// Read last 100 lines to check for recent RCON activity
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(parser.NewLogReader(file))
 	var lastLines []string
 	maxLines := 100
 
@@ -202,7 +196,7 @@ func (c *CatchupProcessor) hasRecentRconLogs(filePath string, threshold time.Dur
 		if strings.Contains(line, "LogRcon:") {
 			// Try to extract timestamp from line
 			if matches := timestampPattern.FindStringSubmatch(line); len(matches) >= 2 {
-				if ts, err := parseTimestampFromLog(matches[1]); err == nil {
+				if ts, err := parser.ParseLogTimestamp(matches[1], loc); err == nil {
 					if ts.After(cutoffTime) {
 						return true
 					}
@@ -225,28 +219,30 @@ func (c *CatchupProcessor) processHistoricalEvents(filePath, serverID string, st
 
 	// Create context that marks this as catchup mode
 	// Events will be created, but side effects (scoring, RCON) will be skipped
-	type contextKey string
-	isCatchupModeKey := contextKey("isCatchupMode")
-	catchupCtx := context.WithValue(c.ctx, isCatchupModeKey, true)
+	catchupCtx := parser.WithCatchupMode(c.ctx)
 	c.logger.Debug("Processing historical events in catchup mode (no scoring/RCON)")
 
-	scanner := bufio.NewScanner(file)
+	reader := parser.NewLogLineReader(file, parser.DetectLogFileEncoding(file))
 	lineNum := 0
 	linesProcessed := 0
+	var offset int64
 
-	// Read until we hit the start line
-	for scanner.Scan() && lineNum < startLine {
-		lineNum++
-	}
-
-	// Process lines from startLine until we reach endOffset
-	for scanner.Scan() {
-		currentPos, _ := file.Seek(0, 1)
-		if currentPos > endOffset {
+	// Process lines after the map event at startLine (the match was created from it)
+	// until we reach endOffset
+	for {
+		line, size, err := reader.ReadLine()
+		if err != nil {
+			break
+		}
+		offset += int64(size)
+		if offset > endOffset {
 			break
 		}
+		if lineNum <= startLine {
+			lineNum++
+			continue
+		}
 
-		line := scanner.Text()
 		if err := c.parser.ParseAndProcess(catchupCtx, line, serverID, filePath); err != nil {
 			c.logger.Debug("Error processing line in catch-up", "lineNum", lineNum, "error", err)
 		}
@@ -257,27 +253,3 @@ func (c *CatchupProcessor) processHistoricalEvents(filePath, serverID string, st
 
 	return linesProcessed
 }
-
-// parseTimestampFromLog parses a timestamp from log format (2025.10.04-15.23.38:790)
-func parseTimestampFromLog(ts string) (time.Time, error) {
-	colonIdx := strings.LastIndex(ts, ":")
-	if colonIdx == -1 {
-		return time.Time{}, fmt.Errorf("invalid timestamp format: %s", ts)
-	}
-
-	dateTimePart := ts[:colonIdx]
-	msPart := ts[colonIdx+1:]
-
-	// Parse in local timezone (log timestamps are in server's local time)
-	dt, err := time.ParseInLocation("2006.01.02-15.04.05", dateTimePart, time.Local)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse datetime part: %w", err)
-	}
-
-	ms, err := strconv.Atoi(msPart)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse milliseconds: %w", err)
-	}
-
-	return dt.Add(time.Duration(ms) * time.Millisecond), nil
-}
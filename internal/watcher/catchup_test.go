@@ -133,6 +133,10 @@ func TestStartupCatchup(t *testing.T) {
 		catchupProcessor: NewCatchupProcessor(testApp.Logger(), logParser, mockA2S, serverConfigs, testApp, ctx),
 	}
 
+	// Count the refreshes requested once a catch-up has been replayed
+	completed := make(map[string]int)
+	watcher.OnCatchupComplete(func(serverID string) { completed[serverID]++ })
+
 	// Create test server in database
 	serverID := "test-server-123"
 	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Test Server", testLogPath)
@@ -154,6 +158,10 @@ func TestStartupCatchup(t *testing.T) {
 
 		t.Logf("Catch-up detected: offset=%d", offset)
 
+		if completed[serverID] != 1 {
+			t.Errorf("Expected one refresh after catch-up, got %d", completed[serverID])
+		}
+
 		// Verify offset was saved to server record
 		serverRecord, err := testApp.FindFirstRecordByFilter(
 			"servers",
@@ -290,6 +298,10 @@ func TestStartupCatchup(t *testing.T) {
 			t.Errorf("Expected offset=0 for skipped catch-up, got %d", offset)
 		}
 
+		if completed[oldServerID] != 0 {
+			t.Errorf("Expected no refresh for skipped catch-up, got %d", completed[oldServerID])
+		}
+
 		t.Log("✓ Correctly skipped catch-up for old file")
 	})
 }
@@ -0,0 +1,108 @@
+package watcher
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"sandstorm-tracker/internal/parser"
+)
+
+// FollowLog sends the last backlog lines of a log file to emit, then every line appended to it,
+// until ctx is cancelled or emit returns an error. The file is polled every interval.
+// Like the watcher it follows rotation: when the file is replaced or shrinks below what has
+// been read, it is read again from the start.
+func FollowLog(ctx context.Context, path string, backlog int, interval time.Duration, emit func(line string) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	offset, err := emitBacklog(path, backlog, emit)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := os.Stat(path)
+		if err != nil {
+			// Missing while the server rotates it; pick it up on the next poll
+			continue
+		}
+		if !os.SameFile(info, current) || current.Size() < offset {
+			offset = 0
+		}
+		info = current
+
+		if current.Size() == offset {
+			continue
+		}
+		if offset, err = readLines(path, offset, emit); err != nil {
+			return err
+		}
+	}
+}
+
+// emitBacklog sends the last n complete lines of a file and returns the offset after them
+func emitBacklog(path string, n int, emit func(line string) error) (int64, error) {
+	var backlog []string
+	offset, err := readLines(path, 0, func(line string) error {
+		if n <= 0 {
+			return nil
+		}
+		if len(backlog) == n {
+			backlog = backlog[1:]
+		}
+		backlog = append(backlog, line)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range backlog {
+		if err := emit(line); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// readLines sends the complete lines of a file from offset and returns the offset after the last one.
+// A trailing partial line is left for the next read.
+func readLines(path string, offset int64, emit func(line string) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	reader := parser.NewLogLineReader(file, parser.DetectLogFileEncoding(file))
+	for {
+		line, size, err := reader.ReadLine()
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		if err := emit(line); err != nil {
+			return offset, err
+		}
+		offset += int64(size)
+	}
+}
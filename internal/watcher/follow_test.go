@@ -0,0 +1,57 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFollowLog_Rotation checks a truncated log is read again from the start
+func TestFollowLog_Rotation(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "server.log")
+	if err := os.WriteFile(logPath, []byte("old 1\nold 2\nold 3\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lines := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- FollowLog(ctx, logPath, 1, 20*time.Millisecond, func(line string) error {
+			lines <- line
+			return nil
+		})
+	}()
+
+	next := func() string {
+		t.Helper()
+		select {
+		case line := <-lines:
+			return line
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a line")
+			return ""
+		}
+	}
+
+	if line := next(); line != "old 3" {
+		t.Fatalf("Expected backlog line %q, got %q", "old 3", line)
+	}
+
+	// The server starts a new, shorter log in place of the old one
+	if err := os.WriteFile(logPath, []byte("new 1\n"), 0644); err != nil {
+		t.Fatalf("failed to rotate log: %v", err)
+	}
+	if line := next(); line != "new 1" {
+		t.Fatalf("Expected the first line of the new log, got %q", line)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("FollowLog returned %v after cancel", err)
+	}
+}
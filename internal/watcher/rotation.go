@@ -52,7 +52,7 @@ func (r *RotationDetector) CheckRotation(
 	if savedLogFileTime != "" && !currentLogFileTime.IsZero() {
 		savedTime, err := time.Parse(time.RFC3339, savedLogFileTime)
 		if err == nil && !currentLogFileTime.Equal(savedTime) {
-			logger.Debug("Log rotation detected", "serverID", serverID, "oldTime", savedTime.Format("2006-01-02 15:04:05"), "newTime", currentLogFileTime.Format("2006-01-02 15:04:05"))
+			logger.Debug("Log rotation detected", "server_id", serverID, "oldTime", savedTime.Format("2006-01-02 15:04:05"), "newTime", currentLogFileTime.Format("2006-01-02 15:04:05"))
 			rotationDetected = true
 			offset = 0
 		}
@@ -60,7 +60,7 @@ func (r *RotationDetector) CheckRotation(
 
 	// Fallback: If current size is less than offset, log file was rotated (truncated/reset)
 	if !rotationDetected && currentSize < int64(offset) {
-		logger.Debug("Log rotation detected", "serverID", serverID, "reason", "file size < offset", "fileSize", currentSize, "offset", offset)
+		logger.Debug("Log rotation detected", "server_id", serverID, "reason", "file size < offset", "fileSize", currentSize, "offset", offset)
 		rotationDetected = true
 		offset = 0
 	}
@@ -86,20 +86,20 @@ func (r *RotationDetector) ShouldSkipProcessing(
 ) (shouldSkip bool, reason string) {
 	// If offset is 0 (new server or just after rotation), save current state
 	if offset == 0 && !rotationDetected {
-		logger.Debug("New server detected", "serverID", serverID, "offset", currentSize)
+		logger.Debug("New server detected", "server_id", serverID, "offset", currentSize)
 		serverRecord.Set("offset", currentSize)
 		if !currentLogFileTime.IsZero() {
 			serverRecord.Set("log_file_creation_time", currentLogFileTime.Format(time.RFC3339))
 		}
 		if err := pbApp.Save(serverRecord); err != nil {
-			logger.Debug("Error saving initial offset", "serverID", serverID, "error", err)
+			logger.Debug("Error saving initial offset", "server_id", serverID, "error", err)
 		}
 		return true, "new server - waiting for first rotation"
 	}
 
 	// If offset equals current size, we're at the end of the file - wait for new data
 	if int64(offset) == currentSize {
-		logger.Debug("Server at end of file", "serverID", serverID, "fileSize", currentSize)
+		logger.Debug("Server at end of file", "server_id", serverID, "fileSize", currentSize)
 		return true, "at end of file - waiting for new data"
 	}
 
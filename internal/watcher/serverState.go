@@ -53,7 +53,7 @@ func (s *ServerStateTracker) MarkActive(serverID string) {
 
 	// Only trigger callback if server wasn't already active
 	if !wasActive {
-		s.logger.Debug("Server became active", "serverID", serverID, "reason", "log rotation detected")
+		s.logger.Debug("Server became active", "server_id", serverID, "reason", "log rotation detected")
 
 		s.callbacksMu.RLock()
 		callback := s.onServerActive
@@ -74,7 +74,7 @@ func (s *ServerStateTracker) MarkInactive(serverID string) {
 
 	// Only trigger callback if server was actually active
 	if wasActive {
-		s.logger.Debug("Server became inactive", "serverID", serverID, "reason", "no activity for 10s")
+		s.logger.Debug("Server became inactive", "server_id", serverID, "reason", "no activity for 10s")
 
 		s.callbacksMu.RLock()
 		callback := s.onServerInactive
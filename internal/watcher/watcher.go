@@ -1,9 +1,9 @@
 package watcher
 
 import (
-	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -13,6 +13,7 @@ import (
 
 	"sandstorm-tracker/internal/a2s"
 	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
 	"sandstorm-tracker/internal/parser"
 	"sandstorm-tracker/internal/rcon"
 	"sandstorm-tracker/internal/util"
@@ -21,6 +22,9 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// offsetSaveBatchSize is how many lines are processed between offset saves
+const offsetSaveBatchSize = 100
+
 // A2SQuerier is an interface for querying A2S server status
 // This allows for mocking in tests
 type A2SQuerier interface {
@@ -100,6 +104,12 @@ func (w *Watcher) OnServerActive(callback func(serverID string)) {
 	w.stateTracker.SetCallbacks(callback, nil)
 }
 
+// OnCatchupComplete sets a callback to be called after a server's startup catch-up has been replayed
+// Must be set before the watcher is started
+func (w *Watcher) OnCatchupComplete(callback func(serverID string)) {
+	w.catchupProcessor.onComplete = callback
+}
+
 // OnServerInactive sets a callback to be called when a server becomes inactive (no activity for 10s)
 func (w *Watcher) OnServerInactive(callback func(serverID string)) {
 	var activeCallback func(string)
@@ -185,7 +195,7 @@ func (w *Watcher) Stop() {
 	w.serverQueuesMu.Lock()
 	for serverID, queue := range w.serverQueues {
 		close(queue)
-		w.logger.Info("Closed queue for server", "serverID", serverID)
+		w.logger.Info("Closed queue for server", "server_id", serverID)
 	}
 	w.serverQueuesMu.Unlock()
 
@@ -246,23 +256,23 @@ func (w *Watcher) enqueueFileEvent(serverID, filePath string) {
 	case queue <- filePath:
 		// Successfully enqueued
 	default:
-		w.logger.Warn("Queue full for server, dropping event", "serverID", serverID, "filePath", filePath)
+		w.logger.Warn("Queue full for server, dropping event", "server_id", serverID, "filePath", filePath)
 	}
 }
 
 // serverWorker processes file events sequentially for a single server
 func (w *Watcher) serverWorker(serverID string, queue chan string) {
 	defer w.wg.Done()
-	w.logger.Info("Started worker for server", "serverID", serverID)
+	w.logger.Info("Started worker for server", "server_id", serverID)
 
 	for {
 		select {
 		case <-w.ctx.Done():
-			w.logger.Info("Stopping worker for server", "serverID", serverID)
+			w.logger.Info("Stopping worker for server", "server_id", serverID)
 			return
 		case filePath, ok := <-queue:
 			if !ok {
-				w.logger.Info("Queue closed for server", "serverID", serverID)
+				w.logger.Info("Queue closed for server", "server_id", serverID)
 				return
 			}
 			w.processFile(filePath)
@@ -327,6 +337,18 @@ func (w *Watcher) processFile(filePath string) {
 		w.logger.Warn("Could not extract log file creation time", "filePath", filePath, "error", err)
 	}
 
+	// A replaced log means the server restarted; the parsed log file line records it too, and
+	// the two are merged into one restart
+	if rotationResult.Rotated {
+		restartedAt := currentLogFileTime
+		if restartedAt.IsZero() {
+			restartedAt = time.Now()
+		}
+		if _, err := database.RecordServerRestart(w.ctx, w.pbApp, serverDBID, restartedAt, time.Now(), database.RestartSourceWatchdog); err != nil {
+			w.logger.Warn("Failed to record server restart", "server_id", serverID, "error", err)
+		}
+	}
+
 	// Check if we should skip processing
 	if shouldSkip, _ := w.rotationDetector.ShouldSkipProcessing(
 		w.logger, serverID, offset, rotationResult.CurrentSize, rotationResult.Rotated,
@@ -340,38 +362,45 @@ func (w *Watcher) processFile(filePath string) {
 		return
 	}
 
-	scanner := bufio.NewScanner(file)
+	// Read complete lines only, tracking the exact byte offset of each one so the offset can be
+	// persisted mid-file. A trailing partial line is left for the next write.
+	// UTF-16 logs are decoded to UTF-8 per line; offsets stay in file bytes.
+	reader := parser.NewLogLineReader(file, parser.DetectLogFileEncoding(file))
 	linesProcessed := 0
+	batchLines := 0
+	currentOffset := int64(offset)
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	for {
+		line, size, err := reader.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				w.logger.Error("Error reading file", "filePath", filePath, "error", err)
+			}
+			break
+		}
 
 		// Parse and process directly - pass serverID (external_id), not serverDBID
 		if err := w.parser.ParseAndProcess(w.ctx, line, serverID, filePath); err != nil {
-			w.logger.Error("Error processing line", "error", err, "serverID", serverID)
+			w.logger.Error("Error processing line", "error", err, "server_id", serverID)
 		}
 
+		currentOffset += int64(size)
 		linesProcessed++
-	}
+		batchLines++
 
-	if err := scanner.Err(); err != nil {
-		w.logger.Error("Error scanning file", "filePath", filePath, "error", err)
-		return
+		// Persist progress after each batch so a restart resumes here instead of reprocessing the file
+		if batchLines >= offsetSaveBatchSize {
+			w.saveOffset(serverRecord, currentOffset, currentLogFileTime)
+			batchLines = 0
+		}
 	}
 
-	newOffset, _ := file.Seek(0, 1)
-
 	// Save new offset and log file creation time to database
 	if linesProcessed > 0 {
-		serverRecord.Set("offset", newOffset)
-		if !currentLogFileTime.IsZero() {
-			serverRecord.Set("log_file_creation_time", currentLogFileTime.Format(time.RFC3339))
-		}
-		if err := w.pbApp.Save(serverRecord); err != nil {
-			w.logger.Error("Error saving server offset", "error", err, "serverDBID", serverDBID)
-		} else {
-			w.logger.Info("Processed lines from file", "linesProcessed", linesProcessed, "filePath", filePath, "newOffset", newOffset)
+		if batchLines > 0 {
+			w.saveOffset(serverRecord, currentOffset, currentLogFileTime)
 		}
+		w.logger.Info("Processed lines from file", "linesProcessed", linesProcessed, "filePath", filePath, "newOffset", currentOffset)
 
 		// Mark server as active and update activity time
 		w.stateTracker.MarkActive(serverID)
@@ -379,6 +408,17 @@ func (w *Watcher) processFile(filePath string) {
 	}
 }
 
+// saveOffset persists the byte offset and log file creation time of the last processed line
+func (w *Watcher) saveOffset(serverRecord *core.Record, offset int64, logFileTime time.Time) {
+	serverRecord.Set("offset", offset)
+	if !logFileTime.IsZero() {
+		serverRecord.Set("log_file_creation_time", logFileTime.Format(time.RFC3339))
+	}
+	if err := w.pbApp.Save(serverRecord); err != nil {
+		w.logger.Error("Error saving server offset", "error", err, "serverDBID", serverRecord.Id, "offset", offset)
+	}
+}
+
 func (w *Watcher) extractServerIDFromPath(filePath string) string {
 	base := filepath.Base(filePath)
 	return strings.TrimSuffix(base, filepath.Ext(base))
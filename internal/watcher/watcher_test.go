@@ -1,10 +1,15 @@
 package watcher
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
 	"sandstorm-tracker/internal/parser"
 
 	"github.com/pocketbase/pocketbase/tests"
@@ -152,3 +157,129 @@ func TestLogRotationDetectionViaFileSize(t *testing.T) {
 	t.Log("  Current size: 5,000 bytes")
 	t.Log("  Result:       Rotation detected! Reset to 0")
 }
+
+// TestProcessFileResumesFromSavedOffset processes half a log, "restarts" the watcher and checks
+// that only the new lines are processed
+func TestProcessFileResumesFromSavedOffset(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test app: %v", err)
+	}
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "resume-server"
+	logPath := filepath.Join(t.TempDir(), serverID+".log")
+
+	killLine := func(i int) string {
+		return fmt.Sprintf("[2025.11.10-21.%02d.00:000][%3d]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Bot%d[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419\r\n", i, i, i)
+	}
+	appendLines := func(t *testing.T, content string) {
+		t.Helper()
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatalf("Failed to append to log file: %v", err)
+		}
+	}
+
+	header := "Log file open, 11/10/25 20:58:31\r\n"
+	if err := os.WriteFile(logPath, []byte(header), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	// Existing server positioned after the header of the current log file
+	if _, err := database.GetOrCreateServer(ctx, testApp, serverID, "Resume Server", logPath); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	serverRecord, err := testApp.FindFirstRecordByFilter("servers", "external_id = {:id}", map[string]any{"id": serverID})
+	if err != nil {
+		t.Fatalf("Failed to find server: %v", err)
+	}
+	creationTime, err := parser.NewLogParser(testApp, testApp.Logger()).ExtractLogFileCreationTime(logPath)
+	if err != nil {
+		t.Fatalf("Failed to extract log file creation time: %v", err)
+	}
+	serverRecord.Set("offset", len(header))
+	serverRecord.Set("log_file_creation_time", creationTime.Format(time.RFC3339))
+	if err := testApp.Save(serverRecord); err != nil {
+		t.Fatalf("Failed to save server: %v", err)
+	}
+
+	// newWatcher simulates a tracker (re)start with fresh in-memory state
+	newWatcher := func() *Watcher {
+		logParser := parser.NewLogParser(testApp, testApp.Logger())
+		return &Watcher{
+			pbApp:            testApp,
+			parser:           logParser,
+			logger:           testApp.Logger(),
+			ctx:              ctx,
+			stateTracker:     NewServerStateTracker(testApp.Logger(), 10*time.Second),
+			rotationDetector: NewRotationDetector(logParser),
+		}
+	}
+
+	// victims returns how many kill events were recorded per victim
+	victims := func(t *testing.T) map[string]int {
+		t.Helper()
+		records, err := testApp.FindRecordsByFilter("events", "type = {:type}", "", -1, 0, map[string]any{"type": events.TypePlayerKill})
+		if err != nil {
+			t.Fatalf("Failed to load kill events: %v", err)
+		}
+		counts := make(map[string]int)
+		for _, record := range records {
+			var data struct {
+				Victim struct{ Name string } `json:"victim"`
+			}
+			if err := record.UnmarshalJSONField("data", &data); err != nil {
+				t.Fatalf("Failed to decode kill event: %v", err)
+			}
+			counts[data.Victim.Name]++
+		}
+		return counts
+	}
+
+	// First half, plus a partial line the server is still writing
+	appendLines(t, killLine(1)+killLine(2)+killLine(3)+killLine(4)[:40])
+	newWatcher().processFile(logPath)
+
+	if got := victims(t); len(got) != 3 {
+		t.Fatalf("Expected 3 kill events after first half, got %v", got)
+	}
+
+	// Restart, then the partial line is completed and the second half is written
+	appendLines(t, killLine(4)[40:]+killLine(5)+killLine(6))
+	newWatcher().processFile(logPath)
+
+	got := victims(t)
+	if len(got) != 6 {
+		t.Errorf("Expected 6 distinct kill events after restart, got %v", got)
+	}
+	for victim, count := range got {
+		if count != 1 {
+			t.Errorf("Kill of %s was processed %d times", victim, count)
+		}
+	}
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("Failed to stat log file: %v", err)
+	}
+	serverRecord, _ = testApp.FindRecordById("servers", serverRecord.Id)
+	if offset := serverRecord.GetInt("offset"); int64(offset) != info.Size() {
+		t.Errorf("Expected saved offset %d (end of file), got %d", info.Size(), offset)
+	}
+
+	// Truncation: the file is rewritten shorter than the saved offset, so it is read from the start
+	if err := os.WriteFile(logPath, []byte(header+killLine(7)), 0644); err != nil {
+		t.Fatalf("Failed to truncate log file: %v", err)
+	}
+	newWatcher().processFile(logPath)
+
+	if got := victims(t); got["Bot7"] != 1 || len(got) != 7 {
+		t.Errorf("Expected the truncated file to be read from the start, got %v", got)
+	}
+}
@@ -0,0 +1,210 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_3738798621",
+					"hidden": false,
+					"id": "relation_server",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "server",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"cascadeDelete": false,
+					"collectionId": "pbc_2541054544",
+					"hidden": false,
+					"id": "relation_match",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "match",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"hidden": false,
+					"id": "json_options",
+					"maxSize": 0,
+					"name": "options",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "json"
+				},
+				{
+					"hidden": false,
+					"id": "text_winner_map",
+					"max": 100,
+					"min": 0,
+					"name": "winner_map",
+					"pattern": "",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "text_winner_scenario",
+					"max": 200,
+					"min": 0,
+					"name": "winner_scenario",
+					"pattern": "",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "number_winner_option_id",
+					"max": null,
+					"min": null,
+					"name": "winner_option_id",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "select_outcome",
+					"maxSelect": 1,
+					"name": "outcome",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "select",
+					"values": [
+						"majority",
+						"deadline",
+						"random"
+					]
+				},
+				{
+					"hidden": false,
+					"id": "number_vote_share",
+					"max": null,
+					"min": 0,
+					"name": "vote_share",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number_threshold",
+					"max": null,
+					"min": 0,
+					"name": "threshold",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "datetime_started_at",
+					"max": "",
+					"min": "",
+					"name": "started_at",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"hidden": false,
+					"id": "datetime_ended_at",
+					"max": "",
+					"min": "",
+					"name": "ended_at",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_map_votes",
+			"indexes": [
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_map_votes_server_ended` + "`" + ` ON ` + "`" + `map_votes` + "`" + ` (` + "`" + `server` + "`" + `, ` + "`" + `ended_at` + "`" + `)"
+			],
+			"listRule": "",
+			"name": "map_votes",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": ""
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_map_votes")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,180 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_3738798621",
+					"hidden": false,
+					"id": "relation_server",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "server",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"cascadeDelete": false,
+					"collectionId": "pbc_2541054544",
+					"hidden": false,
+					"id": "relation_match",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "match",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"cascadeDelete": false,
+					"collectionId": "pbc_2936669995",
+					"hidden": false,
+					"id": "relation_player",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "player",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"hidden": false,
+					"id": "text_steam_id",
+					"max": 32,
+					"min": 0,
+					"name": "steam_id",
+					"pattern": "",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "text_player_name",
+					"max": 100,
+					"min": 0,
+					"name": "player_name",
+					"pattern": "",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "select_channel",
+					"maxSelect": 1,
+					"name": "channel",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "select",
+					"values": [
+						"global",
+						"team"
+					]
+				},
+				{
+					"hidden": false,
+					"id": "text_message",
+					"max": 0,
+					"min": 0,
+					"name": "message",
+					"pattern": "",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "datetime_timestamp",
+					"max": "",
+					"min": "",
+					"name": "timestamp",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_chat_messages",
+			"indexes": [
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_chat_messages_server_timestamp` + "`" + ` ON ` + "`" + `chat_messages` + "`" + ` (` + "`" + `server` + "`" + `, ` + "`" + `timestamp` + "`" + `)",
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_chat_messages_steam_id` + "`" + ` ON ` + "`" + `chat_messages` + "`" + ` (` + "`" + `steam_id` + "`" + `)"
+			],
+			"listRule": null,
+			"name": "chat_messages",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": null
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_chat_messages")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
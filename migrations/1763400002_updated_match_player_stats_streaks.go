@@ -0,0 +1,110 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_3080700301")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_current_streak",
+			"max": null,
+			"min": 0,
+			"name": "current_streak",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_best_streak",
+			"max": null,
+			"min": 0,
+			"name": "best_streak",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_multi_kills",
+			"max": null,
+			"min": 0,
+			"name": "multi_kills",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_multi_kill_chain",
+			"max": null,
+			"min": 0,
+			"name": "multi_kill_chain",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "date_last_kill_at",
+			"max": "",
+			"min": "",
+			"name": "last_kill_at",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "date"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_3080700301")
+		if err != nil {
+			return err
+		}
+
+		// remove fields
+		collection.Fields.RemoveById("number_current_streak")
+		collection.Fields.RemoveById("number_best_streak")
+		collection.Fields.RemoveById("number_multi_kills")
+		collection.Fields.RemoveById("number_multi_kill_chain")
+		collection.Fields.RemoveById("date_last_kill_at")
+
+		return app.Save(collection)
+	})
+}
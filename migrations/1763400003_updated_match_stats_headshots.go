@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// match_player_stats and match_weapon_stats
+		for _, collectionID := range []string{"pbc_3080700301", "pbc_626477742"} {
+			collection, err := app.FindCollectionByNameOrId(collectionID)
+			if err != nil {
+				return err
+			}
+
+			// add field
+			if err := collection.Fields.AddMarshaledJSON([]byte(`{
+				"hidden": false,
+				"id": "number_headshots",
+				"max": null,
+				"min": 0,
+				"name": "headshots",
+				"onlyInt": true,
+				"presentable": false,
+				"required": false,
+				"system": false,
+				"type": "number"
+			}`)); err != nil {
+				return err
+			}
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		for _, collectionID := range []string{"pbc_3080700301", "pbc_626477742"} {
+			collection, err := app.FindCollectionByNameOrId(collectionID)
+			if err != nil {
+				return err
+			}
+
+			// remove field
+			collection.Fields.RemoveById("number_headshots")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
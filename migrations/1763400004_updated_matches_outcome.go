@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		matches, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := matches.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_winning_team",
+			"max": null,
+			"min": -1,
+			"name": "winning_team",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := matches.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_team_0_round_wins",
+			"max": null,
+			"min": 0,
+			"name": "team_0_round_wins",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := matches.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_team_1_round_wins",
+			"max": null,
+			"min": 0,
+			"name": "team_1_round_wins",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := app.Save(matches); err != nil {
+			return err
+		}
+
+		// Existing matches have no known winner (0 would mean Security)
+		if _, err := app.DB().NewQuery("UPDATE matches SET winning_team = -1").Execute(); err != nil {
+			return err
+		}
+
+		playerStats, err := app.FindCollectionByNameOrId("pbc_3080700301")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := playerStats.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_team",
+			"max": null,
+			"min": null,
+			"name": "team",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := playerStats.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "select_result",
+			"maxSelect": 1,
+			"name": "result",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "select",
+			"values": [
+				"win",
+				"loss",
+				"tie"
+			]
+		}`)); err != nil {
+			return err
+		}
+
+		if err := app.Save(playerStats); err != nil {
+			return err
+		}
+
+		// Existing player stats have no known team (0 would mean Security)
+		_, err = app.DB().NewQuery("UPDATE match_player_stats SET team = -1").Execute()
+		return err
+	}, func(app core.App) error {
+		matches, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// remove fields
+		matches.Fields.RemoveById("number_winning_team")
+		matches.Fields.RemoveById("number_team_0_round_wins")
+		matches.Fields.RemoveById("number_team_1_round_wins")
+
+		if err := app.Save(matches); err != nil {
+			return err
+		}
+
+		playerStats, err := app.FindCollectionByNameOrId("pbc_3080700301")
+		if err != nil {
+			return err
+		}
+
+		// remove fields
+		playerStats.Fields.RemoveById("number_team")
+		playerStats.Fields.RemoveById("select_result")
+
+		return app.Save(playerStats)
+	})
+}
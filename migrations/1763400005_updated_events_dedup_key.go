@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text_dedup_key",
+			"max": 0,
+			"min": 0,
+			"name": "dedup_key",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// events created outside the parser have no key
+		collection.AddIndex("idx_events_dedup_key", true, "`dedup_key`", "`dedup_key` != ''")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_events_dedup_key")
+
+		// remove field
+		collection.Fields.RemoveById("text_dedup_key")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		matches, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := matches.Fields.AddMarshaledJSON([]byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text_scenario",
+			"max": 0,
+			"min": 0,
+			"name": "scenario",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := matches.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "json_round_results",
+			"maxSize": 0,
+			"name": "round_results",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "json"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(matches)
+	}, func(app core.App) error {
+		matches, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// remove fields
+		matches.Fields.RemoveById("text_scenario")
+		matches.Fields.RemoveById("json_round_results")
+
+		return app.Save(matches)
+	})
+}
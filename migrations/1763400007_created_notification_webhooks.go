@@ -0,0 +1,135 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "text_name",
+					"max": 100,
+					"min": 0,
+					"name": "name",
+					"pattern": "",
+					"presentable": true,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"exceptDomains": null,
+					"hidden": false,
+					"id": "url_webhook_url",
+					"name": "url",
+					"onlyDomains": null,
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "url"
+				},
+				{
+					"hidden": false,
+					"id": "bool_enabled",
+					"name": "enabled",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "bool"
+				},
+				{
+					"hidden": false,
+					"id": "select_events",
+					"maxSelect": 2,
+					"name": "events",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "select",
+					"values": [
+						"match_end",
+						"teamkills"
+					]
+				},
+				{
+					"hidden": false,
+					"id": "number_teamkill_threshold",
+					"max": null,
+					"min": 0,
+					"name": "teamkill_threshold",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_notification_webhooks",
+			"indexes": [],
+			"listRule": null,
+			"name": "notification_webhooks",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": null
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_notification_webhooks")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,149 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "number_kill",
+					"max": null,
+					"min": null,
+					"name": "kill",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number_assist",
+					"max": null,
+					"min": null,
+					"name": "assist",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number_objective_captured",
+					"max": null,
+					"min": null,
+					"name": "objective_captured",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number_objective_destroyed",
+					"max": null,
+					"min": null,
+					"name": "objective_destroyed",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number_death_penalty",
+					"max": null,
+					"min": null,
+					"name": "death_penalty",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_score_settings",
+			"indexes": [],
+			"listRule": "",
+			"name": "score_settings",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": ""
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Seed the default weights (see database.DefaultScoreWeights)
+		record := core.NewRecord(collection)
+		record.Set("kill", 10)
+		record.Set("assist", 5)
+		record.Set("objective_captured", 50)
+		record.Set("objective_destroyed", 50)
+		record.Set("death_penalty", 5)
+
+		return app.Save(record)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_score_settings")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		matches, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := matches.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "json_score_weights",
+			"maxSize": 0,
+			"name": "score_weights",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "json"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := app.Save(matches); err != nil {
+			return err
+		}
+
+		stats, err := app.FindCollectionByNameOrId("pbc_3080700301")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := stats.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_computed_score",
+			"max": null,
+			"min": null,
+			"name": "computed_score",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(stats)
+	}, func(app core.App) error {
+		matches, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// remove field
+		matches.Fields.RemoveById("json_score_weights")
+
+		if err := app.Save(matches); err != nil {
+			return err
+		}
+
+		stats, err := app.FindCollectionByNameOrId("pbc_3080700301")
+		if err != nil {
+			return err
+		}
+
+		// remove field
+		stats.Fields.RemoveById("number_computed_score")
+
+		return app.Save(stats)
+	})
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"cascadeDelete": false,
+			"collectionId": "pbc_2541054544",
+			"hidden": false,
+			"id": "relation_events_match",
+			"maxSelect": 1,
+			"minSelect": 0,
+			"name": "match",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "relation"
+		}`)); err != nil {
+			return err
+		}
+
+		collection.AddIndex("idx_events_match", false, "`match`", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_events_match")
+
+		// remove field
+		collection.Fields.RemoveById("relation_events_match")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,164 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_map_name",
+					"max": 0,
+					"min": 0,
+					"name": "name",
+					"pattern": "",
+					"presentable": true,
+					"primaryKey": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_map_display_name",
+					"max": 0,
+					"min": 0,
+					"name": "display_name",
+					"pattern": "",
+					"presentable": false,
+					"primaryKey": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_map_image",
+					"max": 0,
+					"min": 0,
+					"name": "image",
+					"pattern": "",
+					"presentable": false,
+					"primaryKey": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "json_map_objectives",
+					"maxSize": 0,
+					"name": "objectives",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "json"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_maps",
+			"indexes": [
+				"CREATE UNIQUE INDEX IF NOT EXISTS ` + "`" + `idx_maps_name` + "`" + ` ON ` + "`" + `maps` + "`" + ` (` + "`" + `name` + "`" + `)"
+			],
+			"listRule": "",
+			"name": "maps",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": ""
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Seed the stock maps: logged map name -> in-game name.
+		// Images and objective counts are left for admins to fill in.
+		stockMaps := [][2]string{
+			{"Bab", "Bab"},
+			{"Buhriz", "Tideway"},
+			{"Canyon", "Crossing"},
+			{"Citadel", "Citadel"},
+			{"Compound", "Outskirts"},
+			{"Farmhouse", "Farmhouse"},
+			{"Gap", "Gap"},
+			{"Ministry", "Ministry"},
+			{"Mountain", "Summit"},
+			{"Oilfield", "Refinery"},
+			{"PowerPlant", "Power Plant"},
+			{"Precinct", "Precinct"},
+			{"Prison", "Prison"},
+			{"Sinjar", "Hillside"},
+			{"Tell", "Tell"},
+			{"Town", "Hideout"},
+		}
+		for _, stock := range stockMaps {
+			record := core.NewRecord(collection)
+			record.Set("name", stock[0])
+			record.Set("display_name", stock[1])
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_maps")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,176 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_2936669995",
+					"hidden": false,
+					"id": "relation_flag_player",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "player",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"hidden": false,
+					"id": "select_flag_kind",
+					"maxSelect": 1,
+					"name": "kind",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "select",
+					"values": [
+						"headshot_ratio",
+						"kill_rate",
+						"zero_deaths"
+					]
+				},
+				{
+					"hidden": false,
+					"id": "number_flag_value",
+					"max": null,
+					"min": null,
+					"name": "value",
+					"onlyInt": false,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number_flag_threshold",
+					"max": null,
+					"min": null,
+					"name": "threshold",
+					"onlyInt": false,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"cascadeDelete": false,
+					"collectionId": "pbc_2541054544",
+					"hidden": false,
+					"id": "relation_flag_match",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "match",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_flag_details",
+					"max": 0,
+					"min": 0,
+					"name": "details",
+					"pattern": "",
+					"presentable": false,
+					"primaryKey": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "bool_flag_advisory",
+					"name": "advisory",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "bool"
+				},
+				{
+					"hidden": false,
+					"id": "bool_flag_reviewed",
+					"name": "reviewed",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "bool"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_moderation_flags",
+			"indexes": [
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_moderation_flags_player` + "`" + ` ON ` + "`" + `moderation_flags` + "`" + ` (` + "`" + `player` + "`" + `)"
+			],
+			"listRule": null,
+			"name": "moderation_flags",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": null
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_moderation_flags")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
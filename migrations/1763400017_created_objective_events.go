@@ -0,0 +1,166 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_2541054544",
+					"hidden": false,
+					"id": "relation_objective_match",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "match",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_1687431684",
+					"hidden": false,
+					"id": "relation_objective_event",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "event",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_objective_objective",
+					"max": 0,
+					"min": 0,
+					"name": "objective",
+					"pattern": "",
+					"presentable": true,
+					"primaryKey": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "select_objective_action",
+					"maxSelect": 1,
+					"name": "action",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "select",
+					"values": [
+						"captured",
+						"destroyed"
+					]
+				},
+				{
+					"hidden": false,
+					"id": "number_objective_team",
+					"max": null,
+					"min": null,
+					"name": "team",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "json_objective_players",
+					"maxSize": 0,
+					"name": "players",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "json"
+				},
+				{
+					"hidden": false,
+					"id": "date_objective_timestamp",
+					"max": "",
+					"min": "",
+					"name": "timestamp",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_objective_events",
+			"indexes": [
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_objective_events_match` + "`" + ` ON ` + "`" + `objective_events` + "`" + ` (` + "`" + `match` + "`" + `, ` + "`" + `timestamp` + "`" + `)"
+			],
+			"listRule": "",
+			"name": "objective_events",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": ""
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_objective_events")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_626477742")
+		if err != nil {
+			return err
+		}
+
+		// add fields
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_weapon_shots",
+			"max": null,
+			"min": 0,
+			"name": "shots",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_weapon_hits",
+			"max": null,
+			"min": 0,
+			"name": "hits",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_626477742")
+		if err != nil {
+			return err
+		}
+
+		// remove fields
+		collection.Fields.RemoveById("number_weapon_shots")
+		collection.Fields.RemoveById("number_weapon_hits")
+
+		return app.Save(collection)
+	})
+}
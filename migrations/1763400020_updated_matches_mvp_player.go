@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"cascadeDelete": false,
+			"collectionId": "pbc_2936669995",
+			"hidden": false,
+			"id": "relation_matches_mvp_player",
+			"maxSelect": 1,
+			"minSelect": 0,
+			"name": "mvp_player",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "relation"
+		}`)); err != nil {
+			return err
+		}
+
+		collection.AddIndex("idx_matches_mvp_player", false, "`mvp_player`", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_matches_mvp_player")
+
+		// remove field
+		collection.Fields.RemoveById("relation_matches_mvp_player")
+
+		return app.Save(collection)
+	})
+}
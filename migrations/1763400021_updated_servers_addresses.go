@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_3738798621")
+		if err != nil {
+			return err
+		}
+
+		// add fields
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text_server_query_address",
+			"max": 0,
+			"min": 0,
+			"name": "query_address",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text_server_rcon_address",
+			"max": 0,
+			"min": 0,
+			"name": "rcon_address",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"autogeneratePattern": "",
+			"hidden": true,
+			"id": "text_server_rcon_password",
+			"max": 0,
+			"min": 0,
+			"name": "rcon_password",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		collection.AddIndex("idx_servers_external_id", true, "`external_id`", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_3738798621")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_servers_external_id")
+
+		// remove fields
+		collection.Fields.RemoveById("text_server_query_address")
+		collection.Fields.RemoveById("text_server_rcon_address")
+		collection.Fields.RemoveById("text_server_rcon_password")
+
+		return app.Save(collection)
+	})
+}
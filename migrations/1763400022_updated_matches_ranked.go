@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Lifetime stat views before unranked matches were excluded
+const (
+	playerTotalStatsQueryAll  = "SELECT \n  player as id,\n  player,\n  COALESCE(SUM(kills), 0) as total_kills,\n  COALESCE(SUM(deaths), 0) as total_deaths,\n  COALESCE(SUM(score), 0) as total_score,\n  COALESCE(SUM(total_play_time), 0) as total_duration_seconds,\n  COALESCE(SUM(assists), 0) as total_assists,\n  COALESCE(SUM(friendly_fire_kills), 0) as total_ff_kills\nFROM match_player_stats\nGROUP BY player;"
+	playerWeaponStatsQueryAll = "SELECT \n  (player || '_' || weapon_name) as id,\n  player,\n  weapon_name,\n  SUM(kills) as total_kills\nFROM match_weapon_stats\nGROUP BY player, weapon_name;"
+)
+
+// Lifetime stat views counting ranked matches only
+const (
+	playerTotalStatsQueryRanked  = "SELECT \n  s.player as id,\n  s.player,\n  COALESCE(SUM(s.kills), 0) as total_kills,\n  COALESCE(SUM(s.deaths), 0) as total_deaths,\n  COALESCE(SUM(s.score), 0) as total_score,\n  COALESCE(SUM(s.total_play_time), 0) as total_duration_seconds,\n  COALESCE(SUM(s.assists), 0) as total_assists,\n  COALESCE(SUM(s.friendly_fire_kills), 0) as total_ff_kills\nFROM match_player_stats s\nINNER JOIN matches m ON m.id = s.match\nWHERE m.unranked = FALSE\nGROUP BY s.player;"
+	playerWeaponStatsQueryRanked = "SELECT \n  (w.player || '_' || w.weapon_name) as id,\n  w.player,\n  w.weapon_name,\n  SUM(w.kills) as total_kills\nFROM match_weapon_stats w\nINNER JOIN matches m ON m.id = w.match\nWHERE m.unranked = FALSE\nGROUP BY w.player, w.weapon_name;"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// add fields
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "number_matches_peak_players",
+			"max": null,
+			"min": 0,
+			"name": "peak_players",
+			"onlyInt": true,
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "number"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"hidden": false,
+			"id": "bool_matches_unranked",
+			"name": "unranked",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "bool"
+		}`)); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Existing matches only know who played, not how many were connected at once
+		if _, err := app.DB().NewQuery(
+			"UPDATE matches SET peak_players = (SELECT COUNT(DISTINCT player) FROM match_player_stats WHERE match_player_stats.match = matches.id)",
+		).Execute(); err != nil {
+			return err
+		}
+
+		return setViewQueries(app, playerTotalStatsQueryRanked, playerWeaponStatsQueryRanked)
+	}, func(app core.App) error {
+		if err := setViewQueries(app, playerTotalStatsQueryAll, playerWeaponStatsQueryAll); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_2541054544")
+		if err != nil {
+			return err
+		}
+
+		// remove fields
+		collection.Fields.RemoveById("number_matches_peak_players")
+		collection.Fields.RemoveById("bool_matches_unranked")
+
+		return app.Save(collection)
+	})
+}
+
+// setViewQueries replaces the queries of the player_total_stats and player_weapon_stats views
+func setViewQueries(app core.App, totalStatsQuery, weaponStatsQuery string) error {
+	for id, query := range map[string]string{
+		"pbc_1972907995": totalStatsQuery,
+		"pbc_1972907997": weaponStatsQuery,
+	} {
+		collection, err := app.FindCollectionByNameOrId(id)
+		if err != nil {
+			return err
+		}
+		collection.ViewQuery = query
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_3738798621",
+					"hidden": false,
+					"id": "relation_restart_server",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "server",
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"hidden": false,
+					"id": "date_restart_restarted_at",
+					"max": "",
+					"min": "",
+					"name": "restarted_at",
+					"presentable": true,
+					"required": true,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"hidden": false,
+					"id": "select_restart_source",
+					"maxSelect": 1,
+					"name": "source",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "select",
+					"values": [
+						"log_file",
+						"watchdog"
+					]
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_server_restarts",
+			"indexes": [
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_server_restarts_server_restarted_at` + "`" + ` ON ` + "`" + `server_restarts` + "`" + ` (` + "`" + `server` + "`" + `, ` + "`" + `restarted_at` + "`" + `)"
+			],
+			"listRule": "",
+			"name": "server_restarts",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": ""
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_server_restarts")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
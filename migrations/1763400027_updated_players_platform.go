@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2936669995")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSON([]byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text_players_platform",
+			"max": 0,
+			"min": 0,
+			"name": "platform",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// A player is identified by (platform, external_id); IDs from different platforms may collide
+		collection.AddIndex("idx_players_platform_external_id", false, "`platform`, `external_id`", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Logins so far were only parsed for numeric (Steam) IDs
+		_, err = app.DB().NewQuery("UPDATE players SET platform = 'steam' WHERE external_id != '' AND external_id NOT GLOB '*[^0-9]*'").Execute()
+		return err
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2936669995")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_players_platform_external_id")
+
+		// remove field
+		collection.Fields.RemoveById("text_players_platform")
+
+		return app.Save(collection)
+	})
+}
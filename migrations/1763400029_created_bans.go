@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_ban_steam_id",
+					"max": 64,
+					"min": 1,
+					"name": "steam_id",
+					"pattern": "^\\w+$",
+					"presentable": true,
+					"primaryKey": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"cascadeDelete": true,
+					"collectionId": "pbc_3738798621",
+					"hidden": false,
+					"id": "relation_ban_server",
+					"maxSelect": 1,
+					"minSelect": 0,
+					"name": "server",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "relation"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text_ban_reason",
+					"max": 200,
+					"min": 0,
+					"name": "reason",
+					"pattern": "",
+					"presentable": false,
+					"primaryKey": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "date_ban_expires",
+					"max": "",
+					"min": "",
+					"name": "expires",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": true,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_bans",
+			"indexes": [
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_bans_steam_id` + "`" + ` ON ` + "`" + `bans` + "`" + ` (` + "`" + `steam_id` + "`" + `)",
+				"CREATE INDEX IF NOT EXISTS ` + "`" + `idx_bans_server` + "`" + ` ON ` + "`" + `bans` + "`" + ` (` + "`" + `server` + "`" + `)"
+			],
+			"listRule": null,
+			"name": "bans",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": null
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_bans")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// publicCollectionRules are the list and view rules that keep the records API in line with the
+// public pages: private servers are left out, and player stats and events of servers that hide
+// player names are only listed to superusers
+var publicCollectionRules = map[string]string{
+	"pbc_3738798621": "is_public = true",                                                        // servers
+	"pbc_2541054544": "server.is_public = true",                                                 // matches
+	"pbc_3080700301": "match.server.is_public = true && match.server.hide_player_names = false", // match_player_stats
+	"pbc_626477742":  "match.server.is_public = true && match.server.hide_player_names = false", // match_weapon_stats
+	"pbc_1687431684": "server.is_public = true && server.hide_player_names = false",             // events
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		for id, rule := range publicCollectionRules {
+			collection, err := app.FindCollectionByNameOrId(id)
+			if err != nil {
+				return err
+			}
+
+			collection.ListRule = &rule
+			collection.ViewRule = &rule
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		for id := range publicCollectionRules {
+			collection, err := app.FindCollectionByNameOrId(id)
+			if err != nil {
+				return err
+			}
+
+			anyone := ""
+			collection.ListRule = &anyone
+			collection.ViewRule = &anyone
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2936669995")
+		if err != nil {
+			return err
+		}
+
+		// metadata holds players' known IPs and moderation flags; hidden fields are only
+		// returned to superusers, including when a player is expanded from another record
+		collection.Fields.GetById("json9284756123").SetHidden(true)
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_2936669995")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.GetById("json9284756123").SetHidden(false)
+
+		return app.Save(collection)
+	})
+}
@@ -1,15 +1,26 @@
 # run-with-update.ps1
 # Wrapper script to check for updates, then start the app
 # All app logging goes to app.log and the database
+#
+# After an update the new version must pass a health check; if it does not,
+# the previous executable is restored and started instead.
 
 param(
-    [string]$AppName = "sandstorm-tracker"
+    [string]$AppName = "sandstorm-tracker",
+    [string]$HttpAddr = "127.0.0.1:8090",
+    [string]$HealthUrl = "",
+    [string]$HealthTimeout = "60s"
 )
 
+if (-not $HealthUrl) {
+    $HealthUrl = "http://$HttpAddr/health"
+}
+
 # Get app directory (parent of scripts folder)
 $scriptDir = Split-Path -Parent $PSCommandPath
 $appDir = Split-Path -Parent $scriptDir
 $appPath = Join-Path $appDir "$AppName.exe"
+$previousPath = "$appPath.old"
 
 # Check if app exists
 if (-not (Test-Path $appPath)) {
@@ -18,9 +29,32 @@ if (-not (Test-Path $appPath)) {
 }
 
 Write-Host "Checking for updates..."
-& $appPath update
+Remove-Item $previousPath -ErrorAction SilentlyContinue
+& $appPath update --keep-previous
 
 Write-Host "Starting server..."
-& $appPath serve
+$app = Start-Process -FilePath $appPath -ArgumentList @("serve", "--http", $HttpAddr) -WorkingDirectory $appDir -NoNewWindow -PassThru
+
+# Only a fresh update leaves the previous executable behind
+if (Test-Path $previousPath) {
+    & $appPath wait-healthy --url $HealthUrl --timeout $HealthTimeout
+    if ($LASTEXITCODE -eq 0) {
+        Remove-Item $previousPath -ErrorAction SilentlyContinue
+    }
+    else {
+        Write-Warning "!!! The updated $AppName did not become healthy at $HealthUrl within $HealthTimeout"
+        Write-Warning "!!! Rolling back to the previous version"
+        # Stop-Process would kill it outright; stop signals the app's shutdown event first
+        & $appPath stop --pid $app.Id --timeout 30s
+        if (-not $app.WaitForExit(5000)) {
+            Stop-Process -Id $app.Id -Force -ErrorAction SilentlyContinue
+            $app.WaitForExit()
+        }
+        Move-Item -Force $previousPath $appPath
+
+        $app = Start-Process -FilePath $appPath -ArgumentList @("serve", "--http", $HttpAddr) -WorkingDirectory $appDir -NoNewWindow -PassThru
+    }
+}
 
-exit $LASTEXITCODE
+$app.WaitForExit()
+exit $app.ExitCode
@@ -2,13 +2,25 @@
 # run-with-update.sh
 # Wrapper script to check for updates, then start the app
 # All app logging goes to app.log and the database
+#
+# After an update the new version must pass a health check; if it does not,
+# the previous executable is restored and started instead.
+#
+# Environment overrides:
+#   HTTP_ADDR       address passed to serve --http (default 127.0.0.1:8090)
+#   HEALTH_URL      health endpoint to poll (default http://$HTTP_ADDR/health)
+#   HEALTH_TIMEOUT  how long the new version has to become healthy (default 60s)
 
 APP_NAME="${1:-sandstorm-tracker}"
+HTTP_ADDR="${HTTP_ADDR:-127.0.0.1:8090}"
+HEALTH_URL="${HEALTH_URL:-http://$HTTP_ADDR/health}"
+HEALTH_TIMEOUT="${HEALTH_TIMEOUT:-60s}"
 
 # Get app directory (parent of scripts folder)
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 APP_DIR="$(dirname "$SCRIPT_DIR")"
 APP_PATH="$APP_DIR/$APP_NAME"
+PREVIOUS_PATH="$APP_PATH.old"
 
 # Check if app exists
 if [ ! -f "$APP_PATH" ]; then
@@ -20,9 +32,32 @@ fi
 chmod +x "$APP_PATH"
 
 echo "Checking for updates..."
-"$APP_PATH" update
+rm -f "$PREVIOUS_PATH"
+"$APP_PATH" update --keep-previous
 
 echo "Starting server..."
-"$APP_PATH" serve
+"$APP_PATH" serve --http "$HTTP_ADDR" &
+APP_PID=$!
+
+# Forward stop signals to the app
+trap 'kill -TERM "$APP_PID" 2>/dev/null' TERM INT
+
+# Only a fresh update leaves the previous executable behind
+if [ -f "$PREVIOUS_PATH" ]; then
+    if "$APP_PATH" wait-healthy --url "$HEALTH_URL" --timeout "$HEALTH_TIMEOUT"; then
+        rm -f "$PREVIOUS_PATH"
+    else
+        echo "!!! The updated $APP_NAME did not become healthy at $HEALTH_URL within $HEALTH_TIMEOUT" >&2
+        echo "!!! Rolling back to the previous version" >&2
+        kill -TERM "$APP_PID" 2>/dev/null
+        wait "$APP_PID"
+        mv -f "$PREVIOUS_PATH" "$APP_PATH"
+
+        "$APP_PATH" serve --http "$HTTP_ADDR" &
+        APP_PID=$!
+    fi
+fi
+
+wait "$APP_PID"
 
 exit $?
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDebouncer records every score update the handlers ask for
+type countingDebouncer struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (d *countingDebouncer) record(kind string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, kind)
+}
+
+func (d *countingDebouncer) TriggerScoreUpdate(serverID string) { d.record("debounced") }
+func (d *countingDebouncer) TriggerScoreUpdateFixed(serverID string, delay time.Duration) {
+	d.record("fixed")
+}
+func (d *countingDebouncer) ExecuteImmediately(serverID string) { d.record("immediate") }
+
+func (d *countingDebouncer) Calls() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.calls...)
+}
+
+// TestCatchupSkipsScoreUpdates replays a whole match in catchup mode and checks that none of
+// its kills, objectives, round end or game over schedule a score update, leaving the single
+// refresh after the catchup as the only one for the match
+func TestCatchupSkipsScoreUpdates(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-catchup-scores"
+
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	debouncer := &countingDebouncer{}
+	appWrapper := NewTestAppWrapper(testApp)
+	handlers.NewGameEventHandlers(appWrapper, debouncer).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+
+	lines := []string{
+		`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M16A4_C_2147481419`,
+		`[2025.11.08-14.05.00:000][ 50]LogGameplayEvents: Display: Objective 0 was captured for team 0 from team 1 by ArmoredBear[76561198995742987].`,
+		`[2025.11.08-14.08.00:000][ 80]LogGameplayEvents: Display: Objective 1 owned by team 1 was destroyed for team 0 by ArmoredBear[76561198995742987].`,
+		`[2025.11.08-14.10.00:000][100]LogGameMode: Display: Round Over: Team 0 won (win reason: Objective)`,
+		`[2025.11.08-14.11.00:000][110]LogSession: Display: AINSGameSession::HandleMatchHasEnded`,
+	}
+	catchupCtx := parser.WithCatchupMode(ctx)
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(catchupCtx, line, serverID, "test.log"))
+	}
+
+	// The replay still recorded the match
+	player, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+	stats, err := testApp.FindFirstRecordByFilter("match_player_stats", "player = {:player}",
+		map[string]any{"player": player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.GetInt("kills"))
+	assert.Equal(t, 1, stats.GetInt("objectives_captured"))
+
+	assert.Empty(t, debouncer.Calls(), "replayed events must not update scores")
+
+	// Live events still do
+	live := []string{
+		`[2025.11.08-14.20.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.25.00:000][ 50]LogGameplayEvents: Display: Objective 0 was captured for team 0 from team 1 by ArmoredBear[76561198995742987].`,
+	}
+	for _, line := range live {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+	assert.Equal(t, []string{"fixed"}, debouncer.Calls())
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"strings"
 	"testing"
 	"time"
 
 	"sandstorm-tracker/internal/database"
 	"sandstorm-tracker/internal/events"
 	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
 	_ "sandstorm-tracker/migrations"
 
 	"github.com/pocketbase/pocketbase/core"
@@ -440,6 +442,58 @@ func TestLogFileCreatedEvent(t *testing.T) {
 	log.Printf("[TEST] Log file created event successfully ended stale match %s with crashed status", matchID)
 }
 
+// TestServerCrashEndsMatch checks a fatal error in the log ends the active match as crashed with
+// the error as its crash reason, without waiting for the next log file
+func TestServerCrashEndsMatch(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-crash"
+
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+
+	lines := []string{
+		`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+	}
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+
+	crash := []string{
+		`[2025.11.08-14.03.12:114][512]LogWindows: Error: === Critical error: ===`,
+		`[2025.11.08-14.03.12:114][512]LogWindows: Error: Fatal error: [File:D:/Build/++UE4/Sync/Engine/Source/Runtime/Core/Private/GenericPlatform/GenericPlatformMemory.cpp] [Line: 200]`,
+		`[2025.11.08-14.03.12:115][512]LogWindows: Error: [Callstack] 0x00007ff7d3a1c2e0 InsurgencyServer-Win64-Shipping.exe!UnknownFunction []`,
+	}
+	for _, line := range crash {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	record, err := testApp.FindRecordById("matches", match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "crashed", record.GetString("status"))
+	assert.Equal(t, "Fatal error: [File:D:/Build/++UE4/Sync/Engine/Source/Runtime/Core/Private/GenericPlatform/GenericPlatformMemory.cpp] [Line: 200]",
+		record.GetString("crash_reason"))
+	assert.Equal(t, 3, record.GetDateTime("end_time").Time().Minute(), "The match should end when the server crashed")
+
+	_, err = database.GetActiveMatch(ctx, appWrapper, serverID)
+	assert.Error(t, err, "No match should be active after the crash")
+
+	summary, err := database.GetMatchSummary(ctx, appWrapper, match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, record.GetString("crash_reason"), summary.CrashReason)
+}
+
 // TestRoundEndWinnerTeam tests that winner_team is set in handleMatchEnd only when winning team matches player_team
 func TestRoundEndWinnerTeam(t *testing.T) {
 	testApp, err := tests.NewTestApp(t.TempDir())
@@ -580,3 +634,189 @@ func TestRoundEndWinnerTeam(t *testing.T) {
 
 	log.Printf("[TEST] Round end events correctly set winner_team only when winning team matches player_team")
 }
+
+// TestMatchOutcomeWinLoss tests that the match winner is decided from round wins at game over
+// and that connected players get a win, loss or tie based on their team
+func TestMatchOutcomeWinLoss(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-outcome"
+
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+	gameHandlers := handlers.NewGameEventHandlers(appWrapper, nil)
+	gameHandlers.RegisterHooks()
+
+	// Lines are written for 14:xx and shifted to the given hour, so each match's lines are distinct
+	process := func(hour string, line string) {
+		t.Helper()
+		line = strings.Replace(line, "[2025.11.08-14.", "[2025.11.08-"+hour+".", 1)
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	playMatch := func(hour string, lines []string) *database.Match {
+		t.Helper()
+		process(hour, `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`)
+		match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+		require.NoError(t, err)
+
+		for _, line := range lines {
+			process(hour, line)
+		}
+		process(hour, `[2025.11.08-14.30.00:000][900]LogSession: Display: AINSGameSession::HandleMatchHasEnded`)
+		return match
+	}
+
+	resultFor := func(matchID, steamID string) string {
+		t.Helper()
+		player, err := database.GetPlayerByExternalID(ctx, appWrapper, steamID)
+		require.NoError(t, err)
+		stats, err := appWrapper.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": matchID, "player": player.ID})
+		require.NoError(t, err)
+		return stats.GetString("result")
+	}
+
+	// Kills reveal each player's team: ArmoredBear is Security (0), Rabbit is Insurgents (1)
+	teamKills := []string{
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.02.00:000][ 20]LogGameplayEvents: Display: Rabbit[76561198995742956, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+	}
+
+	// Match 1: Security wins 2-1
+	match1 := playMatch("14", append(teamKills,
+		`[2025.11.08-14.05.00:000][100]LogGameplayEvents: Display: Round 1 Over: Team 0 won (win reason: Elimination)`,
+		`[2025.11.08-14.10.00:000][200]LogGameplayEvents: Display: Round 2 Over: Team 1 won (win reason: Elimination)`,
+		`[2025.11.08-14.15.00:000][300]LogGameplayEvents: Display: Round 3 Over: Team 0 won (win reason: Objective)`,
+	))
+
+	record1, err := testApp.FindRecordById("matches", match1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, record1.GetInt("winning_team"), "Security should win the match 2-1")
+	assert.Equal(t, 2, record1.GetInt("team_0_round_wins"))
+	assert.Equal(t, 1, record1.GetInt("team_1_round_wins"))
+	assert.Equal(t, database.MatchResultWin, resultFor(match1.ID, "76561198995742987"))
+	assert.Equal(t, database.MatchResultLoss, resultFor(match1.ID, "76561198995742956"))
+
+	// Match 2: 1-1 is a tie with no winning team
+	match2 := playMatch("15", append(teamKills,
+		`[2025.11.08-14.05.00:000][100]LogGameplayEvents: Display: Round 1 Over: Team 1 won (win reason: Elimination)`,
+		`[2025.11.08-14.10.00:000][200]LogGameplayEvents: Display: Round 2 Over: Team 0 won (win reason: Elimination)`,
+	))
+
+	record2, err := testApp.FindRecordById("matches", match2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.NoWinningTeam, record2.GetInt("winning_team"), "A tied match should have no winning team")
+	assert.Equal(t, database.MatchResultTie, resultFor(match2.ID, "76561198995742987"))
+	assert.Equal(t, database.MatchResultTie, resultFor(match2.ID, "76561198995742956"))
+
+	// Match 3: the server crashes mid-match (new log file) - no winner and no results
+	process("16", `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`)
+	match3, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+	for _, line := range append(teamKills, `[2025.11.08-14.05.00:000][100]LogGameplayEvents: Display: Round 1 Over: Team 0 won (win reason: Elimination)`) {
+		process("16", line)
+	}
+	require.NoError(t, events.NewCreator(appWrapper).CreateEvent(events.TypeLogFileCreated, serverID, map[string]any{"timestamp": time.Now()}))
+
+	record3, err := testApp.FindRecordById("matches", match3.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "crashed", record3.GetString("status"))
+	assert.Equal(t, database.NoWinningTeam, record3.GetInt("winning_team"), "A crashed match should have no winning team")
+	assert.Empty(t, resultFor(match3.ID, "76561198995742987"))
+
+	// ArmoredBear's record across the server: one win, one tie
+	wins, losses, ties := 0, 0, 0
+	for _, matchID := range []string{match1.ID, match2.ID, match3.ID} {
+		switch resultFor(matchID, "76561198995742987") {
+		case database.MatchResultWin:
+			wins++
+		case database.MatchResultLoss:
+			losses++
+		case database.MatchResultTie:
+			ties++
+		}
+	}
+	assert.Equal(t, []int{1, 0, 1}, []int{wins, losses, ties})
+}
+
+// TestScenarioNormalization checks map load and map travel lines for the same kind of scenario
+// create matches with the same mode, with the side stored separately in player_team
+func TestScenarioNormalization(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-scenarios"
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Scenario Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+
+	cases := []struct {
+		name     string
+		line     string
+		wantMode string
+		wantSide string
+	}{
+		{
+			name:     "checkpoint load",
+			line:     `[2025.11.08-13.59.15:803][  0]LogLoad: LoadMap: /Game/Maps/Ministry/Ministry?Name=Player?Scenario=Scenario_Ministry_Checkpoint_Security?MaxPlayers=8?Lighting=Day`,
+			wantMode: "Checkpoint",
+			wantSide: "Security",
+		},
+		{
+			name:     "checkpoint travel",
+			line:     `[2025.11.08-14.30.00:000][ 10]LogGameMode: ProcessServerTravel: Town?Scenario=Scenario_Hideout_Checkpoint_Insurgents?Game=CheckpointHardcore`,
+			wantMode: "Checkpoint",
+			wantSide: "Insurgents",
+		},
+		{
+			name:     "push load",
+			line:     `[2025.11.08-15.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Oilfield/Oilfield?Name=Player?Scenario=Scenario_Refinery_Push_Insurgents?MaxPlayers=28?Game=Push?Lighting=Day`,
+			wantMode: "Push",
+			wantSide: "Insurgents",
+		},
+		{
+			name:     "push travel",
+			line:     `[2025.11.08-15.30.00:000][ 20]LogGameMode: ProcessServerTravel: Oilfield?Scenario=Scenario_Refinery_Push_Security?Game=Push`,
+			wantMode: "Push",
+			wantSide: "Security",
+		},
+		{
+			name:     "skirmish load",
+			line:     `[2025.11.08-16.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Town_Skirmish?MaxPlayers=16?Game=Skirmish?Lighting=Night`,
+			wantMode: "Skirmish",
+			wantSide: "",
+		},
+		{
+			name:     "skirmish travel",
+			line:     `[2025.11.08-16.30.00:000][ 30]LogGameMode: ProcessServerTravel: Town?Scenario=Scenario_Hideout_Skirmish?Game=CheckpointHardcore`,
+			wantMode: "Skirmish",
+			wantSide: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, p.ParseAndProcess(ctx, tc.line, serverID, "test.log"))
+
+			match, err := database.GetActiveMatch(ctx, testApp, serverID)
+			require.NoError(t, err)
+			record, err := testApp.FindRecordById("matches", match.ID)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.wantMode, record.GetString("mode"))
+			assert.Equal(t, tc.wantSide, record.GetString("player_team"))
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package integration
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites the golden files from the current results instead of comparing against them:
+//
+//	go test ./tests/integration -run TestGoldenReplay -update
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden/*.json from the current replay results")
+
+// goldenResult is everything a replayed fixture leaves in the database that the stats pages rely on.
+// Record IDs and wall clock times are left out so results compare across runs and time zones.
+type goldenResult struct {
+	Players []goldenPlayer `json:"players"`
+	Matches []goldenMatch  `json:"matches"`
+}
+
+type goldenPlayer struct {
+	SteamID string `json:"steam_id"`
+	Name    string `json:"name"`
+}
+
+type goldenMatch struct {
+	Map            string              `json:"map"`
+	Scenario       string              `json:"scenario"`
+	Mode           string              `json:"mode"`
+	Status         string              `json:"status"`
+	Round          int                 `json:"round"`
+	WinnerTeam     int                 `json:"winner_team"`
+	Team0RoundWins int                 `json:"team_0_round_wins"`
+	Team1RoundWins int                 `json:"team_1_round_wins"`
+	NumObjectives  int                 `json:"num_objectives"`
+	CrashReason    string              `json:"crash_reason,omitempty"`
+	Ended          bool                `json:"ended"`
+	Objectives     []goldenObjective   `json:"objectives"`
+	Players        []goldenMatchPlayer `json:"players"`
+}
+
+type goldenObjective struct {
+	Objective string `json:"objective"`
+	Action    string `json:"action"`
+	Team      int    `json:"team"`
+}
+
+type goldenMatchPlayer struct {
+	SteamID             string         `json:"steam_id"`
+	Name                string         `json:"name"`
+	Team                int            `json:"team"`
+	Kills               int            `json:"kills"`
+	Assists             int            `json:"assists"`
+	Deaths              int            `json:"deaths"`
+	FriendlyFireKills   int            `json:"friendly_fire_kills"`
+	Headshots           int            `json:"headshots"`
+	BestStreak          int            `json:"best_streak"`
+	ObjectivesCaptured  int            `json:"objectives_captured"`
+	ObjectivesDestroyed int            `json:"objectives_destroyed"`
+	Result              string         `json:"result"`
+	Connected           bool           `json:"connected"`
+	WeaponKills         map[string]int `json:"weapon_kills"`
+}
+
+// TestGoldenReplay feeds every testdata/golden/*.log fixture through the parser and game event
+// handlers line by line, as the watcher does, and compares the resulting matches, player stats and
+// weapon stats against the fixture's golden JSON.
+//
+// The fixtures are trimmed from real server logs, with other players' names, Steam IDs and IPs
+// replaced: checkpoint (a full two round match), checkpoint_hardcore (several players, shared
+// kills and objectives), push (a checkpoint match travelling into push, with objectives logged
+// before the kill that completed them) and crash_restart (a fatal error mid-match followed by
+// the next log's match).
+func TestGoldenReplay(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "golden", "*.log"))
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, logPath := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(logPath), ".log")
+		t.Run(name, func(t *testing.T) {
+			got, err := json.MarshalIndent(replayFixture(t, logPath), "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			goldenPath := strings.TrimSuffix(logPath, ".log") + ".json"
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(goldenPath, got, 0644))
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			require.NoError(t, err, "missing golden file, run the test with -update to create it")
+			assert.Equal(t, string(want), string(got), "replay of %s no longer matches %s (run with -update if the change is intended)", logPath, goldenPath)
+		})
+	}
+}
+
+// replayFixture processes a log through a fresh app and collects the results
+func replayFixture(t *testing.T, logPath string) goldenResult {
+	t.Helper()
+
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(testApp.Cleanup)
+
+	ctx := context.Background()
+	serverID := "golden-server"
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Golden Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+
+	file, err := os.Open(logPath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		require.NoError(t, p.ParseAndProcess(ctx, scanner.Text(), serverID, filepath.Base(logPath)))
+	}
+	require.NoError(t, scanner.Err())
+
+	return collectGoldenResult(t, testApp)
+}
+
+// collectGoldenResult reads the players and matches in a stable order
+func collectGoldenResult(t *testing.T, app core.App) goldenResult {
+	t.Helper()
+
+	result := goldenResult{Players: []goldenPlayer{}, Matches: []goldenMatch{}}
+
+	players, err := app.FindRecordsByFilter("players", "", "name", -1, 0)
+	require.NoError(t, err)
+	for _, player := range players {
+		result.Players = append(result.Players, goldenPlayer{
+			SteamID: player.GetString("external_id"),
+			Name:    player.GetString("name"),
+		})
+	}
+
+	matches, err := app.FindRecordsByFilter("matches", "", "start_time,created", -1, 0)
+	require.NoError(t, err)
+	for _, match := range matches {
+		golden := goldenMatch{
+			Map:            match.GetString("map"),
+			Scenario:       match.GetString("scenario"),
+			Mode:           match.GetString("mode"),
+			Status:         match.GetString("status"),
+			Round:          match.GetInt("round"),
+			WinnerTeam:     match.GetInt("winner_team"),
+			Team0RoundWins: match.GetInt("team_0_round_wins"),
+			Team1RoundWins: match.GetInt("team_1_round_wins"),
+			NumObjectives:  match.GetInt("num_objectives"),
+			CrashReason:    match.GetString("crash_reason"),
+			Objectives:     []goldenObjective{},
+			Players:        []goldenMatchPlayer{},
+		}
+		// Game over ends a match at the time it is processed, so only whether it ended is compared
+		golden.Ended = !match.GetDateTime("end_time").IsZero()
+
+		objectives, err := app.FindRecordsByFilter("objective_events", "match = {:match}", "timestamp,created", -1, 0,
+			map[string]any{"match": match.Id})
+		require.NoError(t, err)
+		for _, objective := range objectives {
+			golden.Objectives = append(golden.Objectives, goldenObjective{
+				Objective: objective.GetString("objective"),
+				Action:    objective.GetString("action"),
+				Team:      objective.GetInt("team"),
+			})
+		}
+
+		stats, err := app.FindRecordsByFilter("match_player_stats", "match = {:match}", "", -1, 0,
+			map[string]any{"match": match.Id})
+		require.NoError(t, err)
+		app.ExpandRecords(stats, []string{"player"}, nil)
+		for _, stat := range stats {
+			player := stat.ExpandedOne("player")
+			require.NotNil(t, player)
+
+			weapons, err := app.FindRecordsByFilter("match_weapon_stats", "match = {:match} && player = {:player}", "", -1, 0,
+				map[string]any{"match": match.Id, "player": player.Id})
+			require.NoError(t, err)
+			weaponKills := make(map[string]int, len(weapons))
+			for _, weapon := range weapons {
+				weaponKills[weapon.GetString("weapon_name")] += weapon.GetInt("kills")
+			}
+
+			golden.Players = append(golden.Players, goldenMatchPlayer{
+				SteamID:             player.GetString("external_id"),
+				Name:                player.GetString("name"),
+				Team:                stat.GetInt("team"),
+				Kills:               stat.GetInt("kills"),
+				Assists:             stat.GetInt("assists"),
+				Deaths:              stat.GetInt("deaths"),
+				FriendlyFireKills:   stat.GetInt("friendly_fire_kills"),
+				Headshots:           stat.GetInt("headshots"),
+				BestStreak:          stat.GetInt("best_streak"),
+				ObjectivesCaptured:  stat.GetInt("objectives_captured"),
+				ObjectivesDestroyed: stat.GetInt("objectives_destroyed"),
+				Result:              stat.GetString("result"),
+				Connected:           stat.GetBool("is_currently_connected"),
+				WeaponKills:         weaponKills,
+			})
+		}
+		slices.SortFunc(golden.Players, func(a, b goldenMatchPlayer) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.SteamID, b.SteamID))
+		})
+
+		result.Matches = append(result.Matches, golden)
+	}
+
+	return result
+}
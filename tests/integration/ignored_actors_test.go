@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIgnoredActorsNeverBecomePlayers checks AI matched by the ignore list never gets a player
+// record, whether it kills, dies, captures an objective or joins, while real players still do
+func TestIgnoredActorsNeverBecomePlayers(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-ignored-actors"
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Coop Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+
+	ignoreList, err := parser.NewIgnoreList([]string{"0"}, []string{"Commander"}, []string{"Bot_"}, []string{`^AI[0-9]+$`})
+	require.NoError(t, err)
+	p.SetIgnoreList(ignoreList)
+
+	for _, line := range []string{
+		`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.00.10:000][ 10]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		// AI with odd IDs that slip past the INVALID check
+		`[2025.11.08-14.01.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Bot_Rifleman[12345, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.01.10:000][ 30]LogGameplayEvents: Display: AI42[0, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+		`[2025.11.08-14.01.20:000][ 40]LogGameplayEvents: Display: Objective 0 was captured for team 0 from team 1 by Commander[98765], ArmoredBear[76561198995742987].`,
+		`[2025.11.08-14.01.30:000][ 50]LogNet: Join succeeded: Bot_Sniper`,
+		`[2025.11.08-14.01.40:000][ 60]LogGameplayEvents: Display: Rifleman[INVALID, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480341`,
+	} {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	players, err := testApp.FindAllRecords("players")
+	require.NoError(t, err)
+	names := make([]string, 0, len(players))
+	for _, player := range players {
+		names = append(names, player.GetString("name"))
+	}
+	assert.Equal(t, []string{"ArmoredBear"}, names, "only the real player should have a player record")
+
+	// The bot kill still counts for the real player
+	armoredBear, err := testApp.FindFirstRecordByData("players", "name", "ArmoredBear")
+	require.NoError(t, err)
+	stats, err := testApp.FindAllRecords("match_weapon_stats", dbx.HashExp{"player": armoredBear.Id})
+	require.NoError(t, err)
+	kills := 0
+	for _, stat := range stats {
+		kills += stat.GetInt("kills")
+	}
+	assert.Equal(t, 1, kills)
+}
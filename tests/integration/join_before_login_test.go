@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJoinBeforeLogin checks a join processed before its login leaves a single player record
+// with the match stats, whether the login or a kill is the first to bring the Steam ID
+func TestJoinBeforeLogin(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-join-before-login"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	process := func(lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+		}
+	}
+
+	process(`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`)
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	process(
+		// ArmoredBear's join is processed before the login that carries the Steam ID
+		`[2025.11.08-14.00.10:000][ 10]LogNet: Join succeeded: ArmoredBear`,
+		`[2025.11.08-14.00.11:000][ 11]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.01.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		// Kestrel scores a kill (creating a Steam player) before the login arrives
+		`[2025.11.08-14.00.20:000][ 30]LogNet: Join succeeded: Kestrel`,
+		`[2025.11.08-14.01.10:000][ 40]LogGameplayEvents: Display: Kestrel[76561198995742911, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+		`[2025.11.08-14.01.20:000][ 50]LogNet: Login request: ?InitialConnectTimeout=30?Name=Kestrel userId: SteamNWI:76561198995742911 platform: SteamNWI`,
+	)
+
+	for _, tc := range []struct {
+		name    string
+		steamID string
+		kills   int
+	}{
+		{"ArmoredBear", "76561198995742987", 1},
+		{"Kestrel", "76561198995742911", 1},
+	} {
+		players, err := baseApp.FindAllRecords("players", dbx.HashExp{"name": tc.name})
+		require.NoError(t, err)
+		require.Len(t, players, 1, "%s should have a single player record", tc.name)
+		assert.Equal(t, tc.steamID, players[0].GetString("external_id"))
+
+		rows, err := baseApp.FindAllRecords("match_player_stats", dbx.HashExp{"match": match.ID, "player": players[0].Id})
+		require.NoError(t, err)
+		require.Len(t, rows, 1, "%s should have a single stats row", tc.name)
+		assert.Equal(t, tc.kills, rows[0].GetInt("kills"))
+		assert.Equal(t, 1, rows[0].GetInt("session_count"))
+		assert.True(t, rows[0].GetBool("is_currently_connected"))
+	}
+}
+
+// TestLinkNameOnlyPlayer_ScopedToMatch checks a login only links the name-only player who is in
+// the match, not another one left over with the same name
+func TestLinkNameOnlyPlayer_ScopedToMatch(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-link-scope"
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+	match, err := database.CreateMatch(ctx, testApp, serverID, nil, nil, nil)
+	require.NoError(t, err)
+
+	leftover, err := database.CreatePlayer(ctx, testApp, "", "Kestrel")
+	require.NoError(t, err)
+	joined, err := database.CreatePlayer(ctx, testApp, "", "Kestrel")
+	require.NoError(t, err)
+	require.NoError(t, database.UpsertMatchPlayerStats(ctx, testApp, match.ID, joined.ID, nil, nil))
+
+	player, err := database.LinkNameOnlyPlayer(ctx, testApp, match.ID, "SteamNWI", "76561198995742911", "Kestrel")
+	require.NoError(t, err)
+	require.NotNil(t, player)
+	assert.Equal(t, joined.ID, player.ID)
+
+	record, err := testApp.FindRecordById("players", joined.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "76561198995742911", record.GetString("external_id"))
+	record, err = testApp.FindRecordById("players", leftover.ID)
+	require.NoError(t, err)
+	assert.Empty(t, record.GetString("external_id"), "the leftover player is not in the match")
+}
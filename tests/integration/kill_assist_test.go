@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitLineKillAssist checks an assist logged on its own line after the kill is credited to
+// the assister, alongside the single-line multi-killer form, and that an assist too late or for
+// another victim is not
+func TestSplitLineKillAssist(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-split-assist"
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Split Assist Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+
+	for _, line := range []string{
+		`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Ministry/Ministry?Name=Player?Scenario=Scenario_Ministry_Checkpoint_Security?MaxPlayers=8?Lighting=Day`,
+		// Split-line kill: Rabbit and Kestrel assist ArmoredBear
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198000000001, team 0] killed Marksman[INVALID, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.01.00:100][ 10]LogGameplayEvents: Display: Rabbit[76561198000000002, team 0] assisted in killing Marksman[INVALID, team 1]`,
+		`[2025.11.08-14.01.01:000][ 11]LogGameplayEvents: Display: Kestrel[76561198000000003, team 0] + Rabbit[76561198000000002, team 0] assisted in killing Marksman[INVALID, team 1]`,
+		// Single-line multi-killer kill still credits Rabbit's assist from the kill line
+		`[2025.11.08-14.02.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198000000001, team 0] + Rabbit[76561198000000002, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M4A1_C_2147480340`,
+		// Another victim, and a line long after the kill: neither is an assist
+		`[2025.11.08-14.02.00:500][ 21]LogGameplayEvents: Display: Kestrel[76561198000000003, team 0] assisted in killing Sniper[INVALID, team 1]`,
+		`[2025.11.08-14.02.30:000][ 22]LogGameplayEvents: Display: Kestrel[76561198000000003, team 0] assisted in killing Rifleman[INVALID, team 1]`,
+	} {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	stat := func(steamID, field string) int {
+		t.Helper()
+		player, err := database.GetPlayerByExternalID(ctx, appWrapper, steamID)
+		require.NoError(t, err)
+		stats, err := appWrapper.FindFirstRecordByFilter(
+			"match_player_stats",
+			"match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": player.ID},
+		)
+		require.NoError(t, err)
+		return stats.GetInt(field)
+	}
+
+	assert.Equal(t, 2, stat("76561198000000001", "kills"), "ArmoredBear keeps both kills")
+	assert.Equal(t, 0, stat("76561198000000001", "assists"))
+	assert.Equal(t, 2, stat("76561198000000002", "assists"), "Rabbit assists once per kill, however often it is logged")
+	assert.Equal(t, 0, stat("76561198000000002", "kills"))
+	assert.Equal(t, 1, stat("76561198000000003", "assists"), "only Kestrel's first assist line follows a kill on the same victim")
+
+	// The assists survive a rebuild from the stored events
+	_, err = handlers.RecomputeMatchStats(ctx, appWrapper, match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stat("76561198000000002", "assists"))
+	assert.Equal(t, 1, stat("76561198000000003", "assists"))
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLeaderboardWindow checks the leaderboard counts kills from matches that ended inside the
+// window and leaves out those that ended before it
+func TestLeaderboardWindow(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-leaderboard"
+	serverRecordID, err := database.GetOrCreateServer(ctx, testApp, serverID, "Leaderboard Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+
+	process := func(lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+		}
+	}
+
+	// An old match with one kill, then a recent one with one kill
+	process(
+		`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.00.10:000][ 10]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.01.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+	)
+	oldMatch, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+	process(
+		`[2025.11.08-15.00.00:000][ 30]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Hideout_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-15.01.00:000][ 40]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480340`,
+	)
+	recentMatch, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+	require.NotEqual(t, oldMatch.ID, recentMatch.ID)
+
+	now := time.Now()
+	for id, endTime := range map[string]time.Time{
+		oldMatch.ID:    now.Add(-10 * 24 * time.Hour),
+		recentMatch.ID: now.Add(-2 * time.Hour),
+	} {
+		record, err := testApp.FindRecordById("matches", id)
+		require.NoError(t, err)
+		record.Set("end_time", endTime.UTC())
+		require.NoError(t, testApp.Save(record))
+	}
+
+	killsFor := func(filter database.LeaderboardFilter) int {
+		t.Helper()
+		entries, err := database.GetLeaderboard(ctx, testApp, filter, now, 10, 0)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			if entry.Name == "ArmoredBear" {
+				return entry.Kills
+			}
+		}
+		return 0
+	}
+
+	assert.Equal(t, 1, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowDay}), "only the recent kill is inside the day")
+	assert.Equal(t, 1, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowWeek}), "the old kill is outside the week")
+	assert.Equal(t, 2, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowMonth}))
+	assert.Equal(t, 2, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowAll}))
+
+	// Server and mode filters
+	assert.Equal(t, 2, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowAll, ServerID: serverRecordID}))
+	assert.Equal(t, 0, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowAll, ServerID: "missing"}))
+	assert.Equal(t, 1, killsFor(database.LeaderboardFilter{Window: database.LeaderboardWindowAll, Mode: recentMatch.Mode}))
+}
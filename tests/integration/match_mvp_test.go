@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatchMVP plays a match between two players and checks the higher scorer is recorded as MVP
+// when the match ends, with ties broken by K/D
+func TestMatchMVP(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-mvp"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`, serverID, "test.log"))
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	lines := []string{
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.02.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.03.00:000][ 30]LogGameplayEvents: Display: Rabbit[76561198995742956, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+	}
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	bear, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+	rabbit, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742956")
+	require.NoError(t, err)
+
+	setScore := func(playerID string, score int) {
+		t.Helper()
+		stats, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": playerID})
+		require.NoError(t, err)
+		stats.Set("score", score)
+		require.NoError(t, baseApp.Save(stats))
+	}
+
+	// Rabbit has fewer kills but the higher scoreboard score, so score decides
+	setScore(bear.ID, 150)
+	setScore(rabbit.ID, 400)
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.30.00:000][900]LogSession: Display: AINSGameSession::HandleMatchHasEnded`, serverID, "test.log"))
+
+	matchRecord, err := baseApp.FindRecordById("matches", match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rabbit.ID, matchRecord.GetString("mvp_player"))
+
+	counts, err := database.GetPlayerMVPCounts(ctx, baseApp)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{rabbit.ID: 1}, counts)
+
+	t.Run("tie broken by K/D", func(t *testing.T) {
+		setScore(bear.ID, 400)
+
+		mvp, err := database.RecordMatchMVP(ctx, baseApp, match.ID, database.MVPMetricScore)
+		require.NoError(t, err)
+		assert.Equal(t, bear.ID, mvp, "equal scores should go to the better K/D (2/1 over 1/2)")
+	})
+
+	t.Run("kills metric", func(t *testing.T) {
+		setScore(bear.ID, 0)
+
+		mvp, err := database.RecordMatchMVP(ctx, baseApp, match.ID, database.MVPMetricKills)
+		require.NoError(t, err)
+		assert.Equal(t, bear.ID, mvp)
+	})
+
+	t.Run("no scoreboard falls back to computed score", func(t *testing.T) {
+		setScore(rabbit.ID, 0)
+
+		mvp, err := database.RecordMatchMVP(ctx, baseApp, match.ID, database.MVPMetricScore)
+		require.NoError(t, err)
+		assert.Equal(t, bear.ID, mvp)
+	})
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatchSummaryEndpoint plays a full match through the parser and checks the summary API
+func TestMatchSummaryEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-summary"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`, serverID, "test.log"))
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	lines := []string{
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.02.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.03.00:000][ 30]LogGameplayEvents: Display: Rabbit[76561198995742956, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+		`[2025.11.08-14.05.00:000][100]LogGameplayEvents: Display: Round 1 Over: Team 0 won (win reason: Elimination)`,
+		`[2025.11.08-14.10.00:000][200]LogGameplayEvents: Display: Round 2 Over: Team 1 won (win reason: Elimination)`,
+		`[2025.11.08-14.15.00:000][300]LogGameplayEvents: Display: Round 3 Over: Team 0 won (win reason: Objective)`,
+	}
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	// Scores come from the live scoreboard rather than the log; give Rabbit the higher score
+	// so ranking by score is observable
+	setScore := func(steamID string, score int) {
+		t.Helper()
+		player, err := database.GetPlayerByExternalID(ctx, appWrapper, steamID)
+		require.NoError(t, err)
+		stats, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": player.ID})
+		require.NoError(t, err)
+		stats.Set("score", score)
+		require.NoError(t, baseApp.Save(stats))
+	}
+	setScore("76561198995742987", 150)
+	setScore("76561198995742956", 400)
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.30.00:000][900]LogSession: Display: AINSGameSession::HandleMatchHasEnded`, serverID, "test.log"))
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		if err != nil {
+			t.Fatalf("failed to create test app: %v", err)
+		}
+		return testApp
+	}
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		handlers.Register(NewTestAppWrapper(app), e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:           "finished match summary",
+			Method:         http.MethodGet,
+			URL:            "/api/matches/" + match.ID,
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"map":"Farmhouse"`,
+				`"mode":"Checkpoint"`,
+				`"scenario":"Scenario_Farmhouse_Checkpoint_Security"`,
+				`"winning_team":0`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				var summary database.MatchSummary
+				if err := json.NewDecoder(res.Body).Decode(&summary); err != nil {
+					t.Fatalf("failed to decode summary: %v", err)
+				}
+
+				assert.Equal(t, match.ID, summary.ID)
+				assert.Positive(t, summary.DurationSeconds)
+				assert.Equal(t, []database.RoundResult{
+					{Round: 1, WinningTeam: 0},
+					{Round: 2, WinningTeam: 1},
+					{Round: 3, WinningTeam: 0},
+				}, summary.Rounds)
+
+				if assert.Len(t, summary.Teams, 2) {
+					assert.Equal(t, database.MatchTeamSummary{
+						Team: 0, Name: "Security", Players: 1, RoundWins: 2, Kills: 2, Deaths: 1, Score: 150, ComputedScore: 15,
+					}, summary.Teams[0])
+					assert.Equal(t, database.MatchTeamSummary{
+						Team: 1, Name: "Insurgents", Players: 1, RoundWins: 1, Kills: 1, Deaths: 2, Score: 400, ComputedScore: 0,
+					}, summary.Teams[1])
+				}
+
+				if assert.Len(t, summary.Players, 2) {
+					assert.Equal(t, "Rabbit", summary.Players[0].Name)
+					assert.Equal(t, 1, summary.Players[0].Rank)
+					assert.Equal(t, database.MatchResultLoss, summary.Players[0].Result)
+					assert.Equal(t, "ArmoredBear", summary.Players[1].Name)
+					assert.Equal(t, 2, summary.Players[1].Rank)
+					assert.Equal(t, 2, summary.Players[1].Kills)
+					assert.Equal(t, database.MatchResultWin, summary.Players[1].Result)
+				}
+			},
+		},
+		{
+			Name:            "unknown match",
+			Method:          http.MethodGet,
+			URL:             "/api/matches/missing",
+			ExpectedStatus:  http.StatusNotFound,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestObjectiveTimeline checks that each captured objective adds a timeline row, in log order
+func TestObjectiveTimeline(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-objectives"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`, serverID, "test.log"))
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	lines := []string{
+		`[2025.11.08-14.05.00:000][ 50]LogGameplayEvents: Display: Objective 0 was captured for team 0 from team 1 by ArmoredBear[76561198995742987], Kestrel[76561198995742999].`,
+		`[2025.11.08-14.10.00:000][100]LogGameplayEvents: Display: Objective 1 was captured for team 0 from team 1 by ArmoredBear[76561198995742987].`,
+	}
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	timeline, err := database.GetMatchObjectiveTimeline(ctx, baseApp, match.ID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 2)
+
+	assert.Equal(t, "A", timeline[0].Label())
+	assert.Equal(t, database.ObjectiveCaptured, timeline[0].Action)
+	assert.Equal(t, 0, timeline[0].Team)
+	assert.Equal(t, time.Date(2025, 11, 8, 14, 5, 0, 0, time.UTC), timeline[0].Timestamp.UTC())
+	require.Len(t, timeline[0].Players, 2)
+	assert.Equal(t, "ArmoredBear", timeline[0].Players[0].PlayerName)
+	assert.Equal(t, "Kestrel", timeline[0].Players[1].PlayerName)
+
+	assert.Equal(t, "B", timeline[1].Label())
+	assert.Equal(t, time.Date(2025, 11, 8, 14, 10, 0, 0, time.UTC), timeline[1].Timestamp.UTC())
+	require.Len(t, timeline[1].Players, 1)
+	assert.Equal(t, "76561198995742987", timeline[1].Players[0].SteamID)
+
+	// The timeline sits alongside the counters rather than replacing them
+	matchRecord, err := baseApp.FindRecordById("matches", match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, matchRecord.GetInt("round_objective"))
+
+	player, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+	stats, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+		map[string]any{"match": match.ID, "player": player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.GetInt("objectives_captured"))
+}
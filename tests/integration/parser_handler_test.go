@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
 	"sandstorm-tracker/internal/handlers"
 	"sandstorm-tracker/internal/parser"
 	_ "sandstorm-tracker/migrations"
@@ -510,3 +511,52 @@ func TestMultipleKillsStatAccumulation(t *testing.T) {
 		assert.Equal(t, 1, ws.GetInt("kills"), "Each weapon should have 1 kill")
 	}
 }
+
+// TestDuplicateKillLineCountedOnce tests that reprocessing the same kill line (restart, replay) does not double count
+func TestDuplicateKillLineCountedOnce(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server"
+
+	// Setup
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+	gameHandlers := handlers.NewGameEventHandlers(appWrapper, nil)
+	gameHandlers.RegisterHooks()
+
+	// Parse map load
+	mapLine := `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Ministry/Ministry?Name=Player?Scenario=Scenario_Ministry_Checkpoint_Security?MaxPlayers=8?Lighting=Day`
+	require.NoError(t, p.ParseAndProcess(ctx, mapLine, serverID, "test.log"))
+
+	// The same kill line is processed twice, the second time by a fresh parser in catchup mode (like a replay)
+	killLine := `[2025.11.08-14.00.03:000][  3]LogGameplayEvents: Display: TestPlayer[76561198995742987, team 0] killed Bot[INVALID, team 1] with BP_Firearm_AKM_C_2147480339`
+	require.NoError(t, p.ParseAndProcess(ctx, killLine, serverID, "test.log"))
+	replayParser := parser.NewLogParser(appWrapper, testApp.Logger())
+	require.NoError(t, replayParser.ParseAndProcess(parser.WithCatchupMode(ctx), killLine, serverID, "test.log"))
+
+	// A different kill in the same millisecond is still counted
+	otherKillLine := `[2025.11.08-14.00.03:000][  3]LogGameplayEvents: Display: TestPlayer[76561198995742987, team 0] killed Bot2[INVALID, team 1] with BP_Firearm_AKM_C_2147480339`
+	require.NoError(t, p.ParseAndProcess(ctx, otherKillLine, serverID, "test.log"))
+
+	killEvents, err := testApp.FindRecordsByFilter("events", "type = {:type}", "", -1, 0, map[string]any{"type": events.TypePlayerKill})
+	require.NoError(t, err)
+	assert.Len(t, killEvents, 2, "The duplicate kill line should create a single event")
+
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+	player, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+	stats, err := appWrapper.FindFirstRecordByFilter(
+		"match_player_stats",
+		"match = {:match} && player = {:player}",
+		map[string]any{"match": match.ID, "player": player.ID},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.GetInt("kills"), "The duplicate kill line should be counted once")
+}
@@ -0,0 +1,206 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlayerMergeEndpoint creates a name-only duplicate of a Steam player and checks merging
+// it moves or folds its stats, friendly fire and known IPs onto the Steam player
+func TestPlayerMergeEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-merge"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	process := func(lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+		}
+	}
+
+	// ArmoredBear goes 2/1 with the M4A1 in the first match
+	process(`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`)
+	firstMatch, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+	process(
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.02.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.03.00:000][ 30]LogGameplayEvents: Display: Rabbit[76561198995742956, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+		`[2025.11.08-14.30.00:000][900]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Hideout_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+	)
+	secondMatch, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	armoredBear, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+	kestrel, err := database.GetOrCreatePlayerBySteamID(ctx, baseApp, "76561198995742911", "Kestrel")
+	require.NoError(t, err)
+
+	// A name-only duplicate, as created when a join lands before its login: one kill in the
+	// first match, two kills and a team kill in the second
+	duplicate, err := database.CreatePlayer(ctx, baseApp, "", "ArmoredBear")
+	require.NoError(t, err)
+	require.NoError(t, database.UpsertMatchPlayerStats(ctx, baseApp, firstMatch.ID, duplicate.ID, nil, nil))
+	require.NoError(t, database.IncrementMatchPlayerStat(ctx, baseApp, firstMatch.ID, duplicate.ID, "kills"))
+	oneKill := int64(1)
+	require.NoError(t, database.UpsertMatchWeaponStats(ctx, baseApp, firstMatch.ID, duplicate.ID, "BP_Firearm_M4A1_C_2147480339", &oneKill, nil))
+	require.NoError(t, database.UpsertMatchPlayerStats(ctx, baseApp, secondMatch.ID, duplicate.ID, nil, nil))
+	for range 2 {
+		require.NoError(t, database.IncrementMatchPlayerStat(ctx, baseApp, secondMatch.ID, duplicate.ID, "kills"))
+	}
+	require.NoError(t, database.RecordFriendlyFireIncident(ctx, baseApp, &database.FriendlyFireIncident{
+		MatchID:   secondMatch.ID,
+		KillerID:  duplicate.ID,
+		VictimID:  kestrel.ID,
+		Weapon:    "M4A1",
+		Timestamp: time.Date(2025, 11, 8, 14, 35, 0, 0, time.UTC),
+	}))
+
+	// The duplicate's visit to the first match was a separate session, which adds to the Steam player's
+	duplicateRow, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+		dbx.Params{"match": firstMatch.ID, "player": duplicate.ID})
+	require.NoError(t, err)
+	duplicateRow.Set("session_count", 1)
+	duplicateRow.Set("total_play_time", 300)
+	require.NoError(t, baseApp.Save(duplicateRow))
+	steamRow, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+		dbx.Params{"match": firstMatch.ID, "player": armoredBear.ID})
+	require.NoError(t, err)
+	steamSessions, steamPlayTime := steamRow.GetInt("session_count"), steamRow.GetInt("total_play_time")
+
+	for id, ips := range map[string][]any{armoredBear.ID: {"10.0.0.1"}, duplicate.ID: {"10.0.0.1", "10.0.0.2"}} {
+		record, err := baseApp.FindRecordById("players", id)
+		require.NoError(t, err)
+		require.NoError(t, database.UpdatePlayerMetadata(baseApp, record, func(metadata map[string]any) {
+			metadata["knownIPs"] = ips
+		}))
+	}
+
+	superusers, err := baseApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	require.NoError(t, err)
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	require.NoError(t, baseApp.Save(superuser))
+	token, err := superuser.NewAuthToken()
+	require.NoError(t, err)
+
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		require.NoError(t, err)
+		return testApp
+	}
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		handlers.Register(NewTestAppWrapper(app), e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "requires a superuser",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/players/merge",
+			Body:            strings.NewReader(`{"source":"` + duplicate.ID + `","target":"` + armoredBear.ID + `"}`),
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "rejects merging a player into itself",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/players/merge",
+			Body:            strings.NewReader(`{"source":"` + armoredBear.ID + `","target":"76561198995742987"}`),
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"Source and target are the same player"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:           "merges the duplicate into the Steam player",
+			Method:         http.MethodPost,
+			URL:            "/api/admin/players/merge",
+			Body:           strings.NewReader(`{"source":"` + duplicate.ID + `","target":"76561198995742987"}`),
+			Headers:        map[string]string{"Authorization": token},
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"matchStats":2`,
+				`"weaponStats":1`,
+				`"friendlyFire":1`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				_, err := app.FindRecordById("players", duplicate.ID)
+				assert.Error(t, err, "the duplicate should be deleted")
+
+				// The shared match is folded into one row, the other match is moved over
+				rows, err := app.FindAllRecords("match_player_stats", dbx.HashExp{"player": armoredBear.ID})
+				require.NoError(t, err)
+				kills := map[string]int{}
+				for _, row := range rows {
+					kills[row.GetString("match")] += row.GetInt("kills")
+				}
+				assert.Len(t, rows, 2)
+				assert.Equal(t, map[string]int{firstMatch.ID: 3, secondMatch.ID: 2}, kills)
+				for _, row := range rows {
+					if row.GetString("match") == firstMatch.ID {
+						assert.Equal(t, steamSessions+1, row.GetInt("session_count"))
+						assert.Equal(t, steamPlayTime+300, row.GetInt("total_play_time"))
+					}
+				}
+
+				weapon, err := app.FindFirstRecordByFilter("match_weapon_stats", "match = {:match} && player = {:player}",
+					dbx.Params{"match": firstMatch.ID, "player": armoredBear.ID})
+				require.NoError(t, err)
+				assert.Equal(t, 3, weapon.GetInt("kills"))
+
+				totalKills, totalDeaths, err := database.GetPlayerTotalKD(context.Background(), app, armoredBear.ID)
+				require.NoError(t, err)
+				assert.Equal(t, 5, totalKills)
+				assert.Equal(t, 1, totalDeaths)
+
+				incident, err := app.FindFirstRecordByFilter("friendly_fire_incidents", "victim = {:victim}", dbx.Params{"victim": kestrel.ID})
+				require.NoError(t, err)
+				assert.Equal(t, armoredBear.ID, incident.GetString("killer"))
+
+				player, err := app.FindRecordById("players", armoredBear.ID)
+				require.NoError(t, err)
+				var metadata struct {
+					KnownIPs []string `json:"knownIPs"`
+				}
+				require.NoError(t, player.UnmarshalJSONField("metadata", &metadata))
+				assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, metadata.KnownIPs)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
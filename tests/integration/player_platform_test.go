@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlayerPlatforms checks players are keyed by platform and ID: a Steam and an Epic login
+// with the same ID are two players, and an Epic player first seen in a kill is claimed by its login
+func TestPlayerPlatforms(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-platforms"
+	_, err = database.GetOrCreateServer(ctx, testApp, serverID, "Platform Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+
+	for _, line := range []string{
+		`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.00.10:000][ 10]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.00.11:000][ 11]LogNet: Login request: ?InitialConnectTimeout=30?Name=Impostor userId: EOS:76561198995742987 platform: Epic`,
+		// Kestrel's hex Epic ID is first seen in a kill, before the login names the platform
+		`[2025.11.08-14.01.00:000][ 20]LogGameplayEvents: Display: Kestrel[0002a4c1f9e64b3d8e0c7f1b2a3d4e5f, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+		`[2025.11.08-14.01.10:000][ 30]LogNet: Login request: ?InitialConnectTimeout=30?Name=Kestrel userId: EOS:0002a4c1f9e64b3d8e0c7f1b2a3d4e5f platform: Epic`,
+	} {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	steam, err := database.GetPlayerByPlatformID(ctx, testApp, database.PlatformSteam, "76561198995742987")
+	require.NoError(t, err)
+	epic, err := database.GetPlayerByPlatformID(ctx, testApp, database.PlatformEpic, "76561198995742987")
+	require.NoError(t, err)
+	assert.NotEqual(t, steam.ID, epic.ID, "a Steam and an Epic login should be two players")
+	assert.Equal(t, "ArmoredBear", steam.Name)
+	assert.Equal(t, "Impostor", epic.Name)
+
+	kestrel, err := testApp.FindAllRecords("players", dbx.HashExp{"name": "Kestrel"})
+	require.NoError(t, err)
+	require.Len(t, kestrel, 1, "the kill and the login should be one player")
+	assert.Equal(t, database.PlatformEpic, kestrel[0].GetString("platform"))
+	assert.Equal(t, "0002a4c1f9e64b3d8e0c7f1b2a3d4e5f", kestrel[0].GetString("external_id"))
+
+	// A numeric Epic ID first seen in a kill could be a Steam ID, so it stays unknown until the login
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.02.00:000][ 40]LogGameplayEvents: Display: Wolf[12345678901234567, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`, serverID, "test.log"))
+	wolf, err := database.GetPlayerByExternalID(ctx, testApp, "12345678901234567")
+	require.NoError(t, err)
+	assert.Empty(t, wolf.Platform)
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.02.10:000][ 50]LogNet: Login request: ?InitialConnectTimeout=30?Name=Wolf userId: EOS:12345678901234567 platform: Epic`, serverID, "test.log"))
+	wolves, err := testApp.FindAllRecords("players", dbx.HashExp{"external_id": "12345678901234567"})
+	require.NoError(t, err)
+	require.Len(t, wolves, 1, "the login should claim the player the kill created")
+	assert.Equal(t, wolf.ID, wolves[0].Id)
+	assert.Equal(t, database.PlatformEpic, wolves[0].GetString("platform"))
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sandstorm-tracker/internal/config"
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rankedTestApp configures a ranked minimum on top of the test app wrapper
+type rankedTestApp struct {
+	*TestAppWrapper
+	minPlayers int
+}
+
+func (a *rankedTestApp) GetRankedConfig() config.RankedConfig {
+	return config.RankedConfig{MinPlayers: a.minPlayers}
+}
+
+// TestRankedMinPlayers checks a match that never reaches the ranked minimum is left out of a
+// player's lifetime K/D while a full match counts, and that lowering the minimum brings it back
+func TestRankedMinPlayers(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-ranked"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	app := &rankedTestApp{TestAppWrapper: NewTestAppWrapper(baseApp), minPlayers: 3}
+	handlers.NewGameEventHandlers(app, nil).RegisterHooks()
+	p := parser.NewLogParser(app, baseApp.Logger())
+
+	process := func(lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+		}
+	}
+
+	// A full match: three players, ArmoredBear goes 2/1
+	process(`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`)
+	fullMatch, err := database.GetActiveMatch(ctx, app, serverID)
+	require.NoError(t, err)
+	process(
+		`[2025.11.08-14.00.10:000][ 10]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.00.11:000][ 11]LogNet: Join succeeded: ArmoredBear`,
+		`[2025.11.08-14.00.12:000][ 12]LogNet: Login request: ?InitialConnectTimeout=30?Name=Rabbit userId: SteamNWI:76561198995742956 platform: SteamNWI`,
+		`[2025.11.08-14.00.13:000][ 13]LogNet: Join succeeded: Rabbit`,
+		`[2025.11.08-14.00.14:000][ 14]LogNet: Login request: ?InitialConnectTimeout=30?Name=Kestrel userId: SteamNWI:76561198995742911 platform: SteamNWI`,
+		`[2025.11.08-14.00.15:000][ 15]LogNet: Join succeeded: Kestrel`,
+		`[2025.11.08-14.01.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.02.00:000][ 30]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.03.00:000][ 40]LogGameplayEvents: Display: Rabbit[76561198995742956, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+	)
+
+	// A one-player match against bots: ArmoredBear goes 5/3
+	process(
+		`[2025.11.08-15.00.00:000][ 50]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Hideout_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-15.00.10:000][ 60]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-15.00.11:000][ 61]LogNet: Join succeeded: ArmoredBear`,
+	)
+	soloMatch, err := database.GetActiveMatch(ctx, app, serverID)
+	require.NoError(t, err)
+	require.NotEqual(t, fullMatch.ID, soloMatch.ID)
+	for i := 0; i < 5; i++ {
+		process(fmt.Sprintf(`[2025.11.08-15.01.%02d:000][ 70]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 255] with BP_Firearm_M4A1_C_2147480339`, i))
+	}
+	for i := 0; i < 3; i++ {
+		process(fmt.Sprintf(`[2025.11.08-15.02.%02d:000][ 80]LogGameplayEvents: Display: Rifleman[INVALID, team 255] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`, i))
+	}
+
+	full, err := baseApp.FindRecordById("matches", fullMatch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, full.GetInt("peak_players"))
+	assert.False(t, full.GetBool("unranked"))
+
+	solo, err := baseApp.FindRecordById("matches", soloMatch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, solo.GetInt("peak_players"))
+	assert.True(t, solo.GetBool("unranked"))
+
+	bear, err := database.GetPlayerByExternalID(ctx, app, "76561198995742987")
+	require.NoError(t, err)
+
+	// The solo match still has its stats, it just doesn't count towards lifetime totals
+	soloStats, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+		map[string]any{"match": soloMatch.ID, "player": bear.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 5, soloStats.GetInt("kills"))
+	assert.Equal(t, 3, soloStats.GetInt("deaths"))
+
+	kills, deaths, err := database.GetPlayerTotalKD(ctx, app, bear.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, kills, "lifetime kills should only count the full match")
+	assert.Equal(t, 1, deaths, "lifetime deaths should only count the full match")
+
+	// Dropping the minimum re-flags stored matches from their peak
+	unranked, err := database.ApplyRankedMinPlayers(ctx, app, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unranked)
+
+	kills, deaths, err = database.GetPlayerTotalKD(ctx, app, bear.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 7, kills)
+	assert.Equal(t, 4, deaths)
+}
@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecomputeEndpoint corrupts a match's aggregates and checks the recompute endpoint
+// restores them from the stored events
+func TestRecomputeEndpoint(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-recompute"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`, serverID, "test.log"))
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	lines := []string{
+		`[2025.11.08-14.01.00:000][ 10]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.02.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] + Kestrel[76561198995742999, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.03.00:000][ 30]LogGameplayEvents: Display: Rabbit[76561198995742956, team 1] killed ArmoredBear[76561198995742987, team 0] with BP_Firearm_AKM_C_2147480340`,
+		`[2025.11.08-14.04.00:000][ 40]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Kestrel[76561198995742999, team 0] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.05.00:000][ 50]LogGameplayEvents: Display: Objective 1 was captured for team 0 from team 1 by ArmoredBear[76561198995742987], Kestrel[76561198995742999].`,
+		// The next map's kill belongs to another match and must not be replayed into this one
+		`[2025.11.08-14.30.00:000][900]LogLoad: LoadMap: /Game/Maps/Town/Town?Name=Player?Scenario=Scenario_Hideout_Checkpoint_Security?MaxPlayers=16?Lighting=Day`,
+		`[2025.11.08-14.31.00:000][910]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+	}
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	armoredBear, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+
+	playerStats := func(t testing.TB, app core.App) *core.Record {
+		stats, err := app.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": armoredBear.ID})
+		require.NoError(t, err)
+		return stats
+	}
+
+	// The RCON score is not derived from events and must survive the rebuild
+	stats := playerStats(t, baseApp)
+	stats.Set("score", 150)
+	require.NoError(t, baseApp.Save(stats))
+
+	superusers, err := baseApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+	require.NoError(t, err)
+	superuser := core.NewRecord(superusers)
+	superuser.SetEmail("admin@example.com")
+	superuser.SetPassword("1234567890")
+	require.NoError(t, baseApp.Save(superuser))
+	token, err := superuser.NewAuthToken()
+	require.NoError(t, err)
+
+	// Corrupt the aggregates the way a buggy handler would
+	setup := func(t testing.TB) *tests.TestApp {
+		testApp, err := tests.NewTestApp(baseApp.DataDir())
+		require.NoError(t, err)
+
+		stats := playerStats(t, testApp)
+		stats.Set("kills", 99)
+		stats.Set("deaths", 0)
+		stats.Set("objectives_captured", 7)
+		require.NoError(t, testApp.Save(stats))
+
+		weapons, err := testApp.FindRecordsByFilter("match_weapon_stats", "match = {:match}", "", -1, 0, map[string]any{"match": match.ID})
+		require.NoError(t, err)
+		for _, weapon := range weapons {
+			require.NoError(t, testApp.Delete(weapon))
+		}
+		return testApp
+	}
+	registerRoutes := func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+		handlers.Register(NewTestAppWrapper(app), e)
+	}
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "requires a superuser",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/recompute?match=" + match.ID,
+			ExpectedStatus:  http.StatusUnauthorized,
+			ExpectedContent: []string{`"data":{}`},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:            "requires a scope",
+			Method:          http.MethodPost,
+			URL:             "/api/admin/recompute",
+			Headers:         map[string]string{"Authorization": token},
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedContent: []string{"Match or server is required"},
+			TestAppFactory:  setup,
+			BeforeTestFunc:  registerRoutes,
+		},
+		{
+			Name:           "rebuilds a match from its events",
+			Method:         http.MethodPost,
+			URL:            "/api/admin/recompute?match=" + match.ID,
+			Headers:        map[string]string{"Authorization": token},
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"matches":1`,
+				`"eventsReplayed":5`,
+				// ArmoredBear, Kestrel and Rabbit, plus their M4A1, M4A1 (assist) and AKM weapon stats
+				`"rowsRewritten":6`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				stats := playerStats(t, app)
+				assert.Equal(t, 2, stats.GetInt("kills"))
+				assert.Equal(t, 1, stats.GetInt("deaths"))
+				assert.Equal(t, 1, stats.GetInt("friendly_fire_kills"))
+				assert.Equal(t, 1, stats.GetInt("objectives_captured"))
+				assert.Equal(t, 150, stats.GetInt("score"))
+
+				weapon, err := app.FindFirstRecordByFilter("match_weapon_stats", "match = {:match} && player = {:player}",
+					map[string]any{"match": match.ID, "player": armoredBear.ID})
+				require.NoError(t, err)
+				assert.Equal(t, 2, weapon.GetInt("kills"))
+
+				incidents, err := app.CountRecords("friendly_fire_incidents")
+				require.NoError(t, err)
+				assert.EqualValues(t, 1, incidents)
+			},
+		},
+		{
+			Name:           "rebuilds every match on a server",
+			Method:         http.MethodPost,
+			URL:            "/api/admin/recompute?server=" + serverID,
+			Headers:        map[string]string{"Authorization": token},
+			ExpectedStatus: http.StatusOK,
+			ExpectedContent: []string{
+				`"matches":2`,
+				`"eventsReplayed":6`,
+			},
+			TestAppFactory: setup,
+			BeforeTestFunc: registerRoutes,
+			AfterTestFunc: func(t testing.TB, app *tests.TestApp, res *http.Response) {
+				assert.Equal(t, 2, playerStats(t, app).GetInt("kills"))
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.Test(t)
+	}
+}
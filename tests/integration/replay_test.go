@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/events"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/loader"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplayLogFile replays a fixture log and checks the final per-match stats
+func TestReplayLogFile(t *testing.T) {
+	logPath := filepath.Join("testdata", "replay.log")
+	serverID := "replay-server"
+
+	// killsByMap returns map -> steam ID -> kills/deaths for all matches on the server
+	killsByMap := func(t *testing.T, app *tests.TestApp) map[string]map[string][2]int {
+		t.Helper()
+		stats, err := app.FindRecordsByFilter("match_player_stats", "match.server.external_id = {:server}", "", -1, 0,
+			map[string]any{"server": serverID})
+		require.NoError(t, err)
+		app.ExpandRecords(stats, []string{"match", "player"}, nil)
+
+		result := make(map[string]map[string][2]int)
+		for _, stat := range stats {
+			mapName := stat.ExpandedOne("match").GetString("map")
+			if result[mapName] == nil {
+				result[mapName] = make(map[string][2]int)
+			}
+			result[mapName][stat.ExpandedOne("player").GetString("external_id")] = [2]int{stat.GetInt("kills"), stat.GetInt("deaths")}
+		}
+		return result
+	}
+
+	setup := func(t *testing.T) (*tests.TestApp, *parser.LogParser) {
+		testApp, err := tests.NewTestApp(t.TempDir())
+		require.NoError(t, err)
+		t.Cleanup(testApp.Cleanup)
+
+		appWrapper := NewTestAppWrapper(testApp)
+		handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+		return testApp, parser.NewLogParser(appWrapper, testApp.Logger())
+	}
+
+	t.Run("whole file", func(t *testing.T) {
+		testApp, p := setup(t)
+
+		result, err := loader.ReplayLogFile(context.Background(), testApp, p, logPath, serverID, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 12, result.LinesProcessed)
+		assert.Equal(t, 0, result.StartLine)
+
+		assert.Equal(t, map[string]map[string][2]int{
+			"Ministry": {
+				"76561198995742987": {2, 0}, // ArmoredBear
+				"76561198995742956": {1, 1}, // Rabbit
+			},
+			"Oilfield": {
+				"76561198995742987": {1, 0},
+				"76561198995742956": {2, 0},
+			},
+		}, killsByMap(t, testApp))
+
+		// The Ministry match was ended by game over; Oilfield is still active
+		active, err := database.GetActiveMatch(context.Background(), testApp, serverID)
+		require.NoError(t, err)
+		require.NotNil(t, active.Map)
+		assert.Equal(t, "Oilfield", *active.Map)
+
+		// Every event is marked as catchup so no RCON or score side effects run
+		kills, err := testApp.FindRecordsByFilter("events", "type = {:type}", "", -1, 0, map[string]any{"type": events.TypePlayerKill})
+		require.NoError(t, err)
+		assert.Len(t, kills, 7)
+		for _, kill := range kills {
+			var data map[string]any
+			require.NoError(t, kill.UnmarshalJSONField("data", &data))
+			assert.Equal(t, true, data["is_catchup"], "kill event should be marked as catchup")
+		}
+	})
+
+	t.Run("mid-file slice", func(t *testing.T) {
+		testApp, p := setup(t)
+
+		// Start between the Oilfield kills; the replay begins at the Oilfield map travel
+		since := time.Date(2025, 11, 10, 21, 13, 35, 0, time.Local)
+		result, err := loader.ReplayLogFile(context.Background(), testApp, p, logPath, serverID, since)
+		require.NoError(t, err)
+		assert.Equal(t, 8, result.StartLine)
+		assert.Equal(t, "Oilfield", result.Map)
+		assert.Equal(t, 3, result.LinesProcessed)
+
+		assert.Equal(t, map[string]map[string][2]int{
+			"Oilfield": {
+				"76561198995742987": {1, 0},
+				"76561198995742956": {2, 0},
+			},
+		}, killsByMap(t, testApp))
+	})
+
+	t.Run("replaying twice is idempotent", func(t *testing.T) {
+		testApp, p := setup(t)
+
+		_, err := loader.ReplayLogFile(context.Background(), testApp, p, logPath, serverID, time.Time{})
+		require.NoError(t, err)
+		first := killsByMap(t, testApp)
+
+		_, err = loader.ReplayLogFile(context.Background(), testApp, p, logPath, serverID, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, first, killsByMap(t, testApp))
+
+		matches, err := testApp.FindAllRecords("matches")
+		require.NoError(t, err)
+		assert.Len(t, matches, 2, "replaying again should not create new matches")
+	})
+
+	t.Run("gzipped archive", func(t *testing.T) {
+		testApp, p := setup(t)
+
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		archive := filepath.Join(t.TempDir(), "replay.log.gz")
+		file, err := os.Create(archive)
+		require.NoError(t, err)
+		gz := gzip.NewWriter(file)
+		_, err = gz.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		require.NoError(t, file.Close())
+
+		// Replayed the same as the plaintext log, including the slice after a map event
+		since := time.Date(2025, 11, 10, 21, 13, 35, 0, time.Local)
+		result, err := loader.ReplayLogFile(context.Background(), testApp, p, archive, serverID, since)
+		require.NoError(t, err)
+		assert.Equal(t, 8, result.StartLine)
+		assert.Equal(t, 3, result.LinesProcessed)
+		assert.Equal(t, map[string]map[string][2]int{
+			"Oilfield": {
+				"76561198995742987": {1, 0},
+				"76561198995742956": {2, 0},
+			},
+		}, killsByMap(t, testApp))
+	})
+
+	// offsetOf returns the byte offset of the line containing substr
+	offsetOf := func(t *testing.T, substr string) int64 {
+		t.Helper()
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		index := strings.Index(string(data), substr)
+		require.GreaterOrEqual(t, index, 0)
+		return int64(strings.LastIndex(string(data[:index]), "\n") + 1)
+	}
+
+	t.Run("from offset ends a stale match", func(t *testing.T) {
+		testApp, p := setup(t)
+		ctx := context.Background()
+
+		// A match left active from before the tracker stopped, on the map before the travel
+		_, err := database.GetOrCreateServer(ctx, testApp, serverID, serverID, logPath)
+		require.NoError(t, err)
+		staleMap, staleScenario := "Ministry", "Scenario_Ministry_Checkpoint_Security"
+		staleStart := time.Date(2025, 11, 10, 20, 58, 34, 0, time.Local)
+		stale, err := database.CreateMatch(ctx, testApp, serverID, &staleMap, &staleScenario, &staleStart, nil)
+		require.NoError(t, err)
+
+		// Start after the Oilfield travel and ArmoredBear's kill
+		result, err := loader.ReplayLogFileFrom(ctx, testApp, p, logPath, serverID, offsetOf(t, "21.13.40"))
+		require.NoError(t, err)
+		assert.Equal(t, 8, result.StartLine)
+		assert.Equal(t, "Oilfield", result.Map)
+		assert.Equal(t, 2, result.LinesProcessed)
+
+		assert.Equal(t, map[string]map[string][2]int{
+			"Oilfield": {
+				"76561198995742956": {2, 0},
+			},
+		}, killsByMap(t, testApp))
+
+		// The stale match had no players, so ending it removes it
+		_, err = testApp.FindRecordById("matches", stale.ID)
+		assert.Error(t, err, "stale match should no longer be active")
+		active, err := database.GetActiveMatch(ctx, testApp, serverID)
+		require.NoError(t, err)
+		require.NotNil(t, active.Map)
+		assert.Equal(t, "Oilfield", *active.Map)
+	})
+
+	t.Run("from offset resumes the active match", func(t *testing.T) {
+		testApp, p := setup(t)
+		ctx := context.Background()
+
+		// The tracker stopped after ArmoredBear's Oilfield kill, with that match still active
+		_, err := database.GetOrCreateServer(ctx, testApp, serverID, serverID, logPath)
+		require.NoError(t, err)
+		offset := offsetOf(t, "21.13.40")
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		for _, line := range strings.Split(strings.TrimSpace(string(data[:offset])), "\n") {
+			require.NoError(t, p.ParseAndProcess(parser.WithCatchupMode(ctx), line, serverID, logPath))
+		}
+		before, err := database.GetActiveMatch(ctx, testApp, serverID)
+		require.NoError(t, err)
+
+		_, err = loader.ReplayLogFileFrom(ctx, testApp, p, logPath, serverID, offset)
+		require.NoError(t, err)
+
+		after, err := database.GetActiveMatch(ctx, testApp, serverID)
+		require.NoError(t, err)
+		assert.Equal(t, before.ID, after.ID, "the match started by the travel should be kept")
+		assert.Equal(t, map[string]map[string][2]int{
+			"Ministry": {
+				"76561198995742987": {2, 0},
+				"76561198995742956": {1, 1},
+			},
+			"Oilfield": {
+				"76561198995742987": {1, 0},
+				"76561198995742956": {2, 0},
+			},
+		}, killsByMap(t, testApp))
+	})
+
+	t.Run("no map event before since", func(t *testing.T) {
+		testApp, p := setup(t)
+
+		_, err := loader.ReplayLogFile(context.Background(), testApp, p, logPath, serverID, time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"testing"
+
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/rcon"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRconPool records the servers registered with it
+type mockRconPool struct {
+	servers  map[string]*rcon.ServerConfig
+	removals int
+}
+
+func (p *mockRconPool) AddServer(serverID string, config *rcon.ServerConfig) {
+	p.servers[serverID] = config
+}
+
+func (p *mockRconPool) RemoveServer(serverID string) {
+	delete(p.servers, serverID)
+	p.removals++
+}
+
+// mockA2SPool records the addresses registered with it
+type mockA2SPool struct {
+	servers  map[string]string // Address -> name
+	removals int
+}
+
+func (p *mockA2SPool) AddServer(address string, name string) {
+	p.servers[address] = name
+}
+
+func (p *mockA2SPool) RemoveServer(address string) {
+	delete(p.servers, address)
+	p.removals++
+}
+
+// TestServerPoolSync checks creating, updating and deleting a server record registers and
+// deregisters it with the pools, and that invalid addresses are rejected
+func TestServerPoolSync(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	rconPool := &mockRconPool{servers: map[string]*rcon.ServerConfig{}}
+	a2sPool := &mockA2SPool{servers: map[string]string{}}
+	handlers.NewServerPoolSync(testApp, rconPool, a2sPool).RegisterHooks()
+
+	collection, err := testApp.FindCollectionByNameOrId("servers")
+	require.NoError(t, err)
+
+	server := core.NewRecord(collection)
+	server.Set("external_id", "api-server-1")
+	server.Set("name", "API Server")
+	server.Set("path", "/path")
+	server.Set("enabled", true)
+	server.Set("rcon_address", "127.0.0.1:27015")
+	server.Set("rcon_password", "secret")
+	server.Set("query_address", "127.0.0.1:27131")
+	require.NoError(t, testApp.Save(server))
+
+	require.Contains(t, rconPool.servers, "api-server-1")
+	assert.Equal(t, "127.0.0.1:27015", rconPool.servers["api-server-1"].Address)
+	assert.Equal(t, "secret", rconPool.servers["api-server-1"].Password)
+	assert.Equal(t, map[string]string{"127.0.0.1:27131": "API Server"}, a2sPool.servers)
+
+	// Saving the log offset, as the watcher does every batch, keeps the registration
+	registered := rconPool.servers["api-server-1"]
+	server.Set("offset", 4096)
+	require.NoError(t, testApp.Save(server))
+	assert.Same(t, registered, rconPool.servers["api-server-1"])
+	assert.Zero(t, rconPool.removals)
+	assert.Zero(t, a2sPool.removals)
+
+	// Renaming re-registers it under the new name
+	server.Set("name", "Renamed Server")
+	require.NoError(t, testApp.Save(server))
+	assert.Equal(t, map[string]string{"127.0.0.1:27131": "Renamed Server"}, a2sPool.servers)
+	server.Set("name", "API Server")
+	require.NoError(t, testApp.Save(server))
+
+	// Moving the query port swaps the A2S registration
+	server.Set("query_address", "127.0.0.1:27132")
+	require.NoError(t, testApp.Save(server))
+	assert.Equal(t, map[string]string{"127.0.0.1:27132": "API Server"}, a2sPool.servers)
+	assert.Contains(t, rconPool.servers, "api-server-1")
+
+	// Disabling takes it out of both pools
+	server.Set("enabled", false)
+	require.NoError(t, testApp.Save(server))
+	assert.Empty(t, rconPool.servers)
+	assert.Empty(t, a2sPool.servers)
+
+	server.Set("enabled", true)
+	require.NoError(t, testApp.Save(server))
+	require.NoError(t, testApp.Delete(server))
+	assert.Empty(t, rconPool.servers)
+	assert.Empty(t, a2sPool.servers)
+
+	// Invalid addresses and duplicate external IDs are rejected
+	invalid := core.NewRecord(collection)
+	invalid.Set("external_id", "api-server-2")
+	invalid.Set("name", "Bad Port")
+	invalid.Set("path", "/path")
+	invalid.Set("enabled", true)
+	invalid.Set("query_address", "127.0.0.1:99999")
+	assert.Error(t, testApp.Save(invalid))
+
+	invalid.Set("query_address", "")
+	invalid.Set("rcon_address", "127.0.0.1:27015")
+	assert.Error(t, testApp.Save(invalid), "rcon_address without a password should be rejected")
+	assert.Empty(t, rconPool.servers)
+	assert.Empty(t, a2sPool.servers)
+
+	first := core.NewRecord(collection)
+	first.Set("external_id", "api-server-3")
+	first.Set("name", "First")
+	first.Set("path", "/path")
+	require.NoError(t, testApp.Save(first))
+	duplicate := core.NewRecord(collection)
+	duplicate.Set("external_id", "api-server-3")
+	duplicate.Set("name", "Duplicate")
+	duplicate.Set("path", "/path")
+	assert.Error(t, testApp.Save(duplicate))
+
+	// Records without addresses are left to the config
+	assert.Empty(t, rconPool.servers)
+	assert.Empty(t, a2sPool.servers)
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServerRestartFromLogFile checks the first line of a new log records a server restart, that
+// the watcher seeing the same restart does not record it again, and that uptime is measured from it
+func TestServerRestartFromLogFile(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-restarts"
+	serverRecordID, err := database.GetOrCreateServer(ctx, testApp, serverID, "Restart Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(testApp)
+	p := parser.NewLogParser(appWrapper, testApp.Logger())
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+
+	require.NoError(t, p.ParseAndProcess(ctx, "Log file open, 11/08/25 13:59:12", serverID, "test.log"))
+
+	restarts, err := database.GetServerRestarts(ctx, testApp, serverRecordID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, restarts, 1)
+	assert.Equal(t, database.RestartSourceLogFile, restarts[0].Source)
+	restartedAt := restarts[0].RestartedAt
+	assert.Equal(t, 2025, restartedAt.Year())
+	assert.Equal(t, time.November, restartedAt.Month())
+
+	// The watcher noticing the new log a little later is the same restart
+	recorded, err := database.RecordServerRestart(ctx, testApp, serverRecordID, restartedAt.Add(30*time.Second), time.Now(), database.RestartSourceWatchdog)
+	require.NoError(t, err)
+	assert.False(t, recorded)
+	restarts, err = database.GetServerRestarts(ctx, testApp, serverRecordID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, restarts, 1)
+	assert.Equal(t, database.RestartSourceLogFile, restarts[0].Source)
+
+	// Uptime is measured from the log file's creation time
+	server, err := testApp.FindRecordById("servers", serverRecordID)
+	require.NoError(t, err)
+	startedAt := database.LastServerStart(ctx, testApp, server)
+	assert.True(t, startedAt.Equal(restartedAt), "expected start %v, got %v", restartedAt, startedAt)
+	assert.Equal(t, 90*time.Minute, database.ServerUptime(startedAt, startedAt.Add(90*time.Minute)))
+
+	t.Run("clock skew", func(t *testing.T) {
+		// A game server clock ahead of the tracker's never gives a negative uptime
+		now := startedAt.Add(-time.Minute)
+		assert.Equal(t, time.Duration(0), database.ServerUptime(startedAt, now))
+
+		// and a restart logged in the tracker's future is recorded at the tracker's time
+		trackerNow := time.Now().UTC().Truncate(time.Millisecond)
+		recorded, err := database.RecordServerRestart(ctx, testApp, serverRecordID, trackerNow.Add(10*time.Minute), trackerNow, database.RestartSourceLogFile)
+		require.NoError(t, err)
+		assert.True(t, recorded)
+
+		restarts, err := database.GetServerRestarts(ctx, testApp, serverRecordID, trackerNow.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Len(t, restarts, 1)
+		assert.True(t, restarts[0].RestartedAt.Equal(trackerNow), "expected restart at %v, got %v", trackerNow, restarts[0].RestartedAt)
+	})
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sandstorm-tracker/internal/database"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCloseStaleMatches checks an active match with no recent events is closed at its last
+// activity, as crashed when the server has started a new log since, while a match with recent
+// events, or on a server that answers A2S queries, is left running
+func TestCloseStaleMatches(t *testing.T) {
+	testApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer testApp.Cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+	started := now.Add(-5 * time.Hour)
+
+	startMatch := func(serverID string) (string, string) {
+		t.Helper()
+		serverRecordID, err := database.GetOrCreateServer(ctx, testApp, serverID, serverID, "/path")
+		require.NoError(t, err)
+		mapName, scenario := "Ministry", "Scenario_Ministry_Checkpoint_Security"
+		match, err := database.CreateMatch(ctx, testApp, serverID, &mapName, &scenario, &started)
+		require.NoError(t, err)
+		// Matches are created now; pretend the tracker created this one when it started
+		_, err = testApp.DB().NewQuery("UPDATE matches SET created = {:created} WHERE id = {:id}").
+			Bind(dbx.Params{"created": started.UTC().Format(types.DefaultDateLayout), "id": match.ID}).
+			Execute()
+		require.NoError(t, err)
+		return serverRecordID, match.ID
+	}
+
+	_, idleMatch := startMatch("test-server-idle")
+	busyServer, busyMatch := startMatch("test-server-busy")
+	restartedServer, restartedMatch := startMatch("test-server-restarted")
+	_, emptyMatch := startMatch("test-server-empty")
+	online := map[string]bool{"test-server-empty": true}
+
+	// The busy server logged something a minute ago
+	eventsCollection, err := testApp.FindCollectionByNameOrId("events")
+	require.NoError(t, err)
+	event := core.NewRecord(eventsCollection)
+	event.Set("type", "chat_message")
+	event.Set("server", busyServer)
+	event.Set("data", "{}")
+	require.NoError(t, testApp.Save(event))
+
+	// The restarted server opened a new log an hour ago, which the tracker never processed
+	server, err := testApp.FindRecordById("servers", restartedServer)
+	require.NoError(t, err)
+	server.Set("log_file_creation_time", now.Add(-time.Hour).UTC().Format(time.RFC3339))
+	require.NoError(t, testApp.Save(server))
+
+	closed, err := database.CloseStaleMatches(ctx, testApp, 2*time.Hour, online, now)
+	require.NoError(t, err)
+
+	statuses := map[string]string{}
+	for _, match := range closed {
+		statuses[match.MatchID] = match.Status
+	}
+	assert.Equal(t, map[string]string{idleMatch: "finished", restartedMatch: "crashed"}, statuses)
+
+	idle, err := testApp.FindRecordById("matches", idleMatch)
+	require.NoError(t, err)
+	assert.Equal(t, "finished", idle.GetString("status"))
+	assert.WithinDuration(t, started, idle.GetDateTime("end_time").Time(), time.Second, "ended at its last activity")
+
+	busy, err := testApp.FindRecordById("matches", busyMatch)
+	require.NoError(t, err)
+	assert.Empty(t, busy.GetString("end_time"), "a match with recent events stays active")
+
+	empty, err := testApp.FindRecordById("matches", emptyMatch)
+	require.NoError(t, err)
+	assert.Empty(t, empty.GetString("end_time"), "the match of an online but empty server stays active")
+
+	// Nothing left to close
+	closed, err = database.CloseStaleMatches(ctx, testApp, 2*time.Hour, online, now)
+	require.NoError(t, err)
+	assert.Empty(t, closed)
+}
@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTeamSwapKeepsSession checks a quick leave and rejoin (switching teams) keeps the player's
+// match stats and session, while a rejoin after the window starts a new session
+func TestTeamSwapKeepsSession(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-team-swap"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+
+	process := func(lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+		}
+	}
+
+	process(`[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Firefight_East?MaxPlayers=16?Lighting=Day`)
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	process(
+		`[2025.11.08-14.00.10:000][ 10]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.00.12:000][ 12]LogNet: Join succeeded: ArmoredBear`,
+		`[2025.11.08-14.01.00:000][ 20]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+		`[2025.11.08-14.01.30:000][ 30]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rabbit[76561198995742956, team 1] with BP_Firearm_M4A1_C_2147480339`,
+	)
+
+	player, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+
+	stats := func() (kills, sessions int, connected bool, status string, leftAt types.DateTime) {
+		t.Helper()
+		record, err := baseApp.FindFirstRecordByFilter("match_player_stats", "match = {:match} && player = {:player}",
+			map[string]any{"match": match.ID, "player": player.ID})
+		require.NoError(t, err)
+		return record.GetInt("kills"), record.GetInt("session_count"), record.GetBool("is_currently_connected"),
+			record.GetString("status"), record.GetDateTime("left_at")
+	}
+
+	// Switching teams: leave, then log in and join again a few seconds later
+	process(
+		`[2025.11.08-14.02.00:000][ 40]LogEOSAntiCheat: Display: ServerUnregisterClient: UserId (76561198995742987), Result: (EOS_Success)`,
+		`[2025.11.08-14.02.03:000][ 41]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.02.05:000][ 42]LogNet: Join succeeded: ArmoredBear`,
+	)
+
+	kills, sessions, connected, status, leftAt := stats()
+	assert.Equal(t, 2, kills, "kills should survive the team swap")
+	assert.Equal(t, 1, sessions, "a team swap should not count another session")
+	assert.True(t, connected)
+	assert.Equal(t, "ongoing", status)
+	assert.True(t, leftAt.IsZero(), "left_at should be cleared after a team swap")
+
+	count, err := baseApp.CountRecords("match_player_stats", dbx.HashExp{"player": player.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "the player should keep a single stats row")
+
+	// Kills after the swap add to the same row
+	process(`[2025.11.08-14.03.00:000][ 50]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 1] killed Kestrel[76561198995742911, team 0] with BP_Firearm_AKM_C_2147480340`)
+	kills, _, _, _, _ = stats()
+	assert.Equal(t, 3, kills)
+
+	// Leaving for longer than the window is a real session end; the next join is a new session
+	process(`[2025.11.08-14.04.00:000][ 60]LogEOSAntiCheat: Display: ServerUnregisterClient: UserId (76561198995742987), Result: (EOS_Success)`)
+	_, _, connected, status, leftAt = stats()
+	assert.False(t, connected)
+	assert.Equal(t, "disconnected", status)
+	assert.False(t, leftAt.IsZero())
+
+	process(
+		`[2025.11.08-14.06.00:000][ 70]LogNet: Login request: ?InitialConnectTimeout=30?Name=ArmoredBear userId: SteamNWI:76561198995742987 platform: SteamNWI`,
+		`[2025.11.08-14.06.02:000][ 71]LogNet: Join succeeded: ArmoredBear`,
+	)
+	kills, sessions, connected, status, _ = stats()
+	assert.Equal(t, 3, kills)
+	assert.Equal(t, 2, sessions)
+	assert.True(t, connected)
+	assert.Equal(t, "ongoing", status)
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"sandstorm-tracker/internal/database"
+	"sandstorm-tracker/internal/handlers"
+	"sandstorm-tracker/internal/parser"
+	_ "sandstorm-tracker/migrations"
+
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWeaponAccuracy checks shots fired and hits accumulate on the shooter's match weapon stats
+func TestWeaponAccuracy(t *testing.T) {
+	baseApp, err := tests.NewTestApp(t.TempDir())
+	require.NoError(t, err)
+	defer baseApp.Cleanup()
+
+	ctx := context.Background()
+	serverID := "test-server-accuracy"
+
+	_, err = database.GetOrCreateServer(ctx, baseApp, serverID, "Test Server", "/path")
+	require.NoError(t, err)
+
+	appWrapper := NewTestAppWrapper(baseApp)
+	handlers.NewGameEventHandlers(appWrapper, nil).RegisterHooks()
+	p := parser.NewLogParser(appWrapper, baseApp.Logger())
+	p.SetTrackWeaponFire(true)
+
+	require.NoError(t, p.ParseAndProcess(ctx, `[2025.11.08-14.00.00:000][  0]LogLoad: LoadMap: /Game/Maps/Farmhouse/Farmhouse?Name=Player?Scenario=Scenario_Farmhouse_Checkpoint_Security?MaxPlayers=16?Lighting=Day`, serverID, "test.log"))
+	match, err := database.GetActiveMatch(ctx, appWrapper, serverID)
+	require.NoError(t, err)
+
+	lines := []string{
+		`[2025.11.08-14.05.00:000][ 50]LogGameplayEvents: Verbose: ArmoredBear[76561198995742987, team 0] fired 30 shots with BP_Firearm_M4A1_C_2147480587 (6 hits)`,
+		`[2025.11.08-14.05.01:000][ 52]LogGameplayEvents: Display: ArmoredBear[76561198995742987, team 0] killed Rifleman[INVALID, team 1] with BP_Firearm_M4A1_C_2147480587`,
+		`[2025.11.08-14.06.00:000][ 60]LogGameplayEvents: Verbose: ArmoredBear[76561198995742987, team 0] fired 10 shots with BP_Firearm_M4A1_C_2147480590 (4 hits)`,
+	}
+	for _, line := range lines {
+		require.NoError(t, p.ParseAndProcess(ctx, line, serverID, "test.log"))
+	}
+
+	player, err := database.GetPlayerByExternalID(ctx, appWrapper, "76561198995742987")
+	require.NoError(t, err)
+	stats, err := baseApp.FindFirstRecordByFilter("match_weapon_stats", "match = {:match} && player = {:player} && weapon_name = {:weapon}",
+		map[string]any{"match": match.ID, "player": player.ID, "weapon": database.CleanWeaponName("BP_Firearm_M4A1_C_2147480587")})
+	require.NoError(t, err)
+
+	// Both bursts land on one row alongside the kill, whatever the actor instance suffix
+	assert.Equal(t, 40, stats.GetInt("shots"))
+	assert.Equal(t, 10, stats.GetInt("hits"))
+	assert.Equal(t, 1, stats.GetInt("kills"))
+	assert.Equal(t, 25.0, database.WeaponAccuracy(stats.GetInt("shots"), stats.GetInt("hits")))
+	assert.Equal(t, 0.0, database.WeaponAccuracy(0, 0))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -38,64 +39,134 @@ func main() {
 	fmt.Println("=============================================================")
 	fmt.Println()
 
-	// Create output buffer
+	// Write to file
+	err := os.WriteFile(outputFile, runTests(address), 0644)
+	if err != nil {
+		fmt.Printf("\n❌ Failed to write output file: %v\n", err)
+	} else {
+		fmt.Printf("\n✅ Results written to %s\n", outputFile)
+	}
+}
+
+// separator divides the tests in console output and the results file
+var separator = strings.Repeat("-", 70)
+
+// runTests runs every query against the server and returns the report written to the output file
+func runTests(address string) []byte {
 	var output bytes.Buffer
 	output.WriteString(fmt.Sprintf("A2S Query Results for %s\n", address))
 	output.WriteString(fmt.Sprintf("Timestamp: %s\n", time.Now().Format(time.RFC3339)))
 	output.WriteString("=============================================================\n\n")
 
-	// Test 1: Server Info Query
-	fmt.Println("Test 1: A2S_INFO (Server Information)")
-	infoResult := testServerInfo(address)
-	output.WriteString("Test 1: A2S_INFO (Server Information)\n")
-	output.WriteString(infoResult)
-	output.WriteString("\n")
-	output.WriteString(string(make([]byte, 70)))
-	output.WriteString("\n\n")
+	tests := []struct {
+		title string
+		run   func(address string) string
+	}{
+		{"A2S_INFO (Server Information)", testServerInfo},
+		{"A2S_PLAYER with challenge request", testWithChallenge},
+		{"Player query with -1 challenge (no challenge request)", testWithMinusOne},
+		{"A2S_RULES (Server Rules/CVars)", testRules},
+	}
+
+	for i, test := range tests {
+		if i > 0 {
+			output.WriteString("\n")
+			output.WriteString(separator)
+			output.WriteString("\n\n")
+
+			fmt.Println()
+			fmt.Println(separator)
+			fmt.Println()
+		}
 
-	fmt.Println()
-	fmt.Println(string(make([]byte, 70)))
-	fmt.Println()
+		title := fmt.Sprintf("Test %d: %s", i+1, test.title)
+		fmt.Println(title)
+		output.WriteString(title + "\n")
+		output.WriteString(test.run(address))
+	}
+
+	return output.Bytes()
+}
 
-	// Test 2: Standard A2S with challenge
-	fmt.Println("Test 2: A2S_PLAYER with challenge request")
-	playerResult := testWithChallenge(address)
-	output.WriteString("Test 2: A2S_PLAYER with challenge request\n")
-	output.WriteString(playerResult)
-	output.WriteString("\n")
-	output.WriteString(string(make([]byte, 70)))
-	output.WriteString("\n\n")
+// report prints a message and appends it to the test result
+func report(result *bytes.Buffer, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Print(msg)
+	result.WriteString(msg)
+}
 
-	fmt.Println()
-	fmt.Println(string(make([]byte, 70)))
-	fmt.Println()
+// challengeQuery sends an A2S request with challenge -1 and, if the server answers with
+// S2A_CHALLENGE, repeats it with the issued challenge. It returns a reader positioned after
+// the response type byte, or nil if no response of expectedType was received.
+func challengeQuery(conn net.Conn, result *bytes.Buffer, requestType, expectedType byte, name string, bufferSize int) *bytes.Reader {
+	send := func(challenge int32) (*bytes.Reader, byte, bool) {
+		request := &bytes.Buffer{}
+		binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
+		request.WriteByte(requestType)
+		binary.Write(request, binary.LittleEndian, challenge)
+
+		conn.Write(request.Bytes())
+
+		response := make([]byte, bufferSize)
+		n, err := conn.Read(response)
+		if err != nil {
+			report(result, "❌ Failed to read %s response: %v\n", name, err)
+			return nil, 0, false
+		}
 
-	// Test 3: Query with -1 challenge directly
-	fmt.Println("Test 4: Player query with -1 challenge (no challenge request)")
-	minusOneResult := testWithMinusOne(address)
-	output.WriteString("Test 4: Player query with -1 challenge (no challenge request)\n")
-	output.WriteString(minusOneResult)
-	output.WriteString("\n")
-	output.WriteString(string(make([]byte, 70)))
-	output.WriteString("\n\n")
+		report(result, "  ← Received %d bytes\n", n)
+		report(result, "  Raw response: %x\n", response[:min(50, n)])
 
-	fmt.Println()
-	fmt.Println(string(make([]byte, 70)))
-	fmt.Println()
+		reader := bytes.NewReader(response[:n])
 
-	// Test 4: Rules Query with challenge
-	fmt.Println("Test 5: A2S_RULES (Server Rules/CVars)")
-	rulesResult := testRules(address)
-	output.WriteString("Test 5: A2S_RULES (Server Rules/CVars)\n")
-	output.WriteString(rulesResult)
+		// Skip header
+		var header uint32
+		binary.Read(reader, binary.LittleEndian, &header)
 
-	// Write to file
-	err := os.WriteFile(outputFile, output.Bytes(), 0644)
-	if err != nil {
-		fmt.Printf("\n❌ Failed to write output file: %v\n", err)
-	} else {
-		fmt.Printf("\n✅ Results written to %s\n", outputFile)
+		// Read response type
+		responseType, _ := reader.ReadByte()
+		report(result, "  Response type: 0x%02x ('%c')\n", responseType, responseType)
+
+		return reader, responseType, true
+	}
+
+	// Step 1: Request challenge
+	report(result, "  → Sending challenge request...\n")
+	reader, responseType, ok := send(-1)
+	if !ok {
+		return nil
+	}
+
+	// Some servers return the data directly without a challenge
+	if responseType == expectedType {
+		report(result, "✅ Server returned %s data directly (no challenge required)\n", name)
+		return reader
+	}
+
+	if responseType != S2A_CHALLENGE {
+		report(result, "❌ Expected S2A_CHALLENGE (0x41) or 0x%02x, got 0x%02x\n", expectedType, responseType)
+		return nil
+	}
+
+	// Read challenge
+	var challenge int32
+	binary.Read(reader, binary.LittleEndian, &challenge)
+	report(result, "  Challenge: %d (0x%08x)\n", challenge, challenge)
+
+	// Step 2: Query with challenge
+	report(result, "  → Sending %s query with challenge...\n", name)
+	reader, responseType, ok = send(challenge)
+	if !ok {
+		return nil
+	}
+
+	if responseType != expectedType {
+		report(result, "❌ Expected 0x%02x, got 0x%02x\n", expectedType, responseType)
+		return nil
 	}
+
+	report(result, "✅ Success! Got %s data\n", name)
+	return reader
 }
 
 func testServerInfo(address string) string {
@@ -220,131 +291,15 @@ func testWithChallenge(address string) string {
 
 	conn, err := net.DialTimeout("udp", address, 5*time.Second)
 	if err != nil {
-		msg := fmt.Sprintf("❌ Failed to connect: %v\n", err)
-		fmt.Print(msg)
-		result.WriteString(msg)
+		report(&result, "❌ Failed to connect: %v\n", err)
 		return result.String()
 	}
 	defer conn.Close()
 
 	conn.SetDeadline(time.Now().Add(5 * time.Second))
 
-	// Step 1: Request challenge
-	msg := "  → Sending challenge request...\n"
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	request := &bytes.Buffer{}
-	binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
-	request.WriteByte(A2S_PLAYER)
-	binary.Write(request, binary.LittleEndian, int32(-1))
-
-	conn.Write(request.Bytes())
-
-	// Read challenge response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
-	if err != nil {
-		msg := fmt.Sprintf("❌ Failed to read challenge response: %v\n", err)
-		fmt.Print(msg)
-		result.WriteString(msg)
-		return result.String()
-	}
-
-	msg = fmt.Sprintf("  ← Received %d bytes\n", n)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	msg = fmt.Sprintf("  Raw response: %x\n", response[:min(20, n)])
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	reader := bytes.NewReader(response[:n])
-
-	// Skip header
-	var header uint32
-	binary.Read(reader, binary.LittleEndian, &header)
-
-	// Read response type
-	responseType, _ := reader.ReadByte()
-	msg = fmt.Sprintf("  Response type: 0x%02x ('%c')\n", responseType, responseType)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	// Some servers return player data directly without challenge
-	if responseType == S2A_PLAYER {
-		msg := "✅ Server returned player data directly (no challenge required)\n"
-		fmt.Print(msg)
-		result.WriteString(msg)
-
-		playerMsg := parsePlayers(reader)
-		fmt.Print(playerMsg)
-		result.WriteString(playerMsg)
-		return result.String()
-	}
-
-	if responseType != S2A_CHALLENGE {
-		msg := fmt.Sprintf("❌ Expected S2A_CHALLENGE (0x41) or S2A_PLAYER (0x44), got 0x%02x\n", responseType)
-		fmt.Print(msg)
-		result.WriteString(msg)
-		return result.String()
-	}
-
-	// Read challenge
-	var challenge int32
-	binary.Read(reader, binary.LittleEndian, &challenge)
-	msg = fmt.Sprintf("  Challenge: %d (0x%08x)\n", challenge, challenge)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	// Step 2: Query with challenge
-	msg = "  → Sending player query with challenge...\n"
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	request2 := &bytes.Buffer{}
-	binary.Write(request2, binary.LittleEndian, uint32(PACKET_HEADER))
-	request2.WriteByte(A2S_PLAYER)
-	binary.Write(request2, binary.LittleEndian, challenge)
-
-	conn.Write(request2.Bytes())
-
-	response2 := make([]byte, 1400)
-	n2, err := conn.Read(response2)
-	if err != nil {
-		msg := fmt.Sprintf("❌ Failed to read player response: %v\n", err)
-		fmt.Print(msg)
-		result.WriteString(msg)
-		return result.String()
-	}
-
-	msg = fmt.Sprintf("  ← Received %d bytes\n", n2)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	msg = fmt.Sprintf("  Raw response: %x\n", response2[:min(50, n2)])
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	reader2 := bytes.NewReader(response2[:n2])
-	binary.Read(reader2, binary.LittleEndian, &header)
-	playerResponseType, _ := reader2.ReadByte()
-	msg = fmt.Sprintf("  Response type: 0x%02x ('%c')\n", playerResponseType, playerResponseType)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	if playerResponseType == S2A_PLAYER {
-		msg := "✅ Success! Got player data\n"
-		fmt.Print(msg)
-		result.WriteString(msg)
-
-		playerMsg := parsePlayers(reader2)
-		fmt.Print(playerMsg)
-		result.WriteString(playerMsg)
-	} else {
-		msg := fmt.Sprintf("❌ Expected S2A_PLAYER (0x44), got 0x%02x\n", playerResponseType)
-		fmt.Print(msg)
-		result.WriteString(msg)
+	if reader := challengeQuery(conn, &result, A2S_PLAYER, S2A_PLAYER, "player", 1400); reader != nil {
+		report(&result, "%s", parsePlayers(reader))
 	}
 
 	return result.String()
@@ -414,7 +369,7 @@ func testWithMinusOne(address string) string {
 		fmt.Print(playerMsg)
 		result.WriteString(playerMsg)
 	} else if responseType == S2A_CHALLENGE {
-		msg := "⚠️  Server sent challenge (try Test 1 instead)\n"
+		msg := "⚠️  Server sent challenge (try Test 2 instead)\n"
 		fmt.Print(msg)
 		result.WriteString(msg)
 	} else {
@@ -431,127 +386,16 @@ func testRules(address string) string {
 
 	conn, err := net.DialTimeout("udp", address, 5*time.Second)
 	if err != nil {
-		msg := fmt.Sprintf("❌ Failed to connect: %v\n", err)
-		fmt.Print(msg)
-		result.WriteString(msg)
+		report(&result, "❌ Failed to connect: %v\n", err)
 		return result.String()
 	}
 	defer conn.Close()
 
 	conn.SetDeadline(time.Now().Add(5 * time.Second))
 
-	// Step 1: Request challenge
-	msg := "  → Sending challenge request...\n"
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	request := &bytes.Buffer{}
-	binary.Write(request, binary.LittleEndian, uint32(PACKET_HEADER))
-	request.WriteByte(A2S_RULES)
-	binary.Write(request, binary.LittleEndian, int32(-1))
-
-	conn.Write(request.Bytes())
-
-	// Read challenge response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
-	if err != nil {
-		msg := fmt.Sprintf("❌ Failed to read challenge response: %v\n", err)
-		fmt.Print(msg)
-		result.WriteString(msg)
-		return result.String()
-	}
-
-	msg = fmt.Sprintf("  ← Received %d bytes\n", n)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	reader := bytes.NewReader(response[:n])
-
-	// Skip header
-	var header uint32
-	binary.Read(reader, binary.LittleEndian, &header)
-
-	// Read response type
-	responseType, _ := reader.ReadByte()
-	msg = fmt.Sprintf("  Response type: 0x%02x ('%c')\n", responseType, responseType)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	// Some servers return rules directly without challenge
-	if responseType == S2A_RULES {
-		msg := "✅ Server returned rules directly (no challenge required)\n"
-		fmt.Print(msg)
-		result.WriteString(msg)
-
-		rulesMsg := parseRules(reader)
-		fmt.Print(rulesMsg)
-		result.WriteString(rulesMsg)
-		return result.String()
-	}
-
-	if responseType != S2A_CHALLENGE {
-		msg := fmt.Sprintf("❌ Expected S2A_CHALLENGE (0x41) or S2A_RULES (0x45), got 0x%02x\n", responseType)
-		fmt.Print(msg)
-		result.WriteString(msg)
-		return result.String()
-	}
-
-	// Read challenge
-	var challenge int32
-	binary.Read(reader, binary.LittleEndian, &challenge)
-	msg = fmt.Sprintf("  Challenge: %d (0x%08x)\n", challenge, challenge)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	// Step 2: Query with challenge
-	msg = "  → Sending rules query with challenge...\n"
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	request2 := &bytes.Buffer{}
-	binary.Write(request2, binary.LittleEndian, uint32(PACKET_HEADER))
-	request2.WriteByte(A2S_RULES)
-	binary.Write(request2, binary.LittleEndian, challenge)
-
-	conn.Write(request2.Bytes())
-
-	response2 := make([]byte, 4096) // Rules can be larger
-	n2, err := conn.Read(response2)
-	if err != nil {
-		msg := fmt.Sprintf("❌ Failed to read rules response: %v\n", err)
-		fmt.Print(msg)
-		result.WriteString(msg)
-		return result.String()
-	}
-
-	msg = fmt.Sprintf("  ← Received %d bytes\n", n2)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	msg = fmt.Sprintf("  Raw response: %x\n", response2[:min(50, n2)])
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	reader2 := bytes.NewReader(response2[:n2])
-	binary.Read(reader2, binary.LittleEndian, &header)
-	rulesResponseType, _ := reader2.ReadByte()
-	msg = fmt.Sprintf("  Response type: 0x%02x ('%c')\n", rulesResponseType, rulesResponseType)
-	fmt.Print(msg)
-	result.WriteString(msg)
-
-	if rulesResponseType == S2A_RULES {
-		msg := "✅ Success! Got rules data\n"
-		fmt.Print(msg)
-		result.WriteString(msg)
-
-		rulesMsg := parseRules(reader2)
-		fmt.Print(rulesMsg)
-		result.WriteString(rulesMsg)
-	} else {
-		msg := fmt.Sprintf("❌ Expected S2A_RULES (0x45), got 0x%02x\n", rulesResponseType)
-		fmt.Print(msg)
-		result.WriteString(msg)
+	// Rules can be larger than the other responses
+	if reader := challengeQuery(conn, &result, A2S_RULES, S2A_RULES, "rules", 4096); reader != nil {
+		report(&result, "%s", parseRules(reader))
 	}
 
 	return result.String()
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sandstorm-tracker/internal/a2s"
+	"sandstorm-tracker/internal/a2s/a2stest"
+)
+
+func TestRunTestsOutputHasNoNULBytes(t *testing.T) {
+	// Player and rules queries require a challenge
+	server := a2stest.NewServer(t, a2stest.Config{
+		Info:      a2s.ServerInfo{Name: "Test Server", Map: "Town", Players: 1, MaxPlayers: 28},
+		Players:   []a2s.Player{{Index: 0, Name: "ArmoredBear", Score: 1200, Duration: 300}},
+		Rules:     map[string]string{"GameMode_s": "Checkpoint"},
+		Challenge: 0x12345678,
+	})
+
+	outputFile := filepath.Join(t.TempDir(), "a2s_response.txt")
+	if err := os.WriteFile(outputFile, runTests(server.Addr()), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	output := string(content)
+
+	if strings.ContainsRune(output, 0) {
+		t.Error("output file contains NUL bytes")
+	}
+
+	for _, want := range []string{
+		"Test 1: A2S_INFO",
+		"Test 2: A2S_PLAYER",
+		"Test 3: Player query",
+		"Test 4: A2S_RULES",
+		strings.Repeat("-", 70),
+		"Server Name: Test Server",
+		"ArmoredBear - Score: 1200",
+		"GameMode_s = Checkpoint",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"strings"
 	"time"
 
 	"sandstorm-tracker/internal/rcon"
@@ -53,24 +52,20 @@ func main() {
 }
 
 func parseListPlayersResponse(response string) {
-	lines := strings.Split(response, "\n")
+	players := rcon.ParseListPlayers(response)
 
 	fmt.Println("\n=== Parsed Players ===")
-	playerCount := 0
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	for _, player := range players {
+		ping := "n/a"
+		if player.Ping >= 0 {
+			ping = fmt.Sprintf("%dms", player.Ping)
 		}
-
-		// Format: [ID] PlayerName - Score: X, Time: Y
-		// or similar variations
-		if strings.Contains(line, "Score:") || strings.Contains(line, "Kills:") {
-			playerCount++
-			fmt.Printf("  %s\n", line)
+		fmt.Printf("  %s (%s) - Score: %d, Ping: %s", player.Name, player.NetID, player.Score, ping)
+		if player.Team != "" {
+			fmt.Printf(", Team: %s", player.Team)
 		}
+		fmt.Println()
 	}
 
-	fmt.Printf("\nTotal players found: %d\n", playerCount)
+	fmt.Printf("\nTotal players found: %d\n", len(players))
 }
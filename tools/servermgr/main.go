@@ -1,15 +1,20 @@
 package main
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
+	"sandstorm-tracker/internal/logger"
+	"sandstorm-tracker/internal/servermgr"
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
@@ -55,6 +60,15 @@ type ServerManager struct {
 	servers        map[string]*ManagedServer
 	logger         *slog.Logger
 	defaultSAWPath string
+
+	// Console output capture for detached servers, see servermgr.CaptureConsoleLogs
+	consoleLogDir     string
+	consoleLogMaxMB   int
+	consoleLogBackups int
+
+	// Extra mutator and ruleset names to accept, see servermgr.Catalog
+	knownMutators []string
+	knownRuleSets []string
 }
 
 // ProcessInfo holds information about a running process
@@ -65,13 +79,17 @@ type ProcessInfo struct {
 
 func main() {
 	godotenv.Load() // Load .env file if present
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	// LOG_FORMAT=json and LOG_LEVEL select the log format and level, as for the tracker
+	level, err := logger.ParseLevel(cmp.Or(os.Getenv("LOG_LEVEL"), "info"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	log := slog.New(logger.NewHandler(os.Stdout, os.Getenv("LOG_FORMAT"), level))
 
 	sm := &ServerManager{
 		servers: make(map[string]*ManagedServer),
-		logger:  logger,
+		logger:  log,
 	}
 
 	rootCmd := &cobra.Command{
@@ -82,6 +100,11 @@ func main() {
 
 	// Set default SAW path from environment or flag
 	rootCmd.PersistentFlags().StringVar(&sm.defaultSAWPath, "saw-path", os.Getenv("SAW_PATH"), "Path to Sandstorm Admin Wrapper installation")
+	rootCmd.PersistentFlags().StringVar(&sm.consoleLogDir, "console-log-dir", os.Getenv("SERVER_CONSOLE_LOG_DIR"), "Capture detached servers' stdout/stderr into rotating files in this directory")
+	rootCmd.PersistentFlags().IntVar(&sm.consoleLogMaxMB, "console-log-max-mb", 50, "Rotate a server's console logs on start once they reach this size")
+	rootCmd.PersistentFlags().IntVar(&sm.consoleLogBackups, "console-log-backups", 5, "Number of rotated console logs to keep per server")
+	rootCmd.PersistentFlags().StringSliceVar(&sm.knownMutators, "known-mutators", nil, "Extra mutator names (e.g. from mods) to accept without a warning, comma-separated")
+	rootCmd.PersistentFlags().StringSliceVar(&sm.knownRuleSets, "known-rulesets", nil, "Extra ruleset names to accept without a warning, comma-separated")
 
 	sm.registerCommands(rootCmd)
 
@@ -102,6 +125,7 @@ func (sm *ServerManager) registerCommands(rootCmd *cobra.Command) {
 	}
 	startCmd.Flags().Bool("logs", false, "Show server logs in console (default: log to file)")
 	startCmd.Flags().Bool("all", false, "Start all servers from SAW configuration")
+	startCmd.Flags().Bool("dry-run", false, "Print the executable, arguments and working directory without starting anything")
 
 	// Stop command
 	stopCmd := &cobra.Command{
@@ -143,13 +167,22 @@ func (sm *ServerManager) registerCommands(rootCmd *cobra.Command) {
 	updateGameCmd.Flags().Bool("validate", false, "Validate all server files (slower but more thorough)")
 	updateGameCmd.Flags().Bool("force", false, "Force update even if servers are running (not recommended)")
 
-	rootCmd.AddCommand(startCmd, stopCmd, statusCmd, listCmd, updateSteamCmdCmd, updateGameCmd)
+	// Diff command
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show what changed in the SAW configuration since servers were started",
+		Long:  "Compare server-configs.json to the config each server was last started with, listing added and removed servers and changed fields.",
+		RunE:  sm.diffCommand,
+	}
+
+	rootCmd.AddCommand(startCmd, stopCmd, statusCmd, listCmd, diffCmd, updateSteamCmdCmd, updateGameCmd)
 }
 
 // startCommand handles the start command
 func (sm *ServerManager) startCommand(cmd *cobra.Command, args []string) error {
 	showLogs, _ := cmd.Flags().GetBool("logs")
 	startAll, _ := cmd.Flags().GetBool("all")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	sawPath := sm.getSAWPath()
 
 	if sawPath == "" {
@@ -168,6 +201,30 @@ func (sm *ServerManager) startCommand(cmd *cobra.Command, args []string) error {
 
 	// Start all servers if --all flag is set
 	if startAll {
+		// Refuse to start anything while a config is broken or a port is taken
+		validate := make(map[string]servermgr.SAWServerConfig, len(configs))
+		for serverID, serverConfig := range configs {
+			validate[serverID] = servermgr.SAWServerConfig(serverConfig)
+		}
+		isRunning := func(serverID string) bool {
+			pid, err := sm.loadPIDFile(serverID)
+			return err == nil && sm.isProcessRunning(pid)
+		}
+		if err := servermgr.PreflightStartAll(validate, isRunning); err != nil {
+			return err
+		}
+
+		if dryRun {
+			for _, serverID := range sortedServerIDs(validate) {
+				command, err := sm.dryRunCommand(serverID, validate[serverID], sawPath, false)
+				if err != nil {
+					return err
+				}
+				servermgr.PrintLaunchCommand(serverID, command, false)
+			}
+			return nil
+		}
+
 		fmt.Printf("Starting %d server(s)...\n", len(configs))
 		successCount := 0
 		failCount := 0
@@ -213,6 +270,19 @@ func (sm *ServerManager) startCommand(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no servers found in SAW configuration")
 	}
 
+	if err := servermgr.ValidateConfigs(map[string]servermgr.SAWServerConfig{serverID: servermgr.SAWServerConfig(serverConfig)}); err != nil {
+		return err
+	}
+
+	if dryRun {
+		command, err := sm.dryRunCommand(serverID, servermgr.SAWServerConfig(serverConfig), sawPath, showLogs)
+		if err != nil {
+			return err
+		}
+		servermgr.PrintLaunchCommand(serverID, command, showLogs)
+		return nil
+	}
+
 	fmt.Printf("Starting server: %s\n", serverID)
 	if showLogs {
 		fmt.Println("Server logs will be displayed in console (Press Ctrl+C to stop)")
@@ -223,6 +293,42 @@ func (sm *ServerManager) startCommand(cmd *cobra.Command, args []string) error {
 	return sm.startServer(serverID, serverConfig, sawPath, showLogs)
 }
 
+// consoleLogRotation returns the rotation policy for captured console output
+func (sm *ServerManager) consoleLogRotation() logger.RotationPolicy {
+	return logger.RotationPolicy{
+		MaxSize:    int64(sm.consoleLogMaxMB) * 1024 * 1024,
+		MaxBackups: sm.consoleLogBackups,
+	}
+}
+
+// dryRunCommand builds the launch command for a dry run, including where console output would go
+func (sm *ServerManager) dryRunCommand(serverID string, config servermgr.SAWServerConfig, sawPath string, showLogs bool) (*servermgr.LaunchCommand, error) {
+	command, err := servermgr.BuildLaunchCommand(serverID, config, sawPath, showLogs)
+	if err != nil {
+		return nil, err
+	}
+	if !showLogs && sm.consoleLogDir != "" {
+		command.StdoutPath, command.StderrPath = servermgr.ConsoleLogPaths(sm.consoleLogDir, serverID)
+	}
+	command.Warnings = append(command.Warnings, sm.catalog().Warnings(config)...)
+	return command, nil
+}
+
+// catalog returns the mutators and rulesets configs are checked against
+func (sm *ServerManager) catalog() servermgr.Catalog {
+	return servermgr.NewCatalog(sm.knownMutators, sm.knownRuleSets)
+}
+
+// sortedServerIDs returns the server IDs of configs in a stable order
+func sortedServerIDs(configs map[string]servermgr.SAWServerConfig) []string {
+	serverIDs := make([]string, 0, len(configs))
+	for serverID := range configs {
+		serverIDs = append(serverIDs, serverID)
+	}
+	sort.Strings(serverIDs)
+	return serverIDs
+}
+
 // stopCommand handles the stop command
 func (sm *ServerManager) stopCommand(cmd *cobra.Command, args []string) error {
 	stopAll, _ := cmd.Flags().GetBool("all")
@@ -260,7 +366,7 @@ func (sm *ServerManager) stopCommand(cmd *cobra.Command, args []string) error {
 			// Check if the process is actually running
 			if !sm.isProcessRunning(pid) {
 				// Clean up stale PID file
-				sm.logger.Info("Cleaning up stale PID file", "serverID", serverID, "pid", pid)
+				sm.logger.Info("Cleaning up stale PID file", "server_id", serverID, "pid", pid)
 				if err := sm.removePIDFile(serverID); err != nil {
 					sm.logger.Warn("Failed to remove stale PID file", "error", err)
 				}
@@ -405,6 +511,31 @@ func (sm *ServerManager) listCommand(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// diffCommand handles the diff command
+func (sm *ServerManager) diffCommand(cmd *cobra.Command, args []string) error {
+	sawPath := sm.getSAWPath()
+	if sawPath == "" {
+		return fmt.Errorf("SAW path not provided. Use --saw-path flag or set SAW_PATH environment variable")
+	}
+
+	configs, err := sm.loadSAWConfigs(sawPath)
+	if err != nil {
+		return fmt.Errorf("failed to load SAW configs: %w", err)
+	}
+	current := make(map[string]servermgr.SAWServerConfig, len(configs))
+	for serverID, serverConfig := range configs {
+		current[serverID] = servermgr.SAWServerConfig(serverConfig)
+	}
+
+	applied, err := servermgr.LoadAppliedConfigs("data")
+	if err != nil {
+		return err
+	}
+
+	servermgr.PrintConfigDiff(servermgr.DiffConfigs(applied, current))
+	return nil
+}
+
 // updateSteamCmdCommand handles the update-steamcmd command
 func (sm *ServerManager) updateSteamCmdCommand(cmd *cobra.Command, args []string) error {
 	sawPath := sm.getSAWPath()
@@ -583,7 +714,7 @@ func (sm *ServerManager) startServer(serverID string, config SAWServerConfig, sa
 	// Check for stale PID file and clean it up
 	if pid, err := sm.loadPIDFile(serverID); err == nil {
 		if !sm.isProcessRunning(pid) {
-			sm.logger.Info("Cleaning up stale PID file before starting", "serverID", serverID, "pid", pid)
+			sm.logger.Info("Cleaning up stale PID file before starting", "server_id", serverID, "pid", pid)
 			if err := sm.removePIDFile(serverID); err != nil {
 				sm.logger.Warn("Failed to remove stale PID file", "error", err)
 			}
@@ -596,93 +727,18 @@ func (sm *ServerManager) startServer(serverID string, config SAWServerConfig, sa
 		return fmt.Errorf("server %s is already running", serverID)
 	}
 
-	sawPath = strings.ReplaceAll(sawPath, "\\", "/")
-
-	serverExe := os.Getenv("INSURGENCY_SERVER_PATH")
-	if serverExe == "" {
-		serverExe = filepath.Join(sawPath, "sandstorm-server", "Insurgency", "Binaries", "Win64", "InsurgencyServer-Win64-Shipping.exe")
+	for _, warning := range sm.catalog().Warnings(servermgr.SAWServerConfig(config)) {
+		sm.logger.Warn("Server config warning", "server_id", serverID, "warning", warning)
 	}
 
-	absServerExe, err := filepath.Abs(serverExe)
+	command, err := servermgr.BuildLaunchCommand(serverID, servermgr.SAWServerConfig(config), sawPath, showLogs)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path for server executable: %w", err)
-	}
-	serverExe = absServerExe
-
-	if _, err := os.Stat(serverExe); os.IsNotExist(err) {
-		return fmt.Errorf("server executable not found at: %s", serverExe)
-	}
-
-	// Build scenario name - for Checkpoint and Push modes, include the side
-	scenarioName := fmt.Sprintf("Scenario_%s_%s", config.ServerDefaultMap, config.ServerScenarioMode)
-	if config.ServerScenarioMode == "Checkpoint" || config.ServerScenarioMode == "Push" {
-		scenarioName += "_" + config.ServerDefaultSide
-	}
-	travelArgs := config.ServerDefaultMap + "?Scenario=" + scenarioName
-
-	if config.ServerMaxPlayers != "" {
-		travelArgs += "?MaxPlayers=" + config.ServerMaxPlayers
-	}
-
-	// Add Game mode if specified
-	if config.ServerGameMode != "" && config.ServerGameMode != "None" {
-		travelArgs += "?Game=" + config.ServerGameMode
-	}
-
-	// Add password if specified
-	if config.ServerPassword != "" {
-		travelArgs += "?Password=" + config.ServerPassword
-	}
-
-	if config.ServerLightingDay == "true" {
-		travelArgs += "?Lighting=Day"
-	} else {
-		travelArgs += "?Lighting=Night"
-	}
-
-	if config.ServerCustomTravelArgs != "" {
-		travelArgs += "?" + config.ServerCustomTravelArgs
-	}
-
-	args := []string{
-		travelArgs,
-		"-Hostname=" + config.ServerHostname,
-		"-MaxPlayers=" + config.ServerMaxPlayers,
-		"-Port=" + config.ServerGamePort,
-		"-QueryPort=" + config.ServerQueryPort,
-		"-LogCmds=LogGameplayEvents Log",
-		"-LOCALLOGTIMES",
-		"-AdminList=Admins",
-		"-MapCycle=MapCycle",
-	}
-
-	if showLogs {
-		args = append(args, "-stdout")
-	} else {
-		args = append(args, "-log="+serverID+".log")
-	}
-
-	if len(config.ServerMutators) > 0 {
-		mutators := strings.Join(config.ServerMutators, ",")
-		args = append(args, "-Mutators="+mutators)
-	}
-	if config.ServerMutatorsCustom != "" {
-		args = append(args, "-Mutators="+config.ServerMutatorsCustom)
-	}
-
-	if config.ServerCheats == "true" {
-		args = append(args, "-CmdServerCheats")
-	}
-
-	if config.ServerCustomServerArgs != "" {
-		customArgs := strings.Fields(config.ServerCustomServerArgs)
-		args = append(args, customArgs...)
+		return err
 	}
-
-	absSAWPath, err := filepath.Abs(sawPath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path for SAW: %w", err)
+	for _, warning := range command.Warnings {
+		sm.logger.Warn("Server config warning", "server_id", serverID, "warning", warning)
 	}
+	absSAWPath := command.WorkDir
 
 	// Apply server configuration before starting
 	serverInstancePath := filepath.Join(absSAWPath, "sandstorm-server", "Insurgency")
@@ -693,22 +749,21 @@ func (sm *ServerManager) startServer(serverID string, config SAWServerConfig, sa
 	}
 
 	sm.logger.Info("Starting Insurgency server",
-		"serverID", serverID,
+		"server_id", serverID,
 		"name", config.ServerHostname,
-		"executable", serverExe,
+		"executable", command.Executable,
 		"workDir", absSAWPath,
 	)
 
 	// For servers without console logs, use PowerShell Start-Process to detach
 	if !showLogs {
-		argString := strings.Join(args, " ")
-
-		psCmd := fmt.Sprintf(`
-			$proc = Start-Process -FilePath '%s' -ArgumentList '%s' -WorkingDirectory '%s' -WindowStyle Hidden -PassThru
-			Write-Output $proc.Id
-		`, serverExe, argString, absSAWPath)
+		if sm.consoleLogDir != "" {
+			if err := servermgr.CaptureConsoleLogs(command, sm.consoleLogDir, serverID, sm.consoleLogRotation()); err != nil {
+				sm.logger.Warn("Failed to capture server console output", "error", err)
+			}
+		}
 
-		cmd := exec.Command("powershell", "-Command", psCmd)
+		cmd := command.PowerShellCommand()
 		output, err := cmd.Output()
 		if err != nil {
 			return fmt.Errorf("failed to start server: %w", err)
@@ -725,12 +780,12 @@ func (sm *ServerManager) startServer(serverID string, config SAWServerConfig, sa
 		}
 
 		sm.logger.Info("Server started in detached mode", "pid", pid)
+		sm.saveAppliedConfig(serverID, config)
 		return nil
 	}
 
 	// For console logs, use regular exec
-	cmd := exec.Command(serverExe, args...)
-	cmd.Dir = absSAWPath
+	cmd := command.ForegroundCommand()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -747,10 +802,19 @@ func (sm *ServerManager) startServer(serverID string, config SAWServerConfig, sa
 	}
 
 	go sm.monitorServer(serverID, cmd)
+	sm.saveAppliedConfig(serverID, config)
 
 	return nil
 }
 
+// saveAppliedConfig records the config a server was started with for the diff command.
+// It is kept in the data directory next to the PID files.
+func (sm *ServerManager) saveAppliedConfig(serverID string, config SAWServerConfig) {
+	if err := servermgr.SaveAppliedConfig("data", serverID, servermgr.SAWServerConfig(config)); err != nil {
+		sm.logger.Warn("Failed to save applied server config", "server_id", serverID, "error", err)
+	}
+}
+
 // monitorServer monitors a server process and updates status when it exits
 func (sm *ServerManager) monitorServer(serverID string, cmd *exec.Cmd) {
 	err := cmd.Wait()
@@ -761,9 +825,9 @@ func (sm *ServerManager) monitorServer(serverID string, cmd *exec.Cmd) {
 	if server, exists := sm.servers[serverID]; exists {
 		server.IsRunning = false
 		if err != nil {
-			sm.logger.Error("Server exited with error", "serverID", serverID, "error", err)
+			sm.logger.Error("Server exited with error", "server_id", serverID, "error", err)
 		} else {
-			sm.logger.Info("Server stopped", "serverID", serverID)
+			sm.logger.Info("Server stopped", "server_id", serverID)
 		}
 	}
 }
@@ -774,14 +838,14 @@ func (sm *ServerManager) stopServer(serverID string, sawPath string) error {
 	pid, err := sm.loadPIDFile(serverID)
 	if err == nil {
 		if !sm.isProcessRunning(pid) {
-			sm.logger.Info("Process not running, cleaning up stale PID file", "serverID", serverID, "pid", pid)
+			sm.logger.Info("Process not running, cleaning up stale PID file", "server_id", serverID, "pid", pid)
 			if err := sm.removePIDFile(serverID); err != nil {
 				sm.logger.Warn("Failed to remove stale PID file", "error", err)
 			}
 			return fmt.Errorf("server %s is not running (stale PID file cleaned up)", serverID)
 		}
 
-		sm.logger.Info("Stopping server via PID file", "serverID", serverID, "pid", pid)
+		sm.logger.Info("Stopping server via PID file", "server_id", serverID, "pid", pid)
 
 		psCmd := fmt.Sprintf("Stop-Process -Id %d -Force -ErrorAction SilentlyContinue", pid)
 		cmd := exec.Command("powershell", "-Command", psCmd)
@@ -819,7 +883,7 @@ func (sm *ServerManager) stopServer(serverID string, sawPath string) error {
 		return fmt.Errorf("server %s has no process", serverID)
 	}
 
-	sm.logger.Info("Stopping server", "serverID", serverID)
+	sm.logger.Info("Stopping server", "server_id", serverID)
 
 	if err := server.Cmd.Process.Kill(); err != nil {
 		return fmt.Errorf("failed to kill server process: %w", err)